@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	"myapp/core/helper/messages"
+	"myapp/core/service"
+	"myapp/support/base"
+
+	"github.com/gin-gonic/gin"
+)
+
+type apiKeyController struct {
+	apiKeyService service.APIKeyService
+}
+
+type APIKeyController interface {
+	CreateAPIKey(ctx *gin.Context)
+	GetAllAPIKeys(ctx *gin.Context)
+	RevokeAPIKey(ctx *gin.Context)
+}
+
+func NewAPIKeyController(apiKeyS service.APIKeyService) APIKeyController {
+	return &apiKeyController{
+		apiKeyService: apiKeyS,
+	}
+}
+
+func (kc *apiKeyController) CreateAPIKey(ctx *gin.Context) {
+	var req dto.APIKeyCreateRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		msg := base.GetValidationErrorMessage(err, req, messages.MsgAPIKeyCreateFailed)
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest, msg, err))
+		return
+	}
+	req.CreatedByID = ctx.MustGet("ID").(string)
+	req.CreatedByRole = ctx.GetString("ROLE")
+
+	key, err := kc.apiKeyService.CreateAPIKey(ctx, req)
+	if errors.Is(err, errs.ErrAPIKeyScopeNotAllowed) {
+		_ = ctx.Error(base.NewAppError(http.StatusForbidden,
+			messages.MsgAPIKeyCreateFailed, err))
+		return
+	}
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+			messages.MsgAPIKeyCreateFailed, err))
+		return
+	}
+
+	// The raw key is in this response only, keep it out of caches
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusCreated, base.CreateSuccessResponse(
+		messages.MsgAPIKeyCreateSuccess,
+		http.StatusCreated, key,
+	))
+}
+
+func (kc *apiKeyController) GetAllAPIKeys(ctx *gin.Context) {
+	keys, err := kc.apiKeyService.GetAllAPIKeys(ctx)
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusInternalServerError,
+			messages.MsgAPIKeyGetsFailed, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		messages.MsgAPIKeyGetsSuccess,
+		http.StatusOK, keys,
+	))
+}
+
+func (kc *apiKeyController) RevokeAPIKey(ctx *gin.Context) {
+	id := ctx.Param("api_key_id")
+	HandleDelete(ctx, id, kc.apiKeyService.RevokeAPIKey,
+		messages.MsgAPIKeyRevokeSuccess, messages.MsgAPIKeyRevokeFailed)
+}
@@ -0,0 +1,48 @@
+package controller
+
+import (
+	"myapp/core/helper/dto"
+	"myapp/core/helper/messages"
+	"myapp/core/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type auditLogController struct {
+	auditLogService service.AuditLogService
+}
+
+type AuditLogController interface {
+	GetAllAuditLogs(ctx *gin.Context)
+}
+
+func NewAuditLogController(auditLogS service.AuditLogService) AuditLogController {
+	return &auditLogController{
+		auditLogService: auditLogS,
+	}
+}
+
+// GetAllAuditLogs godoc
+// @Summary      Get all audit logs
+// @Description  Get the audit log of user, product and category changes with filtering, sorting, and pagination
+// @Tags         Audit Logs
+// @Accept       json
+// @Produce      json
+// @Param        filter[actor_id]    query     string  false  "Filter by actor ID"
+// @Param        filter[action]      query     string  false  "Filter by action (create, update, delete, restore, purge)"
+// @Param        filter[entity_type] query     string  false  "Filter by entity type (user, product, category)"
+// @Param        filter[entity_id]   query     string  false  "Filter by entity ID"
+// @Param        filter[request_id]  query     string  false  "Filter by request ID"
+// @Param        filter[from]        query     string  false  "Only entries at or after this RFC 3339 time"
+// @Param        filter[to]          query     string  false  "Only entries at or before this RFC 3339 time"
+// @Param        sort                query     string  false  "Sort field (prefix with - for desc)"
+// @Param        page                query     int     false  "Page number"
+// @Param        per_page            query     int     false  "Items per page"
+// @Success      200                 {object}  base.Response{data=[]dto.AuditLogResponse}
+// @Failure      400                 {object}  base.Response
+// @Security     ApiKeyAuth
+// @Router       /audit-logs [get]
+func (ac *auditLogController) GetAllAuditLogs(ctx *gin.Context) {
+	HandleGetAll(ctx, dto.AuditLogGetsRequest{}, ac.auditLogService.GetAllAuditLogs,
+		messages.MsgAuditLogsFetchSuccess, messages.MsgAuditLogsFetchFailed)
+}
@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	"myapp/core/helper/messages"
+	"myapp/core/service"
+	"myapp/support/base"
+	"myapp/support/constant"
+
+	"github.com/gin-gonic/gin"
+)
+
+type authController struct {
+	jwtService       service.JWTService
+	authService      service.AuthService
+	oidcService      service.OIDCService
+	twoFactorService service.TwoFactorService
+	secureCookies    bool
+}
+
+type AuthController interface {
+	GetJWKS(ctx *gin.Context)
+	OIDCLogin(ctx *gin.Context)
+	OIDCCallback(ctx *gin.Context)
+}
+
+func NewAuthController(jwtS service.JWTService, authS service.AuthService,
+	oidcS service.OIDCService, twoFactorS service.TwoFactorService) AuthController {
+	return &authController{
+		jwtService:       jwtS,
+		authService:      authS,
+		oidcService:      oidcS,
+		twoFactorService: twoFactorS,
+		secureCookies:    os.Getenv("APP_ENV") == "production",
+	}
+}
+
+// ============== Helper Functions ==============
+
+// issueTokensOrChallenge finishes a login whose first factor checked out. It
+// issues tokens, or a two-factor challenge when the user has two-factor
+// authentication on; challenged tells which one resp holds.
+func issueTokensOrChallenge(ctx *gin.Context, authS service.AuthService, twoFactorS service.TwoFactorService,
+	userID string, role string) (resp any, challenged bool, err error) {
+	enabled, err := twoFactorS.IsEnabled(ctx, userID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if enabled {
+		challenge, err := twoFactorS.CreateChallenge(ctx, userID)
+		return challenge, true, err
+	}
+
+	authResp, err := authS.IssueTokens(ctx, userID, role, sessionClient(ctx))
+	return authResp, false, err
+}
+
+// sessionClient describes the device a request came from, for the session
+// a login starts.
+func sessionClient(ctx *gin.Context) dto.UserSessionClient {
+	return dto.UserSessionClient{
+		UserAgent: ctx.Request.UserAgent(),
+		IPAddress: ctx.ClientIP(),
+	}
+}
+
+// setStateCookie keeps the state of a provider login in the browser for
+// maxAge seconds; a negative maxAge removes it. Lax still sends it on the
+// provider's redirect back, which is a top-level navigation.
+func (ac *authController) setStateCookie(ctx *gin.Context, state string, maxAge int) {
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(constant.OIDCStateCookie, state, maxAge, "/api/v1/auth", "", ac.secureCookies, true)
+}
+
+// GetJWKS serves the token verification keys as a plain JWK Set (RFC 7517)
+// rather than the usual response envelope, since JWT libraries consume it
+// directly.
+func (ac *authController) GetJWKS(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, ac.jwtService.GetJWKS())
+}
+
+// OIDCLogin redirects the browser to the provider's sign in page.
+func (ac *authController) OIDCLogin(ctx *gin.Context) {
+	start, err := ac.oidcService.BeginLogin(ctx, ctx.Param("provider"))
+	if errors.Is(err, errs.ErrOIDCProviderNotFound) {
+		_ = ctx.Error(base.NewAppError(http.StatusNotFound,
+			messages.MsgAuthOIDCProviderNotFound, err))
+		return
+	}
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusBadGateway,
+			messages.MsgAuthOIDCLoginFailed, err))
+		return
+	}
+
+	ac.setStateCookie(ctx, start.State, int(time.Until(start.ExpiresAt).Seconds()))
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Redirect(http.StatusFound, start.AuthURL)
+}
+
+// OIDCCallback finishes a provider login and issues tokens, or a two-factor
+// challenge, like the password login does.
+func (ac *authController) OIDCCallback(ctx *gin.Context) {
+	var req dto.OIDCCallbackRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		msg := base.GetValidationErrorMessage(err, req, messages.MsgAuthOIDCCallbackFailed)
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest, msg, err))
+		return
+	}
+	req.Provider = ctx.Param("provider")
+	req.BrowserState, _ = ctx.Cookie(constant.OIDCStateCookie)
+	// The state is single use either way
+	ac.setStateCookie(ctx, "", -1)
+
+	user, err := ac.oidcService.CompleteLogin(ctx, req)
+	switch {
+	case errors.Is(err, errs.ErrOIDCProviderNotFound):
+		_ = ctx.Error(base.NewAppError(http.StatusNotFound,
+			messages.MsgAuthOIDCProviderNotFound, err))
+		return
+	case errors.Is(err, errs.ErrOIDCEmailNotVerified):
+		_ = ctx.Error(base.NewAppError(http.StatusForbidden,
+			messages.MsgAuthOIDCEmailNotVerified, err))
+		return
+	case errors.Is(err, errs.ErrOIDCStateInvalid), errors.Is(err, errs.ErrOIDCExchangeFailed),
+		errors.Is(err, errs.ErrOIDCIDTokenInvalid):
+		_ = ctx.Error(base.NewAppError(http.StatusUnauthorized,
+			messages.MsgAuthOIDCCallbackFailed, err))
+		return
+	case err != nil:
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+			messages.MsgAuthOIDCCallbackFailed, err))
+		return
+	}
+
+	resp, challenged, err := issueTokensOrChallenge(ctx, ac.authService, ac.twoFactorService, user.ID, user.Role)
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+			messages.MsgAuthOIDCCallbackFailed, err))
+		return
+	}
+
+	msg := messages.MsgAuthOIDCCallbackSuccess
+	if challenged {
+		msg = messages.MsgUserLoginTwoFactorRequired
+	}
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		msg,
+		http.StatusOK, resp,
+	))
+}
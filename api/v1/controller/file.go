@@ -1,39 +1,116 @@
 package controller
 
 import (
+	"errors"
+	"io"
 	"net/http"
-	"os"
+	"regexp"
 	"strings"
 
+	errs "myapp/core/helper/errors"
 	"myapp/core/helper/messages"
+	storageiface "myapp/core/interface/storage"
+	"myapp/core/service"
 	"myapp/support/base"
-	"myapp/support/constant"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
-type fileController struct{}
+type fileController struct {
+	storage      storageiface.Storage
+	fileURLs     service.FileURLService
+	cacheControl map[string]string
+}
 
 type FileController interface {
 	GetFile(ctx *gin.Context)
 }
 
-func NewFileController() FileController {
-	return &fileController{}
+// NewFileController serves the files of the namespaces in cacheControl,
+// each with its Cache-Control header value.
+func NewFileController(storage storageiface.Storage, fileURLs service.FileURLService,
+	cacheControl map[string]string) FileController {
+	return &fileController{
+		storage:      storage,
+		fileURLs:     fileURLs,
+		cacheControl: cacheControl,
+	}
 }
 
+// fileVariantName matches the suffix image variants add to a file ID.
+var fileVariantName = regexp.MustCompile(`^[a-z0-9]+$`)
+
+// GetFile answers conditional and range requests through
+// http.ServeContent. Anything but a known namespace and a file ID shaped
+// like the generated ones, optionally followed by "_<variant>", is not
+// found, so keys never reach storage unchecked. Files of private
+// namespaces need the signature of the URL the services handed out.
 func (ct *fileController) GetFile(ctx *gin.Context) {
-	dir := ctx.Param("dir")
-	fileID := ctx.Param("file_id")
+	namespace := ctx.Param("dir")
+	cacheControl, known := ct.cacheControl[namespace]
+	id, variant, hasVariant := strings.Cut(ctx.Param("file_id"), "_")
+	fileID, err := uuid.Parse(id)
+	if !known || err != nil || fileID.String() != id || (hasVariant && !fileVariantName.MatchString(variant)) {
+		_ = ctx.Error(base.NewAppError(http.StatusNotFound,
+			messages.MsgFileNotFound, errs.ErrFileNotFound))
+		return
+	}
 
-	filePath := strings.Join([]string{constant.FileBasePath, dir, fileID}, "/")
+	key := namespace + "/" + ctx.Param("file_id")
+	if err := ct.fileURLs.Verify(key, ctx.Query("expires"), ctx.Query("signature")); err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusForbidden,
+			messages.MsgFileURLDenied, err))
+		return
+	}
+
+	obj, err := ct.storage.Get(ctx, key)
+	if errors.Is(err, errs.ErrFileNotFound) {
+		_ = ctx.Error(base.NewAppError(http.StatusNotFound,
+			messages.MsgFileNotFound, err))
+		return
+	}
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusInternalServerError,
+			messages.MsgFileFetchFailed, err))
+		return
+	}
+	defer obj.Body.Close()
 
-	_, err := os.Stat(filePath)
-	if os.IsNotExist(err) {
-		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+	contentType, err := fileContentType(obj)
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusInternalServerError,
 			messages.MsgFileFetchFailed, err))
 		return
 	}
 
-	ctx.File(filePath)
+	header := ctx.Writer.Header()
+	header.Set("Content-Type", contentType)
+	header.Set("Cache-Control", cacheControl)
+	header.Set("X-Content-Type-Options", "nosniff")
+	if obj.ETag != "" {
+		header.Set("ETag", obj.ETag)
+	}
+	http.ServeContent(ctx.Writer, ctx.Request, "", obj.ModTime, obj.Body)
+}
+
+// fileContentType returns the stored type of obj, or sniffs it when the
+// backend does not know it. Uploads are stored without extension, so
+// there is nothing else to go by.
+func fileContentType(obj storageiface.Object) (string, error) {
+	switch obj.ContentType {
+	case "", "application/octet-stream", "binary/octet-stream":
+	default:
+		return obj.ContentType, nil
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(obj.Body, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	if _, err := obj.Body.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return http.DetectContentType(head[:n]), nil
 }
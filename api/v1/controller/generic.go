@@ -2,6 +2,7 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"reflect"
 
@@ -10,6 +11,21 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// LimitRequestBody fails reading the request body past n bytes, so an
+// oversized upload is cut off instead of being parsed in full.
+func LimitRequestBody(ctx *gin.Context, n int64) {
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, n)
+}
+
+// bindErrorStatus is the status for a request that failed to bind.
+func bindErrorStatus(err error) int {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
 func HandleCreate[T any, R any](
 	ctx *gin.Context,
 	dto T,
@@ -18,13 +34,13 @@ func HandleCreate[T any, R any](
 ) {
 	if err := ctx.ShouldBind(&dto); err != nil {
 		msg := base.GetValidationErrorMessage(err, dto, failMsg)
-		_ = ctx.Error(base.NewAppError(http.StatusBadRequest, msg, err))
+		_ = ctx.Error(base.NewAppError(bindErrorStatus(err), msg, err))
 		return
 	}
 
 	result, err := createFunc(ctx, dto)
 	if err != nil {
-		_ = ctx.Error(base.NewAppError(http.StatusBadRequest, failMsg, err))
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest, base.GetFieldErrorMessage(err, failMsg), err))
 		return
 	}
 
@@ -40,13 +56,13 @@ func HandleGetAll[T any, R any](
 ) {
 	if err := ctx.ShouldBind(&dto); err != nil {
 		msg := base.GetValidationErrorMessage(err, dto, failMsg)
-		_ = ctx.Error(base.NewAppError(http.StatusBadRequest, msg, err))
+		_ = ctx.Error(base.NewAppError(bindErrorStatus(err), msg, err))
 		return
 	}
 
 	results, pageMeta, err := getAllFunc(ctx, dto)
 	if err != nil {
-		_ = ctx.Error(base.NewAppError(http.StatusBadRequest, failMsg, err))
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest, base.GetFieldErrorMessage(err, failMsg), err))
 		return
 	}
 
@@ -87,7 +103,7 @@ func HandleUpdate[T any, R any](
 ) {
 	if err := ctx.ShouldBind(&dto); err != nil {
 		msg := base.GetValidationErrorMessage(err, dto, failMsg)
-		_ = ctx.Error(base.NewAppError(http.StatusBadRequest, msg, err))
+		_ = ctx.Error(base.NewAppError(bindErrorStatus(err), msg, err))
 		return
 	}
 
@@ -98,7 +114,7 @@ func HandleUpdate[T any, R any](
 
 	result, err := updateFunc(ctx, dto)
 	if err != nil {
-		_ = ctx.Error(base.NewAppError(http.StatusBadRequest, failMsg, err))
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest, base.GetFieldErrorMessage(err, failMsg), err))
 		return
 	}
 
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	"myapp/core/helper/messages"
+	"myapp/core/service"
+	"myapp/support/base"
+
+	"github.com/gin-gonic/gin"
+)
+
+type invitationController struct {
+	invitationService service.InvitationService
+}
+
+type InvitationController interface {
+	CreateInvitation(ctx *gin.Context)
+	GetAllInvitations(ctx *gin.Context)
+	ResendInvitation(ctx *gin.Context)
+	RevokeInvitation(ctx *gin.Context)
+	AcceptInvitation(ctx *gin.Context)
+}
+
+func NewInvitationController(invitationS service.InvitationService) InvitationController {
+	return &invitationController{
+		invitationService: invitationS,
+	}
+}
+
+func (ic *invitationController) CreateInvitation(ctx *gin.Context) {
+	var req dto.InvitationCreateRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		msg := base.GetValidationErrorMessage(err, req, messages.MsgInvitationCreateFailed)
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest, msg, err))
+		return
+	}
+	req.InvitedByID = ctx.MustGet("ID").(string)
+	req.InvitedByRole = ctx.MustGet("ROLE").(string)
+
+	invitation, err := ic.invitationService.CreateInvitation(ctx, req)
+	if errors.Is(err, errs.ErrInvitationRoleNotAllowed) {
+		_ = ctx.Error(base.NewAppError(http.StatusForbidden,
+			messages.MsgInvitationCreateFailed, err))
+		return
+	}
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+			messages.MsgInvitationCreateFailed, err))
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, base.CreateSuccessResponse(
+		messages.MsgInvitationCreateSuccess,
+		http.StatusCreated, invitation,
+	))
+}
+
+func (ic *invitationController) GetAllInvitations(ctx *gin.Context) {
+	invitations, err := ic.invitationService.GetAllInvitations(ctx)
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusInternalServerError,
+			messages.MsgInvitationGetsFailed, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		messages.MsgInvitationGetsSuccess,
+		http.StatusOK, invitations,
+	))
+}
+
+func (ic *invitationController) ResendInvitation(ctx *gin.Context) {
+	invitation, err := ic.invitationService.ResendInvitation(ctx, ctx.Param("invitation_id"))
+	if errors.Is(err, errs.ErrInvitationNotFound) {
+		_ = ctx.Error(base.NewAppError(http.StatusNotFound,
+			messages.MsgInvitationResendFailed, err))
+		return
+	}
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+			messages.MsgInvitationResendFailed, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		messages.MsgInvitationResendSuccess,
+		http.StatusOK, invitation,
+	))
+}
+
+func (ic *invitationController) RevokeInvitation(ctx *gin.Context) {
+	id := ctx.Param("invitation_id")
+	HandleDelete(ctx, id, ic.invitationService.RevokeInvitation,
+		messages.MsgInvitationRevokeSuccess, messages.MsgInvitationRevokeFailed)
+}
+
+// AcceptInvitation is public, the token in the body is the credential.
+func (ic *invitationController) AcceptInvitation(ctx *gin.Context) {
+	HandleCreate(ctx, dto.InvitationAcceptRequest{}, ic.invitationService.AcceptInvitation,
+		messages.MsgInvitationAcceptSuccess, messages.MsgInvitationAcceptFailed)
+}
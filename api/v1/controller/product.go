@@ -25,6 +25,9 @@ type ProductController interface {
 	UpdateProduct(ctx *gin.Context)
 	DeleteProduct(ctx *gin.Context)
 
+	// Product History
+	GetProductHistory(ctx *gin.Context)
+
 	// Product Image
 	ChangeProductImage(ctx *gin.Context)
 	DeleteProductImage(ctx *gin.Context)
@@ -67,6 +70,7 @@ func NewProductController(productS service.ProductService, categoryS service.Cat
 // @Success      201      {object}  base.Response{data=dto.ProductResponse}
 // @Failure      400      {object}  base.Response
 // @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /products [post]
 func (pc *productController) CreateProduct(ctx *gin.Context) {
 	HandleCreate(ctx, dto.ProductCreateRequest{}, pc.productService.CreateProduct,
@@ -101,18 +105,41 @@ func (pc *productController) GetAllProducts(ctx *gin.Context) {
 
 // GetProductByID godoc
 // @Summary      Get product by ID
-// @Description  Get a single product by its ID
+// @Description  Get a single product by its ID, or with as_of its state at that time
 // @Tags         Products
 // @Accept       json
 // @Produce      json
-// @Param        product_id  path      string  true  "Product ID"
+// @Param        product_id  path      string  true   "Product ID"
+// @Param        as_of       query     string  false  "RFC 3339 time to rebuild the product at"
 // @Success      200         {object}  base.Response{data=dto.ProductResponse}
 // @Failure      400         {object}  base.Response
 // @Router       /products/{product_id} [get]
 func (pc *productController) GetProductByID(ctx *gin.Context) {
+	var req dto.ProductGetRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		msg := base.GetValidationErrorMessage(err, req, messages.MsgProductFetchFailed)
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest, msg, err))
+		return
+	}
+
 	id := ctx.Param("product_id")
-	HandleGetByID(ctx, id, pc.productService.GetProductByID,
-		messages.MsgProductFetchSuccess, messages.MsgProductFetchFailed)
+	if req.AsOf == nil {
+		HandleGetByID(ctx, id, pc.productService.GetProductByID,
+			messages.MsgProductFetchSuccess, messages.MsgProductFetchFailed)
+		return
+	}
+
+	product, err := pc.productService.GetProductAsOf(ctx, id, *req.AsOf)
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+			messages.MsgProductFetchFailed, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		messages.MsgProductFetchSuccess,
+		http.StatusOK, product,
+	))
 }
 
 // UpdateProduct godoc
@@ -126,6 +153,7 @@ func (pc *productController) GetProductByID(ctx *gin.Context) {
 // @Success      200         {object}  base.Response{data=dto.ProductResponse}
 // @Failure      400         {object}  base.Response
 // @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /products/{product_id} [patch]
 func (pc *productController) UpdateProduct(ctx *gin.Context) {
 	id := ctx.Param("product_id")
@@ -143,6 +171,7 @@ func (pc *productController) UpdateProduct(ctx *gin.Context) {
 // @Success      200         {object}  base.Response
 // @Failure      400         {object}  base.Response
 // @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /products/{product_id} [delete]
 func (pc *productController) DeleteProduct(ctx *gin.Context) {
 	id := ctx.Param("product_id")
@@ -150,6 +179,47 @@ func (pc *productController) DeleteProduct(ctx *gin.Context) {
 		messages.MsgProductDeleteSuccess, messages.MsgProductDeleteFailed)
 }
 
+// ============== Product History ==============
+
+// GetProductHistory godoc
+// @Summary      Get product history
+// @Description  Get the revisions of a product, newest first, with filtering and pagination
+// @Tags         Products
+// @Accept       json
+// @Produce      json
+// @Param        product_id      path      string  true   "Product ID"
+// @Param        filter[action]  query     string  false  "Filter by action (create, update, delete, restore)"
+// @Param        filter[from]    query     string  false  "Only revisions at or after this RFC 3339 time"
+// @Param        filter[to]      query     string  false  "Only revisions at or before this RFC 3339 time"
+// @Param        sort            query     string  false  "Sort field (prefix with - for desc)"
+// @Param        page            query     int     false  "Page number"
+// @Param        per_page        query     int     false  "Items per page"
+// @Success      200             {object}  base.Response{data=[]dto.ProductRevisionResponse}
+// @Failure      400             {object}  base.Response
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Router       /products/{product_id}/history [get]
+func (pc *productController) GetProductHistory(ctx *gin.Context) {
+	var req dto.ProductHistoryRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		msg := base.GetValidationErrorMessage(err, req, messages.MsgProductHistoryFailed)
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest, msg, err))
+		return
+	}
+	req.ProductID = ctx.Param("product_id")
+
+	revisions, pageMeta, err := pc.productService.GetProductHistory(ctx, req)
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+			base.GetFieldErrorMessage(err, messages.MsgProductHistoryFailed), err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, base.CreatePaginatedResponse(
+		messages.MsgProductHistorySuccess, http.StatusOK, revisions, pageMeta,
+	))
+}
+
 // ============== Product Image ==============
 
 // ChangeProductImage godoc
@@ -163,9 +233,11 @@ func (pc *productController) DeleteProduct(ctx *gin.Context) {
 // @Success      200         {object}  base.Response{data=dto.ProductResponse}
 // @Failure      400         {object}  base.Response
 // @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /products/{product_id}/image [patch]
 func (pc *productController) ChangeProductImage(ctx *gin.Context) {
 	id := ctx.Param("product_id")
+	LimitRequestBody(ctx, pc.productService.ImageRequestLimit())
 	HandleUpdate(ctx, id, dto.ProductChangeImageRequest{}, pc.productService.ChangeProductImage,
 		messages.MsgProductImageUpdateSuccess, messages.MsgProductImageUpdateFailed)
 }
@@ -180,6 +252,7 @@ func (pc *productController) ChangeProductImage(ctx *gin.Context) {
 // @Success      200         {object}  base.Response
 // @Failure      400         {object}  base.Response
 // @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /products/{product_id}/image [delete]
 func (pc *productController) DeleteProductImage(ctx *gin.Context) {
 	id := ctx.Param("product_id")
@@ -200,6 +273,7 @@ func (pc *productController) DeleteProductImage(ctx *gin.Context) {
 // @Success      200         {object}  base.Response{data=dto.ProductResponse}
 // @Failure      400         {object}  base.Response
 // @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /products/{product_id}/stock [patch]
 func (pc *productController) UpdateStock(ctx *gin.Context) {
 	id := ctx.Param("product_id")
@@ -354,6 +428,7 @@ func (pc *productController) RunProductMaintenance(ctx *gin.Context) {
 // @Success      201       {object}  base.Response{data=dto.CategoryResponse}
 // @Failure      400       {object}  base.Response
 // @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /categories [post]
 func (pc *productController) CreateCategory(ctx *gin.Context) {
 	HandleCreate(ctx, dto.CategoryCreateRequest{}, pc.categoryService.CreateCategory,
@@ -406,6 +481,7 @@ func (pc *productController) GetCategoryByID(ctx *gin.Context) {
 // @Success      200          {object}  base.Response{data=dto.CategoryResponse}
 // @Failure      400          {object}  base.Response
 // @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /categories/{category_id} [patch]
 func (pc *productController) UpdateCategory(ctx *gin.Context) {
 	id := ctx.Param("category_id")
@@ -423,6 +499,7 @@ func (pc *productController) UpdateCategory(ctx *gin.Context) {
 // @Success      200          {object}  base.Response
 // @Failure      400          {object}  base.Response
 // @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Router       /categories/{category_id} [delete]
 func (pc *productController) DeleteCategory(ctx *gin.Context) {
 	id := ctx.Param("category_id")
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"net/http"
+
+	"myapp/core/helper/dto"
+	"myapp/core/helper/messages"
+	"myapp/core/service"
+	"myapp/support/base"
+
+	"github.com/gin-gonic/gin"
+)
+
+type roleController struct {
+	roleService service.RoleService
+}
+
+type RoleController interface {
+	GetAllRoles(ctx *gin.Context)
+	GetAllPermissions(ctx *gin.Context)
+	CreateRole(ctx *gin.Context)
+	UpdateRolePermissions(ctx *gin.Context)
+	DeleteRole(ctx *gin.Context)
+}
+
+func NewRoleController(roleS service.RoleService) RoleController {
+	return &roleController{
+		roleService: roleS,
+	}
+}
+
+func (rc *roleController) GetAllRoles(ctx *gin.Context) {
+	roles, err := rc.roleService.GetAllRoles(ctx)
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusInternalServerError,
+			messages.MsgRoleGetsFailed, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		messages.MsgRoleGetsSuccess,
+		http.StatusOK, roles,
+	))
+}
+
+func (rc *roleController) GetAllPermissions(ctx *gin.Context) {
+	permissions, err := rc.roleService.GetAllPermissions(ctx)
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusInternalServerError,
+			messages.MsgPermissionGetsFailed, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		messages.MsgPermissionGetsSuccess,
+		http.StatusOK, permissions,
+	))
+}
+
+func (rc *roleController) CreateRole(ctx *gin.Context) {
+	HandleCreate(ctx, dto.RoleCreateRequest{}, rc.roleService.CreateRole,
+		messages.MsgRoleCreateSuccess, messages.MsgRoleCreateFailed)
+}
+
+func (rc *roleController) UpdateRolePermissions(ctx *gin.Context) {
+	id := ctx.Param("role_id")
+	HandleUpdate(ctx, id, dto.RolePermissionsUpdateRequest{}, rc.roleService.UpdateRolePermissions,
+		messages.MsgRoleUpdateSuccess, messages.MsgRoleUpdateFailed)
+}
+
+func (rc *roleController) DeleteRole(ctx *gin.Context) {
+	id := ctx.Param("role_id")
+	HandleDelete(ctx, id, rc.roleService.DeleteRole,
+		messages.MsgRoleDeleteSuccess, messages.MsgRoleDeleteFailed)
+}
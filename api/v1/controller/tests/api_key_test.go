@@ -0,0 +1,148 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"myapp/api/v1/controller"
+	"myapp/api/v1/router"
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	"myapp/core/helper/messages"
+	"myapp/core/service"
+	"myapp/support/constant"
+	"myapp/support/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Test Helpers ---
+
+func setupAPIKeyControllerTest() (*gin.Engine, *apiKeyServiceMock, *jwtServiceMock) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+
+	apiKeyS := new(apiKeyServiceMock)
+	jwtS := new(jwtServiceMock)
+
+	injector := do.New()
+	do.Provide(injector, func(i *do.Injector) (service.JWTService, error) {
+		return jwtS, nil
+	})
+	do.Provide(injector, func(i *do.Injector) (service.AuthService, error) {
+		return new(authServiceMock), nil
+	})
+	do.Provide(injector, func(i *do.Injector) (service.APIKeyService, error) {
+		return apiKeyS, nil
+	})
+	do.Provide(injector, func(i *do.Injector) (service.RoleService, error) {
+		return new(roleServiceMock), nil
+	})
+	do.Provide(injector, func(i *do.Injector) (service.TwoFactorService, error) {
+		return new(twoFactorServiceMock), nil
+	})
+	do.Provide(injector, func(i *do.Injector) (controller.APIKeyController, error) {
+		return controller.NewAPIKeyController(apiKeyS), nil
+	})
+
+	router.APIKeyRouter(r, injector)
+	return r, apiKeyS, jwtS
+}
+
+// --- Tests ---
+
+func TestAPIKeyController_CreateAPIKey(t *testing.T) {
+	r, apiKeyS, jwtS := setupAPIKeyControllerTest()
+
+	adminID := uuid.NewString()
+	jwtS.On("GetAttrByToken", "admin-token").Return(adminID, constant.EnumRoleAdmin, nil)
+
+	createReq := dto.APIKeyCreateRequest{Name: "warehouse", Scopes: []string{constant.EnumPermissionProductsStock}}
+	apiKeyS.On("CreateAPIKey", mock.Anything, mock.MatchedBy(func(req dto.APIKeyCreateRequest) bool {
+		return req.CreatedByID == adminID && req.CreatedByRole == constant.EnumRoleAdmin && req.Name == createReq.Name
+	})).Return(dto.APIKeyCreateResponse{
+		APIKeyResponse: dto.APIKeyResponse{ID: uuid.NewString(), Name: createReq.Name},
+		Key:            "mak_secret",
+	}, nil)
+
+	b, _ := json.Marshal(createReq)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/api-keys", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer admin-token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	require.Contains(t, w.Body.String(), `"key":"mak_secret"`)
+	apiKeyS.AssertExpectations(t)
+}
+
+func TestAPIKeyController_CreateAPIKey_ScopeRejected(t *testing.T) {
+	r, apiKeyS, jwtS := setupAPIKeyControllerTest()
+
+	jwtS.On("GetAttrByToken", "admin-token").Return(uuid.NewString(), constant.EnumRoleAdmin, nil)
+	apiKeyS.On("CreateAPIKey", mock.Anything, mock.MatchedBy(func(req dto.APIKeyCreateRequest) bool {
+		return req.Scopes[0] == "reports:read"
+	})).Return(dto.APIKeyCreateResponse{}, errs.ErrPermissionNotFound)
+	apiKeyS.On("CreateAPIKey", mock.Anything, mock.MatchedBy(func(req dto.APIKeyCreateRequest) bool {
+		return req.Scopes[0] == constant.EnumPermissionAPIKeysManage
+	})).Return(dto.APIKeyCreateResponse{}, errs.ErrAPIKeyScopeNotAllowed)
+
+	cases := map[string]int{
+		"reports:read":                       http.StatusBadRequest,
+		constant.EnumPermissionAPIKeysManage: http.StatusForbidden,
+		"":                                   http.StatusBadRequest,
+	}
+	for scope, status := range cases {
+		b, _ := json.Marshal(dto.APIKeyCreateRequest{Name: "warehouse", Scopes: []string{scope}})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/api-keys", bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer admin-token")
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+		require.Equal(t, status, w.Code, scope)
+	}
+}
+
+func TestAPIKeyController_APIKeyCannotManageKeys(t *testing.T) {
+	r, apiKeyS, _ := setupAPIKeyControllerTest()
+
+	apiKeyS.On("AuthenticateAPIKey", mock.Anything, "mak_secret").Return(dto.APIKeyResponse{
+		ID:     uuid.NewString(),
+		Scopes: []string{constant.EnumPermissionProductsWrite},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/api-keys", nil)
+	req.Header.Set("X-API-Key", "mak_secret")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusForbidden, w.Code)
+	apiKeyS.AssertNotCalled(t, "GetAllAPIKeys", mock.Anything)
+}
+
+func TestAPIKeyController_RevokeAPIKey(t *testing.T) {
+	r, apiKeyS, jwtS := setupAPIKeyControllerTest()
+
+	keyID := uuid.NewString()
+	jwtS.On("GetAttrByToken", "admin-token").Return(uuid.NewString(), constant.EnumRoleAdmin, nil)
+	apiKeyS.On("RevokeAPIKey", mock.Anything, keyID).Return(nil)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/api-keys/"+keyID, nil)
+	req.Header.Set("Authorization", "Bearer admin-token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), messages.MsgAPIKeyRevokeSuccess)
+}
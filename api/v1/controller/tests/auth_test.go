@@ -0,0 +1,148 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"myapp/api/v1/controller"
+	"myapp/api/v1/router"
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	"myapp/core/service"
+	"myapp/support/base"
+	"myapp/support/constant"
+	"myapp/support/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mock Service ---
+
+type oidcServiceMock struct{ mock.Mock }
+
+func (o *oidcServiceMock) BeginLogin(ctx context.Context, providerName string) (dto.OIDCLoginStart, error) {
+	args := o.Called(ctx, providerName)
+	return args.Get(0).(dto.OIDCLoginStart), args.Error(1)
+}
+func (o *oidcServiceMock) CompleteLogin(ctx context.Context, req dto.OIDCCallbackRequest) (dto.UserResponse, error) {
+	args := o.Called(ctx, req)
+	return args.Get(0).(dto.UserResponse), args.Error(1)
+}
+
+// --- Test Helpers ---
+
+func setupAuthControllerTest(t *testing.T) (*gin.Engine, *authServiceMock, *oidcServiceMock, *twoFactorServiceMock) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+
+	jwtS, err := service.NewJWTService()
+	require.NoError(t, err)
+	authS := new(authServiceMock)
+	oidcS := new(oidcServiceMock)
+	twoFactorS := new(twoFactorServiceMock)
+
+	injector := do.New()
+	do.Provide(injector, func(i *do.Injector) (controller.AuthController, error) {
+		return controller.NewAuthController(jwtS, authS, oidcS, twoFactorS), nil
+	})
+	router.AuthRouter(r, injector)
+	return r, authS, oidcS, twoFactorS
+}
+
+// --- Tests ---
+
+func TestAuthController_GetJWKS(t *testing.T) {
+	r, _, _, _ := setupAuthControllerTest(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var jwks dto.JWKSResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &jwks))
+	require.Len(t, jwks.Keys, 1)
+	require.Equal(t, "EdDSA", jwks.Keys[0].Alg)
+	require.NotEmpty(t, jwks.Keys[0].Kid)
+}
+
+func TestAuthController_OIDCLogin(t *testing.T) {
+	r, _, oidcS, _ := setupAuthControllerTest(t)
+
+	oidcS.On("BeginLogin", mock.Anything, "google").Return(dto.OIDCLoginStart{
+		AuthURL: "https://issuer.test/authorize?state=s", State: "s", ExpiresAt: time.Now().Add(time.Minute),
+	}, nil)
+	oidcS.On("BeginLogin", mock.Anything, "unknown").Return(dto.OIDCLoginStart{}, errs.ErrOIDCProviderNotFound)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/google/login", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusFound, w.Code)
+	require.Equal(t, "https://issuer.test/authorize?state=s", w.Header().Get("Location"))
+
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, constant.OIDCStateCookie, cookies[0].Name)
+	require.Equal(t, "s", cookies[0].Value)
+	require.True(t, cookies[0].HttpOnly)
+	require.Equal(t, http.SameSiteLaxMode, cookies[0].SameSite)
+	require.Positive(t, cookies[0].MaxAge)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/auth/unknown/login", nil)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAuthController_OIDCCallback(t *testing.T) {
+	r, authS, oidcS, _ := setupAuthControllerTest(t)
+
+	callback := dto.OIDCCallbackRequest{Provider: "google", BrowserState: "state", Code: "code", State: "state"}
+	oidcS.On("CompleteLogin", mock.Anything, callback).Return(dto.UserResponse{ID: "user-1", Role: "user"}, nil)
+	authS.On("IssueTokens", mock.Anything, "user-1", "user", mock.Anything).Return(base.AuthResponse{Token: "access"}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/google/callback?code=code&state=state", nil)
+	req.AddCookie(&http.Cookie{Name: constant.OIDCStateCookie, Value: "state"})
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"token":"access"`)
+
+	// The cookie is cleared once used
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, constant.OIDCStateCookie, cookies[0].Name)
+	require.Negative(t, cookies[0].MaxAge)
+}
+
+func TestAuthController_OIDCCallback_Errors(t *testing.T) {
+	r, _, oidcS, _ := setupAuthControllerTest(t)
+
+	oidcS.On("CompleteLogin", mock.Anything, mock.MatchedBy(func(req dto.OIDCCallbackRequest) bool {
+		return req.State == "stale"
+	})).Return(dto.UserResponse{}, errs.ErrOIDCStateInvalid)
+	oidcS.On("CompleteLogin", mock.Anything, mock.MatchedBy(func(req dto.OIDCCallbackRequest) bool {
+		return req.State == "unverified"
+	})).Return(dto.UserResponse{}, errs.ErrOIDCEmailNotVerified)
+
+	cases := map[string]int{
+		"/api/v1/auth/google/callback?state=stale":                http.StatusBadRequest,
+		"/api/v1/auth/google/callback?code=code&state=stale":      http.StatusUnauthorized,
+		"/api/v1/auth/google/callback?code=code&state=unverified": http.StatusForbidden,
+	}
+	for target, status := range cases {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, status, w.Code, target)
+	}
+}
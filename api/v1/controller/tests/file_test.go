@@ -0,0 +1,192 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"myapp/api/v1/controller"
+	"myapp/api/v1/router"
+	storageiface "myapp/core/interface/storage"
+	"myapp/core/service"
+	"myapp/infrastructure/storage"
+	"myapp/support/constant"
+	"myapp/support/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Test Helpers ---
+
+func setupFileControllerTest(t *testing.T) (*gin.Engine, storageiface.Storage, service.FileURLService) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+
+	st := storage.NewLocalStorage(t.TempDir())
+	fileURLs, err := service.NewFileURLService()
+	require.NoError(t, err)
+
+	injector := do.New()
+	do.Provide(injector, func(i *do.Injector) (controller.FileController, error) {
+		return controller.NewFileController(st, fileURLs, map[string]string{
+			constant.EnumFileNamespaceUserPicture:  "private, max-age=60",
+			constant.EnumFileNamespaceProductImage: "public, max-age=3600",
+		}), nil
+	})
+	router.FileRouter(r, injector)
+	return r, st, fileURLs
+}
+
+func putFile(t *testing.T, st storageiface.Storage, key string, content []byte, contentType string) {
+	t.Helper()
+	err := st.Put(context.Background(), key, bytes.NewReader(content), int64(len(content)), contentType)
+	require.NoError(t, err)
+}
+
+// expiredFileURL signs a link to key that expired a minute ago.
+func expiredFileURL(key string, secret string) string {
+	expires := strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(key + "\n" + expires))
+	signature := base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+	return "/api/v1/files/" + key + "?expires=" + expires + "&signature=" + signature
+}
+
+func getFile(r *gin.Engine, path string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for name, value := range header {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// --- Tests ---
+
+func TestFileController_GetFile(t *testing.T) {
+	r, st, fileURLs := setupFileControllerTest(t)
+	key := constant.EnumFileNamespaceProductImage + "/" + uuid.NewString()
+	content := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 32)...)
+	putFile(t, st, key, content, "")
+
+	w := getFile(r, fileURLs.URL(key), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, content, w.Body.Bytes())
+	require.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	require.Equal(t, "public, max-age=3600", w.Header().Get("Cache-Control"))
+	require.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	require.Equal(t, "bytes", w.Header().Get("Accept-Ranges"))
+	require.NotEmpty(t, w.Header().Get("ETag"))
+	require.NotEmpty(t, w.Header().Get("Last-Modified"))
+}
+
+func TestFileController_GetFile_Conditional(t *testing.T) {
+	r, st, fileURLs := setupFileControllerTest(t)
+	key := constant.EnumFileNamespaceUserPicture + "/" + uuid.NewString()
+	putFile(t, st, key, []byte("hello"), "text/plain")
+
+	w := getFile(r, fileURLs.URL(key), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	lastModified := w.Header().Get("Last-Modified")
+
+	w = getFile(r, fileURLs.URL(key), map[string]string{"If-None-Match": etag})
+	require.Equal(t, http.StatusNotModified, w.Code)
+	require.Empty(t, w.Body.Bytes())
+	require.Equal(t, "private, max-age=60", w.Header().Get("Cache-Control"))
+
+	w = getFile(r, fileURLs.URL(key), map[string]string{"If-Modified-Since": lastModified})
+	require.Equal(t, http.StatusNotModified, w.Code)
+
+	w = getFile(r, fileURLs.URL(key), map[string]string{"If-None-Match": `"other"`})
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestFileController_GetFile_Range(t *testing.T) {
+	r, st, fileURLs := setupFileControllerTest(t)
+	key := constant.EnumFileNamespaceUserPicture + "/" + uuid.NewString()
+	putFile(t, st, key, []byte("0123456789"), "text/plain")
+
+	w := getFile(r, fileURLs.URL(key), map[string]string{"Range": "bytes=2-5"})
+	require.Equal(t, http.StatusPartialContent, w.Code)
+	require.Equal(t, "2345", w.Body.String())
+	require.Equal(t, "bytes 2-5/10", w.Header().Get("Content-Range"))
+
+	w = getFile(r, fileURLs.URL(key), map[string]string{"Range": "bytes=20-"})
+	require.Equal(t, http.StatusRequestedRangeNotSatisfiable, w.Code)
+}
+
+func TestFileController_GetFile_Variant(t *testing.T) {
+	r, st, fileURLs := setupFileControllerTest(t)
+	key := constant.EnumFileNamespaceProductImage + "/" + uuid.NewString() + "_thumbnail"
+	content := append([]byte("\xff\xd8\xff"), bytes.Repeat([]byte{0}, 32)...)
+	putFile(t, st, key, content, "image/jpeg")
+
+	w := getFile(r, fileURLs.URL(key), nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, content, w.Body.Bytes())
+	require.Equal(t, "image/jpeg", w.Header().Get("Content-Type"))
+}
+
+func TestFileController_GetFile_NotFound(t *testing.T) {
+	r, st, fileURLs := setupFileControllerTest(t)
+	id := uuid.NewString()
+	putFile(t, st, "secret/"+uuid.NewString(), []byte("hidden"), "")
+	putFile(t, st, constant.EnumFileNamespaceUserPicture+"/not-an-id", []byte("hidden"), "")
+	putFile(t, st, constant.EnumFileNamespaceProductImage+"/"+id+"_Big.jpg", []byte("hidden"), "")
+
+	paths := []string{
+		fileURLs.URL(constant.EnumFileNamespaceUserPicture + "/" + uuid.NewString()),
+		"/api/v1/files/secret/" + uuid.NewString(),
+		"/api/v1/files/" + constant.EnumFileNamespaceUserPicture + "/not-an-id",
+		"/api/v1/files/" + constant.EnumFileNamespaceUserPicture + "/..%2F..%2Fetc%2Fpasswd",
+		"/api/v1/files/..%2Fsecret/" + uuid.NewString(),
+		"/api/v1/files/" + constant.EnumFileNamespaceProductImage + "/" + id + "_Big.jpg",
+		"/api/v1/files/" + constant.EnumFileNamespaceProductImage + "/" + id + "_",
+	}
+	for _, path := range paths {
+		w := getFile(r, path, nil)
+		require.Equal(t, http.StatusNotFound, w.Code, path)
+	}
+}
+
+func TestFileController_GetFile_Signed(t *testing.T) {
+	t.Setenv("FILE_URL_SECRET", "test-secret")
+	r, st, fileURLs := setupFileControllerTest(t)
+	key := constant.EnumFileNamespaceUserPicture + "/" + uuid.NewString()
+	putFile(t, st, key, []byte("hello"), "text/plain")
+
+	signed := fileURLs.URL(key)
+	require.Contains(t, signed, "signature=")
+	w := getFile(r, signed, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "hello", w.Body.String())
+
+	otherKey := constant.EnumFileNamespaceUserPicture + "/" + uuid.NewString()
+	path, query, _ := strings.Cut(signed, "?")
+
+	denied := []string{
+		path,
+		path + "?expires=9999999999&" + query[strings.Index(query, "signature="):],
+		path + "?" + strings.Replace(query, "signature=", "signature=x", 1),
+		expiredFileURL(key, "test-secret"),
+		path + "?" + strings.SplitN(fileURLs.URL(otherKey), "?", 2)[1],
+	}
+	for _, url := range denied {
+		w := getFile(r, url, nil)
+		require.Equal(t, http.StatusForbidden, w.Code, url)
+	}
+}
@@ -0,0 +1,173 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"myapp/api/v1/controller"
+	"myapp/api/v1/router"
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	"myapp/core/helper/messages"
+	"myapp/core/service"
+	"myapp/support/constant"
+	"myapp/support/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Mock Services ---
+
+type invitationServiceMock struct{ mock.Mock }
+
+func (m *invitationServiceMock) CreateInvitation(ctx context.Context, req dto.InvitationCreateRequest) (dto.InvitationResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(dto.InvitationResponse), args.Error(1)
+}
+func (m *invitationServiceMock) GetAllInvitations(ctx context.Context) ([]dto.InvitationResponse, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]dto.InvitationResponse), args.Error(1)
+}
+func (m *invitationServiceMock) ResendInvitation(ctx context.Context, id string) (dto.InvitationResponse, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(dto.InvitationResponse), args.Error(1)
+}
+func (m *invitationServiceMock) RevokeInvitation(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *invitationServiceMock) AcceptInvitation(ctx context.Context, req dto.InvitationAcceptRequest) (dto.UserResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(dto.UserResponse), args.Error(1)
+}
+
+// --- Test Helpers ---
+
+func setupInvitationControllerTest() (*gin.Engine, *invitationServiceMock, *jwtServiceMock) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+
+	invitationS := new(invitationServiceMock)
+	jwtS := new(jwtServiceMock)
+
+	injector := do.New()
+	do.Provide(injector, func(i *do.Injector) (service.JWTService, error) {
+		return jwtS, nil
+	})
+	do.Provide(injector, func(i *do.Injector) (service.AuthService, error) {
+		return new(authServiceMock), nil
+	})
+	do.Provide(injector, func(i *do.Injector) (service.APIKeyService, error) {
+		return new(apiKeyServiceMock), nil
+	})
+	do.Provide(injector, func(i *do.Injector) (service.RoleService, error) {
+		return new(roleServiceMock), nil
+	})
+	do.Provide(injector, func(i *do.Injector) (service.TwoFactorService, error) {
+		return new(twoFactorServiceMock), nil
+	})
+	do.Provide(injector, func(i *do.Injector) (controller.InvitationController, error) {
+		return controller.NewInvitationController(invitationS), nil
+	})
+
+	router.InvitationRouter(r, injector)
+	return r, invitationS, jwtS
+}
+
+// --- Tests ---
+
+func TestInvitationController_CreateInvitation(t *testing.T) {
+	r, invitationS, jwtS := setupInvitationControllerTest()
+
+	adminID := uuid.NewString()
+	jwtS.On("GetAttrByToken", "admin-token").Return(adminID, constant.EnumRoleAdmin, nil)
+	createReq := dto.InvitationCreateRequest{Email: "new@mail.test", Role: "editor"}
+	invitationS.On("CreateInvitation", mock.Anything, dto.InvitationCreateRequest{
+		InvitedByID: adminID, InvitedByRole: constant.EnumRoleAdmin, Email: "new@mail.test", Role: "editor",
+	}).Return(dto.InvitationResponse{ID: uuid.NewString(), Status: dto.InvitationStatusPending}, nil)
+
+	b, _ := json.Marshal(createReq)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/invitations", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer admin-token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	require.Contains(t, w.Body.String(), messages.MsgInvitationCreateSuccess)
+	invitationS.AssertExpectations(t)
+}
+
+func TestInvitationController_CreateInvitation_RoleNotAllowed(t *testing.T) {
+	r, invitationS, jwtS := setupInvitationControllerTest()
+
+	jwtS.On("GetAttrByToken", "admin-token").Return(uuid.NewString(), constant.EnumRoleAdmin, nil)
+	invitationS.On("CreateInvitation", mock.Anything, mock.Anything).
+		Return(dto.InvitationResponse{}, errs.ErrInvitationRoleNotAllowed)
+
+	b, _ := json.Marshal(dto.InvitationCreateRequest{Email: "new@mail.test", Role: "owner"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/invitations", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer admin-token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestInvitationController_UserCannotInvite(t *testing.T) {
+	r, invitationS, jwtS := setupInvitationControllerTest()
+
+	jwtS.On("GetAttrByToken", "user-token").Return(uuid.NewString(), constant.EnumRoleUser, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/invitations", nil)
+	req.Header.Set("Authorization", "Bearer user-token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusForbidden, w.Code)
+	invitationS.AssertNotCalled(t, "GetAllInvitations", mock.Anything)
+}
+
+func TestInvitationController_ResendInvitation_NotFound(t *testing.T) {
+	r, invitationS, jwtS := setupInvitationControllerTest()
+
+	invitationID := uuid.NewString()
+	jwtS.On("GetAttrByToken", "admin-token").Return(uuid.NewString(), constant.EnumRoleAdmin, nil)
+	invitationS.On("ResendInvitation", mock.Anything, invitationID).
+		Return(dto.InvitationResponse{}, errs.ErrInvitationNotFound)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/invitations/"+invitationID+"/resend", nil)
+	req.Header.Set("Authorization", "Bearer admin-token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestInvitationController_AcceptInvitation(t *testing.T) {
+	r, invitationS, _ := setupInvitationControllerTest()
+
+	acceptReq := dto.InvitationAcceptRequest{Token: "raw", Name: "Nina New", Password: "correct horse battery"}
+	invitationS.On("AcceptInvitation", mock.Anything, acceptReq).
+		Return(dto.UserResponse{ID: uuid.NewString(), Email: "new@mail.test", Role: "editor"}, nil)
+
+	// no Authorization header, the token is the credential
+	b, _ := json.Marshal(acceptReq)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/invitations/accept", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	require.Contains(t, w.Body.String(), messages.MsgInvitationAcceptSuccess)
+}
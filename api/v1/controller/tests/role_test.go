@@ -0,0 +1,153 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"myapp/api/v1/controller"
+	"myapp/api/v1/router"
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	"myapp/core/helper/messages"
+	"myapp/core/service"
+	"myapp/support/constant"
+	"myapp/support/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Test Helpers ---
+
+func setupRoleControllerTest() (*gin.Engine, *roleServiceMock, *jwtServiceMock) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+
+	roleS := new(roleServiceMock)
+	jwtS := new(jwtServiceMock)
+
+	injector := do.New()
+	do.Provide(injector, func(i *do.Injector) (service.JWTService, error) {
+		return jwtS, nil
+	})
+	do.Provide(injector, func(i *do.Injector) (service.AuthService, error) {
+		return new(authServiceMock), nil
+	})
+	do.Provide(injector, func(i *do.Injector) (service.APIKeyService, error) {
+		return new(apiKeyServiceMock), nil
+	})
+	do.Provide(injector, func(i *do.Injector) (service.RoleService, error) {
+		return roleS, nil
+	})
+	do.Provide(injector, func(i *do.Injector) (service.TwoFactorService, error) {
+		return new(twoFactorServiceMock), nil
+	})
+	do.Provide(injector, func(i *do.Injector) (controller.RoleController, error) {
+		return controller.NewRoleController(roleS), nil
+	})
+
+	router.RoleRouter(r, injector)
+	return r, roleS, jwtS
+}
+
+// --- Tests ---
+
+func TestRoleController_CreateRole(t *testing.T) {
+	r, roleS, jwtS := setupRoleControllerTest()
+
+	jwtS.On("GetAttrByToken", "admin-token").Return(uuid.NewString(), constant.EnumRoleAdmin, nil)
+	createReq := dto.RoleCreateRequest{
+		Name:        "catalog_manager",
+		Permissions: []string{constant.EnumPermissionProductsWrite},
+	}
+	roleS.On("CreateRole", mock.Anything, createReq).Return(dto.RoleResponse{
+		ID:          uuid.NewString(),
+		Name:        createReq.Name,
+		Permissions: createReq.Permissions,
+	}, nil)
+
+	b, _ := json.Marshal(createReq)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/roles", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer admin-token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+	require.Contains(t, w.Body.String(), messages.MsgRoleCreateSuccess)
+	roleS.AssertExpectations(t)
+}
+
+func TestRoleController_CreateRole_InvalidName(t *testing.T) {
+	r, roleS, jwtS := setupRoleControllerTest()
+
+	jwtS.On("GetAttrByToken", "admin-token").Return(uuid.NewString(), constant.EnumRoleAdmin, nil)
+
+	b, _ := json.Marshal(dto.RoleCreateRequest{Name: "Catalog Manager"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/roles", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer admin-token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	roleS.AssertNotCalled(t, "CreateRole", mock.Anything, mock.Anything)
+}
+
+func TestRoleController_UserCannotManageRoles(t *testing.T) {
+	r, roleS, jwtS := setupRoleControllerTest()
+
+	jwtS.On("GetAttrByToken", "user-token").Return(uuid.NewString(), constant.EnumRoleUser, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/roles", nil)
+	req.Header.Set("Authorization", "Bearer user-token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusForbidden, w.Code)
+	roleS.AssertNotCalled(t, "GetAllRoles", mock.Anything)
+}
+
+func TestRoleController_UpdateRolePermissions(t *testing.T) {
+	r, roleS, jwtS := setupRoleControllerTest()
+
+	roleID := uuid.NewString()
+	jwtS.On("GetAttrByToken", "admin-token").Return(uuid.NewString(), constant.EnumRoleAdmin, nil)
+	roleS.On("UpdateRolePermissions", mock.Anything, dto.RolePermissionsUpdateRequest{
+		ID:          roleID,
+		Permissions: []string{constant.EnumPermissionProductsStock},
+	}).Return(dto.RoleResponse{ID: roleID}, nil)
+
+	b, _ := json.Marshal(map[string]any{"permissions": []string{constant.EnumPermissionProductsStock}})
+	req := httptest.NewRequest(http.MethodPut, "/api/v1/roles/"+roleID+"/permissions", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer admin-token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	roleS.AssertExpectations(t)
+}
+
+func TestRoleController_DeleteRole_InUse(t *testing.T) {
+	r, roleS, jwtS := setupRoleControllerTest()
+
+	roleID := uuid.NewString()
+	jwtS.On("GetAttrByToken", "admin-token").Return(uuid.NewString(), constant.EnumRoleAdmin, nil)
+	roleS.On("DeleteRole", mock.Anything, roleID).Return(errs.ErrRoleInUse)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/roles/"+roleID, nil)
+	req.Header.Set("Authorization", "Bearer admin-token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Contains(t, w.Body.String(), errs.ErrRoleInUse.Error())
+}
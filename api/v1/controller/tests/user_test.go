@@ -8,8 +8,10 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"myapp/api/v1/controller"
 	"myapp/api/v1/router"
@@ -65,19 +67,245 @@ func (m *userServiceMock) ChangePicture(ctx context.Context, req dto.UserChangeP
 	args := m.Called(ctx, req)
 	return args.Get(0).(dto.UserResponse), args.Error(1)
 }
+func (m *userServiceMock) PictureRequestLimit() int64 {
+	args := m.Called()
+	return args.Get(0).(int64)
+}
 func (m *userServiceMock) DeletePicture(ctx context.Context, userID string) error {
 	args := m.Called(ctx, userID)
 	return args.Error(0)
 }
+func (m *userServiceMock) ScheduleUserDeletion(ctx context.Context, id string) (dto.UserResponse, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(dto.UserResponse), args.Error(1)
+}
+func (m *userServiceMock) CancelUserDeletion(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+func (m *userServiceMock) PurgeScheduledUsers(ctx context.Context) (int, error) {
+	args := m.Called(ctx)
+	return args.Int(0), args.Error(1)
+}
+func (m *userServiceMock) ExportUserData(ctx context.Context, id string) (dto.UserExportResponse, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(dto.UserExportResponse), args.Error(1)
+}
 
 func (m *userServiceMock) RunUserMaintenance(ctx context.Context, req dto.UserMaintenanceRequest) (dto.UserMaintenanceResponse, error) {
 	args := m.Called(ctx, req)
 	return args.Get(0).(dto.UserMaintenanceResponse), args.Error(1)
 }
 
-type jwtServiceMock struct{ mock.Mock }
+type authServiceMock struct{ mock.Mock }
+
+func (a *authServiceMock) IssueTokens(ctx context.Context, userID string, role string, client dto.UserSessionClient) (base.AuthResponse, error) {
+	args := a.Called(ctx, userID, role, client)
+	return args.Get(0).(base.AuthResponse), args.Error(1)
+}
+func (a *authServiceMock) RefreshTokens(ctx context.Context, req dto.UserRefreshTokenRequest) (base.AuthResponse, error) {
+	args := a.Called(ctx, req)
+	return args.Get(0).(base.AuthResponse), args.Error(1)
+}
+func (a *authServiceMock) VerifyAccessToken(ctx context.Context, claims *service.TokenClaims) error {
+	return nil
+}
+func (a *authServiceMock) Logout(ctx context.Context, req dto.UserLogoutRequest) error {
+	args := a.Called(ctx, req)
+	return args.Error(0)
+}
+func (a *authServiceMock) RevokeUserSessions(ctx context.Context, userID string) error {
+	args := a.Called(ctx, userID)
+	return args.Error(0)
+}
+func (a *authServiceMock) GetUserSessions(ctx context.Context, userID string, currentSessionID string) ([]dto.UserSessionResponse, error) {
+	args := a.Called(ctx, userID, currentSessionID)
+	return args.Get(0).([]dto.UserSessionResponse), args.Error(1)
+}
+func (a *authServiceMock) EndUserSession(ctx context.Context, req dto.UserSessionEndRequest) error {
+	args := a.Called(ctx, req)
+	return args.Error(0)
+}
+
+type passwordServiceMock struct{ mock.Mock }
+
+func (p *passwordServiceMock) RequestPasswordReset(ctx context.Context, req dto.UserPasswordForgotRequest) error {
+	args := p.Called(ctx, req)
+	return args.Error(0)
+}
+func (p *passwordServiceMock) ResetPassword(ctx context.Context, req dto.UserPasswordResetRequest) error {
+	args := p.Called(ctx, req)
+	return args.Error(0)
+}
+func (p *passwordServiceMock) ChangePassword(ctx context.Context, req dto.UserPasswordChangeRequest) error {
+	args := p.Called(ctx, req)
+	return args.Error(0)
+}
+
+type verificationServiceMock struct {
+	mock.Mock
+	// checkErr is returned by every policy check, nil lets all users through
+	checkErr error
+}
+
+func (v *verificationServiceMock) SendVerificationEmail(ctx context.Context, userID string) error {
+	args := v.Called(ctx, userID)
+	return args.Error(0)
+}
+func (v *verificationServiceMock) ResendVerificationEmail(ctx context.Context, req dto.UserVerifyEmailResendRequest) error {
+	args := v.Called(ctx, req)
+	return args.Error(0)
+}
+func (v *verificationServiceMock) VerifyEmail(ctx context.Context, req dto.UserVerifyEmailRequest) error {
+	args := v.Called(ctx, req)
+	return args.Error(0)
+}
+func (v *verificationServiceMock) CheckEmailVerified(ctx context.Context, userID string, checkpoint string) error {
+	return v.checkErr
+}
+
+type twoFactorServiceMock struct {
+	mock.Mock
+	// enabled is what IsEnabled reports for every user
+	enabled bool
+	// requiredErr is returned by every policy check, nil lets all users through
+	requiredErr error
+}
+
+func (f *twoFactorServiceMock) EnrollTOTP(ctx context.Context, userID string) (dto.UserTOTPEnrollResponse, error) {
+	args := f.Called(ctx, userID)
+	return args.Get(0).(dto.UserTOTPEnrollResponse), args.Error(1)
+}
+func (f *twoFactorServiceMock) ConfirmTOTP(ctx context.Context, req dto.UserTwoFactorCodeRequest) (dto.UserRecoveryCodesResponse, error) {
+	args := f.Called(ctx, req)
+	return args.Get(0).(dto.UserRecoveryCodesResponse), args.Error(1)
+}
+func (f *twoFactorServiceMock) DisableTOTP(ctx context.Context, req dto.UserTwoFactorCodeRequest) error {
+	args := f.Called(ctx, req)
+	return args.Error(0)
+}
+func (f *twoFactorServiceMock) RegenerateRecoveryCodes(ctx context.Context, req dto.UserTwoFactorCodeRequest) (dto.UserRecoveryCodesResponse, error) {
+	args := f.Called(ctx, req)
+	return args.Get(0).(dto.UserRecoveryCodesResponse), args.Error(1)
+}
+func (f *twoFactorServiceMock) IsEnabled(ctx context.Context, userID string) (bool, error) {
+	return f.enabled, nil
+}
+func (f *twoFactorServiceMock) CreateChallenge(ctx context.Context, userID string) (dto.UserLoginChallengeResponse, error) {
+	args := f.Called(ctx, userID)
+	return args.Get(0).(dto.UserLoginChallengeResponse), args.Error(1)
+}
+func (f *twoFactorServiceMock) VerifyChallenge(ctx context.Context, req dto.UserLoginTwoFactorRequest) (string, error) {
+	args := f.Called(ctx, req)
+	return args.String(0), args.Error(1)
+}
+func (f *twoFactorServiceMock) CheckTwoFactorRequired(ctx context.Context, userID string, role string) error {
+	return f.requiredErr
+}
+
+type impersonationServiceMock struct{ mock.Mock }
+
+func (s *impersonationServiceMock) Impersonate(ctx context.Context,
+	req dto.UserImpersonateRequest) (base.AuthResponse, error) {
+	args := s.Called(ctx, req)
+	return args.Get(0).(base.AuthResponse), args.Error(1)
+}
+
+type loginThrottleServiceMock struct {
+	mock.Mock
+	// checkErr and retryAfter are what CheckLogin reports for every login
+	checkErr   error
+	retryAfter time.Duration
+	// failures and successes collect the emails passed to RecordFailure
+	// and RecordSuccess
+	failures  []string
+	successes []string
+}
+
+func (l *loginThrottleServiceMock) CheckLogin(ctx context.Context, email string, ip string) (time.Duration, error) {
+	return l.retryAfter, l.checkErr
+}
+func (l *loginThrottleServiceMock) RecordFailure(ctx context.Context, email string, ip string) error {
+	l.failures = append(l.failures, email)
+	return nil
+}
+func (l *loginThrottleServiceMock) RecordSuccess(ctx context.Context, email string) error {
+	l.successes = append(l.successes, email)
+	return nil
+}
+func (l *loginThrottleServiceMock) UnlockUser(ctx context.Context, userID string) error {
+	args := l.Called(ctx, userID)
+	return args.Error(0)
+}
+
+type apiKeyServiceMock struct{ mock.Mock }
+
+func (k *apiKeyServiceMock) CreateAPIKey(ctx context.Context, req dto.APIKeyCreateRequest) (dto.APIKeyCreateResponse, error) {
+	args := k.Called(ctx, req)
+	return args.Get(0).(dto.APIKeyCreateResponse), args.Error(1)
+}
+func (k *apiKeyServiceMock) GetAllAPIKeys(ctx context.Context) ([]dto.APIKeyResponse, error) {
+	args := k.Called(ctx)
+	return args.Get(0).([]dto.APIKeyResponse), args.Error(1)
+}
+func (k *apiKeyServiceMock) RevokeAPIKey(ctx context.Context, id string) error {
+	args := k.Called(ctx, id)
+	return args.Error(0)
+}
+func (k *apiKeyServiceMock) AuthenticateAPIKey(ctx context.Context, rawKey string) (dto.APIKeyResponse, error) {
+	args := k.Called(ctx, rawKey)
+	return args.Get(0).(dto.APIKeyResponse), args.Error(1)
+}
+
+type roleServiceMock struct {
+	mock.Mock
+	// permissions overrides what HasPermission grants; by default only
+	// admin is allowed, to everything
+	permissions map[string][]string
+}
 
-func (j *jwtServiceMock) GenerateToken(id string, role string) string { return "token" }
+func (r *roleServiceMock) GetAllRoles(ctx context.Context) ([]dto.RoleResponse, error) {
+	args := r.Called(ctx)
+	return args.Get(0).([]dto.RoleResponse), args.Error(1)
+}
+func (r *roleServiceMock) GetAllPermissions(ctx context.Context) ([]dto.PermissionResponse, error) {
+	args := r.Called(ctx)
+	return args.Get(0).([]dto.PermissionResponse), args.Error(1)
+}
+func (r *roleServiceMock) CreateRole(ctx context.Context, req dto.RoleCreateRequest) (dto.RoleResponse, error) {
+	args := r.Called(ctx, req)
+	return args.Get(0).(dto.RoleResponse), args.Error(1)
+}
+func (r *roleServiceMock) UpdateRolePermissions(ctx context.Context, req dto.RolePermissionsUpdateRequest) (dto.RoleResponse, error) {
+	args := r.Called(ctx, req)
+	return args.Get(0).(dto.RoleResponse), args.Error(1)
+}
+func (r *roleServiceMock) DeleteRole(ctx context.Context, id string) error {
+	args := r.Called(ctx, id)
+	return args.Error(0)
+}
+func (r *roleServiceMock) HasPermission(ctx context.Context, role string, permission string) (bool, error) {
+	if r.permissions == nil {
+		return role == constant.EnumRoleAdmin, nil
+	}
+	return slices.Contains(r.permissions[role], permission), nil
+}
+
+type jwtServiceMock struct {
+	mock.Mock
+	// actorID makes every token an impersonation token by this user
+	actorID string
+}
+
+func (j *jwtServiceMock) GenerateToken(id string, role string, sessionID string) string {
+	return "token"
+}
+func (j *jwtServiceMock) GenerateImpersonationToken(id string, role string, actorID string,
+	ttl time.Duration) string {
+	return "impersonation-token"
+}
+func (j *jwtServiceMock) GetAccessTokenTTL() time.Duration { return time.Hour }
+func (j *jwtServiceMock) GetJWKS() dto.JWKSResponse        { return dto.JWKSResponse{} }
 func (j *jwtServiceMock) ValidateToken(token string) (*jwt.Token, error) {
 	return &jwt.Token{Valid: true}, nil
 }
@@ -85,37 +313,90 @@ func (j *jwtServiceMock) GetAttrByToken(token string) (string, string, error) {
 	args := j.Called(token)
 	return args.String(0), args.String(1), args.Error(2)
 }
+func (j *jwtServiceMock) GetClaimsByToken(token string) (*service.TokenClaims, error) {
+	id, role, err := j.GetAttrByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	return &service.TokenClaims{UserID: id, Role: role, TokenID: "jti", SessionID: testSessionID,
+		ActorID: j.actorID, ExpiresAt: testTokenExpiry}, nil
+}
+
+var testTokenExpiry = time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+
+const testSessionID = "session-1"
 
 // --- Test Helpers ---
 
-func setupUserControllerTest() (*gin.Engine, *userServiceMock, *jwtServiceMock) {
+// userControllerMocks holds the service mocks behind the user routes.
+type userControllerMocks struct {
+	user     *userServiceMock
+	jwt      *jwtServiceMock
+	auth     *authServiceMock
+	password *passwordServiceMock
+	verify   *verificationServiceMock
+	mfa      *twoFactorServiceMock
+	throttle *loginThrottleServiceMock
+	imperson *impersonationServiceMock
+	apiKey   *apiKeyServiceMock
+	role     *roleServiceMock
+}
+
+func setupUserControllerTest() (*gin.Engine, *userControllerMocks) {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 	r.Use(middleware.ErrorHandler())
 
 	// Setup dependencies
 	injector := do.New()
-	usm := new(userServiceMock)
-	jwtm := new(jwtServiceMock)
-	userC := controller.NewUserController(usm, jwtm)
+	m := &userControllerMocks{
+		user:     new(userServiceMock),
+		jwt:      new(jwtServiceMock),
+		auth:     new(authServiceMock),
+		password: new(passwordServiceMock),
+		verify:   new(verificationServiceMock),
+		mfa:      new(twoFactorServiceMock),
+		throttle: new(loginThrottleServiceMock),
+		imperson: new(impersonationServiceMock),
+		apiKey:   new(apiKeyServiceMock),
+		role:     new(roleServiceMock),
+	}
+	userC := controller.NewUserController(m.user, m.auth, m.password, m.verify, m.mfa, m.throttle, m.imperson)
 	do.Provide(injector, func(i *do.Injector) (service.JWTService, error) {
-		return jwtm, nil
+		return m.jwt, nil
+	})
+	do.Provide(injector, func(i *do.Injector) (service.AuthService, error) {
+		return m.auth, nil
+	})
+	do.Provide(injector, func(i *do.Injector) (service.EmailVerificationService, error) {
+		return m.verify, nil
+	})
+	do.Provide(injector, func(i *do.Injector) (service.TwoFactorService, error) {
+		return m.mfa, nil
+	})
+	do.Provide(injector, func(i *do.Injector) (service.APIKeyService, error) {
+		return m.apiKey, nil
+	})
+	do.Provide(injector, func(i *do.Injector) (service.RoleService, error) {
+		return m.role, nil
 	})
 	do.Provide(injector, func(i *do.Injector) (controller.UserController, error) {
 		return userC, nil
 	})
 
 	router.UserRouter(r, injector)
-	return r, usm, jwtm
+	return r, m
 }
 
 // --- Tests ---
 
 func TestUserController_Register(t *testing.T) {
-	r, usm, _ := setupUserControllerTest()
+	r, m := setupUserControllerTest()
 
+	userID := uuid.NewString()
 	regReq := dto.UserRegisterRequest{Name: "A", Email: "a@mail.test", Password: "secret"}
-	usm.On("CreateNewUser", mock.Anything, regReq).Return(dto.UserResponse{ID: uuid.NewString(), Email: regReq.Email, Name: regReq.Name}, nil)
+	m.user.On("CreateNewUser", mock.Anything, regReq).Return(dto.UserResponse{ID: userID, Email: regReq.Email, Name: regReq.Name}, nil)
+	m.verify.On("SendVerificationEmail", mock.Anything, userID).Return(nil)
 
 	b, _ := json.Marshal(regReq)
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/users", bytes.NewReader(b))
@@ -131,18 +412,48 @@ func TestUserController_Register(t *testing.T) {
 	require.Equal(t, messages.MsgUserRegisterSuccess, resp["message"])
 }
 
+func TestUserController_Register_WeakPassword(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	regReq := dto.UserRegisterRequest{Name: "A", Email: "a@mail.test", Password: "short"}
+	violation := "The value of 'password' must be at least 8 characters long."
+	m.user.On("CreateNewUser", mock.Anything, regReq).
+		Return(dto.UserResponse{}, &errs.PasswordPolicyError{Violations: []string{violation}})
+
+	b, _ := json.Marshal(regReq)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	var resp map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Equal(t, violation, resp["message"])
+	m.verify.AssertNotCalled(t, "SendVerificationEmail", mock.Anything, mock.Anything)
+}
+
 func TestUserController_Login(t *testing.T) {
-	r, usm, _ := setupUserControllerTest()
+	r, m := setupUserControllerTest()
 
+	userID := uuid.NewString()
 	loginReq := dto.UserLoginRequest{Email: "a@mail.test", Password: "secret"}
-	usm.On("VerifyLogin", mock.Anything, loginReq.Email, loginReq.Password).Return(true)
-	usm.On("GetUserByPrimaryKey", mock.Anything, "email", loginReq.Email).Return(
-		dto.UserResponse{ID: uuid.NewString(), Email: loginReq.Email, Name: "A", Role: constant.EnumRoleUser}, nil,
+	m.user.On("VerifyLogin", mock.Anything, loginReq.Email, loginReq.Password).Return(true)
+	m.user.On("GetUserByPrimaryKey", mock.Anything, "email", loginReq.Email).Return(
+		dto.UserResponse{ID: userID, Email: loginReq.Email, Name: "A", Role: constant.EnumRoleUser}, nil,
+	)
+	m.auth.On("IssueTokens", mock.Anything, userID, constant.EnumRoleUser, mock.MatchedBy(func(c dto.UserSessionClient) bool {
+		return c.UserAgent == "test-agent/1.0" && c.IPAddress != ""
+	})).Return(
+		base.AuthResponse{Token: "token", RefreshToken: "refresh", Role: constant.EnumRoleUser}, nil,
 	)
 
 	b, _ := json.Marshal(loginReq)
 	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/login", bytes.NewReader(b))
 	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "test-agent/1.0")
 	w := httptest.NewRecorder()
 
 	r.ServeHTTP(w, req)
@@ -152,14 +463,602 @@ func TestUserController_Login(t *testing.T) {
 	err := json.Unmarshal(w.Body.Bytes(), &resp)
 	require.NoError(t, err)
 	require.Equal(t, messages.MsgUserLoginSuccess, resp["message"])
+
+	data := resp["data"].(map[string]interface{})
+	require.Equal(t, "refresh", data["refresh_token"])
+	require.Equal(t, []string{loginReq.Email}, m.throttle.successes)
+}
+
+func TestUserController_Login_WrongCredential(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	loginReq := dto.UserLoginRequest{Email: "a@mail.test", Password: "wrong"}
+	m.user.On("VerifyLogin", mock.Anything, loginReq.Email, loginReq.Password).Return(false)
+
+	b, _ := json.Marshal(loginReq)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/login", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Equal(t, []string{loginReq.Email}, m.throttle.failures)
+}
+
+func TestUserController_Login_Throttled(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	m.throttle.checkErr = errs.ErrLoginLocked
+	m.throttle.retryAfter = 90*time.Second + time.Millisecond
+
+	b, _ := json.Marshal(dto.UserLoginRequest{Email: "a@mail.test", Password: "secret"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/login", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "91", w.Header().Get("Retry-After"))
+	require.Contains(t, w.Body.String(), messages.MsgUserLoginThrottled)
+	m.user.AssertNotCalled(t, "VerifyLogin", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserController_Login_EmailNotVerified(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	loginReq := dto.UserLoginRequest{Email: "a@mail.test", Password: "secret"}
+	m.user.On("VerifyLogin", mock.Anything, loginReq.Email, loginReq.Password).Return(true)
+	m.user.On("GetUserByPrimaryKey", mock.Anything, "email", loginReq.Email).Return(
+		dto.UserResponse{ID: uuid.NewString(), Email: loginReq.Email, Role: constant.EnumRoleUser}, nil,
+	)
+	m.verify.checkErr = errs.ErrEmailNotVerified
+
+	b, _ := json.Marshal(loginReq)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/login", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusForbidden, w.Code)
+	m.auth.AssertNotCalled(t, "IssueTokens", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserController_Login_TwoFactorChallenge(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	userID := uuid.NewString()
+	loginReq := dto.UserLoginRequest{Email: "a@mail.test", Password: "secret"}
+	m.user.On("VerifyLogin", mock.Anything, loginReq.Email, loginReq.Password).Return(true)
+	m.user.On("GetUserByPrimaryKey", mock.Anything, "email", loginReq.Email).Return(
+		dto.UserResponse{ID: userID, Email: loginReq.Email, Role: constant.EnumRoleUser}, nil,
+	)
+	m.mfa.enabled = true
+	m.mfa.On("CreateChallenge", mock.Anything, userID).Return(
+		dto.UserLoginChallengeResponse{TwoFactorRequired: true, ChallengeToken: "challenge", ExpiresIn: 300}, nil,
+	)
+
+	b, _ := json.Marshal(loginReq)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/login", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	m.auth.AssertNotCalled(t, "IssueTokens", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	var resp map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	require.Equal(t, messages.MsgUserLoginTwoFactorRequired, resp["message"])
+	data := resp["data"].(map[string]interface{})
+	require.Equal(t, "challenge", data["challenge_token"])
+	require.Nil(t, data["token"])
+
+	// Failures are kept until the challenge is passed too
+	require.Empty(t, m.throttle.successes)
+}
+
+func TestUserController_LoginTwoFactor(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	userID := uuid.NewString()
+	good := dto.UserLoginTwoFactorRequest{ChallengeToken: "challenge", Code: "123456"}
+	bad := dto.UserLoginTwoFactorRequest{ChallengeToken: "challenge", Code: "000000"}
+	m.mfa.On("VerifyChallenge", mock.Anything, good).Return(userID, nil)
+	m.mfa.On("VerifyChallenge", mock.Anything, bad).Return(userID, errs.ErrTwoFactorCodeInvalid)
+	m.user.On("GetUserByPrimaryKey", mock.Anything, constant.DBAttrID, userID).Return(
+		dto.UserResponse{ID: userID, Email: "a@mail.test", Role: constant.EnumRoleAdmin}, nil,
+	)
+	m.auth.On("IssueTokens", mock.Anything, userID, constant.EnumRoleAdmin, mock.Anything).Return(
+		base.AuthResponse{Token: "token", Role: constant.EnumRoleAdmin}, nil,
+	)
+
+	post := func(body dto.UserLoginTwoFactorRequest) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users/login/2fa", bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// A wrong code counts against the account like a wrong password
+	w := post(bad)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	require.Contains(t, w.Body.String(), messages.MsgUserTwoFactorCodeInvalid)
+	require.Equal(t, []string{"a@mail.test"}, m.throttle.failures)
+	require.Empty(t, m.throttle.successes)
+
+	w = post(good)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"token":"token"`)
+	require.Equal(t, []string{"a@mail.test"}, m.throttle.successes)
+}
+
+func TestUserController_AdminRoute_TwoFactorRequired(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	m.jwt.On("GetAttrByToken", "admin-token").Return(uuid.NewString(), constant.EnumRoleAdmin, nil)
+	m.mfa.requiredErr = errs.ErrTwoFactorRequired
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/maintenance", nil)
+	req.Header.Set("Authorization", "Bearer admin-token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusForbidden, w.Code)
+	require.Contains(t, w.Body.String(), messages.MsgAuthTwoFactorRequired)
+}
+
+func TestUserController_UnlockUser(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	userID := uuid.NewString()
+	m.jwt.On("GetAttrByToken", "admin-token").Return(uuid.NewString(), constant.EnumRoleAdmin, nil)
+	m.throttle.On("UnlockUser", mock.Anything, userID).Return(nil)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/"+userID+"/lockout", nil)
+	req.Header.Set("Authorization", "Bearer admin-token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), messages.MsgUserLockoutClearSuccess)
+	m.throttle.AssertExpectations(t)
+}
+
+func TestUserController_VerifyEmail(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	m.verify.On("VerifyEmail", mock.Anything, dto.UserVerifyEmailRequest{Token: "abc"}).Return(nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/verify-email?token=abc", nil)
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Equal(t, messages.MsgUserEmailVerifySuccess, resp["message"])
+}
+
+func TestUserController_ResendVerificationEmail(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	resendReq := dto.UserVerifyEmailResendRequest{Email: "a@mail.test"}
+	m.verify.On("ResendVerificationEmail", mock.Anything, resendReq).Return(nil)
+
+	b, _ := json.Marshal(resendReq)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/verify-email/resend", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	m.verify.AssertExpectations(t)
+}
+
+func TestUserController_RefreshToken(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	refreshReq := dto.UserRefreshTokenRequest{RefreshToken: "refresh"}
+	m.auth.On("RefreshTokens", mock.Anything, refreshReq).Return(
+		base.AuthResponse{Token: "token", RefreshToken: "rotated", Role: constant.EnumRoleUser}, nil,
+	)
+
+	b, _ := json.Marshal(refreshReq)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/token/refresh", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Equal(t, messages.MsgUserTokenRefreshSuccess, resp["message"])
+}
+
+func TestUserController_RefreshToken_Reused(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	refreshReq := dto.UserRefreshTokenRequest{RefreshToken: "stale"}
+	m.auth.On("RefreshTokens", mock.Anything, refreshReq).Return(base.AuthResponse{}, errs.ErrRefreshTokenReused)
+
+	b, _ := json.Marshal(refreshReq)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/token/refresh", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestUserController_Logout(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	uuidStr := uuid.NewString()
+	m.jwt.On("GetAttrByToken", "token").Return(uuidStr, constant.EnumRoleUser, nil)
+	logoutReq := dto.UserLogoutRequest{
+		UserID:       uuidStr,
+		TokenID:      "jti",
+		SessionID:    testSessionID,
+		ExpiresAt:    testTokenExpiry,
+		RefreshToken: "refresh",
+	}
+	m.auth.On("Logout", mock.Anything, logoutReq).Return(nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/logout",
+		strings.NewReader(`{"refresh_token":"refresh"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	m.auth.AssertExpectations(t)
+}
+
+func TestUserController_APIKeyOnSelfRoutes(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	m.apiKey.On("AuthenticateAPIKey", mock.Anything, "mak_secret").Return(dto.APIKeyResponse{
+		ID:     uuid.NewString(),
+		Scopes: []string{constant.EnumPermissionUsersRead},
+	}, nil)
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPost, "/api/v1/users/logout"},
+		{http.MethodGet, "/api/v1/users/me"},
+		{http.MethodGet, "/api/v1/users/me/sessions"},
+		{http.MethodDelete, "/api/v1/users/me"},
+	} {
+		req := httptest.NewRequest(route.method, route.path, nil)
+		req.Header.Set("X-API-Key", "mak_secret")
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusForbidden, w.Code, route.path)
+	}
+	m.auth.AssertNotCalled(t, "Logout", mock.Anything, mock.Anything)
+	m.user.AssertNotCalled(t, "GetUserByPrimaryKey", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserController_GetMySessions(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	userID := uuid.NewString()
+	m.jwt.On("GetAttrByToken", "token").Return(userID, constant.EnumRoleUser, nil)
+	m.auth.On("GetUserSessions", mock.Anything, userID, testSessionID).Return([]dto.UserSessionResponse{
+		{ID: testSessionID, UserAgent: "test-agent/1.0", Current: true},
+		{ID: uuid.NewString(), UserAgent: "other-agent/2.0"},
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/me/sessions", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"current":true`)
+	m.auth.AssertExpectations(t)
+}
+
+func TestUserController_ChangeMyPassword(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	userID := uuid.NewString()
+	m.jwt.On("GetAttrByToken", "token").Return(userID, constant.EnumRoleUser, nil)
+	changeReq := dto.UserPasswordChangeRequest{ID: userID, CurrentPassword: "old-password", Password: "new-password"}
+	m.password.On("ChangePassword", mock.Anything, changeReq).Return(nil)
+
+	b, _ := json.Marshal(changeReq)
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/users/me/password", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), messages.MsgUserPasswordChangeSuccess)
+	m.password.AssertExpectations(t)
+}
+
+func TestUserController_ChangeMyPassword_WrongCurrent(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	userID := uuid.NewString()
+	m.jwt.On("GetAttrByToken", "token").Return(userID, constant.EnumRoleUser, nil)
+	changeReq := dto.UserPasswordChangeRequest{ID: userID, CurrentPassword: "guess", Password: "new-password"}
+	m.password.On("ChangePassword", mock.Anything, changeReq).Return(errs.ErrPasswordIncorrect)
+
+	b, _ := json.Marshal(changeReq)
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/users/me/password", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUserController_ImpersonateUser(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	actorID, userID := uuid.NewString(), uuid.NewString()
+	m.jwt.On("GetAttrByToken", "token").Return(actorID, constant.EnumRoleAdmin, nil)
+	m.imperson.On("Impersonate", mock.Anything,
+		dto.UserImpersonateRequest{ActorID: actorID, ActorRole: constant.EnumRoleAdmin, UserID: userID}).
+		Return(base.AuthResponse{Token: "impersonation-token", Role: constant.EnumRoleUser}, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/"+userID+"/impersonate", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), "impersonation-token")
+}
+
+func TestUserController_ImpersonateUser_NotAllowed(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	actorID, userID := uuid.NewString(), uuid.NewString()
+	m.jwt.On("GetAttrByToken", "token").Return(actorID, constant.EnumRoleAdmin, nil)
+	m.imperson.On("Impersonate", mock.Anything, mock.Anything).
+		Return(base.AuthResponse{}, errs.ErrImpersonationNotAllowed)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/"+userID+"/impersonate", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestUserController_Impersonating_SensitiveActionsForbidden(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	userID := uuid.NewString()
+	m.jwt.actorID = uuid.NewString()
+	m.jwt.On("GetAttrByToken", "token").Return(userID, constant.EnumRoleUser, nil)
+	m.user.On("GetUserByPrimaryKey", mock.Anything, constant.DBAttrID, userID).
+		Return(dto.UserResponse{ID: userID}, nil)
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPatch, "/api/v1/users/me/password"},
+		{http.MethodDelete, "/api/v1/users/me"},
+		{http.MethodGet, "/api/v1/users/me/export"},
+		{http.MethodGet, "/api/v1/users/me/sessions"},
+		{http.MethodDelete, "/api/v1/users/me/sessions/" + uuid.NewString()},
+		{http.MethodDelete, "/api/v1/users/me/2fa/totp"},
+		{http.MethodPost, "/api/v1/users/" + uuid.NewString() + "/impersonate"},
+	} {
+		req := httptest.NewRequest(route.method, route.path, nil)
+		req.Header.Set("Authorization", "Bearer token")
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+		require.Equal(t, http.StatusForbidden, w.Code, route.path)
+		require.Contains(t, w.Body.String(), messages.MsgAuthImpersonating)
+	}
+
+	// reading what the user sees still works
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/me", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	m.password.AssertNotCalled(t, "ChangePassword", mock.Anything, mock.Anything)
+}
+
+func TestUserController_DeleteSelfUser_SchedulesDeletion(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	userID := uuid.NewString()
+	at := time.Now().Add(30 * 24 * time.Hour)
+	m.jwt.On("GetAttrByToken", "token").Return(userID, constant.EnumRoleUser, nil)
+	m.user.On("ScheduleUserDeletion", mock.Anything, userID).
+		Return(dto.UserResponse{ID: userID, ScheduledDeletionAt: &at}, nil)
+	m.auth.On("RevokeUserSessions", mock.Anything, userID).Return(nil)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/me", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), messages.MsgUserDeletionScheduleSuccess)
+	require.Contains(t, w.Body.String(), "scheduled_deletion_at")
+	m.user.AssertNotCalled(t, "DeleteUserByID", mock.Anything, mock.Anything)
+	m.auth.AssertExpectations(t)
+}
+
+func TestUserController_DeleteSelfUser_AlreadyScheduled(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	userID := uuid.NewString()
+	m.jwt.On("GetAttrByToken", "token").Return(userID, constant.EnumRoleUser, nil)
+	m.user.On("ScheduleUserDeletion", mock.Anything, userID).
+		Return(dto.UserResponse{}, errs.ErrUserDeletionAlreadyScheduled)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/me", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	m.auth.AssertNotCalled(t, "RevokeUserSessions", mock.Anything, mock.Anything)
+}
+
+func TestUserController_CancelMyDeletion(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	userID := uuid.NewString()
+	m.jwt.On("GetAttrByToken", "token").Return(userID, constant.EnumRoleUser, nil)
+	m.user.On("CancelUserDeletion", mock.Anything, userID).Return(nil)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/me/deletion", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), messages.MsgUserDeletionCancelSuccess)
+	m.user.AssertExpectations(t)
+}
+
+func TestUserController_ExportMyData(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	userID := uuid.NewString()
+	m.jwt.On("GetAttrByToken", "token").Return(userID, constant.EnumRoleUser, nil)
+	m.user.On("ExportUserData", mock.Anything, userID).Return(dto.UserExportResponse{
+		Profile: dto.UserExportProfile{ID: userID, Email: "a@mail.test"},
+		Picture: &dto.UserExportFile{Path: "user_picture/a.png", Data: []byte("png")},
+	}, nil)
+	m.auth.On("GetUserSessions", mock.Anything, userID, testSessionID).Return([]dto.UserSessionResponse{
+		{ID: testSessionID, UserAgent: "test-agent/1.0", Current: true},
+	}, nil)
+	m.mfa.enabled = true
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/me/export", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, `attachment; filename="user-export-`+userID+`.json"`, w.Header().Get("Content-Disposition"))
+
+	var export dto.UserExportResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &export))
+	require.Equal(t, "a@mail.test", export.Profile.Email)
+	require.Equal(t, []byte("png"), export.Picture.Data)
+	require.True(t, export.TwoFactorEnabled)
+	require.Len(t, export.Sessions, 1)
+}
+
+func TestUserController_EndMySession(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	userID := uuid.NewString()
+	ownSessionID, foreignSessionID := uuid.NewString(), uuid.NewString()
+	m.jwt.On("GetAttrByToken", "token").Return(userID, constant.EnumRoleUser, nil)
+	m.auth.On("EndUserSession", mock.Anything, dto.UserSessionEndRequest{UserID: userID, SessionID: ownSessionID}).
+		Return(nil)
+	m.auth.On("EndUserSession", mock.Anything, dto.UserSessionEndRequest{UserID: userID, SessionID: foreignSessionID}).
+		Return(errs.ErrSessionNotFound)
+
+	for sessionID, want := range map[string]int{ownSessionID: http.StatusOK, foreignSessionID: http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/me/sessions/"+sessionID, nil)
+		req.Header.Set("Authorization", "Bearer token")
+		w := httptest.NewRecorder()
+
+		r.ServeHTTP(w, req)
+		require.Equal(t, want, w.Code)
+	}
+}
+
+func TestUserController_RevokeUserSessions(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	targetUserID := uuid.NewString()
+	m.jwt.On("GetAttrByToken", "token").Return(uuid.NewString(), constant.EnumRoleAdmin, nil)
+	m.auth.On("RevokeUserSessions", mock.Anything, targetUserID).Return(nil)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/"+targetUserID+"/sessions", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Equal(t, messages.MsgUserSessionsRevokeSuccess, resp["message"])
+}
+
+func TestUserController_ForgotPassword(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	forgotReq := dto.UserPasswordForgotRequest{Email: "a@mail.test"}
+	m.password.On("RequestPasswordReset", mock.Anything, forgotReq).Return(nil)
+
+	b, _ := json.Marshal(forgotReq)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/password/forgot", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	m.password.AssertExpectations(t)
+}
+
+func TestUserController_ResetPassword(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	resetReq := dto.UserPasswordResetRequest{Token: "reset", Password: "new-secret"}
+	m.password.On("ResetPassword", mock.Anything, resetReq).Return(nil)
+
+	b, _ := json.Marshal(resetReq)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/password/reset", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	require.NoError(t, err)
+	require.Equal(t, messages.MsgUserPasswordResetSuccess, resp["message"])
+	m.password.AssertExpectations(t)
+}
+
+func TestUserController_ResetPassword_InvalidToken(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	resetReq := dto.UserPasswordResetRequest{Token: "used", Password: "new-secret"}
+	m.password.On("ResetPassword", mock.Anything, resetReq).Return(errs.ErrPasswordResetTokenInvalid)
+
+	b, _ := json.Marshal(resetReq)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/password/reset", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
 }
 
 func TestUserController_Login_Invalid(t *testing.T) {
-	r, usm, _ := setupUserControllerTest()
+	r, m := setupUserControllerTest()
 
 	loginReq := dto.UserLoginRequest{Email: "a@mail.test", Password: "wrong"}
-	usm.On("VerifyLogin", mock.Anything, loginReq.Email, loginReq.Password).Return(false)
-	usm.On("GetUserByPrimaryKey", mock.Anything, "email", loginReq.Email).Return(
+	m.user.On("VerifyLogin", mock.Anything, loginReq.Email, loginReq.Password).Return(false)
+	m.user.On("GetUserByPrimaryKey", mock.Anything, "email", loginReq.Email).Return(
 		dto.UserResponse{}, errs.ErrUserNotFound,
 	)
 
@@ -173,11 +1072,11 @@ func TestUserController_Login_Invalid(t *testing.T) {
 }
 
 func TestUserController_GetMe(t *testing.T) {
-	r, usm, jwtm := setupUserControllerTest()
+	r, m := setupUserControllerTest()
 
 	uuidStr := uuid.NewString()
-	jwtm.On("GetAttrByToken", "token").Return(uuidStr, constant.EnumRoleUser, nil)
-	usm.On("GetUserByPrimaryKey", mock.Anything, mock.Anything, mock.Anything).Return(
+	m.jwt.On("GetAttrByToken", "token").Return(uuidStr, constant.EnumRoleUser, nil)
+	m.user.On("GetUserByPrimaryKey", mock.Anything, mock.Anything, mock.Anything).Return(
 		dto.UserResponse{ID: uuidStr, Email: "a@mail.test", Name: "A", Role: constant.EnumRoleUser}, nil,
 	)
 
@@ -195,7 +1094,7 @@ func TestUserController_GetMe(t *testing.T) {
 }
 
 func TestUserController_GetMe_Unauthenticated(t *testing.T) {
-	r, _, _ := setupUserControllerTest()
+	r, _ := setupUserControllerTest()
 
 	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/me", nil)
 	w := httptest.NewRecorder()
@@ -205,11 +1104,11 @@ func TestUserController_GetMe_Unauthenticated(t *testing.T) {
 }
 
 func TestUserController_GetAllUsers(t *testing.T) {
-	r, usm, jwtm := setupUserControllerTest()
+	r, m := setupUserControllerTest()
 
 	getsReq := dto.UserGetsRequest{Search: "a", PaginationRequest: base.PaginationRequest{Page: 1, PerPage: 10}}
-	jwtm.On("GetAttrByToken", "token").Return(uuid.NewString(), constant.EnumRoleAdmin, nil)
-	usm.On("GetAllUsers", mock.Anything, getsReq).Return(
+	m.jwt.On("GetAttrByToken", "token").Return(uuid.NewString(), constant.EnumRoleAdmin, nil)
+	m.user.On("GetAllUsers", mock.Anything, getsReq).Return(
 		[]dto.UserResponse{
 			{ID: uuid.NewString(), Email: "a@mail.test", Name: "A", Role: constant.EnumRoleUser},
 		}, base.PaginationResponse{Page: 1, PerPage: 10, Total: 1}, nil,
@@ -229,13 +1128,13 @@ func TestUserController_GetAllUsers(t *testing.T) {
 }
 
 func TestUserController_UpdateSelfName(t *testing.T) {
-	r, usm, jwtm := setupUserControllerTest()
+	r, m := setupUserControllerTest()
 
 	uuidStr := uuid.NewString()
-	jwtm.On("GetAttrByToken", "token").Return(uuidStr, constant.EnumRoleUser, nil)
+	m.jwt.On("GetAttrByToken", "token").Return(uuidStr, constant.EnumRoleUser, nil)
 
 	updateReq := dto.UserNameUpdateRequest{ID: uuidStr, Name: "Updated Name"}
-	usm.On("UpdateSelfName", mock.Anything, updateReq).Return(
+	m.user.On("UpdateSelfName", mock.Anything, updateReq).Return(
 		dto.UserResponse{ID: uuidStr, Email: "a@mail.test", Name: "Updated Name", Role: constant.EnumRoleUser}, nil,
 	)
 
@@ -255,13 +1154,16 @@ func TestUserController_UpdateSelfName(t *testing.T) {
 }
 
 func TestUserController_UpdateUserByID(t *testing.T) {
-	r, usm, jwtm := setupUserControllerTest()
+	r, m := setupUserControllerTest()
 
-	targetUserID := uuid.NewString()
-	jwtm.On("GetAttrByToken", "token").Return(uuid.NewString(), constant.EnumRoleAdmin, nil)
+	targetUserID, adminID := uuid.NewString(), uuid.NewString()
+	m.jwt.On("GetAttrByToken", "token").Return(adminID, constant.EnumRoleAdmin, nil)
 
-	updateReq := dto.UserUpdateRequest{ID: targetUserID, Name: "Updated Name", Role: constant.EnumRoleUser}
-	usm.On("UpdateUserByID", mock.Anything, updateReq).Return(
+	updateReq := dto.UserUpdateRequest{
+		ID: targetUserID, ActorID: adminID, ActorRole: constant.EnumRoleAdmin,
+		Name: "Updated Name", Role: constant.EnumRoleUser,
+	}
+	m.user.On("UpdateUserByID", mock.Anything, updateReq).Return(
 		dto.UserResponse{ID: targetUserID, Email: "a@mail.test", Name: "Updated Name", Role: constant.EnumRoleUser}, nil,
 	)
 
@@ -280,12 +1182,51 @@ func TestUserController_UpdateUserByID(t *testing.T) {
 	require.Equal(t, messages.MsgUserUpdateSuccess, resp["message"])
 }
 
+func TestUserController_UpdateUserByID_PasswordRevokesSessions(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	targetUserID := uuid.NewString()
+	m.jwt.On("GetAttrByToken", "token").Return(uuid.NewString(), constant.EnumRoleAdmin, nil)
+	m.user.On("UpdateUserByID", mock.Anything, mock.MatchedBy(func(req dto.UserUpdateRequest) bool {
+		return req.ID == targetUserID && req.Password == "new-password"
+	})).Return(dto.UserResponse{ID: targetUserID}, nil)
+	m.auth.On("RevokeUserSessions", mock.Anything, targetUserID).Return(nil)
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/users/"+targetUserID,
+		strings.NewReader(`{"password":"new-password"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	m.auth.AssertExpectations(t)
+}
+
+func TestUserController_UpdateUserByID_OwnRole(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	adminID := uuid.NewString()
+	m.jwt.On("GetAttrByToken", "token").Return(adminID, constant.EnumRoleAdmin, nil)
+	m.user.On("UpdateUserByID", mock.Anything, mock.Anything).Return(dto.UserResponse{}, errs.ErrUserRoleChangeSelf)
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/users/"+adminID,
+		strings.NewReader(`{"role":"user"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
+
 func TestUserController_Delete(t *testing.T) {
-	r, usm, jwtm := setupUserControllerTest()
+	r, m := setupUserControllerTest()
 
 	targetUserID := uuid.NewString()
-	jwtm.On("GetAttrByToken", "token").Return(uuid.NewString(), constant.EnumRoleAdmin, nil)
-	usm.On("DeleteUserByID", mock.Anything, targetUserID).Return(nil)
+	m.jwt.On("GetAttrByToken", "token").Return(uuid.NewString(), constant.EnumRoleAdmin, nil)
+	m.user.On("DeleteUserByID", mock.Anything, targetUserID).Return(nil)
+	m.auth.On("RevokeUserSessions", mock.Anything, targetUserID).Return(nil)
 
 	req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/"+targetUserID, nil)
 	req.Header.Set("Authorization", "Bearer token")
@@ -301,10 +1242,10 @@ func TestUserController_Delete(t *testing.T) {
 }
 
 func TestUserController_ChangePicture(t *testing.T) {
-	r, usm, jwtm := setupUserControllerTest()
+	r, m := setupUserControllerTest()
 
 	uuidStr := uuid.NewString()
-	jwtm.On("GetAttrByToken", "token").Return(uuidStr, constant.EnumRoleUser, nil)
+	m.jwt.On("GetAttrByToken", "token").Return(uuidStr, constant.EnumRoleUser, nil)
 
 	// Create a multipart form file
 	body := &bytes.Buffer{}
@@ -326,11 +1267,12 @@ func TestUserController_ChangePicture(t *testing.T) {
 	fileHeader := req.MultipartForm.File["picture"][0]
 
 	changePicReq := dto.UserChangePictureRequest{ID: uuidStr, Picture: fileHeader}
-	usm.On("ChangePicture", mock.Anything, changePicReq).Return(
+	m.user.On("PictureRequestLimit").Return(int64(1 << 20))
+	m.user.On("ChangePicture", mock.Anything, changePicReq).Return(
 		dto.UserResponse{ID: uuidStr}, nil,
 	)
 
-	usm.On("ChangePicture", mock.Anything, changePicReq).
+	m.user.On("ChangePicture", mock.Anything, changePicReq).
 		Return(dto.UserResponse{ID: uuidStr, Name: "Updated"}, nil)
 
 	r.ServeHTTP(w, req)
@@ -342,13 +1284,39 @@ func TestUserController_ChangePicture(t *testing.T) {
 	require.Equal(t, messages.MsgUserPictureUpdateSuccess, resp["message"])
 }
 
+func TestUserController_ChangePicture_TooLarge(t *testing.T) {
+	r, m := setupUserControllerTest()
+
+	uuidStr := uuid.NewString()
+	m.jwt.On("GetAttrByToken", "token").Return(uuidStr, constant.EnumRoleUser, nil)
+	m.user.On("PictureRequestLimit").Return(int64(1024))
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	fileWriter, err := writer.CreateFormFile("picture", "profile.png")
+	require.NoError(t, err)
+
+	_, err = fileWriter.Write(bytes.Repeat([]byte{0xff}, 4096))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/v1/users/picture", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	m.user.AssertNotCalled(t, "ChangePicture", mock.Anything, mock.Anything)
+}
+
 func TestUserController_DeletePicture(t *testing.T) {
-	r, usm, jwtm := setupUserControllerTest()
+	r, m := setupUserControllerTest()
 
 	uuidStr := uuid.NewString()
-	jwtm.On("GetAttrByToken", "token").Return(uuidStr, constant.EnumRoleUser, nil)
+	m.jwt.On("GetAttrByToken", "token").Return(uuidStr, constant.EnumRoleUser, nil)
 
-	usm.On("DeletePicture", mock.Anything, uuidStr).Return(nil)
+	m.user.On("DeletePicture", mock.Anything, uuidStr).Return(nil)
 
 	req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/picture/"+uuidStr, nil)
 	req.Header.Set("Authorization", "Bearer token")
@@ -0,0 +1,227 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	"myapp/core/helper/messages"
+	"myapp/core/service"
+	"myapp/support/base"
+
+	"github.com/gin-gonic/gin"
+)
+
+type trashController struct {
+	trashService service.TrashService
+}
+
+type TrashController interface {
+	GetTrashedUsers(ctx *gin.Context)
+	GetTrashedProducts(ctx *gin.Context)
+	GetTrashedCategories(ctx *gin.Context)
+	RestoreUser(ctx *gin.Context)
+	RestoreProduct(ctx *gin.Context)
+	RestoreCategory(ctx *gin.Context)
+	PurgeUser(ctx *gin.Context)
+	PurgeProduct(ctx *gin.Context)
+	PurgeCategory(ctx *gin.Context)
+}
+
+func NewTrashController(trashS service.TrashService) TrashController {
+	return &trashController{
+		trashService: trashS,
+	}
+}
+
+// GetTrashedUsers godoc
+// @Summary      Get deleted users
+// @Description  Get soft-deleted users, most recently deleted first, with search, sorting, and pagination
+// @Tags         Trash
+// @Accept       json
+// @Produce      json
+// @Param        search    query     string  false  "Search by name or email"
+// @Param        sort      query     string  false  "Sort field (prefix with - for desc)"
+// @Param        page      query     int     false  "Page number"
+// @Param        per_page  query     int     false  "Items per page"
+// @Success      200       {object}  base.Response{data=[]dto.TrashedUserResponse}
+// @Failure      400       {object}  base.Response
+// @Security     ApiKeyAuth
+// @Router       /trash/users [get]
+func (tc *trashController) GetTrashedUsers(ctx *gin.Context) {
+	HandleGetAll(ctx, dto.TrashGetsRequest{}, tc.trashService.GetTrashedUsers,
+		messages.MsgTrashFetchSuccess, messages.MsgTrashFetchFailed)
+}
+
+// GetTrashedProducts godoc
+// @Summary      Get deleted products
+// @Description  Get soft-deleted products, most recently deleted first, with search, sorting, and pagination
+// @Tags         Trash
+// @Accept       json
+// @Produce      json
+// @Param        search    query     string  false  "Search by name or SKU"
+// @Param        sort      query     string  false  "Sort field (prefix with - for desc)"
+// @Param        page      query     int     false  "Page number"
+// @Param        per_page  query     int     false  "Items per page"
+// @Success      200       {object}  base.Response{data=[]dto.TrashedProductResponse}
+// @Failure      400       {object}  base.Response
+// @Security     ApiKeyAuth
+// @Router       /trash/products [get]
+func (tc *trashController) GetTrashedProducts(ctx *gin.Context) {
+	HandleGetAll(ctx, dto.TrashGetsRequest{}, tc.trashService.GetTrashedProducts,
+		messages.MsgTrashFetchSuccess, messages.MsgTrashFetchFailed)
+}
+
+// GetTrashedCategories godoc
+// @Summary      Get deleted categories
+// @Description  Get soft-deleted categories, most recently deleted first, with search, sorting, and pagination
+// @Tags         Trash
+// @Accept       json
+// @Produce      json
+// @Param        search    query     string  false  "Search by name"
+// @Param        sort      query     string  false  "Sort field (prefix with - for desc)"
+// @Param        page      query     int     false  "Page number"
+// @Param        per_page  query     int     false  "Items per page"
+// @Success      200       {object}  base.Response{data=[]dto.TrashedCategoryResponse}
+// @Failure      400       {object}  base.Response
+// @Security     ApiKeyAuth
+// @Router       /trash/categories [get]
+func (tc *trashController) GetTrashedCategories(ctx *gin.Context) {
+	HandleGetAll(ctx, dto.TrashGetsRequest{}, tc.trashService.GetTrashedCategories,
+		messages.MsgTrashFetchSuccess, messages.MsgTrashFetchFailed)
+}
+
+// RestoreUser godoc
+// @Summary      Restore a deleted user
+// @Description  Bring a soft-deleted user back, unless their email was taken meanwhile or their data was erased
+// @Tags         Trash
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object}  base.Response{data=dto.UserResponse}
+// @Failure      404  {object}  base.Response
+// @Failure      409  {object}  base.Response
+// @Security     ApiKeyAuth
+// @Router       /trash/users/{id}/restore [post]
+func (tc *trashController) RestoreUser(ctx *gin.Context) {
+	handleRestore(ctx, tc.trashService.RestoreUser)
+}
+
+// RestoreProduct godoc
+// @Summary      Restore a deleted product
+// @Description  Bring a soft-deleted product back, unless its SKU was taken meanwhile or its category is deleted
+// @Tags         Trash
+// @Produce      json
+// @Param        id   path      string  true  "Product ID"
+// @Success      200  {object}  base.Response{data=dto.ProductResponse}
+// @Failure      404  {object}  base.Response
+// @Failure      409  {object}  base.Response
+// @Security     ApiKeyAuth
+// @Router       /trash/products/{id}/restore [post]
+func (tc *trashController) RestoreProduct(ctx *gin.Context) {
+	handleRestore(ctx, tc.trashService.RestoreProduct)
+}
+
+// RestoreCategory godoc
+// @Summary      Restore a deleted category
+// @Description  Bring a soft-deleted category back, unless its name was taken meanwhile
+// @Tags         Trash
+// @Produce      json
+// @Param        id   path      string  true  "Category ID"
+// @Success      200  {object}  base.Response{data=dto.CategoryResponse}
+// @Failure      404  {object}  base.Response
+// @Failure      409  {object}  base.Response
+// @Security     ApiKeyAuth
+// @Router       /trash/categories/{id}/restore [post]
+func (tc *trashController) RestoreCategory(ctx *gin.Context) {
+	handleRestore(ctx, tc.trashService.RestoreCategory)
+}
+
+// PurgeUser godoc
+// @Summary      Purge a deleted user
+// @Description  Permanently delete a soft-deleted user along with their sessions, credentials and picture
+// @Tags         Trash
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object}  base.Response
+// @Failure      404  {object}  base.Response
+// @Security     ApiKeyAuth
+// @Router       /trash/users/{id} [delete]
+func (tc *trashController) PurgeUser(ctx *gin.Context) {
+	handlePurge(ctx, tc.trashService.PurgeUser)
+}
+
+// PurgeProduct godoc
+// @Summary      Purge a deleted product
+// @Description  Permanently delete a soft-deleted product along with its revisions and image
+// @Tags         Trash
+// @Produce      json
+// @Param        id   path      string  true  "Product ID"
+// @Success      200  {object}  base.Response
+// @Failure      404  {object}  base.Response
+// @Security     ApiKeyAuth
+// @Router       /trash/products/{id} [delete]
+func (tc *trashController) PurgeProduct(ctx *gin.Context) {
+	handlePurge(ctx, tc.trashService.PurgeProduct)
+}
+
+// PurgeCategory godoc
+// @Summary      Purge a deleted category
+// @Description  Permanently delete a soft-deleted category that no product, deleted or not, belongs to
+// @Tags         Trash
+// @Produce      json
+// @Param        id   path      string  true  "Category ID"
+// @Success      200  {object}  base.Response
+// @Failure      404  {object}  base.Response
+// @Failure      409  {object}  base.Response
+// @Security     ApiKeyAuth
+// @Router       /trash/categories/{id} [delete]
+func (tc *trashController) PurgeCategory(ctx *gin.Context) {
+	handlePurge(ctx, tc.trashService.PurgeCategory)
+}
+
+func handleRestore[R any](ctx *gin.Context, restoreFunc func(context.Context, string) (R, error)) {
+	result, err := restoreFunc(ctx, ctx.Param("id"))
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(trashErrorStatus(err), messages.MsgTrashRestoreFailed, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		messages.MsgTrashRestoreSuccess,
+		http.StatusOK, result,
+	))
+}
+
+func handlePurge(ctx *gin.Context, purgeFunc func(context.Context, string) error) {
+	if err := purgeFunc(ctx, ctx.Param("id")); err != nil {
+		_ = ctx.Error(base.NewAppError(trashErrorStatus(err), messages.MsgTrashPurgeFailed, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		messages.MsgTrashPurgeSuccess,
+		http.StatusOK, nil,
+	))
+}
+
+// trashErrorStatus answers 404 for IDs that are not in the trash and 409 for
+// records that cannot be restored or purged in the current state.
+func trashErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, errs.ErrUserNotFound),
+		errors.Is(err, errs.ErrProductNotFound),
+		errors.Is(err, errs.ErrCategoryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errs.ErrEmailAlreadyExists),
+		errors.Is(err, errs.ErrProductSKUExists),
+		errors.Is(err, errs.ErrCategoryNameExists),
+		errors.Is(err, errs.ErrProductCategoryTrashed),
+		errors.Is(err, errs.ErrCategoryHasProducts),
+		errors.Is(err, errs.ErrUserAnonymized):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
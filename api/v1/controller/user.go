@@ -1,30 +1,62 @@
 package controller
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
 
 	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
 	"myapp/core/helper/messages"
 	"myapp/core/service"
 	"myapp/support/base"
 	"myapp/support/constant"
+	"myapp/support/logger"
 
 	"github.com/gin-gonic/gin"
 )
 
 type userController struct {
-	userService service.UserService
-	jwtService  service.JWTService
+	userService          service.UserService
+	authService          service.AuthService
+	passwordService      service.PasswordService
+	verificationService  service.EmailVerificationService
+	twoFactorService     service.TwoFactorService
+	throttleService      service.LoginThrottleService
+	impersonationService service.ImpersonationService
 }
 
 type UserController interface {
 	Register(ctx *gin.Context)
 	Login(ctx *gin.Context)
+	LoginTwoFactor(ctx *gin.Context)
+	RefreshToken(ctx *gin.Context)
+	Logout(ctx *gin.Context)
+	RevokeUserSessions(ctx *gin.Context)
+	GetMySessions(ctx *gin.Context)
+	EndMySession(ctx *gin.Context)
+	UnlockUser(ctx *gin.Context)
+	ImpersonateUser(ctx *gin.Context)
+	ForgotPassword(ctx *gin.Context)
+	ResetPassword(ctx *gin.Context)
+	ChangeMyPassword(ctx *gin.Context)
+	VerifyEmail(ctx *gin.Context)
+	ResendVerificationEmail(ctx *gin.Context)
+	EnrollTOTP(ctx *gin.Context)
+	ConfirmTOTP(ctx *gin.Context)
+	DisableTOTP(ctx *gin.Context)
+	RegenerateRecoveryCodes(ctx *gin.Context)
 	GetAllUsers(ctx *gin.Context)
 	GetMe(ctx *gin.Context)
 	UpdateSelfName(ctx *gin.Context)
 	UpdateUserByID(ctx *gin.Context)
 	DeleteSelfUser(ctx *gin.Context)
+	CancelMyDeletion(ctx *gin.Context)
+	ExportMyData(ctx *gin.Context)
 	DeleteUserByID(ctx *gin.Context)
 	ChangePicture(ctx *gin.Context)
 	DeletePicture(ctx *gin.Context)
@@ -33,15 +65,67 @@ type UserController interface {
 	RunUserMaintenance(ctx *gin.Context)
 }
 
-func NewUserController(userS service.UserService, jwtS service.JWTService) UserController {
+func NewUserController(userS service.UserService, authS service.AuthService,
+	passwordS service.PasswordService, verificationS service.EmailVerificationService,
+	twoFactorS service.TwoFactorService, throttleS service.LoginThrottleService,
+	impersonationS service.ImpersonationService,
+) UserController {
 	return &userController{
-		userService: userS,
-		jwtService:  jwtS,
+		userService:          userS,
+		authService:          authS,
+		passwordService:      passwordS,
+		verificationService:  verificationS,
+		twoFactorService:     twoFactorS,
+		throttleService:      throttleS,
+		impersonationService: impersonationS,
+	}
+}
+
+// ============== Helper Functions ==============
+
+// deleteUserAndSessions deletes a user and then ends all of their sessions so
+// tokens issued before the deletion stop working immediately.
+func (uc *userController) deleteUserAndSessions(ctx context.Context, id string) error {
+	if err := uc.userService.DeleteUserByID(ctx, id); err != nil {
+		return err
+	}
+	return uc.authService.RevokeUserSessions(ctx, id)
+}
+
+// registerAndSendVerification creates the user and mails the verification
+// link. A failed mail does not fail the registration, the user can ask for
+// the link again through the resend endpoint.
+func (uc *userController) registerAndSendVerification(ctx context.Context,
+	req dto.UserRegisterRequest) (dto.UserResponse, error) {
+	user, err := uc.userService.CreateNewUser(ctx, req)
+	if err != nil {
+		return dto.UserResponse{}, err
+	}
+
+	if err := uc.verificationService.SendVerificationEmail(ctx, user.ID); err != nil {
+		logger.Warn("Failed to send verification email to user %s: %v", user.ID, err)
+	}
+	return user, nil
+}
+
+// recordTwoFactorFailure counts a wrong two-factor code like a wrong
+// password, so the account locks out however many challenges are started.
+func (uc *userController) recordTwoFactorFailure(ctx *gin.Context, userID string) {
+	if userID == "" {
+		return
+	}
+	user, err := uc.userService.GetUserByPrimaryKey(ctx, constant.DBAttrID, userID)
+	if err != nil {
+		logger.Error("Failed to record failed two-factor log in for user %s: %v", userID, err)
+		return
+	}
+	if err := uc.throttleService.RecordFailure(ctx, user.Email, ctx.ClientIP()); err != nil {
+		logger.Error("Failed to record failed log in for %s: %v", user.Email, err)
 	}
 }
 
 func (uc *userController) Register(ctx *gin.Context) {
-	HandleCreate(ctx, dto.UserRegisterRequest{}, uc.userService.CreateNewUser,
+	HandleCreate(ctx, dto.UserRegisterRequest{}, uc.registerAndSendVerification,
 		messages.MsgUserRegisterSuccess, messages.MsgUserRegisterFailed)
 }
 
@@ -53,8 +137,25 @@ func (uc *userController) Login(ctx *gin.Context) {
 		return
 	}
 
+	clientIP := ctx.ClientIP()
+	retryAfter, err := uc.throttleService.CheckLogin(ctx, userDTO.Email, clientIP)
+	if errors.Is(err, errs.ErrLoginThrottled) || errors.Is(err, errs.ErrLoginLocked) {
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+		_ = ctx.Error(base.NewAppError(http.StatusTooManyRequests,
+			messages.MsgUserLoginThrottled, err))
+		return
+	}
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+			messages.MsgUserLoginFailed, err))
+		return
+	}
+
 	res := uc.userService.VerifyLogin(ctx, userDTO.Email, userDTO.Password)
 	if !res {
+		if err := uc.throttleService.RecordFailure(ctx, userDTO.Email, clientIP); err != nil {
+			logger.Error("Failed to record failed log in for %s: %v", userDTO.Email, err)
+		}
 		_ = ctx.Error(base.NewAppError(http.StatusUnauthorized,
 			messages.MsgUserWrongCredential, nil))
 		return
@@ -67,14 +168,370 @@ func (uc *userController) Login(ctx *gin.Context) {
 		return
 	}
 
-	token := uc.jwtService.GenerateToken(user.ID, user.Role)
-	authResp := base.CreateAuthResponse(token, user.Role)
+	err = uc.verificationService.CheckEmailVerified(ctx, user.ID, constant.EnumEmailVerificationLogin)
+	if errors.Is(err, errs.ErrEmailNotVerified) {
+		_ = ctx.Error(base.NewAppError(http.StatusForbidden,
+			messages.MsgUserEmailNotVerified, err))
+		return
+	}
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+			messages.MsgUserLoginFailed, err))
+		return
+	}
+
+	resp, challenged, err := issueTokensOrChallenge(ctx, uc.authService, uc.twoFactorService, user.ID, user.Role)
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+			messages.MsgUserLoginFailed, err))
+		return
+	}
+
+	// With a challenge the failures are only forgotten once it is passed,
+	// or a new challenge would reset the limit on guessing codes
+	msg := messages.MsgUserLoginSuccess
+	if challenged {
+		msg = messages.MsgUserLoginTwoFactorRequired
+	} else if err := uc.throttleService.RecordSuccess(ctx, userDTO.Email); err != nil {
+		logger.Error("Failed to clear failed log ins for %s: %v", userDTO.Email, err)
+	}
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		msg,
+		http.StatusOK, resp,
+	))
+}
+
+func (uc *userController) LoginTwoFactor(ctx *gin.Context) {
+	var req dto.UserLoginTwoFactorRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		msg := base.GetValidationErrorMessage(err, req, messages.MsgUserLoginFailed)
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest, msg, err))
+		return
+	}
+
+	userID, err := uc.twoFactorService.VerifyChallenge(ctx, req)
+	if errors.Is(err, errs.ErrTwoFactorCodeInvalid) {
+		uc.recordTwoFactorFailure(ctx, userID)
+		_ = ctx.Error(base.NewAppError(http.StatusUnauthorized,
+			messages.MsgUserTwoFactorCodeInvalid, err))
+		return
+	}
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusUnauthorized,
+			messages.MsgUserLoginFailed, err))
+		return
+	}
+
+	user, err := uc.userService.GetUserByPrimaryKey(ctx, constant.DBAttrID, userID)
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+			messages.MsgUserLoginFailed, err))
+		return
+	}
+
+	authResp, err := uc.authService.IssueTokens(ctx, user.ID, user.Role, sessionClient(ctx))
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+			messages.MsgUserLoginFailed, err))
+		return
+	}
+	if err := uc.throttleService.RecordSuccess(ctx, user.Email); err != nil {
+		logger.Error("Failed to clear failed log ins for %s: %v", user.Email, err)
+	}
+
 	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
 		messages.MsgUserLoginSuccess,
 		http.StatusOK, authResp,
 	))
 }
 
+func (uc *userController) RefreshToken(ctx *gin.Context) {
+	var req dto.UserRefreshTokenRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		msg := base.GetValidationErrorMessage(err, req, messages.MsgUserTokenRefreshFailed)
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest, msg, err))
+		return
+	}
+
+	authResp, err := uc.authService.RefreshTokens(ctx, req)
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusUnauthorized,
+			messages.MsgUserTokenRefreshFailed, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		messages.MsgUserTokenRefreshSuccess,
+		http.StatusOK, authResp,
+	))
+}
+
+func (uc *userController) Logout(ctx *gin.Context) {
+	// the body is optional, only bind it when one was sent
+	var req dto.UserLogoutRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBind(&req); err != nil {
+			msg := base.GetValidationErrorMessage(err, req, messages.MsgUserLogoutFailed)
+			_ = ctx.Error(base.NewAppError(http.StatusBadRequest, msg, err))
+			return
+		}
+	}
+
+	// only user tokens carry a token ID and expiry to revoke
+	expiresAt, _ := ctx.Get("TOKEN_EXP")
+	req.ExpiresAt, _ = expiresAt.(time.Time)
+	req.UserID = ctx.GetString("ID")
+	req.TokenID = ctx.GetString("TOKEN_ID")
+	req.SessionID = ctx.GetString("SESSION_ID")
+	if req.ExpiresAt.IsZero() || req.UserID == "" || req.TokenID == "" {
+		_ = ctx.Error(base.NewAppError(http.StatusUnauthorized,
+			messages.MsgAuthInvalidToken, nil))
+		return
+	}
+
+	if err := uc.authService.Logout(ctx, req); err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+			messages.MsgUserLogoutFailed, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		messages.MsgUserLogoutSuccess,
+		http.StatusOK, nil,
+	))
+}
+
+func (uc *userController) RevokeUserSessions(ctx *gin.Context) {
+	id := ctx.Param("user_id")
+	HandleDelete(ctx, id, uc.authService.RevokeUserSessions,
+		messages.MsgUserSessionsRevokeSuccess, messages.MsgUserSessionsRevokeFailed)
+}
+
+func (uc *userController) GetMySessions(ctx *gin.Context) {
+	userID := ctx.MustGet("ID").(string)
+
+	sessions, err := uc.authService.GetUserSessions(ctx, userID, ctx.GetString("SESSION_ID"))
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+			messages.MsgUserSessionGetsFailed, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		messages.MsgUserSessionGetsSuccess,
+		http.StatusOK, sessions,
+	))
+}
+
+func (uc *userController) EndMySession(ctx *gin.Context) {
+	req := dto.UserSessionEndRequest{
+		UserID:    ctx.MustGet("ID").(string),
+		SessionID: ctx.Param("session_id"),
+	}
+
+	err := uc.authService.EndUserSession(ctx, req)
+	if errors.Is(err, errs.ErrSessionNotFound) {
+		_ = ctx.Error(base.NewAppError(http.StatusNotFound,
+			messages.MsgUserSessionEndFailed, err))
+		return
+	}
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+			messages.MsgUserSessionEndFailed, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		messages.MsgUserSessionEndSuccess,
+		http.StatusOK, nil,
+	))
+}
+
+func (uc *userController) UnlockUser(ctx *gin.Context) {
+	id := ctx.Param("user_id")
+	HandleDelete(ctx, id, uc.throttleService.UnlockUser,
+		messages.MsgUserLockoutClearSuccess, messages.MsgUserLockoutClearFailed)
+}
+
+// ImpersonateUser hands the caller a short-lived token acting as another
+// user, for support staff to see what the user sees.
+func (uc *userController) ImpersonateUser(ctx *gin.Context) {
+	req := dto.UserImpersonateRequest{
+		ActorID:   ctx.MustGet("ID").(string),
+		ActorRole: ctx.MustGet("ROLE").(string),
+		UserID:    ctx.Param("user_id"),
+	}
+
+	resp, err := uc.impersonationService.Impersonate(ctx, req)
+	if err != nil {
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, errs.ErrUserNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, errs.ErrImpersonateSelf), errors.Is(err, errs.ErrImpersonationNotAllowed):
+			status = http.StatusForbidden
+		}
+		_ = ctx.Error(base.NewAppError(status, messages.MsgUserImpersonateFailed, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		messages.MsgUserImpersonateSuccess,
+		http.StatusOK, resp,
+	))
+}
+
+func (uc *userController) ForgotPassword(ctx *gin.Context) {
+	var req dto.UserPasswordForgotRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		msg := base.GetValidationErrorMessage(err, req, messages.MsgUserPasswordForgotFailed)
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest, msg, err))
+		return
+	}
+
+	if err := uc.passwordService.RequestPasswordReset(ctx, req); err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusInternalServerError,
+			messages.MsgUserPasswordForgotFailed, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		messages.MsgUserPasswordForgotSuccess,
+		http.StatusOK, nil,
+	))
+}
+
+func (uc *userController) ResetPassword(ctx *gin.Context) {
+	var req dto.UserPasswordResetRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		msg := base.GetValidationErrorMessage(err, req, messages.MsgUserPasswordResetFailed)
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest, msg, err))
+		return
+	}
+
+	if err := uc.passwordService.ResetPassword(ctx, req); err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+			base.GetFieldErrorMessage(err, messages.MsgUserPasswordResetFailed), err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		messages.MsgUserPasswordResetSuccess,
+		http.StatusOK, nil,
+	))
+}
+
+// ChangeMyPassword sets a new password for the signed in user and signs out
+// all of their sessions, including the current one.
+func (uc *userController) ChangeMyPassword(ctx *gin.Context) {
+	var req dto.UserPasswordChangeRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		msg := base.GetValidationErrorMessage(err, req, messages.MsgUserPasswordChangeFailed)
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest, msg, err))
+		return
+	}
+	req.ID = ctx.MustGet("ID").(string)
+
+	if err := uc.passwordService.ChangePassword(ctx, req); err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+			base.GetFieldErrorMessage(err, messages.MsgUserPasswordChangeFailed), err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		messages.MsgUserPasswordChangeSuccess,
+		http.StatusOK, nil,
+	))
+}
+
+func (uc *userController) VerifyEmail(ctx *gin.Context) {
+	var req dto.UserVerifyEmailRequest
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		msg := base.GetValidationErrorMessage(err, req, messages.MsgUserEmailVerifyFailed)
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest, msg, err))
+		return
+	}
+
+	if err := uc.verificationService.VerifyEmail(ctx, req); err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+			messages.MsgUserEmailVerifyFailed, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		messages.MsgUserEmailVerifySuccess,
+		http.StatusOK, nil,
+	))
+}
+
+func (uc *userController) ResendVerificationEmail(ctx *gin.Context) {
+	var req dto.UserVerifyEmailResendRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		msg := base.GetValidationErrorMessage(err, req, messages.MsgUserEmailResendFailed)
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest, msg, err))
+		return
+	}
+
+	if err := uc.verificationService.ResendVerificationEmail(ctx, req); err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusInternalServerError,
+			messages.MsgUserEmailResendFailed, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		messages.MsgUserEmailResendSuccess,
+		http.StatusOK, nil,
+	))
+}
+
+func (uc *userController) EnrollTOTP(ctx *gin.Context) {
+	id := ctx.MustGet("ID").(string)
+	resp, err := uc.twoFactorService.EnrollTOTP(ctx, id)
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+			messages.MsgUserTOTPEnrollFailed, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		messages.MsgUserTOTPEnrollSuccess,
+		http.StatusOK, resp,
+	))
+}
+
+func (uc *userController) ConfirmTOTP(ctx *gin.Context) {
+	id := ctx.MustGet("ID").(string)
+	HandleUpdate(ctx, id, dto.UserTwoFactorCodeRequest{}, uc.twoFactorService.ConfirmTOTP,
+		messages.MsgUserTOTPConfirmSuccess, messages.MsgUserTOTPConfirmFailed)
+}
+
+func (uc *userController) DisableTOTP(ctx *gin.Context) {
+	var req dto.UserTwoFactorCodeRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		msg := base.GetValidationErrorMessage(err, req, messages.MsgUserTOTPDisableFailed)
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest, msg, err))
+		return
+	}
+	req.ID = ctx.MustGet("ID").(string)
+
+	if err := uc.twoFactorService.DisableTOTP(ctx, req); err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+			messages.MsgUserTOTPDisableFailed, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		messages.MsgUserTOTPDisableSuccess,
+		http.StatusOK, nil,
+	))
+}
+
+func (uc *userController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	id := ctx.MustGet("ID").(string)
+	HandleUpdate(ctx, id, dto.UserTwoFactorCodeRequest{}, uc.twoFactorService.RegenerateRecoveryCodes,
+		messages.MsgUserRecoveryCodesSuccess, messages.MsgUserRecoveryCodesFailed)
+}
+
 func (uc *userController) GetAllUsers(ctx *gin.Context) {
 	HandleGetAll(ctx, dto.UserGetsRequest{}, uc.userService.GetAllUsers,
 		messages.MsgUsersFetchSuccess, messages.MsgUsersFetchFailed)
@@ -102,25 +559,113 @@ func (uc *userController) UpdateSelfName(ctx *gin.Context) {
 }
 
 func (uc *userController) UpdateUserByID(ctx *gin.Context) {
-	id := ctx.Param("user_id")
-	HandleUpdate(ctx, id, dto.UserUpdateRequest{}, uc.userService.UpdateUserByID,
-		messages.MsgUserUpdateSuccess, messages.MsgUserUpdateFailed)
+	var req dto.UserUpdateRequest
+	if err := ctx.ShouldBind(&req); err != nil {
+		msg := base.GetValidationErrorMessage(err, req, messages.MsgUserUpdateFailed)
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest, msg, err))
+		return
+	}
+	req.ID = ctx.Param("user_id")
+	req.ActorID = ctx.GetString("ID")
+	req.ActorRole = ctx.GetString("ROLE")
+
+	user, err := uc.userService.UpdateUserByID(ctx, req)
+	if errors.Is(err, errs.ErrUserRoleChangeSelf) || errors.Is(err, errs.ErrUserRoleNotAllowed) {
+		_ = ctx.Error(base.NewAppError(http.StatusForbidden,
+			messages.MsgUserUpdateFailed, err))
+		return
+	}
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+			base.GetFieldErrorMessage(err, messages.MsgUserUpdateFailed), err))
+		return
+	}
+
+	// Whoever knew the old password must not stay signed in
+	if req.Password != "" {
+		if err := uc.authService.RevokeUserSessions(ctx, req.ID); err != nil {
+			_ = ctx.Error(base.NewAppError(http.StatusInternalServerError,
+				messages.MsgUserUpdateFailed, err))
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		messages.MsgUserUpdateSuccess,
+		http.StatusOK, user,
+	))
 }
 
+// DeleteSelfUser schedules the account for deletion after the grace period
+// and signs out all of its sessions. Logging in again and cancelling keeps
+// the account.
 func (uc *userController) DeleteSelfUser(ctx *gin.Context) {
 	id := ctx.MustGet("ID").(string)
-	HandleDelete(ctx, id, uc.userService.DeleteUserByID,
-		messages.MsgUserDeleteSuccess, messages.MsgUserDeleteFailed)
+
+	user, err := uc.userService.ScheduleUserDeletion(ctx, id)
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+			messages.MsgUserDeletionScheduleFailed, err))
+		return
+	}
+
+	if err := uc.authService.RevokeUserSessions(ctx, id); err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusInternalServerError,
+			messages.MsgUserDeletionScheduleFailed, err))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, base.CreateSuccessResponse(
+		messages.MsgUserDeletionScheduleSuccess,
+		http.StatusOK, user,
+	))
+}
+
+func (uc *userController) CancelMyDeletion(ctx *gin.Context) {
+	id := ctx.MustGet("ID").(string)
+	HandleDelete(ctx, id, uc.userService.CancelUserDeletion,
+		messages.MsgUserDeletionCancelSuccess, messages.MsgUserDeletionCancelFailed)
+}
+
+// ExportMyData sends everything stored about the signed in user as a JSON
+// file download.
+func (uc *userController) ExportMyData(ctx *gin.Context) {
+	id := ctx.MustGet("ID").(string)
+
+	export, err := uc.userService.ExportUserData(ctx, id)
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusBadRequest,
+			messages.MsgUserExportFailed, err))
+		return
+	}
+
+	export.Sessions, err = uc.authService.GetUserSessions(ctx, id, ctx.GetString("SESSION_ID"))
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusInternalServerError,
+			messages.MsgUserExportFailed, err))
+		return
+	}
+
+	export.TwoFactorEnabled, err = uc.twoFactorService.IsEnabled(ctx, id)
+	if err != nil {
+		_ = ctx.Error(base.NewAppError(http.StatusInternalServerError,
+			messages.MsgUserExportFailed, err))
+		return
+	}
+
+	ctx.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="user-export-%s.json"`, id))
+	ctx.JSON(http.StatusOK, export)
 }
 
 func (uc *userController) DeleteUserByID(ctx *gin.Context) {
 	id := ctx.Param("user_id")
-	HandleDelete(ctx, id, uc.userService.DeleteUserByID,
+	HandleDelete(ctx, id, uc.deleteUserAndSessions,
 		messages.MsgUserDeleteSuccess, messages.MsgUserDeleteFailed)
 }
 
 func (uc *userController) ChangePicture(ctx *gin.Context) {
 	id := ctx.MustGet("ID").(string)
+	LimitRequestBody(ctx, uc.userService.PictureRequestLimit())
 	HandleUpdate(ctx, id, dto.UserChangePictureRequest{}, uc.userService.ChangePicture,
 		messages.MsgUserPictureUpdateSuccess, messages.MsgUserPictureUpdateFailed)
 }
@@ -142,7 +687,15 @@ func (uc *userController) RunUserMaintenance(ctx *gin.Context) {
 		return
 	}
 
+	req.ActorID = ctx.GetString("ID")
+	req.ActorRole = ctx.GetString("ROLE")
+
 	resp, err := uc.userService.RunUserMaintenance(ctx, req)
+	if errors.Is(err, errs.ErrUserRoleNotAllowed) {
+		_ = ctx.Error(base.NewAppError(http.StatusForbidden,
+			messages.MsgUserUpdateFailed, err))
+		return
+	}
 	if err != nil {
 		_ = ctx.Error(base.NewAppError(http.StatusInternalServerError,
 			messages.MsgUserUpdateFailed, err))
@@ -0,0 +1,32 @@
+package router
+
+import (
+	"myapp/api/v1/controller"
+	"myapp/core/service"
+	"myapp/support/constant"
+	"myapp/support/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+)
+
+func APIKeyRouter(router *gin.Engine, injector *do.Injector) {
+	var (
+		apiKeyC = do.MustInvoke[controller.APIKeyController](injector)
+		jwtS    = do.MustInvoke[service.JWTService](injector)
+		authS   = do.MustInvoke[service.AuthService](injector)
+
+		apiKeyS    = do.MustInvoke[service.APIKeyService](injector)
+		roleS      = do.MustInvoke[service.RoleService](injector)
+		twoFactorS = do.MustInvoke[service.TwoFactorService](injector)
+	)
+
+	// Admin routes. API keys cannot be issued api_keys:manage, so keys
+	// never manage keys themselves.
+	apiKeyRoutes := router.Group("/api/v1/api-keys")
+	{
+		apiKeyRoutes.POST("", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionAPIKeysManage), middleware.RequireTwoFactor(twoFactorS), apiKeyC.CreateAPIKey)
+		apiKeyRoutes.GET("", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionAPIKeysManage), middleware.RequireTwoFactor(twoFactorS), apiKeyC.GetAllAPIKeys)
+		apiKeyRoutes.DELETE("/:api_key_id", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionAPIKeysManage), middleware.RequireTwoFactor(twoFactorS), apiKeyC.RevokeAPIKey)
+	}
+}
@@ -0,0 +1,29 @@
+package router
+
+import (
+	"myapp/api/v1/controller"
+	"myapp/core/service"
+	"myapp/support/constant"
+	"myapp/support/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+)
+
+func AuditLogRouter(router *gin.Engine, injector *do.Injector) {
+	var (
+		auditLogC = do.MustInvoke[controller.AuditLogController](injector)
+		jwtS      = do.MustInvoke[service.JWTService](injector)
+		authS     = do.MustInvoke[service.AuthService](injector)
+
+		apiKeyS    = do.MustInvoke[service.APIKeyService](injector)
+		roleS      = do.MustInvoke[service.RoleService](injector)
+		twoFactorS = do.MustInvoke[service.TwoFactorService](injector)
+	)
+
+	auditLogRoutes := router.Group("/api/v1/audit-logs")
+	{
+		// Admin routes
+		auditLogRoutes.GET("", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionAuditLogsRead), middleware.RequireTwoFactor(twoFactorS), auditLogC.GetAllAuditLogs)
+	}
+}
@@ -0,0 +1,24 @@
+package router
+
+import (
+	"myapp/api/v1/controller"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+)
+
+func AuthRouter(route *gin.Engine, injector *do.Injector) {
+	var (
+		authC = do.MustInvoke[controller.AuthController](injector)
+	)
+
+	// Well-known endpoints live at the root, outside the API version prefix
+	route.GET("/.well-known/jwks.json", authC.GetJWKS)
+
+	authRoutes := route.Group("/api/v1/auth")
+	{
+		// social login
+		authRoutes.GET("/:provider/login", authC.OIDCLogin)
+		authRoutes.GET("/:provider/callback", authC.OIDCCallback)
+	}
+}
@@ -15,5 +15,6 @@ func FileRouter(route *gin.Engine, injector *do.Injector) {
 	routes := route.Group("/api/v1/files")
 	{
 		routes.GET("/:dir/:file_id", fileController.GetFile)
+		routes.HEAD("/:dir/:file_id", fileController.GetFile)
 	}
 }
@@ -6,7 +6,13 @@ import (
 )
 
 func InitRoutes(server *gin.Engine, injector *do.Injector) {
+	AuthRouter(server, injector)
 	UserRouter(server, injector)
 	FileRouter(server, injector)
 	ProductRouter(server, injector)
+	APIKeyRouter(server, injector)
+	RoleRouter(server, injector)
+	InvitationRouter(server, injector)
+	AuditLogRouter(server, injector)
+	TrashRouter(server, injector)
 }
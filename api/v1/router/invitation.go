@@ -0,0 +1,35 @@
+package router
+
+import (
+	"myapp/api/v1/controller"
+	"myapp/core/service"
+	"myapp/support/constant"
+	"myapp/support/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+)
+
+func InvitationRouter(router *gin.Engine, injector *do.Injector) {
+	var (
+		invitationC = do.MustInvoke[controller.InvitationController](injector)
+		jwtS        = do.MustInvoke[service.JWTService](injector)
+		authS       = do.MustInvoke[service.AuthService](injector)
+
+		apiKeyS    = do.MustInvoke[service.APIKeyService](injector)
+		roleS      = do.MustInvoke[service.RoleService](injector)
+		twoFactorS = do.MustInvoke[service.TwoFactorService](injector)
+	)
+
+	invitationRoutes := router.Group("/api/v1/invitations")
+	{
+		// Public route, the invitee has no account yet
+		invitationRoutes.POST("/accept", invitationC.AcceptInvitation)
+
+		// Admin routes
+		invitationRoutes.POST("", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.ForbidImpersonation(), middleware.RequirePermission(roleS, constant.EnumPermissionUsersInvite), middleware.RequireTwoFactor(twoFactorS), invitationC.CreateInvitation)
+		invitationRoutes.GET("", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionUsersInvite), middleware.RequireTwoFactor(twoFactorS), invitationC.GetAllInvitations)
+		invitationRoutes.POST("/:invitation_id/resend", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.ForbidImpersonation(), middleware.RequirePermission(roleS, constant.EnumPermissionUsersInvite), middleware.RequireTwoFactor(twoFactorS), invitationC.ResendInvitation)
+		invitationRoutes.DELETE("/:invitation_id", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.ForbidImpersonation(), middleware.RequirePermission(roleS, constant.EnumPermissionUsersInvite), middleware.RequireTwoFactor(twoFactorS), invitationC.RevokeInvitation)
+	}
+}
@@ -3,6 +3,7 @@ package router
 import (
 	"myapp/api/v1/controller"
 	"myapp/core/service"
+	"myapp/support/constant"
 	"myapp/support/middleware"
 
 	"github.com/gin-gonic/gin"
@@ -13,6 +14,11 @@ func ProductRouter(router *gin.Engine, injector *do.Injector) {
 	var (
 		productC = do.MustInvoke[controller.ProductController](injector)
 		jwtS     = do.MustInvoke[service.JWTService](injector)
+		authS    = do.MustInvoke[service.AuthService](injector)
+
+		twoFactorS = do.MustInvoke[service.TwoFactorService](injector)
+		apiKeyS    = do.MustInvoke[service.APIKeyService](injector)
+		roleS      = do.MustInvoke[service.RoleService](injector)
 	)
 
 	// ============== Product Routes ==============
@@ -25,20 +31,24 @@ func ProductRouter(router *gin.Engine, injector *do.Injector) {
 		productRoutes.GET("/price-range", productC.GetProductsByPriceRange)
 		productRoutes.GET("/stats/by-category", productC.GetProductStatsByCategory)
 
-		// Admin routes (require authentication and authorization)
-		productRoutes.POST("", middleware.Authenticate(jwtS), middleware.Authorize(), productC.CreateProduct)
-		productRoutes.PATCH("/:product_id", middleware.Authenticate(jwtS), middleware.Authorize(), productC.UpdateProduct)
-		productRoutes.DELETE("/:product_id", middleware.Authenticate(jwtS), middleware.Authorize(), productC.DeleteProduct)
+		// Admin routes (require authentication and a permission, which API
+		// keys hold as scopes)
+		productRoutes.POST("", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionProductsWrite), middleware.RequireTwoFactor(twoFactorS), productC.CreateProduct)
+		productRoutes.PATCH("/:product_id", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionProductsWrite), middleware.RequireTwoFactor(twoFactorS), productC.UpdateProduct)
+		productRoutes.DELETE("/:product_id", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionProductsWrite), middleware.RequireTwoFactor(twoFactorS), productC.DeleteProduct)
+
+		// Product history route
+		productRoutes.GET("/:product_id/history", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionProductsHistory), middleware.RequireTwoFactor(twoFactorS), productC.GetProductHistory)
 
 		// Product image routes
-		productRoutes.PATCH("/:product_id/image", middleware.Authenticate(jwtS), middleware.Authorize(), productC.ChangeProductImage)
-		productRoutes.DELETE("/:product_id/image", middleware.Authenticate(jwtS), middleware.Authorize(), productC.DeleteProductImage)
+		productRoutes.PATCH("/:product_id/image", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionProductsWrite), middleware.RequireTwoFactor(twoFactorS), productC.ChangeProductImage)
+		productRoutes.DELETE("/:product_id/image", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionProductsWrite), middleware.RequireTwoFactor(twoFactorS), productC.DeleteProductImage)
 
 		// Stock management routes
-		productRoutes.PATCH("/:product_id/stock", middleware.Authenticate(jwtS), middleware.Authorize(), productC.UpdateStock)
+		productRoutes.PATCH("/:product_id/stock", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionProductsStock), middleware.RequireTwoFactor(twoFactorS), productC.UpdateStock)
 
 		// Complex maintenance operation
-		productRoutes.POST("/maintenance", middleware.Authenticate(jwtS), middleware.Authorize(), productC.RunProductMaintenance)
+		productRoutes.POST("/maintenance", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionProductsWrite), middleware.RequireTwoFactor(twoFactorS), productC.RunProductMaintenance)
 	}
 
 	// ============== Category Routes ==============
@@ -49,8 +59,8 @@ func ProductRouter(router *gin.Engine, injector *do.Injector) {
 		categoryRoutes.GET("/:category_id", productC.GetCategoryByID)
 
 		// Admin routes
-		categoryRoutes.POST("", middleware.Authenticate(jwtS), middleware.Authorize(), productC.CreateCategory)
-		categoryRoutes.PATCH("/:category_id", middleware.Authenticate(jwtS), middleware.Authorize(), productC.UpdateCategory)
-		categoryRoutes.DELETE("/:category_id", middleware.Authenticate(jwtS), middleware.Authorize(), productC.DeleteCategory)
+		categoryRoutes.POST("", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionCategoriesWrite), middleware.RequireTwoFactor(twoFactorS), productC.CreateCategory)
+		categoryRoutes.PATCH("/:category_id", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionCategoriesWrite), middleware.RequireTwoFactor(twoFactorS), productC.UpdateCategory)
+		categoryRoutes.DELETE("/:category_id", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionCategoriesWrite), middleware.RequireTwoFactor(twoFactorS), productC.DeleteCategory)
 	}
 }
@@ -0,0 +1,37 @@
+package router
+
+import (
+	"myapp/api/v1/controller"
+	"myapp/core/service"
+	"myapp/support/constant"
+	"myapp/support/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+)
+
+func RoleRouter(router *gin.Engine, injector *do.Injector) {
+	var (
+		roleC = do.MustInvoke[controller.RoleController](injector)
+		jwtS  = do.MustInvoke[service.JWTService](injector)
+		authS = do.MustInvoke[service.AuthService](injector)
+
+		apiKeyS    = do.MustInvoke[service.APIKeyService](injector)
+		roleS      = do.MustInvoke[service.RoleService](injector)
+		twoFactorS = do.MustInvoke[service.TwoFactorService](injector)
+	)
+
+	// Admin routes
+	roleRoutes := router.Group("/api/v1/roles")
+	{
+		roleRoutes.GET("", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionRolesManage), middleware.RequireTwoFactor(twoFactorS), roleC.GetAllRoles)
+		roleRoutes.POST("", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionRolesManage), middleware.RequireTwoFactor(twoFactorS), roleC.CreateRole)
+		roleRoutes.PUT("/:role_id/permissions", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionRolesManage), middleware.RequireTwoFactor(twoFactorS), roleC.UpdateRolePermissions)
+		roleRoutes.DELETE("/:role_id", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionRolesManage), middleware.RequireTwoFactor(twoFactorS), roleC.DeleteRole)
+	}
+
+	permissionRoutes := router.Group("/api/v1/permissions")
+	{
+		permissionRoutes.GET("", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionRolesManage), middleware.RequireTwoFactor(twoFactorS), roleC.GetAllPermissions)
+	}
+}
@@ -0,0 +1,39 @@
+package router
+
+import (
+	"myapp/api/v1/controller"
+	"myapp/core/service"
+	"myapp/support/constant"
+	"myapp/support/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/samber/do"
+)
+
+func TrashRouter(router *gin.Engine, injector *do.Injector) {
+	var (
+		trashC = do.MustInvoke[controller.TrashController](injector)
+		jwtS   = do.MustInvoke[service.JWTService](injector)
+		authS  = do.MustInvoke[service.AuthService](injector)
+
+		apiKeyS    = do.MustInvoke[service.APIKeyService](injector)
+		roleS      = do.MustInvoke[service.RoleService](injector)
+		twoFactorS = do.MustInvoke[service.TwoFactorService](injector)
+	)
+
+	// Admin routes
+	trashRoutes := router.Group("/api/v1/trash")
+	{
+		trashRoutes.GET("/users", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionTrashManage), middleware.RequireTwoFactor(twoFactorS), trashC.GetTrashedUsers)
+		trashRoutes.POST("/users/:id/restore", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionTrashManage), middleware.RequireTwoFactor(twoFactorS), trashC.RestoreUser)
+		trashRoutes.DELETE("/users/:id", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionTrashManage), middleware.RequireTwoFactor(twoFactorS), trashC.PurgeUser)
+
+		trashRoutes.GET("/products", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionTrashManage), middleware.RequireTwoFactor(twoFactorS), trashC.GetTrashedProducts)
+		trashRoutes.POST("/products/:id/restore", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionTrashManage), middleware.RequireTwoFactor(twoFactorS), trashC.RestoreProduct)
+		trashRoutes.DELETE("/products/:id", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionTrashManage), middleware.RequireTwoFactor(twoFactorS), trashC.PurgeProduct)
+
+		trashRoutes.GET("/categories", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionTrashManage), middleware.RequireTwoFactor(twoFactorS), trashC.GetTrashedCategories)
+		trashRoutes.POST("/categories/:id/restore", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionTrashManage), middleware.RequireTwoFactor(twoFactorS), trashC.RestoreCategory)
+		trashRoutes.DELETE("/categories/:id", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionTrashManage), middleware.RequireTwoFactor(twoFactorS), trashC.PurgeCategory)
+	}
+}
@@ -3,6 +3,7 @@ package router
 import (
 	"myapp/api/v1/controller"
 	"myapp/core/service"
+	"myapp/support/constant"
 	"myapp/support/middleware"
 
 	"github.com/samber/do"
@@ -14,25 +15,52 @@ func UserRouter(router *gin.Engine, injector *do.Injector) {
 	var (
 		userC = do.MustInvoke[controller.UserController](injector)
 		jwtS  = do.MustInvoke[service.JWTService](injector)
+		authS = do.MustInvoke[service.AuthService](injector)
+
+		verificationS = do.MustInvoke[service.EmailVerificationService](injector)
+		twoFactorS    = do.MustInvoke[service.TwoFactorService](injector)
+		apiKeyS       = do.MustInvoke[service.APIKeyService](injector)
+		roleS         = do.MustInvoke[service.RoleService](injector)
 	)
 
 	userRoutes := router.Group("/api/v1/users")
 	{
 		// admin routes
-		userRoutes.GET("", middleware.Authenticate(jwtS), middleware.Authorize(), userC.GetAllUsers)
-		userRoutes.PATCH("/:user_id", middleware.Authenticate(jwtS), middleware.Authorize(), userC.UpdateUserByID)
-		userRoutes.DELETE("/:user_id", middleware.Authenticate(jwtS), middleware.Authorize(), userC.DeleteUserByID)
-		userRoutes.POST("/maintenance", middleware.Authenticate(jwtS), middleware.Authorize(), userC.RunUserMaintenance)
+		userRoutes.GET("", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionUsersRead), middleware.RequireTwoFactor(twoFactorS), userC.GetAllUsers)
+		userRoutes.PATCH("/:user_id", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionUsersWrite), middleware.RequireTwoFactor(twoFactorS), userC.UpdateUserByID)
+		userRoutes.DELETE("/:user_id", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionUsersWrite), middleware.RequireTwoFactor(twoFactorS), userC.DeleteUserByID)
+		userRoutes.DELETE("/:user_id/sessions", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionUsersWrite), middleware.RequireTwoFactor(twoFactorS), userC.RevokeUserSessions)
+		userRoutes.DELETE("/:user_id/lockout", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionUsersWrite), middleware.RequireTwoFactor(twoFactorS), userC.UnlockUser)
+		userRoutes.POST("/:user_id/impersonate", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.ForbidImpersonation(), middleware.RequirePermission(roleS, constant.EnumPermissionUsersImpersonate), middleware.RequireTwoFactor(twoFactorS), userC.ImpersonateUser)
+		userRoutes.POST("/maintenance", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.RequirePermission(roleS, constant.EnumPermissionUsersWrite), middleware.RequireTwoFactor(twoFactorS), userC.RunUserMaintenance)
 
 		// user routes
-		userRoutes.GET("/me", middleware.Authenticate(jwtS), userC.GetMe)
-		userRoutes.PATCH("/me/name", middleware.Authenticate(jwtS), userC.UpdateSelfName)
-		userRoutes.DELETE("/me", middleware.Authenticate(jwtS), userC.DeleteSelfUser)
+		userRoutes.GET("/me", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.ForbidAPIKey(), userC.GetMe)
+		userRoutes.PATCH("/me/name", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.ForbidAPIKey(), middleware.RequireVerifiedEmail(verificationS), userC.UpdateSelfName)
+		userRoutes.PATCH("/me/password", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.ForbidAPIKey(), middleware.ForbidImpersonation(), userC.ChangeMyPassword)
+		userRoutes.DELETE("/me", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.ForbidAPIKey(), middleware.ForbidImpersonation(), userC.DeleteSelfUser)
+		userRoutes.DELETE("/me/deletion", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.ForbidAPIKey(), middleware.ForbidImpersonation(), userC.CancelMyDeletion)
+		userRoutes.GET("/me/export", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.ForbidAPIKey(), middleware.ForbidImpersonation(), userC.ExportMyData)
+		userRoutes.GET("/me/sessions", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.ForbidAPIKey(), middleware.ForbidImpersonation(), userC.GetMySessions)
+		userRoutes.DELETE("/me/sessions/:session_id", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.ForbidAPIKey(), middleware.ForbidImpersonation(), userC.EndMySession)
 		userRoutes.POST("", userC.Register)
 		userRoutes.POST("/login", userC.Login)
+		userRoutes.POST("/login/2fa", userC.LoginTwoFactor)
+		userRoutes.POST("/token/refresh", userC.RefreshToken)
+		userRoutes.POST("/password/forgot", userC.ForgotPassword)
+		userRoutes.POST("/password/reset", userC.ResetPassword)
+		userRoutes.GET("/verify-email", userC.VerifyEmail)
+		userRoutes.POST("/verify-email/resend", userC.ResendVerificationEmail)
+		userRoutes.POST("/logout", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.ForbidAPIKey(), userC.Logout)
+
+		// two-factor routes
+		userRoutes.POST("/me/2fa/totp", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.ForbidAPIKey(), middleware.ForbidImpersonation(), userC.EnrollTOTP)
+		userRoutes.POST("/me/2fa/totp/confirm", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.ForbidAPIKey(), middleware.ForbidImpersonation(), userC.ConfirmTOTP)
+		userRoutes.DELETE("/me/2fa/totp", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.ForbidAPIKey(), middleware.ForbidImpersonation(), userC.DisableTOTP)
+		userRoutes.POST("/me/2fa/recovery-codes", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.ForbidAPIKey(), middleware.ForbidImpersonation(), userC.RegenerateRecoveryCodes)
 
 		// user file routes
-		userRoutes.PATCH("/picture", middleware.Authenticate(jwtS), userC.ChangePicture)
-		userRoutes.DELETE("/picture/:user_id", middleware.Authenticate(jwtS), userC.DeletePicture)
+		userRoutes.PATCH("/picture", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.ForbidAPIKey(), middleware.RequireVerifiedEmail(verificationS), userC.ChangePicture)
+		userRoutes.DELETE("/picture/:user_id", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.ForbidAPIKey(), middleware.RequireVerifiedEmail(verificationS), userC.DeletePicture)
 	}
 }
@@ -11,7 +11,24 @@ import (
 )
 
 func main() {
-	stmts, err := gormschema.New("postgres").Load(entity.User{}, entity.Category{}, entity.Product{})
+	stmts, err := gormschema.New("postgres").Load(
+		entity.User{}, entity.Category{}, entity.Product{},
+		entity.RefreshToken{}, entity.TokenRevocation{}, entity.PasswordResetToken{},
+		entity.EmailVerificationToken{},
+		entity.OIDCState{},
+		entity.UserIdentity{},
+		entity.TOTPCredential{},
+		entity.RecoveryCode{},
+		entity.TwoFactorChallenge{},
+		entity.LoginThrottle{},
+		entity.APIKey{},
+		entity.Role{},
+		entity.Permission{},
+		entity.Session{},
+		entity.Invitation{},
+		entity.AuditLog{},
+		entity.ProductRevision{},
+	)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "failed to load schema: %v\n", err)
 		os.Exit(1)
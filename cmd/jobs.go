@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	"myapp/core/service"
+	"myapp/support/constant"
+	"myapp/support/logger"
+	"myapp/support/util"
+
+	"github.com/samber/do"
+)
+
+// StartJobs runs the background jobs of the server in their own goroutines.
+// Each job runs once at start up and then on its interval.
+func StartJobs(injector *do.Injector) {
+	userS := do.MustInvoke[service.UserService](injector)
+	runPeriodically("purge scheduled users",
+		util.GetEnvDuration("ACCOUNT_PURGE_INTERVAL", constant.DefaultAccountPurgeInterval),
+		func(ctx context.Context) (int, error) {
+			return userS.PurgeScheduledUsers(ctx)
+		})
+
+	trashS := do.MustInvoke[service.TrashService](injector)
+	runPeriodically("purge expired trash",
+		util.GetEnvDuration("TRASH_PURGE_INTERVAL", constant.DefaultTrashPurgeInterval),
+		func(ctx context.Context) (int, error) {
+			return trashS.PurgeExpiredTrash(ctx)
+		})
+}
+
+// runPeriodically runs job every interval, logging how many records it
+// handled. A failed run is logged and retried on the next tick.
+func runPeriodically(name string, interval time.Duration, job func(ctx context.Context) (int, error)) {
+	if interval <= 0 {
+		logger.Warn("Job %q disabled, interval must be positive", name)
+		return
+	}
+
+	run := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		defer cancel()
+
+		count, err := job(ctx)
+		if err != nil {
+			logger.Error("Job %q failed after %d records: %v", name, count, err)
+			return
+		}
+		if count > 0 {
+			logger.Info("Job %q handled %d records", name, count)
+		}
+	}
+
+	go func() {
+		run()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			run()
+		}
+	}()
+}
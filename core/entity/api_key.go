@@ -0,0 +1,25 @@
+package entity
+
+import (
+	"time"
+
+	"myapp/support/base"
+
+	"github.com/google/uuid"
+)
+
+// APIKey lets a machine call the API without a user login. Only the SHA-256
+// hash of the key is stored, Prefix keeps its first characters so admins
+// can tell keys apart. Scopes is a space separated list.
+type APIKey struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Name        string     `gorm:"not null" json:"name"`
+	Prefix      string     `gorm:"not null" json:"prefix"`
+	KeyHash     string     `gorm:"not null;uniqueIndex" json:"-"`
+	Scopes      string     `gorm:"not null" json:"scopes"`
+	CreatedByID uuid.UUID  `gorm:"type:uuid;not null;index" json:"created_by_id"`
+	ExpiresAt   time.Time  `gorm:"not null" json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	base.Model
+}
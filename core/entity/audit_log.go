@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+
+	"myapp/support/base"
+
+	"github.com/google/uuid"
+)
+
+// AuditLog records one create, update or delete made through the user,
+// product or category services, or one restore or purge from the trash. It is written in the transaction of the
+// change itself, so a rolled back change leaves no entry behind.
+// ActorID is nil for changes made by background jobs.
+type AuditLog struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ActorID   *uuid.UUID `gorm:"type:uuid;index" json:"actor_id"`
+	ActorType string     `gorm:"not null" json:"actor_type"`
+	// ImpersonatorID is the support user behind an impersonated actor
+	ImpersonatorID *uuid.UUID   `gorm:"type:uuid" json:"impersonator_id"`
+	Action         string       `gorm:"not null;index" json:"action"`
+	EntityType     string       `gorm:"not null;index:idx_audit_logs_entity" json:"entity_type"`
+	EntityID       string       `gorm:"not null;index:idx_audit_logs_entity" json:"entity_id"`
+	Changes        AuditChanges `gorm:"type:jsonb;not null" json:"changes"`
+	RequestID      string       `gorm:"index" json:"request_id"`
+	IPAddress      string       `json:"ip_address"`
+	base.Model
+}
+
+// AuditChange holds the JSON encoded value of a field before and after a
+// change. Before is null for creates, After for deletes.
+type AuditChange struct {
+	Before json.RawMessage `json:"before"`
+	After  json.RawMessage `json:"after"`
+}
+
+// AuditChanges maps the JSON name of each changed field to its change and
+// is stored as a jsonb column.
+type AuditChanges map[string]AuditChange
+
+func (c AuditChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (c *AuditChanges) Scan(value any) error {
+	var b []byte
+	switch v := value.(type) {
+	case []byte:
+		b = v
+	case string:
+		b = []byte(v)
+	case nil:
+		*c = nil
+		return nil
+	default:
+		return errors.New("unsupported type for audit changes")
+	}
+	return json.Unmarshal(b, c)
+}
@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"time"
+
+	"myapp/support/base"
+
+	"github.com/google/uuid"
+)
+
+// EmailVerificationToken is a single-use token mailed to a newly registered
+// user to confirm they own the address. Only the SHA-256 hash is stored.
+type EmailVerificationToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	TokenHash string     `gorm:"not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	base.Model
+}
@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"time"
+
+	"myapp/support/base"
+
+	"github.com/google/uuid"
+)
+
+// Invitation lets an admin create an account with a role chosen up front.
+// The invitee gets a single-use link and picks their password when
+// accepting it. Only the SHA-256 hash of the token is stored.
+type Invitation struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Email       string     `gorm:"not null;index" json:"email"`
+	Role        string     `gorm:"not null" json:"role"`
+	TokenHash   string     `gorm:"not null;uniqueIndex" json:"-"`
+	InvitedByID uuid.UUID  `gorm:"type:uuid;not null;index" json:"invited_by_id"`
+	ExpiresAt   time.Time  `gorm:"not null" json:"expires_at"`
+	AcceptedAt  *time.Time `json:"accepted_at"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	base.Model
+}
@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"time"
+
+	"myapp/support/base"
+
+	"github.com/google/uuid"
+)
+
+// LoginThrottle counts recent failed logins for one key, either an account
+// ("account:<email>") or a client IP ("ip:<address>").
+type LoginThrottle struct {
+	ID            uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Key           string    `gorm:"not null;uniqueIndex" json:"key"`
+	Failures      int       `gorm:"not null;default:0" json:"failures"`
+	LastFailureAt time.Time `gorm:"not null" json:"last_failure_at"`
+	// NextAttemptAt enforces the progressive delay between attempts
+	NextAttemptAt *time.Time `json:"next_attempt_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+	base.Model
+}
@@ -0,0 +1,28 @@
+package entity
+
+import (
+	"time"
+
+	"myapp/support/base"
+
+	"github.com/google/uuid"
+)
+
+// OIDCState tracks one pending provider login between the redirect to the
+// provider and its callback. Only the SHA-256 hash of the state parameter is
+// stored; the PKCE verifier and nonce never leave the server.
+type OIDCState struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Provider     string    `gorm:"not null" json:"provider"`
+	StateHash    string    `gorm:"not null;uniqueIndex" json:"-"`
+	CodeVerifier string    `gorm:"not null" json:"-"`
+	Nonce        string    `gorm:"not null" json:"-"`
+	ExpiresAt    time.Time `gorm:"not null;index" json:"expires_at"`
+	base.Model
+}
+
+// TableName overrides the default naming, which would split the OIDC
+// initialism into "o_id_c_states".
+func (OIDCState) TableName() string {
+	return "oidc_states"
+}
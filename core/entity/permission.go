@@ -0,0 +1,17 @@
+package entity
+
+import (
+	"myapp/support/base"
+
+	"github.com/google/uuid"
+)
+
+// Permission is one action guarded by RequirePermission, such as
+// "products:write". The set is defined by the application, roles only pick
+// from it.
+type Permission struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Name        string    `gorm:"not null;uniqueIndex" json:"name"`
+	Description string    `json:"description"`
+	base.Model
+}
@@ -11,7 +11,7 @@ type Product struct {
 	ID          uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
 	Name        string          `json:"name" gorm:"not null"`
 	Description string          `json:"description"`
-	SKU         string          `json:"sku" gorm:"unique;not null"`
+	SKU         string          `json:"sku" gorm:"uniqueIndex:uni_products_sku,where:deleted_at IS NULL;not null"`
 	Price       decimal.Decimal `json:"price" gorm:"type:decimal(15,2);not null"`
 	Stock       int             `json:"stock" gorm:"not null;default:0"`
 	CategoryID  *uuid.UUID      `json:"category_id" gorm:"type:uuid"`
@@ -25,7 +25,7 @@ type Product struct {
 
 type Category struct {
 	ID          uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	Name        string    `json:"name" gorm:"unique;not null"`
+	Name        string    `json:"name" gorm:"uniqueIndex:uni_categories_name,where:deleted_at IS NULL;not null"`
 	Description string    `json:"description"`
 	base.Model
 
@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"myapp/support/base"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// ProductRevision is the state of a product right after a change, so the
+// product as of any point in time is its latest revision created up to
+// then. A delete revision keeps the last state and marks the product gone.
+// ChangedByID is nil for changes made by background jobs.
+type ProductRevision struct {
+	ID          uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ProductID   uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_product_revisions_product_revision" json:"product_id"`
+	Revision    int             `gorm:"not null;uniqueIndex:idx_product_revisions_product_revision" json:"revision"`
+	Action      string          `gorm:"not null" json:"action"`
+	Name        string          `gorm:"not null" json:"name"`
+	Description string          `json:"description"`
+	SKU         string          `gorm:"not null" json:"sku"`
+	Price       decimal.Decimal `gorm:"type:decimal(15,2);not null" json:"price"`
+	Stock       int             `gorm:"not null" json:"stock"`
+	CategoryID  *uuid.UUID      `gorm:"type:uuid" json:"category_id"`
+	IsActive    bool            `gorm:"not null" json:"is_active"`
+	Image       *string         `json:"image"`
+	ChangedByID *uuid.UUID      `gorm:"type:uuid" json:"changed_by_id"`
+	base.Model
+}
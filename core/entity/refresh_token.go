@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"time"
+
+	"myapp/support/base"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is a long-lived, single-use credential exchanged for a new
+// access token. Tokens issued from the same login share a FamilyID so a
+// replayed (already rotated) token can revoke the whole chain.
+// RevokedReason tells rotated tokens apart from ones ended on purpose, like
+// by logging out, which are refused without suspecting theft.
+type RefreshToken struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	FamilyID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"family_id"`
+	TokenHash     string     `gorm:"not null;uniqueIndex" json:"-"`
+	ExpiresAt     time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at"`
+	RevokedReason *string    `json:"revoked_reason"`
+	ReplacedBy    *uuid.UUID `gorm:"type:uuid" json:"replaced_by"`
+	base.Model
+}
@@ -0,0 +1,18 @@
+package entity
+
+import (
+	"myapp/support/base"
+
+	"github.com/google/uuid"
+)
+
+// Role is what User.Role refers to by name. System roles come with the
+// application and cannot be deleted.
+type Role struct {
+	ID          uuid.UUID    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Name        string       `gorm:"not null;uniqueIndex" json:"name"`
+	Description string       `json:"description"`
+	System      bool         `gorm:"not null;default:false" json:"system"`
+	Permissions []Permission `gorm:"many2many:role_permissions" json:"permissions"`
+	base.Model
+}
@@ -0,0 +1,24 @@
+package entity
+
+import (
+	"time"
+
+	"myapp/support/base"
+
+	"github.com/google/uuid"
+)
+
+// Session is one signed in device. It starts with a login and its ID is
+// both the FamilyID of the refresh tokens issued for it and the "sid" claim
+// of its access tokens, so ending a session cuts off both. ExpiresAt follows
+// the newest refresh token of the session.
+type Session struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	LastSeenAt time.Time  `gorm:"not null" json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	EndedAt    *time.Time `json:"ended_at"`
+	base.Model
+}
@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"time"
+
+	"myapp/support/base"
+
+	"github.com/google/uuid"
+)
+
+// TokenRevocation invalidates access tokens before their natural expiry.
+// A row either targets a single token by its jti (TokenID), every token of a
+// session (SessionID) or, when RevokedBefore is set, every token of the user
+// issued up to that instant.
+// Rows are only needed until ExpiresAt, after which the tokens they cover
+// have expired on their own.
+type TokenRevocation struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID        uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	TokenID       *string    `gorm:"uniqueIndex" json:"token_id"`
+	SessionID     *uuid.UUID `gorm:"type:uuid;index" json:"session_id"`
+	RevokedBefore *time.Time `json:"revoked_before"`
+	ExpiresAt     time.Time  `gorm:"not null;index" json:"expires_at"`
+	base.Model
+}
+
+// PasswordChangeCutoff is the RevokedBefore covering the tokens of a user
+// whose password changed at changedAt. JWT "iat" has second precision, and
+// a token stamped with the second of the change may come from the log in
+// right after it, so only tokens of earlier seconds are cut off.
+func PasswordChangeCutoff(changedAt time.Time) time.Time {
+	return changedAt.Truncate(time.Second).Add(-time.Second)
+}
@@ -0,0 +1,22 @@
+package entity
+
+import (
+	"time"
+
+	"myapp/support/base"
+
+	"github.com/google/uuid"
+)
+
+// TOTPCredential is a user's authenticator app secret. Two-factor login is
+// on once the enrollment is confirmed with a first valid code.
+type TOTPCredential struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex" json:"user_id"`
+	Secret      string     `gorm:"not null" json:"-"`
+	ConfirmedAt *time.Time `json:"confirmed_at"`
+	// LastUsedStep is the TOTP time step of the last accepted code, so a
+	// code cannot be replayed within its validity window.
+	LastUsedStep int64 `gorm:"not null;default:0" json:"-"`
+	base.Model
+}
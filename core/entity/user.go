@@ -1,6 +1,8 @@
 package entity
 
 import (
+	"time"
+
 	"myapp/support/base"
 	"myapp/support/util"
 
@@ -11,12 +13,19 @@ import (
 
 type User struct {
 	ID       uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
-	Name     string    `json:"name" gorm:"not null"`
-	Email    string    `json:"email" gorm:"unique;not null"`
-	Password string    `json:"password" gorm:"not null"`
+	Name     string    `json:"name" gorm:"not null" audit:"personal"`
+	Email    string    `json:"email" gorm:"uniqueIndex:uni_users_email,where:deleted_at IS NULL;not null" audit:"personal"`
+	Password string    `json:"password" gorm:"not null" audit:"redact"`
 	Role     string    `json:"role" gorm:"not null"`
 	Provider string    `json:"provider" gorm:"not null"`
-	Picture  *string   `json:"picture"`
+	Picture  *string   `json:"picture" audit:"personal"`
+	// EmailVerifiedAt is nil until the user confirms their address
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	// PasswordChangedAt is nil until the password is changed after sign up
+	PasswordChangedAt *time.Time `json:"password_changed_at"`
+	// ScheduledDeletionAt is set when the user asked for their account to be
+	// deleted; it is purged once this passes unless they cancel.
+	ScheduledDeletionAt *time.Time `json:"scheduled_deletion_at" gorm:"index"`
 	base.Model
 }
 
@@ -36,6 +45,8 @@ func (u *User) BeforeUpdate(tx *gorm.DB) error {
 		if err != nil {
 			return err
 		}
+		now := time.Now()
+		u.PasswordChangedAt = &now
 	}
 	return nil
 }
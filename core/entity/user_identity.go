@@ -0,0 +1,18 @@
+package entity
+
+import (
+	"myapp/support/base"
+
+	"github.com/google/uuid"
+)
+
+// UserIdentity links a user to their account at a login provider. It is
+// found by the subject the provider assigns, which unlike the email address
+// never changes or passes on to someone else.
+type UserIdentity struct {
+	ID       uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserID   uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Provider string    `gorm:"not null;uniqueIndex:idx_user_identities_provider_subject" json:"provider"`
+	Subject  string    `gorm:"not null;uniqueIndex:idx_user_identities_provider_subject" json:"subject"`
+	base.Model
+}
@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"myapp/support/base"
+)
+
+type (
+	// AuditLogGetsRequest filters the audit log. From and To bound the
+	// time of the change and are inclusive.
+	AuditLogGetsRequest struct {
+		ActorID    string     `json:"filter[actor_id]" form:"filter[actor_id]" binding:"omitempty,uuid"`
+		Action     string     `json:"filter[action]" form:"filter[action]" binding:"omitempty,oneof=create update delete restore purge"`
+		EntityType string     `json:"filter[entity_type]" form:"filter[entity_type]"`
+		EntityID   string     `json:"filter[entity_id]" form:"filter[entity_id]"`
+		RequestID  string     `json:"filter[request_id]" form:"filter[request_id]"`
+		From       *time.Time `json:"filter[from]" form:"filter[from]" time_format:"2006-01-02T15:04:05Z07:00"`
+		To         *time.Time `json:"filter[to]" form:"filter[to]" time_format:"2006-01-02T15:04:05Z07:00"`
+		base.PaginationRequest
+	}
+
+	AuditChangeResponse struct {
+		Before json.RawMessage `json:"before"`
+		After  json.RawMessage `json:"after"`
+	}
+
+	AuditLogResponse struct {
+		ID             string                         `json:"id"`
+		ActorID        *string                        `json:"actor_id"`
+		ActorType      string                         `json:"actor_type"`
+		ImpersonatorID *string                        `json:"impersonator_id,omitempty"`
+		Action         string                         `json:"action"`
+		EntityType     string                         `json:"entity_type"`
+		EntityID       string                         `json:"entity_id"`
+		Changes        map[string]AuditChangeResponse `json:"changes"`
+		RequestID      string                         `json:"request_id,omitempty"`
+		IPAddress      string                         `json:"ip_address,omitempty"`
+		CreatedAt      time.Time                      `json:"created_at"`
+	}
+)
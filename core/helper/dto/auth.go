@@ -0,0 +1,70 @@
+package dto
+
+import "time"
+
+type (
+	// JWK is a public JSON Web Key (RFC 7517). Only the members used by
+	// RSA and Ed25519 keys are present.
+	JWK struct {
+		Kty string `json:"kty"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+		N   string `json:"n,omitempty"`
+		E   string `json:"e,omitempty"`
+		Crv string `json:"crv,omitempty"`
+		X   string `json:"x,omitempty"`
+	}
+
+	JWKSResponse struct {
+		Keys []JWK `json:"keys"`
+	}
+
+	// OIDCLoginStart is a pending provider login. State also goes into a
+	// cookie until ExpiresAt, so the callback only works in the browser
+	// that was sent to AuthURL.
+	OIDCLoginStart struct {
+		AuthURL   string
+		State     string
+		ExpiresAt time.Time
+	}
+
+	// OIDCCallbackRequest is what the provider appends to the redirect URL
+	// after the user signed in. Provider comes from the route and
+	// BrowserState from the cookie set when the login started.
+	OIDCCallbackRequest struct {
+		Provider     string `form:"-"`
+		BrowserState string `form:"-"`
+		Code         string `form:"code" binding:"required"`
+		State        string `form:"state" binding:"required"`
+	}
+
+	// APIKeyCreateRequest issues a key. Scopes are permission names the
+	// creator's role holds. ExpiresAt defaults to API_KEY_TTL from now and
+	// may not be further out than API_KEY_MAX_TTL.
+	APIKeyCreateRequest struct {
+		CreatedByID   string     `json:"-"`
+		CreatedByRole string     `json:"-"`
+		Name          string     `json:"name" form:"name" binding:"required,max=100"`
+		Scopes        []string   `json:"scopes" form:"scopes" binding:"required,min=1,dive,required"`
+		ExpiresAt     *time.Time `json:"expires_at" form:"expires_at"`
+	}
+
+	APIKeyResponse struct {
+		ID          string     `json:"id"`
+		Name        string     `json:"name"`
+		Prefix      string     `json:"prefix"`
+		Scopes      []string   `json:"scopes"`
+		CreatedByID string     `json:"created_by_id"`
+		ExpiresAt   time.Time  `json:"expires_at"`
+		LastUsedAt  *time.Time `json:"last_used_at"`
+		RevokedAt   *time.Time `json:"revoked_at"`
+		CreatedAt   time.Time  `json:"created_at"`
+	}
+
+	// APIKeyCreateResponse is the only response that carries the raw key.
+	APIKeyCreateResponse struct {
+		APIKeyResponse
+		Key string `json:"key"`
+	}
+)
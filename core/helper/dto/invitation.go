@@ -0,0 +1,41 @@
+package dto
+
+import "time"
+
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusRevoked  = "revoked"
+	InvitationStatusExpired  = "expired"
+)
+
+type (
+	// InvitationCreateRequest invites an email address to sign up with the
+	// given role. The inviter fields come from the authenticated user.
+	InvitationCreateRequest struct {
+		InvitedByID   string `json:"-"`
+		InvitedByRole string `json:"-"`
+		Email         string `json:"email" form:"email" binding:"required,email"`
+		Role          string `json:"role" form:"role" binding:"required"`
+	}
+
+	// InvitationAcceptRequest completes the sign up of an invited user. The
+	// email address and role are taken from the invitation.
+	InvitationAcceptRequest struct {
+		Token    string `json:"token" form:"token" binding:"required"`
+		Name     string `json:"name" form:"name" binding:"required"`
+		Password string `json:"password" form:"password" binding:"required"`
+	}
+
+	InvitationResponse struct {
+		ID          string     `json:"id"`
+		Email       string     `json:"email"`
+		Role        string     `json:"role"`
+		Status      string     `json:"status"`
+		InvitedByID string     `json:"invited_by_id"`
+		ExpiresAt   time.Time  `json:"expires_at"`
+		AcceptedAt  *time.Time `json:"accepted_at"`
+		RevokedAt   *time.Time `json:"revoked_at"`
+		CreatedAt   time.Time  `json:"created_at"`
+	}
+)
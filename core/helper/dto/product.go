@@ -2,6 +2,7 @@ package dto
 
 import (
 	"mime/multipart"
+	"time"
 
 	"myapp/support/base"
 
@@ -51,7 +52,7 @@ type (
 
 	ProductChangeImageRequest struct {
 		ID    string                `json:"id"`
-		Image *multipart.FileHeader `json:"image" form:"image"`
+		Image *multipart.FileHeader `json:"image" form:"image" binding:"required"`
 	}
 
 	ProductStockUpdateRequest struct {
@@ -59,6 +60,8 @@ type (
 		Quantity int    `json:"quantity" form:"quantity" binding:"required"`
 	}
 
+	// ProductResponse.Image maps variant names to download URLs, with the
+	// uploaded image as "original".
 	ProductResponse struct {
 		ID          string            `json:"id"`
 		Name        string            `json:"name,omitempty"`
@@ -68,11 +71,46 @@ type (
 		Stock       int               `json:"stock,omitempty"`
 		CategoryID  string            `json:"category_id,omitempty"`
 		IsActive    bool              `json:"is_active"`
-		Image       string            `json:"image,omitempty"`
+		Image       map[string]string `json:"image,omitempty"`
 		Category    *CategoryResponse `json:"category,omitempty"`
 	}
 )
 
+// ============== Product History DTOs ==============
+
+type (
+	// ProductGetRequest reads a product, or with AsOf its state at that
+	// time.
+	ProductGetRequest struct {
+		AsOf *time.Time `json:"as_of" form:"as_of" time_format:"2006-01-02T15:04:05Z07:00"`
+	}
+
+	// ProductHistoryRequest filters the revisions of a product. From and To
+	// bound the time of the change and are inclusive.
+	ProductHistoryRequest struct {
+		ProductID string     `json:"product_id"`
+		Action    string     `json:"filter[action]" form:"filter[action]" binding:"omitempty,oneof=create update delete restore"`
+		From      *time.Time `json:"filter[from]" form:"filter[from]" time_format:"2006-01-02T15:04:05Z07:00"`
+		To        *time.Time `json:"filter[to]" form:"filter[to]" time_format:"2006-01-02T15:04:05Z07:00"`
+		base.PaginationRequest
+	}
+
+	ProductRevisionResponse struct {
+		Revision    int               `json:"revision"`
+		Action      string            `json:"action"`
+		Name        string            `json:"name"`
+		Description string            `json:"description"`
+		SKU         string            `json:"sku"`
+		Price       decimal.Decimal   `json:"price"`
+		Stock       int               `json:"stock"`
+		CategoryID  string            `json:"category_id,omitempty"`
+		IsActive    bool              `json:"is_active"`
+		Image       map[string]string `json:"image,omitempty"`
+		ChangedByID string            `json:"changed_by_id,omitempty"`
+		ChangedAt   time.Time         `json:"changed_at"`
+	}
+)
+
 // ============== Category DTOs ==============
 
 type (
@@ -0,0 +1,29 @@
+package dto
+
+type (
+	PermissionResponse struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+
+	RoleResponse struct {
+		ID          string   `json:"id"`
+		Name        string   `json:"name"`
+		Description string   `json:"description"`
+		System      bool     `json:"system"`
+		Permissions []string `json:"permissions"`
+	}
+
+	RoleCreateRequest struct {
+		Name        string   `json:"name" form:"name" binding:"required,max=50,lowercase"`
+		Description string   `json:"description" form:"description" binding:"max=255"`
+		Permissions []string `json:"permissions" form:"permissions" binding:"dive,required"`
+	}
+
+	// RolePermissionsUpdateRequest replaces all permissions of a role, an
+	// empty list takes every permission away.
+	RolePermissionsUpdateRequest struct {
+		ID          string   `json:"-"`
+		Permissions []string `json:"permissions" form:"permissions" binding:"required,dive,required"`
+	}
+)
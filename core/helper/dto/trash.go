@@ -0,0 +1,31 @@
+package dto
+
+import (
+	"time"
+
+	"myapp/support/base"
+)
+
+type (
+	// TrashGetsRequest lists soft-deleted records, most recently deleted
+	// first unless sorted otherwise.
+	TrashGetsRequest struct {
+		Search string `json:"search" form:"search"`
+		base.PaginationRequest
+	}
+
+	TrashedUserResponse struct {
+		UserResponse
+		DeletedAt time.Time `json:"deleted_at"`
+	}
+
+	TrashedProductResponse struct {
+		ProductResponse
+		DeletedAt time.Time `json:"deleted_at"`
+	}
+
+	TrashedCategoryResponse struct {
+		CategoryResponse
+		DeletedAt time.Time `json:"deleted_at"`
+	}
+)
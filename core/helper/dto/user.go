@@ -2,6 +2,7 @@ package dto
 
 import (
 	"mime/multipart"
+	"time"
 
 	"myapp/support/base"
 )
@@ -25,29 +26,159 @@ type (
 		Password string `json:"password" form:"password" binding:"required"`
 	}
 
+	// UserLoginChallengeResponse replaces the tokens of a login when the
+	// user has two-factor authentication on. The challenge token is then
+	// exchanged together with a code at /users/login/2fa.
+	UserLoginChallengeResponse struct {
+		TwoFactorRequired bool   `json:"two_factor_required"`
+		ChallengeToken    string `json:"challenge_token"`
+		ExpiresIn         int64  `json:"expires_in"`
+	}
+
+	UserLoginTwoFactorRequest struct {
+		ChallengeToken string `json:"challenge_token" form:"challenge_token" binding:"required"`
+		// Code is a code from the authenticator app or a recovery code
+		Code string `json:"code" form:"code" binding:"required"`
+	}
+
+	UserTOTPEnrollResponse struct {
+		Secret          string `json:"secret"`
+		ProvisioningURI string `json:"provisioning_uri"`
+	}
+
+	UserTwoFactorCodeRequest struct {
+		ID   string `json:"-"`
+		Code string `json:"code" form:"code" binding:"required"`
+	}
+
+	UserRecoveryCodesResponse struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+
+	UserRefreshTokenRequest struct {
+		RefreshToken string `json:"refresh_token" form:"refresh_token" binding:"required"`
+	}
+
+	// UserSessionClient describes the device a session is started from.
+	UserSessionClient struct {
+		UserAgent string
+		IPAddress string
+	}
+
+	UserSessionResponse struct {
+		ID         string    `json:"id"`
+		UserAgent  string    `json:"user_agent"`
+		IPAddress  string    `json:"ip_address"`
+		CreatedAt  time.Time `json:"created_at"`
+		LastSeenAt time.Time `json:"last_seen_at"`
+		// Current marks the session the request was made from
+		Current bool `json:"current"`
+	}
+
+	UserSessionEndRequest struct {
+		UserID    string
+		SessionID string
+	}
+
+	UserImpersonateRequest struct {
+		ActorID   string
+		ActorRole string
+		UserID    string
+	}
+
+	UserLogoutRequest struct {
+		UserID    string    `json:"-"`
+		TokenID   string    `json:"-"`
+		SessionID string    `json:"-"`
+		ExpiresAt time.Time `json:"-"`
+		// RefreshToken is optional; when given its family is revoked too so
+		// the client cannot silently obtain a new access token.
+		RefreshToken string `json:"refresh_token" form:"refresh_token"`
+	}
+
+	UserPasswordForgotRequest struct {
+		Email string `json:"email" form:"email" binding:"required,email"`
+	}
+
+	UserPasswordResetRequest struct {
+		Token    string `json:"token" form:"token" binding:"required"`
+		Password string `json:"password" form:"password" binding:"required"`
+	}
+
+	UserPasswordChangeRequest struct {
+		ID              string `json:"-"`
+		CurrentPassword string `json:"current_password" form:"current_password" binding:"required"`
+		Password        string `json:"password" form:"password" binding:"required"`
+	}
+
+	UserVerifyEmailRequest struct {
+		Token string `json:"token" form:"token" binding:"required"`
+	}
+
+	UserVerifyEmailResendRequest struct {
+		Email string `json:"email" form:"email" binding:"required,email"`
+	}
+
 	UserNameUpdateRequest struct {
 		ID   string `json:"id"`
 		Name string `json:"name" binding:"required"`
 	}
 
+	// UserUpdateRequest changes a user on behalf of an admin. The actor
+	// fields come from the authenticated user.
 	UserUpdateRequest struct {
-		ID       string `json:"id"`
-		Name     string `json:"name" form:"name"`
-		Email    string `json:"email" form:"email" binding:"omitempty,email"`
-		Role     string `json:"role" form:"role" binding:"omitempty,oneof=admin user"`
-		Password string `json:"password" form:"password"`
+		ID        string `json:"id"`
+		ActorID   string `json:"-" form:"-"`
+		ActorRole string `json:"-" form:"-"`
+		Name      string `json:"name" form:"name"`
+		Email     string `json:"email" form:"email" binding:"omitempty,email"`
+		Role      string `json:"role" form:"role" binding:"omitempty,max=50"`
+		Password  string `json:"password" form:"password"`
 	}
 
 	UserChangePictureRequest struct {
 		ID      string                `json:"id"`
-		Picture *multipart.FileHeader `json:"picture" form:"picture"`
+		Picture *multipart.FileHeader `json:"picture" form:"picture" binding:"required"`
 	}
 
+	// UserResponse.Picture maps variant names to download URLs, with the
+	// uploaded picture as "original".
 	UserResponse struct {
-		ID      string `json:"id"`
-		Name    string `json:"name,omitempty"`
-		Email   string `json:"email,omitempty"`
-		Role    string `json:"role,omitempty"`
-		Picture string `json:"picture,omitempty"`
+		ID      string            `json:"id"`
+		Name    string            `json:"name,omitempty"`
+		Email   string            `json:"email,omitempty"`
+		Role    string            `json:"role,omitempty"`
+		Picture map[string]string `json:"picture,omitempty"`
+
+		EmailVerifiedAt     *time.Time `json:"email_verified_at,omitempty"`
+		ScheduledDeletionAt *time.Time `json:"scheduled_deletion_at,omitempty"`
+	}
+
+	// UserExportResponse is everything stored about a user, handed out as a
+	// downloadable JSON archive.
+	UserExportResponse struct {
+		ExportedAt       time.Time             `json:"exported_at"`
+		Profile          UserExportProfile     `json:"profile"`
+		Picture          *UserExportFile       `json:"picture,omitempty"`
+		TwoFactorEnabled bool                  `json:"two_factor_enabled"`
+		Sessions         []UserSessionResponse `json:"sessions"`
+	}
+
+	UserExportProfile struct {
+		ID                  string     `json:"id"`
+		Name                string     `json:"name"`
+		Email               string     `json:"email"`
+		Role                string     `json:"role"`
+		Provider            string     `json:"provider"`
+		CreatedAt           time.Time  `json:"created_at"`
+		EmailVerifiedAt     *time.Time `json:"email_verified_at"`
+		PasswordChangedAt   *time.Time `json:"password_changed_at"`
+		ScheduledDeletionAt *time.Time `json:"scheduled_deletion_at"`
+	}
+
+	// UserExportFile embeds an uploaded file, base64 encoded by encoding/json.
+	UserExportFile struct {
+		Path string `json:"path"`
+		Data []byte `json:"data"`
 	}
 )
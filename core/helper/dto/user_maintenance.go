@@ -20,6 +20,10 @@ type UserMaintenanceRequest struct {
 
 	// ClearPicture: if true, clear profile pictures for processed users.
 	ClearPicture bool `json:"clear_picture" form:"clear_picture"`
+
+	// ActorID and ActorRole come from the authenticated user.
+	ActorID   string `json:"-" form:"-"`
+	ActorRole string `json:"-" form:"-"`
 }
 
 // UserMaintenanceResult summarizes what happened to a single user.
@@ -0,0 +1,46 @@
+package errs
+
+import "errors"
+
+var (
+	ErrRefreshTokenInvalid = errors.New("refresh token is invalid")
+	ErrRefreshTokenExpired = errors.New("refresh token has expired")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used")
+	ErrRefreshTokenRevoked = errors.New("refresh token has been revoked")
+	ErrAccessTokenRevoked  = errors.New("access token has been revoked")
+
+	ErrSessionNotFound = errors.New("session not found")
+
+	ErrPasswordResetTokenInvalid = errors.New("password reset token is invalid")
+	ErrPasswordResetTokenExpired = errors.New("password reset token has expired")
+
+	ErrEmailVerificationTokenInvalid = errors.New("email verification token is invalid")
+	ErrEmailVerificationTokenExpired = errors.New("email verification token has expired")
+	ErrEmailNotVerified              = errors.New("email address is not verified")
+
+	ErrOIDCProviderNotFound = errors.New("login provider not found")
+	ErrOIDCStateInvalid     = errors.New("login state is invalid or has expired")
+	ErrOIDCExchangeFailed   = errors.New("failed to redeem authorization code")
+	ErrOIDCIDTokenInvalid   = errors.New("id token from login provider is invalid")
+	ErrOIDCEmailNotVerified = errors.New("login provider did not verify the email address")
+	ErrOIDCIdentityNotFound = errors.New("login provider identity not found")
+
+	ErrTwoFactorAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnrolled      = errors.New("two-factor authentication is not enrolled")
+	ErrTwoFactorCodeInvalid      = errors.New("two-factor code is invalid")
+	ErrTwoFactorChallengeInvalid = errors.New("two-factor challenge is invalid")
+	ErrTwoFactorChallengeExpired = errors.New("two-factor challenge has expired")
+	ErrTwoFactorRequired         = errors.New("two-factor authentication is required")
+
+	ErrLoginThrottled = errors.New("too many failed log in attempts, retry later")
+	ErrLoginLocked    = errors.New("log in is temporarily locked after too many failed attempts")
+
+	ErrImpersonateSelf         = errors.New("cannot impersonate yourself")
+	ErrImpersonationNotAllowed = errors.New("user has permissions the impersonator lacks")
+
+	ErrAPIKeyInvalid         = errors.New("api key is invalid")
+	ErrAPIKeyExpired         = errors.New("api key has expired")
+	ErrAPIKeyNotFound        = errors.New("api key not found")
+	ErrAPIKeyExpiryInvalid   = errors.New("api key expiry must be in the future and within the allowed lifetime")
+	ErrAPIKeyScopeNotAllowed = errors.New("api key scope is not held by its creator or cannot be granted to keys")
+)
@@ -1,8 +1,37 @@
 package errs
 
-import "errors"
+import (
+	"errors"
+	"strings"
+)
 
 var (
 	ErrFileNotFound     = errors.New("file not found")
 	ErrFileDeleteFailed = errors.New("failed to delete file")
+	ErrFileInvalidKey   = errors.New("invalid file key")
+	ErrFileURLInvalid   = errors.New("file link is invalid")
+	ErrFileURLExpired   = errors.New("file link has expired")
 )
+
+var ErrUploadPolicy = errors.New("file does not meet the upload policy")
+
+// UploadPolicyError lists every upload policy rule a file breaks, worded
+// like request validation messages. It matches ErrUploadPolicy with
+// errors.Is.
+type UploadPolicyError struct {
+	Violations []string
+}
+
+func (e *UploadPolicyError) Error() string {
+	return strings.Join(e.Violations, " ")
+}
+
+func (e *UploadPolicyError) Is(target error) bool {
+	return target == ErrUploadPolicy
+}
+
+// FieldErrors lets base.GetFieldErrorMessage report the violations like
+// binding errors.
+func (e *UploadPolicyError) FieldErrors() []string {
+	return e.Violations
+}
@@ -0,0 +1,11 @@
+package errs
+
+import "errors"
+
+var (
+	ErrInvitationNotFound       = errors.New("invitation not found")
+	ErrInvitationInvalid        = errors.New("invitation is invalid or was already used")
+	ErrInvitationExpired        = errors.New("invitation has expired")
+	ErrInvitationAlreadyPending = errors.New("a pending invitation for this email already exists")
+	ErrInvitationRoleNotAllowed = errors.New("cannot invite users to a role with permissions you do not have")
+)
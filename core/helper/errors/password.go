@@ -0,0 +1,32 @@
+package errs
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrPasswordPolicy    = errors.New("password does not meet the password policy")
+	ErrPasswordIncorrect = errors.New("current password is incorrect")
+)
+
+// PasswordPolicyError lists every password policy rule a password breaks,
+// worded like request validation messages. It matches ErrPasswordPolicy
+// with errors.Is.
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return strings.Join(e.Violations, " ")
+}
+
+func (e *PasswordPolicyError) Is(target error) bool {
+	return target == ErrPasswordPolicy
+}
+
+// FieldErrors lets base.FormatValidationErrors report the violations like
+// binding errors.
+func (e *PasswordPolicyError) FieldErrors() []string {
+	return e.Violations
+}
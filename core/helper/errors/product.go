@@ -4,12 +4,14 @@ import "errors"
 
 var (
 	// Product errors
-	ErrProductNotFound      = errors.New("product not found")
-	ErrProductSKUExists     = errors.New("product SKU already exists")
-	ErrProductNoImage       = errors.New("product doesn't have any image")
-	ErrInsufficientStock    = errors.New("insufficient stock")
-	ErrInvalidPriceRange    = errors.New("invalid price range")
-	ErrInvalidStockQuantity = errors.New("invalid stock quantity")
+	ErrProductNotFound        = errors.New("product not found")
+	ErrProductSKUExists       = errors.New("product SKU already exists")
+	ErrProductNoImage         = errors.New("product doesn't have any image")
+	ErrInsufficientStock      = errors.New("insufficient stock")
+	ErrInvalidPriceRange      = errors.New("invalid price range")
+	ErrInvalidStockQuantity   = errors.New("invalid stock quantity")
+	ErrProductNotFoundAsOf    = errors.New("product did not exist at the given time")
+	ErrProductCategoryTrashed = errors.New("category of the product is in the trash, restore it first")
 
 	// Category errors
 	ErrCategoryNotFound    = errors.New("category not found")
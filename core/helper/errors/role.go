@@ -0,0 +1,12 @@
+package errs
+
+import "errors"
+
+var (
+	ErrRoleNotFound       = errors.New("role not found")
+	ErrRoleAlreadyExists  = errors.New("role already exists")
+	ErrRoleInUse          = errors.New("role is still assigned to users")
+	ErrRoleSystem         = errors.New("built-in roles cannot be deleted")
+	ErrRoleAdminLockout   = errors.New("the admin role must keep the roles:manage permission")
+	ErrPermissionNotFound = errors.New("permission not found")
+)
@@ -6,4 +6,10 @@ var (
 	ErrEmailAlreadyExists = errors.New("email already exists")
 	ErrUserNotFound       = errors.New("user not found")
 	ErrUserNoPicture      = errors.New("user don't have any picture")
+	ErrUserRoleChangeSelf = errors.New("cannot change your own role")
+	ErrUserRoleNotAllowed = errors.New("cannot change roles to or from one with permissions you do not have")
+
+	ErrUserDeletionAlreadyScheduled = errors.New("account deletion is already scheduled")
+	ErrUserDeletionNotScheduled     = errors.New("account deletion is not scheduled")
+	ErrUserAnonymized               = errors.New("user data was erased and cannot be restored")
 )
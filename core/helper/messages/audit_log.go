@@ -0,0 +1,6 @@
+package messages
+
+const (
+	MsgAuditLogsFetchSuccess = "Audit logs retrieved"
+	MsgAuditLogsFetchFailed  = "Failed to retrieve audit logs"
+)
@@ -5,4 +5,21 @@ const (
 	MsgAuthInvalidToken       = "Invalid token"
 	MsgAuthFailedProcess      = "Failed to process request"
 	MsgAuthActionUnauthorized = "Action unauthorized"
+	MsgAuthTwoFactorRequired  = "Two-factor authentication must be enabled for this action"
+	MsgAuthInvalidAPIKey      = "Invalid API key"
+	MsgAuthImpersonating      = "Action not allowed while impersonating a user"
+	MsgAuthAPIKeyNotAllowed   = "Action not allowed with an API key"
+
+	MsgAuthOIDCLoginFailed      = "Failed to start provider log in"
+	MsgAuthOIDCCallbackSuccess  = "Provider log in successful"
+	MsgAuthOIDCCallbackFailed   = "Failed to log in with provider"
+	MsgAuthOIDCProviderNotFound = "Login provider not found"
+	MsgAuthOIDCEmailNotVerified = "Login provider did not verify the email address"
+
+	MsgAPIKeyCreateSuccess = "API key created, store it now as it will not be shown again"
+	MsgAPIKeyCreateFailed  = "Failed to create API key"
+	MsgAPIKeyGetsSuccess   = "API keys retrieved"
+	MsgAPIKeyGetsFailed    = "Failed to retrieve API keys"
+	MsgAPIKeyRevokeSuccess = "API key revoked"
+	MsgAPIKeyRevokeFailed  = "Failed to revoke API key"
 )
@@ -3,4 +3,6 @@ package messages
 const (
 	MsgFileFetchSuccess = "File fetched successfully"
 	MsgFileFetchFailed  = "Failed to fetch file"
+	MsgFileNotFound     = "File not found"
+	MsgFileURLDenied    = "File link is invalid or has expired"
 )
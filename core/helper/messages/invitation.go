@@ -0,0 +1,14 @@
+package messages
+
+const (
+	MsgInvitationCreateSuccess = "Invitation sent"
+	MsgInvitationCreateFailed  = "Failed to send invitation"
+	MsgInvitationGetsSuccess   = "Invitations retrieved"
+	MsgInvitationGetsFailed    = "Failed to retrieve invitations"
+	MsgInvitationResendSuccess = "Invitation resent"
+	MsgInvitationResendFailed  = "Failed to resend invitation"
+	MsgInvitationRevokeSuccess = "Invitation revoked"
+	MsgInvitationRevokeFailed  = "Failed to revoke invitation"
+	MsgInvitationAcceptSuccess = "Invitation accepted, you can now log in"
+	MsgInvitationAcceptFailed  = "Failed to accept invitation"
+)
@@ -31,6 +31,9 @@ const (
 	MsgProductMaintenanceSuccess = "Product maintenance completed successfully"
 	MsgProductMaintenanceFailed  = "Failed to run product maintenance"
 
+	MsgProductHistorySuccess = "Product history fetched successfully"
+	MsgProductHistoryFailed  = "Failed to fetch product history"
+
 	// Category messages
 	MsgCategoryCreateSuccess = "Category created successfully"
 	MsgCategoryCreateFailed  = "Failed to create category"
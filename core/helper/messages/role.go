@@ -0,0 +1,15 @@
+package messages
+
+const (
+	MsgRoleCreateSuccess = "Role created"
+	MsgRoleCreateFailed  = "Failed to create role"
+	MsgRoleGetsSuccess   = "Roles retrieved"
+	MsgRoleGetsFailed    = "Failed to retrieve roles"
+	MsgRoleUpdateSuccess = "Role permissions updated"
+	MsgRoleUpdateFailed  = "Failed to update role permissions"
+	MsgRoleDeleteSuccess = "Role deleted"
+	MsgRoleDeleteFailed  = "Failed to delete role"
+
+	MsgPermissionGetsSuccess = "Permissions retrieved"
+	MsgPermissionGetsFailed  = "Failed to retrieve permissions"
+)
@@ -0,0 +1,12 @@
+package messages
+
+const (
+	MsgTrashFetchSuccess = "Deleted records fetched successfully"
+	MsgTrashFetchFailed  = "Failed to fetch deleted records"
+
+	MsgTrashRestoreSuccess = "Record restored successfully"
+	MsgTrashRestoreFailed  = "Failed to restore record"
+
+	MsgTrashPurgeSuccess = "Record purged permanently"
+	MsgTrashPurgeFailed  = "Failed to purge record"
+)
@@ -7,6 +7,51 @@ const (
 	MsgUserLoginSuccess    = "User log in successful"
 	MsgUserLoginFailed     = "Failed to log in user"
 	MsgUserWrongCredential = "Entered credentials invalid"
+	MsgUserLoginThrottled  = "Too many failed log in attempts, try again later"
+
+	MsgUserImpersonateSuccess = "User impersonation started"
+	MsgUserImpersonateFailed  = "Failed to impersonate user"
+
+	MsgUserLockoutClearSuccess = "User log in lockout cleared"
+	MsgUserLockoutClearFailed  = "Failed to clear user log in lockout"
+
+	MsgUserLoginTwoFactorRequired = "User two-factor code required"
+	MsgUserTwoFactorCodeInvalid   = "Entered two-factor code invalid"
+
+	MsgUserTOTPEnrollSuccess    = "User two-factor enrollment started"
+	MsgUserTOTPEnrollFailed     = "Failed to start user two-factor enrollment"
+	MsgUserTOTPConfirmSuccess   = "User two-factor authentication enabled"
+	MsgUserTOTPConfirmFailed    = "Failed to enable user two-factor authentication"
+	MsgUserTOTPDisableSuccess   = "User two-factor authentication disabled"
+	MsgUserTOTPDisableFailed    = "Failed to disable user two-factor authentication"
+	MsgUserRecoveryCodesSuccess = "User recovery codes regenerated"
+	MsgUserRecoveryCodesFailed  = "Failed to regenerate user recovery codes"
+
+	MsgUserTokenRefreshSuccess = "Token refresh successful"
+	MsgUserTokenRefreshFailed  = "Failed to refresh token"
+
+	MsgUserLogoutSuccess = "User log out successful"
+	MsgUserLogoutFailed  = "Failed to log out user"
+
+	MsgUserPasswordForgotSuccess = "User password reset request successful"
+	MsgUserPasswordForgotFailed  = "Failed to request user password reset"
+	MsgUserPasswordResetSuccess  = "User password reset successful"
+	MsgUserPasswordResetFailed   = "Failed to reset user password"
+	MsgUserPasswordChangeSuccess = "User password change successful"
+	MsgUserPasswordChangeFailed  = "Failed to change user password"
+
+	MsgUserEmailVerifySuccess = "User email verification successful"
+	MsgUserEmailVerifyFailed  = "Failed to verify user email"
+	MsgUserEmailResendSuccess = "User verification email resend successful"
+	MsgUserEmailResendFailed  = "Failed to resend user verification email"
+	MsgUserEmailNotVerified   = "User email not verified"
+
+	MsgUserSessionsRevokeSuccess = "User sessions revoke successful"
+	MsgUserSessionsRevokeFailed  = "Failed to revoke user sessions"
+	MsgUserSessionGetsSuccess    = "User sessions fetched successfully"
+	MsgUserSessionGetsFailed     = "Failed to fetch user sessions"
+	MsgUserSessionEndSuccess     = "User session sign out successful"
+	MsgUserSessionEndFailed      = "Failed to sign out user session"
 
 	MsgUsersFetchSuccess = "Users fetched successfully"
 	MsgUsersFetchFailed  = "Failed to fetch users"
@@ -19,6 +64,13 @@ const (
 	MsgUserDeleteSuccess = "User delete successful"
 	MsgUserDeleteFailed  = "Failed to delete user"
 
+	MsgUserDeletionScheduleSuccess = "User deletion scheduled, cancel it before the date to keep the account"
+	MsgUserDeletionScheduleFailed  = "Failed to schedule user deletion"
+	MsgUserDeletionCancelSuccess   = "User deletion cancelled"
+	MsgUserDeletionCancelFailed    = "Failed to cancel user deletion"
+
+	MsgUserExportFailed = "Failed to export user data"
+
 	MsgUserPictureUpdateSuccess = "User picture update successful"
 	MsgUserPictureUpdateFailed  = "Failed to update user picture"
 
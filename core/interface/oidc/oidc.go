@@ -0,0 +1,26 @@
+package oidciface
+
+import "context"
+
+// Identity is what an OpenID Connect provider asserts about the signed in
+// user, taken from a verified ID token.
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+type Provider interface {
+	Name() string
+	// AuthCodeURL returns the provider URL the browser is sent to. The code
+	// challenge is derived from verifier (PKCE, S256).
+	AuthCodeURL(ctx context.Context, state string, nonce string, verifier string) (string, error)
+	// Exchange redeems an authorization code and verifies the returned ID
+	// token, including its nonce.
+	Exchange(ctx context.Context, code string, verifier string, nonce string) (Identity, error)
+}
+
+// Providers holds the configured providers by name, as used in the
+// /auth/:provider routes.
+type Providers map[string]Provider
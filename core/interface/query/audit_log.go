@@ -0,0 +1,13 @@
+package queryiface
+
+import (
+	"context"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	"myapp/support/base"
+)
+
+type AuditLogQuery interface {
+	GetAllAuditLogs(ctx context.Context, req dto.AuditLogGetsRequest) ([]entity.AuditLog, base.PaginationResponse, error)
+}
@@ -18,6 +18,10 @@ type ProductQuery interface {
 	GetProductStatsByCategory(ctx context.Context) ([]dto.CategoryProductStats, error)
 }
 
+type ProductRevisionQuery interface {
+	GetProductRevisions(ctx context.Context, req dto.ProductHistoryRequest) ([]entity.ProductRevision, base.PaginationResponse, error)
+}
+
 type CategoryQuery interface {
 	GetAllCategories(ctx context.Context, req dto.CategoryGetsRequest) ([]entity.Category, base.PaginationResponse, error)
 }
@@ -0,0 +1,16 @@
+package queryiface
+
+import (
+	"context"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	"myapp/support/base"
+)
+
+// TrashQuery lists soft-deleted records.
+type TrashQuery interface {
+	GetTrashedUsers(ctx context.Context, req dto.TrashGetsRequest) ([]entity.User, base.PaginationResponse, error)
+	GetTrashedProducts(ctx context.Context, req dto.TrashGetsRequest) ([]entity.Product, base.PaginationResponse, error)
+	GetTrashedCategories(ctx context.Context, req dto.TrashGetsRequest) ([]entity.Category, base.PaginationResponse, error)
+}
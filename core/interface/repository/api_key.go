@@ -0,0 +1,22 @@
+package repositoryiface
+
+import (
+	"context"
+	"time"
+
+	"myapp/core/entity"
+
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	// db
+	DB() *gorm.DB
+
+	// functional
+	CreateAPIKey(ctx context.Context, tx *gorm.DB, key entity.APIKey) (entity.APIKey, error)
+	GetAllAPIKeys(ctx context.Context, tx *gorm.DB) ([]entity.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, tx *gorm.DB, hash string) (entity.APIKey, error)
+	TouchAPIKey(ctx context.Context, tx *gorm.DB, id string, now time.Time, staleBefore time.Time) error
+	RevokeAPIKey(ctx context.Context, tx *gorm.DB, id string) error
+}
@@ -0,0 +1,20 @@
+package repositoryiface
+
+import (
+	"context"
+
+	"myapp/core/entity"
+
+	"gorm.io/gorm"
+)
+
+type AuditLogRepository interface {
+	// db
+	DB() *gorm.DB
+
+	// functional
+	CreateAuditLog(ctx context.Context, tx *gorm.DB, log entity.AuditLog) (entity.AuditLog, error)
+	// RedactAuditLogs replaces the before and after values of fields in
+	// the entries of an entity with "[redacted]".
+	RedactAuditLogs(ctx context.Context, tx *gorm.DB, entityType string, entityID string, fields []string) error
+}
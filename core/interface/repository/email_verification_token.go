@@ -0,0 +1,21 @@
+package repositoryiface
+
+import (
+	"context"
+
+	"myapp/core/entity"
+
+	"gorm.io/gorm"
+)
+
+type EmailVerificationTokenRepository interface {
+	// db
+	DB() *gorm.DB
+
+	// functional
+	CreateEmailVerificationToken(ctx context.Context, tx *gorm.DB,
+		token entity.EmailVerificationToken) (entity.EmailVerificationToken, error)
+	GetEmailVerificationTokenByHash(ctx context.Context, tx *gorm.DB, hash string) (entity.EmailVerificationToken, error)
+	UseEmailVerificationToken(ctx context.Context, tx *gorm.DB, id string) error
+	InvalidateUserEmailVerificationTokens(ctx context.Context, tx *gorm.DB, userID string) error
+}
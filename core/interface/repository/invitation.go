@@ -0,0 +1,29 @@
+package repositoryiface
+
+import (
+	"context"
+	"time"
+
+	"myapp/core/entity"
+
+	"gorm.io/gorm"
+)
+
+type InvitationRepository interface {
+	// db
+	DB() *gorm.DB
+
+	// functional
+	CreateInvitation(ctx context.Context, tx *gorm.DB, invitation entity.Invitation) (entity.Invitation, error)
+	GetAllInvitations(ctx context.Context, tx *gorm.DB) ([]entity.Invitation, error)
+	GetInvitationByID(ctx context.Context, tx *gorm.DB, id string) (entity.Invitation, error)
+	GetInvitationByHash(ctx context.Context, tx *gorm.DB, hash string) (entity.Invitation, error)
+	// GetPendingInvitationByEmail returns the unexpired invitation of an
+	// email that was neither accepted nor revoked.
+	GetPendingInvitationByEmail(ctx context.Context, tx *gorm.DB, email string, now time.Time) (entity.Invitation, error)
+	// RenewInvitation replaces the token of an open invitation, so links
+	// sent before stop working.
+	RenewInvitation(ctx context.Context, tx *gorm.DB, id string, hash string, expiresAt time.Time) error
+	AcceptInvitation(ctx context.Context, tx *gorm.DB, id string) error
+	RevokeInvitation(ctx context.Context, tx *gorm.DB, id string) error
+}
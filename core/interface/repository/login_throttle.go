@@ -0,0 +1,22 @@
+package repositoryiface
+
+import (
+	"context"
+	"time"
+
+	"myapp/core/entity"
+
+	"gorm.io/gorm"
+)
+
+type LoginThrottleRepository interface {
+	// db
+	DB() *gorm.DB
+
+	// functional
+	GetLoginThrottles(ctx context.Context, tx *gorm.DB, keys []string) ([]entity.LoginThrottle, error)
+	RecordLoginFailure(ctx context.Context, tx *gorm.DB, key string, now time.Time,
+		windowStart time.Time) (entity.LoginThrottle, error)
+	UpdateLoginThrottle(ctx context.Context, tx *gorm.DB, throttle entity.LoginThrottle) error
+	DeleteLoginThrottle(ctx context.Context, tx *gorm.DB, key string) error
+}
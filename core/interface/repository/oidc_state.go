@@ -0,0 +1,18 @@
+package repositoryiface
+
+import (
+	"context"
+
+	"myapp/core/entity"
+
+	"gorm.io/gorm"
+)
+
+type OIDCStateRepository interface {
+	// db
+	DB() *gorm.DB
+
+	// functional
+	CreateOIDCState(ctx context.Context, tx *gorm.DB, state entity.OIDCState) (entity.OIDCState, error)
+	ConsumeOIDCState(ctx context.Context, tx *gorm.DB, hash string) (entity.OIDCState, error)
+}
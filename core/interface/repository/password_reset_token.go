@@ -0,0 +1,21 @@
+package repositoryiface
+
+import (
+	"context"
+
+	"myapp/core/entity"
+
+	"gorm.io/gorm"
+)
+
+type PasswordResetTokenRepository interface {
+	// db
+	DB() *gorm.DB
+
+	// functional
+	CreatePasswordResetToken(ctx context.Context, tx *gorm.DB,
+		token entity.PasswordResetToken) (entity.PasswordResetToken, error)
+	GetPasswordResetTokenByHash(ctx context.Context, tx *gorm.DB, hash string) (entity.PasswordResetToken, error)
+	UsePasswordResetToken(ctx context.Context, tx *gorm.DB, id string) error
+	InvalidateUserPasswordResetTokens(ctx context.Context, tx *gorm.DB, userID string) error
+}
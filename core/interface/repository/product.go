@@ -2,6 +2,7 @@ package repositoryiface
 
 import (
 	"context"
+	"time"
 
 	"myapp/core/entity"
 
@@ -24,6 +25,16 @@ type ProductRepository interface {
 	BulkUpdatePrices(ctx context.Context, tx *gorm.DB, ids []string, priceMultiplier float64) error
 }
 
+// ProductRevisionRepository keeps the state of a product after each change.
+type ProductRevisionRepository interface {
+	// db
+	DB() *gorm.DB
+
+	// functional
+	CreateProductRevision(ctx context.Context, tx *gorm.DB, revision entity.ProductRevision) (entity.ProductRevision, error)
+	GetProductRevisionAsOf(ctx context.Context, tx *gorm.DB, productID string, asOf time.Time) (entity.ProductRevision, error)
+}
+
 type CategoryRepository interface {
 	// db
 	DB() *gorm.DB
@@ -0,0 +1,19 @@
+package repositoryiface
+
+import (
+	"context"
+
+	"myapp/core/entity"
+
+	"gorm.io/gorm"
+)
+
+type RecoveryCodeRepository interface {
+	// db
+	DB() *gorm.DB
+
+	// functional
+	CreateRecoveryCodes(ctx context.Context, tx *gorm.DB, codes []entity.RecoveryCode) error
+	UseRecoveryCode(ctx context.Context, tx *gorm.DB, userID string, hash string) error
+	DeleteUserRecoveryCodes(ctx context.Context, tx *gorm.DB, userID string) error
+}
@@ -0,0 +1,22 @@
+package repositoryiface
+
+import (
+	"context"
+
+	"myapp/core/entity"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RefreshTokenRepository interface {
+	// db
+	DB() *gorm.DB
+
+	// functional
+	CreateRefreshToken(ctx context.Context, tx *gorm.DB, token entity.RefreshToken) (entity.RefreshToken, error)
+	GetRefreshTokenByHash(ctx context.Context, tx *gorm.DB, hash string) (entity.RefreshToken, error)
+	RevokeRefreshToken(ctx context.Context, tx *gorm.DB, id string, replacedBy *uuid.UUID) error
+	RevokeRefreshTokenFamily(ctx context.Context, tx *gorm.DB, familyID string, reason string) error
+	RevokeUserRefreshTokens(ctx context.Context, tx *gorm.DB, userID string, reason string) error
+}
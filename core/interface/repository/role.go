@@ -0,0 +1,26 @@
+package repositoryiface
+
+import (
+	"context"
+
+	"myapp/core/entity"
+
+	"gorm.io/gorm"
+)
+
+type RoleRepository interface {
+	// db
+	DB() *gorm.DB
+
+	// functional
+	CreateRole(ctx context.Context, tx *gorm.DB, role entity.Role) (entity.Role, error)
+	GetAllRoles(ctx context.Context, tx *gorm.DB) ([]entity.Role, error)
+	GetRoleByPrimaryKey(ctx context.Context, tx *gorm.DB, key string, val string) (entity.Role, error)
+	ReplaceRolePermissions(ctx context.Context, tx *gorm.DB, role entity.Role, permissions []entity.Permission) error
+	DeleteRole(ctx context.Context, tx *gorm.DB, role entity.Role) error
+	IsRoleAssigned(ctx context.Context, tx *gorm.DB, name string) (bool, error)
+	RoleHasPermission(ctx context.Context, tx *gorm.DB, name string, permission string) (bool, error)
+
+	GetAllPermissions(ctx context.Context, tx *gorm.DB) ([]entity.Permission, error)
+	GetPermissionsByNames(ctx context.Context, tx *gorm.DB, names []string) ([]entity.Permission, error)
+}
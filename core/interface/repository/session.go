@@ -0,0 +1,23 @@
+package repositoryiface
+
+import (
+	"context"
+	"time"
+
+	"myapp/core/entity"
+
+	"gorm.io/gorm"
+)
+
+type SessionRepository interface {
+	// db
+	DB() *gorm.DB
+
+	// functional
+	CreateSession(ctx context.Context, tx *gorm.DB, session entity.Session) (entity.Session, error)
+	GetActiveUserSessions(ctx context.Context, tx *gorm.DB, userID string) ([]entity.Session, error)
+	TouchSession(ctx context.Context, tx *gorm.DB, id string, now time.Time, staleBefore time.Time) error
+	ExtendSession(ctx context.Context, tx *gorm.DB, id string, now time.Time, expiresAt time.Time) error
+	EndSession(ctx context.Context, tx *gorm.DB, userID string, id string) error
+	EndUserSessions(ctx context.Context, tx *gorm.DB, userID string) error
+}
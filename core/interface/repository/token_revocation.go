@@ -0,0 +1,22 @@
+package repositoryiface
+
+import (
+	"context"
+	"time"
+
+	"myapp/core/entity"
+
+	"gorm.io/gorm"
+)
+
+type TokenRevocationRepository interface {
+	// db
+	DB() *gorm.DB
+
+	// functional
+	CreateTokenRevocation(ctx context.Context, tx *gorm.DB, revocation entity.TokenRevocation) error
+	// IsTokenRevoked also reports tokens issued up to the last password
+	// change of the user as revoked.
+	IsTokenRevoked(ctx context.Context, userID string, tokenID string, sessionID string,
+		issuedAt time.Time) (bool, error)
+}
@@ -0,0 +1,21 @@
+package repositoryiface
+
+import (
+	"context"
+
+	"myapp/core/entity"
+
+	"gorm.io/gorm"
+)
+
+type TOTPCredentialRepository interface {
+	// db
+	DB() *gorm.DB
+
+	// functional
+	CreateTOTPCredential(ctx context.Context, tx *gorm.DB, credential entity.TOTPCredential) (entity.TOTPCredential, error)
+	GetTOTPCredentialByUserID(ctx context.Context, tx *gorm.DB, userID string) (entity.TOTPCredential, error)
+	ConfirmTOTPCredential(ctx context.Context, tx *gorm.DB, id string, step int64) error
+	UseTOTPStep(ctx context.Context, tx *gorm.DB, id string, step int64) error
+	DeleteUserTOTPCredential(ctx context.Context, tx *gorm.DB, userID string) error
+}
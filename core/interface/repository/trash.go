@@ -0,0 +1,42 @@
+package repositoryiface
+
+import (
+	"context"
+	"time"
+
+	"myapp/core/entity"
+
+	"gorm.io/gorm"
+)
+
+// TrashRepository restores and permanently deletes soft-deleted users,
+// products and categories. Every method only sees rows in the trash and
+// fails with the entity's not found error for any other ID.
+type TrashRepository interface {
+	// db
+	DB() *gorm.DB
+
+	GetTrashedUserByID(ctx context.Context, tx *gorm.DB, id string) (entity.User, error)
+	GetTrashedProductByID(ctx context.Context, tx *gorm.DB, id string) (entity.Product, error)
+	GetTrashedCategoryByID(ctx context.Context, tx *gorm.DB, id string) (entity.Category, error)
+
+	// Restoring fails with the entity's "already exists" error when a live
+	// row took its email, SKU or name meanwhile.
+	RestoreUserByID(ctx context.Context, tx *gorm.DB, id string) error
+	RestoreProductByID(ctx context.Context, tx *gorm.DB, id string) error
+	RestoreCategoryByID(ctx context.Context, tx *gorm.DB, id string) error
+
+	// PurgeUserByID also drops the sessions and credentials of the user,
+	// PurgeProductByID the revisions of the product. PurgeCategoryByID
+	// fails with ErrCategoryHasProducts while any product, trashed or not,
+	// is in the category.
+	PurgeUserByID(ctx context.Context, tx *gorm.DB, id string) error
+	PurgeProductByID(ctx context.Context, tx *gorm.DB, id string) error
+	PurgeCategoryByID(ctx context.Context, tx *gorm.DB, id string) error
+
+	// The oldest trashed rows deleted before the given time. Categories
+	// still holding products are left out, they cannot be purged.
+	GetUsersTrashedBefore(ctx context.Context, tx *gorm.DB, before time.Time, limit int) ([]entity.User, error)
+	GetProductsTrashedBefore(ctx context.Context, tx *gorm.DB, before time.Time, limit int) ([]entity.Product, error)
+	GetCategoriesTrashedBefore(ctx context.Context, tx *gorm.DB, before time.Time, limit int) ([]entity.Category, error)
+}
@@ -0,0 +1,21 @@
+package repositoryiface
+
+import (
+	"context"
+
+	"myapp/core/entity"
+
+	"gorm.io/gorm"
+)
+
+type TwoFactorChallengeRepository interface {
+	// db
+	DB() *gorm.DB
+
+	// functional
+	CreateTwoFactorChallenge(ctx context.Context, tx *gorm.DB,
+		challenge entity.TwoFactorChallenge) (entity.TwoFactorChallenge, error)
+	GetTwoFactorChallengeByHash(ctx context.Context, tx *gorm.DB, hash string) (entity.TwoFactorChallenge, error)
+	AttemptTwoFactorChallenge(ctx context.Context, tx *gorm.DB, id string, maxAttempts int) error
+	UseTwoFactorChallenge(ctx context.Context, tx *gorm.DB, id string) error
+}
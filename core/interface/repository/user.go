@@ -2,6 +2,7 @@ package repositoryiface
 
 import (
 	"context"
+	"time"
 
 	"myapp/core/entity"
 
@@ -16,5 +17,20 @@ type UserRepository interface {
 	CreateNewUser(ctx context.Context, tx *gorm.DB, user entity.User) (entity.User, error)
 	GetUserByPrimaryKey(ctx context.Context, tx *gorm.DB, key string, val string) (entity.User, error)
 	UpdateUser(ctx context.Context, tx *gorm.DB, user entity.User) error
+	// UpdateUserPasswordHash stores an already hashed password as is,
+	// without counting it as a password change.
+	UpdateUserPasswordHash(ctx context.Context, tx *gorm.DB, id string, hash string) error
 	DeleteUserByID(ctx context.Context, tx *gorm.DB, id string) error
+
+	// ScheduleUserDeletion fails with ErrUserDeletionAlreadyScheduled when a
+	// deletion is pending already, CancelUserDeletion with
+	// ErrUserDeletionNotScheduled when none is.
+	ScheduleUserDeletion(ctx context.Context, tx *gorm.DB, id string, at time.Time) error
+	CancelUserDeletion(ctx context.Context, tx *gorm.DB, id string) error
+	GetUsersDueForDeletion(ctx context.Context, tx *gorm.DB, now time.Time, limit int) ([]entity.User, error)
+	// PurgeUser anonymizes the personal data of a user, drops their
+	// sessions and credentials and soft deletes the row, which frees the
+	// email address for a new account. The picture key is kept so the files
+	// can be deleted once the purge is committed.
+	PurgeUser(ctx context.Context, tx *gorm.DB, user entity.User) error
 }
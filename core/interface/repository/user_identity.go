@@ -0,0 +1,19 @@
+package repositoryiface
+
+import (
+	"context"
+
+	"myapp/core/entity"
+
+	"gorm.io/gorm"
+)
+
+type UserIdentityRepository interface {
+	// db
+	DB() *gorm.DB
+
+	// functional
+	CreateUserIdentity(ctx context.Context, tx *gorm.DB, identity entity.UserIdentity) (entity.UserIdentity, error)
+	GetUserIdentity(ctx context.Context, tx *gorm.DB, provider string, subject string) (entity.UserIdentity, error)
+	DeleteUserIdentity(ctx context.Context, tx *gorm.DB, id string) error
+}
@@ -0,0 +1,30 @@
+package storageiface
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// Object is a stored file opened for reading. The caller closes Body, which
+// can seek so ranges of the file can be served. ContentType is empty when
+// the backend does not keep it. ETag is a quoted entity tag that changes
+// whenever the file does.
+type Object struct {
+	Body        io.ReadSeekCloser
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+	ETag        string
+}
+
+// Storage keeps uploaded files under slash separated keys such as
+// "user_picture/<id>", the paths stored on users and products. Get and
+// Delete fail with errs.ErrFileNotFound for keys that hold no file.
+type Storage interface {
+	// Put stores size bytes read from r under key, replacing any file
+	// already there.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (Object, error)
+	Delete(ctx context.Context, key string) error
+}
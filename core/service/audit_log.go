@@ -0,0 +1,253 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	queryiface "myapp/core/interface/query"
+	repositoryiface "myapp/core/interface/repository"
+	"myapp/support/base"
+	"myapp/support/constant"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type auditLogService struct {
+	auditLogQuery queryiface.AuditLogQuery
+}
+
+type AuditLogService interface {
+	GetAllAuditLogs(ctx context.Context, req dto.AuditLogGetsRequest) ([]dto.AuditLogResponse, base.PaginationResponse, error)
+}
+
+func NewAuditLogService(auditLogQ queryiface.AuditLogQuery) AuditLogService {
+	return &auditLogService{
+		auditLogQuery: auditLogQ,
+	}
+}
+
+func (sv *auditLogService) GetAllAuditLogs(ctx context.Context, req dto.AuditLogGetsRequest) (
+	logsResp []dto.AuditLogResponse, pageResp base.PaginationResponse, err error) {
+	logs, pageResp, err := sv.auditLogQuery.GetAllAuditLogs(ctx, req)
+	if err != nil {
+		return []dto.AuditLogResponse{}, base.PaginationResponse{}, err
+	}
+
+	for _, log := range logs {
+		logResp := dto.AuditLogResponse{
+			ID:         log.ID.String(),
+			ActorType:  log.ActorType,
+			Action:     log.Action,
+			EntityType: log.EntityType,
+			EntityID:   log.EntityID,
+			Changes:    map[string]dto.AuditChangeResponse{},
+			RequestID:  log.RequestID,
+			IPAddress:  log.IPAddress,
+			CreatedAt:  log.CreatedAt,
+		}
+		if log.ActorID != nil {
+			actorID := log.ActorID.String()
+			logResp.ActorID = &actorID
+		}
+		if log.ImpersonatorID != nil {
+			impersonatorID := log.ImpersonatorID.String()
+			logResp.ImpersonatorID = &impersonatorID
+		}
+		for field, change := range log.Changes {
+			logResp.Changes[field] = dto.AuditChangeResponse{Before: change.Before, After: change.After}
+		}
+
+		logsResp = append(logsResp, logResp)
+	}
+	return logsResp, pageResp, nil
+}
+
+// ============== Recording ==============
+
+// auditRedacted replaces the value of fields tagged `audit:"redact"`, and
+// of fields tagged `audit:"personal"` once the entity is purged.
+var auditRedacted = json.RawMessage(`"[redacted]"`)
+
+// recordAudit writes an audit log entry for a change to an entity within
+// tx. Before and after are full snapshots of the entity, before is nil for
+// creates and after for deletes. An update that changes nothing is not
+// recorded.
+func recordAudit(ctx context.Context, tx *gorm.DB, auditLogR repositoryiface.AuditLogRepository,
+	action string, entityType string, entityID string, before any, after any) error {
+	changes := auditChanges(before, after)
+	if len(changes) == 0 && action == constant.EnumAuditActionUpdate {
+		return nil
+	}
+
+	return writeAudit(ctx, tx, auditLogR, action, entityType, entityID, changes)
+}
+
+// recordErasure writes the audit log entry for a purge that erases the
+// personal data of an entity. Fields tagged `audit:"personal"` are redacted
+// in this entry and in every earlier entry of the entity, so the audit log
+// does not keep what the purge erased.
+func recordErasure(ctx context.Context, tx *gorm.DB, auditLogR repositoryiface.AuditLogRepository,
+	action string, entityType string, entityID string, before any) error {
+	personal := auditPersonalFields(before)
+	if err := auditLogR.RedactAuditLogs(ctx, tx, entityType, entityID, personal); err != nil {
+		return err
+	}
+
+	changes := auditChanges(before, nil)
+	for _, name := range personal {
+		if change, ok := changes[name]; ok {
+			change.Before = auditRedacted
+			changes[name] = change
+		}
+	}
+	return writeAudit(ctx, tx, auditLogR, action, entityType, entityID, changes)
+}
+
+func writeAudit(ctx context.Context, tx *gorm.DB, auditLogR repositoryiface.AuditLogRepository,
+	action string, entityType string, entityID string, changes entity.AuditChanges) error {
+	log := entity.AuditLog{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    changes,
+	}
+	setAuditActor(ctx, &log)
+
+	_, err := auditLogR.CreateAuditLog(ctx, tx, log)
+	return err
+}
+
+// setAuditActor fills in who made the change from the request context.
+// Controllers pass the gin context on, whose Value looks up the keys set by
+// the RequestID and Authenticate middlewares. Background jobs pass a plain
+// context and are recorded as the system.
+func setAuditActor(ctx context.Context, log *entity.AuditLog) {
+	log.RequestID, _ = ctx.Value("REQUEST_ID").(string)
+	log.IPAddress, _ = ctx.Value("CLIENT_IP").(string)
+
+	actorID := requestActorID(ctx)
+	switch {
+	case actorID != nil && ctx.Value("ROLE") == constant.EnumRoleAPIKey:
+		log.ActorID = actorID
+		log.ActorType = constant.EnumAuditActorAPIKey
+	case actorID != nil:
+		log.ActorID = actorID
+		log.ActorType = constant.EnumAuditActorUser
+	case log.RequestID != "":
+		log.ActorType = constant.EnumAuditActorAnonymous
+	default:
+		log.ActorType = constant.EnumAuditActorSystem
+	}
+
+	impersonator, _ := ctx.Value("ACTOR_ID").(string)
+	if impersonatorID, err := uuid.Parse(impersonator); err == nil {
+		log.ImpersonatorID = &impersonatorID
+	}
+}
+
+// requestActorID is the user or API key making the request, nil outside of
+// authenticated requests.
+func requestActorID(ctx context.Context) *uuid.UUID {
+	id, _ := ctx.Value("ID").(string)
+	actorID, err := uuid.Parse(id)
+	if err != nil {
+		return nil
+	}
+	return &actorID
+}
+
+type auditField struct {
+	value    json.RawMessage
+	redact   bool
+	personal bool
+}
+
+// auditChanges lists the fields that differ between before and after.
+func auditChanges(before any, after any) entity.AuditChanges {
+	beforeFields, afterFields := auditFields(before), auditFields(after)
+
+	changes := entity.AuditChanges{}
+	for name := range beforeFields {
+		if _, ok := afterFields[name]; !ok {
+			afterFields[name] = auditField{}
+		}
+	}
+	for name, a := range afterFields {
+		b := beforeFields[name]
+		if bytes.Equal(b.value, a.value) {
+			continue
+		}
+
+		change := entity.AuditChange{Before: b.value, After: a.value}
+		if b.redact || a.redact {
+			if b.value != nil {
+				change.Before = auditRedacted
+			}
+			if a.value != nil {
+				change.After = auditRedacted
+			}
+		}
+		changes[name] = change
+	}
+	return changes
+}
+
+// auditFields encodes the fields of an entity by their JSON name. The ID,
+// embedded structs like base.Model and relations are skipped.
+func auditFields(v any) map[string]auditField {
+	fields := map[string]auditField{}
+	if v == nil {
+		return fields
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return fields
+	}
+
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		if !field.IsExported() || field.Anonymous || strings.Contains(field.Tag.Get("gorm"), "foreignKey") {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		if name == "-" || name == constant.DBAttrID {
+			continue
+		}
+
+		encoded, err := json.Marshal(rv.Field(i).Interface())
+		if err != nil {
+			continue
+		}
+		fields[name] = auditField{
+			value:    encoded,
+			redact:   field.Tag.Get("audit") == "redact",
+			personal: field.Tag.Get("audit") == "personal",
+		}
+	}
+	return fields
+}
+
+// auditPersonalFields lists the JSON names of the fields of an entity
+// tagged `audit:"personal"`, in order.
+func auditPersonalFields(v any) []string {
+	var personal []string
+	for name, field := range auditFields(v) {
+		if field.personal {
+			personal = append(personal, name)
+		}
+	}
+	slices.Sort(personal)
+	return personal
+}
@@ -0,0 +1,426 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	repositoryiface "myapp/core/interface/repository"
+	"myapp/support/base"
+	"myapp/support/constant"
+	"myapp/support/logger"
+	"myapp/support/util"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type authService struct {
+	jwtService             JWTService
+	refreshTokenRepository repositoryiface.RefreshTokenRepository
+	revocationRepository   repositoryiface.TokenRevocationRepository
+	sessionRepository      repositoryiface.SessionRepository
+	userRepository         repositoryiface.UserRepository
+	txRepository           repositoryiface.TxRepository
+	refreshTokenTTL        time.Duration
+}
+
+type AuthService interface {
+	// IssueTokens starts a new session with its own refresh token family
+	// for a freshly authenticated user and returns an access/refresh token
+	// pair.
+	IssueTokens(ctx context.Context, userID string, role string,
+		client dto.UserSessionClient) (base.AuthResponse, error)
+	// RefreshTokens rotates a refresh token. Presenting a token that was
+	// already rotated is treated as theft and revokes its whole family.
+	RefreshTokens(ctx context.Context, req dto.UserRefreshTokenRequest) (base.AuthResponse, error)
+	// VerifyAccessToken rejects access tokens that were revoked before
+	// their expiry, either individually, with their session or through
+	// RevokeUserSessions of their user or impersonator. Accepted tokens
+	// mark their session as seen.
+	VerifyAccessToken(ctx context.Context, claims *TokenClaims) error
+	// Logout revokes the presented access token and ends its session. A
+	// given refresh token has its family revoked too.
+	Logout(ctx context.Context, req dto.UserLogoutRequest) error
+	// RevokeUserSessions ends every session of a user: refresh tokens are
+	// revoked and all access tokens issued so far stop being accepted.
+	RevokeUserSessions(ctx context.Context, userID string) error
+	// GetUserSessions lists the active sessions of a user, marking
+	// currentSessionID as the current one.
+	GetUserSessions(ctx context.Context, userID string, currentSessionID string) ([]dto.UserSessionResponse, error)
+	// EndUserSession signs a user out of one of their sessions.
+	EndUserSession(ctx context.Context, req dto.UserSessionEndRequest) error
+}
+
+func NewAuthService(jwtS JWTService, refreshTokenR repositoryiface.RefreshTokenRepository,
+	revocationR repositoryiface.TokenRevocationRepository, sessionR repositoryiface.SessionRepository,
+	userR repositoryiface.UserRepository, txR repositoryiface.TxRepository,
+) AuthService {
+	return &authService{
+		jwtService:             jwtS,
+		refreshTokenRepository: refreshTokenR,
+		revocationRepository:   revocationR,
+		sessionRepository:      sessionR,
+		userRepository:         userR,
+		txRepository:           txR,
+		refreshTokenTTL:        util.GetEnvDuration("JWT_REFRESH_TOKEN_TTL", constant.DefaultRefreshTokenTTL),
+	}
+}
+
+// ============== Helper Functions ==============
+
+func (sv *authService) newRefreshToken(userID uuid.UUID, familyID uuid.UUID) (entity.RefreshToken, string, error) {
+	raw, err := util.GenerateRandomToken(constant.RefreshTokenBytes)
+	if err != nil {
+		return entity.RefreshToken{}, "", err
+	}
+
+	token := entity.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: util.HashToken(raw),
+		ExpiresAt: time.Now().Add(sv.refreshTokenTTL),
+	}
+	return token, raw, nil
+}
+
+func (sv *authService) toAuthResponse(userID string, role string, sessionID string,
+	refreshToken string) base.AuthResponse {
+	accessToken := sv.jwtService.GenerateToken(userID, role, sessionID)
+	return base.CreateAuthResponse(accessToken, refreshToken, sv.jwtService.GetAccessTokenTTL(), role)
+}
+
+// revokedTokenError refuses a revoked token presented again. An already
+// rotated one means either the legitimate client or an attacker holds a
+// stale copy, and we cannot tell which, so every token of the family is
+// revoked. Tokens revoked on purpose, like by logging out, are just refused.
+func (sv *authService) revokedTokenError(ctx context.Context, token entity.RefreshToken) error {
+	if token.RevokedReason == nil || *token.RevokedReason != constant.EnumRefreshTokenRevokedRotated {
+		return errs.ErrRefreshTokenRevoked
+	}
+
+	logger.Warn("Refresh token reuse detected for user %s (family %s), revoking family",
+		token.UserID, token.FamilyID)
+
+	err := sv.refreshTokenRepository.RevokeRefreshTokenFamily(ctx, nil, token.FamilyID.String(),
+		constant.EnumRefreshTokenRevokedReuse)
+	if err != nil {
+		return err
+	}
+	return errs.ErrRefreshTokenReused
+}
+
+// endSession ends a session of a user together with its refresh tokens and
+// every access token issued for it.
+func (sv *authService) endSession(ctx context.Context, tx *gorm.DB, userID uuid.UUID, sessionID string) error {
+	sid, err := uuid.Parse(sessionID)
+	if err != nil {
+		return errs.ErrSessionNotFound
+	}
+
+	if err := sv.sessionRepository.EndSession(ctx, tx, userID.String(), sessionID); err != nil {
+		return err
+	}
+
+	err = sv.refreshTokenRepository.RevokeRefreshTokenFamily(ctx, tx, sessionID,
+		constant.EnumRefreshTokenRevokedSessionEnded)
+	if err != nil {
+		return err
+	}
+
+	return sv.revocationRepository.CreateTokenRevocation(ctx, tx, entity.TokenRevocation{
+		UserID:    userID,
+		SessionID: &sid,
+		// No token of the session is issued after now, so all of them are
+		// expired by then
+		ExpiresAt: time.Now().Add(sv.jwtService.GetAccessTokenTTL()),
+	})
+}
+
+// ============== Token Issuing ==============
+
+func (sv *authService) IssueTokens(ctx context.Context, userID string, role string,
+	client dto.UserSessionClient) (resp base.AuthResponse, err error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return base.AuthResponse{}, err
+	}
+
+	// The session ID doubles as the refresh token family
+	token, raw, err := sv.newRefreshToken(uid, uuid.New())
+	if err != nil {
+		return base.AuthResponse{}, err
+	}
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return base.AuthResponse{}, err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	_, err = sv.sessionRepository.CreateSession(ctx, tx, entity.Session{
+		ID:         token.FamilyID,
+		UserID:     uid,
+		UserAgent:  client.UserAgent,
+		IPAddress:  client.IPAddress,
+		LastSeenAt: time.Now(),
+		ExpiresAt:  token.ExpiresAt,
+	})
+	if err != nil {
+		return base.AuthResponse{}, err
+	}
+
+	if _, err = sv.refreshTokenRepository.CreateRefreshToken(ctx, tx, token); err != nil {
+		return base.AuthResponse{}, err
+	}
+
+	return sv.toAuthResponse(userID, role, token.FamilyID.String(), raw), nil
+}
+
+func (sv *authService) RefreshTokens(ctx context.Context, req dto.UserRefreshTokenRequest) (base.AuthResponse, error) {
+	stored, err := sv.refreshTokenRepository.GetRefreshTokenByHash(ctx, nil, util.HashToken(req.RefreshToken))
+	if err != nil {
+		return base.AuthResponse{}, err
+	}
+
+	if stored.RevokedAt != nil {
+		return base.AuthResponse{}, sv.revokedTokenError(ctx, stored)
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return base.AuthResponse{}, errs.ErrRefreshTokenExpired
+	}
+
+	// Re-read the user so role changes and deletions take effect on refresh
+	user, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrID, stored.UserID.String())
+	if err != nil {
+		return base.AuthResponse{}, err
+	}
+
+	// A password change ends every session started before it
+	if user.PasswordChangedAt != nil && !stored.CreatedAt.After(*user.PasswordChangedAt) {
+		return base.AuthResponse{}, errs.ErrRefreshTokenRevoked
+	}
+
+	resp, err := sv.rotateRefreshToken(ctx, stored, user)
+	if errors.Is(err, errs.ErrRefreshTokenReused) {
+		// Lost a race against another request presenting the same token, or
+		// against the token being revoked
+		stored, err = sv.refreshTokenRepository.GetRefreshTokenByHash(ctx, nil, stored.TokenHash)
+		if err != nil {
+			return base.AuthResponse{}, err
+		}
+		return base.AuthResponse{}, sv.revokedTokenError(ctx, stored)
+	}
+	return resp, err
+}
+
+func (sv *authService) rotateRefreshToken(ctx context.Context, stored entity.RefreshToken,
+	user entity.User) (resp base.AuthResponse, err error) {
+	next, raw, err := sv.newRefreshToken(user.ID, stored.FamilyID)
+	if err != nil {
+		return base.AuthResponse{}, err
+	}
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return base.AuthResponse{}, err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.refreshTokenRepository.RevokeRefreshToken(ctx, tx, stored.ID.String(), &next.ID); err != nil {
+		return base.AuthResponse{}, err
+	}
+
+	if _, err = sv.refreshTokenRepository.CreateRefreshToken(ctx, tx, next); err != nil {
+		return base.AuthResponse{}, err
+	}
+
+	// Families started before sessions existed have no session to extend
+	sessionID := stored.FamilyID.String()
+	if err = sv.sessionRepository.ExtendSession(ctx, tx, sessionID, time.Now(), next.ExpiresAt); err != nil {
+		return base.AuthResponse{}, err
+	}
+
+	return sv.toAuthResponse(user.ID.String(), user.Role, sessionID, raw), nil
+}
+
+// ============== Revocation ==============
+
+func (sv *authService) VerifyAccessToken(ctx context.Context, claims *TokenClaims) error {
+	revoked, err := sv.revocationRepository.IsTokenRevoked(ctx, claims.UserID, claims.TokenID,
+		claims.SessionID, claims.IssuedAt)
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return errs.ErrAccessTokenRevoked
+	}
+
+	// Signing the impersonator out everywhere ends their impersonation too
+	if claims.ActorID != "" {
+		revoked, err = sv.revocationRepository.IsTokenRevoked(ctx, claims.ActorID, "", "", claims.IssuedAt)
+		if err != nil {
+			return err
+		}
+		if revoked {
+			return errs.ErrAccessTokenRevoked
+		}
+	}
+
+	if claims.SessionID != "" {
+		// A failed write only costs accuracy of last_seen_at, the request goes on
+		now := time.Now()
+		staleBefore := now.Add(-constant.SessionLastSeenInterval)
+		if err := sv.sessionRepository.TouchSession(ctx, nil, claims.SessionID, now, staleBefore); err != nil {
+			logger.Warn("Failed to record activity of session %s: %v", claims.SessionID, err)
+		}
+	}
+	return nil
+}
+
+func (sv *authService) Logout(ctx context.Context, req dto.UserLogoutRequest) (err error) {
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return err
+	}
+
+	var stored entity.RefreshToken
+	if req.RefreshToken != "" {
+		stored, err = sv.refreshTokenRepository.GetRefreshTokenByHash(ctx, nil, util.HashToken(req.RefreshToken))
+		if err != nil {
+			return err
+		}
+		// Never let one user end another user's session
+		if stored.UserID != userID {
+			return errs.ErrRefreshTokenInvalid
+		}
+	}
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	tokenID := req.TokenID
+	err = sv.revocationRepository.CreateTokenRevocation(ctx, tx, entity.TokenRevocation{
+		UserID:    userID,
+		TokenID:   &tokenID,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	if req.RefreshToken != "" {
+		err = sv.refreshTokenRepository.RevokeRefreshTokenFamily(ctx, tx, stored.FamilyID.String(),
+			constant.EnumRefreshTokenRevokedLogout)
+		if err != nil {
+			return err
+		}
+	}
+
+	if req.SessionID != "" {
+		err = sv.endSession(ctx, tx, userID, req.SessionID)
+		// Already ended, e.g. from another device
+		if errors.Is(err, errs.ErrSessionNotFound) {
+			err = nil
+		}
+	}
+	return err
+}
+
+func (sv *authService) RevokeUserSessions(ctx context.Context, userID string) (err error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	// JWT "iat" has second precision, so a token issued within the current
+	// second carries a timestamp at or before the truncated instant.
+	return revokeUserTokens(ctx, tx, sv.refreshTokenRepository, sv.sessionRepository, sv.revocationRepository,
+		uid, time.Now().Truncate(time.Second), sv.jwtService.GetAccessTokenTTL())
+}
+
+// revokeUserTokens ends every session of a user within tx: refresh tokens
+// are revoked and access tokens issued up to revokedBefore stop being
+// accepted. accessTokenTTL bounds how long the revocation has to be kept.
+func revokeUserTokens(ctx context.Context, tx *gorm.DB, refreshTokenR repositoryiface.RefreshTokenRepository,
+	sessionR repositoryiface.SessionRepository, revocationR repositoryiface.TokenRevocationRepository,
+	userID uuid.UUID, revokedBefore time.Time, accessTokenTTL time.Duration) error {
+	err := refreshTokenR.RevokeUserRefreshTokens(ctx, tx, userID.String(),
+		constant.EnumRefreshTokenRevokedSessionsRevoked)
+	if err != nil {
+		return err
+	}
+
+	if err := sessionR.EndUserSessions(ctx, tx, userID.String()); err != nil {
+		return err
+	}
+
+	return revocationR.CreateTokenRevocation(ctx, tx, entity.TokenRevocation{
+		UserID:        userID,
+		RevokedBefore: &revokedBefore,
+		// Any access token issued before now is expired by then
+		ExpiresAt: time.Now().Add(accessTokenTTL),
+	})
+}
+
+// ============== Sessions ==============
+
+func (sv *authService) GetUserSessions(ctx context.Context, userID string,
+	currentSessionID string) ([]dto.UserSessionResponse, error) {
+	sessions, err := sv.sessionRepository.GetActiveUserSessions(ctx, nil, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionsResp := make([]dto.UserSessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		sessionsResp = append(sessionsResp, dto.UserSessionResponse{
+			ID:         session.ID.String(),
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.ID.String() == currentSessionID,
+		})
+	}
+	return sessionsResp, nil
+}
+
+func (sv *authService) EndUserSession(ctx context.Context, req dto.UserSessionEndRequest) (err error) {
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	return sv.endSession(ctx, tx, userID, req.SessionID)
+}
@@ -18,6 +18,8 @@ type categoryService struct {
 	productRepository  repositoryiface.ProductRepository
 	categoryQuery      queryiface.CategoryQuery
 	productQuery       queryiface.ProductQuery
+	txRepository       repositoryiface.TxRepository
+	auditLogRepository repositoryiface.AuditLogRepository
 }
 
 type CategoryService interface {
@@ -33,16 +35,20 @@ func NewCategoryService(
 	productR repositoryiface.ProductRepository,
 	categoryQ queryiface.CategoryQuery,
 	productQ queryiface.ProductQuery,
+	txR repositoryiface.TxRepository,
+	auditLogR repositoryiface.AuditLogRepository,
 ) CategoryService {
 	return &categoryService{
 		categoryRepository: categoryR,
 		productRepository:  productR,
 		categoryQuery:      categoryQ,
 		productQuery:       productQ,
+		txRepository:       txR,
+		auditLogRepository: auditLogR,
 	}
 }
 
-func (sv *categoryService) toCategoryResponse(category entity.Category) dto.CategoryResponse {
+func toCategoryResponse(category entity.Category) dto.CategoryResponse {
 	return dto.CategoryResponse{
 		ID:          category.ID.String(),
 		Name:        category.Name,
@@ -50,7 +56,8 @@ func (sv *categoryService) toCategoryResponse(category entity.Category) dto.Cate
 	}
 }
 
-func (sv *categoryService) CreateCategory(ctx context.Context, req dto.CategoryCreateRequest) (dto.CategoryResponse, error) {
+func (sv *categoryService) CreateCategory(ctx context.Context, req dto.CategoryCreateRequest) (
+	resp dto.CategoryResponse, err error) {
 	// Check if category name already exists
 	existing, err := sv.categoryRepository.GetCategoryByPrimaryKey(ctx, nil, constant.DBAttrName, req.Name)
 	if err != nil && err != errs.ErrCategoryNotFound {
@@ -65,12 +72,26 @@ func (sv *categoryService) CreateCategory(ctx context.Context, req dto.CategoryC
 		Description: req.Description,
 	}
 
-	newCategory, err := sv.categoryRepository.CreateCategory(ctx, nil, category)
+	tx, err := sv.txRepository.BeginTx(ctx)
 	if err != nil {
 		return dto.CategoryResponse{}, err
 	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
 
-	return sv.toCategoryResponse(newCategory), nil
+	newCategory, err := sv.categoryRepository.CreateCategory(ctx, tx, category)
+	if err != nil {
+		return dto.CategoryResponse{}, err
+	}
+
+	err = recordAudit(ctx, tx, sv.auditLogRepository, constant.EnumAuditActionCreate,
+		constant.EnumAuditEntityCategory, newCategory.ID.String(), nil, newCategory)
+	if err != nil {
+		return dto.CategoryResponse{}, err
+	}
+
+	return toCategoryResponse(newCategory), nil
 }
 
 func (sv *categoryService) GetAllCategories(ctx context.Context, req dto.CategoryGetsRequest) (
@@ -82,7 +103,7 @@ func (sv *categoryService) GetAllCategories(ctx context.Context, req dto.Categor
 	}
 
 	for _, category := range categories {
-		categoriesResp = append(categoriesResp, sv.toCategoryResponse(category))
+		categoriesResp = append(categoriesResp, toCategoryResponse(category))
 	}
 	return categoriesResp, pageResp, nil
 }
@@ -92,10 +113,11 @@ func (sv *categoryService) GetCategoryByID(ctx context.Context, id string) (dto.
 	if err != nil {
 		return dto.CategoryResponse{}, err
 	}
-	return sv.toCategoryResponse(category), nil
+	return toCategoryResponse(category), nil
 }
 
-func (sv *categoryService) UpdateCategory(ctx context.Context, req dto.CategoryUpdateRequest) (dto.CategoryResponse, error) {
+func (sv *categoryService) UpdateCategory(ctx context.Context, req dto.CategoryUpdateRequest) (
+	resp dto.CategoryResponse, err error) {
 	category, err := sv.categoryRepository.GetCategoryByID(ctx, nil, req.ID)
 	if err != nil {
 		return dto.CategoryResponse{}, err
@@ -118,16 +140,34 @@ func (sv *categoryService) UpdateCategory(ctx context.Context, req dto.CategoryU
 		Description: req.Description,
 	}
 
-	err = sv.categoryRepository.UpdateCategory(ctx, nil, categoryEdit)
+	tx, err := sv.txRepository.BeginTx(ctx)
 	if err != nil {
 		return dto.CategoryResponse{}, err
 	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.categoryRepository.UpdateCategory(ctx, tx, categoryEdit); err != nil {
+		return dto.CategoryResponse{}, err
+	}
 
-	return sv.toCategoryResponse(categoryEdit), nil
+	after, err := sv.categoryRepository.GetCategoryByID(ctx, tx, req.ID)
+	if err != nil {
+		return dto.CategoryResponse{}, err
+	}
+
+	err = recordAudit(ctx, tx, sv.auditLogRepository, constant.EnumAuditActionUpdate,
+		constant.EnumAuditEntityCategory, req.ID, category, after)
+	if err != nil {
+		return dto.CategoryResponse{}, err
+	}
+
+	return toCategoryResponse(categoryEdit), nil
 }
 
-func (sv *categoryService) DeleteCategory(ctx context.Context, id string) error {
-	_, err := sv.categoryRepository.GetCategoryByID(ctx, nil, id)
+func (sv *categoryService) DeleteCategory(ctx context.Context, id string) (err error) {
+	category, err := sv.categoryRepository.GetCategoryByID(ctx, nil, id)
 	if err != nil {
 		return err
 	}
@@ -147,5 +187,18 @@ func (sv *categoryService) DeleteCategory(ctx context.Context, id string) error
 		return errs.ErrCategoryHasProducts
 	}
 
-	return sv.categoryRepository.DeleteCategoryByID(ctx, nil, id)
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.categoryRepository.DeleteCategoryByID(ctx, tx, id); err != nil {
+		return err
+	}
+
+	return recordAudit(ctx, tx, sv.auditLogRepository, constant.EnumAuditActionDelete,
+		constant.EnumAuditEntityCategory, id, category, nil)
 }
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	maileriface "myapp/core/interface/mailer"
+	repositoryiface "myapp/core/interface/repository"
+	"myapp/support/constant"
+	"myapp/support/logger"
+	"myapp/support/util"
+
+	"github.com/google/uuid"
+)
+
+type emailVerificationService struct {
+	userRepository         repositoryiface.UserRepository
+	verificationRepository repositoryiface.EmailVerificationTokenRepository
+	txRepository           repositoryiface.TxRepository
+	mailer                 maileriface.Mailer
+	tokenTTL               time.Duration
+	verifyURL              string
+	policy                 string
+}
+
+type EmailVerificationService interface {
+	// SendVerificationEmail mails a fresh verification link to the user,
+	// invalidating any link sent before.
+	SendVerificationEmail(ctx context.Context, userID string) error
+	// ResendVerificationEmail is the public variant of SendVerificationEmail.
+	// It succeeds without sending anything for unknown or already verified
+	// addresses, so it cannot be used to probe for accounts.
+	ResendVerificationEmail(ctx context.Context, req dto.UserVerifyEmailResendRequest) error
+	VerifyEmail(ctx context.Context, req dto.UserVerifyEmailRequest) error
+	// CheckEmailVerified returns ErrEmailNotVerified when the configured
+	// policy requires a verified address at the given checkpoint, which is
+	// either EnumEmailVerificationLogin or EnumEmailVerificationRoutes.
+	CheckEmailVerified(ctx context.Context, userID string, checkpoint string) error
+}
+
+func NewEmailVerificationService(userR repositoryiface.UserRepository,
+	verificationR repositoryiface.EmailVerificationTokenRepository, txR repositoryiface.TxRepository,
+	mailer maileriface.Mailer,
+) EmailVerificationService {
+	policy := util.GetEnv("EMAIL_VERIFICATION_POLICY", constant.EnumEmailVerificationNone)
+	switch policy {
+	case constant.EnumEmailVerificationNone, constant.EnumEmailVerificationLogin, constant.EnumEmailVerificationRoutes:
+	default:
+		logger.Warn("Invalid EMAIL_VERIFICATION_POLICY %q, using %q", policy, constant.EnumEmailVerificationNone)
+		policy = constant.EnumEmailVerificationNone
+	}
+
+	return &emailVerificationService{
+		userRepository:         userR,
+		verificationRepository: verificationR,
+		txRepository:           txR,
+		mailer:                 mailer,
+		tokenTTL:               util.GetEnvDuration("EMAIL_VERIFICATION_TOKEN_TTL", constant.DefaultEmailVerificationTokenTTL),
+		verifyURL:              util.GetEnv("EMAIL_VERIFICATION_URL", constant.DefaultEmailVerificationURL),
+		policy:                 policy,
+	}
+}
+
+// ============== Helper Functions ==============
+
+func (sv *emailVerificationService) verifyLink(token string) (string, error) {
+	link, err := url.Parse(sv.verifyURL)
+	if err != nil {
+		return "", err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
+
+func (sv *emailVerificationService) sendVerificationEmail(ctx context.Context, user entity.User) (err error) {
+	raw, err := util.GenerateRandomToken(constant.EmailVerificationTokenBytes)
+	if err != nil {
+		return err
+	}
+	link, err := sv.verifyLink(raw)
+	if err != nil {
+		return err
+	}
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.verificationRepository.InvalidateUserEmailVerificationTokens(ctx, tx, user.ID.String()); err != nil {
+		return err
+	}
+
+	_, err = sv.verificationRepository.CreateEmailVerificationToken(ctx, tx, entity.EmailVerificationToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: util.HashToken(raw),
+		ExpiresAt: time.Now().Add(sv.tokenTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Sent inside the transaction so the token is discarded if mailing fails
+	err = sv.mailer.Send(ctx, maileriface.Message{
+		To:      []string{user.Email},
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below. "+
+			"It expires in %s.\n\n%s\n\n"+
+			"If you did not create an account, you can ignore this email.\n",
+			user.Name, sv.tokenTTL, link),
+	})
+	return err
+}
+
+// ============== Email Verification ==============
+
+func (sv *emailVerificationService) SendVerificationEmail(ctx context.Context, userID string) error {
+	user, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrID, userID)
+	if err != nil {
+		return err
+	}
+	return sv.sendVerificationEmail(ctx, user)
+}
+
+func (sv *emailVerificationService) ResendVerificationEmail(ctx context.Context,
+	req dto.UserVerifyEmailResendRequest) error {
+	user, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrEmail, req.Email)
+	if errors.Is(err, errs.ErrUserNotFound) {
+		logger.Debug("Verification email requested for unknown email %s", req.Email)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+	return sv.sendVerificationEmail(ctx, user)
+}
+
+func (sv *emailVerificationService) VerifyEmail(ctx context.Context, req dto.UserVerifyEmailRequest) (err error) {
+	token, err := sv.verificationRepository.GetEmailVerificationTokenByHash(ctx, nil, util.HashToken(req.Token))
+	if err != nil {
+		return err
+	}
+
+	if token.UsedAt != nil {
+		return errs.ErrEmailVerificationTokenInvalid
+	}
+
+	if time.Now().After(token.ExpiresAt) {
+		return errs.ErrEmailVerificationTokenExpired
+	}
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.verificationRepository.UseEmailVerificationToken(ctx, tx, token.ID.String()); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	userEdit := entity.User{
+		ID:              token.UserID,
+		EmailVerifiedAt: &now,
+	}
+	return sv.userRepository.UpdateUser(ctx, tx, userEdit)
+}
+
+func (sv *emailVerificationService) CheckEmailVerified(ctx context.Context, userID string, checkpoint string) error {
+	switch {
+	case sv.policy == constant.EnumEmailVerificationNone:
+		return nil
+	case sv.policy == constant.EnumEmailVerificationRoutes && checkpoint != constant.EnumEmailVerificationRoutes:
+		return nil
+	}
+
+	user, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrID, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt == nil {
+		return errs.ErrEmailNotVerified
+	}
+	return nil
+}
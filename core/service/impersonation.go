@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	repositoryiface "myapp/core/interface/repository"
+	"myapp/support/base"
+	"myapp/support/constant"
+	"myapp/support/logger"
+	"myapp/support/util"
+)
+
+type impersonationService struct {
+	jwtService     JWTService
+	userRepository repositoryiface.UserRepository
+	roleRepository repositoryiface.RoleRepository
+	tokenTTL       time.Duration
+}
+
+type ImpersonationService interface {
+	// Impersonate mints a short-lived access token that acts as the target
+	// user and names the actor in its "act" claim. No refresh token is
+	// issued, so the impersonation ends with the token. Users whose role has
+	// permissions the actor's role lacks cannot be impersonated.
+	Impersonate(ctx context.Context, req dto.UserImpersonateRequest) (base.AuthResponse, error)
+}
+
+func NewImpersonationService(jwtS JWTService, userR repositoryiface.UserRepository,
+	roleR repositoryiface.RoleRepository) ImpersonationService {
+	return &impersonationService{
+		jwtService:     jwtS,
+		userRepository: userR,
+		roleRepository: roleR,
+		tokenTTL:       util.GetEnvDuration("IMPERSONATION_TOKEN_TTL", constant.DefaultImpersonationTokenTTL),
+	}
+}
+
+// ============== Helper Functions ==============
+
+// grantsAtMost reports whether every permission of target is also held by
+// actor, so impersonating cannot be used to gain permissions.
+func grantsAtMost(target entity.Role, actor entity.Role) bool {
+	held := make(map[string]bool, len(actor.Permissions))
+	for _, permission := range actor.Permissions {
+		held[permission.Name] = true
+	}
+	for _, permission := range target.Permissions {
+		if !held[permission.Name] {
+			return false
+		}
+	}
+	return true
+}
+
+func (sv *impersonationService) Impersonate(ctx context.Context,
+	req dto.UserImpersonateRequest) (base.AuthResponse, error) {
+	if req.ActorID == req.UserID {
+		return base.AuthResponse{}, errs.ErrImpersonateSelf
+	}
+
+	user, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrID, req.UserID)
+	if err != nil {
+		return base.AuthResponse{}, err
+	}
+
+	actorRole, err := sv.roleRepository.GetRoleByPrimaryKey(ctx, nil, constant.DBAttrName, req.ActorRole)
+	if err != nil {
+		return base.AuthResponse{}, err
+	}
+	targetRole, err := sv.roleRepository.GetRoleByPrimaryKey(ctx, nil, constant.DBAttrName, user.Role)
+	if err != nil {
+		return base.AuthResponse{}, err
+	}
+	if !grantsAtMost(targetRole, actorRole) {
+		return base.AuthResponse{}, errs.ErrImpersonationNotAllowed
+	}
+
+	logger.Info("User %s started impersonating user %s", req.ActorID, req.UserID)
+	token := sv.jwtService.GenerateImpersonationToken(user.ID.String(), user.Role, req.ActorID, sv.tokenTTL)
+	return base.CreateAuthResponse(token, "", sv.tokenTTL, user.Role), nil
+}
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	maileriface "myapp/core/interface/mailer"
+	repositoryiface "myapp/core/interface/repository"
+	"myapp/support/constant"
+	"myapp/support/logger"
+	"myapp/support/util"
+
+	"github.com/google/uuid"
+)
+
+type invitationService struct {
+	invitationRepository repositoryiface.InvitationRepository
+	userRepository       repositoryiface.UserRepository
+	roleRepository       repositoryiface.RoleRepository
+	txRepository         repositoryiface.TxRepository
+	mailer               maileriface.Mailer
+	passwordPolicy       PasswordPolicy
+	invitationTTL        time.Duration
+	invitationURL        string
+}
+
+type InvitationService interface {
+	// CreateInvitation mails a single-use sign up link to the email. Roles
+	// with permissions the inviter's role lacks cannot be handed out.
+	CreateInvitation(ctx context.Context, req dto.InvitationCreateRequest) (dto.InvitationResponse, error)
+	GetAllInvitations(ctx context.Context) ([]dto.InvitationResponse, error)
+	// ResendInvitation mails a fresh link with a new expiry. Links sent
+	// before stop working.
+	ResendInvitation(ctx context.Context, id string) (dto.InvitationResponse, error)
+	RevokeInvitation(ctx context.Context, id string) error
+	// AcceptInvitation consumes the link and creates the account with the
+	// invited email and role. The email counts as verified.
+	AcceptInvitation(ctx context.Context, req dto.InvitationAcceptRequest) (dto.UserResponse, error)
+}
+
+func NewInvitationService(invitationR repositoryiface.InvitationRepository,
+	userR repositoryiface.UserRepository, roleR repositoryiface.RoleRepository,
+	txR repositoryiface.TxRepository, mailer maileriface.Mailer, passwordPolicy PasswordPolicy,
+) InvitationService {
+	return &invitationService{
+		invitationRepository: invitationR,
+		userRepository:       userR,
+		roleRepository:       roleR,
+		txRepository:         txR,
+		mailer:               mailer,
+		passwordPolicy:       passwordPolicy,
+		invitationTTL:        util.GetEnvDuration("INVITATION_TTL", constant.DefaultInvitationTTL),
+		invitationURL:        util.GetEnv("INVITATION_URL", constant.DefaultInvitationURL),
+	}
+}
+
+// ============== Helper Functions ==============
+
+func invitationToResponse(invitation entity.Invitation) dto.InvitationResponse {
+	status := dto.InvitationStatusPending
+	switch {
+	case invitation.AcceptedAt != nil:
+		status = dto.InvitationStatusAccepted
+	case invitation.RevokedAt != nil:
+		status = dto.InvitationStatusRevoked
+	case time.Now().After(invitation.ExpiresAt):
+		status = dto.InvitationStatusExpired
+	}
+
+	return dto.InvitationResponse{
+		ID:          invitation.ID.String(),
+		Email:       invitation.Email,
+		Role:        invitation.Role,
+		Status:      status,
+		InvitedByID: invitation.InvitedByID.String(),
+		ExpiresAt:   invitation.ExpiresAt,
+		AcceptedAt:  invitation.AcceptedAt,
+		RevokedAt:   invitation.RevokedAt,
+		CreatedAt:   invitation.CreatedAt,
+	}
+}
+
+func (sv *invitationService) invitationLink(token string) (string, error) {
+	link, err := url.Parse(sv.invitationURL)
+	if err != nil {
+		return "", err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
+
+func (sv *invitationService) sendInvitation(ctx context.Context, invitation entity.Invitation,
+	token string) error {
+	link, err := sv.invitationLink(token)
+	if err != nil {
+		return err
+	}
+
+	return sv.mailer.Send(ctx, maileriface.Message{
+		To:      []string{invitation.Email},
+		Subject: "You are invited",
+		Body: fmt.Sprintf("Hi,\n\nYou have been invited to create an account. Use the link below "+
+			"to choose your password. It expires in %s and can only be used once.\n\n%s\n\n"+
+			"If you did not expect this invitation, you can ignore this email.\n",
+			sv.invitationTTL, link),
+	})
+}
+
+// ============== Invitations ==============
+
+func (sv *invitationService) CreateInvitation(ctx context.Context,
+	req dto.InvitationCreateRequest) (resp dto.InvitationResponse, err error) {
+	invitedByID, err := uuid.Parse(req.InvitedByID)
+	if err != nil {
+		return dto.InvitationResponse{}, err
+	}
+
+	role, err := sv.roleRepository.GetRoleByPrimaryKey(ctx, nil, constant.DBAttrName, req.Role)
+	if err != nil {
+		return dto.InvitationResponse{}, err
+	}
+	inviterRole, err := sv.roleRepository.GetRoleByPrimaryKey(ctx, nil, constant.DBAttrName, req.InvitedByRole)
+	if err != nil {
+		return dto.InvitationResponse{}, err
+	}
+	if !grantsAtMost(role, inviterRole) {
+		return dto.InvitationResponse{}, errs.ErrInvitationRoleNotAllowed
+	}
+
+	_, err = sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrEmail, req.Email)
+	if err == nil {
+		return dto.InvitationResponse{}, errs.ErrEmailAlreadyExists
+	}
+	if !errors.Is(err, errs.ErrUserNotFound) {
+		return dto.InvitationResponse{}, err
+	}
+
+	_, err = sv.invitationRepository.GetPendingInvitationByEmail(ctx, nil, req.Email, time.Now())
+	if err == nil {
+		return dto.InvitationResponse{}, errs.ErrInvitationAlreadyPending
+	}
+	if !errors.Is(err, errs.ErrInvitationNotFound) {
+		return dto.InvitationResponse{}, err
+	}
+
+	raw, err := util.GenerateRandomToken(constant.InvitationTokenBytes)
+	if err != nil {
+		return dto.InvitationResponse{}, err
+	}
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return dto.InvitationResponse{}, err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	invitation, err := sv.invitationRepository.CreateInvitation(ctx, tx, entity.Invitation{
+		ID:          uuid.New(),
+		Email:       req.Email,
+		Role:        role.Name,
+		TokenHash:   util.HashToken(raw),
+		InvitedByID: invitedByID,
+		ExpiresAt:   time.Now().Add(sv.invitationTTL),
+	})
+	if err != nil {
+		return dto.InvitationResponse{}, err
+	}
+
+	// Sent inside the transaction so the invitation is discarded if mailing fails
+	if err = sv.sendInvitation(ctx, invitation, raw); err != nil {
+		return dto.InvitationResponse{}, err
+	}
+
+	logger.Info("User %s invited %s as %s", req.InvitedByID, req.Email, role.Name)
+	return invitationToResponse(invitation), nil
+}
+
+func (sv *invitationService) GetAllInvitations(ctx context.Context) ([]dto.InvitationResponse, error) {
+	invitations, err := sv.invitationRepository.GetAllInvitations(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	invitationsResp := make([]dto.InvitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		invitationsResp = append(invitationsResp, invitationToResponse(invitation))
+	}
+	return invitationsResp, nil
+}
+
+func (sv *invitationService) ResendInvitation(ctx context.Context,
+	id string) (resp dto.InvitationResponse, err error) {
+	invitation, err := sv.invitationRepository.GetInvitationByID(ctx, nil, id)
+	if err != nil {
+		return dto.InvitationResponse{}, err
+	}
+
+	raw, err := util.GenerateRandomToken(constant.InvitationTokenBytes)
+	if err != nil {
+		return dto.InvitationResponse{}, err
+	}
+	invitation.TokenHash = util.HashToken(raw)
+	invitation.ExpiresAt = time.Now().Add(sv.invitationTTL)
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return dto.InvitationResponse{}, err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	// Accepted and revoked invitations are not renewed
+	err = sv.invitationRepository.RenewInvitation(ctx, tx, id, invitation.TokenHash, invitation.ExpiresAt)
+	if err != nil {
+		return dto.InvitationResponse{}, err
+	}
+
+	if err = sv.sendInvitation(ctx, invitation, raw); err != nil {
+		return dto.InvitationResponse{}, err
+	}
+
+	return invitationToResponse(invitation), nil
+}
+
+func (sv *invitationService) RevokeInvitation(ctx context.Context, id string) error {
+	if err := sv.invitationRepository.RevokeInvitation(ctx, nil, id); err != nil {
+		return err
+	}
+
+	logger.Info("Invitation %s revoked", id)
+	return nil
+}
+
+func (sv *invitationService) AcceptInvitation(ctx context.Context,
+	req dto.InvitationAcceptRequest) (resp dto.UserResponse, err error) {
+	invitation, err := sv.invitationRepository.GetInvitationByHash(ctx, nil, util.HashToken(req.Token))
+	if err != nil {
+		return dto.UserResponse{}, err
+	}
+
+	if invitation.AcceptedAt != nil || invitation.RevokedAt != nil {
+		return dto.UserResponse{}, errs.ErrInvitationInvalid
+	}
+
+	if time.Now().After(invitation.ExpiresAt) {
+		return dto.UserResponse{}, errs.ErrInvitationExpired
+	}
+
+	// The role may have been deleted since the invitation was sent
+	if _, err = sv.roleRepository.GetRoleByPrimaryKey(ctx, nil, constant.DBAttrName, invitation.Role); err != nil {
+		return dto.UserResponse{}, err
+	}
+
+	// The address may have signed up on its own meanwhile
+	_, err = sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrEmail, invitation.Email)
+	if err == nil {
+		return dto.UserResponse{}, errs.ErrEmailAlreadyExists
+	}
+	if !errors.Is(err, errs.ErrUserNotFound) {
+		return dto.UserResponse{}, err
+	}
+
+	// validate before the invitation is consumed so the user can try again
+	if err = sv.passwordPolicy.Validate(req.Password, invitation.Email, req.Name); err != nil {
+		return dto.UserResponse{}, err
+	}
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return dto.UserResponse{}, err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.invitationRepository.AcceptInvitation(ctx, tx, invitation.ID.String()); err != nil {
+		return dto.UserResponse{}, err
+	}
+
+	// Following the link proves the invitee owns the address
+	now := time.Now()
+	user, err := sv.userRepository.CreateNewUser(ctx, tx, entity.User{
+		Name:            req.Name,
+		Email:           invitation.Email,
+		Password:        req.Password,
+		Role:            invitation.Role,
+		Provider:        constant.EnumProviderLocal,
+		EmailVerifiedAt: &now,
+	})
+	if err != nil {
+		return dto.UserResponse{}, err
+	}
+
+	logger.Info("Invitation %s accepted by new user %s", invitation.ID, user.ID)
+	return dto.UserResponse{
+		ID:              user.ID.String(),
+		Name:            user.Name,
+		Email:           user.Email,
+		Role:            user.Role,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+	}, nil
+}
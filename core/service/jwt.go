@@ -5,69 +5,151 @@ import (
 	"os"
 	"time"
 
+	"myapp/core/helper/dto"
 	"myapp/support/constant"
 	"myapp/support/logger"
+	"myapp/support/util"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type JWTService interface {
-	GenerateToken(id string, role string) string
+	// GenerateToken issues an access token. sessionID ties the token to a
+	// Session so it can be ended with it; it may be empty.
+	GenerateToken(id string, role string, sessionID string) string
+	// GenerateImpersonationToken issues an access token for user id on
+	// behalf of actorID, who is named in the "act" claim.
+	GenerateImpersonationToken(id string, role string, actorID string, ttl time.Duration) string
 	ValidateToken(token string) (*jwt.Token, error)
 	GetAttrByToken(token string) (string, string, error)
+	GetClaimsByToken(token string) (*TokenClaims, error)
+	GetAccessTokenTTL() time.Duration
+	// GetJWKS returns the public keys tokens are currently verified with,
+	// for other services to validate tokens without sharing a secret.
+	GetJWKS() dto.JWKSResponse
+}
+
+// TokenClaims is the typed view of an access token handed to callers that
+// need more than the user ID and role, e.g. revocation checks.
+type TokenClaims struct {
+	UserID    string
+	Role      string
+	TokenID   string
+	SessionID string
+	// ActorID is the user acting through an impersonation token, empty for
+	// regular tokens.
+	ActorID   string
+	IssuedAt  time.Time
+	ExpiresAt time.Time
 }
 
 type jwtCustomClaim struct {
-	ID   string `json:"id"`
-	Role string `json:"role"`
+	ID        string `json:"id"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid,omitempty"`
+	// Actor follows RFC 8693: it names who is really acting when the token
+	// was minted for impersonation.
+	Actor *actorClaim `json:"act,omitempty"`
+	// jwt.RegisteredClaims.ID is serialized as the "jti" claim and uniquely
+	// identifies each token so it can be revoked individually.
 	jwt.RegisteredClaims
 }
 
+type actorClaim struct {
+	Subject string `json:"sub"`
+}
+
 type jwtService struct {
-	secretKey string
-	issuer    string
+	signingKey       signingKey
+	verificationKeys map[string]verificationKey
+	jwks             dto.JWKSResponse
+	issuer           string
+	accessTokenTTL   time.Duration
 }
 
-func NewJWTService() JWTService {
-	return &jwtService{
-		secretKey: getSecretKey(),
-		issuer:    constant.EnumRoleAdmin,
+// NewJWTService loads the signing keys from the environment. It fails in
+// production when no private key is configured instead of falling back to
+// an ephemeral one, which would invalidate tokens on every restart.
+func NewJWTService() (JWTService, error) {
+	production := os.Getenv("APP_ENV") == "production"
+	signing, keys, err := loadJWTKeys(production)
+	if err != nil {
+		return nil, err
+	}
+	if !production && os.Getenv("JWT_PRIVATE_KEY") == "" && os.Getenv("JWT_PRIVATE_KEY_FILE") == "" {
+		logger.Warn("No JWT private key configured, using an ephemeral key; tokens will not survive a restart")
 	}
-}
 
-func getSecretKey() string {
-	secretKey := os.Getenv("JWT_SECRET")
-	if secretKey == "" {
-		secretKey = "jwt_secret_key"
+	sv := &jwtService{
+		signingKey:       signing,
+		verificationKeys: make(map[string]verificationKey, len(keys)),
+		jwks:             dto.JWKSResponse{Keys: make([]dto.JWK, 0, len(keys))},
+		issuer:           constant.EnumRoleAdmin,
+		accessTokenTTL:   util.GetEnvDuration("JWT_ACCESS_TOKEN_TTL", constant.DefaultAccessTokenTTL),
 	}
-	return secretKey
-}
-
-func (sv *jwtService) GenerateToken(id string, role string) string {
-	claims := &jwtCustomClaim{
-		id,
-		role,
-		jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute * 120)),
-			Issuer:    sv.issuer,
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+	for _, key := range keys {
+		sv.verificationKeys[key.kid] = key
+		sv.jwks.Keys = append(sv.jwks.Keys, key.jwk)
+	}
+	return sv, nil
+}
+
+func (sv *jwtService) GenerateToken(id string, role string, sessionID string) string {
+	return sv.signClaims(&jwtCustomClaim{
+		ID:               id,
+		Role:             role,
+		SessionID:        sessionID,
+		RegisteredClaims: sv.registeredClaims(sv.accessTokenTTL),
+	})
+}
+
+func (sv *jwtService) GenerateImpersonationToken(id string, role string, actorID string,
+	ttl time.Duration) string {
+	return sv.signClaims(&jwtCustomClaim{
+		ID:               id,
+		Role:             role,
+		Actor:            &actorClaim{Subject: actorID},
+		RegisteredClaims: sv.registeredClaims(ttl),
+	})
+}
+
+func (sv *jwtService) registeredClaims(ttl time.Duration) jwt.RegisteredClaims {
+	return jwt.RegisteredClaims{
+		ID:        uuid.NewString(),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		Issuer:    sv.issuer,
+		IssuedAt:  jwt.NewNumericDate(time.Now()),
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	t, err := token.SignedString([]byte(sv.secretKey))
+}
+
+func (sv *jwtService) signClaims(claims *jwtCustomClaim) string {
+	token := jwt.NewWithClaims(sv.signingKey.method, claims)
+	token.Header["kid"] = sv.signingKey.kid
+	t, err := token.SignedString(sv.signingKey.key)
 	if err != nil {
 		logger.Error("JWT token generation failed: %v", err)
 	}
 	return t
 }
 
+// keyFunc picks the verification key named by the token's kid header and
+// makes sure the token was signed with that key's algorithm, so a token can
+// never choose how it is verified.
+func (sv *jwtService) keyFunc(t_ *jwt.Token) (any, error) {
+	kid, _ := t_.Header["kid"].(string)
+	key, ok := sv.verificationKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if t_.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %v", t_.Header["alg"])
+	}
+	return key.key, nil
+}
+
 func (sv *jwtService) ValidateToken(token string) (*jwt.Token, error) {
-	return jwt.Parse(token, func(t_ *jwt.Token) (any, error) {
-		if _, ok := t_.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method %v", t_.Header["alg"])
-		}
-		return []byte(sv.secretKey), nil
-	})
+	return jwt.Parse(token, sv.keyFunc)
 }
 
 func (sv *jwtService) GetAttrByToken(token string) (string, string, error) {
@@ -80,3 +162,35 @@ func (sv *jwtService) GetAttrByToken(token string) (string, string, error) {
 	role := fmt.Sprintf("%v", claims["role"])
 	return id, role, nil
 }
+
+func (sv *jwtService) GetClaimsByToken(token string) (*TokenClaims, error) {
+	claims := &jwtCustomClaim{}
+	if _, err := jwt.ParseWithClaims(token, claims, sv.keyFunc); err != nil {
+		return nil, err
+	}
+
+	tokenClaims := &TokenClaims{
+		UserID:    claims.ID,
+		Role:      claims.Role,
+		TokenID:   claims.RegisteredClaims.ID,
+		SessionID: claims.SessionID,
+	}
+	if claims.Actor != nil {
+		tokenClaims.ActorID = claims.Actor.Subject
+	}
+	if claims.IssuedAt != nil {
+		tokenClaims.IssuedAt = claims.IssuedAt.Time
+	}
+	if claims.ExpiresAt != nil {
+		tokenClaims.ExpiresAt = claims.ExpiresAt.Time
+	}
+	return tokenClaims, nil
+}
+
+func (sv *jwtService) GetAccessTokenTTL() time.Duration {
+	return sv.accessTokenTTL
+}
+
+func (sv *jwtService) GetJWKS() dto.JWKSResponse {
+	return sv.jwks
+}
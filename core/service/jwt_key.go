@@ -0,0 +1,197 @@
+package service
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"myapp/core/helper/dto"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// signingKey is the private key new tokens are signed with.
+type signingKey struct {
+	kid    string
+	method jwt.SigningMethod
+	key    crypto.Signer
+}
+
+// verificationKey is a public key tokens are accepted from. The current
+// signing key is always one of them; retired keys stay verification-only
+// until every token they signed has expired.
+type verificationKey struct {
+	kid    string
+	method jwt.SigningMethod
+	key    crypto.PublicKey
+	jwk    dto.JWK
+}
+
+var errJWTKeyNotConfigured = errors.New("no JWT signing key configured, set JWT_PRIVATE_KEY or JWT_PRIVATE_KEY_FILE")
+
+// loadJWTKeys reads the signing key and any additional verification keys from
+// the environment. Outside production a throwaway Ed25519 key is generated
+// when none is configured, so local runs and tests work out of the box.
+func loadJWTKeys(production bool) (signingKey, []verificationKey, error) {
+	privatePEM, err := readPEMFromEnv("JWT_PRIVATE_KEY", "JWT_PRIVATE_KEY_FILE")
+	if err != nil {
+		return signingKey{}, nil, err
+	}
+
+	var signer crypto.Signer
+	switch {
+	case privatePEM != nil:
+		signer, err = parsePrivateKey(privatePEM)
+		if err != nil {
+			return signingKey{}, nil, fmt.Errorf("invalid JWT private key: %w", err)
+		}
+	case production:
+		return signingKey{}, nil, errJWTKeyNotConfigured
+	default:
+		_, signer, err = ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return signingKey{}, nil, err
+		}
+	}
+
+	current, err := newVerificationKey(signer.Public())
+	if err != nil {
+		return signingKey{}, nil, err
+	}
+	keys := []verificationKey{current}
+
+	// Retired public keys, still accepted during rotation
+	for _, path := range strings.Split(os.Getenv("JWT_PUBLIC_KEY_FILES"), ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return signingKey{}, nil, err
+		}
+		pub, err := parsePublicKey(data)
+		if err != nil {
+			return signingKey{}, nil, fmt.Errorf("invalid JWT public key %s: %w", path, err)
+		}
+		key, err := newVerificationKey(pub)
+		if err != nil {
+			return signingKey{}, nil, err
+		}
+		if key.kid != current.kid {
+			keys = append(keys, key)
+		}
+	}
+
+	return signingKey{kid: current.kid, method: current.method, key: signer}, keys, nil
+}
+
+func readPEMFromEnv(valueKey string, fileKey string) ([]byte, error) {
+	if val := os.Getenv(valueKey); val != "" {
+		// Allow single-line values with escaped newlines, as commonly
+		// produced by secret managers and .env files.
+		return []byte(strings.ReplaceAll(val, `\n`, "\n")), nil
+	}
+	if path := os.Getenv(fileKey); path != "" {
+		return os.ReadFile(path)
+	}
+	return nil, nil
+}
+
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case ed25519.PrivateKey:
+		return k, nil
+	default:
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+}
+
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	if block.Type == "RSA PUBLIC KEY" {
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+// newVerificationKey derives the JWK, signing method and key ID of a public
+// key. The key ID is the RFC 7638 thumbprint, so it is stable across
+// restarts and identical on every instance sharing the key.
+func newVerificationKey(pub crypto.PublicKey) (verificationKey, error) {
+	enc := base64.RawURLEncoding
+
+	var (
+		method jwt.SigningMethod
+		jwk    dto.JWK
+		// members required by RFC 7638, in lexicographic order
+		thumbprint any
+	)
+
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		method = jwt.SigningMethodRS256
+		n := enc.EncodeToString(k.N.Bytes())
+		e := enc.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+		jwk = dto.JWK{Kty: "RSA", N: n, E: e}
+		thumbprint = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{e, "RSA", n}
+	case ed25519.PublicKey:
+		method = jwt.SigningMethodEdDSA
+		x := enc.EncodeToString(k)
+		jwk = dto.JWK{Kty: "OKP", Crv: "Ed25519", X: x}
+		thumbprint = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{"Ed25519", "OKP", x}
+	default:
+		return verificationKey{}, fmt.Errorf("unsupported public key type %T", pub)
+	}
+
+	raw, err := json.Marshal(thumbprint)
+	if err != nil {
+		return verificationKey{}, err
+	}
+	sum := sha256.Sum256(raw)
+	kid := enc.EncodeToString(sum[:])
+
+	jwk.Kid = kid
+	jwk.Use = "sig"
+	jwk.Alg = method.Alg()
+	return verificationKey{kid: kid, method: method, key: pub, jwk: jwk}, nil
+}
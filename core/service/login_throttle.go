@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"myapp/core/entity"
+	errs "myapp/core/helper/errors"
+	repositoryiface "myapp/core/interface/repository"
+	"myapp/support/constant"
+	"myapp/support/logger"
+	"myapp/support/util"
+)
+
+type loginThrottleService struct {
+	throttleRepository repositoryiface.LoginThrottleRepository
+	userRepository     repositoryiface.UserRepository
+	window             time.Duration
+	delayAfter         int
+	delayBase          time.Duration
+	delayMax           time.Duration
+	accountThreshold   int
+	ipThreshold        int
+	lockoutDuration    time.Duration
+}
+
+type LoginThrottleService interface {
+	// CheckLogin returns ErrLoginLocked or ErrLoginThrottled, together with
+	// how long the client has to wait, when the account or the client IP may
+	// not attempt a login right now.
+	CheckLogin(ctx context.Context, email string, ip string) (time.Duration, error)
+	// RecordFailure counts a failed login against the account and the IP,
+	// delaying further attempts and eventually locking them out.
+	RecordFailure(ctx context.Context, email string, ip string) error
+	// RecordSuccess forgets the failures of the account. Failures of the IP
+	// are kept so one valid account does not reset a spraying client.
+	RecordSuccess(ctx context.Context, email string) error
+	UnlockUser(ctx context.Context, userID string) error
+}
+
+func NewLoginThrottleService(throttleR repositoryiface.LoginThrottleRepository,
+	userR repositoryiface.UserRepository) LoginThrottleService {
+	return &loginThrottleService{
+		throttleRepository: throttleR,
+		userRepository:     userR,
+		window:             util.GetEnvDuration("LOGIN_THROTTLE_WINDOW", constant.DefaultLoginThrottleWindow),
+		delayAfter:         util.GetEnvInt("LOGIN_DELAY_AFTER", constant.DefaultLoginDelayAfter),
+		delayBase:          util.GetEnvDuration("LOGIN_DELAY_BASE", constant.DefaultLoginDelayBase),
+		delayMax:           util.GetEnvDuration("LOGIN_DELAY_MAX", constant.DefaultLoginDelayMax),
+		accountThreshold:   util.GetEnvInt("LOGIN_LOCKOUT_THRESHOLD", constant.DefaultLoginLockoutThreshold),
+		ipThreshold:        util.GetEnvInt("LOGIN_IP_LOCKOUT_THRESHOLD", constant.DefaultLoginIPLockoutThreshold),
+		lockoutDuration:    util.GetEnvDuration("LOGIN_LOCKOUT_DURATION", constant.DefaultLoginLockoutDuration),
+	}
+}
+
+// ============== Helper Functions ==============
+
+func accountThrottleKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+// delayFor returns the wait imposed after the given number of failures,
+// doubling with every failure past delayAfter up to delayMax.
+func (sv *loginThrottleService) delayFor(failures int) time.Duration {
+	if failures < sv.delayAfter {
+		return 0
+	}
+
+	delay := sv.delayBase
+	for i := sv.delayAfter; i < failures && delay < sv.delayMax; i++ {
+		delay *= 2
+	}
+	return min(delay, sv.delayMax)
+}
+
+func (sv *loginThrottleService) recordFailure(ctx context.Context, key string, threshold int,
+	now time.Time) error {
+	throttle, err := sv.throttleRepository.RecordLoginFailure(ctx, nil, key, now, now.Add(-sv.window))
+	if err != nil {
+		return err
+	}
+
+	update := entity.LoginThrottle{Key: key}
+	if throttle.Failures >= threshold {
+		lockedUntil := now.Add(sv.lockoutDuration)
+		update.LockedUntil = &lockedUntil
+		logger.Warn("event=login_lockout key=%s failures=%d locked_until=%s",
+			key, throttle.Failures, lockedUntil.Format(time.RFC3339))
+	} else if delay := sv.delayFor(throttle.Failures); delay > 0 {
+		nextAttemptAt := now.Add(delay)
+		update.NextAttemptAt = &nextAttemptAt
+	}
+
+	return sv.throttleRepository.UpdateLoginThrottle(ctx, nil, update)
+}
+
+// ============== Login Throttling ==============
+
+func (sv *loginThrottleService) CheckLogin(ctx context.Context, email string, ip string) (time.Duration, error) {
+	throttles, err := sv.throttleRepository.GetLoginThrottles(ctx, nil,
+		[]string{accountThrottleKey(email), ipThrottleKey(ip)})
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	var (
+		wait     time.Duration
+		throttle error
+	)
+	for _, t := range throttles {
+		switch {
+		case t.LockedUntil != nil && t.LockedUntil.After(now):
+			logger.Warn("event=login_rejected_locked key=%s ip=%s locked_until=%s",
+				t.Key, ip, t.LockedUntil.Format(time.RFC3339))
+			wait = max(wait, t.LockedUntil.Sub(now))
+			throttle = errs.ErrLoginLocked
+		case t.NextAttemptAt != nil && t.NextAttemptAt.After(now):
+			wait = max(wait, t.NextAttemptAt.Sub(now))
+			if throttle == nil {
+				throttle = errs.ErrLoginThrottled
+			}
+		}
+	}
+	return wait, throttle
+}
+
+func (sv *loginThrottleService) RecordFailure(ctx context.Context, email string, ip string) error {
+	logger.Info("event=login_failed email=%s ip=%s", email, ip)
+
+	now := time.Now()
+	if err := sv.recordFailure(ctx, accountThrottleKey(email), sv.accountThreshold, now); err != nil {
+		return err
+	}
+	return sv.recordFailure(ctx, ipThrottleKey(ip), sv.ipThreshold, now)
+}
+
+func (sv *loginThrottleService) RecordSuccess(ctx context.Context, email string) error {
+	return sv.throttleRepository.DeleteLoginThrottle(ctx, nil, accountThrottleKey(email))
+}
+
+func (sv *loginThrottleService) UnlockUser(ctx context.Context, userID string) error {
+	user, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrID, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := sv.throttleRepository.DeleteLoginThrottle(ctx, nil, accountThrottleKey(user.Email)); err != nil {
+		return err
+	}
+	logger.Warn("event=login_unlocked user=%s email=%s", user.ID, user.Email)
+	return nil
+}
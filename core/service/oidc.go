@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"strings"
+	"time"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	oidciface "myapp/core/interface/oidc"
+	repositoryiface "myapp/core/interface/repository"
+	"myapp/support/constant"
+	"myapp/support/logger"
+	"myapp/support/util"
+
+	"github.com/google/uuid"
+	"golang.org/x/oauth2"
+)
+
+type oidcService struct {
+	providers          oidciface.Providers
+	stateRepository    repositoryiface.OIDCStateRepository
+	userRepository     repositoryiface.UserRepository
+	identityRepository repositoryiface.UserIdentityRepository
+	txRepository       repositoryiface.TxRepository
+	authService        AuthService
+	stateTTL           time.Duration
+}
+
+type OIDCService interface {
+	// BeginLogin records a pending login and returns the provider URL the
+	// browser has to be redirected to, with the state it has to keep.
+	BeginLogin(ctx context.Context, providerName string) (dto.OIDCLoginStart, error)
+	// CompleteLogin redeems the callback of BeginLogin and returns the user
+	// to sign in. The state has to come back from the browser that began
+	// the login, so nobody can sign a victim in to their own account. Identities are found by their provider subject; a new one
+	// is linked to the account with the email address the provider vouches
+	// for, and otherwise gets a new account.
+	CompleteLogin(ctx context.Context, req dto.OIDCCallbackRequest) (dto.UserResponse, error)
+}
+
+func NewOIDCService(providers oidciface.Providers, stateR repositoryiface.OIDCStateRepository,
+	userR repositoryiface.UserRepository, identityR repositoryiface.UserIdentityRepository,
+	txR repositoryiface.TxRepository, authS AuthService) OIDCService {
+	return &oidcService{
+		providers:          providers,
+		stateRepository:    stateR,
+		userRepository:     userR,
+		identityRepository: identityR,
+		txRepository:       txR,
+		authService:        authS,
+		stateTTL:           util.GetEnvDuration("OIDC_STATE_TTL", constant.DefaultOIDCStateTTL),
+	}
+}
+
+// ============== Helper Functions ==============
+
+func (sv *oidcService) provider(name string) (oidciface.Provider, error) {
+	p, ok := sv.providers[name]
+	if !ok {
+		return nil, errs.ErrOIDCProviderNotFound
+	}
+	return p, nil
+}
+
+// userForIdentity returns the account the identity signs in to, linking or
+// creating it as needed.
+func (sv *oidcService) userForIdentity(ctx context.Context, providerName string,
+	identity oidciface.Identity) (entity.User, error) {
+	if identity.Subject == "" {
+		return entity.User{}, errs.ErrOIDCIDTokenInvalid
+	}
+
+	linked, err := sv.identityRepository.GetUserIdentity(ctx, nil, providerName, identity.Subject)
+	switch {
+	case err == nil:
+		user, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrID, linked.UserID.String())
+		if !errors.Is(err, errs.ErrUserNotFound) {
+			return user, err
+		}
+		// The account is gone, so the identity starts over
+		if err := sv.identityRepository.DeleteUserIdentity(ctx, nil, linked.ID.String()); err != nil {
+			return entity.User{}, err
+		}
+	case !errors.Is(err, errs.ErrOIDCIdentityNotFound):
+		return entity.User{}, err
+	}
+
+	// Without a verified address anyone could claim someone else's account
+	if identity.Email == "" || !identity.EmailVerified {
+		return entity.User{}, errs.ErrOIDCEmailNotVerified
+	}
+
+	user, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrEmail, identity.Email)
+	if errors.Is(err, errs.ErrUserNotFound) {
+		return sv.createUser(ctx, providerName, identity)
+	}
+	if err != nil {
+		return entity.User{}, err
+	}
+	return sv.linkUser(ctx, providerName, identity, user)
+}
+
+// linkUser links the identity to the account with its email address. An
+// account that never verified the address may have been signed up by
+// someone else ahead of its owner, so the owner claims it: its password is
+// replaced and its sessions end, leaving the provider as the only way in.
+func (sv *oidcService) linkUser(ctx context.Context, providerName string, identity oidciface.Identity,
+	user entity.User) (entity.User, error) {
+	userEdit := entity.User{ID: user.ID}
+	// Password accounts are linked to the first provider they sign in with;
+	// an account that is already linked keeps its provider.
+	if user.Provider == "" || user.Provider == constant.EnumProviderLocal {
+		userEdit.Provider = providerName
+	}
+
+	claimed := user.EmailVerifiedAt == nil
+	if claimed {
+		password, err := util.GenerateRandomToken(constant.OIDCStateBytes)
+		if err != nil {
+			return entity.User{}, err
+		}
+		// The provider has verified the address just now
+		now := time.Now()
+		userEdit.Password = password
+		userEdit.EmailVerifiedAt = &now
+	}
+
+	if err := sv.saveLink(ctx, providerName, identity, userEdit); err != nil {
+		return entity.User{}, err
+	}
+	if claimed {
+		if err := sv.authService.RevokeUserSessions(ctx, user.ID.String()); err != nil {
+			return entity.User{}, err
+		}
+		logger.Warn("User %s claimed unverified account through login provider %s, password reset",
+			user.ID, providerName)
+	}
+	logger.Info("Linked user %s to login provider %s", user.ID, providerName)
+	return user, nil
+}
+
+func (sv *oidcService) saveLink(ctx context.Context, providerName string, identity oidciface.Identity,
+	userEdit entity.User) (err error) {
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if userEdit.Provider != "" || userEdit.EmailVerifiedAt != nil {
+		if err = sv.userRepository.UpdateUser(ctx, tx, userEdit); err != nil {
+			return err
+		}
+	}
+
+	_, err = sv.identityRepository.CreateUserIdentity(ctx, tx, entity.UserIdentity{
+		UserID:   userEdit.ID,
+		Provider: providerName,
+		Subject:  identity.Subject,
+	})
+	return err
+}
+
+func (sv *oidcService) createUser(ctx context.Context, providerName string,
+	identity oidciface.Identity) (user entity.User, err error) {
+	// Provider accounts have no usable password until the user resets it
+	password, err := util.GenerateRandomToken(constant.OIDCStateBytes)
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	name := strings.TrimSpace(identity.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return entity.User{}, err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	now := time.Now()
+	user, err = sv.userRepository.CreateNewUser(ctx, tx, entity.User{
+		Name:            name,
+		Email:           identity.Email,
+		Password:        password,
+		Role:            constant.EnumRoleUser,
+		Provider:        providerName,
+		EmailVerifiedAt: &now,
+	})
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	_, err = sv.identityRepository.CreateUserIdentity(ctx, tx, entity.UserIdentity{
+		UserID:   user.ID,
+		Provider: providerName,
+		Subject:  identity.Subject,
+	})
+	if err != nil {
+		return entity.User{}, err
+	}
+	return user, nil
+}
+
+// ============== OIDC Login ==============
+
+func (sv *oidcService) BeginLogin(ctx context.Context, providerName string) (dto.OIDCLoginStart, error) {
+	p, err := sv.provider(providerName)
+	if err != nil {
+		return dto.OIDCLoginStart{}, err
+	}
+
+	state, err := util.GenerateRandomToken(constant.OIDCStateBytes)
+	if err != nil {
+		return dto.OIDCLoginStart{}, err
+	}
+	nonce, err := util.GenerateRandomToken(constant.OIDCStateBytes)
+	if err != nil {
+		return dto.OIDCLoginStart{}, err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	authURL, err := p.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return dto.OIDCLoginStart{}, err
+	}
+
+	expiresAt := time.Now().Add(sv.stateTTL)
+	_, err = sv.stateRepository.CreateOIDCState(ctx, nil, entity.OIDCState{
+		ID:           uuid.New(),
+		Provider:     providerName,
+		StateHash:    util.HashToken(state),
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    expiresAt,
+	})
+	if err != nil {
+		return dto.OIDCLoginStart{}, err
+	}
+	return dto.OIDCLoginStart{AuthURL: authURL, State: state, ExpiresAt: expiresAt}, nil
+}
+
+func (sv *oidcService) CompleteLogin(ctx context.Context, req dto.OIDCCallbackRequest) (dto.UserResponse, error) {
+	p, err := sv.provider(req.Provider)
+	if err != nil {
+		return dto.UserResponse{}, err
+	}
+	if req.BrowserState == "" || subtle.ConstantTimeCompare([]byte(req.BrowserState), []byte(req.State)) != 1 {
+		return dto.UserResponse{}, errs.ErrOIDCStateInvalid
+	}
+
+	state, err := sv.stateRepository.ConsumeOIDCState(ctx, nil, util.HashToken(req.State))
+	if err != nil {
+		return dto.UserResponse{}, err
+	}
+	if state.Provider != req.Provider || time.Now().After(state.ExpiresAt) {
+		return dto.UserResponse{}, errs.ErrOIDCStateInvalid
+	}
+
+	identity, err := p.Exchange(ctx, req.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		return dto.UserResponse{}, err
+	}
+
+	user, err := sv.userForIdentity(ctx, req.Provider, identity)
+	if err != nil {
+		return dto.UserResponse{}, err
+	}
+
+	return dto.UserResponse{
+		ID:    user.ID.String(),
+		Name:  user.Name,
+		Email: user.Email,
+		Role:  user.Role,
+	}, nil
+}
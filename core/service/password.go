@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	maileriface "myapp/core/interface/mailer"
+	repositoryiface "myapp/core/interface/repository"
+	"myapp/support/constant"
+	"myapp/support/logger"
+	"myapp/support/util"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type passwordService struct {
+	jwtService              JWTService
+	userRepository          repositoryiface.UserRepository
+	passwordResetRepository repositoryiface.PasswordResetTokenRepository
+	refreshTokenRepository  repositoryiface.RefreshTokenRepository
+	sessionRepository       repositoryiface.SessionRepository
+	revocationRepository    repositoryiface.TokenRevocationRepository
+	auditLogRepository      repositoryiface.AuditLogRepository
+	txRepository            repositoryiface.TxRepository
+	mailer                  maileriface.Mailer
+	passwordPolicy          PasswordPolicy
+	resetTokenTTL           time.Duration
+	resetURL                string
+}
+
+type PasswordService interface {
+	// RequestPasswordReset mails a reset link to the account's address. It
+	// succeeds for unknown emails too, so it cannot be used to find out
+	// which addresses are registered.
+	RequestPasswordReset(ctx context.Context, req dto.UserPasswordForgotRequest) error
+	// ResetPassword consumes a reset token, sets the new password and signs
+	// the user out everywhere.
+	ResetPassword(ctx context.Context, req dto.UserPasswordResetRequest) error
+	// ChangePassword sets a new password for a signed in user after checking
+	// their current one, and signs out all of their sessions.
+	ChangePassword(ctx context.Context, req dto.UserPasswordChangeRequest) error
+}
+
+func NewPasswordService(jwtS JWTService, userR repositoryiface.UserRepository,
+	passwordResetR repositoryiface.PasswordResetTokenRepository,
+	refreshTokenR repositoryiface.RefreshTokenRepository, sessionR repositoryiface.SessionRepository,
+	revocationR repositoryiface.TokenRevocationRepository, auditLogR repositoryiface.AuditLogRepository,
+	txR repositoryiface.TxRepository, mailer maileriface.Mailer, passwordPolicy PasswordPolicy,
+) PasswordService {
+	return &passwordService{
+		jwtService:              jwtS,
+		userRepository:          userR,
+		passwordResetRepository: passwordResetR,
+		refreshTokenRepository:  refreshTokenR,
+		sessionRepository:       sessionR,
+		revocationRepository:    revocationR,
+		auditLogRepository:      auditLogR,
+		txRepository:            txR,
+		mailer:                  mailer,
+		passwordPolicy:          passwordPolicy,
+		resetTokenTTL:           util.GetEnvDuration("PASSWORD_RESET_TOKEN_TTL", constant.DefaultPasswordResetTokenTTL),
+		resetURL:                util.GetEnv("PASSWORD_RESET_URL", constant.DefaultPasswordResetURL),
+	}
+}
+
+// ============== Helper Functions ==============
+
+func (sv *passwordService) resetLink(token string) (string, error) {
+	link, err := url.Parse(sv.resetURL)
+	if err != nil {
+		return "", err
+	}
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
+
+// setPassword stores a new password for before within tx, records the
+// change and revokes every token issued before it, so whoever knew the old
+// password does not stay signed in.
+func (sv *passwordService) setPassword(ctx context.Context, tx *gorm.DB, before entity.User, password string) error {
+	err := sv.userRepository.UpdateUser(ctx, tx, entity.User{
+		ID:       before.ID,
+		Password: password,
+	})
+	if err != nil {
+		return err
+	}
+
+	after, err := sv.userRepository.GetUserByPrimaryKey(ctx, tx, constant.DBAttrID, before.ID.String())
+	if err != nil {
+		return err
+	}
+
+	err = recordAudit(ctx, tx, sv.auditLogRepository, constant.EnumAuditActionUpdate,
+		constant.EnumAuditEntityUser, before.ID.String(), before, after)
+	if err != nil {
+		return err
+	}
+
+	revokedBefore := entity.PasswordChangeCutoff(time.Now())
+	if after.PasswordChangedAt != nil {
+		revokedBefore = entity.PasswordChangeCutoff(*after.PasswordChangedAt)
+	}
+	return revokeUserTokens(ctx, tx, sv.refreshTokenRepository, sv.sessionRepository, sv.revocationRepository,
+		before.ID, revokedBefore, sv.jwtService.GetAccessTokenTTL())
+}
+
+// ============== Password Reset ==============
+
+func (sv *passwordService) RequestPasswordReset(ctx context.Context,
+	req dto.UserPasswordForgotRequest) (err error) {
+	user, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrEmail, req.Email)
+	if errors.Is(err, errs.ErrUserNotFound) {
+		logger.Debug("Password reset requested for unknown email %s", req.Email)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	raw, err := util.GenerateRandomToken(constant.PasswordResetTokenBytes)
+	if err != nil {
+		return err
+	}
+	link, err := sv.resetLink(raw)
+	if err != nil {
+		return err
+	}
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	// Only the latest link works
+	if err = sv.passwordResetRepository.InvalidateUserPasswordResetTokens(ctx, tx, user.ID.String()); err != nil {
+		return err
+	}
+
+	_, err = sv.passwordResetRepository.CreatePasswordResetToken(ctx, tx, entity.PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: util.HashToken(raw),
+		ExpiresAt: time.Now().Add(sv.resetTokenTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Sent inside the transaction so the token is discarded if mailing fails
+	err = sv.mailer.Send(ctx, maileriface.Message{
+		To:      []string{user.Email},
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. "+
+			"It expires in %s and can only be used once.\n\n%s\n\n"+
+			"If you did not ask for a password reset, you can ignore this email.\n",
+			user.Name, sv.resetTokenTTL, link),
+	})
+	return err
+}
+
+func (sv *passwordService) ResetPassword(ctx context.Context, req dto.UserPasswordResetRequest) (err error) {
+	token, err := sv.passwordResetRepository.GetPasswordResetTokenByHash(ctx, nil, util.HashToken(req.Token))
+	if err != nil {
+		return err
+	}
+
+	if token.UsedAt != nil {
+		return errs.ErrPasswordResetTokenInvalid
+	}
+
+	if time.Now().After(token.ExpiresAt) {
+		return errs.ErrPasswordResetTokenExpired
+	}
+
+	// validate before the token is consumed so the user can try again
+	user, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrID, token.UserID.String())
+	if err != nil {
+		return err
+	}
+	if err = sv.passwordPolicy.Validate(req.Password, user.Email, user.Name); err != nil {
+		return err
+	}
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.passwordResetRepository.UsePasswordResetToken(ctx, tx, token.ID.String()); err != nil {
+		return err
+	}
+
+	err = sv.setPassword(ctx, tx, user, req.Password)
+	return err
+}
+
+func (sv *passwordService) ChangePassword(ctx context.Context, req dto.UserPasswordChangeRequest) (err error) {
+	user, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrID, req.ID)
+	if err != nil {
+		return err
+	}
+
+	match, err := util.PasswordCompare(user.Password, []byte(req.CurrentPassword))
+	if err != nil || !match {
+		return errs.ErrPasswordIncorrect
+	}
+
+	if err = sv.passwordPolicy.Validate(req.Password, user.Email, user.Name); err != nil {
+		return err
+	}
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	err = sv.setPassword(ctx, tx, user, req.Password)
+	return err
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	errs "myapp/core/helper/errors"
+	"myapp/support/base"
+	"myapp/support/constant"
+	"myapp/support/util"
+)
+
+// passwordPersonalMinLength is the shortest name or email part checked for
+// inside a password, so short names like "Al" do not reject most passwords.
+const passwordPersonalMinLength = 3
+
+type passwordPolicy struct {
+	minLength  int
+	maxLength  int
+	minClasses int
+	breached   map[string]struct{}
+}
+
+type PasswordPolicy interface {
+	// Validate returns a PasswordPolicyError listing every rule the password
+	// breaks. email and name are the account's, for the personal info rule;
+	// either may be empty.
+	Validate(password string, email string, name string) error
+}
+
+// NewPasswordPolicy reads the policy from the environment. It fails when
+// PASSWORD_BREACHED_LIST_FILE is set but cannot be read, rather than
+// running without the breached-password check.
+func NewPasswordPolicy() (PasswordPolicy, error) {
+	policy := &passwordPolicy{
+		minLength:  util.GetEnvInt("PASSWORD_MIN_LENGTH", constant.DefaultPasswordMinLength),
+		maxLength:  util.GetEnvInt("PASSWORD_MAX_LENGTH", constant.DefaultPasswordMaxLength),
+		minClasses: util.GetEnvInt("PASSWORD_MIN_CLASSES", constant.DefaultPasswordMinClasses),
+		breached:   map[string]struct{}{},
+	}
+
+	if path := util.GetEnv("PASSWORD_BREACHED_LIST_FILE", ""); path != "" {
+		breached, err := loadBreachedPasswords(path)
+		if err != nil {
+			return nil, err
+		}
+		policy.breached = breached
+	}
+	return policy, nil
+}
+
+// ============== Helper Functions ==============
+
+// loadBreachedPasswords reads a list with one password per line. Blank lines
+// and lines starting with # are skipped, and matching ignores case.
+func loadBreachedPasswords(path string) (map[string]struct{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open breached password list: %w", err)
+	}
+	defer file.Close()
+
+	breached := map[string]struct{}{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		breached[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read breached password list: %w", err)
+	}
+	return breached, nil
+}
+
+// passwordClasses counts how many of lowercase letters, uppercase letters,
+// numbers and symbols the password uses.
+func passwordClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	count := 0
+	for _, used := range []bool{lower, upper, digit, symbol} {
+		if used {
+			count++
+		}
+	}
+	return count
+}
+
+// containsPersonalInfo reports whether the password contains the local part
+// of the email or any word of the name.
+func containsPersonalInfo(password string, email string, name string) bool {
+	password = strings.ToLower(password)
+
+	parts := strings.Fields(strings.ToLower(name))
+	if local, _, found := strings.Cut(strings.ToLower(email), "@"); found {
+		parts = append(parts, local)
+	}
+
+	for _, part := range parts {
+		if utf8.RuneCountInString(part) >= passwordPersonalMinLength && strings.Contains(password, part) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *passwordPolicy) Validate(password string, email string, name string) error {
+	var violations []string
+
+	if utf8.RuneCountInString(password) < p.minLength {
+		violations = append(violations,
+			base.FormatFieldErrorMessage("password", "min_length", strconv.Itoa(p.minLength)))
+	}
+	// bcrypt limits bytes, not characters
+	if p.maxLength > 0 && len(password) > p.maxLength {
+		violations = append(violations,
+			base.FormatFieldErrorMessage("password", "max_length", strconv.Itoa(p.maxLength)))
+	}
+	if passwordClasses(password) < p.minClasses {
+		violations = append(violations,
+			base.FormatFieldErrorMessage("password", "password_classes", strconv.Itoa(p.minClasses)))
+	}
+	if containsPersonalInfo(password, email, name) {
+		violations = append(violations,
+			base.FormatFieldErrorMessage("password", "password_personal", ""))
+	}
+	if _, found := p.breached[strings.ToLower(password)]; found {
+		violations = append(violations,
+			base.FormatFieldErrorMessage("password", "password_breached", ""))
+	}
+
+	if len(violations) > 0 {
+		return &errs.PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
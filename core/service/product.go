@@ -4,18 +4,20 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"time"
 
 	"myapp/core/entity"
 	"myapp/core/helper/dto"
 	errs "myapp/core/helper/errors"
 	queryiface "myapp/core/interface/query"
 	repositoryiface "myapp/core/interface/repository"
+	storageiface "myapp/core/interface/storage"
 	"myapp/support/base"
 	"myapp/support/constant"
-	"myapp/support/util"
 
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
+	"gorm.io/gorm"
 )
 
 type productService struct {
@@ -24,6 +26,12 @@ type productService struct {
 	productQuery       queryiface.ProductQuery
 	categoryQuery      queryiface.CategoryQuery
 	txRepository       repositoryiface.TxRepository
+	auditLogRepository repositoryiface.AuditLogRepository
+	revisionRepository repositoryiface.ProductRevisionRepository
+	revisionQuery      queryiface.ProductRevisionQuery
+	storage            storageiface.Storage
+	fileURLs           FileURLService
+	imageUpload        uploadPolicy
 }
 
 type ProductService interface {
@@ -31,11 +39,18 @@ type ProductService interface {
 	CreateProduct(ctx context.Context, req dto.ProductCreateRequest) (dto.ProductResponse, error)
 	GetAllProducts(ctx context.Context, req dto.ProductGetsRequest) ([]dto.ProductResponse, base.PaginationResponse, error)
 	GetProductByID(ctx context.Context, id string) (dto.ProductResponse, error)
+	// GetProductAsOf rebuilds the product as it was at the given time from
+	// its revisions.
+	GetProductAsOf(ctx context.Context, id string, asOf time.Time) (dto.ProductResponse, error)
+	GetProductHistory(ctx context.Context, req dto.ProductHistoryRequest) ([]dto.ProductRevisionResponse, base.PaginationResponse, error)
 	UpdateProduct(ctx context.Context, req dto.ProductUpdateRequest) (dto.ProductResponse, error)
 	DeleteProduct(ctx context.Context, id string) error
 
 	// Product Image
 	ChangeProductImage(ctx context.Context, req dto.ProductChangeImageRequest) (dto.ProductResponse, error)
+	// ImageRequestLimit is the largest request body an image upload may
+	// have.
+	ImageRequestLimit() int64
 	DeleteProductImage(ctx context.Context, id string) error
 
 	// Stock Management
@@ -56,6 +71,11 @@ func NewProductService(
 	productQ queryiface.ProductQuery,
 	categoryQ queryiface.CategoryQuery,
 	txR repositoryiface.TxRepository,
+	auditLogR repositoryiface.AuditLogRepository,
+	revisionR repositoryiface.ProductRevisionRepository,
+	revisionQ queryiface.ProductRevisionQuery,
+	storage storageiface.Storage,
+	fileURLs FileURLService,
 ) ProductService {
 	return &productService{
 		productRepository:  productR,
@@ -63,12 +83,18 @@ func NewProductService(
 		productQuery:       productQ,
 		categoryQuery:      categoryQ,
 		txRepository:       txR,
+		auditLogRepository: auditLogR,
+		revisionRepository: revisionR,
+		revisionQuery:      revisionQ,
+		storage:            storage,
+		fileURLs:           fileURLs,
+		imageUpload:        newProductImageUpload(),
 	}
 }
 
 // ============== Helper Functions ==============
 
-func (sv *productService) toProductResponse(product entity.Product) dto.ProductResponse {
+func toProductResponse(product entity.Product, fileURLs FileURLService, images uploadPolicy) dto.ProductResponse {
 	resp := dto.ProductResponse{
 		ID:          product.ID.String(),
 		Name:        product.Name,
@@ -84,7 +110,7 @@ func (sv *productService) toProductResponse(product entity.Product) dto.ProductR
 	}
 
 	if product.Image != nil {
-		resp.Image = *product.Image
+		resp.Image = images.urls(fileURLs, *product.Image)
 	}
 
 	if product.Category != nil {
@@ -98,9 +124,67 @@ func (sv *productService) toProductResponse(product entity.Product) dto.ProductR
 	return resp
 }
 
+// updateAudited applies productEdit and records the change in the same
+// transaction. before is the product as read ahead of the update.
+func (sv *productService) updateAudited(ctx context.Context, tx *gorm.DB, before entity.Product,
+	productEdit entity.Product) error {
+	if err := sv.productRepository.UpdateProduct(ctx, tx, productEdit); err != nil {
+		return err
+	}
+
+	after, err := sv.productRepository.GetProductByID(ctx, tx, before.ID.String())
+	if err != nil {
+		return err
+	}
+
+	return sv.recordChange(ctx, tx, constant.EnumAuditActionUpdate, &before, &after)
+}
+
+// recordChange writes the audit log entry and the revision for a change to
+// a product within tx. before is nil for creates, after for deletes. An
+// update that changes nothing is not recorded.
+func (sv *productService) recordChange(ctx context.Context, tx *gorm.DB, action string,
+	before *entity.Product, after *entity.Product) error {
+	state := after
+	if action == constant.EnumAuditActionDelete {
+		state = before
+	}
+	if action == constant.EnumAuditActionUpdate && len(auditChanges(before, after)) == 0 {
+		return nil
+	}
+
+	err := recordAudit(ctx, tx, sv.auditLogRepository, action,
+		constant.EnumAuditEntityProduct, state.ID.String(), before, after)
+	if err != nil {
+		return err
+	}
+
+	_, err = sv.revisionRepository.CreateProductRevision(ctx, tx, newProductRevision(ctx, action, *state))
+	return err
+}
+
+// newProductRevision captures product as changed by action in the request
+// of ctx.
+func newProductRevision(ctx context.Context, action string, product entity.Product) entity.ProductRevision {
+	return entity.ProductRevision{
+		ProductID:   product.ID,
+		Action:      action,
+		Name:        product.Name,
+		Description: product.Description,
+		SKU:         product.SKU,
+		Price:       product.Price,
+		Stock:       product.Stock,
+		CategoryID:  product.CategoryID,
+		IsActive:    product.IsActive,
+		Image:       product.Image,
+		ChangedByID: requestActorID(ctx),
+	}
+}
+
 // ============== Product CRUD ==============
 
-func (sv *productService) CreateProduct(ctx context.Context, req dto.ProductCreateRequest) (dto.ProductResponse, error) {
+func (sv *productService) CreateProduct(ctx context.Context, req dto.ProductCreateRequest) (
+	resp dto.ProductResponse, err error) {
 	// Check if SKU already exists
 	existingProduct, err := sv.productRepository.GetProductByPrimaryKey(ctx, nil, constant.DBAttrSKU, req.SKU)
 	if err != nil && err != errs.ErrProductNotFound {
@@ -139,12 +223,25 @@ func (sv *productService) CreateProduct(ctx context.Context, req dto.ProductCrea
 		IsActive:    isActive,
 	}
 
-	newProduct, err := sv.productRepository.CreateProduct(ctx, nil, product)
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return dto.ProductResponse{}, err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	newProduct, err := sv.productRepository.CreateProduct(ctx, tx, product)
 	if err != nil {
 		return dto.ProductResponse{}, err
 	}
 
-	return sv.toProductResponse(newProduct), nil
+	err = sv.recordChange(ctx, tx, constant.EnumAuditActionCreate, nil, &newProduct)
+	if err != nil {
+		return dto.ProductResponse{}, err
+	}
+
+	return toProductResponse(newProduct, sv.fileURLs, sv.imageUpload), nil
 }
 
 func (sv *productService) GetAllProducts(ctx context.Context, req dto.ProductGetsRequest) (
@@ -156,7 +253,7 @@ func (sv *productService) GetAllProducts(ctx context.Context, req dto.ProductGet
 	}
 
 	for _, product := range products {
-		productsResp = append(productsResp, sv.toProductResponse(product))
+		productsResp = append(productsResp, toProductResponse(product, sv.fileURLs, sv.imageUpload))
 	}
 	return productsResp, pageResp, nil
 }
@@ -166,10 +263,67 @@ func (sv *productService) GetProductByID(ctx context.Context, id string) (dto.Pr
 	if err != nil {
 		return dto.ProductResponse{}, err
 	}
-	return sv.toProductResponse(product), nil
+	return toProductResponse(product, sv.fileURLs, sv.imageUpload), nil
 }
 
-func (sv *productService) UpdateProduct(ctx context.Context, req dto.ProductUpdateRequest) (dto.ProductResponse, error) {
+func (sv *productService) GetProductAsOf(ctx context.Context, id string, asOf time.Time) (dto.ProductResponse, error) {
+	revision, err := sv.revisionRepository.GetProductRevisionAsOf(ctx, nil, id, asOf)
+	if err != nil {
+		return dto.ProductResponse{}, err
+	}
+	if revision.Action == constant.EnumAuditActionDelete {
+		return dto.ProductResponse{}, errs.ErrProductNotFoundAsOf
+	}
+
+	return toProductResponse(entity.Product{
+		ID:          revision.ProductID,
+		Name:        revision.Name,
+		Description: revision.Description,
+		SKU:         revision.SKU,
+		Price:       revision.Price,
+		Stock:       revision.Stock,
+		CategoryID:  revision.CategoryID,
+		IsActive:    revision.IsActive,
+		Image:       revision.Image,
+	}, sv.fileURLs, sv.imageUpload), nil
+}
+
+func (sv *productService) GetProductHistory(ctx context.Context, req dto.ProductHistoryRequest) (
+	revisionsResp []dto.ProductRevisionResponse, pageResp base.PaginationResponse, err error) {
+	revisions, pageResp, err := sv.revisionQuery.GetProductRevisions(ctx, req)
+	if err != nil {
+		return []dto.ProductRevisionResponse{}, base.PaginationResponse{}, err
+	}
+
+	for _, revision := range revisions {
+		revisionResp := dto.ProductRevisionResponse{
+			Revision:    revision.Revision,
+			Action:      revision.Action,
+			Name:        revision.Name,
+			Description: revision.Description,
+			SKU:         revision.SKU,
+			Price:       revision.Price,
+			Stock:       revision.Stock,
+			IsActive:    revision.IsActive,
+			ChangedAt:   revision.CreatedAt,
+		}
+		if revision.CategoryID != nil {
+			revisionResp.CategoryID = revision.CategoryID.String()
+		}
+		if revision.Image != nil {
+			revisionResp.Image = sv.imageUpload.urls(sv.fileURLs, *revision.Image)
+		}
+		if revision.ChangedByID != nil {
+			revisionResp.ChangedByID = revision.ChangedByID.String()
+		}
+
+		revisionsResp = append(revisionsResp, revisionResp)
+	}
+	return revisionsResp, pageResp, nil
+}
+
+func (sv *productService) UpdateProduct(ctx context.Context, req dto.ProductUpdateRequest) (
+	resp dto.ProductResponse, err error) {
 	product, err := sv.productRepository.GetProductByID(ctx, nil, req.ID)
 	if err != nil {
 		return dto.ProductResponse{}, err
@@ -220,61 +374,96 @@ func (sv *productService) UpdateProduct(ctx context.Context, req dto.ProductUpda
 		productEdit.IsActive = *req.IsActive
 	}
 
-	err = sv.productRepository.UpdateProduct(ctx, nil, productEdit)
+	tx, err := sv.txRepository.BeginTx(ctx)
 	if err != nil {
 		return dto.ProductResponse{}, err
 	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.updateAudited(ctx, tx, product, productEdit); err != nil {
+		return dto.ProductResponse{}, err
+	}
 
-	return sv.toProductResponse(productEdit), nil
+	return toProductResponse(productEdit, sv.fileURLs, sv.imageUpload), nil
 }
 
-func (sv *productService) DeleteProduct(ctx context.Context, id string) error {
-	_, err := sv.productRepository.GetProductByID(ctx, nil, id)
+func (sv *productService) DeleteProduct(ctx context.Context, id string) (err error) {
+	product, err := sv.productRepository.GetProductByID(ctx, nil, id)
 	if err != nil {
 		return err
 	}
 
-	return sv.productRepository.DeleteProductByID(ctx, nil, id)
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.productRepository.DeleteProductByID(ctx, tx, id); err != nil {
+		return err
+	}
+
+	return sv.recordChange(ctx, tx, constant.EnumAuditActionDelete, &product, nil)
 }
 
 // ============== Product Image ==============
 
-func (sv *productService) ChangeProductImage(ctx context.Context, req dto.ProductChangeImageRequest) (dto.ProductResponse, error) {
+func (sv *productService) ImageRequestLimit() int64 {
+	return sv.imageUpload.requestLimit()
+}
+
+func (sv *productService) ChangeProductImage(ctx context.Context, req dto.ProductChangeImageRequest) (
+	resp dto.ProductResponse, err error) {
 	product, err := sv.productRepository.GetProductByID(ctx, nil, req.ID)
 	if err != nil {
 		return dto.ProductResponse{}, err
 	}
 
+	// Upload new image first so a rejected file keeps the current one
+	imgID := uuid.New()
+	imgPath := fmt.Sprintf("%s/%v", constant.EnumFileNamespaceProductImage, imgID)
+	if err := uploadFile(ctx, sv.storage, sv.imageUpload, req.Image, imgPath); err != nil {
+		return dto.ProductResponse{}, err
+	}
+
 	// Delete old image if exists
 	if product.Image != nil && *product.Image != "" {
-		if err := util.DeleteFile(*product.Image); err != nil {
+		if err := sv.storage.Delete(ctx, *product.Image); err != nil {
+			return dto.ProductResponse{}, err
+		}
+		if err := deleteImageVariants(ctx, sv.storage, sv.imageUpload, product.Image); err != nil {
 			return dto.ProductResponse{}, err
 		}
-	}
-
-	// Upload new image
-	imgID := uuid.New()
-	imgPath := fmt.Sprintf("product_image/%v", imgID)
-	if err := util.UploadFile(req.Image, imgPath); err != nil {
-		return dto.ProductResponse{}, err
 	}
 
 	productEdit := entity.Product{
 		ID:    product.ID,
 		Image: &imgPath,
 	}
-	err = sv.productRepository.UpdateProduct(ctx, nil, productEdit)
+
+	tx, err := sv.txRepository.BeginTx(ctx)
 	if err != nil {
 		return dto.ProductResponse{}, err
 	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.updateAudited(ctx, tx, product, productEdit); err != nil {
+		return dto.ProductResponse{}, err
+	}
 
 	return dto.ProductResponse{
 		ID:    productEdit.ID.String(),
-		Image: imgPath,
+		Image: sv.imageUpload.urls(sv.fileURLs, imgPath),
 	}, nil
 }
 
-func (sv *productService) DeleteProductImage(ctx context.Context, id string) error {
+func (sv *productService) DeleteProductImage(ctx context.Context, id string) (err error) {
 	product, err := sv.productRepository.GetProductByID(ctx, nil, id)
 	if err != nil {
 		return err
@@ -284,7 +473,10 @@ func (sv *productService) DeleteProductImage(ctx context.Context, id string) err
 		return errs.ErrProductNoImage
 	}
 
-	if err := util.DeleteFile(*product.Image); err != nil {
+	if err := sv.storage.Delete(ctx, *product.Image); err != nil {
+		return err
+	}
+	if err := deleteImageVariants(ctx, sv.storage, sv.imageUpload, product.Image); err != nil {
 		return err
 	}
 
@@ -294,12 +486,21 @@ func (sv *productService) DeleteProductImage(ctx context.Context, id string) err
 		Image: &emptyString,
 	}
 
-	return sv.productRepository.UpdateProduct(ctx, nil, productEdit)
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	return sv.updateAudited(ctx, tx, product, productEdit)
 }
 
 // ============== Stock Management ==============
 
-func (sv *productService) UpdateStock(ctx context.Context, req dto.ProductStockUpdateRequest) (dto.ProductResponse, error) {
+func (sv *productService) UpdateStock(ctx context.Context, req dto.ProductStockUpdateRequest) (
+	resp dto.ProductResponse, err error) {
 	product, err := sv.productRepository.GetProductByID(ctx, nil, req.ID)
 	if err != nil {
 		return dto.ProductResponse{}, err
@@ -310,13 +511,30 @@ func (sv *productService) UpdateStock(ctx context.Context, req dto.ProductStockU
 		return dto.ProductResponse{}, errs.ErrInsufficientStock
 	}
 
-	err = sv.productRepository.UpdateProductStock(ctx, nil, req.ID, req.Quantity)
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return dto.ProductResponse{}, err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	err = sv.productRepository.UpdateProductStock(ctx, tx, req.ID, req.Quantity)
+	if err != nil {
+		return dto.ProductResponse{}, err
+	}
+
+	after, err := sv.productRepository.GetProductByID(ctx, tx, req.ID)
+	if err != nil {
+		return dto.ProductResponse{}, err
+	}
+
+	err = sv.recordChange(ctx, tx, constant.EnumAuditActionUpdate, &product, &after)
 	if err != nil {
 		return dto.ProductResponse{}, err
 	}
 
-	product.Stock += req.Quantity
-	return sv.toProductResponse(product), nil
+	return toProductResponse(after, sv.fileURLs, sv.imageUpload), nil
 }
 
 // ============== Complex Queries ==============
@@ -329,7 +547,7 @@ func (sv *productService) GetLowStockProducts(ctx context.Context, threshold int
 
 	var result []dto.ProductResponse
 	for _, product := range products {
-		result = append(result, sv.toProductResponse(product))
+		result = append(result, toProductResponse(product, sv.fileURLs, sv.imageUpload))
 	}
 	return result, nil
 }
@@ -346,7 +564,7 @@ func (sv *productService) GetProductsByPriceRange(ctx context.Context, minPrice,
 
 	var result []dto.ProductResponse
 	for _, product := range products {
-		result = append(result, sv.toProductResponse(product))
+		result = append(result, toProductResponse(product, sv.fileURLs, sv.imageUpload))
 	}
 	return result, nil
 }
@@ -478,7 +696,7 @@ func (sv *productService) RunProductMaintenance(
 			continue
 		}
 
-		if err = sv.productRepository.UpdateProduct(ctx, tx, productEdit); err != nil {
+		if err = sv.updateAudited(ctx, tx, product, productEdit); err != nil {
 			return resp, err
 		}
 
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	repositoryiface "myapp/core/interface/repository"
+	"myapp/support/constant"
+
+	"gorm.io/gorm"
+)
+
+type roleService struct {
+	roleRepository repositoryiface.RoleRepository
+	txRepository   repositoryiface.TxRepository
+}
+
+type RoleService interface {
+	GetAllRoles(ctx context.Context) ([]dto.RoleResponse, error)
+	GetAllPermissions(ctx context.Context) ([]dto.PermissionResponse, error)
+	CreateRole(ctx context.Context, req dto.RoleCreateRequest) (dto.RoleResponse, error)
+	UpdateRolePermissions(ctx context.Context, req dto.RolePermissionsUpdateRequest) (dto.RoleResponse, error)
+	DeleteRole(ctx context.Context, id string) error
+	// HasPermission reports whether users with the given role may perform
+	// permission. Unknown roles have no permissions.
+	HasPermission(ctx context.Context, role string, permission string) (bool, error)
+}
+
+func NewRoleService(roleR repositoryiface.RoleRepository, txR repositoryiface.TxRepository) RoleService {
+	return &roleService{
+		roleRepository: roleR,
+		txRepository:   txR,
+	}
+}
+
+// ============== Helper Functions ==============
+
+func roleToResponse(role entity.Role) dto.RoleResponse {
+	permissions := make([]string, 0, len(role.Permissions))
+	for _, permission := range role.Permissions {
+		permissions = append(permissions, permission.Name)
+	}
+	slices.Sort(permissions)
+
+	return dto.RoleResponse{
+		ID:          role.ID.String(),
+		Name:        role.Name,
+		Description: role.Description,
+		System:      role.System,
+		Permissions: permissions,
+	}
+}
+
+// resolvePermissions loads the named permissions and fails if any of them
+// does not exist.
+func (sv *roleService) resolvePermissions(ctx context.Context, tx *gorm.DB,
+	names []string) ([]entity.Permission, error) {
+	names = slices.Clone(names)
+	slices.Sort(names)
+	names = slices.Compact(names)
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	permissions, err := sv.roleRepository.GetPermissionsByNames(ctx, tx, names)
+	if err != nil {
+		return nil, err
+	}
+	if len(permissions) != len(names) {
+		return nil, errs.ErrPermissionNotFound
+	}
+	return permissions, nil
+}
+
+// ============== Roles ==============
+
+func (sv *roleService) GetAllRoles(ctx context.Context) ([]dto.RoleResponse, error) {
+	roles, err := sv.roleRepository.GetAllRoles(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	rolesResp := make([]dto.RoleResponse, 0, len(roles))
+	for _, role := range roles {
+		rolesResp = append(rolesResp, roleToResponse(role))
+	}
+	return rolesResp, nil
+}
+
+func (sv *roleService) GetAllPermissions(ctx context.Context) ([]dto.PermissionResponse, error) {
+	permissions, err := sv.roleRepository.GetAllPermissions(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	permissionsResp := make([]dto.PermissionResponse, 0, len(permissions))
+	for _, permission := range permissions {
+		permissionsResp = append(permissionsResp, dto.PermissionResponse{
+			Name:        permission.Name,
+			Description: permission.Description,
+		})
+	}
+	return permissionsResp, nil
+}
+
+func (sv *roleService) CreateRole(ctx context.Context, req dto.RoleCreateRequest) (dto.RoleResponse, error) {
+	// api_key is what requests made with an API key carry as role
+	if req.Name == constant.EnumRoleAPIKey {
+		return dto.RoleResponse{}, errs.ErrRoleAlreadyExists
+	}
+
+	_, err := sv.roleRepository.GetRoleByPrimaryKey(ctx, nil, constant.DBAttrName, req.Name)
+	if err == nil {
+		return dto.RoleResponse{}, errs.ErrRoleAlreadyExists
+	}
+	if !errors.Is(err, errs.ErrRoleNotFound) {
+		return dto.RoleResponse{}, err
+	}
+
+	permissions, err := sv.resolvePermissions(ctx, nil, req.Permissions)
+	if err != nil {
+		return dto.RoleResponse{}, err
+	}
+
+	role, err := sv.roleRepository.CreateRole(ctx, nil, entity.Role{
+		Name:        req.Name,
+		Description: req.Description,
+		Permissions: permissions,
+	})
+	if err != nil {
+		return dto.RoleResponse{}, err
+	}
+
+	return roleToResponse(role), nil
+}
+
+func (sv *roleService) UpdateRolePermissions(ctx context.Context,
+	req dto.RolePermissionsUpdateRequest) (resp dto.RoleResponse, err error) {
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return dto.RoleResponse{}, err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	role, err := sv.roleRepository.GetRoleByPrimaryKey(ctx, tx, constant.DBAttrID, req.ID)
+	if err != nil {
+		return dto.RoleResponse{}, err
+	}
+
+	// Without roles:manage on the admin role nobody could fix the roles anymore
+	if role.Name == constant.EnumRoleAdmin && !slices.Contains(req.Permissions, constant.EnumPermissionRolesManage) {
+		return dto.RoleResponse{}, errs.ErrRoleAdminLockout
+	}
+
+	permissions, err := sv.resolvePermissions(ctx, tx, req.Permissions)
+	if err != nil {
+		return dto.RoleResponse{}, err
+	}
+
+	if err = sv.roleRepository.ReplaceRolePermissions(ctx, tx, role, permissions); err != nil {
+		return dto.RoleResponse{}, err
+	}
+
+	role.Permissions = permissions
+	return roleToResponse(role), nil
+}
+
+func (sv *roleService) DeleteRole(ctx context.Context, id string) (err error) {
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	role, err := sv.roleRepository.GetRoleByPrimaryKey(ctx, tx, constant.DBAttrID, id)
+	if err != nil {
+		return err
+	}
+	if role.System {
+		return errs.ErrRoleSystem
+	}
+
+	assigned, err := sv.roleRepository.IsRoleAssigned(ctx, tx, role.Name)
+	if err != nil {
+		return err
+	}
+	if assigned {
+		return errs.ErrRoleInUse
+	}
+
+	return sv.roleRepository.DeleteRole(ctx, tx, role)
+}
+
+func (sv *roleService) HasPermission(ctx context.Context, role string, permission string) (bool, error) {
+	return sv.roleRepository.RoleHasPermission(ctx, nil, role, permission)
+}
@@ -0,0 +1,204 @@
+package service_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	"myapp/core/service"
+	"myapp/support/constant"
+	"myapp/support/util"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// --- Mock Repository ---
+
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) DB() *gorm.DB {
+	return nil
+}
+
+func (m *MockAPIKeyRepository) CreateAPIKey(ctx context.Context, tx *gorm.DB,
+	key entity.APIKey) (entity.APIKey, error) {
+	args := m.Called(ctx, tx, key)
+	return args.Get(0).(entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) GetAllAPIKeys(ctx context.Context, tx *gorm.DB) ([]entity.APIKey, error) {
+	args := m.Called(ctx, tx)
+	return args.Get(0).([]entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) GetAPIKeyByHash(ctx context.Context, tx *gorm.DB,
+	hash string) (entity.APIKey, error) {
+	args := m.Called(ctx, tx, hash)
+	return args.Get(0).(entity.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) TouchAPIKey(ctx context.Context, tx *gorm.DB, id string,
+	now time.Time, staleBefore time.Time) error {
+	args := m.Called(ctx, tx, id, now, staleBefore)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyRepository) RevokeAPIKey(ctx context.Context, tx *gorm.DB, id string) error {
+	args := m.Called(ctx, tx, id)
+	return args.Error(0)
+}
+
+// --- Test Helpers ---
+
+func setupAPIKeyServiceMock() (service.APIKeyService, *MockAPIKeyRepository, *MockRoleRepository, context.Context) {
+	apiKeyRepo := new(MockAPIKeyRepository)
+	roleRepo := new(MockRoleRepository)
+	ks := service.NewAPIKeyService(apiKeyRepo, roleRepo)
+
+	return ks, apiKeyRepo, roleRepo, context.Background()
+}
+
+// expectScopes sets up the named permissions to exist, and role to hold
+// the ones in held.
+func expectScopes(ctx context.Context, roleRepo *MockRoleRepository, role string, names []string, held ...string) {
+	permissions := make([]entity.Permission, 0, len(names))
+	for _, name := range names {
+		permissions = append(permissions, entity.Permission{Name: name})
+	}
+	roleRepo.On("GetPermissionsByNames", ctx, (*gorm.DB)(nil), names).Return(permissions, nil)
+	for _, name := range names {
+		roleRepo.On("RoleHasPermission", ctx, (*gorm.DB)(nil), role, name).Return(slices.Contains(held, name), nil)
+	}
+}
+
+// --- Tests ---
+
+func TestAPIKeyService_CreateAPIKey(t *testing.T) {
+	ks, apiKeyRepo, roleRepo, ctx := setupAPIKeyServiceMock()
+
+	adminID := uuid.New()
+	scopes := []string{constant.EnumPermissionProductsStock, constant.EnumPermissionProductsWrite}
+	expectScopes(ctx, roleRepo, constant.EnumRoleAdmin, scopes, scopes...)
+	var stored entity.APIKey
+	apiKeyRepo.On("CreateAPIKey", ctx, (*gorm.DB)(nil), mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(2).(entity.APIKey)
+	}).Return(entity.APIKey{ID: uuid.New()}, nil)
+
+	resp, err := ks.CreateAPIKey(ctx, dto.APIKeyCreateRequest{
+		CreatedByID:   adminID.String(),
+		CreatedByRole: constant.EnumRoleAdmin,
+		Name:          "warehouse",
+		Scopes: []string{constant.EnumPermissionProductsStock, constant.EnumPermissionProductsWrite,
+			constant.EnumPermissionProductsStock},
+	})
+	require.NoError(t, err)
+
+	require.True(t, strings.HasPrefix(resp.Key, constant.APIKeyPrefix))
+	require.True(t, strings.HasPrefix(resp.Key, stored.Prefix))
+	require.Equal(t, util.HashToken(resp.Key), stored.KeyHash)
+	require.NotContains(t, stored.KeyHash, resp.Key)
+	require.Equal(t, "products:stock products:write", stored.Scopes)
+	require.Equal(t, adminID, stored.CreatedByID)
+	require.WithinDuration(t, time.Now().Add(constant.DefaultAPIKeyTTL), stored.ExpiresAt, time.Minute)
+}
+
+func TestAPIKeyService_CreateAPIKey_ScopeNotAllowed(t *testing.T) {
+	testCases := []struct {
+		name    string
+		scopes  []string
+		held    []string
+		exists  bool
+		wantErr error
+	}{
+		{"not held by the creator", []string{constant.EnumPermissionCategoriesWrite, constant.EnumPermissionProductsStock},
+			[]string{constant.EnumPermissionProductsStock}, true, errs.ErrAPIKeyScopeNotAllowed},
+		{"key management", []string{constant.EnumPermissionAPIKeysManage},
+			[]string{constant.EnumPermissionAPIKeysManage}, true, errs.ErrAPIKeyScopeNotAllowed},
+		{"unknown", []string{"reports:read"}, nil, false, errs.ErrPermissionNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ks, apiKeyRepo, roleRepo, ctx := setupAPIKeyServiceMock()
+			if tc.exists {
+				expectScopes(ctx, roleRepo, "editor", tc.scopes, tc.held...)
+			} else {
+				roleRepo.On("GetPermissionsByNames", ctx, (*gorm.DB)(nil), tc.scopes).Return([]entity.Permission{}, nil)
+			}
+
+			_, err := ks.CreateAPIKey(ctx, dto.APIKeyCreateRequest{
+				CreatedByID:   uuid.NewString(),
+				CreatedByRole: "editor",
+				Name:          "warehouse",
+				Scopes:        tc.scopes,
+			})
+			require.ErrorIs(t, err, tc.wantErr)
+			apiKeyRepo.AssertNotCalled(t, "CreateAPIKey", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestAPIKeyService_CreateAPIKey_ExpiryInvalid(t *testing.T) {
+	ks, _, _, ctx := setupAPIKeyServiceMock()
+
+	for _, expiresAt := range []time.Time{
+		time.Now().Add(-time.Hour),
+		time.Now().Add(constant.DefaultAPIKeyMaxTTL + time.Hour),
+	} {
+		_, err := ks.CreateAPIKey(ctx, dto.APIKeyCreateRequest{
+			CreatedByID: uuid.NewString(),
+			Name:        "warehouse",
+			Scopes:      []string{constant.EnumPermissionProductsStock},
+			ExpiresAt:   &expiresAt,
+		})
+		require.ErrorIs(t, err, errs.ErrAPIKeyExpiryInvalid)
+	}
+}
+
+func TestAPIKeyService_AuthenticateAPIKey(t *testing.T) {
+	ks, apiKeyRepo, _, ctx := setupAPIKeyServiceMock()
+
+	key := entity.APIKey{ID: uuid.New(), Scopes: "products:stock", ExpiresAt: time.Now().Add(time.Hour)}
+	apiKeyRepo.On("GetAPIKeyByHash", ctx, (*gorm.DB)(nil), util.HashToken("mak_raw")).Return(key, nil)
+	apiKeyRepo.On("TouchAPIKey", ctx, (*gorm.DB)(nil), key.ID.String(), mock.Anything, mock.Anything).Return(nil)
+
+	resp, err := ks.AuthenticateAPIKey(ctx, "mak_raw")
+	require.NoError(t, err)
+	require.Equal(t, []string{constant.EnumPermissionProductsStock}, resp.Scopes)
+	apiKeyRepo.AssertExpectations(t)
+}
+
+func TestAPIKeyService_AuthenticateAPIKey_Rejected(t *testing.T) {
+	revokedAt := time.Now()
+	testCases := []struct {
+		name    string
+		key     entity.APIKey
+		wantErr error
+	}{
+		{"revoked", entity.APIKey{ID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour), RevokedAt: &revokedAt},
+			errs.ErrAPIKeyInvalid},
+		{"expired", entity.APIKey{ID: uuid.New(), ExpiresAt: time.Now().Add(-time.Minute)}, errs.ErrAPIKeyExpired},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ks, apiKeyRepo, _, ctx := setupAPIKeyServiceMock()
+			apiKeyRepo.On("GetAPIKeyByHash", ctx, (*gorm.DB)(nil), util.HashToken("mak_raw")).Return(tc.key, nil)
+
+			_, err := ks.AuthenticateAPIKey(ctx, "mak_raw")
+			require.ErrorIs(t, err, tc.wantErr)
+			apiKeyRepo.AssertNotCalled(t, "TouchAPIKey", mock.Anything, mock.Anything, mock.Anything,
+				mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package service_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	"myapp/core/service"
+	"myapp/support/base"
+	"myapp/support/constant"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// --- Mock Query ---
+
+type MockAuditLogQuery struct {
+	mock.Mock
+}
+
+func (m *MockAuditLogQuery) GetAllAuditLogs(ctx context.Context, req dto.AuditLogGetsRequest) ([]entity.AuditLog, base.PaginationResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).([]entity.AuditLog), args.Get(1).(base.PaginationResponse), args.Error(2)
+}
+
+// --- Test Helpers ---
+
+// auditedUserService records every audit log entry it writes in logs.
+func auditedUserService(t *testing.T) (service.UserService, *MockUserRepository, *[]entity.AuditLog) {
+	t.Helper()
+	repo, txRepo, auditLogRepo := new(MockUserRepository), new(MockTxRepository), new(MockAuditLogRepository)
+	policy, _ := service.NewPasswordPolicy()
+	us := service.NewUserService(repo, new(MockUserQuery), new(MockRoleRepository), txRepo, auditLogRepo, nil, nil, policy)
+
+	logs := &[]entity.AuditLog{}
+	tx := &gorm.DB{}
+	txRepo.On("BeginTx", mock.Anything).Return(tx, nil)
+	txRepo.On("CommitOrRollbackTx", mock.Anything, tx, mock.Anything).Return()
+	auditLogRepo.On("CreateAuditLog", mock.Anything, tx, mock.AnythingOfType("entity.AuditLog")).
+		Run(func(args mock.Arguments) { *logs = append(*logs, args.Get(2).(entity.AuditLog)) }).
+		Return(entity.AuditLog{}, nil)
+	return us, repo, logs
+}
+
+// requestContext mimics a gin context after the RequestID and Authenticate
+// middlewares ran.
+func requestContext(keys map[string]any) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	for k, v := range keys {
+		c.Set(k, v)
+	}
+	return c
+}
+
+// --- Tests ---
+
+func TestAuditLog_RecordsActorAndRedacts(t *testing.T) {
+	us, repo, logs := auditedUserService(t)
+
+	adminID, impersonatorID := uuid.New(), uuid.New()
+	ctx := requestContext(map[string]any{
+		"ID":         adminID.String(),
+		"ROLE":       constant.EnumRoleAdmin,
+		"ACTOR_ID":   impersonatorID.String(),
+		"REQUEST_ID": "req-1",
+		"CLIENT_IP":  "10.0.0.1",
+	})
+
+	user := entity.User{ID: uuid.New(), Name: "D", Email: "d@mail.test", Password: "hash"}
+	repo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrID, user.ID.String()).Return(user, nil)
+	repo.On("DeleteUserByID", ctx, mock.Anything, user.ID.String()).Return(nil)
+
+	require.NoError(t, us.DeleteUserByID(ctx, user.ID.String()))
+	require.Len(t, *logs, 1)
+
+	log := (*logs)[0]
+	require.Equal(t, constant.EnumAuditActionDelete, log.Action)
+	require.Equal(t, constant.EnumAuditEntityUser, log.EntityType)
+	require.Equal(t, user.ID.String(), log.EntityID)
+	require.Equal(t, constant.EnumAuditActorUser, log.ActorType)
+	require.Equal(t, adminID, *log.ActorID)
+	require.Equal(t, impersonatorID, *log.ImpersonatorID)
+	require.Equal(t, "req-1", log.RequestID)
+	require.Equal(t, "10.0.0.1", log.IPAddress)
+
+	require.JSONEq(t, `"d@mail.test"`, string(log.Changes["email"].Before))
+	require.Nil(t, log.Changes["email"].After)
+	require.JSONEq(t, `"[redacted]"`, string(log.Changes["password"].Before))
+	require.NotContains(t, string(mustJSON(t, log.Changes)), "hash")
+}
+
+func TestAuditLog_SkipsUnchangedUpdate(t *testing.T) {
+	us, repo, logs := auditedUserService(t)
+	ctx := context.Background()
+
+	user := entity.User{ID: uuid.New(), Name: "Same"}
+	repo.On("GetUserByPrimaryKey", ctx, mock.Anything, constant.DBAttrID, user.ID.String()).Return(user, nil)
+	repo.On("UpdateUser", ctx, mock.Anything, mock.AnythingOfType("entity.User")).Return(nil)
+
+	_, err := us.UpdateSelfName(ctx, dto.UserNameUpdateRequest{ID: user.ID.String(), Name: "Same"})
+	require.NoError(t, err)
+	require.Empty(t, *logs)
+}
+
+func TestAuditLog_SystemActor(t *testing.T) {
+	us, repo, logs := auditedUserService(t)
+	ctx := context.Background()
+
+	before := entity.User{ID: uuid.New(), Name: "Old"}
+	after := entity.User{ID: before.ID, Name: "New"}
+	repo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrID, before.ID.String()).Return(before, nil)
+	repo.On("UpdateUser", ctx, mock.Anything, mock.AnythingOfType("entity.User")).Return(nil)
+	repo.On("GetUserByPrimaryKey", ctx, mock.AnythingOfType("*gorm.DB"), constant.DBAttrID, before.ID.String()).
+		Return(after, nil)
+
+	_, err := us.UpdateSelfName(ctx, dto.UserNameUpdateRequest{ID: before.ID.String(), Name: "New"})
+	require.NoError(t, err)
+	require.Len(t, *logs, 1)
+	require.Equal(t, constant.EnumAuditActorSystem, (*logs)[0].ActorType)
+	require.Nil(t, (*logs)[0].ActorID)
+	require.Equal(t, entity.AuditChanges{
+		"name": {Before: json.RawMessage(`"Old"`), After: json.RawMessage(`"New"`)},
+	}, (*logs)[0].Changes)
+}
+
+func TestAuditLogService_GetAllAuditLogs(t *testing.T) {
+	q := new(MockAuditLogQuery)
+	s := service.NewAuditLogService(q)
+	ctx := context.Background()
+
+	actorID := uuid.New()
+	logs := []entity.AuditLog{{
+		ID:         uuid.New(),
+		ActorID:    &actorID,
+		ActorType:  constant.EnumAuditActorUser,
+		Action:     constant.EnumAuditActionUpdate,
+		EntityType: constant.EnumAuditEntityProduct,
+		EntityID:   "p-1",
+		Changes: entity.AuditChanges{
+			"stock": {Before: json.RawMessage(`1`), After: json.RawMessage(`2`)},
+		},
+	}}
+	q.On("GetAllAuditLogs", ctx, mock.AnythingOfType("dto.AuditLogGetsRequest")).
+		Return(logs, base.PaginationResponse{LastPage: 1, Total: 1}, nil)
+
+	got, page, err := s.GetAllAuditLogs(ctx, dto.AuditLogGetsRequest{})
+	require.NoError(t, err)
+	require.Len(t, got, 1)
+	require.Equal(t, actorID.String(), *got[0].ActorID)
+	require.Nil(t, got[0].ImpersonatorID)
+	require.JSONEq(t, `2`, string(got[0].Changes["stock"].After))
+	require.Equal(t, int64(1), page.Total)
+}
+
+func mustJSON(t *testing.T, v any) []byte {
+	t.Helper()
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return b
+}
@@ -0,0 +1,446 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	"myapp/core/service"
+	"myapp/support/base"
+	"myapp/support/constant"
+	"myapp/support/util"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// --- Mock Repository ---
+
+type MockRefreshTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockRefreshTokenRepository) DB() *gorm.DB {
+	return nil
+}
+
+func (m *MockRefreshTokenRepository) CreateRefreshToken(ctx context.Context, tx *gorm.DB,
+	token entity.RefreshToken) (entity.RefreshToken, error) {
+	args := m.Called(ctx, tx, token)
+	return args.Get(0).(entity.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) GetRefreshTokenByHash(ctx context.Context, tx *gorm.DB,
+	hash string) (entity.RefreshToken, error) {
+	args := m.Called(ctx, tx, hash)
+	return args.Get(0).(entity.RefreshToken), args.Error(1)
+}
+
+func (m *MockRefreshTokenRepository) RevokeRefreshToken(ctx context.Context, tx *gorm.DB,
+	id string, replacedBy *uuid.UUID) error {
+	args := m.Called(ctx, tx, id, replacedBy)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeRefreshTokenFamily(ctx context.Context, tx *gorm.DB,
+	familyID string, reason string) error {
+	args := m.Called(ctx, tx, familyID, reason)
+	return args.Error(0)
+}
+
+func (m *MockRefreshTokenRepository) RevokeUserRefreshTokens(ctx context.Context, tx *gorm.DB,
+	userID string, reason string) error {
+	args := m.Called(ctx, tx, userID, reason)
+	return args.Error(0)
+}
+
+type MockTokenRevocationRepository struct {
+	mock.Mock
+}
+
+func (m *MockTokenRevocationRepository) DB() *gorm.DB {
+	return nil
+}
+
+func (m *MockTokenRevocationRepository) CreateTokenRevocation(ctx context.Context, tx *gorm.DB,
+	revocation entity.TokenRevocation) error {
+	args := m.Called(ctx, tx, revocation)
+	return args.Error(0)
+}
+
+func (m *MockTokenRevocationRepository) IsTokenRevoked(ctx context.Context, userID string,
+	tokenID string, sessionID string, issuedAt time.Time) (bool, error) {
+	args := m.Called(ctx, userID, tokenID, sessionID, issuedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepository) DB() *gorm.DB {
+	return nil
+}
+
+func (m *MockSessionRepository) CreateSession(ctx context.Context, tx *gorm.DB,
+	session entity.Session) (entity.Session, error) {
+	args := m.Called(ctx, tx, session)
+	return args.Get(0).(entity.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) GetActiveUserSessions(ctx context.Context, tx *gorm.DB,
+	userID string) ([]entity.Session, error) {
+	args := m.Called(ctx, tx, userID)
+	return args.Get(0).([]entity.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) TouchSession(ctx context.Context, tx *gorm.DB, id string,
+	now time.Time, staleBefore time.Time) error {
+	args := m.Called(ctx, tx, id, now, staleBefore)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) ExtendSession(ctx context.Context, tx *gorm.DB, id string,
+	now time.Time, expiresAt time.Time) error {
+	args := m.Called(ctx, tx, id, now, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) EndSession(ctx context.Context, tx *gorm.DB, userID string, id string) error {
+	args := m.Called(ctx, tx, userID, id)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) EndUserSessions(ctx context.Context, tx *gorm.DB, userID string) error {
+	args := m.Called(ctx, tx, userID)
+	return args.Error(0)
+}
+
+// --- Test Helpers ---
+
+func setupAuthServiceMock(t *testing.T) (service.AuthService, *MockRefreshTokenRepository, *MockUserRepository,
+	*MockTxRepository, context.Context) {
+	as, refreshRepo, _, userRepo, txRepo, ctx := setupAuthServiceWithRevocationMock(t)
+	return as, refreshRepo, userRepo, txRepo, ctx
+}
+
+func setupAuthServiceWithRevocationMock(t *testing.T) (service.AuthService, *MockRefreshTokenRepository,
+	*MockTokenRevocationRepository, *MockUserRepository, *MockTxRepository, context.Context) {
+	as, refreshRepo, revocationRepo, _, userRepo, txRepo, ctx := setupAuthServiceWithSessionMock(t)
+	return as, refreshRepo, revocationRepo, userRepo, txRepo, ctx
+}
+
+func setupAuthServiceWithSessionMock(t *testing.T) (service.AuthService, *MockRefreshTokenRepository,
+	*MockTokenRevocationRepository, *MockSessionRepository, *MockUserRepository, *MockTxRepository,
+	context.Context) {
+	refreshRepo := new(MockRefreshTokenRepository)
+	revocationRepo := new(MockTokenRevocationRepository)
+	sessionRepo := new(MockSessionRepository)
+	userRepo := new(MockUserRepository)
+	txRepo := new(MockTxRepository)
+	jwtS, err := service.NewJWTService()
+	require.NoError(t, err)
+	as := service.NewAuthService(jwtS, refreshRepo, revocationRepo, sessionRepo, userRepo, txRepo)
+
+	return as, refreshRepo, revocationRepo, sessionRepo, userRepo, txRepo, context.Background()
+}
+
+// --- Tests ---
+
+func TestAuthService_IssueTokens(t *testing.T) {
+	as, refreshRepo, _, sessionRepo, _, txRepo, ctx := setupAuthServiceWithSessionMock(t)
+
+	userID := uuid.New()
+	tx := &gorm.DB{}
+	var session entity.Session
+	txRepo.On("BeginTx", ctx).Return(tx, nil)
+	txRepo.On("CommitOrRollbackTx", ctx, tx, nil).Return()
+	sessionRepo.On("CreateSession", ctx, tx, mock.Anything).Run(func(args mock.Arguments) {
+		session = args.Get(2).(entity.Session)
+	}).Return(entity.Session{}, nil)
+	refreshRepo.On("CreateRefreshToken", ctx, tx, mock.MatchedBy(func(tk entity.RefreshToken) bool {
+		return tk.UserID == userID && tk.FamilyID == session.ID && tk.ExpiresAt.Equal(session.ExpiresAt)
+	})).Return(entity.RefreshToken{}, nil)
+
+	resp, err := as.IssueTokens(ctx, userID.String(), constant.EnumRoleUser,
+		dto.UserSessionClient{UserAgent: "test-agent/1.0", IPAddress: "192.0.2.1"})
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.Token)
+	require.NotEmpty(t, resp.RefreshToken)
+	require.Positive(t, resp.ExpiresIn)
+	require.Equal(t, userID, session.UserID)
+	require.Equal(t, "test-agent/1.0", session.UserAgent)
+	require.Equal(t, "192.0.2.1", session.IPAddress)
+	refreshRepo.AssertExpectations(t)
+	txRepo.AssertExpectations(t)
+}
+
+func TestAuthService_RefreshTokens_Rotates(t *testing.T) {
+	as, refreshRepo, _, sessionRepo, userRepo, txRepo, ctx := setupAuthServiceWithSessionMock(t)
+
+	user := entity.User{ID: uuid.New(), Role: constant.EnumRoleUser}
+	stored := entity.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		FamilyID:  uuid.New(),
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	tx := &gorm.DB{}
+
+	refreshRepo.On("GetRefreshTokenByHash", ctx, (*gorm.DB)(nil), util.HashToken("raw")).Return(stored, nil)
+	userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), "id", user.ID.String()).Return(user, nil)
+	txRepo.On("BeginTx", ctx).Return(tx, nil)
+	txRepo.On("CommitOrRollbackTx", ctx, tx, nil).Return()
+	refreshRepo.On("RevokeRefreshToken", ctx, tx, stored.ID.String(), mock.AnythingOfType("*uuid.UUID")).Return(nil)
+	refreshRepo.On("CreateRefreshToken", ctx, tx, mock.MatchedBy(func(tk entity.RefreshToken) bool {
+		return tk.FamilyID == stored.FamilyID && tk.TokenHash != util.HashToken("raw")
+	})).Return(entity.RefreshToken{}, nil)
+	sessionRepo.On("ExtendSession", ctx, tx, stored.FamilyID.String(), mock.Anything, mock.Anything).Return(nil)
+
+	resp, err := as.RefreshTokens(ctx, dto.UserRefreshTokenRequest{RefreshToken: "raw"})
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.Token)
+	require.NotEqual(t, "raw", resp.RefreshToken)
+	refreshRepo.AssertExpectations(t)
+	sessionRepo.AssertExpectations(t)
+	txRepo.AssertExpectations(t)
+}
+
+func TestAuthService_RefreshTokens_ReuseRevokesFamily(t *testing.T) {
+	as, refreshRepo, _, _, ctx := setupAuthServiceMock(t)
+
+	revokedAt := time.Now().Add(-time.Minute)
+	reason := constant.EnumRefreshTokenRevokedRotated
+	stored := entity.RefreshToken{
+		ID:            uuid.New(),
+		UserID:        uuid.New(),
+		FamilyID:      uuid.New(),
+		ExpiresAt:     time.Now().Add(time.Hour),
+		RevokedAt:     &revokedAt,
+		RevokedReason: &reason,
+	}
+
+	refreshRepo.On("GetRefreshTokenByHash", ctx, (*gorm.DB)(nil), util.HashToken("stale")).Return(stored, nil)
+	refreshRepo.On("RevokeRefreshTokenFamily", ctx, (*gorm.DB)(nil), stored.FamilyID.String(),
+		constant.EnumRefreshTokenRevokedReuse).Return(nil)
+
+	_, err := as.RefreshTokens(ctx, dto.UserRefreshTokenRequest{RefreshToken: "stale"})
+	require.ErrorIs(t, err, errs.ErrRefreshTokenReused)
+	refreshRepo.AssertExpectations(t)
+}
+
+func TestAuthService_RefreshTokens_RevokedOnPurpose(t *testing.T) {
+	revokedAt := time.Now().Add(-time.Minute)
+	for _, reason := range []string{
+		constant.EnumRefreshTokenRevokedLogout,
+		constant.EnumRefreshTokenRevokedSessionEnded,
+		constant.EnumRefreshTokenRevokedSessionsRevoked,
+		constant.EnumRefreshTokenRevokedReuse,
+	} {
+		t.Run(reason, func(t *testing.T) {
+			as, refreshRepo, _, _, ctx := setupAuthServiceMock(t)
+			stored := entity.RefreshToken{
+				ID:            uuid.New(),
+				UserID:        uuid.New(),
+				FamilyID:      uuid.New(),
+				ExpiresAt:     time.Now().Add(time.Hour),
+				RevokedAt:     &revokedAt,
+				RevokedReason: &reason,
+			}
+			refreshRepo.On("GetRefreshTokenByHash", ctx, (*gorm.DB)(nil), util.HashToken("old")).Return(stored, nil)
+
+			// Refused, but not taken for theft
+			_, err := as.RefreshTokens(ctx, dto.UserRefreshTokenRequest{RefreshToken: "old"})
+			require.ErrorIs(t, err, errs.ErrRefreshTokenRevoked)
+			refreshRepo.AssertNotCalled(t, "RevokeRefreshTokenFamily", mock.Anything, mock.Anything, mock.Anything,
+				mock.Anything)
+		})
+	}
+}
+
+func TestAuthService_RefreshTokens_Expired(t *testing.T) {
+	as, refreshRepo, _, _, ctx := setupAuthServiceMock(t)
+
+	stored := entity.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    uuid.New(),
+		FamilyID:  uuid.New(),
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}
+	refreshRepo.On("GetRefreshTokenByHash", ctx, (*gorm.DB)(nil), util.HashToken("old")).Return(stored, nil)
+
+	_, err := as.RefreshTokens(ctx, dto.UserRefreshTokenRequest{RefreshToken: "old"})
+	require.ErrorIs(t, err, errs.ErrRefreshTokenExpired)
+}
+
+func TestAuthService_RefreshTokens_BeforePasswordChange(t *testing.T) {
+	as, refreshRepo, userRepo, txRepo, ctx := setupAuthServiceMock(t)
+
+	changedAt := time.Now().Add(-time.Minute)
+	user := entity.User{ID: uuid.New(), Role: constant.EnumRoleUser, PasswordChangedAt: &changedAt}
+	stored := entity.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		FamilyID:  uuid.New(),
+		ExpiresAt: time.Now().Add(time.Hour),
+		Model:     base.Model{CreatedAt: changedAt.Add(-time.Hour)},
+	}
+	refreshRepo.On("GetRefreshTokenByHash", ctx, (*gorm.DB)(nil), util.HashToken("old")).Return(stored, nil)
+	userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), "id", user.ID.String()).Return(user, nil)
+
+	_, err := as.RefreshTokens(ctx, dto.UserRefreshTokenRequest{RefreshToken: "old"})
+	require.ErrorIs(t, err, errs.ErrRefreshTokenRevoked)
+	txRepo.AssertNotCalled(t, "BeginTx", mock.Anything)
+}
+
+func TestAuthService_VerifyAccessToken_Revoked(t *testing.T) {
+	as, _, revocationRepo, _, _, ctx := setupAuthServiceWithRevocationMock(t)
+
+	claims := &service.TokenClaims{UserID: uuid.NewString(), TokenID: "jti", IssuedAt: time.Now()}
+	revocationRepo.On("IsTokenRevoked", ctx, claims.UserID, claims.TokenID, "", claims.IssuedAt).Return(true, nil)
+
+	err := as.VerifyAccessToken(ctx, claims)
+	require.ErrorIs(t, err, errs.ErrAccessTokenRevoked)
+}
+
+func TestAuthService_VerifyAccessToken_ImpersonatorRevoked(t *testing.T) {
+	as, _, revocationRepo, _, _, ctx := setupAuthServiceWithRevocationMock(t)
+
+	claims := &service.TokenClaims{UserID: uuid.NewString(), TokenID: "jti", ActorID: uuid.NewString(),
+		IssuedAt: time.Now()}
+	revocationRepo.On("IsTokenRevoked", ctx, claims.UserID, claims.TokenID, "", claims.IssuedAt).Return(false, nil)
+	revocationRepo.On("IsTokenRevoked", ctx, claims.ActorID, "", "", claims.IssuedAt).Return(true, nil)
+
+	err := as.VerifyAccessToken(ctx, claims)
+	require.ErrorIs(t, err, errs.ErrAccessTokenRevoked)
+}
+
+func TestAuthService_VerifyAccessToken_TouchesSession(t *testing.T) {
+	as, _, revocationRepo, sessionRepo, _, _, ctx := setupAuthServiceWithSessionMock(t)
+
+	claims := &service.TokenClaims{UserID: uuid.NewString(), TokenID: "jti", SessionID: uuid.NewString(),
+		IssuedAt: time.Now()}
+	revocationRepo.On("IsTokenRevoked", ctx, claims.UserID, claims.TokenID, claims.SessionID, claims.IssuedAt).
+		Return(false, nil)
+	sessionRepo.On("TouchSession", ctx, (*gorm.DB)(nil), claims.SessionID, mock.Anything,
+		mock.MatchedBy(func(staleBefore time.Time) bool {
+			return staleBefore.Before(time.Now().Add(-constant.SessionLastSeenInterval + time.Second))
+		})).Return(nil)
+
+	require.NoError(t, as.VerifyAccessToken(ctx, claims))
+	sessionRepo.AssertExpectations(t)
+}
+
+func TestAuthService_Logout(t *testing.T) {
+	as, refreshRepo, revocationRepo, _, txRepo, ctx := setupAuthServiceWithRevocationMock(t)
+
+	userID := uuid.New()
+	stored := entity.RefreshToken{ID: uuid.New(), UserID: userID, FamilyID: uuid.New()}
+	req := dto.UserLogoutRequest{
+		UserID:       userID.String(),
+		TokenID:      "jti",
+		ExpiresAt:    time.Now().Add(time.Hour),
+		RefreshToken: "raw",
+	}
+	tx := &gorm.DB{}
+
+	refreshRepo.On("GetRefreshTokenByHash", ctx, (*gorm.DB)(nil), util.HashToken("raw")).Return(stored, nil)
+	txRepo.On("BeginTx", ctx).Return(tx, nil)
+	txRepo.On("CommitOrRollbackTx", ctx, tx, nil).Return()
+	revocationRepo.On("CreateTokenRevocation", ctx, tx, mock.MatchedBy(func(r entity.TokenRevocation) bool {
+		return r.UserID == userID && r.TokenID != nil && *r.TokenID == "jti" && r.ExpiresAt.Equal(req.ExpiresAt)
+	})).Return(nil)
+	refreshRepo.On("RevokeRefreshTokenFamily", ctx, tx, stored.FamilyID.String(),
+		constant.EnumRefreshTokenRevokedLogout).Return(nil)
+
+	err := as.Logout(ctx, req)
+	require.NoError(t, err)
+	refreshRepo.AssertExpectations(t)
+	revocationRepo.AssertExpectations(t)
+}
+
+func TestAuthService_Logout_ForeignRefreshToken(t *testing.T) {
+	as, refreshRepo, _, _, _, ctx := setupAuthServiceWithRevocationMock(t)
+
+	stored := entity.RefreshToken{ID: uuid.New(), UserID: uuid.New(), FamilyID: uuid.New()}
+	refreshRepo.On("GetRefreshTokenByHash", ctx, (*gorm.DB)(nil), util.HashToken("other")).Return(stored, nil)
+
+	err := as.Logout(ctx, dto.UserLogoutRequest{UserID: uuid.NewString(), TokenID: "jti", RefreshToken: "other"})
+	require.ErrorIs(t, err, errs.ErrRefreshTokenInvalid)
+}
+
+func TestAuthService_RevokeUserSessions(t *testing.T) {
+	as, refreshRepo, revocationRepo, sessionRepo, _, txRepo, ctx := setupAuthServiceWithSessionMock(t)
+
+	userID := uuid.New()
+	tx := &gorm.DB{}
+	txRepo.On("BeginTx", ctx).Return(tx, nil)
+	txRepo.On("CommitOrRollbackTx", ctx, tx, nil).Return()
+	refreshRepo.On("RevokeUserRefreshTokens", ctx, tx, userID.String(),
+		constant.EnumRefreshTokenRevokedSessionsRevoked).Return(nil)
+	sessionRepo.On("EndUserSessions", ctx, tx, userID.String()).Return(nil)
+	revocationRepo.On("CreateTokenRevocation", ctx, tx, mock.MatchedBy(func(r entity.TokenRevocation) bool {
+		return r.UserID == userID && r.TokenID == nil && r.RevokedBefore != nil && r.ExpiresAt.After(time.Now())
+	})).Return(nil)
+
+	err := as.RevokeUserSessions(ctx, userID.String())
+	require.NoError(t, err)
+	refreshRepo.AssertExpectations(t)
+	revocationRepo.AssertExpectations(t)
+}
+
+func TestAuthService_GetUserSessions(t *testing.T) {
+	as, _, _, sessionRepo, _, _, ctx := setupAuthServiceWithSessionMock(t)
+
+	userID := uuid.NewString()
+	current, other := entity.Session{ID: uuid.New()}, entity.Session{ID: uuid.New()}
+	sessionRepo.On("GetActiveUserSessions", ctx, (*gorm.DB)(nil), userID).Return([]entity.Session{other, current}, nil)
+
+	sessions, err := as.GetUserSessions(ctx, userID, current.ID.String())
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	require.False(t, sessions[0].Current)
+	require.True(t, sessions[1].Current)
+}
+
+func TestAuthService_EndUserSession(t *testing.T) {
+	as, refreshRepo, revocationRepo, sessionRepo, _, txRepo, ctx := setupAuthServiceWithSessionMock(t)
+
+	userID, sessionID := uuid.New(), uuid.New()
+	tx := &gorm.DB{}
+	txRepo.On("BeginTx", ctx).Return(tx, nil)
+	txRepo.On("CommitOrRollbackTx", ctx, tx, nil).Return()
+	sessionRepo.On("EndSession", ctx, tx, userID.String(), sessionID.String()).Return(nil)
+	refreshRepo.On("RevokeRefreshTokenFamily", ctx, tx, sessionID.String(),
+		constant.EnumRefreshTokenRevokedSessionEnded).Return(nil)
+	revocationRepo.On("CreateTokenRevocation", ctx, tx, mock.MatchedBy(func(r entity.TokenRevocation) bool {
+		return r.UserID == userID && r.SessionID != nil && *r.SessionID == sessionID && r.TokenID == nil &&
+			r.ExpiresAt.After(time.Now())
+	})).Return(nil)
+
+	err := as.EndUserSession(ctx, dto.UserSessionEndRequest{UserID: userID.String(), SessionID: sessionID.String()})
+	require.NoError(t, err)
+	refreshRepo.AssertExpectations(t)
+	revocationRepo.AssertExpectations(t)
+	sessionRepo.AssertExpectations(t)
+}
+
+func TestAuthService_EndUserSession_Foreign(t *testing.T) {
+	as, refreshRepo, _, sessionRepo, _, txRepo, ctx := setupAuthServiceWithSessionMock(t)
+
+	userID, sessionID := uuid.NewString(), uuid.NewString()
+	tx := &gorm.DB{}
+	txRepo.On("BeginTx", ctx).Return(tx, nil)
+	txRepo.On("CommitOrRollbackTx", ctx, tx, errs.ErrSessionNotFound).Return()
+	sessionRepo.On("EndSession", ctx, tx, userID, sessionID).Return(errs.ErrSessionNotFound)
+
+	err := as.EndUserSession(ctx, dto.UserSessionEndRequest{UserID: userID, SessionID: sessionID})
+	require.ErrorIs(t, err, errs.ErrSessionNotFound)
+	refreshRepo.AssertNotCalled(t, "RevokeRefreshTokenFamily", mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything)
+}
@@ -0,0 +1,162 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	"myapp/core/service"
+	"myapp/support/constant"
+	"myapp/support/util"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// --- Mock Repository ---
+
+type MockEmailVerificationTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockEmailVerificationTokenRepository) DB() *gorm.DB {
+	return nil
+}
+
+func (m *MockEmailVerificationTokenRepository) CreateEmailVerificationToken(ctx context.Context, tx *gorm.DB,
+	token entity.EmailVerificationToken) (entity.EmailVerificationToken, error) {
+	args := m.Called(ctx, tx, token)
+	return args.Get(0).(entity.EmailVerificationToken), args.Error(1)
+}
+
+func (m *MockEmailVerificationTokenRepository) GetEmailVerificationTokenByHash(ctx context.Context, tx *gorm.DB,
+	hash string) (entity.EmailVerificationToken, error) {
+	args := m.Called(ctx, tx, hash)
+	return args.Get(0).(entity.EmailVerificationToken), args.Error(1)
+}
+
+func (m *MockEmailVerificationTokenRepository) UseEmailVerificationToken(ctx context.Context,
+	tx *gorm.DB, id string) error {
+	args := m.Called(ctx, tx, id)
+	return args.Error(0)
+}
+
+func (m *MockEmailVerificationTokenRepository) InvalidateUserEmailVerificationTokens(ctx context.Context,
+	tx *gorm.DB, userID string) error {
+	args := m.Called(ctx, tx, userID)
+	return args.Error(0)
+}
+
+// --- Test Helpers ---
+
+func setupEmailVerificationServiceMock() (service.EmailVerificationService, *MockUserRepository,
+	*MockEmailVerificationTokenRepository, *MockTxRepository, *recordingMailer, context.Context) {
+	userRepo := new(MockUserRepository)
+	verificationRepo := new(MockEmailVerificationTokenRepository)
+	txRepo := new(MockTxRepository)
+	mailer := new(recordingMailer)
+	vs := service.NewEmailVerificationService(userRepo, verificationRepo, txRepo, mailer)
+
+	return vs, userRepo, verificationRepo, txRepo, mailer, context.Background()
+}
+
+// --- Tests ---
+
+func TestEmailVerificationService_SendVerificationEmail(t *testing.T) {
+	vs, userRepo, verificationRepo, txRepo, mailer, ctx := setupEmailVerificationServiceMock()
+
+	user := entity.User{ID: uuid.New(), Name: "Vera", Email: "vera@mail.test"}
+	tx := &gorm.DB{}
+
+	userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrID, user.ID.String()).Return(user, nil)
+	txRepo.On("BeginTx", ctx).Return(tx, nil)
+	txRepo.On("CommitOrRollbackTx", ctx, tx, nil).Return()
+	verificationRepo.On("InvalidateUserEmailVerificationTokens", ctx, tx, user.ID.String()).Return(nil)
+	verificationRepo.On("CreateEmailVerificationToken", ctx, tx, mock.MatchedBy(func(tk entity.EmailVerificationToken) bool {
+		return tk.UserID == user.ID && tk.TokenHash != "" && tk.ExpiresAt.After(time.Now())
+	})).Return(entity.EmailVerificationToken{}, nil)
+
+	err := vs.SendVerificationEmail(ctx, user.ID.String())
+	require.NoError(t, err)
+	require.Len(t, mailer.sent, 1)
+	require.Contains(t, mailer.sent[0].Body, constant.DefaultEmailVerificationURL+"?token=")
+}
+
+func TestEmailVerificationService_ResendVerificationEmail_AlreadyVerified(t *testing.T) {
+	vs, userRepo, _, _, mailer, ctx := setupEmailVerificationServiceMock()
+
+	verifiedAt := time.Now()
+	user := entity.User{ID: uuid.New(), Email: "vera@mail.test", EmailVerifiedAt: &verifiedAt}
+	userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrEmail, user.Email).Return(user, nil)
+
+	err := vs.ResendVerificationEmail(ctx, dto.UserVerifyEmailResendRequest{Email: user.Email})
+	require.NoError(t, err)
+	require.Empty(t, mailer.sent)
+}
+
+func TestEmailVerificationService_VerifyEmail(t *testing.T) {
+	vs, userRepo, verificationRepo, txRepo, _, ctx := setupEmailVerificationServiceMock()
+
+	token := entity.EmailVerificationToken{ID: uuid.New(), UserID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)}
+	tx := &gorm.DB{}
+
+	verificationRepo.On("GetEmailVerificationTokenByHash", ctx, (*gorm.DB)(nil), util.HashToken("raw")).Return(token, nil)
+	txRepo.On("BeginTx", ctx).Return(tx, nil)
+	txRepo.On("CommitOrRollbackTx", ctx, tx, nil).Return()
+	verificationRepo.On("UseEmailVerificationToken", ctx, tx, token.ID.String()).Return(nil)
+	userRepo.On("UpdateUser", ctx, tx, mock.MatchedBy(func(u entity.User) bool {
+		return u.ID == token.UserID && u.EmailVerifiedAt != nil
+	})).Return(nil)
+
+	err := vs.VerifyEmail(ctx, dto.UserVerifyEmailRequest{Token: "raw"})
+	require.NoError(t, err)
+	userRepo.AssertExpectations(t)
+}
+
+func TestEmailVerificationService_VerifyEmail_Expired(t *testing.T) {
+	vs, _, verificationRepo, _, _, ctx := setupEmailVerificationServiceMock()
+
+	token := entity.EmailVerificationToken{ID: uuid.New(), UserID: uuid.New(), ExpiresAt: time.Now().Add(-time.Minute)}
+	verificationRepo.On("GetEmailVerificationTokenByHash", ctx, (*gorm.DB)(nil), util.HashToken("old")).Return(token, nil)
+
+	err := vs.VerifyEmail(ctx, dto.UserVerifyEmailRequest{Token: "old"})
+	require.ErrorIs(t, err, errs.ErrEmailVerificationTokenExpired)
+}
+
+func TestEmailVerificationService_CheckEmailVerified_Policies(t *testing.T) {
+	unverified := entity.User{ID: uuid.New()}
+
+	testCases := []struct {
+		policy     string
+		checkpoint string
+		wantErr    error
+	}{
+		{constant.EnumEmailVerificationNone, constant.EnumEmailVerificationLogin, nil},
+		{constant.EnumEmailVerificationNone, constant.EnumEmailVerificationRoutes, nil},
+		{constant.EnumEmailVerificationRoutes, constant.EnumEmailVerificationLogin, nil},
+		{constant.EnumEmailVerificationRoutes, constant.EnumEmailVerificationRoutes, errs.ErrEmailNotVerified},
+		{constant.EnumEmailVerificationLogin, constant.EnumEmailVerificationLogin, errs.ErrEmailNotVerified},
+		{constant.EnumEmailVerificationLogin, constant.EnumEmailVerificationRoutes, errs.ErrEmailNotVerified},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.policy+"/"+tc.checkpoint, func(t *testing.T) {
+			t.Setenv("EMAIL_VERIFICATION_POLICY", tc.policy)
+			vs, userRepo, _, _, _, ctx := setupEmailVerificationServiceMock()
+			userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrID, unverified.ID.String()).
+				Return(unverified, nil)
+
+			err := vs.CheckEmailVerified(ctx, unverified.ID.String(), tc.checkpoint)
+			if tc.wantErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package service_test
+
+import (
+	"strings"
+	"testing"
+
+	errs "myapp/core/helper/errors"
+	"myapp/core/service"
+	"myapp/support/constant"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileURLService_PublicAndPrivate(t *testing.T) {
+	t.Setenv("FILE_URL_BASE", "https://api.example.com/api/v1/files/")
+	fileURLs, err := service.NewFileURLService()
+	require.NoError(t, err)
+
+	image := constant.EnumFileNamespaceProductImage + "/" + uuid.NewString()
+	require.Equal(t, "https://api.example.com/api/v1/files/"+image, fileURLs.URL(image))
+	require.NoError(t, fileURLs.Verify(image, "", ""))
+
+	picture := constant.EnumFileNamespaceUserPicture + "/" + uuid.NewString()
+	url := fileURLs.URL(picture)
+	path, query, ok := strings.Cut(url, "?")
+	require.True(t, ok)
+	require.Equal(t, "https://api.example.com/api/v1/files/"+picture, path)
+
+	values := map[string]string{}
+	for _, pair := range strings.Split(query, "&") {
+		name, value, _ := strings.Cut(pair, "=")
+		values[name] = value
+	}
+	require.NoError(t, fileURLs.Verify(picture, values["expires"], values["signature"]))
+	require.ErrorIs(t, fileURLs.Verify(picture, "", ""), errs.ErrFileURLInvalid)
+	require.ErrorIs(t, fileURLs.Verify(picture, values["expires"]+"0", values["signature"]), errs.ErrFileURLInvalid)
+
+	require.Empty(t, fileURLs.URL(""))
+}
+
+func TestFileURLService_Visibility(t *testing.T) {
+	t.Setenv("FILE_VISIBILITY_USER_PICTURE", constant.EnumFileVisibilityPublic)
+	t.Setenv("FILE_VISIBILITY_PRODUCT_IMAGE", constant.EnumFileVisibilityPrivate)
+	fileURLs, err := service.NewFileURLService()
+	require.NoError(t, err)
+
+	require.NotContains(t, fileURLs.URL(constant.EnumFileNamespaceUserPicture+"/a"), "signature=")
+	require.Contains(t, fileURLs.URL(constant.EnumFileNamespaceProductImage+"/a"), "signature=")
+
+	t.Setenv("FILE_VISIBILITY_PRODUCT_IMAGE", "secret")
+	_, err = service.NewFileURLService()
+	require.ErrorContains(t, err, "unknown visibility")
+}
+
+func TestFileURLService_ProductionNeedsSecret(t *testing.T) {
+	t.Setenv("APP_ENV", "production")
+	t.Setenv("FILE_URL_SECRET", "")
+	_, err := service.NewFileURLService()
+	require.ErrorContains(t, err, "FILE_URL_SECRET")
+}
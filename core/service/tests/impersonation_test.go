@@ -0,0 +1,86 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	"myapp/core/service"
+	"myapp/support/constant"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// --- Test Helpers ---
+
+func setupImpersonationServiceMock(t *testing.T) (service.ImpersonationService, service.JWTService,
+	*MockUserRepository, *MockRoleRepository, context.Context) {
+	userRepo := new(MockUserRepository)
+	roleRepo := new(MockRoleRepository)
+	jwtS, err := service.NewJWTService()
+	require.NoError(t, err)
+	is := service.NewImpersonationService(jwtS, userRepo, roleRepo)
+
+	return is, jwtS, userRepo, roleRepo, context.Background()
+}
+
+func roleWith(name string, permissions ...string) entity.Role {
+	role := entity.Role{ID: uuid.New(), Name: name}
+	for _, permission := range permissions {
+		role.Permissions = append(role.Permissions, entity.Permission{Name: permission})
+	}
+	return role
+}
+
+// --- Tests ---
+
+func TestImpersonationService_Impersonate(t *testing.T) {
+	is, jwtS, userRepo, roleRepo, ctx := setupImpersonationServiceMock(t)
+
+	actorID := uuid.NewString()
+	user := entity.User{ID: uuid.New(), Role: constant.EnumRoleUser}
+	userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrID, user.ID.String()).Return(user, nil)
+	roleRepo.On("GetRoleByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrName, "support").
+		Return(roleWith("support", constant.EnumPermissionUsersImpersonate), nil)
+	roleRepo.On("GetRoleByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrName, constant.EnumRoleUser).
+		Return(roleWith(constant.EnumRoleUser), nil)
+
+	resp, err := is.Impersonate(ctx, dto.UserImpersonateRequest{
+		ActorID: actorID, ActorRole: "support", UserID: user.ID.String(),
+	})
+	require.NoError(t, err)
+	require.Empty(t, resp.RefreshToken)
+	require.Equal(t, constant.EnumRoleUser, resp.Role)
+	require.Equal(t, int64(constant.DefaultImpersonationTokenTTL.Seconds()), resp.ExpiresIn)
+
+	claims, err := jwtS.GetClaimsByToken(resp.Token)
+	require.NoError(t, err)
+	require.Equal(t, user.ID.String(), claims.UserID)
+	require.Equal(t, actorID, claims.ActorID)
+}
+
+func TestImpersonationService_Impersonate_Rejected(t *testing.T) {
+	is, _, userRepo, roleRepo, ctx := setupImpersonationServiceMock(t)
+
+	actorID := uuid.NewString()
+	_, err := is.Impersonate(ctx, dto.UserImpersonateRequest{ActorID: actorID, ActorRole: "support", UserID: actorID})
+	require.ErrorIs(t, err, errs.ErrImpersonateSelf)
+
+	// support staff cannot become an admin and gain their permissions
+	admin := entity.User{ID: uuid.New(), Role: constant.EnumRoleAdmin}
+	userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrID, admin.ID.String()).Return(admin, nil)
+	roleRepo.On("GetRoleByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrName, "support").
+		Return(roleWith("support", constant.EnumPermissionUsersImpersonate), nil)
+	roleRepo.On("GetRoleByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrName, constant.EnumRoleAdmin).
+		Return(roleWith(constant.EnumRoleAdmin, constant.EnumPermissionUsersImpersonate,
+			constant.EnumPermissionRolesManage), nil)
+
+	_, err = is.Impersonate(ctx, dto.UserImpersonateRequest{
+		ActorID: actorID, ActorRole: "support", UserID: admin.ID.String(),
+	})
+	require.ErrorIs(t, err, errs.ErrImpersonationNotAllowed)
+}
@@ -0,0 +1,248 @@
+package service_test
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	"myapp/core/service"
+	"myapp/support/constant"
+	"myapp/support/util"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// --- Mock Repository ---
+
+type MockInvitationRepository struct {
+	mock.Mock
+}
+
+func (m *MockInvitationRepository) DB() *gorm.DB {
+	return nil
+}
+
+func (m *MockInvitationRepository) CreateInvitation(ctx context.Context, tx *gorm.DB,
+	invitation entity.Invitation) (entity.Invitation, error) {
+	args := m.Called(ctx, tx, invitation)
+	return args.Get(0).(entity.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepository) GetAllInvitations(ctx context.Context, tx *gorm.DB) ([]entity.Invitation, error) {
+	args := m.Called(ctx, tx)
+	return args.Get(0).([]entity.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepository) GetInvitationByID(ctx context.Context, tx *gorm.DB,
+	id string) (entity.Invitation, error) {
+	args := m.Called(ctx, tx, id)
+	return args.Get(0).(entity.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepository) GetInvitationByHash(ctx context.Context, tx *gorm.DB,
+	hash string) (entity.Invitation, error) {
+	args := m.Called(ctx, tx, hash)
+	return args.Get(0).(entity.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepository) GetPendingInvitationByEmail(ctx context.Context, tx *gorm.DB,
+	email string, now time.Time) (entity.Invitation, error) {
+	args := m.Called(ctx, tx, email, now)
+	return args.Get(0).(entity.Invitation), args.Error(1)
+}
+
+func (m *MockInvitationRepository) RenewInvitation(ctx context.Context, tx *gorm.DB, id string,
+	hash string, expiresAt time.Time) error {
+	args := m.Called(ctx, tx, id, hash, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockInvitationRepository) AcceptInvitation(ctx context.Context, tx *gorm.DB, id string) error {
+	args := m.Called(ctx, tx, id)
+	return args.Error(0)
+}
+
+func (m *MockInvitationRepository) RevokeInvitation(ctx context.Context, tx *gorm.DB, id string) error {
+	args := m.Called(ctx, tx, id)
+	return args.Error(0)
+}
+
+// --- Test Helpers ---
+
+func setupInvitationServiceMock() (service.InvitationService, *MockInvitationRepository, *MockUserRepository,
+	*MockRoleRepository, *MockTxRepository, *recordingMailer, context.Context) {
+	invitationRepo := new(MockInvitationRepository)
+	userRepo := new(MockUserRepository)
+	roleRepo := new(MockRoleRepository)
+	txRepo := new(MockTxRepository)
+	mailer := new(recordingMailer)
+	policy, _ := service.NewPasswordPolicy()
+	is := service.NewInvitationService(invitationRepo, userRepo, roleRepo, txRepo, mailer, policy)
+
+	return is, invitationRepo, userRepo, roleRepo, txRepo, mailer, context.Background()
+}
+
+func mailedInvitationToken(t *testing.T, mailer *recordingMailer) string {
+	t.Helper()
+	require.NotEmpty(t, mailer.sent)
+	body := mailer.sent[len(mailer.sent)-1].Body
+	start := strings.Index(body, constant.DefaultInvitationURL)
+	require.GreaterOrEqual(t, start, 0)
+	link, err := url.Parse(strings.Fields(body[start:])[0])
+	require.NoError(t, err)
+	return link.Query().Get("token")
+}
+
+// --- Tests ---
+
+func TestInvitationService_CreateInvitation(t *testing.T) {
+	is, invitationRepo, userRepo, roleRepo, txRepo, mailer, ctx := setupInvitationServiceMock()
+
+	adminID := uuid.NewString()
+	tx := &gorm.DB{}
+	var stored entity.Invitation
+	roleRepo.On("GetRoleByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrName, "editor").
+		Return(roleWith("editor", constant.EnumPermissionProductsWrite), nil)
+	roleRepo.On("GetRoleByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrName, constant.EnumRoleAdmin).
+		Return(roleWith(constant.EnumRoleAdmin, constant.EnumPermissionProductsWrite, constant.EnumPermissionUsersInvite), nil)
+	userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrEmail, "new@mail.test").
+		Return(entity.User{}, errs.ErrUserNotFound)
+	invitationRepo.On("GetPendingInvitationByEmail", ctx, (*gorm.DB)(nil), "new@mail.test", mock.Anything).
+		Return(entity.Invitation{}, errs.ErrInvitationNotFound)
+	txRepo.On("BeginTx", ctx).Return(tx, nil)
+	txRepo.On("CommitOrRollbackTx", ctx, tx, nil).Return()
+	invitationRepo.On("CreateInvitation", ctx, tx, mock.AnythingOfType("entity.Invitation")).
+		Run(func(args mock.Arguments) { stored = args.Get(2).(entity.Invitation) }).
+		Return(entity.Invitation{ID: uuid.New(), Email: "new@mail.test", Role: "editor", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+
+	resp, err := is.CreateInvitation(ctx, dto.InvitationCreateRequest{
+		InvitedByID: adminID, InvitedByRole: constant.EnumRoleAdmin, Email: "new@mail.test", Role: "editor",
+	})
+	require.NoError(t, err)
+	require.Equal(t, dto.InvitationStatusPending, resp.Status)
+	require.Equal(t, "editor", stored.Role)
+	require.Equal(t, adminID, stored.InvitedByID.String())
+	require.WithinDuration(t, time.Now().Add(constant.DefaultInvitationTTL), stored.ExpiresAt, time.Minute)
+
+	// The mailed link carries the raw token, only its hash is stored
+	require.Equal(t, []string{"new@mail.test"}, mailer.sent[0].To)
+	require.Equal(t, util.HashToken(mailedInvitationToken(t, mailer)), stored.TokenHash)
+}
+
+func TestInvitationService_CreateInvitation_Rejected(t *testing.T) {
+	is, invitationRepo, userRepo, roleRepo, _, mailer, ctx := setupInvitationServiceMock()
+
+	roleRepo.On("GetRoleByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrName, constant.EnumRoleAdmin).
+		Return(roleWith(constant.EnumRoleAdmin, constant.EnumPermissionRolesManage, constant.EnumPermissionUsersInvite), nil)
+	roleRepo.On("GetRoleByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrName, "recruiter").
+		Return(roleWith("recruiter", constant.EnumPermissionUsersInvite), nil)
+	roleRepo.On("GetRoleByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrName, constant.EnumRoleUser).
+		Return(roleWith(constant.EnumRoleUser), nil)
+
+	// A recruiter cannot hand out admin
+	_, err := is.CreateInvitation(ctx, dto.InvitationCreateRequest{
+		InvitedByID: uuid.NewString(), InvitedByRole: "recruiter", Email: "x@mail.test", Role: constant.EnumRoleAdmin,
+	})
+	require.ErrorIs(t, err, errs.ErrInvitationRoleNotAllowed)
+
+	userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrEmail, "taken@mail.test").
+		Return(entity.User{ID: uuid.New()}, nil)
+	_, err = is.CreateInvitation(ctx, dto.InvitationCreateRequest{
+		InvitedByID: uuid.NewString(), InvitedByRole: "recruiter", Email: "taken@mail.test", Role: constant.EnumRoleUser,
+	})
+	require.ErrorIs(t, err, errs.ErrEmailAlreadyExists)
+
+	userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrEmail, "invited@mail.test").
+		Return(entity.User{}, errs.ErrUserNotFound)
+	invitationRepo.On("GetPendingInvitationByEmail", ctx, (*gorm.DB)(nil), "invited@mail.test", mock.Anything).
+		Return(entity.Invitation{ID: uuid.New()}, nil)
+	_, err = is.CreateInvitation(ctx, dto.InvitationCreateRequest{
+		InvitedByID: uuid.NewString(), InvitedByRole: "recruiter", Email: "invited@mail.test", Role: constant.EnumRoleUser,
+	})
+	require.ErrorIs(t, err, errs.ErrInvitationAlreadyPending)
+	require.Empty(t, mailer.sent)
+}
+
+func TestInvitationService_ResendInvitation(t *testing.T) {
+	is, invitationRepo, _, _, txRepo, mailer, ctx := setupInvitationServiceMock()
+
+	invitation := entity.Invitation{ID: uuid.New(), Email: "new@mail.test", Role: "editor",
+		TokenHash: "old", ExpiresAt: time.Now().Add(-time.Hour)}
+	tx := &gorm.DB{}
+	var renewedHash string
+	invitationRepo.On("GetInvitationByID", ctx, (*gorm.DB)(nil), invitation.ID.String()).Return(invitation, nil)
+	txRepo.On("BeginTx", ctx).Return(tx, nil)
+	txRepo.On("CommitOrRollbackTx", ctx, tx, nil).Return()
+	invitationRepo.On("RenewInvitation", ctx, tx, invitation.ID.String(), mock.AnythingOfType("string"),
+		mock.AnythingOfType("time.Time")).
+		Run(func(args mock.Arguments) { renewedHash = args.String(3) }).
+		Return(nil)
+
+	resp, err := is.ResendInvitation(ctx, invitation.ID.String())
+	require.NoError(t, err)
+	// an expired invitation is pending again
+	require.Equal(t, dto.InvitationStatusPending, resp.Status)
+	require.NotEqual(t, "old", renewedHash)
+	require.Equal(t, util.HashToken(mailedInvitationToken(t, mailer)), renewedHash)
+}
+
+func TestInvitationService_AcceptInvitation(t *testing.T) {
+	is, invitationRepo, userRepo, roleRepo, txRepo, _, ctx := setupInvitationServiceMock()
+
+	invitation := entity.Invitation{ID: uuid.New(), Email: "new@mail.test", Role: "editor",
+		ExpiresAt: time.Now().Add(time.Hour)}
+	tx := &gorm.DB{}
+	var created entity.User
+	invitationRepo.On("GetInvitationByHash", ctx, (*gorm.DB)(nil), util.HashToken("raw")).Return(invitation, nil)
+	roleRepo.On("GetRoleByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrName, "editor").
+		Return(roleWith("editor"), nil)
+	userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrEmail, "new@mail.test").
+		Return(entity.User{}, errs.ErrUserNotFound)
+	txRepo.On("BeginTx", ctx).Return(tx, nil)
+	txRepo.On("CommitOrRollbackTx", ctx, tx, nil).Return()
+	invitationRepo.On("AcceptInvitation", ctx, tx, invitation.ID.String()).Return(nil)
+	userRepo.On("CreateNewUser", ctx, tx, mock.AnythingOfType("entity.User")).
+		Run(func(args mock.Arguments) { created = args.Get(2).(entity.User) }).
+		Return(entity.User{ID: uuid.New(), Email: "new@mail.test", Role: "editor"}, nil)
+
+	user, err := is.AcceptInvitation(ctx, dto.InvitationAcceptRequest{
+		Token: "raw", Name: "Nina New", Password: "correct horse battery",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "new@mail.test", user.Email)
+	require.Equal(t, "editor", user.Role)
+	require.NotNil(t, created.EmailVerifiedAt)
+	require.Equal(t, constant.EnumProviderLocal, created.Provider)
+}
+
+func TestInvitationService_AcceptInvitation_Rejected(t *testing.T) {
+	is, invitationRepo, _, _, txRepo, _, ctx := setupInvitationServiceMock()
+
+	now := time.Now()
+	invitationRepo.On("GetInvitationByHash", ctx, (*gorm.DB)(nil), util.HashToken("used")).
+		Return(entity.Invitation{ID: uuid.New(), ExpiresAt: now.Add(time.Hour), AcceptedAt: &now}, nil)
+	invitationRepo.On("GetInvitationByHash", ctx, (*gorm.DB)(nil), util.HashToken("revoked")).
+		Return(entity.Invitation{ID: uuid.New(), ExpiresAt: now.Add(time.Hour), RevokedAt: &now}, nil)
+	invitationRepo.On("GetInvitationByHash", ctx, (*gorm.DB)(nil), util.HashToken("expired")).
+		Return(entity.Invitation{ID: uuid.New(), ExpiresAt: now.Add(-time.Minute)}, nil)
+
+	for token, want := range map[string]error{
+		"used":    errs.ErrInvitationInvalid,
+		"revoked": errs.ErrInvitationInvalid,
+		"expired": errs.ErrInvitationExpired,
+	} {
+		_, err := is.AcceptInvitation(ctx, dto.InvitationAcceptRequest{
+			Token: token, Name: "Nina New", Password: "correct horse battery",
+		})
+		require.ErrorIs(t, err, want, token)
+	}
+	txRepo.AssertNotCalled(t, "BeginTx", mock.Anything)
+}
@@ -1,17 +1,26 @@
 package service_test
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"myapp/core/service"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/require"
 )
 
 func TestJWTService_GenerateValidateAndGetAttrs(t *testing.T) {
-	jwtS := service.NewJWTService()
+	jwtS, err := service.NewJWTService()
+	require.NoError(t, err)
 
-	token := jwtS.GenerateToken("user-123", "user")
+	token := jwtS.GenerateToken("user-123", "user", "")
 	require.NotEmpty(t, token)
 
 	parsed, err := jwtS.ValidateToken(token)
@@ -23,3 +32,124 @@ func TestJWTService_GenerateValidateAndGetAttrs(t *testing.T) {
 	require.Equal(t, "user-123", id)
 	require.Equal(t, "user", role)
 }
+
+func TestJWTService_GetClaimsByToken_UniqueTokenID(t *testing.T) {
+	jwtS, err := service.NewJWTService()
+	require.NoError(t, err)
+
+	first, err := jwtS.GetClaimsByToken(jwtS.GenerateToken("user-123", "user", ""))
+	require.NoError(t, err)
+	second, err := jwtS.GetClaimsByToken(jwtS.GenerateToken("user-123", "user", ""))
+	require.NoError(t, err)
+
+	require.Equal(t, "user-123", first.UserID)
+	require.NotEmpty(t, first.TokenID)
+	require.NotEqual(t, first.TokenID, second.TokenID)
+	require.True(t, first.ExpiresAt.After(first.IssuedAt))
+}
+
+func writeRSAKeyPair(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	privDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	pubDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	privPath := filepath.Join(dir, "private.pem")
+	pubPath := filepath.Join(dir, "public.pem")
+	require.NoError(t, os.WriteFile(privPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER}), 0o600))
+	require.NoError(t, os.WriteFile(pubPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}), 0o600))
+	return privPath, pubPath
+}
+
+func TestJWTService_RS256WithKeyID(t *testing.T) {
+	privPath, _ := writeRSAKeyPair(t)
+	t.Setenv("JWT_PRIVATE_KEY_FILE", privPath)
+
+	jwtS, err := service.NewJWTService()
+	require.NoError(t, err)
+
+	parsed, err := jwtS.ValidateToken(jwtS.GenerateToken("user-123", "user", ""))
+	require.NoError(t, err)
+	require.Equal(t, "RS256", parsed.Method.Alg())
+
+	jwks := jwtS.GetJWKS()
+	require.Len(t, jwks.Keys, 1)
+	require.Equal(t, "RSA", jwks.Keys[0].Kty)
+	require.Equal(t, jwks.Keys[0].Kid, parsed.Header["kid"])
+}
+
+func TestJWTService_KeyRotation(t *testing.T) {
+	oldPriv, oldPub := writeRSAKeyPair(t)
+	t.Setenv("JWT_PRIVATE_KEY_FILE", oldPriv)
+	oldS, err := service.NewJWTService()
+	require.NoError(t, err)
+	oldToken := oldS.GenerateToken("user-123", "user", "")
+
+	// New signing key, old key kept for verification only
+	newPriv, _ := writeRSAKeyPair(t)
+	t.Setenv("JWT_PRIVATE_KEY_FILE", newPriv)
+	t.Setenv("JWT_PUBLIC_KEY_FILES", oldPub)
+	newS, err := service.NewJWTService()
+	require.NoError(t, err)
+
+	_, err = newS.ValidateToken(oldToken)
+	require.NoError(t, err)
+	require.Len(t, newS.GetJWKS().Keys, 2)
+
+	// Once the old key is dropped its tokens are rejected
+	t.Setenv("JWT_PUBLIC_KEY_FILES", "")
+	rotatedS, err := service.NewJWTService()
+	require.NoError(t, err)
+	_, err = rotatedS.ValidateToken(oldToken)
+	require.Error(t, err)
+}
+
+func TestJWTService_RejectsHMACToken(t *testing.T) {
+	jwtS, err := service.NewJWTService()
+	require.NoError(t, err)
+	kid := jwtS.GetJWKS().Keys[0].Kid
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"id": "user-123", "role": "admin"})
+	token.Header["kid"] = kid
+	signed, err := token.SignedString([]byte("jwt_secret_key"))
+	require.NoError(t, err)
+
+	_, err = jwtS.ValidateToken(signed)
+	require.Error(t, err)
+}
+
+func TestJWTService_ProductionRequiresKey(t *testing.T) {
+	t.Setenv("APP_ENV", "production")
+	t.Setenv("JWT_PRIVATE_KEY", "")
+	t.Setenv("JWT_PRIVATE_KEY_FILE", "")
+
+	_, err := service.NewJWTService()
+	require.Error(t, err)
+}
+
+func TestJWTService_GenerateImpersonationToken(t *testing.T) {
+	jwtS, err := service.NewJWTService()
+	require.NoError(t, err)
+
+	token := jwtS.GenerateImpersonationToken("user-123", "user", "admin-1", 15*time.Minute)
+	claims, err := jwtS.GetClaimsByToken(token)
+	require.NoError(t, err)
+	require.Equal(t, "user-123", claims.UserID)
+	require.Equal(t, "admin-1", claims.ActorID)
+	require.Empty(t, claims.SessionID)
+	require.WithinDuration(t, time.Now().Add(15*time.Minute), claims.ExpiresAt, 2*time.Second)
+
+	// RFC 8693 actor claim
+	parsed, err := jwtS.ValidateToken(token)
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"sub": "admin-1"}, parsed.Claims.(jwt.MapClaims)["act"])
+
+	regular, err := jwtS.GetClaimsByToken(jwtS.GenerateToken("user-123", "user", ""))
+	require.NoError(t, err)
+	require.Empty(t, regular.ActorID)
+}
@@ -0,0 +1,163 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"myapp/core/entity"
+	errs "myapp/core/helper/errors"
+	"myapp/core/service"
+	"myapp/support/constant"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// --- Mock Repository ---
+
+type MockLoginThrottleRepository struct {
+	mock.Mock
+}
+
+func (m *MockLoginThrottleRepository) DB() *gorm.DB {
+	return nil
+}
+
+func (m *MockLoginThrottleRepository) GetLoginThrottles(ctx context.Context, tx *gorm.DB,
+	keys []string) ([]entity.LoginThrottle, error) {
+	args := m.Called(ctx, tx, keys)
+	return args.Get(0).([]entity.LoginThrottle), args.Error(1)
+}
+
+func (m *MockLoginThrottleRepository) RecordLoginFailure(ctx context.Context, tx *gorm.DB, key string,
+	now time.Time, windowStart time.Time) (entity.LoginThrottle, error) {
+	args := m.Called(ctx, tx, key, now, windowStart)
+	return args.Get(0).(entity.LoginThrottle), args.Error(1)
+}
+
+func (m *MockLoginThrottleRepository) UpdateLoginThrottle(ctx context.Context, tx *gorm.DB,
+	throttle entity.LoginThrottle) error {
+	args := m.Called(ctx, tx, throttle)
+	return args.Error(0)
+}
+
+func (m *MockLoginThrottleRepository) DeleteLoginThrottle(ctx context.Context, tx *gorm.DB, key string) error {
+	args := m.Called(ctx, tx, key)
+	return args.Error(0)
+}
+
+// --- Test Helpers ---
+
+func setupLoginThrottleServiceMock() (service.LoginThrottleService, *MockLoginThrottleRepository,
+	*MockUserRepository, context.Context) {
+	throttleRepo := new(MockLoginThrottleRepository)
+	userRepo := new(MockUserRepository)
+	ts := service.NewLoginThrottleService(throttleRepo, userRepo)
+
+	return ts, throttleRepo, userRepo, context.Background()
+}
+
+// expectFailure makes RecordLoginFailure report the given count for key and
+// returns the update the service writes back.
+func expectFailure(throttleRepo *MockLoginThrottleRepository, ctx context.Context, key string,
+	failures int) *entity.LoginThrottle {
+	var update entity.LoginThrottle
+	throttleRepo.On("RecordLoginFailure", ctx, (*gorm.DB)(nil), key, mock.Anything, mock.Anything).
+		Return(entity.LoginThrottle{Key: key, Failures: failures}, nil)
+	throttleRepo.On("UpdateLoginThrottle", ctx, (*gorm.DB)(nil), mock.MatchedBy(func(t entity.LoginThrottle) bool {
+		return t.Key == key
+	})).Run(func(args mock.Arguments) {
+		update = args.Get(2).(entity.LoginThrottle)
+	}).Return(nil)
+	return &update
+}
+
+// --- Tests ---
+
+func TestLoginThrottleService_RecordFailure_ProgressiveDelay(t *testing.T) {
+	testCases := []struct {
+		failures  int
+		wantDelay time.Duration
+	}{
+		{constant.DefaultLoginDelayAfter - 1, 0},
+		{constant.DefaultLoginDelayAfter, time.Second},
+		{constant.DefaultLoginDelayAfter + 2, 4 * time.Second},
+		{constant.DefaultLoginLockoutThreshold - 1, constant.DefaultLoginDelayMax},
+	}
+
+	for _, tc := range testCases {
+		ts, throttleRepo, _, ctx := setupLoginThrottleServiceMock()
+		account := expectFailure(throttleRepo, ctx, "account:a@mail.test", tc.failures)
+		expectFailure(throttleRepo, ctx, "ip:10.0.0.1", 1)
+
+		before := time.Now()
+		err := ts.RecordFailure(ctx, "A@mail.test", "10.0.0.1")
+		require.NoError(t, err)
+		require.Nil(t, account.LockedUntil)
+
+		if tc.wantDelay == 0 {
+			require.Nil(t, account.NextAttemptAt)
+			continue
+		}
+		require.NotNil(t, account.NextAttemptAt)
+		require.WithinDuration(t, before.Add(tc.wantDelay), *account.NextAttemptAt, time.Second)
+	}
+}
+
+func TestLoginThrottleService_RecordFailure_Lockout(t *testing.T) {
+	t.Setenv("LOGIN_IP_LOCKOUT_THRESHOLD", "3")
+	ts, throttleRepo, _, ctx := setupLoginThrottleServiceMock()
+
+	account := expectFailure(throttleRepo, ctx, "account:a@mail.test", 1)
+	ip := expectFailure(throttleRepo, ctx, "ip:10.0.0.1", 3)
+
+	err := ts.RecordFailure(ctx, "a@mail.test", "10.0.0.1")
+	require.NoError(t, err)
+	require.Nil(t, account.LockedUntil)
+	require.NotNil(t, ip.LockedUntil)
+	require.WithinDuration(t, time.Now().Add(constant.DefaultLoginLockoutDuration), *ip.LockedUntil, time.Second)
+}
+
+func TestLoginThrottleService_CheckLogin(t *testing.T) {
+	ts, throttleRepo, _, ctx := setupLoginThrottleServiceMock()
+
+	next := time.Now().Add(5 * time.Second)
+	locked := time.Now().Add(10 * time.Minute)
+	keys := []string{"account:a@mail.test", "ip:10.0.0.1"}
+
+	throttleRepo.On("GetLoginThrottles", ctx, (*gorm.DB)(nil), keys).Return([]entity.LoginThrottle{
+		{Key: keys[0], NextAttemptAt: &next},
+		{Key: keys[1], LockedUntil: &locked},
+	}, nil).Once()
+	wait, err := ts.CheckLogin(ctx, "a@mail.test", "10.0.0.1")
+	require.ErrorIs(t, err, errs.ErrLoginLocked)
+	require.InDelta(t, 10*time.Minute, wait, float64(time.Second))
+
+	throttleRepo.On("GetLoginThrottles", ctx, (*gorm.DB)(nil), keys).Return([]entity.LoginThrottle{
+		{Key: keys[0], NextAttemptAt: &next},
+	}, nil).Once()
+	_, err = ts.CheckLogin(ctx, "a@mail.test", "10.0.0.1")
+	require.ErrorIs(t, err, errs.ErrLoginThrottled)
+
+	expired := time.Now().Add(-time.Second)
+	throttleRepo.On("GetLoginThrottles", ctx, (*gorm.DB)(nil), keys).Return([]entity.LoginThrottle{
+		{Key: keys[1], LockedUntil: &expired},
+	}, nil).Once()
+	_, err = ts.CheckLogin(ctx, "a@mail.test", "10.0.0.1")
+	require.NoError(t, err)
+}
+
+func TestLoginThrottleService_UnlockUser(t *testing.T) {
+	ts, throttleRepo, userRepo, ctx := setupLoginThrottleServiceMock()
+
+	user := entity.User{ID: uuid.New(), Email: "Locked@mail.test"}
+	userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrID, user.ID.String()).Return(user, nil)
+	throttleRepo.On("DeleteLoginThrottle", ctx, (*gorm.DB)(nil), "account:locked@mail.test").Return(nil)
+
+	err := ts.UnlockUser(ctx, user.ID.String())
+	require.NoError(t, err)
+	throttleRepo.AssertExpectations(t)
+}
@@ -0,0 +1,359 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	oidciface "myapp/core/interface/oidc"
+	"myapp/core/service"
+	"myapp/support/constant"
+	"myapp/support/util"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// --- Mock Repository ---
+
+type MockOIDCStateRepository struct {
+	mock.Mock
+}
+
+func (m *MockOIDCStateRepository) DB() *gorm.DB {
+	return nil
+}
+
+func (m *MockOIDCStateRepository) CreateOIDCState(ctx context.Context, tx *gorm.DB,
+	state entity.OIDCState) (entity.OIDCState, error) {
+	args := m.Called(ctx, tx, state)
+	return args.Get(0).(entity.OIDCState), args.Error(1)
+}
+
+func (m *MockOIDCStateRepository) ConsumeOIDCState(ctx context.Context, tx *gorm.DB,
+	hash string) (entity.OIDCState, error) {
+	args := m.Called(ctx, tx, hash)
+	return args.Get(0).(entity.OIDCState), args.Error(1)
+}
+
+// --- Mock Provider ---
+
+type MockOIDCProvider struct {
+	mock.Mock
+}
+
+func (m *MockOIDCProvider) Name() string {
+	return "test"
+}
+
+func (m *MockOIDCProvider) AuthCodeURL(ctx context.Context, state string, nonce string,
+	verifier string) (string, error) {
+	args := m.Called(ctx, state, nonce, verifier)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockOIDCProvider) Exchange(ctx context.Context, code string, verifier string,
+	nonce string) (oidciface.Identity, error) {
+	args := m.Called(ctx, code, verifier, nonce)
+	return args.Get(0).(oidciface.Identity), args.Error(1)
+}
+
+type MockUserIdentityRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserIdentityRepository) DB() *gorm.DB {
+	return nil
+}
+
+func (m *MockUserIdentityRepository) CreateUserIdentity(ctx context.Context, tx *gorm.DB,
+	identity entity.UserIdentity) (entity.UserIdentity, error) {
+	args := m.Called(ctx, tx, identity)
+	return args.Get(0).(entity.UserIdentity), args.Error(1)
+}
+
+func (m *MockUserIdentityRepository) GetUserIdentity(ctx context.Context, tx *gorm.DB,
+	provider string, subject string) (entity.UserIdentity, error) {
+	args := m.Called(ctx, tx, provider, subject)
+	return args.Get(0).(entity.UserIdentity), args.Error(1)
+}
+
+func (m *MockUserIdentityRepository) DeleteUserIdentity(ctx context.Context, tx *gorm.DB, id string) error {
+	args := m.Called(ctx, tx, id)
+	return args.Error(0)
+}
+
+// --- Test Helpers ---
+
+type oidcServiceMocks struct {
+	provider     *MockOIDCProvider
+	stateRepo    *MockOIDCStateRepository
+	userRepo     *MockUserRepository
+	identityRepo *MockUserIdentityRepository
+	txRepo       *MockTxRepository
+	// Behind the auth service ending sessions of claimed accounts
+	refreshRepo    *MockRefreshTokenRepository
+	revocationRepo *MockTokenRevocationRepository
+	sessionRepo    *MockSessionRepository
+	tx             *gorm.DB
+}
+
+func setupOIDCServiceMock(t *testing.T) (service.OIDCService, *oidcServiceMocks, context.Context) {
+	as, refreshRepo, revocationRepo, sessionRepo, _, authTxRepo, ctx := setupAuthServiceWithSessionMock(t)
+	m := &oidcServiceMocks{
+		provider:       new(MockOIDCProvider),
+		stateRepo:      new(MockOIDCStateRepository),
+		userRepo:       new(MockUserRepository),
+		identityRepo:   new(MockUserIdentityRepository),
+		txRepo:         authTxRepo,
+		refreshRepo:    refreshRepo,
+		revocationRepo: revocationRepo,
+		sessionRepo:    sessionRepo,
+		tx:             &gorm.DB{},
+	}
+	m.txRepo.On("BeginTx", ctx).Return(m.tx, nil).Maybe()
+	m.txRepo.On("CommitOrRollbackTx", ctx, m.tx, nil).Return().Maybe()
+
+	sv := service.NewOIDCService(oidciface.Providers{"test": m.provider}, m.stateRepo, m.userRepo,
+		m.identityRepo, m.txRepo, as)
+	return sv, m, ctx
+}
+
+// expectCallback sets up a valid pending login for the state "raw-state"
+// that signs in identity.
+func expectCallback(ctx context.Context, m *oidcServiceMocks, identity oidciface.Identity) {
+	state := entity.OIDCState{
+		Provider:     "test",
+		CodeVerifier: "verifier",
+		Nonce:        "nonce",
+		ExpiresAt:    time.Now().Add(time.Minute),
+	}
+	m.stateRepo.On("ConsumeOIDCState", ctx, (*gorm.DB)(nil), util.HashToken("raw-state")).Return(state, nil)
+	m.provider.On("Exchange", ctx, "code", "verifier", "nonce").Return(identity, nil)
+}
+
+// expectNewIdentity sets up identity as not linked yet, expecting it to be
+// linked to userID.
+func expectNewIdentity(ctx context.Context, m *oidcServiceMocks, identity oidciface.Identity, userID *uuid.UUID) {
+	m.identityRepo.On("GetUserIdentity", ctx, (*gorm.DB)(nil), "test", identity.Subject).
+		Return(entity.UserIdentity{}, errs.ErrOIDCIdentityNotFound)
+	m.identityRepo.On("CreateUserIdentity", ctx, m.tx, mock.MatchedBy(func(i entity.UserIdentity) bool {
+		return i.UserID == *userID && i.Provider == "test" && i.Subject == identity.Subject
+	})).Return(entity.UserIdentity{}, nil)
+}
+
+func completeLogin(ctx context.Context, sv service.OIDCService) (dto.UserResponse, error) {
+	return sv.CompleteLogin(ctx, dto.OIDCCallbackRequest{
+		Provider: "test", BrowserState: "raw-state", Code: "code", State: "raw-state",
+	})
+}
+
+// --- Tests ---
+
+func TestOIDCService_BeginLogin(t *testing.T) {
+	sv, m, ctx := setupOIDCServiceMock(t)
+
+	m.provider.On("AuthCodeURL", ctx, mock.Anything, mock.Anything, mock.Anything).
+		Return("https://issuer.test/authorize", nil)
+	m.stateRepo.On("CreateOIDCState", ctx, (*gorm.DB)(nil), mock.MatchedBy(func(s entity.OIDCState) bool {
+		return s.Provider == "test" && s.StateHash != "" && s.CodeVerifier != "" && s.Nonce != "" &&
+			s.ExpiresAt.After(time.Now())
+	})).Return(entity.OIDCState{}, nil)
+
+	start, err := sv.BeginLogin(ctx, "test")
+	require.NoError(t, err)
+	require.Equal(t, "https://issuer.test/authorize", start.AuthURL)
+
+	// The stored hash belongs to the state sent to the provider, which is
+	// also what the browser keeps
+	state := m.provider.Calls[0].Arguments.String(1)
+	stored := m.stateRepo.Calls[0].Arguments.Get(2).(entity.OIDCState)
+	require.Equal(t, util.HashToken(state), stored.StateHash)
+	require.Equal(t, state, start.State)
+	require.Equal(t, stored.ExpiresAt, start.ExpiresAt)
+	require.Equal(t, m.provider.Calls[0].Arguments.String(3), stored.CodeVerifier)
+}
+
+func TestOIDCService_BeginLogin_UnknownProvider(t *testing.T) {
+	sv, _, ctx := setupOIDCServiceMock(t)
+
+	_, err := sv.BeginLogin(ctx, "unknown")
+	require.ErrorIs(t, err, errs.ErrOIDCProviderNotFound)
+}
+
+func TestOIDCService_CompleteLogin_CreatesUser(t *testing.T) {
+	sv, m, ctx := setupOIDCServiceMock(t)
+
+	identity := oidciface.Identity{Subject: "sub", Email: "new@mail.test", EmailVerified: true, Name: "New"}
+	created := entity.User{ID: uuid.New(), Name: "New", Email: identity.Email, Role: constant.EnumRoleUser}
+	expectCallback(ctx, m, identity)
+	expectNewIdentity(ctx, m, identity, &created.ID)
+	m.userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrEmail, identity.Email).
+		Return(entity.User{}, errs.ErrUserNotFound)
+	m.userRepo.On("CreateNewUser", ctx, m.tx, mock.MatchedBy(func(u entity.User) bool {
+		return u.Email == identity.Email && u.Provider == "test" && u.Role == constant.EnumRoleUser &&
+			u.Password != "" && u.EmailVerifiedAt != nil
+	})).Return(created, nil)
+
+	user, err := completeLogin(ctx, sv)
+	require.NoError(t, err)
+	require.Equal(t, created.ID.String(), user.ID)
+	m.userRepo.AssertExpectations(t)
+	m.identityRepo.AssertExpectations(t)
+}
+
+func TestOIDCService_CompleteLogin_LinksExistingUser(t *testing.T) {
+	sv, m, ctx := setupOIDCServiceMock(t)
+
+	verifiedAt := time.Now().Add(-time.Hour)
+	existing := entity.User{ID: uuid.New(), Email: "old@mail.test", Role: constant.EnumRoleAdmin,
+		Provider: constant.EnumProviderLocal, EmailVerifiedAt: &verifiedAt}
+	identity := oidciface.Identity{Subject: "sub", Email: existing.Email, EmailVerified: true}
+	expectCallback(ctx, m, identity)
+	expectNewIdentity(ctx, m, identity, &existing.ID)
+	m.userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrEmail, existing.Email).
+		Return(existing, nil)
+	// A verified account keeps its password
+	m.userRepo.On("UpdateUser", ctx, m.tx, mock.MatchedBy(func(u entity.User) bool {
+		return u.ID == existing.ID && u.Provider == "test" && u.EmailVerifiedAt == nil && u.Password == ""
+	})).Return(nil)
+
+	user, err := completeLogin(ctx, sv)
+	require.NoError(t, err)
+	require.Equal(t, existing.ID.String(), user.ID)
+	require.Equal(t, constant.EnumRoleAdmin, user.Role)
+	m.userRepo.AssertExpectations(t)
+	m.identityRepo.AssertExpectations(t)
+	m.refreshRepo.AssertNotCalled(t, "RevokeUserRefreshTokens", mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything)
+}
+
+func TestOIDCService_CompleteLogin_ClaimsUnverifiedUser(t *testing.T) {
+	sv, m, ctx := setupOIDCServiceMock(t)
+
+	// Possibly signed up by someone else with the owner's address
+	existing := entity.User{ID: uuid.New(), Email: "old@mail.test", Role: constant.EnumRoleUser,
+		Provider: constant.EnumProviderLocal}
+	identity := oidciface.Identity{Subject: "sub", Email: existing.Email, EmailVerified: true}
+	expectCallback(ctx, m, identity)
+	expectNewIdentity(ctx, m, identity, &existing.ID)
+	m.userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrEmail, existing.Email).
+		Return(existing, nil)
+	m.userRepo.On("UpdateUser", ctx, m.tx, mock.MatchedBy(func(u entity.User) bool {
+		return u.ID == existing.ID && u.Provider == "test" && u.EmailVerifiedAt != nil && u.Password != ""
+	})).Return(nil)
+	m.refreshRepo.On("RevokeUserRefreshTokens", ctx, m.tx, existing.ID.String(),
+		constant.EnumRefreshTokenRevokedSessionsRevoked).Return(nil)
+	m.sessionRepo.On("EndUserSessions", ctx, m.tx, existing.ID.String()).Return(nil)
+	m.revocationRepo.On("CreateTokenRevocation", ctx, m.tx, mock.MatchedBy(func(r entity.TokenRevocation) bool {
+		return r.UserID == existing.ID && r.RevokedBefore != nil
+	})).Return(nil)
+
+	user, err := completeLogin(ctx, sv)
+	require.NoError(t, err)
+	require.Equal(t, existing.ID.String(), user.ID)
+	m.userRepo.AssertExpectations(t)
+	m.identityRepo.AssertExpectations(t)
+	m.refreshRepo.AssertExpectations(t)
+	m.sessionRepo.AssertExpectations(t)
+	m.revocationRepo.AssertExpectations(t)
+}
+
+func TestOIDCService_CompleteLogin_LinkedIdentity(t *testing.T) {
+	sv, m, ctx := setupOIDCServiceMock(t)
+
+	linked := entity.User{ID: uuid.New(), Email: "old@mail.test", Role: constant.EnumRoleUser}
+	// Found by subject, whatever the address is now
+	expectCallback(ctx, m, oidciface.Identity{Subject: "sub", Email: "new@mail.test"})
+	m.identityRepo.On("GetUserIdentity", ctx, (*gorm.DB)(nil), "test", "sub").
+		Return(entity.UserIdentity{ID: uuid.New(), UserID: linked.ID, Provider: "test", Subject: "sub"}, nil)
+	m.userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrID, linked.ID.String()).
+		Return(linked, nil)
+
+	user, err := completeLogin(ctx, sv)
+	require.NoError(t, err)
+	require.Equal(t, linked.ID.String(), user.ID)
+	require.Equal(t, linked.Email, user.Email)
+	m.userRepo.AssertNotCalled(t, "GetUserByPrimaryKey", mock.Anything, mock.Anything, constant.DBAttrEmail,
+		mock.Anything)
+	m.identityRepo.AssertNotCalled(t, "CreateUserIdentity", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOIDCService_CompleteLogin_IdentityOfDeletedUser(t *testing.T) {
+	sv, m, ctx := setupOIDCServiceMock(t)
+
+	stale := entity.UserIdentity{ID: uuid.New(), UserID: uuid.New(), Provider: "test", Subject: "sub"}
+	identity := oidciface.Identity{Subject: "sub", Email: "new@mail.test", EmailVerified: true}
+	created := entity.User{ID: uuid.New(), Email: identity.Email, Role: constant.EnumRoleUser}
+	expectCallback(ctx, m, identity)
+	m.identityRepo.On("GetUserIdentity", ctx, (*gorm.DB)(nil), "test", "sub").Return(stale, nil)
+	m.userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrID, stale.UserID.String()).
+		Return(entity.User{}, errs.ErrUserNotFound)
+	m.identityRepo.On("DeleteUserIdentity", ctx, (*gorm.DB)(nil), stale.ID.String()).Return(nil)
+	m.userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrEmail, identity.Email).
+		Return(entity.User{}, errs.ErrUserNotFound)
+	m.userRepo.On("CreateNewUser", ctx, m.tx, mock.Anything).Return(created, nil)
+	m.identityRepo.On("CreateUserIdentity", ctx, m.tx, mock.MatchedBy(func(i entity.UserIdentity) bool {
+		return i.UserID == created.ID && i.Subject == "sub"
+	})).Return(entity.UserIdentity{}, nil)
+
+	user, err := completeLogin(ctx, sv)
+	require.NoError(t, err)
+	require.Equal(t, created.ID.String(), user.ID)
+	m.identityRepo.AssertExpectations(t)
+}
+
+func TestOIDCService_CompleteLogin_UnverifiedEmail(t *testing.T) {
+	sv, m, ctx := setupOIDCServiceMock(t)
+
+	identity := oidciface.Identity{Subject: "sub", Email: "old@mail.test", EmailVerified: false}
+	expectCallback(ctx, m, identity)
+	m.identityRepo.On("GetUserIdentity", ctx, (*gorm.DB)(nil), "test", "sub").
+		Return(entity.UserIdentity{}, errs.ErrOIDCIdentityNotFound)
+
+	_, err := completeLogin(ctx, sv)
+	require.ErrorIs(t, err, errs.ErrOIDCEmailNotVerified)
+	m.userRepo.AssertNotCalled(t, "GetUserByPrimaryKey", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOIDCService_CompleteLogin_MissingSubject(t *testing.T) {
+	sv, m, ctx := setupOIDCServiceMock(t)
+
+	expectCallback(ctx, m, oidciface.Identity{Email: "old@mail.test", EmailVerified: true})
+
+	_, err := completeLogin(ctx, sv)
+	require.ErrorIs(t, err, errs.ErrOIDCIDTokenInvalid)
+	m.identityRepo.AssertNotCalled(t, "GetUserIdentity", mock.Anything, mock.Anything, mock.Anything,
+		mock.Anything)
+}
+
+func TestOIDCService_CompleteLogin_StateFromOtherProvider(t *testing.T) {
+	sv, m, ctx := setupOIDCServiceMock(t)
+
+	m.stateRepo.On("ConsumeOIDCState", ctx, (*gorm.DB)(nil), util.HashToken("raw-state")).
+		Return(entity.OIDCState{Provider: "other", ExpiresAt: time.Now().Add(time.Minute)}, nil)
+
+	_, err := completeLogin(ctx, sv)
+	require.ErrorIs(t, err, errs.ErrOIDCStateInvalid)
+	m.provider.AssertNotCalled(t, "Exchange", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestOIDCService_CompleteLogin_StateFromOtherBrowser(t *testing.T) {
+	sv, m, ctx := setupOIDCServiceMock(t)
+
+	// A callback carrying someone else's state, as in login CSRF, or one
+	// without the cookie at all
+	for _, browserState := range []string{"other-state", ""} {
+		_, err := sv.CompleteLogin(ctx, dto.OIDCCallbackRequest{
+			Provider: "test", BrowserState: browserState, Code: "code", State: "raw-state",
+		})
+		require.ErrorIs(t, err, errs.ErrOIDCStateInvalid)
+	}
+	m.stateRepo.AssertNotCalled(t, "ConsumeOIDCState", mock.Anything, mock.Anything, mock.Anything)
+	m.provider.AssertNotCalled(t, "Exchange", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,82 @@
+package service_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	errs "myapp/core/helper/errors"
+	"myapp/core/service"
+
+	"github.com/stretchr/testify/require"
+)
+
+func policyViolations(t *testing.T, err error) []string {
+	t.Helper()
+	var policyErr *errs.PasswordPolicyError
+	require.ErrorAs(t, err, &policyErr)
+	return policyErr.Violations
+}
+
+func TestPasswordPolicy_Defaults(t *testing.T) {
+	policy, err := service.NewPasswordPolicy()
+	require.NoError(t, err)
+
+	require.NoError(t, policy.Validate("correct horse battery", "rosa@mail.test", "Rosa Diaz"))
+
+	violations := policyViolations(t, policy.Validate("short", "", ""))
+	require.Equal(t, []string{"The value of 'password' must be at least 8 characters long."}, violations)
+
+	// bcrypt only hashes the first 72 bytes
+	violations = policyViolations(t, policy.Validate(string(make([]byte, 73)), "", ""))
+	require.Equal(t, []string{"The value of 'password' must be at most 72 characters long."}, violations)
+}
+
+func TestPasswordPolicy_CharacterClasses(t *testing.T) {
+	t.Setenv("PASSWORD_MIN_CLASSES", "3")
+	policy, err := service.NewPasswordPolicy()
+	require.NoError(t, err)
+
+	require.NoError(t, policy.Validate("Horse-battery", "", ""))
+	require.NoError(t, policy.Validate("horse battery 9", "", ""))
+
+	violations := policyViolations(t, policy.Validate("horsebattery9", "", ""))
+	require.Equal(t, []string{"The value of 'password' must mix at least 3 of lowercase letters, " +
+		"uppercase letters, numbers and symbols."}, violations)
+}
+
+func TestPasswordPolicy_PersonalInfo(t *testing.T) {
+	policy, err := service.NewPasswordPolicy()
+	require.NoError(t, err)
+
+	require.ErrorIs(t, policy.Validate("ILoveDiaz!", "rosa@mail.test", "Rosa Diaz"), errs.ErrPasswordPolicy)
+	require.ErrorIs(t, policy.Validate("rosa.d2024", "rosa.d@mail.test", ""), errs.ErrPasswordPolicy)
+	// name parts shorter than three characters are not checked
+	require.NoError(t, policy.Validate("Jo-battery", "", "Jo Li"))
+}
+
+func TestPasswordPolicy_BreachedList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# common passwords\n\npassword1\nQwertyuiop\n"), 0o600))
+	t.Setenv("PASSWORD_BREACHED_LIST_FILE", path)
+
+	policy, err := service.NewPasswordPolicy()
+	require.NoError(t, err)
+
+	violations := policyViolations(t, policy.Validate("qwertyuiop", "", ""))
+	require.Equal(t, []string{"The value of 'password' is too common or appeared in a data breach, " +
+		"choose another one."}, violations)
+	require.NoError(t, policy.Validate("# common passwords", "", ""))
+
+	// every broken rule is reported
+	violations = policyViolations(t, policy.Validate("Password1", "", ""))
+	require.Len(t, violations, 1)
+	require.Len(t, policyViolations(t, policy.Validate("pass", "pass@mail.test", "")), 2)
+}
+
+func TestPasswordPolicy_MissingBreachedList(t *testing.T) {
+	t.Setenv("PASSWORD_BREACHED_LIST_FILE", filepath.Join(t.TempDir(), "missing.txt"))
+
+	_, err := service.NewPasswordPolicy()
+	require.Error(t, err)
+}
@@ -0,0 +1,287 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	maileriface "myapp/core/interface/mailer"
+	"myapp/core/service"
+	"myapp/support/constant"
+	"myapp/support/util"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// --- Mock Repository ---
+
+type MockPasswordResetTokenRepository struct {
+	mock.Mock
+}
+
+func (m *MockPasswordResetTokenRepository) DB() *gorm.DB {
+	return nil
+}
+
+func (m *MockPasswordResetTokenRepository) CreatePasswordResetToken(ctx context.Context, tx *gorm.DB,
+	token entity.PasswordResetToken) (entity.PasswordResetToken, error) {
+	args := m.Called(ctx, tx, token)
+	return args.Get(0).(entity.PasswordResetToken), args.Error(1)
+}
+
+func (m *MockPasswordResetTokenRepository) GetPasswordResetTokenByHash(ctx context.Context, tx *gorm.DB,
+	hash string) (entity.PasswordResetToken, error) {
+	args := m.Called(ctx, tx, hash)
+	return args.Get(0).(entity.PasswordResetToken), args.Error(1)
+}
+
+func (m *MockPasswordResetTokenRepository) UsePasswordResetToken(ctx context.Context, tx *gorm.DB, id string) error {
+	args := m.Called(ctx, tx, id)
+	return args.Error(0)
+}
+
+func (m *MockPasswordResetTokenRepository) InvalidateUserPasswordResetTokens(ctx context.Context,
+	tx *gorm.DB, userID string) error {
+	args := m.Called(ctx, tx, userID)
+	return args.Error(0)
+}
+
+// --- Mock Mailer ---
+
+type recordingMailer struct {
+	sent []maileriface.Message
+}
+
+func (m *recordingMailer) Send(_ context.Context, msg maileriface.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// --- Test Helpers ---
+
+func setupPasswordServiceMock() (service.PasswordService, *MockUserRepository, *MockPasswordResetTokenRepository,
+	*MockTxRepository, *recordingMailer, context.Context) {
+	ps, userRepo, resetRepo, _, txRepo, mailer, ctx := setupPasswordServiceWithRevocationMock()
+	return ps, userRepo, resetRepo, txRepo, mailer, ctx
+}
+
+type passwordRevocationMocks struct {
+	refresh    *MockRefreshTokenRepository
+	session    *MockSessionRepository
+	revocation *MockTokenRevocationRepository
+	auditLog   *MockAuditLogRepository
+}
+
+func setupPasswordServiceWithRevocationMock() (service.PasswordService, *MockUserRepository,
+	*MockPasswordResetTokenRepository, passwordRevocationMocks, *MockTxRepository, *recordingMailer,
+	context.Context) {
+	userRepo := new(MockUserRepository)
+	resetRepo := new(MockPasswordResetTokenRepository)
+	m := passwordRevocationMocks{
+		refresh:    new(MockRefreshTokenRepository),
+		session:    new(MockSessionRepository),
+		revocation: new(MockTokenRevocationRepository),
+		auditLog:   new(MockAuditLogRepository),
+	}
+	txRepo := new(MockTxRepository)
+	mailer := new(recordingMailer)
+	jwtS, _ := service.NewJWTService()
+	policy, _ := service.NewPasswordPolicy()
+	ps := service.NewPasswordService(jwtS, userRepo, resetRepo, m.refresh, m.session, m.revocation, m.auditLog,
+		txRepo, mailer, policy)
+
+	return ps, userRepo, resetRepo, m, txRepo, mailer, context.Background()
+}
+
+// expectPasswordSet sets up the re-read, audit entry and revocation that
+// follow a password update of user within tx.
+func expectPasswordSet(ctx context.Context, tx *gorm.DB, userRepo *MockUserRepository,
+	m passwordRevocationMocks, user entity.User) {
+	changedAt := time.Now()
+	after := user
+	after.Password = "hashed"
+	after.PasswordChangedAt = &changedAt
+	userRepo.On("GetUserByPrimaryKey", ctx, tx, constant.DBAttrID, user.ID.String()).Return(after, nil)
+	m.auditLog.On("CreateAuditLog", ctx, tx, mock.MatchedBy(func(log entity.AuditLog) bool {
+		return log.EntityID == user.ID.String() && log.Action == constant.EnumAuditActionUpdate
+	})).Return(entity.AuditLog{}, nil)
+	m.refresh.On("RevokeUserRefreshTokens", ctx, tx, user.ID.String(),
+		constant.EnumRefreshTokenRevokedSessionsRevoked).Return(nil)
+	m.session.On("EndUserSessions", ctx, tx, user.ID.String()).Return(nil)
+	m.revocation.On("CreateTokenRevocation", ctx, tx, mock.MatchedBy(func(r entity.TokenRevocation) bool {
+		return r.UserID == user.ID && r.RevokedBefore != nil &&
+			r.RevokedBefore.Equal(entity.PasswordChangeCutoff(changedAt))
+	})).Return(nil)
+}
+
+// --- Tests ---
+
+func TestPasswordService_RequestPasswordReset(t *testing.T) {
+	ps, userRepo, resetRepo, txRepo, mailer, ctx := setupPasswordServiceMock()
+
+	user := entity.User{ID: uuid.New(), Name: "Rosa", Email: "rosa@mail.test"}
+	tx := &gorm.DB{}
+	var stored entity.PasswordResetToken
+
+	userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrEmail, user.Email).Return(user, nil)
+	txRepo.On("BeginTx", ctx).Return(tx, nil)
+	txRepo.On("CommitOrRollbackTx", ctx, tx, nil).Return()
+	resetRepo.On("InvalidateUserPasswordResetTokens", ctx, tx, user.ID.String()).Return(nil)
+	resetRepo.On("CreatePasswordResetToken", ctx, tx, mock.AnythingOfType("entity.PasswordResetToken")).
+		Run(func(args mock.Arguments) { stored = args.Get(2).(entity.PasswordResetToken) }).
+		Return(entity.PasswordResetToken{}, nil)
+
+	err := ps.RequestPasswordReset(ctx, dto.UserPasswordForgotRequest{Email: user.Email})
+	require.NoError(t, err)
+	require.Len(t, mailer.sent, 1)
+	require.Equal(t, []string{user.Email}, mailer.sent[0].To)
+
+	// The mailed link carries the raw token, only its hash is stored
+	start := strings.Index(mailer.sent[0].Body, constant.DefaultPasswordResetURL)
+	require.GreaterOrEqual(t, start, 0)
+	link, err := url.Parse(strings.Fields(mailer.sent[0].Body[start:])[0])
+	require.NoError(t, err)
+	raw := link.Query().Get("token")
+	require.NotEmpty(t, raw)
+	require.Equal(t, util.HashToken(raw), stored.TokenHash)
+	require.True(t, stored.ExpiresAt.After(time.Now()))
+}
+
+func TestPasswordService_RequestPasswordReset_UnknownEmail(t *testing.T) {
+	ps, userRepo, _, _, mailer, ctx := setupPasswordServiceMock()
+
+	userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrEmail, "nobody@mail.test").
+		Return(entity.User{}, errs.ErrUserNotFound)
+
+	err := ps.RequestPasswordReset(ctx, dto.UserPasswordForgotRequest{Email: "nobody@mail.test"})
+	require.NoError(t, err)
+	require.Empty(t, mailer.sent)
+}
+
+func TestPasswordService_ResetPassword(t *testing.T) {
+	ps, userRepo, resetRepo, m, txRepo, _, ctx := setupPasswordServiceWithRevocationMock()
+
+	token := entity.PasswordResetToken{ID: uuid.New(), UserID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)}
+	user := entity.User{ID: token.UserID, Name: "Rosa", Email: "rosa@mail.test"}
+	tx := &gorm.DB{}
+
+	resetRepo.On("GetPasswordResetTokenByHash", ctx, (*gorm.DB)(nil), util.HashToken("raw")).Return(token, nil)
+	userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrID, token.UserID.String()).
+		Return(user, nil)
+	txRepo.On("BeginTx", ctx).Return(tx, nil)
+	txRepo.On("CommitOrRollbackTx", ctx, tx, nil).Return()
+	resetRepo.On("UsePasswordResetToken", ctx, tx, token.ID.String()).Return(nil)
+	userRepo.On("UpdateUser", ctx, tx, entity.User{ID: token.UserID, Password: "new-secret"}).Return(nil)
+	expectPasswordSet(ctx, tx, userRepo, m, user)
+
+	err := ps.ResetPassword(ctx, dto.UserPasswordResetRequest{Token: "raw", Password: "new-secret"})
+	require.NoError(t, err)
+	userRepo.AssertExpectations(t)
+	m.auditLog.AssertExpectations(t)
+	m.revocation.AssertExpectations(t)
+}
+
+func TestPasswordService_ResetPassword_UsedOrExpired(t *testing.T) {
+	ps, _, resetRepo, _, _, ctx := setupPasswordServiceMock()
+
+	usedAt := time.Now()
+	used := entity.PasswordResetToken{ID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour), UsedAt: &usedAt}
+	expired := entity.PasswordResetToken{ID: uuid.New(), ExpiresAt: time.Now().Add(-time.Minute)}
+	resetRepo.On("GetPasswordResetTokenByHash", ctx, (*gorm.DB)(nil), util.HashToken("used")).Return(used, nil)
+	resetRepo.On("GetPasswordResetTokenByHash", ctx, (*gorm.DB)(nil), util.HashToken("expired")).Return(expired, nil)
+
+	err := ps.ResetPassword(ctx, dto.UserPasswordResetRequest{Token: "used", Password: "x"})
+	require.ErrorIs(t, err, errs.ErrPasswordResetTokenInvalid)
+
+	err = ps.ResetPassword(ctx, dto.UserPasswordResetRequest{Token: "expired", Password: "x"})
+	require.ErrorIs(t, err, errs.ErrPasswordResetTokenExpired)
+}
+
+func TestPasswordService_ResetPassword_WeakPassword(t *testing.T) {
+	ps, userRepo, resetRepo, txRepo, _, ctx := setupPasswordServiceMock()
+
+	token := entity.PasswordResetToken{ID: uuid.New(), UserID: uuid.New(), ExpiresAt: time.Now().Add(time.Hour)}
+	resetRepo.On("GetPasswordResetTokenByHash", ctx, (*gorm.DB)(nil), util.HashToken("raw")).Return(token, nil)
+	userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrID, token.UserID.String()).
+		Return(entity.User{ID: token.UserID, Name: "Rosa", Email: "rosa@mail.test"}, nil)
+
+	err := ps.ResetPassword(ctx, dto.UserPasswordResetRequest{Token: "raw", Password: "short"})
+	require.ErrorIs(t, err, errs.ErrPasswordPolicy)
+	// the token stays usable for another attempt
+	txRepo.AssertNotCalled(t, "BeginTx", mock.Anything)
+	resetRepo.AssertNotCalled(t, "UsePasswordResetToken", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestPasswordService_ChangePassword(t *testing.T) {
+	ps, userRepo, _, m, txRepo, _, ctx := setupPasswordServiceWithRevocationMock()
+
+	hashed, _ := util.PasswordHash("old-password")
+	user := entity.User{ID: uuid.New(), Name: "Rosa", Email: "rosa@mail.test", Password: hashed}
+	tx := &gorm.DB{}
+	userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrID, user.ID.String()).Return(user, nil)
+	txRepo.On("BeginTx", ctx).Return(tx, nil)
+	txRepo.On("CommitOrRollbackTx", ctx, tx, nil).Return()
+	userRepo.On("UpdateUser", ctx, tx, entity.User{ID: user.ID, Password: "new-password"}).Return(nil)
+	expectPasswordSet(ctx, tx, userRepo, m, user)
+
+	err := ps.ChangePassword(ctx, dto.UserPasswordChangeRequest{
+		ID: user.ID.String(), CurrentPassword: "old-password", Password: "new-password",
+	})
+	require.NoError(t, err)
+	userRepo.AssertExpectations(t)
+	m.auditLog.AssertExpectations(t)
+	m.refresh.AssertExpectations(t)
+	m.session.AssertExpectations(t)
+	m.revocation.AssertExpectations(t)
+}
+
+func TestPasswordService_ChangePassword_RevocationFails(t *testing.T) {
+	ps, userRepo, _, m, txRepo, _, ctx := setupPasswordServiceWithRevocationMock()
+
+	hashed, _ := util.PasswordHash("old-password")
+	user := entity.User{ID: uuid.New(), Name: "Rosa", Email: "rosa@mail.test", Password: hashed}
+	tx := &gorm.DB{}
+	failure := errors.New("db down")
+	userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrID, user.ID.String()).Return(user, nil)
+	txRepo.On("BeginTx", ctx).Return(tx, nil)
+	// the password update is rolled back with the failed revocation
+	txRepo.On("CommitOrRollbackTx", ctx, tx, failure).Return()
+	userRepo.On("UpdateUser", ctx, tx, entity.User{ID: user.ID, Password: "new-password"}).Return(nil)
+	userRepo.On("GetUserByPrimaryKey", ctx, tx, constant.DBAttrID, user.ID.String()).Return(user, nil)
+	m.refresh.On("RevokeUserRefreshTokens", ctx, tx, user.ID.String(),
+		constant.EnumRefreshTokenRevokedSessionsRevoked).Return(failure)
+
+	err := ps.ChangePassword(ctx, dto.UserPasswordChangeRequest{
+		ID: user.ID.String(), CurrentPassword: "old-password", Password: "new-password",
+	})
+	require.ErrorIs(t, err, failure)
+	txRepo.AssertExpectations(t)
+}
+
+func TestPasswordService_ChangePassword_Rejected(t *testing.T) {
+	ps, userRepo, _, _, _, ctx := setupPasswordServiceMock()
+
+	hashed, _ := util.PasswordHash("old-password")
+	user := entity.User{ID: uuid.New(), Name: "Rosa", Email: "rosa@mail.test", Password: hashed}
+	userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrID, user.ID.String()).Return(user, nil)
+
+	err := ps.ChangePassword(ctx, dto.UserPasswordChangeRequest{
+		ID: user.ID.String(), CurrentPassword: "wrong-password", Password: "new-password",
+	})
+	require.ErrorIs(t, err, errs.ErrPasswordIncorrect)
+
+	err = ps.ChangePassword(ctx, dto.UserPasswordChangeRequest{
+		ID: user.ID.String(), CurrentPassword: "old-password", Password: "new",
+	})
+	require.ErrorIs(t, err, errs.ErrPasswordPolicy)
+	userRepo.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything, mock.Anything)
+}
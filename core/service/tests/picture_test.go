@@ -2,15 +2,22 @@ package service_test
 
 import (
 	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"myapp/core/entity"
 	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	"myapp/support/util"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
@@ -34,6 +41,14 @@ func buildFileHeader(t *testing.T, field, filename string, content []byte) *mult
 	return req.MultipartForm.File[field][0]
 }
 
+// pngBytes encodes a blank PNG of the given size.
+func pngBytes(t *testing.T, width, height int) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
 func setupTemporaryFileDir(t *testing.T) string {
 	t.Helper()
 
@@ -50,20 +65,206 @@ func setupTemporaryFileDir(t *testing.T) string {
 	return tmpD
 }
 
+// jpegWithExif encodes a blank JPEG of the given size carrying an EXIF
+// segment with the orientation and a comment.
+func jpegWithExif(t *testing.T, width, height int, orientation byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height)), nil))
+
+	exif := []byte{
+		0xff, 0xe1, 0, 46, 'E', 'x', 'i', 'f', 0, 0,
+		'I', 'I', 42, 0, 8, 0, 0, 0, 2, 0,
+		0x12, 0x01, 3, 0, 1, 0, 0, 0, orientation, 0, 0, 0, // orientation
+		0x0f, 0x01, 2, 0, 4, 0, 0, 0, 'A', 'C', 'M', 0, // camera make
+		0, 0, 0, 0,
+	}
+	comment := append([]byte{0xff, 0xfe, 0, 2 + 11}, "secret spot"...)
+	data := buf.Bytes()
+	return slices.Concat(data[:2], exif, comment, data[2:])
+}
+
+// pictureKeys returns the storage keys the picture URLs point to.
+func pictureKeys(t *testing.T, urls map[string]string) map[string]string {
+	t.Helper()
+	keys := map[string]string{}
+	for name, url := range urls {
+		// User pictures are private, so the response links them signed
+		require.True(t, strings.HasPrefix(url, "/api/v1/files/user_picture/"), url)
+		require.Contains(t, url, "signature=")
+		keys[name], _, _ = strings.Cut(strings.TrimPrefix(url, "/api/v1/files/"), "?")
+	}
+	return keys
+}
+
 func TestUserService_ChangePicture(t *testing.T) {
 	tmpDir := setupTemporaryFileDir(t)
 	us, repo, _, ctx := setupUserServiceMock()
 
 	expectedUser := entity.User{ID: uuid.New(), Name: "P", Email: "p@mail.test"}
-	fh := buildFileHeader(t, "picture", "pic.txt", []byte("hello"))
-	repo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), "id", expectedUser.ID.String()).Return(expectedUser, nil).Once()
+	fh := buildFileHeader(t, "picture", "pic.png", pngBytes(t, 64, 64))
+	repo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), "id", expectedUser.ID.String()).Return(expectedUser, nil)
+	repo.On("UpdateUser", ctx, (*gorm.DB)(nil), mock.AnythingOfType("entity.User")).Return(nil)
+
+	updated, err := us.ChangePicture(ctx, dto.UserChangePictureRequest{ID: expectedUser.ID.String(), Picture: fh})
+	require.NoError(t, err)
+	keys := pictureKeys(t, updated.Picture)
+	require.Len(t, keys, 4)
+	for _, name := range []string{"original", "thumbnail", "medium", "webp"} {
+		require.FileExists(t, filepath.Join(tmpDir, "files", keys[name]), name+" should have been uploaded")
+	}
+	require.Equal(t, keys["original"]+"_thumbnail", keys["thumbnail"])
+}
+
+func TestUserService_ChangePicture_Variants(t *testing.T) {
+	t.Setenv("USER_PICTURE_VARIANTS", "small:40:png,tile:0:webp")
+	tmpDir := setupTemporaryFileDir(t)
+	us, repo, _, ctx := setupUserServiceMock()
+
+	expectedUser := entity.User{ID: uuid.New(), Name: "P", Email: "p@mail.test"}
+	// Stored sideways; orientation 6 turns it clockwise to 60x120
+	fh := buildFileHeader(t, "picture", "pic.jpg", jpegWithExif(t, 120, 60, 6))
+	repo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), "id", expectedUser.ID.String()).Return(expectedUser, nil)
+	repo.On("UpdateUser", ctx, (*gorm.DB)(nil), mock.AnythingOfType("entity.User")).Return(nil)
+
+	updated, err := us.ChangePicture(ctx, dto.UserChangePictureRequest{ID: expectedUser.ID.String(), Picture: fh})
+	require.NoError(t, err)
+	keys := pictureKeys(t, updated.Picture)
+	require.Len(t, keys, 3)
+
+	// The original keeps its pixels and orientation but loses the rest
+	original, err := os.ReadFile(filepath.Join(tmpDir, "files", keys["original"]))
+	require.NoError(t, err)
+	require.NotContains(t, string(original), "ACM")
+	require.NotContains(t, string(original), "secret spot")
+	require.Equal(t, 6, util.ImageOrientation(bytes.NewReader(original), "image/jpeg"))
+	_, err = jpeg.Decode(bytes.NewReader(original))
+	require.NoError(t, err)
+
+	small, err := os.ReadFile(filepath.Join(tmpDir, "files", keys["small"]))
+	require.NoError(t, err)
+	cfg, err := png.DecodeConfig(bytes.NewReader(small))
+	require.NoError(t, err)
+	require.Equal(t, [2]int{20, 40}, [2]int{cfg.Width, cfg.Height})
+
+	tile, err := os.ReadFile(filepath.Join(tmpDir, "files", keys["tile"]))
+	require.NoError(t, err)
+	width, height, err := util.ImageDimensions(bytes.NewReader(tile), "image/webp")
+	require.NoError(t, err)
+	require.Equal(t, [2]int{60, 120}, [2]int{width, height})
+}
+
+func TestUserService_ChangePicture_WebPVariants(t *testing.T) {
+	t.Setenv("USER_PICTURE_VARIANTS", "small:40:png")
+	tmpDir := setupTemporaryFileDir(t)
+	us, repo, _, ctx := setupUserServiceMock()
+
+	var webp bytes.Buffer
+	require.NoError(t, util.EncodeWebP(&webp, image.NewRGBA(image.Rect(0, 0, 120, 60))))
+	expectedUser := entity.User{ID: uuid.New(), Name: "P", Email: "p@mail.test"}
+	fh := buildFileHeader(t, "picture", "pic.webp", webp.Bytes())
+	repo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), "id", expectedUser.ID.String()).Return(expectedUser, nil)
 	repo.On("UpdateUser", ctx, (*gorm.DB)(nil), mock.AnythingOfType("entity.User")).Return(nil)
 
 	updated, err := us.ChangePicture(ctx, dto.UserChangePictureRequest{ID: expectedUser.ID.String(), Picture: fh})
 	require.NoError(t, err)
-	require.NotEmpty(t, updated.Picture)
-	expectedFilePath := filepath.Join(tmpDir, "files", updated.Picture)
-	require.FileExists(t, expectedFilePath, "file should have been uploaded")
+	keys := pictureKeys(t, updated.Picture)
+
+	// Decoded and resized, not a copy of the original
+	small, err := os.ReadFile(filepath.Join(tmpDir, "files", keys["small"]))
+	require.NoError(t, err)
+	cfg, err := png.DecodeConfig(bytes.NewReader(small))
+	require.NoError(t, err)
+	require.Equal(t, [2]int{40, 20}, [2]int{cfg.Width, cfg.Height})
+}
+
+func TestUserService_ChangePicture_RejectsByPolicy(t *testing.T) {
+	t.Setenv("USER_PICTURE_MAX_BYTES", "2048")
+	t.Setenv("USER_PICTURE_MAX_DIMENSION", "256")
+
+	// Same header and chunks, but the compressed pixels are garbage
+	garbled := pngBytes(t, 64, 64)
+	idat := bytes.Index(garbled, []byte("IDAT")) + 4
+	copy(garbled[idat:], bytes.Repeat([]byte{0xff}, 8))
+	tests := []struct {
+		name     string
+		filename string
+		content  []byte
+		message  string
+	}{
+		{
+			// The extension and client header do not count, only the content
+			name: "not an image", filename: "pic.png", content: []byte("hello"),
+			message: "The file in 'picture' must be one of the following types: image/jpeg, image/png, image/gif, image/webp.",
+		},
+		{
+			name: "too large", filename: "pic.png", content: bytes.Repeat([]byte{0}, 4096),
+			message: "The file in 'picture' must be at most 2 KiB.",
+		},
+		{
+			name: "too small", filename: "pic.png", content: pngBytes(t, 16, 64),
+			message: "The image in 'picture' must be at least 32x32 pixels.",
+		},
+		{
+			name: "too big", filename: "pic.png", content: pngBytes(t, 512, 64),
+			message: "The image in 'picture' must be at most 256x256 pixels.",
+		},
+		{
+			name: "corrupt image", filename: "pic.png", content: pngBytes(t, 64, 64)[:20],
+			message: "The file in 'picture' must be a valid image.",
+		},
+		{
+			name: "undecodable image", filename: "pic.png", content: garbled,
+			message: "The file in 'picture' must be a valid image.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := setupTemporaryFileDir(t)
+			us, repo, _, ctx := setupUserServiceMock()
+
+			oldPath := "user_picture/" + uuid.New().String()
+			require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "files", "user_picture"), 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "files", oldPath), []byte("old"), 0644))
+
+			user := entity.User{ID: uuid.New(), Name: "P", Email: "p@mail.test", Picture: &oldPath}
+			repo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), "id", user.ID.String()).Return(user, nil)
+
+			fh := buildFileHeader(t, "picture", tt.filename, tt.content)
+			_, err := us.ChangePicture(ctx, dto.UserChangePictureRequest{ID: user.ID.String(), Picture: fh})
+			require.ErrorIs(t, err, errs.ErrUploadPolicy)
+
+			var policyErr *errs.UploadPolicyError
+			require.ErrorAs(t, err, &policyErr)
+			require.Equal(t, []string{tt.message}, policyErr.FieldErrors())
+
+			// Nothing stored and the current picture is kept
+			entries, err := os.ReadDir(filepath.Join(tmpDir, "files", "user_picture"))
+			require.NoError(t, err)
+			require.Len(t, entries, 1)
+			repo.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestUserService_ChangePicture_RejectsUndecodableWithoutVariants(t *testing.T) {
+	// Only empty entries, so no variants are made
+	t.Setenv("USER_PICTURE_VARIANTS", ",")
+	tmpDir := setupTemporaryFileDir(t)
+	us, repo, _, ctx := setupUserServiceMock()
+
+	garbled := pngBytes(t, 64, 64)
+	idat := bytes.Index(garbled, []byte("IDAT")) + 4
+	copy(garbled[idat:], bytes.Repeat([]byte{0xff}, 8))
+
+	user := entity.User{ID: uuid.New(), Name: "P", Email: "p@mail.test"}
+	repo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), "id", user.ID.String()).Return(user, nil)
+
+	fh := buildFileHeader(t, "picture", "pic.png", garbled)
+	_, err := us.ChangePicture(ctx, dto.UserChangePictureRequest{ID: user.ID.String(), Picture: fh})
+	require.ErrorIs(t, err, errs.ErrUploadPolicy)
+	require.NoDirExists(t, filepath.Join(tmpDir, "files", "user_picture"))
+	repo.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func TestUserService_DeletePicture(t *testing.T) {
@@ -83,7 +284,7 @@ func TestUserService_DeletePicture(t *testing.T) {
 	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "files", picPath), []byte("hello"), 0644))
 
 	repo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), "id", expectedUser.ID.String()).
-		Return(expectedUser, nil).Once()
+		Return(expectedUser, nil)
 	repo.On("UpdateUser", ctx, (*gorm.DB)(nil), mock.AnythingOfType("entity.User")).
 		Return(nil)
 
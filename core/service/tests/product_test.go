@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"testing"
+	"time"
 
 	"myapp/core/entity"
 	"myapp/core/helper/dto"
@@ -150,6 +151,52 @@ func (m *mockTxRepository) CommitOrRollbackTx(ctx context.Context, tx *gorm.DB,
 	m.Called(ctx, tx, err)
 }
 
+type mockAuditLogRepository struct {
+	mock.Mock
+}
+
+func (m *mockAuditLogRepository) DB() *gorm.DB {
+	return nil
+}
+
+func (m *mockAuditLogRepository) CreateAuditLog(ctx context.Context, tx *gorm.DB, log entity.AuditLog) (entity.AuditLog, error) {
+	args := m.Called(ctx, tx, log)
+	return args.Get(0).(entity.AuditLog), args.Error(1)
+}
+
+func (m *mockAuditLogRepository) RedactAuditLogs(ctx context.Context, tx *gorm.DB, entityType string,
+	entityID string, fields []string) error {
+	args := m.Called(ctx, tx, entityType, entityID, fields)
+	return args.Error(0)
+}
+
+type mockProductRevisionRepository struct {
+	mock.Mock
+}
+
+func (m *mockProductRevisionRepository) DB() *gorm.DB {
+	return nil
+}
+
+func (m *mockProductRevisionRepository) CreateProductRevision(ctx context.Context, tx *gorm.DB, revision entity.ProductRevision) (entity.ProductRevision, error) {
+	args := m.Called(ctx, tx, revision)
+	return args.Get(0).(entity.ProductRevision), args.Error(1)
+}
+
+func (m *mockProductRevisionRepository) GetProductRevisionAsOf(ctx context.Context, tx *gorm.DB, productID string, asOf time.Time) (entity.ProductRevision, error) {
+	args := m.Called(ctx, tx, productID, asOf)
+	return args.Get(0).(entity.ProductRevision), args.Error(1)
+}
+
+type mockProductRevisionQuery struct {
+	mock.Mock
+}
+
+func (m *mockProductRevisionQuery) GetProductRevisions(ctx context.Context, req dto.ProductHistoryRequest) ([]entity.ProductRevision, base.PaginationResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).([]entity.ProductRevision), args.Get(1).(base.PaginationResponse), args.Error(2)
+}
+
 // ============== Tests ==============
 
 func TestCreateProduct_Success(t *testing.T) {
@@ -159,9 +206,13 @@ func TestCreateProduct_Success(t *testing.T) {
 	mockProductQ := new(mockProductQuery)
 	mockCategoryQ := new(mockCategoryQuery)
 	mockTxRepo := new(mockTxRepository)
+	mockAuditLogRepo := new(mockAuditLogRepository)
+	mockRevisionRepo := new(mockProductRevisionRepository)
+	mockRevisionQ := new(mockProductRevisionQuery)
 
 	productService := service.NewProductService(
-		mockProductRepo, mockCategoryRepo, mockProductQ, mockCategoryQ, mockTxRepo,
+		mockProductRepo, mockCategoryRepo, mockProductQ, mockCategoryQ, mockTxRepo, mockAuditLogRepo,
+		mockRevisionRepo, mockRevisionQ, nil, nil,
 	)
 
 	ctx := context.Background()
@@ -188,8 +239,17 @@ func TestCreateProduct_Success(t *testing.T) {
 	// Expectations
 	mockProductRepo.On("GetProductByPrimaryKey", ctx, (*gorm.DB)(nil), "sku", req.SKU).
 		Return(entity.Product{}, errs.ErrProductNotFound)
+	mockTxRepo.On("BeginTx", ctx).Return(nil, nil)
+	mockTxRepo.On("CommitOrRollbackTx", ctx, (*gorm.DB)(nil), nil).Return()
 	mockProductRepo.On("CreateProduct", ctx, (*gorm.DB)(nil), mock.AnythingOfType("entity.Product")).
 		Return(expectedProduct, nil)
+	mockAuditLogRepo.On("CreateAuditLog", ctx, (*gorm.DB)(nil), mock.MatchedBy(func(log entity.AuditLog) bool {
+		return log.Action == "create" && log.EntityType == "product" && log.EntityID == productID.String() &&
+			string(log.Changes["sku"].After) == `"TEST-SKU-001"` && log.Changes["sku"].Before == nil
+	})).Return(entity.AuditLog{}, nil)
+	mockRevisionRepo.On("CreateProductRevision", ctx, (*gorm.DB)(nil), mock.MatchedBy(func(rev entity.ProductRevision) bool {
+		return rev.ProductID == productID && rev.Action == "create" && rev.Stock == 100
+	})).Return(entity.ProductRevision{}, nil)
 
 	// Execute
 	result, err := productService.CreateProduct(ctx, req)
@@ -199,6 +259,9 @@ func TestCreateProduct_Success(t *testing.T) {
 	assert.Equal(t, expectedProduct.Name, result.Name)
 	assert.Equal(t, expectedProduct.SKU, result.SKU)
 	mockProductRepo.AssertExpectations(t)
+	mockTxRepo.AssertExpectations(t)
+	mockAuditLogRepo.AssertExpectations(t)
+	mockRevisionRepo.AssertExpectations(t)
 }
 
 func TestCreateProduct_DuplicateSKU(t *testing.T) {
@@ -208,9 +271,13 @@ func TestCreateProduct_DuplicateSKU(t *testing.T) {
 	mockProductQ := new(mockProductQuery)
 	mockCategoryQ := new(mockCategoryQuery)
 	mockTxRepo := new(mockTxRepository)
+	mockAuditLogRepo := new(mockAuditLogRepository)
+	mockRevisionRepo := new(mockProductRevisionRepository)
+	mockRevisionQ := new(mockProductRevisionQuery)
 
 	productService := service.NewProductService(
-		mockProductRepo, mockCategoryRepo, mockProductQ, mockCategoryQ, mockTxRepo,
+		mockProductRepo, mockCategoryRepo, mockProductQ, mockCategoryQ, mockTxRepo, mockAuditLogRepo,
+		mockRevisionRepo, mockRevisionQ, nil, nil,
 	)
 
 	ctx := context.Background()
@@ -247,9 +314,13 @@ func TestGetProductByID_Success(t *testing.T) {
 	mockProductQ := new(mockProductQuery)
 	mockCategoryQ := new(mockCategoryQuery)
 	mockTxRepo := new(mockTxRepository)
+	mockAuditLogRepo := new(mockAuditLogRepository)
+	mockRevisionRepo := new(mockProductRevisionRepository)
+	mockRevisionQ := new(mockProductRevisionQuery)
 
 	productService := service.NewProductService(
-		mockProductRepo, mockCategoryRepo, mockProductQ, mockCategoryQ, mockTxRepo,
+		mockProductRepo, mockCategoryRepo, mockProductQ, mockCategoryQ, mockTxRepo, mockAuditLogRepo,
+		mockRevisionRepo, mockRevisionQ, nil, nil,
 	)
 
 	ctx := context.Background()
@@ -293,9 +364,13 @@ func TestUpdateStock_Success(t *testing.T) {
 	mockProductQ := new(mockProductQuery)
 	mockCategoryQ := new(mockCategoryQuery)
 	mockTxRepo := new(mockTxRepository)
+	mockAuditLogRepo := new(mockAuditLogRepository)
+	mockRevisionRepo := new(mockProductRevisionRepository)
+	mockRevisionQ := new(mockProductRevisionQuery)
 
 	productService := service.NewProductService(
-		mockProductRepo, mockCategoryRepo, mockProductQ, mockCategoryQ, mockTxRepo,
+		mockProductRepo, mockCategoryRepo, mockProductQ, mockCategoryQ, mockTxRepo, mockAuditLogRepo,
+		mockRevisionRepo, mockRevisionQ, nil, nil,
 	)
 
 	ctx := context.Background()
@@ -312,11 +387,26 @@ func TestUpdateStock_Success(t *testing.T) {
 		Quantity: 50,
 	}
 
+	updatedProduct := existingProduct
+	updatedProduct.Stock = 150
+
 	// Expectations
 	mockProductRepo.On("GetProductByID", ctx, (*gorm.DB)(nil), productID.String(), []string(nil)).
-		Return(existingProduct, nil)
+		Return(existingProduct, nil).Once()
+	mockTxRepo.On("BeginTx", ctx).Return(nil, nil)
+	mockTxRepo.On("CommitOrRollbackTx", ctx, (*gorm.DB)(nil), nil).Return()
 	mockProductRepo.On("UpdateProductStock", ctx, (*gorm.DB)(nil), productID.String(), 50).
 		Return(nil)
+	mockProductRepo.On("GetProductByID", ctx, (*gorm.DB)(nil), productID.String(), []string(nil)).
+		Return(updatedProduct, nil).Once()
+	mockAuditLogRepo.On("CreateAuditLog", ctx, (*gorm.DB)(nil), mock.MatchedBy(func(log entity.AuditLog) bool {
+		change, ok := log.Changes["stock"]
+		return log.Action == "update" && len(log.Changes) == 1 && ok &&
+			string(change.Before) == "100" && string(change.After) == "150"
+	})).Return(entity.AuditLog{}, nil)
+	mockRevisionRepo.On("CreateProductRevision", ctx, (*gorm.DB)(nil), mock.MatchedBy(func(rev entity.ProductRevision) bool {
+		return rev.ProductID == productID && rev.Action == "update" && rev.Stock == 150
+	})).Return(entity.ProductRevision{}, nil)
 
 	// Execute
 	result, err := productService.UpdateStock(ctx, req)
@@ -325,6 +415,65 @@ func TestUpdateStock_Success(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, 150, result.Stock)
 	mockProductRepo.AssertExpectations(t)
+	mockAuditLogRepo.AssertExpectations(t)
+	mockRevisionRepo.AssertExpectations(t)
+}
+
+func TestUpdateStock_ReturnsStoredProduct(t *testing.T) {
+	// Setup
+	mockProductRepo := new(mockProductRepository)
+	mockCategoryRepo := new(mockCategoryRepository)
+	mockProductQ := new(mockProductQuery)
+	mockCategoryQ := new(mockCategoryQuery)
+	mockTxRepo := new(mockTxRepository)
+	mockAuditLogRepo := new(mockAuditLogRepository)
+	mockRevisionRepo := new(mockProductRevisionRepository)
+	mockRevisionQ := new(mockProductRevisionQuery)
+
+	productService := service.NewProductService(
+		mockProductRepo, mockCategoryRepo, mockProductQ, mockCategoryQ, mockTxRepo, mockAuditLogRepo,
+		mockRevisionRepo, mockRevisionQ, nil, nil,
+	)
+
+	ctx := context.Background()
+	productID := uuid.New()
+
+	existingProduct := entity.Product{
+		ID:    productID,
+		Name:  "Test Product",
+		Stock: 100,
+	}
+
+	// Another order took 30 items between the read and the update
+	storedProduct := existingProduct
+	storedProduct.Stock = 120
+
+	req := dto.ProductStockUpdateRequest{
+		ID:       productID.String(),
+		Quantity: 50,
+	}
+
+	// Expectations
+	mockProductRepo.On("GetProductByID", ctx, (*gorm.DB)(nil), productID.String(), []string(nil)).
+		Return(existingProduct, nil).Once()
+	mockTxRepo.On("BeginTx", ctx).Return(nil, nil)
+	mockTxRepo.On("CommitOrRollbackTx", ctx, (*gorm.DB)(nil), nil).Return()
+	mockProductRepo.On("UpdateProductStock", ctx, (*gorm.DB)(nil), productID.String(), 50).
+		Return(nil)
+	mockProductRepo.On("GetProductByID", ctx, (*gorm.DB)(nil), productID.String(), []string(nil)).
+		Return(storedProduct, nil).Once()
+	mockAuditLogRepo.On("CreateAuditLog", ctx, (*gorm.DB)(nil), mock.AnythingOfType("entity.AuditLog")).
+		Return(entity.AuditLog{}, nil)
+	mockRevisionRepo.On("CreateProductRevision", ctx, (*gorm.DB)(nil), mock.AnythingOfType("entity.ProductRevision")).
+		Return(entity.ProductRevision{}, nil)
+
+	// Execute
+	result, err := productService.UpdateStock(ctx, req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 120, result.Stock)
+	mockProductRepo.AssertExpectations(t)
 }
 
 func TestUpdateStock_InsufficientStock(t *testing.T) {
@@ -334,9 +483,13 @@ func TestUpdateStock_InsufficientStock(t *testing.T) {
 	mockProductQ := new(mockProductQuery)
 	mockCategoryQ := new(mockCategoryQuery)
 	mockTxRepo := new(mockTxRepository)
+	mockAuditLogRepo := new(mockAuditLogRepository)
+	mockRevisionRepo := new(mockProductRevisionRepository)
+	mockRevisionQ := new(mockProductRevisionQuery)
 
 	productService := service.NewProductService(
-		mockProductRepo, mockCategoryRepo, mockProductQ, mockCategoryQ, mockTxRepo,
+		mockProductRepo, mockCategoryRepo, mockProductQ, mockCategoryQ, mockTxRepo, mockAuditLogRepo,
+		mockRevisionRepo, mockRevisionQ, nil, nil,
 	)
 
 	ctx := context.Background()
@@ -373,9 +526,13 @@ func TestGetLowStockProducts_Success(t *testing.T) {
 	mockProductQ := new(mockProductQuery)
 	mockCategoryQ := new(mockCategoryQuery)
 	mockTxRepo := new(mockTxRepository)
+	mockAuditLogRepo := new(mockAuditLogRepository)
+	mockRevisionRepo := new(mockProductRevisionRepository)
+	mockRevisionQ := new(mockProductRevisionQuery)
 
 	productService := service.NewProductService(
-		mockProductRepo, mockCategoryRepo, mockProductQ, mockCategoryQ, mockTxRepo,
+		mockProductRepo, mockCategoryRepo, mockProductQ, mockCategoryQ, mockTxRepo, mockAuditLogRepo,
+		mockRevisionRepo, mockRevisionQ, nil, nil,
 	)
 
 	ctx := context.Background()
@@ -398,3 +555,100 @@ func TestGetLowStockProducts_Success(t *testing.T) {
 	assert.Len(t, result, 2)
 	mockProductQ.AssertExpectations(t)
 }
+
+func TestGetProductAsOf_Success(t *testing.T) {
+	// Setup
+	mockRevisionRepo := new(mockProductRevisionRepository)
+	productService := service.NewProductService(
+		new(mockProductRepository), new(mockCategoryRepository), new(mockProductQuery), new(mockCategoryQuery),
+		new(mockTxRepository), new(mockAuditLogRepository), mockRevisionRepo, new(mockProductRevisionQuery),
+		nil, nil,
+	)
+
+	ctx := context.Background()
+	productID := uuid.New()
+	asOf := time.Date(2026, 10, 13, 12, 0, 0, 0, time.UTC)
+
+	// Expectations
+	mockRevisionRepo.On("GetProductRevisionAsOf", ctx, (*gorm.DB)(nil), productID.String(), asOf).
+		Return(entity.ProductRevision{
+			ProductID: productID,
+			Revision:  3,
+			Action:    "update",
+			Name:      "Old Name",
+			SKU:       "SKU-1",
+			Price:     decimal.NewFromFloat(12.5),
+			Stock:     7,
+			IsActive:  true,
+		}, nil)
+
+	// Execute
+	result, err := productService.GetProductAsOf(ctx, productID.String(), asOf)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, productID.String(), result.ID)
+	assert.Equal(t, "Old Name", result.Name)
+	assert.True(t, decimal.NewFromFloat(12.5).Equal(result.Price))
+	assert.Equal(t, 7, result.Stock)
+}
+
+func TestGetProductAsOf_Deleted(t *testing.T) {
+	// Setup
+	mockRevisionRepo := new(mockProductRevisionRepository)
+	productService := service.NewProductService(
+		new(mockProductRepository), new(mockCategoryRepository), new(mockProductQuery), new(mockCategoryQuery),
+		new(mockTxRepository), new(mockAuditLogRepository), mockRevisionRepo, new(mockProductRevisionQuery),
+		nil, nil,
+	)
+
+	ctx := context.Background()
+	productID := uuid.New()
+	asOf := time.Now()
+
+	// Expectations
+	mockRevisionRepo.On("GetProductRevisionAsOf", ctx, (*gorm.DB)(nil), productID.String(), asOf).
+		Return(entity.ProductRevision{ProductID: productID, Revision: 2, Action: "delete"}, nil)
+
+	// Execute
+	_, err := productService.GetProductAsOf(ctx, productID.String(), asOf)
+
+	// Assert
+	assert.Equal(t, errs.ErrProductNotFoundAsOf, err)
+}
+
+func TestGetProductHistory_Success(t *testing.T) {
+	// Setup
+	mockRevisionQ := new(mockProductRevisionQuery)
+	productService := service.NewProductService(
+		new(mockProductRepository), new(mockCategoryRepository), new(mockProductQuery), new(mockCategoryQuery),
+		new(mockTxRepository), new(mockAuditLogRepository), new(mockProductRevisionRepository), mockRevisionQ,
+		nil, nil,
+	)
+
+	ctx := context.Background()
+	productID := uuid.New()
+	changedByID := uuid.New()
+	req := dto.ProductHistoryRequest{ProductID: productID.String()}
+
+	revisions := []entity.ProductRevision{
+		{ProductID: productID, Revision: 2, Action: "update", Stock: 5, ChangedByID: &changedByID},
+		{ProductID: productID, Revision: 1, Action: "create", Stock: 10},
+	}
+
+	// Expectations
+	mockRevisionQ.On("GetProductRevisions", ctx, req).
+		Return(revisions, base.PaginationResponse{Page: 1, PerPage: 10, LastPage: 1, Total: 2}, nil)
+
+	// Execute
+	result, page, err := productService.GetProductHistory(ctx, req)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+	assert.Equal(t, 2, result[0].Revision)
+	assert.Equal(t, changedByID.String(), result[0].ChangedByID)
+	assert.Empty(t, result[1].ChangedByID)
+	assert.Equal(t, int64(2), page.Total)
+	mockRevisionQ.AssertExpectations(t)
+}
@@ -0,0 +1,325 @@
+package service_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	"myapp/core/service"
+	"myapp/support/base"
+	"myapp/support/constant"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// --- Mock Repository ---
+
+type MockRoleRepository struct {
+	mock.Mock
+}
+
+func (m *MockRoleRepository) DB() *gorm.DB {
+	return nil
+}
+
+func (m *MockRoleRepository) CreateRole(ctx context.Context, tx *gorm.DB, role entity.Role) (entity.Role, error) {
+	args := m.Called(ctx, tx, role)
+	return args.Get(0).(entity.Role), args.Error(1)
+}
+
+func (m *MockRoleRepository) GetAllRoles(ctx context.Context, tx *gorm.DB) ([]entity.Role, error) {
+	args := m.Called(ctx, tx)
+	return args.Get(0).([]entity.Role), args.Error(1)
+}
+
+func (m *MockRoleRepository) GetRoleByPrimaryKey(ctx context.Context, tx *gorm.DB, key string,
+	val string) (entity.Role, error) {
+	args := m.Called(ctx, tx, key, val)
+	return args.Get(0).(entity.Role), args.Error(1)
+}
+
+func (m *MockRoleRepository) ReplaceRolePermissions(ctx context.Context, tx *gorm.DB, role entity.Role,
+	permissions []entity.Permission) error {
+	args := m.Called(ctx, tx, role, permissions)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepository) DeleteRole(ctx context.Context, tx *gorm.DB, role entity.Role) error {
+	args := m.Called(ctx, tx, role)
+	return args.Error(0)
+}
+
+func (m *MockRoleRepository) IsRoleAssigned(ctx context.Context, tx *gorm.DB, name string) (bool, error) {
+	args := m.Called(ctx, tx, name)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRoleRepository) RoleHasPermission(ctx context.Context, tx *gorm.DB, name string,
+	permission string) (bool, error) {
+	args := m.Called(ctx, tx, name, permission)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockRoleRepository) GetAllPermissions(ctx context.Context, tx *gorm.DB) ([]entity.Permission, error) {
+	args := m.Called(ctx, tx)
+	return args.Get(0).([]entity.Permission), args.Error(1)
+}
+
+func (m *MockRoleRepository) GetPermissionsByNames(ctx context.Context, tx *gorm.DB,
+	names []string) ([]entity.Permission, error) {
+	args := m.Called(ctx, tx, names)
+	return args.Get(0).([]entity.Permission), args.Error(1)
+}
+
+// --- Test Helpers ---
+
+func setupRoleServiceMock() (service.RoleService, *MockRoleRepository, *MockTxRepository, context.Context) {
+	roleRepo := new(MockRoleRepository)
+	txRepo := new(MockTxRepository)
+	rs := service.NewRoleService(roleRepo, txRepo)
+
+	return rs, roleRepo, txRepo, context.Background()
+}
+
+// --- Tests ---
+
+func TestRoleService_CreateRole(t *testing.T) {
+	rs, roleRepo, _, ctx := setupRoleServiceMock()
+
+	writePerm := entity.Permission{ID: uuid.New(), Name: constant.EnumPermissionProductsWrite}
+	stockPerm := entity.Permission{ID: uuid.New(), Name: constant.EnumPermissionProductsStock}
+	// Not found, wrapped as a repository may do
+	roleRepo.On("GetRoleByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrName, "catalog_manager").
+		Return(entity.Role{}, fmt.Errorf("get role: %w", errs.ErrRoleNotFound))
+	roleRepo.On("GetPermissionsByNames", ctx, (*gorm.DB)(nil),
+		[]string{constant.EnumPermissionProductsStock, constant.EnumPermissionProductsWrite}).
+		Return([]entity.Permission{stockPerm, writePerm}, nil)
+	roleRepo.On("CreateRole", ctx, (*gorm.DB)(nil), mock.AnythingOfType("entity.Role")).
+		Return(entity.Role{ID: uuid.New(), Name: "catalog_manager",
+			Permissions: []entity.Permission{writePerm, stockPerm}}, nil)
+
+	resp, err := rs.CreateRole(ctx, dto.RoleCreateRequest{
+		Name: "catalog_manager",
+		Permissions: []string{constant.EnumPermissionProductsWrite, constant.EnumPermissionProductsStock,
+			constant.EnumPermissionProductsWrite},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{constant.EnumPermissionProductsStock, constant.EnumPermissionProductsWrite},
+		resp.Permissions)
+	roleRepo.AssertExpectations(t)
+}
+
+func TestRoleService_CreateRole_UnknownPermission(t *testing.T) {
+	rs, roleRepo, _, ctx := setupRoleServiceMock()
+
+	roleRepo.On("GetRoleByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrName, "catalog_manager").
+		Return(entity.Role{}, errs.ErrRoleNotFound)
+	roleRepo.On("GetPermissionsByNames", ctx, (*gorm.DB)(nil), mock.Anything).
+		Return([]entity.Permission{{Name: constant.EnumPermissionProductsWrite}}, nil)
+
+	_, err := rs.CreateRole(ctx, dto.RoleCreateRequest{
+		Name:        "catalog_manager",
+		Permissions: []string{constant.EnumPermissionProductsWrite, "products:launch"},
+	})
+	require.ErrorIs(t, err, errs.ErrPermissionNotFound)
+	roleRepo.AssertNotCalled(t, "CreateRole", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRoleService_CreateRole_Reserved(t *testing.T) {
+	rs, _, _, ctx := setupRoleServiceMock()
+
+	_, err := rs.CreateRole(ctx, dto.RoleCreateRequest{Name: constant.EnumRoleAPIKey})
+	require.ErrorIs(t, err, errs.ErrRoleAlreadyExists)
+}
+
+func TestRoleService_UpdateRolePermissions_AdminLockout(t *testing.T) {
+	rs, roleRepo, txRepo, ctx := setupRoleServiceMock()
+
+	tx := &gorm.DB{}
+	roleID := uuid.New()
+	txRepo.On("BeginTx", ctx).Return(tx, nil)
+	txRepo.On("CommitOrRollbackTx", ctx, tx, errs.ErrRoleAdminLockout).Return()
+	roleRepo.On("GetRoleByPrimaryKey", ctx, tx, constant.DBAttrID, roleID.String()).
+		Return(entity.Role{ID: roleID, Name: constant.EnumRoleAdmin, System: true}, nil)
+
+	_, err := rs.UpdateRolePermissions(ctx, dto.RolePermissionsUpdateRequest{
+		ID:          roleID.String(),
+		Permissions: []string{constant.EnumPermissionUsersRead},
+	})
+	require.ErrorIs(t, err, errs.ErrRoleAdminLockout)
+	roleRepo.AssertNotCalled(t, "ReplaceRolePermissions", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	txRepo.AssertExpectations(t)
+}
+
+func TestRoleService_DeleteRole(t *testing.T) {
+	testCases := []struct {
+		name     string
+		role     entity.Role
+		assigned bool
+		wantErr  error
+	}{
+		{"system role", entity.Role{Name: constant.EnumRoleUser, System: true}, false, errs.ErrRoleSystem},
+		{"assigned role", entity.Role{Name: "catalog_manager"}, true, errs.ErrRoleInUse},
+		{"unused role", entity.Role{Name: "catalog_manager"}, false, nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rs, roleRepo, txRepo, ctx := setupRoleServiceMock()
+
+			tx := &gorm.DB{}
+			tc.role.ID = uuid.New()
+			txRepo.On("BeginTx", ctx).Return(tx, nil)
+			txRepo.On("CommitOrRollbackTx", ctx, tx, tc.wantErr).Return()
+			roleRepo.On("GetRoleByPrimaryKey", ctx, tx, constant.DBAttrID, tc.role.ID.String()).Return(tc.role, nil)
+			roleRepo.On("IsRoleAssigned", ctx, tx, tc.role.Name).Return(tc.assigned, nil)
+			roleRepo.On("DeleteRole", ctx, tx, tc.role).Return(nil)
+
+			err := rs.DeleteRole(ctx, tc.role.ID.String())
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				roleRepo.AssertNotCalled(t, "DeleteRole", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			roleRepo.AssertCalled(t, "DeleteRole", ctx, tx, tc.role)
+		})
+	}
+}
+
+func TestUserService_UpdateUserByID_UnknownRole(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	roleRepo := new(MockRoleRepository)
+	policy, _ := service.NewPasswordPolicy()
+	us := service.NewUserService(userRepo, new(MockUserQuery), roleRepo, new(MockTxRepository),
+		new(MockAuditLogRepository), nil, nil, policy)
+	ctx := context.Background()
+
+	user := entity.User{ID: uuid.New(), Role: constant.EnumRoleUser}
+	userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrID, user.ID.String()).Return(user, nil)
+	roleRepo.On("GetRoleByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrName, constant.EnumRoleAdmin).
+		Return(roleWith(constant.EnumRoleAdmin, constant.EnumPermissionUsersWrite), nil)
+	roleRepo.On("GetRoleByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrName, "superuser").
+		Return(entity.Role{}, errs.ErrRoleNotFound)
+
+	_, err := us.UpdateUserByID(ctx, dto.UserUpdateRequest{
+		ID: user.ID.String(), ActorID: uuid.NewString(), ActorRole: constant.EnumRoleAdmin, Role: "superuser",
+	})
+	require.ErrorIs(t, err, errs.ErrRoleNotFound)
+	userRepo.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserService_UpdateUserByID_RoleChange(t *testing.T) {
+	manager := roleWith("manager", constant.EnumPermissionUsersRead, constant.EnumPermissionUsersWrite)
+	admin := roleWith(constant.EnumRoleAdmin, constant.EnumPermissionUsersRead, constant.EnumPermissionUsersWrite,
+		constant.EnumPermissionRolesManage)
+	user := roleWith(constant.EnumRoleUser)
+
+	testCases := []struct {
+		name       string
+		self       bool
+		actorRole  string
+		targetRole string
+		newRole    string
+		wantErr    error
+	}{
+		{"demote user below caller", false, "manager", "manager", constant.EnumRoleUser, nil},
+		{"promote to own role", false, "manager", constant.EnumRoleUser, "manager", nil},
+		{"promote above caller", false, "manager", constant.EnumRoleUser, constant.EnumRoleAdmin, errs.ErrUserRoleNotAllowed},
+		{"demote user above caller", false, "manager", constant.EnumRoleAdmin, constant.EnumRoleUser, errs.ErrUserRoleNotAllowed},
+		{"own role", true, constant.EnumRoleAdmin, constant.EnumRoleAdmin, constant.EnumRoleUser, errs.ErrUserRoleChangeSelf},
+		{"api key", false, constant.EnumRoleAPIKey, constant.EnumRoleUser, "manager", errs.ErrUserRoleNotAllowed},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			userRepo := new(MockUserRepository)
+			roleRepo := new(MockRoleRepository)
+			txRepo := new(MockTxRepository)
+			auditLogRepo := new(MockAuditLogRepository)
+			policy, _ := service.NewPasswordPolicy()
+			us := service.NewUserService(userRepo, new(MockUserQuery), roleRepo, txRepo,
+				auditLogRepo, nil, nil, policy)
+			ctx := context.Background()
+
+			target := entity.User{ID: uuid.New(), Role: tc.targetRole}
+			actorID := uuid.NewString()
+			if tc.self {
+				actorID = target.ID.String()
+			}
+			userRepo.On("GetUserByPrimaryKey", ctx, mock.Anything, constant.DBAttrID, target.ID.String()).
+				Return(target, nil)
+			for _, role := range []entity.Role{manager, admin, user} {
+				roleRepo.On("GetRoleByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrName, role.Name).
+					Return(role, nil).Maybe()
+			}
+			txRepo.On("BeginTx", ctx).Return((*gorm.DB)(nil), nil).Maybe()
+			txRepo.On("CommitOrRollbackTx", ctx, (*gorm.DB)(nil), mock.Anything).Return().Maybe()
+			userRepo.On("UpdateUser", ctx, (*gorm.DB)(nil), mock.AnythingOfType("entity.User")).Return(nil).Maybe()
+			auditLogRepo.On("CreateAuditLog", ctx, (*gorm.DB)(nil), mock.AnythingOfType("entity.AuditLog")).
+				Return(entity.AuditLog{}, nil).Maybe()
+
+			resp, err := us.UpdateUserByID(ctx, dto.UserUpdateRequest{
+				ID: target.ID.String(), ActorID: actorID, ActorRole: tc.actorRole, Role: tc.newRole,
+			})
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				userRepo.AssertNotCalled(t, "UpdateUser", mock.Anything, mock.Anything, mock.Anything)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.newRole, resp.Role)
+		})
+	}
+}
+
+func TestUserService_RunUserMaintenance_RoleChange(t *testing.T) {
+	manager := roleWith("manager", constant.EnumPermissionUsersRead, constant.EnumPermissionUsersWrite)
+	admin := roleWith(constant.EnumRoleAdmin, constant.EnumPermissionUsersRead, constant.EnumPermissionUsersWrite,
+		constant.EnumPermissionRolesManage)
+	user := roleWith(constant.EnumRoleUser)
+
+	userRepo := new(MockUserRepository)
+	userQuery := new(MockUserQuery)
+	roleRepo := new(MockRoleRepository)
+	txRepo := new(MockTxRepository)
+	auditLogRepo := new(MockAuditLogRepository)
+	policy, _ := service.NewPasswordPolicy()
+	us := service.NewUserService(userRepo, userQuery, roleRepo, txRepo, auditLogRepo, nil, nil, policy)
+	ctx := context.Background()
+
+	caller := entity.User{ID: uuid.New(), Role: "manager"}
+	superior := entity.User{ID: uuid.New(), Role: constant.EnumRoleAdmin}
+	peer := entity.User{ID: uuid.New(), Role: "manager"}
+	for _, role := range []entity.Role{manager, admin, user} {
+		roleRepo.On("GetRoleByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrName, role.Name).Return(role, nil)
+	}
+	userQuery.On("GetAllUsers", ctx, mock.AnythingOfType("dto.UserGetsRequest")).
+		Return([]entity.User{caller, superior, peer}, base.PaginationResponse{}, nil)
+	txRepo.On("BeginTx", ctx).Return((*gorm.DB)(nil), nil)
+	txRepo.On("CommitOrRollbackTx", ctx, (*gorm.DB)(nil), nil).Return()
+	userRepo.On("UpdateUser", ctx, (*gorm.DB)(nil), entity.User{ID: peer.ID, Role: constant.EnumRoleUser}).Return(nil)
+	userRepo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrID, peer.ID.String()).Return(peer, nil)
+	auditLogRepo.On("CreateAuditLog", ctx, (*gorm.DB)(nil), mock.AnythingOfType("entity.AuditLog")).
+		Return(entity.AuditLog{}, nil)
+
+	resp, err := us.RunUserMaintenance(ctx, dto.UserMaintenanceRequest{
+		NewRole: constant.EnumRoleUser, ActorID: caller.ID.String(), ActorRole: "manager",
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, resp.RoleChangedCount)
+	require.Len(t, resp.Details, 1)
+	require.Equal(t, peer.ID.String(), resp.Details[0].UserID)
+	userRepo.AssertNumberOfCalls(t, "UpdateUser", 1)
+
+	_, err = us.RunUserMaintenance(ctx, dto.UserMaintenanceRequest{
+		NewRole: constant.EnumRoleAdmin, ActorID: caller.ID.String(), ActorRole: "manager",
+	})
+	require.ErrorIs(t, err, errs.ErrUserRoleNotAllowed)
+}
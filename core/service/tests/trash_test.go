@@ -0,0 +1,406 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	"myapp/core/service"
+	"myapp/infrastructure/storage"
+	"myapp/support/base"
+	"myapp/support/constant"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// ============== Mock Trash Repository ==============
+
+type mockTrashRepository struct {
+	mock.Mock
+}
+
+func (m *mockTrashRepository) DB() *gorm.DB {
+	return nil
+}
+
+func (m *mockTrashRepository) GetTrashedUserByID(ctx context.Context, tx *gorm.DB, id string) (entity.User, error) {
+	args := m.Called(ctx, tx, id)
+	return args.Get(0).(entity.User), args.Error(1)
+}
+
+func (m *mockTrashRepository) GetTrashedProductByID(ctx context.Context, tx *gorm.DB, id string) (entity.Product, error) {
+	args := m.Called(ctx, tx, id)
+	return args.Get(0).(entity.Product), args.Error(1)
+}
+
+func (m *mockTrashRepository) GetTrashedCategoryByID(ctx context.Context, tx *gorm.DB, id string) (entity.Category, error) {
+	args := m.Called(ctx, tx, id)
+	return args.Get(0).(entity.Category), args.Error(1)
+}
+
+func (m *mockTrashRepository) RestoreUserByID(ctx context.Context, tx *gorm.DB, id string) error {
+	args := m.Called(ctx, tx, id)
+	return args.Error(0)
+}
+
+func (m *mockTrashRepository) RestoreProductByID(ctx context.Context, tx *gorm.DB, id string) error {
+	args := m.Called(ctx, tx, id)
+	return args.Error(0)
+}
+
+func (m *mockTrashRepository) RestoreCategoryByID(ctx context.Context, tx *gorm.DB, id string) error {
+	args := m.Called(ctx, tx, id)
+	return args.Error(0)
+}
+
+func (m *mockTrashRepository) PurgeUserByID(ctx context.Context, tx *gorm.DB, id string) error {
+	args := m.Called(ctx, tx, id)
+	return args.Error(0)
+}
+
+func (m *mockTrashRepository) PurgeProductByID(ctx context.Context, tx *gorm.DB, id string) error {
+	args := m.Called(ctx, tx, id)
+	return args.Error(0)
+}
+
+func (m *mockTrashRepository) PurgeCategoryByID(ctx context.Context, tx *gorm.DB, id string) error {
+	args := m.Called(ctx, tx, id)
+	return args.Error(0)
+}
+
+func (m *mockTrashRepository) GetUsersTrashedBefore(ctx context.Context, tx *gorm.DB, before time.Time, limit int) ([]entity.User, error) {
+	args := m.Called(ctx, tx, before, limit)
+	return args.Get(0).([]entity.User), args.Error(1)
+}
+
+func (m *mockTrashRepository) GetProductsTrashedBefore(ctx context.Context, tx *gorm.DB, before time.Time, limit int) ([]entity.Product, error) {
+	args := m.Called(ctx, tx, before, limit)
+	return args.Get(0).([]entity.Product), args.Error(1)
+}
+
+func (m *mockTrashRepository) GetCategoriesTrashedBefore(ctx context.Context, tx *gorm.DB, before time.Time, limit int) ([]entity.Category, error) {
+	args := m.Called(ctx, tx, before, limit)
+	return args.Get(0).([]entity.Category), args.Error(1)
+}
+
+type mockTrashQuery struct {
+	mock.Mock
+}
+
+func (m *mockTrashQuery) GetTrashedUsers(ctx context.Context, req dto.TrashGetsRequest) ([]entity.User, base.PaginationResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).([]entity.User), args.Get(1).(base.PaginationResponse), args.Error(2)
+}
+
+func (m *mockTrashQuery) GetTrashedProducts(ctx context.Context, req dto.TrashGetsRequest) ([]entity.Product, base.PaginationResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).([]entity.Product), args.Get(1).(base.PaginationResponse), args.Error(2)
+}
+
+func (m *mockTrashQuery) GetTrashedCategories(ctx context.Context, req dto.TrashGetsRequest) ([]entity.Category, base.PaginationResponse, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).([]entity.Category), args.Get(1).(base.PaginationResponse), args.Error(2)
+}
+
+// ============== Test Cases ==============
+
+func TestRestoreProduct_SKUTaken(t *testing.T) {
+	// Setup
+	mockProductRepo := new(mockProductRepository)
+	mockTrashRepo := new(mockTrashRepository)
+	trashService := service.NewTrashService(nil, mockProductRepo, new(mockCategoryRepository), mockTrashRepo,
+		new(mockTrashQuery), new(mockTxRepository), new(mockAuditLogRepository), new(mockProductRevisionRepository),
+		nil, nil)
+
+	ctx := context.Background()
+	productID := uuid.New()
+
+	// Expectations
+	mockTrashRepo.On("GetTrashedProductByID", ctx, (*gorm.DB)(nil), productID.String()).
+		Return(entity.Product{ID: productID, SKU: "TEST-SKU-001"}, nil)
+	mockProductRepo.On("GetProductByPrimaryKey", ctx, (*gorm.DB)(nil), "sku", "TEST-SKU-001").
+		Return(entity.Product{ID: uuid.New(), SKU: "TEST-SKU-001"}, nil)
+
+	// Execute
+	_, err := trashService.RestoreProduct(ctx, productID.String())
+
+	// Assert
+	assert.Equal(t, errs.ErrProductSKUExists, err)
+	mockTrashRepo.AssertNotCalled(t, "RestoreProductByID", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRestoreProduct_CategoryTrashed(t *testing.T) {
+	// Setup
+	mockProductRepo := new(mockProductRepository)
+	mockCategoryRepo := new(mockCategoryRepository)
+	mockTrashRepo := new(mockTrashRepository)
+	trashService := service.NewTrashService(nil, mockProductRepo, mockCategoryRepo, mockTrashRepo,
+		new(mockTrashQuery), new(mockTxRepository), new(mockAuditLogRepository), new(mockProductRevisionRepository),
+		nil, nil)
+
+	ctx := context.Background()
+	productID := uuid.New()
+	categoryID := uuid.New()
+
+	// Expectations
+	mockTrashRepo.On("GetTrashedProductByID", ctx, (*gorm.DB)(nil), productID.String()).
+		Return(entity.Product{ID: productID, SKU: "TEST-SKU-001", CategoryID: &categoryID}, nil)
+	mockProductRepo.On("GetProductByPrimaryKey", ctx, (*gorm.DB)(nil), "sku", "TEST-SKU-001").
+		Return(entity.Product{}, errs.ErrProductNotFound)
+	mockCategoryRepo.On("GetCategoryByID", ctx, (*gorm.DB)(nil), categoryID.String(), []string(nil)).
+		Return(entity.Category{}, errs.ErrCategoryNotFound)
+
+	// Execute
+	_, err := trashService.RestoreProduct(ctx, productID.String())
+
+	// Assert
+	assert.Equal(t, errs.ErrProductCategoryTrashed, err)
+}
+
+func TestRestoreProduct_Success(t *testing.T) {
+	// Setup
+	mockProductRepo := new(mockProductRepository)
+	mockTrashRepo := new(mockTrashRepository)
+	mockTxRepo := new(mockTxRepository)
+	mockAuditLogRepo := new(mockAuditLogRepository)
+	mockRevisionRepo := new(mockProductRevisionRepository)
+	trashService := service.NewTrashService(nil, mockProductRepo, new(mockCategoryRepository), mockTrashRepo,
+		new(mockTrashQuery), mockTxRepo, mockAuditLogRepo, mockRevisionRepo, nil, nil)
+
+	ctx := context.Background()
+	productID := uuid.New()
+	product := entity.Product{ID: productID, Name: "Test Product", SKU: "TEST-SKU-001", Stock: 5}
+
+	// Expectations
+	mockTrashRepo.On("GetTrashedProductByID", ctx, (*gorm.DB)(nil), productID.String()).Return(product, nil)
+	mockProductRepo.On("GetProductByPrimaryKey", ctx, (*gorm.DB)(nil), "sku", "TEST-SKU-001").
+		Return(entity.Product{}, errs.ErrProductNotFound)
+	mockTxRepo.On("BeginTx", ctx).Return(nil, nil)
+	mockTxRepo.On("CommitOrRollbackTx", ctx, (*gorm.DB)(nil), nil).Return()
+	mockTrashRepo.On("RestoreProductByID", ctx, (*gorm.DB)(nil), productID.String()).Return(nil)
+	mockProductRepo.On("GetProductByID", ctx, (*gorm.DB)(nil), productID.String(), []string{"Category"}).
+		Return(product, nil)
+	mockAuditLogRepo.On("CreateAuditLog", ctx, (*gorm.DB)(nil), mock.MatchedBy(func(log entity.AuditLog) bool {
+		return log.Action == "restore" && log.EntityType == "product" && log.EntityID == productID.String() &&
+			string(log.Changes["sku"].After) == `"TEST-SKU-001"` && log.Changes["sku"].Before == nil
+	})).Return(entity.AuditLog{}, nil)
+	mockRevisionRepo.On("CreateProductRevision", ctx, (*gorm.DB)(nil), mock.MatchedBy(func(rev entity.ProductRevision) bool {
+		return rev.ProductID == productID && rev.Action == "restore" && rev.Stock == 5
+	})).Return(entity.ProductRevision{}, nil)
+
+	// Execute
+	result, err := trashService.RestoreProduct(ctx, productID.String())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "TEST-SKU-001", result.SKU)
+	mockTrashRepo.AssertExpectations(t)
+	mockTxRepo.AssertExpectations(t)
+	mockAuditLogRepo.AssertExpectations(t)
+	mockRevisionRepo.AssertExpectations(t)
+}
+
+func TestRestoreCategory_NameTaken(t *testing.T) {
+	// Setup
+	mockCategoryRepo := new(mockCategoryRepository)
+	mockTrashRepo := new(mockTrashRepository)
+	trashService := service.NewTrashService(nil, new(mockProductRepository), mockCategoryRepo, mockTrashRepo,
+		new(mockTrashQuery), new(mockTxRepository), new(mockAuditLogRepository), new(mockProductRevisionRepository),
+		nil, nil)
+
+	ctx := context.Background()
+	categoryID := uuid.New()
+
+	// Expectations
+	mockTrashRepo.On("GetTrashedCategoryByID", ctx, (*gorm.DB)(nil), categoryID.String()).
+		Return(entity.Category{ID: categoryID, Name: "Books"}, nil)
+	mockCategoryRepo.On("GetCategoryByPrimaryKey", ctx, (*gorm.DB)(nil), "name", "Books").
+		Return(entity.Category{ID: uuid.New(), Name: "Books"}, nil)
+
+	// Execute
+	_, err := trashService.RestoreCategory(ctx, categoryID.String())
+
+	// Assert
+	assert.Equal(t, errs.ErrCategoryNameExists, err)
+}
+
+func TestRestoreUser_Anonymized(t *testing.T) {
+	// Setup
+	mockTrashRepo := new(mockTrashRepository)
+	trashService := service.NewTrashService(nil, new(mockProductRepository), new(mockCategoryRepository), mockTrashRepo,
+		new(mockTrashQuery), new(mockTxRepository), new(mockAuditLogRepository), new(mockProductRevisionRepository),
+		nil, nil)
+
+	ctx := context.Background()
+	userID := uuid.New()
+
+	// Expectations
+	mockTrashRepo.On("GetTrashedUserByID", ctx, (*gorm.DB)(nil), userID.String()).
+		Return(entity.User{ID: userID, Email: "deleted-" + userID.String() + "@deleted.invalid"}, nil)
+
+	// Execute
+	_, err := trashService.RestoreUser(ctx, userID.String())
+
+	// Assert
+	assert.Equal(t, errs.ErrUserAnonymized, err)
+}
+
+func TestPurgeProduct_Success(t *testing.T) {
+	// Setup
+	mockTrashRepo := new(mockTrashRepository)
+	mockTxRepo := new(mockTxRepository)
+	mockAuditLogRepo := new(mockAuditLogRepository)
+	trashService := service.NewTrashService(nil, new(mockProductRepository), new(mockCategoryRepository), mockTrashRepo,
+		new(mockTrashQuery), mockTxRepo, mockAuditLogRepo, new(mockProductRevisionRepository),
+		nil, nil)
+
+	ctx := context.Background()
+	productID := uuid.New()
+
+	// Expectations
+	mockTrashRepo.On("GetTrashedProductByID", ctx, (*gorm.DB)(nil), productID.String()).
+		Return(entity.Product{ID: productID, SKU: "TEST-SKU-001"}, nil)
+	mockTxRepo.On("BeginTx", ctx).Return(nil, nil)
+	mockTxRepo.On("CommitOrRollbackTx", ctx, (*gorm.DB)(nil), nil).Return()
+	mockTrashRepo.On("PurgeProductByID", ctx, (*gorm.DB)(nil), productID.String()).Return(nil)
+	mockAuditLogRepo.On("CreateAuditLog", ctx, (*gorm.DB)(nil), mock.MatchedBy(func(log entity.AuditLog) bool {
+		return log.Action == "purge" && log.EntityID == productID.String() &&
+			string(log.Changes["sku"].Before) == `"TEST-SKU-001"` && log.Changes["sku"].After == nil
+	})).Return(entity.AuditLog{}, nil)
+
+	// Execute
+	err := trashService.PurgeProduct(ctx, productID.String())
+
+	// Assert
+	assert.NoError(t, err)
+	mockTrashRepo.AssertExpectations(t)
+	mockAuditLogRepo.AssertExpectations(t)
+}
+
+func TestPurgeProduct_DeletesImageAfterCommit(t *testing.T) {
+	// Setup
+	tmpDir := t.TempDir()
+	t.Chdir(tmpDir)
+	mockTrashRepo := new(mockTrashRepository)
+	mockTxRepo := new(mockTxRepository)
+	mockAuditLogRepo := new(mockAuditLogRepository)
+	trashService := service.NewTrashService(nil, new(mockProductRepository), new(mockCategoryRepository), mockTrashRepo,
+		new(mockTrashQuery), mockTxRepo, mockAuditLogRepo, new(mockProductRevisionRepository),
+		storage.NewLocalStorage(constant.FileBasePath), nil)
+
+	ctx := context.Background()
+	imagePath := constant.EnumFileNamespaceProductImage + "/" + uuid.New().String()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "files", constant.EnumFileNamespaceProductImage), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "files", imagePath), []byte("hello"), 0644))
+	failing := entity.Product{ID: uuid.New(), Image: &imagePath}
+	purged := entity.Product{ID: uuid.New(), Image: &imagePath}
+	failure := errors.New("db down")
+
+	// Expectations
+	mockTrashRepo.On("GetTrashedProductByID", ctx, (*gorm.DB)(nil), failing.ID.String()).Return(failing, nil)
+	mockTrashRepo.On("GetTrashedProductByID", ctx, (*gorm.DB)(nil), purged.ID.String()).Return(purged, nil)
+	mockTxRepo.On("BeginTx", ctx).Return(nil, nil)
+	mockTxRepo.On("CommitOrRollbackTx", ctx, (*gorm.DB)(nil), mock.Anything).Return()
+	mockTrashRepo.On("PurgeProductByID", ctx, (*gorm.DB)(nil), failing.ID.String()).Return(failure)
+	mockTrashRepo.On("PurgeProductByID", ctx, (*gorm.DB)(nil), purged.ID.String()).Return(nil)
+	mockAuditLogRepo.On("CreateAuditLog", ctx, (*gorm.DB)(nil), mock.AnythingOfType("entity.AuditLog")).
+		Return(entity.AuditLog{}, nil)
+
+	// Execute & Assert: the row still points at the image, so it stays
+	err := trashService.PurgeProduct(ctx, failing.ID.String())
+	assert.ErrorIs(t, err, failure)
+	require.FileExists(t, filepath.Join(tmpDir, "files", imagePath))
+
+	err = trashService.PurgeProduct(ctx, purged.ID.String())
+	assert.NoError(t, err)
+	require.NoFileExists(t, filepath.Join(tmpDir, "files", imagePath))
+}
+
+func TestPurgeUser_RedactsPersonalData(t *testing.T) {
+	// Setup
+	mockTrashRepo := new(mockTrashRepository)
+	mockTxRepo := new(mockTxRepository)
+	mockAuditLogRepo := new(mockAuditLogRepository)
+	trashService := service.NewTrashService(nil, new(mockProductRepository), new(mockCategoryRepository), mockTrashRepo,
+		new(mockTrashQuery), mockTxRepo, mockAuditLogRepo, new(mockProductRevisionRepository),
+		nil, nil)
+
+	ctx := context.Background()
+	user := entity.User{ID: uuid.New(), Name: "Trish", Email: "trish@mail.test", Role: "user"}
+
+	// Expectations
+	mockTrashRepo.On("GetTrashedUserByID", ctx, (*gorm.DB)(nil), user.ID.String()).Return(user, nil)
+	mockTxRepo.On("BeginTx", ctx).Return(nil, nil)
+	mockTxRepo.On("CommitOrRollbackTx", ctx, (*gorm.DB)(nil), nil).Return()
+	mockTrashRepo.On("PurgeUserByID", ctx, (*gorm.DB)(nil), user.ID.String()).Return(nil)
+	mockAuditLogRepo.On("RedactAuditLogs", ctx, (*gorm.DB)(nil), "user", user.ID.String(),
+		[]string{"email", "name", "picture"}).Return(nil)
+	mockAuditLogRepo.On("CreateAuditLog", ctx, (*gorm.DB)(nil), mock.MatchedBy(func(log entity.AuditLog) bool {
+		return log.Action == "purge" && log.EntityID == user.ID.String() &&
+			string(log.Changes["email"].Before) == `"[redacted]"` &&
+			string(log.Changes["name"].Before) == `"[redacted]"` &&
+			string(log.Changes["role"].Before) == `"user"`
+	})).Return(entity.AuditLog{}, nil)
+
+	// Execute
+	err := trashService.PurgeUser(ctx, user.ID.String())
+
+	// Assert
+	assert.NoError(t, err)
+	mockTrashRepo.AssertExpectations(t)
+	mockAuditLogRepo.AssertExpectations(t)
+}
+
+func TestPurgeExpiredTrash_SkipsCategoriesWithProducts(t *testing.T) {
+	// Setup
+	mockTrashRepo := new(mockTrashRepository)
+	mockTxRepo := new(mockTxRepository)
+	mockAuditLogRepo := new(mockAuditLogRepository)
+	trashService := service.NewTrashService(nil, new(mockProductRepository), new(mockCategoryRepository), mockTrashRepo,
+		new(mockTrashQuery), mockTxRepo, mockAuditLogRepo, new(mockProductRevisionRepository),
+		nil, nil)
+
+	ctx := context.Background()
+	product := entity.Product{ID: uuid.New()}
+	emptyCategory := entity.Category{ID: uuid.New()}
+	usedCategory := entity.Category{ID: uuid.New()}
+	user := entity.User{ID: uuid.New()}
+
+	// Expectations
+	mockTrashRepo.On("GetProductsTrashedBefore", ctx, (*gorm.DB)(nil), mock.AnythingOfType("time.Time"), mock.Anything).
+		Return([]entity.Product{product}, nil)
+	mockTrashRepo.On("GetCategoriesTrashedBefore", ctx, (*gorm.DB)(nil), mock.AnythingOfType("time.Time"), mock.Anything).
+		Return([]entity.Category{emptyCategory, usedCategory}, nil)
+	mockTrashRepo.On("GetUsersTrashedBefore", ctx, (*gorm.DB)(nil), mock.AnythingOfType("time.Time"), mock.Anything).
+		Return([]entity.User{user}, nil)
+	mockTxRepo.On("BeginTx", ctx).Return(nil, nil)
+	mockTxRepo.On("CommitOrRollbackTx", ctx, (*gorm.DB)(nil), mock.Anything).Return()
+	mockTrashRepo.On("PurgeProductByID", ctx, (*gorm.DB)(nil), product.ID.String()).Return(nil)
+	mockTrashRepo.On("PurgeCategoryByID", ctx, (*gorm.DB)(nil), emptyCategory.ID.String()).Return(nil)
+	mockTrashRepo.On("PurgeCategoryByID", ctx, (*gorm.DB)(nil), usedCategory.ID.String()).
+		Return(errs.ErrCategoryHasProducts)
+	mockTrashRepo.On("PurgeUserByID", ctx, (*gorm.DB)(nil), user.ID.String()).Return(nil)
+	mockAuditLogRepo.On("RedactAuditLogs", ctx, (*gorm.DB)(nil), "user", user.ID.String(), mock.Anything).Return(nil)
+	mockAuditLogRepo.On("CreateAuditLog", ctx, (*gorm.DB)(nil), mock.MatchedBy(func(log entity.AuditLog) bool {
+		return log.Action == "purge" && log.ActorType == "system"
+	})).Return(entity.AuditLog{}, nil)
+
+	// Execute
+	purged, err := trashService.PurgeExpiredTrash(ctx)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 3, purged)
+	mockTrashRepo.AssertExpectations(t)
+	mockAuditLogRepo.AssertNumberOfCalls(t, "CreateAuditLog", 3)
+}
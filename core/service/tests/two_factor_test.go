@@ -0,0 +1,309 @@
+package service_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	"myapp/core/service"
+	"myapp/support/constant"
+	"myapp/support/util"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// --- Mock Repository ---
+
+type MockTOTPCredentialRepository struct {
+	mock.Mock
+}
+
+func (m *MockTOTPCredentialRepository) DB() *gorm.DB {
+	return nil
+}
+
+func (m *MockTOTPCredentialRepository) CreateTOTPCredential(ctx context.Context, tx *gorm.DB,
+	credential entity.TOTPCredential) (entity.TOTPCredential, error) {
+	args := m.Called(ctx, tx, credential)
+	return args.Get(0).(entity.TOTPCredential), args.Error(1)
+}
+
+func (m *MockTOTPCredentialRepository) GetTOTPCredentialByUserID(ctx context.Context, tx *gorm.DB,
+	userID string) (entity.TOTPCredential, error) {
+	args := m.Called(ctx, tx, userID)
+	return args.Get(0).(entity.TOTPCredential), args.Error(1)
+}
+
+func (m *MockTOTPCredentialRepository) ConfirmTOTPCredential(ctx context.Context, tx *gorm.DB,
+	id string, step int64) error {
+	args := m.Called(ctx, tx, id, step)
+	return args.Error(0)
+}
+
+func (m *MockTOTPCredentialRepository) UseTOTPStep(ctx context.Context, tx *gorm.DB, id string, step int64) error {
+	args := m.Called(ctx, tx, id, step)
+	return args.Error(0)
+}
+
+func (m *MockTOTPCredentialRepository) DeleteUserTOTPCredential(ctx context.Context, tx *gorm.DB,
+	userID string) error {
+	args := m.Called(ctx, tx, userID)
+	return args.Error(0)
+}
+
+type MockRecoveryCodeRepository struct {
+	mock.Mock
+}
+
+func (m *MockRecoveryCodeRepository) DB() *gorm.DB {
+	return nil
+}
+
+func (m *MockRecoveryCodeRepository) CreateRecoveryCodes(ctx context.Context, tx *gorm.DB,
+	codes []entity.RecoveryCode) error {
+	args := m.Called(ctx, tx, codes)
+	return args.Error(0)
+}
+
+func (m *MockRecoveryCodeRepository) UseRecoveryCode(ctx context.Context, tx *gorm.DB,
+	userID string, hash string) error {
+	args := m.Called(ctx, tx, userID, hash)
+	return args.Error(0)
+}
+
+func (m *MockRecoveryCodeRepository) DeleteUserRecoveryCodes(ctx context.Context, tx *gorm.DB,
+	userID string) error {
+	args := m.Called(ctx, tx, userID)
+	return args.Error(0)
+}
+
+type MockTwoFactorChallengeRepository struct {
+	mock.Mock
+}
+
+func (m *MockTwoFactorChallengeRepository) DB() *gorm.DB {
+	return nil
+}
+
+func (m *MockTwoFactorChallengeRepository) CreateTwoFactorChallenge(ctx context.Context, tx *gorm.DB,
+	challenge entity.TwoFactorChallenge) (entity.TwoFactorChallenge, error) {
+	args := m.Called(ctx, tx, challenge)
+	return args.Get(0).(entity.TwoFactorChallenge), args.Error(1)
+}
+
+func (m *MockTwoFactorChallengeRepository) GetTwoFactorChallengeByHash(ctx context.Context, tx *gorm.DB,
+	hash string) (entity.TwoFactorChallenge, error) {
+	args := m.Called(ctx, tx, hash)
+	return args.Get(0).(entity.TwoFactorChallenge), args.Error(1)
+}
+
+func (m *MockTwoFactorChallengeRepository) AttemptTwoFactorChallenge(ctx context.Context, tx *gorm.DB,
+	id string, maxAttempts int) error {
+	args := m.Called(ctx, tx, id, maxAttempts)
+	return args.Error(0)
+}
+
+func (m *MockTwoFactorChallengeRepository) UseTwoFactorChallenge(ctx context.Context, tx *gorm.DB,
+	id string) error {
+	args := m.Called(ctx, tx, id)
+	return args.Error(0)
+}
+
+// --- Test Helpers ---
+
+type twoFactorMocks struct {
+	user      *MockUserRepository
+	totp      *MockTOTPCredentialRepository
+	recovery  *MockRecoveryCodeRepository
+	challenge *MockTwoFactorChallengeRepository
+	tx        *MockTxRepository
+}
+
+func setupTwoFactorServiceMock() (service.TwoFactorService, *twoFactorMocks, context.Context) {
+	m := &twoFactorMocks{
+		user:      new(MockUserRepository),
+		totp:      new(MockTOTPCredentialRepository),
+		recovery:  new(MockRecoveryCodeRepository),
+		challenge: new(MockTwoFactorChallengeRepository),
+		tx:        new(MockTxRepository),
+	}
+	ts := service.NewTwoFactorService(m.user, m.totp, m.recovery, m.challenge, m.tx)
+	return ts, m, context.Background()
+}
+
+// expectTx lets one transaction run and records how it ended.
+func (m *twoFactorMocks) expectTx(ctx context.Context, err error) *gorm.DB {
+	tx := &gorm.DB{}
+	m.tx.On("BeginTx", ctx).Return(tx, nil)
+	m.tx.On("CommitOrRollbackTx", ctx, tx, err).Return()
+	return tx
+}
+
+const testTOTPSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ" // "12345678901234567890"
+
+// --- Tests ---
+
+// Test vectors of RFC 6238 appendix B (SHA-1), truncated to six digits
+func TestTOTP_RFC6238Vectors(t *testing.T) {
+	vectors := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range vectors {
+		code, err := util.TOTPCode(testTOTPSecret, util.TOTPStep(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		require.Equal(t, want, code, unix)
+	}
+
+	step, ok := util.MatchTOTP(testTOTPSecret, "287082", time.Unix(59+30, 0), 1)
+	require.True(t, ok)
+	require.Equal(t, int64(1), step)
+	_, ok = util.MatchTOTP(testTOTPSecret, "287082", time.Unix(59+90, 0), 1)
+	require.False(t, ok)
+}
+
+func TestTwoFactorService_EnrollTOTP(t *testing.T) {
+	ts, m, ctx := setupTwoFactorServiceMock()
+
+	user := entity.User{ID: uuid.New(), Email: "ann@mail.test"}
+	m.user.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrID, user.ID.String()).Return(user, nil)
+	m.totp.On("GetTOTPCredentialByUserID", ctx, (*gorm.DB)(nil), user.ID.String()).
+		Return(entity.TOTPCredential{}, errs.ErrTwoFactorNotEnrolled)
+	tx := m.expectTx(ctx, nil)
+	m.totp.On("DeleteUserTOTPCredential", ctx, tx, user.ID.String()).Return(nil)
+	m.totp.On("CreateTOTPCredential", ctx, tx, mock.MatchedBy(func(c entity.TOTPCredential) bool {
+		return c.UserID == user.ID && c.Secret != "" && c.ConfirmedAt == nil
+	})).Return(entity.TOTPCredential{}, nil)
+
+	resp, err := ts.EnrollTOTP(ctx, user.ID.String())
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.Secret)
+	require.True(t, strings.HasPrefix(resp.ProvisioningURI, "otpauth://totp/myapp:ann@mail.test?"))
+	require.Contains(t, resp.ProvisioningURI, "secret="+resp.Secret)
+}
+
+func TestTwoFactorService_EnrollTOTP_AlreadyEnabled(t *testing.T) {
+	ts, m, ctx := setupTwoFactorServiceMock()
+
+	now := time.Now()
+	user := entity.User{ID: uuid.New(), Email: "ann@mail.test"}
+	m.user.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), constant.DBAttrID, user.ID.String()).Return(user, nil)
+	m.totp.On("GetTOTPCredentialByUserID", ctx, (*gorm.DB)(nil), user.ID.String()).
+		Return(entity.TOTPCredential{UserID: user.ID, ConfirmedAt: &now}, nil)
+
+	_, err := ts.EnrollTOTP(ctx, user.ID.String())
+	require.ErrorIs(t, err, errs.ErrTwoFactorAlreadyEnabled)
+}
+
+func TestTwoFactorService_ConfirmTOTP(t *testing.T) {
+	ts, m, ctx := setupTwoFactorServiceMock()
+
+	credential := entity.TOTPCredential{ID: uuid.New(), UserID: uuid.New(), Secret: testTOTPSecret}
+	step := util.TOTPStep(time.Now())
+	code, err := util.TOTPCode(testTOTPSecret, step)
+	require.NoError(t, err)
+
+	m.totp.On("GetTOTPCredentialByUserID", ctx, (*gorm.DB)(nil), credential.UserID.String()).Return(credential, nil)
+	tx := m.expectTx(ctx, nil)
+	m.totp.On("ConfirmTOTPCredential", ctx, tx, credential.ID.String(), mock.AnythingOfType("int64")).Return(nil)
+	m.recovery.On("DeleteUserRecoveryCodes", ctx, tx, credential.UserID.String()).Return(nil)
+	m.recovery.On("CreateRecoveryCodes", ctx, tx, mock.Anything).Return(nil)
+
+	resp, err := ts.ConfirmTOTP(ctx, dto.UserTwoFactorCodeRequest{ID: credential.UserID.String(), Code: code})
+	require.NoError(t, err)
+	require.Len(t, resp.RecoveryCodes, constant.RecoveryCodeCount)
+
+	// Only hashes are stored, of the codes without the dash
+	stored := m.recovery.Calls[1].Arguments.Get(2).([]entity.RecoveryCode)
+	require.Len(t, stored, constant.RecoveryCodeCount)
+	require.Equal(t, util.HashToken(strings.ReplaceAll(resp.RecoveryCodes[0], "-", "")), stored[0].CodeHash)
+}
+
+func TestTwoFactorService_ConfirmTOTP_WrongCode(t *testing.T) {
+	ts, m, ctx := setupTwoFactorServiceMock()
+
+	credential := entity.TOTPCredential{ID: uuid.New(), UserID: uuid.New(), Secret: testTOTPSecret}
+	m.totp.On("GetTOTPCredentialByUserID", ctx, (*gorm.DB)(nil), credential.UserID.String()).Return(credential, nil)
+
+	_, err := ts.ConfirmTOTP(ctx, dto.UserTwoFactorCodeRequest{ID: credential.UserID.String(), Code: "12345"})
+	require.ErrorIs(t, err, errs.ErrTwoFactorCodeInvalid)
+	m.totp.AssertNotCalled(t, "ConfirmTOTPCredential", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTwoFactorService_VerifyChallenge_RecoveryCode(t *testing.T) {
+	ts, m, ctx := setupTwoFactorServiceMock()
+
+	now := time.Now()
+	challenge := entity.TwoFactorChallenge{ID: uuid.New(), UserID: uuid.New(), ExpiresAt: now.Add(time.Minute)}
+	credential := entity.TOTPCredential{ID: uuid.New(), UserID: challenge.UserID, Secret: testTOTPSecret,
+		ConfirmedAt: &now}
+
+	m.challenge.On("GetTwoFactorChallengeByHash", ctx, (*gorm.DB)(nil), util.HashToken("raw")).Return(challenge, nil)
+	m.challenge.On("AttemptTwoFactorChallenge", ctx, (*gorm.DB)(nil), challenge.ID.String(),
+		constant.DefaultTwoFactorMaxAttempts).Return(nil)
+	m.totp.On("GetTOTPCredentialByUserID", ctx, (*gorm.DB)(nil), challenge.UserID.String()).Return(credential, nil)
+	tx := m.expectTx(ctx, nil)
+	m.recovery.On("UseRecoveryCode", ctx, tx, challenge.UserID.String(), util.HashToken("abcdefghij")).Return(nil)
+	m.challenge.On("UseTwoFactorChallenge", ctx, tx, challenge.ID.String()).Return(nil)
+
+	userID, err := ts.VerifyChallenge(ctx, dto.UserLoginTwoFactorRequest{ChallengeToken: "raw", Code: "ABCDE-FGHIJ"})
+	require.NoError(t, err)
+	require.Equal(t, challenge.UserID.String(), userID)
+}
+
+func TestTwoFactorService_VerifyChallenge_WrongCode(t *testing.T) {
+	ts, m, ctx := setupTwoFactorServiceMock()
+
+	now := time.Now()
+	challenge := entity.TwoFactorChallenge{ID: uuid.New(), UserID: uuid.New(), ExpiresAt: now.Add(time.Minute)}
+	credential := entity.TOTPCredential{ID: uuid.New(), UserID: challenge.UserID, Secret: testTOTPSecret,
+		ConfirmedAt: &now}
+
+	m.challenge.On("GetTwoFactorChallengeByHash", ctx, (*gorm.DB)(nil), util.HashToken("raw")).Return(challenge, nil)
+	m.challenge.On("AttemptTwoFactorChallenge", ctx, (*gorm.DB)(nil), challenge.ID.String(),
+		constant.DefaultTwoFactorMaxAttempts).Return(nil)
+	m.totp.On("GetTOTPCredentialByUserID", ctx, (*gorm.DB)(nil), challenge.UserID.String()).Return(credential, nil)
+	tx := m.expectTx(ctx, errs.ErrTwoFactorCodeInvalid)
+	m.recovery.On("UseRecoveryCode", ctx, tx, challenge.UserID.String(), mock.Anything).
+		Return(errs.ErrTwoFactorCodeInvalid)
+
+	// The user is named so the failure can count against the account
+	userID, err := ts.VerifyChallenge(ctx, dto.UserLoginTwoFactorRequest{ChallengeToken: "raw", Code: "12345"})
+	require.ErrorIs(t, err, errs.ErrTwoFactorCodeInvalid)
+	require.Equal(t, challenge.UserID.String(), userID)
+	m.challenge.AssertNotCalled(t, "UseTwoFactorChallenge", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTwoFactorService_VerifyChallenge_AttemptsExhausted(t *testing.T) {
+	ts, m, ctx := setupTwoFactorServiceMock()
+
+	challenge := entity.TwoFactorChallenge{ID: uuid.New(), UserID: uuid.New(), ExpiresAt: time.Now().Add(time.Minute)}
+	m.challenge.On("GetTwoFactorChallengeByHash", ctx, (*gorm.DB)(nil), util.HashToken("raw")).Return(challenge, nil)
+	m.challenge.On("AttemptTwoFactorChallenge", ctx, (*gorm.DB)(nil), challenge.ID.String(),
+		constant.DefaultTwoFactorMaxAttempts).Return(errs.ErrTwoFactorChallengeInvalid)
+
+	_, err := ts.VerifyChallenge(ctx, dto.UserLoginTwoFactorRequest{ChallengeToken: "raw", Code: "123456"})
+	require.ErrorIs(t, err, errs.ErrTwoFactorChallengeInvalid)
+	m.tx.AssertNotCalled(t, "BeginTx", mock.Anything)
+}
+
+func TestTwoFactorService_CheckTwoFactorRequired(t *testing.T) {
+	t.Setenv("TWO_FACTOR_REQUIRE_ADMIN", "true")
+	ts, m, ctx := setupTwoFactorServiceMock()
+
+	adminID := uuid.NewString()
+	m.totp.On("GetTOTPCredentialByUserID", ctx, (*gorm.DB)(nil), adminID).
+		Return(entity.TOTPCredential{}, errs.ErrTwoFactorNotEnrolled)
+
+	require.ErrorIs(t, ts.CheckTwoFactorRequired(ctx, adminID, constant.EnumRoleAdmin), errs.ErrTwoFactorRequired)
+	require.NoError(t, ts.CheckTwoFactorRequired(ctx, uuid.NewString(), constant.EnumRoleUser))
+}
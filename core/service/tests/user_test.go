@@ -2,18 +2,26 @@ package service_test
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"myapp/core/entity"
 	"myapp/core/helper/dto"
 	errs "myapp/core/helper/errors"
 	"myapp/core/service"
+	"myapp/infrastructure/storage"
 	"myapp/support/base"
+	"myapp/support/constant"
 	"myapp/support/util"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
@@ -43,11 +51,36 @@ func (m *MockUserRepository) UpdateUser(ctx context.Context, tx *gorm.DB, user e
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) UpdateUserPasswordHash(ctx context.Context, tx *gorm.DB, id string, hash string) error {
+	args := m.Called(ctx, tx, id, hash)
+	return args.Error(0)
+}
+
 func (m *MockUserRepository) DeleteUserByID(ctx context.Context, tx *gorm.DB, id string) error {
 	args := m.Called(ctx, tx, id)
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) ScheduleUserDeletion(ctx context.Context, tx *gorm.DB, id string, at time.Time) error {
+	args := m.Called(ctx, tx, id, at)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) CancelUserDeletion(ctx context.Context, tx *gorm.DB, id string) error {
+	args := m.Called(ctx, tx, id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) GetUsersDueForDeletion(ctx context.Context, tx *gorm.DB, now time.Time, limit int) ([]entity.User, error) {
+	args := m.Called(ctx, tx, now, limit)
+	return args.Get(0).([]entity.User), args.Error(1)
+}
+
+func (m *MockUserRepository) PurgeUser(ctx context.Context, tx *gorm.DB, user entity.User) error {
+	args := m.Called(ctx, tx, user)
+	return args.Error(0)
+}
+
 // --- Mock Query ---
 
 type MockUserQuery struct {
@@ -89,15 +122,49 @@ func (m *MockTxRepository) CommitOrRollbackTx(ctx context.Context, tx *gorm.DB,
 	m.Called(ctx, tx, err)
 }
 
+// --- Mock AuditLogRepository ---
+
+type MockAuditLogRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditLogRepository) DB() *gorm.DB {
+	args := m.Called()
+	return args.Get(0).(*gorm.DB)
+}
+
+func (m *MockAuditLogRepository) CreateAuditLog(ctx context.Context, tx *gorm.DB, log entity.AuditLog) (entity.AuditLog, error) {
+	args := m.Called(ctx, tx, log)
+	return args.Get(0).(entity.AuditLog), args.Error(1)
+}
+
+func (m *MockAuditLogRepository) RedactAuditLogs(ctx context.Context, tx *gorm.DB, entityType string,
+	entityID string, fields []string) error {
+	args := m.Called(ctx, tx, entityType, entityID, fields)
+	return args.Error(0)
+}
+
 // --- Test Helpers ---
 
+// setupUserServiceMock runs transactions on a nil tx, so repository calls
+// made inside them match the same expectations as those made outside.
 func setupUserServiceMock() (service.UserService, *MockUserRepository, *MockUserQuery, context.Context) {
 	repo := new(MockUserRepository)
 	query := new(MockUserQuery)
+	roleRepo := new(MockRoleRepository)
 	tx := new(MockTxRepository)
-	us := service.NewUserService(repo, query, tx)
+	auditLogRepo := new(MockAuditLogRepository)
+	policy, _ := service.NewPasswordPolicy()
+	fileURLs, _ := service.NewFileURLService()
+	us := service.NewUserService(repo, query, roleRepo, tx, auditLogRepo,
+		storage.NewLocalStorage(constant.FileBasePath), fileURLs, policy)
 	ctx := context.Background()
 
+	tx.On("BeginTx", ctx).Return((*gorm.DB)(nil), nil).Maybe()
+	tx.On("CommitOrRollbackTx", ctx, (*gorm.DB)(nil), mock.Anything).Return().Maybe()
+	auditLogRepo.On("CreateAuditLog", ctx, (*gorm.DB)(nil), mock.AnythingOfType("entity.AuditLog")).
+		Return(entity.AuditLog{}, nil).Maybe()
+
 	return us, repo, query, ctx
 }
 
@@ -113,13 +180,27 @@ func TestUserService_CreateNewUser(t *testing.T) {
 	user, err := us.CreateNewUser(ctx, dto.UserRegisterRequest{
 		Name:     "A",
 		Email:    "a@mail.test",
-		Password: "secret",
+		Password: "correct-horse",
 	})
 	require.NoError(t, err)
 	require.Equal(t, "a@mail.test", user.Email)
 	repo.AssertExpectations(t)
 }
 
+func TestUserService_CreateNewUser_WeakPassword(t *testing.T) {
+	us, repo, _, ctx := setupUserServiceMock()
+
+	repo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), "email", "rosa@mail.test").Return(entity.User{}, errs.ErrUserNotFound)
+
+	_, err := us.CreateNewUser(ctx, dto.UserRegisterRequest{
+		Name:     "Rosa",
+		Email:    "rosa@mail.test",
+		Password: "rosa1234",
+	})
+	require.ErrorIs(t, err, errs.ErrPasswordPolicy)
+	repo.AssertNotCalled(t, "CreateNewUser", mock.Anything, mock.Anything, mock.Anything)
+}
+
 func TestUserService_VerifyLogin(t *testing.T) {
 	us, repo, _, ctx := setupUserServiceMock()
 
@@ -130,6 +211,31 @@ func TestUserService_VerifyLogin(t *testing.T) {
 	ok := us.VerifyLogin(ctx, "a@mail.test", "secret")
 	require.True(t, ok)
 	repo.AssertExpectations(t)
+	repo.AssertNotCalled(t, "UpdateUserPasswordHash", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestUserService_VerifyLogin_RehashesOutdatedHash(t *testing.T) {
+	us, repo, _, ctx := setupUserServiceMock()
+
+	// hashed before the policy moved from cheap bcrypt to argon2id
+	util.SetPasswordHashPolicy(util.PasswordHashPolicy{Algorithm: util.PasswordAlgorithmBcrypt, BcryptCost: bcrypt.MinCost})
+	hashed, _ := util.PasswordHash("secret")
+	util.SetPasswordHashPolicy(util.LoadPasswordHashPolicy())
+
+	stored := entity.User{ID: uuid.New(), Email: "a@mail.test", Password: hashed}
+	var rehashed string
+	repo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), "email", "a@mail.test").Return(stored, nil)
+	repo.On("UpdateUserPasswordHash", ctx, (*gorm.DB)(nil), stored.ID.String(), mock.AnythingOfType("string")).
+		Run(func(args mock.Arguments) { rehashed = args.String(3) }).
+		Return(nil)
+
+	require.True(t, us.VerifyLogin(ctx, "a@mail.test", "secret"))
+	require.True(t, strings.HasPrefix(rehashed, "$argon2id$"))
+	require.False(t, util.PasswordNeedsRehash(rehashed))
+
+	ok, err := util.PasswordCompare(rehashed, []byte("secret"))
+	require.NoError(t, err)
+	require.True(t, ok)
 }
 
 func TestUserService_GetAllUsers(t *testing.T) {
@@ -190,3 +296,91 @@ func TestUserService_DeleteUserByID(t *testing.T) {
 	require.NoError(t, err)
 	repo.AssertExpectations(t)
 }
+
+func TestUserService_ScheduleUserDeletion(t *testing.T) {
+	t.Setenv("ACCOUNT_DELETION_GRACE_PERIOD", "72h")
+	us, repo, _, ctx := setupUserServiceMock()
+
+	userID := uuid.New()
+	repo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), "id", userID.String()).Return(entity.User{ID: userID}, nil)
+	repo.On("ScheduleUserDeletion", ctx, (*gorm.DB)(nil), userID.String(), mock.AnythingOfType("time.Time")).Return(nil)
+
+	resp, err := us.ScheduleUserDeletion(ctx, userID.String())
+	require.NoError(t, err)
+	require.NotNil(t, resp.ScheduledDeletionAt)
+	require.WithinDuration(t, time.Now().Add(72*time.Hour), *resp.ScheduledDeletionAt, time.Minute)
+	repo.AssertExpectations(t)
+}
+
+func TestUserService_ScheduleUserDeletion_AlreadyScheduled(t *testing.T) {
+	us, repo, _, ctx := setupUserServiceMock()
+
+	userID := uuid.New()
+	repo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), "id", userID.String()).Return(entity.User{ID: userID}, nil)
+	repo.On("ScheduleUserDeletion", ctx, (*gorm.DB)(nil), userID.String(), mock.AnythingOfType("time.Time")).
+		Return(errs.ErrUserDeletionAlreadyScheduled)
+
+	_, err := us.ScheduleUserDeletion(ctx, userID.String())
+	require.ErrorIs(t, err, errs.ErrUserDeletionAlreadyScheduled)
+}
+
+func TestUserService_PurgeScheduledUsers(t *testing.T) {
+	tmpDir := setupTemporaryFileDir(t)
+	repo, txRepo, auditLogRepo := new(MockUserRepository), new(MockTxRepository), new(MockAuditLogRepository)
+	policy, _ := service.NewPasswordPolicy()
+	fileURLs, _ := service.NewFileURLService()
+	us := service.NewUserService(repo, new(MockUserQuery), new(MockRoleRepository), txRepo, auditLogRepo,
+		storage.NewLocalStorage(constant.FileBasePath), fileURLs, policy)
+	ctx := context.Background()
+
+	picPath := "user_picture/" + uuid.New().String()
+	missingPath := "user_picture/" + uuid.New().String()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "files", "user_picture"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "files", picPath), []byte("hello"), 0644))
+
+	keptPath := "user_picture/" + uuid.New().String()
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "files", keptPath), []byte("hello"), 0644))
+
+	withPicture := entity.User{ID: uuid.New(), Picture: &picPath}
+	withMissingPicture := entity.User{ID: uuid.New(), Picture: &missingPath}
+	cancelled := entity.User{ID: uuid.New(), Picture: &keptPath}
+	repo.On("GetUsersDueForDeletion", ctx, (*gorm.DB)(nil), mock.AnythingOfType("time.Time"), mock.AnythingOfType("int")).
+		Return([]entity.User{withPicture, withMissingPicture, cancelled}, nil)
+	tx := &gorm.DB{}
+	txRepo.On("BeginTx", ctx).Return(tx, nil)
+	txRepo.On("CommitOrRollbackTx", ctx, tx, mock.Anything).Return()
+	repo.On("PurgeUser", ctx, tx, withPicture).Return(nil)
+	repo.On("PurgeUser", ctx, tx, withMissingPicture).Return(nil)
+	repo.On("PurgeUser", ctx, tx, cancelled).Return(errs.ErrUserDeletionNotScheduled)
+	auditLogRepo.On("RedactAuditLogs", ctx, tx, constant.EnumAuditEntityUser, mock.Anything,
+		[]string{"email", "name", "picture"}).Return(nil)
+	auditLogRepo.On("CreateAuditLog", ctx, tx, mock.MatchedBy(func(log entity.AuditLog) bool {
+		return string(log.Changes["picture"].Before) == `"[redacted]"`
+	})).Return(entity.AuditLog{}, nil)
+
+	purged, err := us.PurgeScheduledUsers(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, purged)
+	require.NoFileExists(t, filepath.Join(tmpDir, "files", picPath))
+	require.FileExists(t, filepath.Join(tmpDir, "files", keptPath))
+	repo.AssertExpectations(t)
+	auditLogRepo.AssertNumberOfCalls(t, "CreateAuditLog", 2)
+}
+
+func TestUserService_ExportUserData(t *testing.T) {
+	tmpDir := setupTemporaryFileDir(t)
+	us, repo, _, ctx := setupUserServiceMock()
+
+	picPath := "user_picture/" + uuid.New().String()
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, "files", "user_picture"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, "files", picPath), []byte("hello"), 0644))
+
+	stored := entity.User{ID: uuid.New(), Name: "E", Email: "e@mail.test", Password: "hash", Picture: &picPath}
+	repo.On("GetUserByPrimaryKey", ctx, (*gorm.DB)(nil), "id", stored.ID.String()).Return(stored, nil)
+
+	export, err := us.ExportUserData(ctx, stored.ID.String())
+	require.NoError(t, err)
+	require.Equal(t, "e@mail.test", export.Profile.Email)
+	require.Equal(t, []byte("hello"), export.Picture.Data)
+	require.NotContains(t, fmt.Sprintf("%+v", export), "hash")
+}
@@ -0,0 +1,439 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	queryiface "myapp/core/interface/query"
+	repositoryiface "myapp/core/interface/repository"
+	storageiface "myapp/core/interface/storage"
+	"myapp/support/base"
+	"myapp/support/constant"
+	"myapp/support/logger"
+	"myapp/support/util"
+
+	"gorm.io/gorm"
+)
+
+type trashService struct {
+	userRepository     repositoryiface.UserRepository
+	productRepository  repositoryiface.ProductRepository
+	categoryRepository repositoryiface.CategoryRepository
+	trashRepository    repositoryiface.TrashRepository
+	trashQuery         queryiface.TrashQuery
+	txRepository       repositoryiface.TxRepository
+	auditLogRepository repositoryiface.AuditLogRepository
+	revisionRepository repositoryiface.ProductRevisionRepository
+	storage            storageiface.Storage
+	fileURLs           FileURLService
+	pictureUpload      uploadPolicy
+	imageUpload        uploadPolicy
+	retention          time.Duration
+}
+
+type TrashService interface {
+	GetTrashedUsers(ctx context.Context, req dto.TrashGetsRequest) ([]dto.TrashedUserResponse, base.PaginationResponse, error)
+	GetTrashedProducts(ctx context.Context, req dto.TrashGetsRequest) ([]dto.TrashedProductResponse, base.PaginationResponse, error)
+	GetTrashedCategories(ctx context.Context, req dto.TrashGetsRequest) ([]dto.TrashedCategoryResponse, base.PaginationResponse, error)
+	// Restoring fails with the "already exists" error of the entity when a
+	// live record took its email, SKU or name meanwhile.
+	RestoreUser(ctx context.Context, id string) (dto.UserResponse, error)
+	RestoreProduct(ctx context.Context, id string) (dto.ProductResponse, error)
+	RestoreCategory(ctx context.Context, id string) (dto.CategoryResponse, error)
+	// Purging deletes a trashed record for good, along with its files.
+	PurgeUser(ctx context.Context, id string) error
+	PurgeProduct(ctx context.Context, id string) error
+	PurgeCategory(ctx context.Context, id string) error
+	// PurgeExpiredTrash purges every record that has been in the trash for
+	// longer than the retention period. It returns how many were purged.
+	PurgeExpiredTrash(ctx context.Context) (int, error)
+}
+
+func NewTrashService(userR repositoryiface.UserRepository, productR repositoryiface.ProductRepository,
+	categoryR repositoryiface.CategoryRepository, trashR repositoryiface.TrashRepository,
+	trashQ queryiface.TrashQuery, txR repositoryiface.TxRepository,
+	auditLogR repositoryiface.AuditLogRepository, revisionR repositoryiface.ProductRevisionRepository,
+	storage storageiface.Storage, fileURLs FileURLService,
+) TrashService {
+	return &trashService{
+		userRepository:     userR,
+		productRepository:  productR,
+		categoryRepository: categoryR,
+		trashRepository:    trashR,
+		trashQuery:         trashQ,
+		txRepository:       txR,
+		auditLogRepository: auditLogR,
+		revisionRepository: revisionR,
+		storage:            storage,
+		fileURLs:           fileURLs,
+		pictureUpload:      newUserPictureUpload(),
+		imageUpload:        newProductImageUpload(),
+		retention: util.GetEnvDuration("TRASH_RETENTION_PERIOD",
+			constant.DefaultTrashRetentionPeriod),
+	}
+}
+
+func (sv *trashService) GetTrashedUsers(ctx context.Context, req dto.TrashGetsRequest) (
+	usersResp []dto.TrashedUserResponse, pageResp base.PaginationResponse, err error) {
+	users, pageResp, err := sv.trashQuery.GetTrashedUsers(ctx, req)
+	if err != nil {
+		return []dto.TrashedUserResponse{}, base.PaginationResponse{}, err
+	}
+
+	for _, user := range users {
+		usersResp = append(usersResp, dto.TrashedUserResponse{
+			UserResponse: toUserResponse(user, sv.fileURLs, sv.pictureUpload),
+			DeletedAt:    user.DeletedAt.Time,
+		})
+	}
+	return usersResp, pageResp, nil
+}
+
+func (sv *trashService) GetTrashedProducts(ctx context.Context, req dto.TrashGetsRequest) (
+	productsResp []dto.TrashedProductResponse, pageResp base.PaginationResponse, err error) {
+	products, pageResp, err := sv.trashQuery.GetTrashedProducts(ctx, req)
+	if err != nil {
+		return []dto.TrashedProductResponse{}, base.PaginationResponse{}, err
+	}
+
+	for _, product := range products {
+		productsResp = append(productsResp, dto.TrashedProductResponse{
+			ProductResponse: toProductResponse(product, sv.fileURLs, sv.imageUpload),
+			DeletedAt:       product.DeletedAt.Time,
+		})
+	}
+	return productsResp, pageResp, nil
+}
+
+func (sv *trashService) GetTrashedCategories(ctx context.Context, req dto.TrashGetsRequest) (
+	categoriesResp []dto.TrashedCategoryResponse, pageResp base.PaginationResponse, err error) {
+	categories, pageResp, err := sv.trashQuery.GetTrashedCategories(ctx, req)
+	if err != nil {
+		return []dto.TrashedCategoryResponse{}, base.PaginationResponse{}, err
+	}
+
+	for _, category := range categories {
+		categoriesResp = append(categoriesResp, dto.TrashedCategoryResponse{
+			CategoryResponse: toCategoryResponse(category),
+			DeletedAt:        category.DeletedAt.Time,
+		})
+	}
+	return categoriesResp, pageResp, nil
+}
+
+func (sv *trashService) RestoreUser(ctx context.Context, id string) (resp dto.UserResponse, err error) {
+	user, err := sv.trashRepository.GetTrashedUserByID(ctx, nil, id)
+	if err != nil {
+		return dto.UserResponse{}, err
+	}
+	// Purged accounts are kept anonymized, there is nothing left to restore
+	if strings.HasSuffix(user.Email, "@"+constant.AnonymizedEmailDomain) {
+		return dto.UserResponse{}, errs.ErrUserAnonymized
+	}
+
+	_, err = sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrEmail, user.Email)
+	if err == nil {
+		return dto.UserResponse{}, errs.ErrEmailAlreadyExists
+	}
+	if !errors.Is(err, errs.ErrUserNotFound) {
+		return dto.UserResponse{}, err
+	}
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return dto.UserResponse{}, err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.trashRepository.RestoreUserByID(ctx, tx, id); err != nil {
+		return dto.UserResponse{}, err
+	}
+
+	restored, err := sv.userRepository.GetUserByPrimaryKey(ctx, tx, constant.DBAttrID, id)
+	if err != nil {
+		return dto.UserResponse{}, err
+	}
+
+	err = recordAudit(ctx, tx, sv.auditLogRepository, constant.EnumAuditActionRestore,
+		constant.EnumAuditEntityUser, id, nil, restored)
+	if err != nil {
+		return dto.UserResponse{}, err
+	}
+
+	return toUserResponse(restored, sv.fileURLs, sv.pictureUpload), nil
+}
+
+func (sv *trashService) RestoreProduct(ctx context.Context, id string) (resp dto.ProductResponse, err error) {
+	product, err := sv.trashRepository.GetTrashedProductByID(ctx, nil, id)
+	if err != nil {
+		return dto.ProductResponse{}, err
+	}
+
+	_, err = sv.productRepository.GetProductByPrimaryKey(ctx, nil, constant.DBAttrSKU, product.SKU)
+	if err == nil {
+		return dto.ProductResponse{}, errs.ErrProductSKUExists
+	}
+	if !errors.Is(err, errs.ErrProductNotFound) {
+		return dto.ProductResponse{}, err
+	}
+
+	if product.CategoryID != nil {
+		_, err = sv.categoryRepository.GetCategoryByID(ctx, nil, product.CategoryID.String())
+		if errors.Is(err, errs.ErrCategoryNotFound) {
+			return dto.ProductResponse{}, errs.ErrProductCategoryTrashed
+		}
+		if err != nil {
+			return dto.ProductResponse{}, err
+		}
+	}
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return dto.ProductResponse{}, err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.trashRepository.RestoreProductByID(ctx, tx, id); err != nil {
+		return dto.ProductResponse{}, err
+	}
+
+	restored, err := sv.productRepository.GetProductByID(ctx, tx, id, "Category")
+	if err != nil {
+		return dto.ProductResponse{}, err
+	}
+
+	err = recordAudit(ctx, tx, sv.auditLogRepository, constant.EnumAuditActionRestore,
+		constant.EnumAuditEntityProduct, id, nil, &restored)
+	if err != nil {
+		return dto.ProductResponse{}, err
+	}
+
+	_, err = sv.revisionRepository.CreateProductRevision(ctx, tx,
+		newProductRevision(ctx, constant.EnumAuditActionRestore, restored))
+	if err != nil {
+		return dto.ProductResponse{}, err
+	}
+
+	return toProductResponse(restored, sv.fileURLs, sv.imageUpload), nil
+}
+
+func (sv *trashService) RestoreCategory(ctx context.Context, id string) (resp dto.CategoryResponse, err error) {
+	category, err := sv.trashRepository.GetTrashedCategoryByID(ctx, nil, id)
+	if err != nil {
+		return dto.CategoryResponse{}, err
+	}
+
+	_, err = sv.categoryRepository.GetCategoryByPrimaryKey(ctx, nil, constant.DBAttrName, category.Name)
+	if err == nil {
+		return dto.CategoryResponse{}, errs.ErrCategoryNameExists
+	}
+	if !errors.Is(err, errs.ErrCategoryNotFound) {
+		return dto.CategoryResponse{}, err
+	}
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return dto.CategoryResponse{}, err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.trashRepository.RestoreCategoryByID(ctx, tx, id); err != nil {
+		return dto.CategoryResponse{}, err
+	}
+
+	restored, err := sv.categoryRepository.GetCategoryByID(ctx, tx, id)
+	if err != nil {
+		return dto.CategoryResponse{}, err
+	}
+
+	err = recordAudit(ctx, tx, sv.auditLogRepository, constant.EnumAuditActionRestore,
+		constant.EnumAuditEntityCategory, id, nil, restored)
+	if err != nil {
+		return dto.CategoryResponse{}, err
+	}
+
+	return toCategoryResponse(restored), nil
+}
+
+func (sv *trashService) PurgeUser(ctx context.Context, id string) error {
+	user, err := sv.trashRepository.GetTrashedUserByID(ctx, nil, id)
+	if err != nil {
+		return err
+	}
+	return sv.purgeUser(ctx, user)
+}
+
+func (sv *trashService) PurgeProduct(ctx context.Context, id string) error {
+	product, err := sv.trashRepository.GetTrashedProductByID(ctx, nil, id)
+	if err != nil {
+		return err
+	}
+	return sv.purgeProduct(ctx, product)
+}
+
+func (sv *trashService) PurgeCategory(ctx context.Context, id string) error {
+	category, err := sv.trashRepository.GetTrashedCategoryByID(ctx, nil, id)
+	if err != nil {
+		return err
+	}
+	return sv.purgeCategory(ctx, category)
+}
+
+// purgeUser deletes the user row and everything hanging off it, then the
+// picture of the user. Files go last, once the purge is committed, so a
+// failed purge never leaves a row pointing at deleted files.
+func (sv *trashService) purgeUser(ctx context.Context, user entity.User) error {
+	if err := sv.purgeUserRow(ctx, user); err != nil {
+		return err
+	}
+
+	err := deleteStoredFile(ctx, sv.storage, user.Picture)
+	if err == nil {
+		err = deleteImageVariants(ctx, sv.storage, sv.pictureUpload, user.Picture)
+	}
+	if err != nil {
+		logger.Warn("Failed to delete picture of purged user %s: %v", user.ID, err)
+	}
+	return nil
+}
+
+func (sv *trashService) purgeUserRow(ctx context.Context, user entity.User) (err error) {
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.trashRepository.PurgeUserByID(ctx, tx, user.ID.String()); err != nil {
+		return err
+	}
+
+	return recordErasure(ctx, tx, sv.auditLogRepository, constant.EnumAuditActionPurge,
+		constant.EnumAuditEntityUser, user.ID.String(), user)
+}
+
+// purgeProduct deletes the product row, then its image once the purge is
+// committed.
+func (sv *trashService) purgeProduct(ctx context.Context, product entity.Product) error {
+	if err := sv.purgeProductRow(ctx, product); err != nil {
+		return err
+	}
+
+	err := deleteStoredFile(ctx, sv.storage, product.Image)
+	if err == nil {
+		err = deleteImageVariants(ctx, sv.storage, sv.imageUpload, product.Image)
+	}
+	if err != nil {
+		logger.Warn("Failed to delete image of purged product %s: %v", product.ID, err)
+	}
+	return nil
+}
+
+func (sv *trashService) purgeProductRow(ctx context.Context, product entity.Product) (err error) {
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.trashRepository.PurgeProductByID(ctx, tx, product.ID.String()); err != nil {
+		return err
+	}
+
+	return recordAudit(ctx, tx, sv.auditLogRepository, constant.EnumAuditActionPurge,
+		constant.EnumAuditEntityProduct, product.ID.String(), &product, nil)
+}
+
+func (sv *trashService) purgeCategory(ctx context.Context, category entity.Category) (err error) {
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.trashRepository.PurgeCategoryByID(ctx, tx, category.ID.String()); err != nil {
+		return err
+	}
+
+	return recordAudit(ctx, tx, sv.auditLogRepository, constant.EnumAuditActionPurge,
+		constant.EnumAuditEntityCategory, category.ID.String(), category, nil)
+}
+
+// PurgeExpiredTrash works through products before categories, so a category
+// whose products expired along with it can go in the same run.
+func (sv *trashService) PurgeExpiredTrash(ctx context.Context) (int, error) {
+	before := time.Now().Add(-sv.retention)
+
+	products, err := purgeTrashedBefore(ctx, before, sv.trashRepository.GetProductsTrashedBefore, sv.purgeProduct)
+	if err != nil {
+		return products, err
+	}
+
+	categories, err := purgeTrashedBefore(ctx, before, sv.trashRepository.GetCategoriesTrashedBefore,
+		func(ctx context.Context, category entity.Category) error {
+			err := sv.purgeCategory(ctx, category)
+			if errors.Is(err, errs.ErrCategoryHasProducts) {
+				// A product was moved into it meanwhile
+				return errSkipPurge
+			}
+			return err
+		})
+	if err != nil {
+		return products + categories, err
+	}
+
+	users, err := purgeTrashedBefore(ctx, before, sv.trashRepository.GetUsersTrashedBefore, sv.purgeUser)
+	return products + categories + users, err
+}
+
+// errSkipPurge tells purgeTrashedBefore to leave a record in the trash.
+var errSkipPurge = errors.New("skip purge")
+
+// purgeTrashedBefore purges the records listed by get in batches until none
+// are left. It stops at a batch in which nothing could be purged, so skipped
+// records are not fetched over and over.
+func purgeTrashedBefore[T any](ctx context.Context, before time.Time,
+	get func(ctx context.Context, tx *gorm.DB, before time.Time, limit int) ([]T, error),
+	purge func(ctx context.Context, record T) error) (int, error) {
+	purged := 0
+	for {
+		records, err := get(ctx, nil, before, constant.TrashPurgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		batchPurged := 0
+		for _, record := range records {
+			err := purge(ctx, record)
+			if errors.Is(err, errSkipPurge) {
+				continue
+			}
+			if err != nil {
+				return purged, err
+			}
+			batchPurged++
+		}
+		purged += batchPurged
+
+		if len(records) < constant.TrashPurgeBatchSize || batchPurged == 0 {
+			return purged, nil
+		}
+	}
+}
@@ -0,0 +1,370 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
+	repositoryiface "myapp/core/interface/repository"
+	"myapp/support/constant"
+	"myapp/support/util"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type twoFactorService struct {
+	userRepository         repositoryiface.UserRepository
+	totpRepository         repositoryiface.TOTPCredentialRepository
+	recoveryCodeRepository repositoryiface.RecoveryCodeRepository
+	challengeRepository    repositoryiface.TwoFactorChallengeRepository
+	txRepository           repositoryiface.TxRepository
+	issuer                 string
+	challengeTTL           time.Duration
+	maxAttempts            int
+	requireAdmin           bool
+}
+
+type TwoFactorService interface {
+	// EnrollTOTP starts an enrollment with a new secret, replacing any
+	// enrollment that was never confirmed.
+	EnrollTOTP(ctx context.Context, userID string) (dto.UserTOTPEnrollResponse, error)
+	// ConfirmTOTP turns two-factor login on once the user proves the app is
+	// set up, and returns the recovery codes. They are only shown this once.
+	ConfirmTOTP(ctx context.Context, req dto.UserTwoFactorCodeRequest) (dto.UserRecoveryCodesResponse, error)
+	DisableTOTP(ctx context.Context, req dto.UserTwoFactorCodeRequest) error
+	RegenerateRecoveryCodes(ctx context.Context, req dto.UserTwoFactorCodeRequest) (dto.UserRecoveryCodesResponse, error)
+	IsEnabled(ctx context.Context, userID string) (bool, error)
+	// CreateChallenge is called by logins of users with two-factor
+	// authentication on, instead of issuing tokens.
+	CreateChallenge(ctx context.Context, userID string) (dto.UserLoginChallengeResponse, error)
+	// VerifyChallenge checks the code sent with a challenge token and
+	// returns the ID of the user to issue tokens for. With a wrong code it
+	// returns ErrTwoFactorCodeInvalid along with the user ID, so the
+	// failure can count against the account.
+	VerifyChallenge(ctx context.Context, req dto.UserLoginTwoFactorRequest) (string, error)
+	// CheckTwoFactorRequired returns ErrTwoFactorRequired when the role must
+	// have two-factor authentication on but the user has not.
+	CheckTwoFactorRequired(ctx context.Context, userID string, role string) error
+}
+
+func NewTwoFactorService(userR repositoryiface.UserRepository, totpR repositoryiface.TOTPCredentialRepository,
+	recoveryCodeR repositoryiface.RecoveryCodeRepository, challengeR repositoryiface.TwoFactorChallengeRepository,
+	txR repositoryiface.TxRepository,
+) TwoFactorService {
+	return &twoFactorService{
+		userRepository:         userR,
+		totpRepository:         totpR,
+		recoveryCodeRepository: recoveryCodeR,
+		challengeRepository:    challengeR,
+		txRepository:           txR,
+		issuer:                 util.GetEnv("TOTP_ISSUER", constant.DefaultTOTPIssuer),
+		challengeTTL:           util.GetEnvDuration("TWO_FACTOR_CHALLENGE_TTL", constant.DefaultTwoFactorChallengeTTL),
+		maxAttempts:            util.GetEnvInt("TWO_FACTOR_MAX_ATTEMPTS", constant.DefaultTwoFactorMaxAttempts),
+		requireAdmin:           util.GetEnvBool("TWO_FACTOR_REQUIRE_ADMIN", false),
+	}
+}
+
+// ============== Helper Functions ==============
+
+// normalizeRecoveryCode accepts codes as shown (xxxxx-xxxxx) as well as
+// typed without the dash or in upper case.
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func generateRecoveryCodes(userID uuid.UUID) ([]string, []entity.RecoveryCode, error) {
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	codes := make([]string, 0, constant.RecoveryCodeCount)
+	records := make([]entity.RecoveryCode, 0, constant.RecoveryCodeCount)
+	for range constant.RecoveryCodeCount {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(enc.EncodeToString(b))[:10]
+
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		records = append(records, entity.RecoveryCode{
+			ID:       uuid.New(),
+			UserID:   userID,
+			CodeHash: util.HashToken(raw),
+		})
+	}
+	return codes, records, nil
+}
+
+// replaceRecoveryCodes discards the user's recovery codes and stores a new
+// set, returning the codes to show.
+func (sv *twoFactorService) replaceRecoveryCodes(ctx context.Context, tx *gorm.DB,
+	userID uuid.UUID) (dto.UserRecoveryCodesResponse, error) {
+	codes, records, err := generateRecoveryCodes(userID)
+	if err != nil {
+		return dto.UserRecoveryCodesResponse{}, err
+	}
+	if err := sv.recoveryCodeRepository.DeleteUserRecoveryCodes(ctx, tx, userID.String()); err != nil {
+		return dto.UserRecoveryCodesResponse{}, err
+	}
+	if err := sv.recoveryCodeRepository.CreateRecoveryCodes(ctx, tx, records); err != nil {
+		return dto.UserRecoveryCodesResponse{}, err
+	}
+	return dto.UserRecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// confirmedCredential returns the user's TOTP credential, treating an
+// unconfirmed enrollment as none.
+func (sv *twoFactorService) confirmedCredential(ctx context.Context, userID string) (entity.TOTPCredential, error) {
+	credential, err := sv.totpRepository.GetTOTPCredentialByUserID(ctx, nil, userID)
+	if err != nil {
+		return entity.TOTPCredential{}, err
+	}
+	if credential.ConfirmedAt == nil {
+		return entity.TOTPCredential{}, errs.ErrTwoFactorNotEnrolled
+	}
+	return credential, nil
+}
+
+// verifyCode accepts either a current authenticator code or an unused
+// recovery code, and consumes it.
+func (sv *twoFactorService) verifyCode(ctx context.Context, tx *gorm.DB,
+	credential entity.TOTPCredential, code string) error {
+	if step, ok := util.MatchTOTP(credential.Secret, code, time.Now(), 1); ok {
+		return sv.totpRepository.UseTOTPStep(ctx, tx, credential.ID.String(), step)
+	}
+	return sv.recoveryCodeRepository.UseRecoveryCode(ctx, tx, credential.UserID.String(),
+		util.HashToken(normalizeRecoveryCode(code)))
+}
+
+// ============== Enrollment ==============
+
+func (sv *twoFactorService) EnrollTOTP(ctx context.Context, userID string) (resp dto.UserTOTPEnrollResponse, err error) {
+	user, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrID, userID)
+	if err != nil {
+		return dto.UserTOTPEnrollResponse{}, err
+	}
+
+	current, err := sv.totpRepository.GetTOTPCredentialByUserID(ctx, nil, userID)
+	if err != nil && !errors.Is(err, errs.ErrTwoFactorNotEnrolled) {
+		return dto.UserTOTPEnrollResponse{}, err
+	}
+	if err == nil && current.ConfirmedAt != nil {
+		return dto.UserTOTPEnrollResponse{}, errs.ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := util.GenerateTOTPSecret()
+	if err != nil {
+		return dto.UserTOTPEnrollResponse{}, err
+	}
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return dto.UserTOTPEnrollResponse{}, err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.totpRepository.DeleteUserTOTPCredential(ctx, tx, userID); err != nil {
+		return dto.UserTOTPEnrollResponse{}, err
+	}
+	_, err = sv.totpRepository.CreateTOTPCredential(ctx, tx, entity.TOTPCredential{
+		ID:     uuid.New(),
+		UserID: user.ID,
+		Secret: secret,
+	})
+	if err != nil {
+		return dto.UserTOTPEnrollResponse{}, err
+	}
+
+	return dto.UserTOTPEnrollResponse{
+		Secret:          secret,
+		ProvisioningURI: util.TOTPProvisioningURI(sv.issuer, user.Email, secret),
+	}, nil
+}
+
+func (sv *twoFactorService) ConfirmTOTP(ctx context.Context,
+	req dto.UserTwoFactorCodeRequest) (resp dto.UserRecoveryCodesResponse, err error) {
+	credential, err := sv.totpRepository.GetTOTPCredentialByUserID(ctx, nil, req.ID)
+	if err != nil {
+		return dto.UserRecoveryCodesResponse{}, err
+	}
+	if credential.ConfirmedAt != nil {
+		return dto.UserRecoveryCodesResponse{}, errs.ErrTwoFactorAlreadyEnabled
+	}
+
+	// Only an app code proves the enrollment, there are no recovery codes yet
+	step, ok := util.MatchTOTP(credential.Secret, req.Code, time.Now(), 1)
+	if !ok {
+		return dto.UserRecoveryCodesResponse{}, errs.ErrTwoFactorCodeInvalid
+	}
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return dto.UserRecoveryCodesResponse{}, err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.totpRepository.ConfirmTOTPCredential(ctx, tx, credential.ID.String(), step); err != nil {
+		return dto.UserRecoveryCodesResponse{}, err
+	}
+	resp, err = sv.replaceRecoveryCodes(ctx, tx, credential.UserID)
+	return resp, err
+}
+
+func (sv *twoFactorService) DisableTOTP(ctx context.Context, req dto.UserTwoFactorCodeRequest) (err error) {
+	credential, err := sv.confirmedCredential(ctx, req.ID)
+	if err != nil {
+		return err
+	}
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.verifyCode(ctx, tx, credential, req.Code); err != nil {
+		return err
+	}
+	if err = sv.totpRepository.DeleteUserTOTPCredential(ctx, tx, req.ID); err != nil {
+		return err
+	}
+	err = sv.recoveryCodeRepository.DeleteUserRecoveryCodes(ctx, tx, req.ID)
+	return err
+}
+
+func (sv *twoFactorService) RegenerateRecoveryCodes(ctx context.Context,
+	req dto.UserTwoFactorCodeRequest) (resp dto.UserRecoveryCodesResponse, err error) {
+	credential, err := sv.confirmedCredential(ctx, req.ID)
+	if err != nil {
+		return dto.UserRecoveryCodesResponse{}, err
+	}
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return dto.UserRecoveryCodesResponse{}, err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.verifyCode(ctx, tx, credential, req.Code); err != nil {
+		return dto.UserRecoveryCodesResponse{}, err
+	}
+	resp, err = sv.replaceRecoveryCodes(ctx, tx, credential.UserID)
+	return resp, err
+}
+
+func (sv *twoFactorService) IsEnabled(ctx context.Context, userID string) (bool, error) {
+	_, err := sv.confirmedCredential(ctx, userID)
+	if errors.Is(err, errs.ErrTwoFactorNotEnrolled) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ============== Login Challenge ==============
+
+func (sv *twoFactorService) CreateChallenge(ctx context.Context, userID string) (dto.UserLoginChallengeResponse, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return dto.UserLoginChallengeResponse{}, err
+	}
+
+	raw, err := util.GenerateRandomToken(constant.TwoFactorChallengeBytes)
+	if err != nil {
+		return dto.UserLoginChallengeResponse{}, err
+	}
+
+	_, err = sv.challengeRepository.CreateTwoFactorChallenge(ctx, nil, entity.TwoFactorChallenge{
+		ID:        uuid.New(),
+		UserID:    uid,
+		TokenHash: util.HashToken(raw),
+		ExpiresAt: time.Now().Add(sv.challengeTTL),
+	})
+	if err != nil {
+		return dto.UserLoginChallengeResponse{}, err
+	}
+
+	return dto.UserLoginChallengeResponse{
+		TwoFactorRequired: true,
+		ChallengeToken:    raw,
+		ExpiresIn:         int64(sv.challengeTTL.Seconds()),
+	}, nil
+}
+
+func (sv *twoFactorService) VerifyChallenge(ctx context.Context,
+	req dto.UserLoginTwoFactorRequest) (userID string, err error) {
+	challenge, err := sv.challengeRepository.GetTwoFactorChallengeByHash(ctx, nil, util.HashToken(req.ChallengeToken))
+	if err != nil {
+		return "", err
+	}
+
+	if challenge.UsedAt != nil {
+		return "", errs.ErrTwoFactorChallengeInvalid
+	}
+
+	if time.Now().After(challenge.ExpiresAt) {
+		return "", errs.ErrTwoFactorChallengeExpired
+	}
+
+	// Counted outside the transaction below so failed attempts stick
+	err = sv.challengeRepository.AttemptTwoFactorChallenge(ctx, nil, challenge.ID.String(), sv.maxAttempts)
+	if err != nil {
+		return "", err
+	}
+
+	credential, err := sv.confirmedCredential(ctx, challenge.UserID.String())
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.verifyCode(ctx, tx, credential, req.Code); err != nil {
+		if errors.Is(err, errs.ErrTwoFactorCodeInvalid) {
+			return challenge.UserID.String(), err
+		}
+		return "", err
+	}
+	if err = sv.challengeRepository.UseTwoFactorChallenge(ctx, tx, challenge.ID.String()); err != nil {
+		return "", err
+	}
+	return challenge.UserID.String(), nil
+}
+
+func (sv *twoFactorService) CheckTwoFactorRequired(ctx context.Context, userID string, role string) error {
+	if !sv.requireAdmin || role != constant.EnumRoleAdmin {
+		return nil
+	}
+
+	enabled, err := sv.IsEnabled(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		return errs.ErrTwoFactorRequired
+	}
+	return nil
+}
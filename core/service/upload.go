@@ -0,0 +1,343 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	errs "myapp/core/helper/errors"
+	storageiface "myapp/core/interface/storage"
+	"myapp/support/base"
+	"myapp/support/constant"
+	"myapp/support/logger"
+	"myapp/support/util"
+)
+
+// sniffLength is how much of a file http.DetectContentType looks at.
+const sniffLength = 512
+
+// imageOriginal names the uploaded image itself among its variants.
+const imageOriginal = "original"
+
+var imageVariantName = regexp.MustCompile(`^[a-z0-9]+$`)
+
+var imageVariantTypes = map[string]string{
+	"jpeg": "image/jpeg",
+	"png":  "image/png",
+	"webp": "image/webp",
+}
+
+// uploadPolicy limits what may be uploaded for one purpose, like user
+// pictures. The type is detected from the content, not the header the
+// client sent.
+type uploadPolicy struct {
+	field        string
+	maxBytes     int64
+	allowedTypes []string
+	minDimension int
+	maxDimension int
+	variants     []imageVariant
+}
+
+// imageVariant is a resized copy made of every uploaded image, stored
+// under "<key>_<name>". A maxSize of 0 keeps the size.
+type imageVariant struct {
+	name    string
+	maxSize int
+	format  string
+}
+
+func newUserPictureUpload() uploadPolicy {
+	return newImageUploadPolicy("picture", "USER_PICTURE", constant.DefaultUserPictureMaxBytes,
+		constant.DefaultUserPictureMinDimension, constant.DefaultUserPictureMaxDimension,
+		constant.DefaultUserPictureVariants)
+}
+
+func newProductImageUpload() uploadPolicy {
+	return newImageUploadPolicy("image", "PRODUCT_IMAGE", constant.DefaultProductImageMaxBytes,
+		constant.DefaultProductImageMinDimension, constant.DefaultProductImageMaxDimension,
+		constant.DefaultProductImageVariants)
+}
+
+// newImageUploadPolicy reads the policy for images uploaded in field from
+// the environment variables starting with prefix.
+func newImageUploadPolicy(field string, prefix string, maxBytes int64, minDimension int,
+	maxDimension int, variants string) uploadPolicy {
+	allowedTypes := []string{}
+	for _, t := range strings.Split(util.GetEnv(prefix+"_ALLOWED_TYPES", constant.DefaultImageUploadTypes), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			allowedTypes = append(allowedTypes, strings.ToLower(t))
+		}
+	}
+
+	return uploadPolicy{
+		field:        field,
+		maxBytes:     int64(util.GetEnvInt(prefix+"_MAX_BYTES", int(maxBytes))),
+		allowedTypes: allowedTypes,
+		minDimension: util.GetEnvInt(prefix+"_MIN_DIMENSION", minDimension),
+		maxDimension: util.GetEnvInt(prefix+"_MAX_DIMENSION", maxDimension),
+		variants:     parseImageVariants(prefix+"_VARIANTS", util.GetEnv(prefix+"_VARIANTS", variants)),
+	}
+}
+
+// parseImageVariants reads a comma separated list of name:size:format
+// entries, like "thumbnail:128:jpeg". Invalid entries are skipped.
+func parseImageVariants(key string, value string) []imageVariant {
+	variants := []imageVariant{}
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			logger.Warn("Invalid image variant in %s (%q), skipping it", key, entry)
+			continue
+		}
+		maxSize, err := strconv.Atoi(parts[1])
+		_, knownFormat := imageVariantTypes[parts[2]]
+		if !imageVariantName.MatchString(parts[0]) || parts[0] == imageOriginal || err != nil || maxSize < 0 ||
+			!knownFormat {
+			logger.Warn("Invalid image variant in %s (%q), skipping it", key, entry)
+			continue
+		}
+		variants = append(variants, imageVariant{name: parts[0], maxSize: maxSize, format: parts[2]})
+	}
+	return variants
+}
+
+// requestLimit is the largest request body worth reading for an upload.
+func (p uploadPolicy) requestLimit() int64 {
+	return p.maxBytes + constant.UploadFormOverheadBytes
+}
+
+// check returns an UploadPolicyError listing the rules src breaks, and the
+// detected content type and the decoded image otherwise. Only an image that
+// decodes in full passes, as its header alone says nothing about the rest.
+// It leaves src at the start.
+func (p uploadPolicy) check(src io.ReadSeeker, size int64) (string, image.Image, error) {
+	if size > p.maxBytes {
+		// Nothing else is worth reading of a file this size
+		return "", nil, p.violation("file_max_size", formatBytes(p.maxBytes))
+	}
+
+	head := make([]byte, sniffLength)
+	n, err := io.ReadFull(src, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return "", nil, err
+	}
+	contentType, _, _ := strings.Cut(http.DetectContentType(head[:n]), ";")
+	if !slices.Contains(p.allowedTypes, contentType) {
+		return "", nil, p.violation("file_types", strings.Join(p.allowedTypes, ", "))
+	}
+
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return "", nil, err
+	}
+	width, height, err := util.ImageDimensions(src, contentType)
+	if err != nil {
+		return "", nil, p.violation("image", "")
+	}
+
+	var violations []string
+	if width < p.minDimension || height < p.minDimension {
+		violations = append(violations, base.FormatFieldErrorMessage(p.field, "image_min_size",
+			fmt.Sprintf("%dx%d", p.minDimension, p.minDimension)))
+	}
+	if width > p.maxDimension || height > p.maxDimension {
+		violations = append(violations, base.FormatFieldErrorMessage(p.field, "image_max_size",
+			fmt.Sprintf("%dx%d", p.maxDimension, p.maxDimension)))
+	}
+	if len(violations) > 0 {
+		return "", nil, &errs.UploadPolicyError{Violations: violations}
+	}
+
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return "", nil, err
+	}
+	img, _, err := image.Decode(src)
+	if err != nil {
+		return "", nil, p.violation("image", "")
+	}
+
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return "", nil, err
+	}
+	return contentType, img, nil
+}
+
+func (p uploadPolicy) violation(tag string, param string) error {
+	return &errs.UploadPolicyError{
+		Violations: []string{base.FormatFieldErrorMessage(p.field, tag, param)},
+	}
+}
+
+// urls returns the URLs of the image under key and its variants, keyed by
+// variant name with the image itself as "original". It is nil without an
+// image.
+func (p uploadPolicy) urls(fileURLs FileURLService, key string) map[string]string {
+	if key == "" {
+		return nil
+	}
+
+	urls := map[string]string{imageOriginal: fileURLs.URL(key)}
+	for _, variant := range p.variants {
+		urls[variant.name] = fileURLs.URL(imageVariantKey(key, variant.name))
+	}
+	return urls
+}
+
+func imageVariantKey(key string, name string) string {
+	return key + "_" + name
+}
+
+// uploadFile checks an uploaded form file against policy and streams it
+// into storage under key without its metadata, then stores its variants.
+func uploadFile(ctx context.Context, storage storageiface.Storage, policy uploadPolicy,
+	file *multipart.FileHeader, key string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	contentType, img, err := policy.check(src, file.Size)
+	if err != nil {
+		return err
+	}
+
+	if err := putStrippedImage(ctx, storage, src, contentType, key); err != nil {
+		return err
+	}
+	if err := putImageVariants(ctx, storage, policy, src, img, contentType, key); err != nil {
+		_ = deleteStoredFile(ctx, storage, &key)
+		_ = deleteImageVariants(ctx, storage, policy, &key)
+		return err
+	}
+	return nil
+}
+
+// putStrippedImage streams src into storage without its metadata. That
+// changes the size, which storage needs upfront, so a first pass counts it.
+func putStrippedImage(ctx context.Context, storage storageiface.Storage, src io.ReadSeeker,
+	contentType string, key string) error {
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	size, err := util.StripImageMetadata(io.Discard, src, contentType)
+	if err != nil {
+		return err
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := util.StripImageMetadata(pw, src, contentType)
+		pw.CloseWithError(err)
+	}()
+
+	err = storage.Put(ctx, key, pr, size, contentType)
+	// Unblocks the copy when storage stopped reading early
+	pr.CloseWithError(io.ErrClosedPipe)
+	<-done
+	return err
+}
+
+// putImageVariants stores the variants of img, decoded from src, turned
+// upright first as the stripped EXIF orientation no longer does it.
+func putImageVariants(ctx context.Context, storage storageiface.Storage, policy uploadPolicy,
+	src io.ReadSeeker, img image.Image, contentType string, key string) error {
+	if len(policy.variants) == 0 {
+		return nil
+	}
+
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	oriented := util.OrientImage(img, util.ImageOrientation(src, contentType))
+
+	for _, variant := range policy.variants {
+		var buf bytes.Buffer
+		if err := encodeImageVariant(&buf, util.ResizeImage(oriented, variant.maxSize), variant.format); err != nil {
+			return err
+		}
+		err := storage.Put(ctx, imageVariantKey(key, variant.name), &buf, int64(buf.Len()),
+			imageVariantTypes[variant.format])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encodeImageVariant(w io.Writer, img *image.RGBA, format string) error {
+	switch format {
+	case "jpeg":
+		// JPEG has no transparency, so the image is laid over white
+		flat := image.NewRGBA(img.Rect)
+		draw.Draw(flat, flat.Rect, image.White, image.Point{}, draw.Src)
+		draw.Draw(flat, flat.Rect, img, image.Point{}, draw.Over)
+		return jpeg.Encode(w, flat, &jpeg.Options{Quality: constant.ImageVariantJPEGQuality})
+	case "png":
+		return png.Encode(w, img)
+	default:
+		return util.EncodeWebP(w, img)
+	}
+}
+
+// deleteImageVariants removes the variants of the image under key. Any
+// that are gone already, like for images uploaded before a variant was
+// configured, are fine.
+func deleteImageVariants(ctx context.Context, storage storageiface.Storage, policy uploadPolicy,
+	key *string) error {
+	if key == nil || *key == "" {
+		return nil
+	}
+	for _, variant := range policy.variants {
+		variantKey := imageVariantKey(*key, variant.name)
+		if err := deleteStoredFile(ctx, storage, &variantKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteStoredFile removes an uploaded file, if any. A file that is already
+// gone is fine.
+func deleteStoredFile(ctx context.Context, storage storageiface.Storage, key *string) error {
+	if key == nil || *key == "" {
+		return nil
+	}
+	if err := storage.Delete(ctx, *key); err != nil && !errors.Is(err, errs.ErrFileNotFound) {
+		return err
+	}
+	return nil
+}
+
+// formatBytes writes a size limit the way people configure it, like 5 MiB.
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20 && n%(1<<20) == 0:
+		return fmt.Sprintf("%d MiB", n>>20)
+	case n >= 1<<10 && n%(1<<10) == 0:
+		return fmt.Sprintf("%d KiB", n>>10)
+	default:
+		return fmt.Sprintf("%d bytes", n)
+	}
+}
@@ -2,7 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"reflect"
 	"time"
 
@@ -11,17 +13,27 @@ import (
 	errs "myapp/core/helper/errors"
 	queryiface "myapp/core/interface/query"
 	repositoryiface "myapp/core/interface/repository"
+	storageiface "myapp/core/interface/storage"
 	"myapp/support/base"
 	"myapp/support/constant"
+	"myapp/support/logger"
 	"myapp/support/util"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type userService struct {
-	userRepository repositoryiface.UserRepository
-	userQuery      queryiface.UserQuery
-	txRepository   repositoryiface.TxRepository
+	userRepository     repositoryiface.UserRepository
+	userQuery          queryiface.UserQuery
+	roleRepository     repositoryiface.RoleRepository
+	txRepository       repositoryiface.TxRepository
+	auditLogRepository repositoryiface.AuditLogRepository
+	storage            storageiface.Storage
+	fileURLs           FileURLService
+	passwordPolicy     PasswordPolicy
+	deletionGrace      time.Duration
+	pictureUpload      uploadPolicy
 }
 
 type UserService interface {
@@ -33,7 +45,20 @@ type UserService interface {
 	UpdateUserByID(ctx context.Context, req dto.UserUpdateRequest) (dto.UserResponse, error)
 	DeleteUserByID(ctx context.Context, id string) error
 	ChangePicture(ctx context.Context, req dto.UserChangePictureRequest) (dto.UserResponse, error)
+	// PictureRequestLimit is the largest request body a picture upload may
+	// have.
+	PictureRequestLimit() int64
 	DeletePicture(ctx context.Context, userID string) error
+	// ScheduleUserDeletion marks the account for deletion once the grace
+	// period is over. Until then the user can log in and cancel it.
+	ScheduleUserDeletion(ctx context.Context, id string) (dto.UserResponse, error)
+	CancelUserDeletion(ctx context.Context, id string) error
+	// PurgeScheduledUsers anonymizes every account whose grace period is
+	// over and removes their files. It returns how many were purged.
+	PurgeScheduledUsers(ctx context.Context) (int, error)
+	// ExportUserData collects the stored profile and uploaded files of a
+	// user. Sessions and two-factor state are filled in by their services.
+	ExportUserData(ctx context.Context, id string) (dto.UserExportResponse, error)
 	// RunUserMaintenance demonstrates a complex, highly-customizable operation
 	// that can apply multiple business rules and database changes in one
 	// transactional flow.
@@ -41,15 +66,66 @@ type UserService interface {
 }
 
 func NewUserService(userR repositoryiface.UserRepository, userQ queryiface.UserQuery,
-	txR repositoryiface.TxRepository,
+	roleR repositoryiface.RoleRepository, txR repositoryiface.TxRepository,
+	auditLogR repositoryiface.AuditLogRepository, storage storageiface.Storage, fileURLs FileURLService,
+	passwordPolicy PasswordPolicy,
 ) UserService {
 	return &userService{
-		userRepository: userR,
-		userQuery:      userQ,
-		txRepository:   txR,
+		userRepository:     userR,
+		userQuery:          userQ,
+		roleRepository:     roleR,
+		txRepository:       txR,
+		auditLogRepository: auditLogR,
+		storage:            storage,
+		fileURLs:           fileURLs,
+		passwordPolicy:     passwordPolicy,
+		deletionGrace: util.GetEnvDuration("ACCOUNT_DELETION_GRACE_PERIOD",
+			constant.DefaultAccountDeletionGracePeriod),
+		pictureUpload: newUserPictureUpload(),
 	}
 }
 
+// updateAudited applies userEdit and records the change in the same
+// transaction. before is the user as read ahead of the update.
+func (sv *userService) updateAudited(ctx context.Context, tx *gorm.DB, before entity.User,
+	userEdit entity.User) error {
+	if err := sv.userRepository.UpdateUser(ctx, tx, userEdit); err != nil {
+		return err
+	}
+
+	after, err := sv.userRepository.GetUserByPrimaryKey(ctx, tx, constant.DBAttrID, before.ID.String())
+	if err != nil {
+		return err
+	}
+
+	return recordAudit(ctx, tx, sv.auditLogRepository, constant.EnumAuditActionUpdate,
+		constant.EnumAuditEntityUser, before.ID.String(), before, after)
+}
+
+// actorRole loads the role of the caller of a role change. API keys have no
+// role of their own, so they cannot change roles.
+func (sv *userService) actorRole(ctx context.Context, roleName string) (entity.Role, error) {
+	if roleName == constant.EnumRoleAPIKey {
+		return entity.Role{}, errs.ErrUserRoleNotAllowed
+	}
+	return sv.roleRepository.GetRoleByPrimaryKey(ctx, nil, constant.DBAttrName, roleName)
+}
+
+// checkRoleChange rejects moving user to newRole unless both the current and
+// the new role grant at most the permissions of actor, so a role change can
+// neither hand out nor take away permissions the caller does not have.
+func (sv *userService) checkRoleChange(ctx context.Context, actor entity.Role, user entity.User,
+	newRole entity.Role) error {
+	currentRole, err := sv.roleRepository.GetRoleByPrimaryKey(ctx, nil, constant.DBAttrName, user.Role)
+	if err != nil {
+		return err
+	}
+	if !grantsAtMost(newRole, actor) || !grantsAtMost(currentRole, actor) {
+		return errs.ErrUserRoleNotAllowed
+	}
+	return nil
+}
+
 func (sv *userService) VerifyLogin(ctx context.Context, email string, password string) bool {
 	userCheck, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrEmail, email)
 	if err != nil {
@@ -61,12 +137,31 @@ func (sv *userService) VerifyLogin(ctx context.Context, email string, password s
 	}
 
 	if userCheck.Email == email && passwordCheck {
+		sv.rehashPassword(ctx, userCheck, password)
 		return true
 	}
 	return false
 }
 
-func (sv *userService) CreateNewUser(ctx context.Context, req dto.UserRegisterRequest) (dto.UserResponse, error) {
+// rehashPassword upgrades a stored hash made under an older hash policy
+// while the plain password is at hand. Failing to do so does not fail the
+// log in, the next one tries again.
+func (sv *userService) rehashPassword(ctx context.Context, user entity.User, password string) {
+	if !util.PasswordNeedsRehash(user.Password) {
+		return
+	}
+
+	hash, err := util.PasswordHash(password)
+	if err == nil {
+		err = sv.userRepository.UpdateUserPasswordHash(ctx, nil, user.ID.String(), hash)
+	}
+	if err != nil {
+		logger.Warn("Failed to rehash password of user %s: %v", user.ID, err)
+	}
+}
+
+func (sv *userService) CreateNewUser(ctx context.Context, req dto.UserRegisterRequest) (
+	resp dto.UserResponse, err error) {
 	userCheck, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrEmail, req.Email)
 	if err != nil && err != errs.ErrUserNotFound {
 		return dto.UserResponse{}, err
@@ -76,15 +171,34 @@ func (sv *userService) CreateNewUser(ctx context.Context, req dto.UserRegisterRe
 		return dto.UserResponse{}, errs.ErrEmailAlreadyExists
 	}
 
+	if err := sv.passwordPolicy.Validate(req.Password, req.Email, req.Name); err != nil {
+		return dto.UserResponse{}, err
+	}
+
 	user := entity.User{
 		Name:     req.Name,
 		Email:    req.Email,
 		Password: req.Password,
 		Role:     constant.EnumRoleUser,
+		Provider: constant.EnumProviderLocal,
 	}
 
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return dto.UserResponse{}, err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
 	// create new user
-	newUser, err := sv.userRepository.CreateNewUser(ctx, nil, user)
+	newUser, err := sv.userRepository.CreateNewUser(ctx, tx, user)
+	if err != nil {
+		return dto.UserResponse{}, err
+	}
+
+	err = recordAudit(ctx, tx, sv.auditLogRepository, constant.EnumAuditActionCreate,
+		constant.EnumAuditEntityUser, newUser.ID.String(), nil, newUser)
 	if err != nil {
 		return dto.UserResponse{}, err
 	}
@@ -106,13 +220,14 @@ func (sv *userService) GetAllUsers(ctx context.Context, req dto.UserGetsRequest)
 
 	for _, user := range users {
 		userResp := dto.UserResponse{
-			ID:    user.ID.String(),
-			Name:  user.Name,
-			Email: user.Email,
-			Role:  user.Role,
+			ID:              user.ID.String(),
+			Name:            user.Name,
+			Email:           user.Email,
+			Role:            user.Role,
+			EmailVerifiedAt: user.EmailVerifiedAt,
 		}
 		if user.Picture != nil {
-			userResp.Picture = *user.Picture
+			userResp.Picture = sv.pictureUpload.urls(sv.fileURLs, *user.Picture)
 		}
 
 		usersResp = append(usersResp, userResp)
@@ -126,21 +241,27 @@ func (sv *userService) GetUserByPrimaryKey(ctx context.Context, key string, val
 		return dto.UserResponse{}, err
 	}
 
+	return toUserResponse(user, sv.fileURLs, sv.pictureUpload), nil
+}
+
+func toUserResponse(user entity.User, fileURLs FileURLService, pictures uploadPolicy) dto.UserResponse {
 	userResp := dto.UserResponse{
-		ID:    user.ID.String(),
-		Name:  user.Name,
-		Email: user.Email,
-		Role:  user.Role,
+		ID:                  user.ID.String(),
+		Name:                user.Name,
+		Email:               user.Email,
+		Role:                user.Role,
+		EmailVerifiedAt:     user.EmailVerifiedAt,
+		ScheduledDeletionAt: user.ScheduledDeletionAt,
 	}
 	if user.Picture != nil {
-		userResp.Picture = *user.Picture
+		userResp.Picture = pictures.urls(fileURLs, *user.Picture)
 	}
 
-	return userResp, nil
+	return userResp
 }
 
 func (sv *userService) UpdateSelfName(ctx context.Context,
-	req dto.UserNameUpdateRequest) (dto.UserResponse, error) {
+	req dto.UserNameUpdateRequest) (resp dto.UserResponse, err error) {
 	user, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrID, req.ID)
 	if err != nil {
 		return dto.UserResponse{}, err
@@ -150,10 +271,18 @@ func (sv *userService) UpdateSelfName(ctx context.Context,
 		ID:   user.ID,
 		Name: req.Name,
 	}
-	err = sv.userRepository.UpdateUser(ctx, nil, userEdit)
+
+	tx, err := sv.txRepository.BeginTx(ctx)
 	if err != nil {
 		return dto.UserResponse{}, err
 	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.updateAudited(ctx, tx, user, userEdit); err != nil {
+		return dto.UserResponse{}, err
+	}
 
 	return dto.UserResponse{
 		ID:   userEdit.ID.String(),
@@ -162,12 +291,29 @@ func (sv *userService) UpdateSelfName(ctx context.Context,
 }
 
 func (sv *userService) UpdateUserByID(ctx context.Context,
-	req dto.UserUpdateRequest) (dto.UserResponse, error) {
+	req dto.UserUpdateRequest) (resp dto.UserResponse, err error) {
 	user, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrID, req.ID)
 	if err != nil {
 		return dto.UserResponse{}, err
 	}
 
+	if req.Role != "" && req.Role != user.Role {
+		if req.ActorID == user.ID.String() {
+			return dto.UserResponse{}, errs.ErrUserRoleChangeSelf
+		}
+		actor, err := sv.actorRole(ctx, req.ActorRole)
+		if err != nil {
+			return dto.UserResponse{}, err
+		}
+		newRole, err := sv.roleRepository.GetRoleByPrimaryKey(ctx, nil, constant.DBAttrName, req.Role)
+		if err != nil {
+			return dto.UserResponse{}, err
+		}
+		if err := sv.checkRoleChange(ctx, actor, user, newRole); err != nil {
+			return dto.UserResponse{}, err
+		}
+	}
+
 	if req.Email != "" && req.Email != user.Email {
 		us, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrEmail, req.Email)
 		if err != nil && err != errs.ErrUserNotFound {
@@ -179,6 +325,20 @@ func (sv *userService) UpdateUserByID(ctx context.Context,
 		}
 	}
 
+	if req.Password != "" {
+		// check against the details the user will have after the update
+		email, name := user.Email, user.Name
+		if req.Email != "" {
+			email = req.Email
+		}
+		if req.Name != "" {
+			name = req.Name
+		}
+		if err := sv.passwordPolicy.Validate(req.Password, email, name); err != nil {
+			return dto.UserResponse{}, err
+		}
+	}
+
 	userEdit := entity.User{
 		ID:       user.ID,
 		Name:     req.Name,
@@ -186,10 +346,18 @@ func (sv *userService) UpdateUserByID(ctx context.Context,
 		Role:     req.Role,
 		Password: req.Password,
 	}
-	err = sv.userRepository.UpdateUser(ctx, nil, userEdit)
+
+	tx, err := sv.txRepository.BeginTx(ctx)
 	if err != nil {
 		return dto.UserResponse{}, err
 	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.updateAudited(ctx, tx, user, userEdit); err != nil {
+		return dto.UserResponse{}, err
+	}
 
 	return dto.UserResponse{
 		ID:    userEdit.ID.String(),
@@ -199,55 +367,81 @@ func (sv *userService) UpdateUserByID(ctx context.Context,
 	}, nil
 }
 
-func (sv *userService) DeleteUserByID(ctx context.Context, id string) error {
-	_, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrID, id)
+func (sv *userService) DeleteUserByID(ctx context.Context, id string) (err error) {
+	user, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrID, id)
 	if err != nil {
 		return err
 	}
 
-	err = sv.userRepository.DeleteUserByID(ctx, nil, id)
+	tx, err := sv.txRepository.BeginTx(ctx)
 	if err != nil {
 		return err
 	}
-	return nil
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	err = sv.userRepository.DeleteUserByID(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+
+	return recordAudit(ctx, tx, sv.auditLogRepository, constant.EnumAuditActionDelete,
+		constant.EnumAuditEntityUser, id, user, nil)
+}
+
+func (sv *userService) PictureRequestLimit() int64 {
+	return sv.pictureUpload.requestLimit()
 }
 
 func (sv *userService) ChangePicture(ctx context.Context,
-	req dto.UserChangePictureRequest) (dto.UserResponse, error) {
+	req dto.UserChangePictureRequest) (resp dto.UserResponse, err error) {
 	user, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrID, req.ID)
 	if err != nil {
 		return dto.UserResponse{}, err
 	}
 
+	// Upload first so a rejected file keeps the current picture
+	picID := uuid.New()
+	picPath := fmt.Sprintf("%s/%v", constant.EnumFileNamespaceUserPicture, picID)
+	if err := uploadFile(ctx, sv.storage, sv.pictureUpload, req.Picture, picPath); err != nil {
+		return dto.UserResponse{}, err
+	}
+
 	if user.Picture != nil && *user.Picture != "" {
-		if err := util.DeleteFile(*user.Picture); err != nil {
+		if err := sv.storage.Delete(ctx, *user.Picture); err != nil {
+			return dto.UserResponse{}, err
+		}
+		if err := deleteImageVariants(ctx, sv.storage, sv.pictureUpload, user.Picture); err != nil {
 			return dto.UserResponse{}, err
 		}
-	}
-
-	picID := uuid.New()
-	picPath := fmt.Sprintf("user_picture/%v", picID)
-	if err := util.UploadFile(req.Picture, picPath); err != nil {
-		return dto.UserResponse{}, err
 	}
 
 	userEdit := entity.User{
 		ID:      user.ID,
 		Picture: &picPath,
 	}
-	err = sv.userRepository.UpdateUser(ctx, nil, userEdit)
+
+	tx, err := sv.txRepository.BeginTx(ctx)
 	if err != nil {
 		return dto.UserResponse{}, err
 	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.updateAudited(ctx, tx, user, userEdit); err != nil {
+		return dto.UserResponse{}, err
+	}
 
 	userResp := dto.UserResponse{
 		ID:      userEdit.ID.String(),
-		Picture: picPath,
+		Picture: sv.pictureUpload.urls(sv.fileURLs, picPath),
 	}
 	return userResp, nil
 }
 
-func (sv *userService) DeletePicture(ctx context.Context, userID string) error {
+func (sv *userService) DeletePicture(ctx context.Context, userID string) (err error) {
 	user, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrID, userID)
 	if err != nil {
 		return err
@@ -257,7 +451,10 @@ func (sv *userService) DeletePicture(ctx context.Context, userID string) error {
 		return errs.ErrUserNoPicture
 	}
 
-	if err := util.DeleteFile(*user.Picture); err != nil {
+	if err := sv.storage.Delete(ctx, *user.Picture); err != nil {
+		return err
+	}
+	if err := deleteImageVariants(ctx, sv.storage, sv.pictureUpload, user.Picture); err != nil {
 		return err
 	}
 
@@ -267,12 +464,178 @@ func (sv *userService) DeletePicture(ctx context.Context, userID string) error {
 		Picture: &emptyString,
 	}
 
-	err = sv.userRepository.UpdateUser(ctx, nil, userEdit)
+	tx, err := sv.txRepository.BeginTx(ctx)
 	if err != nil {
 		return err
 	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
 
-	return nil
+	return sv.updateAudited(ctx, tx, user, userEdit)
+}
+
+func (sv *userService) ScheduleUserDeletion(ctx context.Context, id string) (resp dto.UserResponse, err error) {
+	user, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrID, id)
+	if err != nil {
+		return dto.UserResponse{}, err
+	}
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return dto.UserResponse{}, err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	at := time.Now().Add(sv.deletionGrace)
+	if err = sv.userRepository.ScheduleUserDeletion(ctx, tx, id, at); err != nil {
+		return dto.UserResponse{}, err
+	}
+
+	if err = sv.recordUserUpdate(ctx, tx, user); err != nil {
+		return dto.UserResponse{}, err
+	}
+
+	return dto.UserResponse{
+		ID:                  user.ID.String(),
+		ScheduledDeletionAt: &at,
+	}, nil
+}
+
+func (sv *userService) CancelUserDeletion(ctx context.Context, id string) (err error) {
+	user, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrID, id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.userRepository.CancelUserDeletion(ctx, tx, id); err != nil {
+		return err
+	}
+
+	return sv.recordUserUpdate(ctx, tx, user)
+}
+
+// recordUserUpdate records the change made to a user by a repository call
+// other than UpdateUser.
+func (sv *userService) recordUserUpdate(ctx context.Context, tx *gorm.DB, before entity.User) error {
+	after, err := sv.userRepository.GetUserByPrimaryKey(ctx, tx, constant.DBAttrID, before.ID.String())
+	if err != nil {
+		return err
+	}
+
+	return recordAudit(ctx, tx, sv.auditLogRepository, constant.EnumAuditActionUpdate,
+		constant.EnumAuditEntityUser, before.ID.String(), before, after)
+}
+
+// purgeUser anonymizes the user and drops their credentials in one
+// transaction.
+func (sv *userService) purgeUser(ctx context.Context, user entity.User) (err error) {
+	tx, err := sv.txRepository.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		sv.txRepository.CommitOrRollbackTx(ctx, tx, err)
+	}()
+
+	if err = sv.userRepository.PurgeUser(ctx, tx, user); err != nil {
+		return err
+	}
+
+	return recordErasure(ctx, tx, sv.auditLogRepository, constant.EnumAuditActionDelete,
+		constant.EnumAuditEntityUser, user.ID.String(), user)
+}
+
+func (sv *userService) PurgeScheduledUsers(ctx context.Context) (int, error) {
+	purged := 0
+	for {
+		users, err := sv.userRepository.GetUsersDueForDeletion(ctx, nil, time.Now(), constant.AccountPurgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		for _, user := range users {
+			err := sv.purgeUser(ctx, user)
+			if errors.Is(err, errs.ErrUserDeletionNotScheduled) {
+				// Cancelled meanwhile, the files stay
+				continue
+			}
+			if err != nil {
+				return purged, err
+			}
+			purged++
+
+			// Files last, once the purge is committed. The purged row keeps
+			// the picture key, so files left behind by a failed delete go
+			// when the trash purge removes the row.
+			err = deleteStoredFile(ctx, sv.storage, user.Picture)
+			if err == nil {
+				err = deleteImageVariants(ctx, sv.storage, sv.pictureUpload, user.Picture)
+			}
+			if err != nil {
+				logger.Warn("Failed to delete picture of purged user %s: %v", user.ID, err)
+			}
+		}
+
+		if len(users) < constant.AccountPurgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+func (sv *userService) ExportUserData(ctx context.Context, id string) (dto.UserExportResponse, error) {
+	user, err := sv.userRepository.GetUserByPrimaryKey(ctx, nil, constant.DBAttrID, id)
+	if err != nil {
+		return dto.UserExportResponse{}, err
+	}
+
+	export := dto.UserExportResponse{
+		ExportedAt: time.Now(),
+		Profile: dto.UserExportProfile{
+			ID:                  user.ID.String(),
+			Name:                user.Name,
+			Email:               user.Email,
+			Role:                user.Role,
+			Provider:            user.Provider,
+			CreatedAt:           user.CreatedAt,
+			EmailVerifiedAt:     user.EmailVerifiedAt,
+			PasswordChangedAt:   user.PasswordChangedAt,
+			ScheduledDeletionAt: user.ScheduledDeletionAt,
+		},
+		Sessions: []dto.UserSessionResponse{},
+	}
+
+	if user.Picture != nil && *user.Picture != "" {
+		data, err := sv.readFile(ctx, *user.Picture)
+		if err != nil && !errors.Is(err, errs.ErrFileNotFound) {
+			return dto.UserExportResponse{}, err
+		}
+		if err == nil {
+			export.Picture = &dto.UserExportFile{Path: *user.Picture, Data: data}
+		}
+	}
+
+	return export, nil
+}
+
+func (sv *userService) readFile(ctx context.Context, key string) ([]byte, error) {
+	obj, err := sv.storage.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer obj.Body.Close()
+
+	return io.ReadAll(obj.Body)
 }
 
 // RunUserMaintenance is a "large" example function that shows how to:
@@ -291,6 +654,19 @@ func (sv *userService) RunUserMaintenance(
 		req.InactiveDays = 0
 	}
 
+	var actor, newRole entity.Role
+	if req.NewRole != "" {
+		if actor, err = sv.actorRole(ctx, req.ActorRole); err != nil {
+			return dto.UserMaintenanceResponse{}, err
+		}
+		if newRole, err = sv.roleRepository.GetRoleByPrimaryKey(ctx, nil, constant.DBAttrName, req.NewRole); err != nil {
+			return dto.UserMaintenanceResponse{}, err
+		}
+		if !grantsAtMost(newRole, actor) {
+			return dto.UserMaintenanceResponse{}, errs.ErrUserRoleNotAllowed
+		}
+	}
+
 	// 2. Build the underlying GetAllUsers request from the embedded struct
 	getReq := dto.UserGetsRequest{
 		ID:     req.ID,
@@ -346,8 +722,19 @@ func (sv *userService) RunUserMaintenance(
 			ID: user.ID,
 		}
 
-		// Business rule: change role if requested
-		if req.NewRole != "" && user.Role != req.NewRole {
+		// Business rule: change role if requested, leaving the caller and
+		// users with permissions the caller lacks as they are
+		changeRole := req.NewRole != "" && user.Role != req.NewRole && user.ID.String() != req.ActorID
+		if changeRole {
+			if errCheck := sv.checkRoleChange(ctx, actor, user, newRole); errCheck != nil {
+				if !errors.Is(errCheck, errs.ErrUserRoleNotAllowed) {
+					err = errCheck
+					return resp, err
+				}
+				changeRole = false
+			}
+		}
+		if changeRole {
 			userEdit.Role = req.NewRole
 			result.NewRole = req.NewRole
 			resp.RoleChangedCount++
@@ -358,7 +745,11 @@ func (sv *userService) RunUserMaintenance(
 		// Business rule: clear picture if requested
 		if req.ClearPicture && user.Picture != nil && *user.Picture != "" {
 			// Remove file from storage
-			if errDel := util.DeleteFile(*user.Picture); errDel != nil {
+			if errDel := sv.storage.Delete(ctx, *user.Picture); errDel != nil {
+				err = errDel
+				return resp, err
+			}
+			if errDel := deleteImageVariants(ctx, sv.storage, sv.pictureUpload, user.Picture); errDel != nil {
 				err = errDel
 				return resp, err
 			}
@@ -375,7 +766,7 @@ func (sv *userService) RunUserMaintenance(
 			continue
 		}
 
-		if err = sv.userRepository.UpdateUser(ctx, tx, userEdit); err != nil {
+		if err = sv.updateAudited(ctx, tx, user, userEdit); err != nil {
 			return resp, err
 		}
 
@@ -0,0 +1,23 @@
+-- +goose Up
+-- create "refresh_tokens" table
+CREATE TABLE "refresh_tokens" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "user_id" uuid NOT NULL, "family_id" uuid NOT NULL, "token_hash" text NOT NULL, "expires_at" timestamptz NOT NULL, "revoked_at" timestamptz NULL, "replaced_by" uuid NULL, "created_at" timestamptz NULL, "updated_at" timestamptz NULL, "deleted_at" timestamptz NULL, PRIMARY KEY ("id"));
+-- create index "idx_refresh_tokens_deleted_at" to table: "refresh_tokens"
+CREATE INDEX "idx_refresh_tokens_deleted_at" ON "refresh_tokens" ("deleted_at");
+-- create index "idx_refresh_tokens_family_id" to table: "refresh_tokens"
+CREATE INDEX "idx_refresh_tokens_family_id" ON "refresh_tokens" ("family_id");
+-- create index "idx_refresh_tokens_token_hash" to table: "refresh_tokens"
+CREATE UNIQUE INDEX "idx_refresh_tokens_token_hash" ON "refresh_tokens" ("token_hash");
+-- create index "idx_refresh_tokens_user_id" to table: "refresh_tokens"
+CREATE INDEX "idx_refresh_tokens_user_id" ON "refresh_tokens" ("user_id");
+
+-- +goose Down
+-- reverse: create index "idx_refresh_tokens_user_id" to table: "refresh_tokens"
+DROP INDEX "idx_refresh_tokens_user_id";
+-- reverse: create index "idx_refresh_tokens_token_hash" to table: "refresh_tokens"
+DROP INDEX "idx_refresh_tokens_token_hash";
+-- reverse: create index "idx_refresh_tokens_family_id" to table: "refresh_tokens"
+DROP INDEX "idx_refresh_tokens_family_id";
+-- reverse: create index "idx_refresh_tokens_deleted_at" to table: "refresh_tokens"
+DROP INDEX "idx_refresh_tokens_deleted_at";
+-- reverse: create "refresh_tokens" table
+DROP TABLE "refresh_tokens";
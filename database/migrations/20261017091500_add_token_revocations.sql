@@ -0,0 +1,23 @@
+-- +goose Up
+-- create "token_revocations" table
+CREATE TABLE "token_revocations" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "user_id" uuid NOT NULL, "token_id" text NULL, "revoked_before" timestamptz NULL, "expires_at" timestamptz NOT NULL, "created_at" timestamptz NULL, "updated_at" timestamptz NULL, "deleted_at" timestamptz NULL, PRIMARY KEY ("id"));
+-- create index "idx_token_revocations_deleted_at" to table: "token_revocations"
+CREATE INDEX "idx_token_revocations_deleted_at" ON "token_revocations" ("deleted_at");
+-- create index "idx_token_revocations_expires_at" to table: "token_revocations"
+CREATE INDEX "idx_token_revocations_expires_at" ON "token_revocations" ("expires_at");
+-- create index "idx_token_revocations_token_id" to table: "token_revocations"
+CREATE UNIQUE INDEX "idx_token_revocations_token_id" ON "token_revocations" ("token_id");
+-- create index "idx_token_revocations_user_id" to table: "token_revocations"
+CREATE INDEX "idx_token_revocations_user_id" ON "token_revocations" ("user_id");
+
+-- +goose Down
+-- reverse: create index "idx_token_revocations_user_id" to table: "token_revocations"
+DROP INDEX "idx_token_revocations_user_id";
+-- reverse: create index "idx_token_revocations_token_id" to table: "token_revocations"
+DROP INDEX "idx_token_revocations_token_id";
+-- reverse: create index "idx_token_revocations_expires_at" to table: "token_revocations"
+DROP INDEX "idx_token_revocations_expires_at";
+-- reverse: create index "idx_token_revocations_deleted_at" to table: "token_revocations"
+DROP INDEX "idx_token_revocations_deleted_at";
+-- reverse: create "token_revocations" table
+DROP TABLE "token_revocations";
@@ -0,0 +1,19 @@
+-- +goose Up
+-- create "password_reset_tokens" table
+CREATE TABLE "password_reset_tokens" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "user_id" uuid NOT NULL, "token_hash" text NOT NULL, "expires_at" timestamptz NOT NULL, "used_at" timestamptz NULL, "created_at" timestamptz NULL, "updated_at" timestamptz NULL, "deleted_at" timestamptz NULL, PRIMARY KEY ("id"));
+-- create index "idx_password_reset_tokens_deleted_at" to table: "password_reset_tokens"
+CREATE INDEX "idx_password_reset_tokens_deleted_at" ON "password_reset_tokens" ("deleted_at");
+-- create index "idx_password_reset_tokens_token_hash" to table: "password_reset_tokens"
+CREATE UNIQUE INDEX "idx_password_reset_tokens_token_hash" ON "password_reset_tokens" ("token_hash");
+-- create index "idx_password_reset_tokens_user_id" to table: "password_reset_tokens"
+CREATE INDEX "idx_password_reset_tokens_user_id" ON "password_reset_tokens" ("user_id");
+
+-- +goose Down
+-- reverse: create index "idx_password_reset_tokens_user_id" to table: "password_reset_tokens"
+DROP INDEX "idx_password_reset_tokens_user_id";
+-- reverse: create index "idx_password_reset_tokens_token_hash" to table: "password_reset_tokens"
+DROP INDEX "idx_password_reset_tokens_token_hash";
+-- reverse: create index "idx_password_reset_tokens_deleted_at" to table: "password_reset_tokens"
+DROP INDEX "idx_password_reset_tokens_deleted_at";
+-- reverse: create "password_reset_tokens" table
+DROP TABLE "password_reset_tokens";
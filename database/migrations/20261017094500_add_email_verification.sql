@@ -0,0 +1,25 @@
+-- +goose Up
+-- modify "users" table
+ALTER TABLE "users" ADD COLUMN "email_verified_at" timestamptz NULL;
+-- accounts created before verification existed are trusted as they are
+UPDATE "users" SET "email_verified_at" = "created_at";
+-- create "email_verification_tokens" table
+CREATE TABLE "email_verification_tokens" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "user_id" uuid NOT NULL, "token_hash" text NOT NULL, "expires_at" timestamptz NOT NULL, "used_at" timestamptz NULL, "created_at" timestamptz NULL, "updated_at" timestamptz NULL, "deleted_at" timestamptz NULL, PRIMARY KEY ("id"));
+-- create index "idx_email_verification_tokens_deleted_at" to table: "email_verification_tokens"
+CREATE INDEX "idx_email_verification_tokens_deleted_at" ON "email_verification_tokens" ("deleted_at");
+-- create index "idx_email_verification_tokens_token_hash" to table: "email_verification_tokens"
+CREATE UNIQUE INDEX "idx_email_verification_tokens_token_hash" ON "email_verification_tokens" ("token_hash");
+-- create index "idx_email_verification_tokens_user_id" to table: "email_verification_tokens"
+CREATE INDEX "idx_email_verification_tokens_user_id" ON "email_verification_tokens" ("user_id");
+
+-- +goose Down
+-- reverse: create index "idx_email_verification_tokens_user_id" to table: "email_verification_tokens"
+DROP INDEX "idx_email_verification_tokens_user_id";
+-- reverse: create index "idx_email_verification_tokens_token_hash" to table: "email_verification_tokens"
+DROP INDEX "idx_email_verification_tokens_token_hash";
+-- reverse: create index "idx_email_verification_tokens_deleted_at" to table: "email_verification_tokens"
+DROP INDEX "idx_email_verification_tokens_deleted_at";
+-- reverse: create "email_verification_tokens" table
+DROP TABLE "email_verification_tokens";
+-- reverse: modify "users" table
+ALTER TABLE "users" DROP COLUMN "email_verified_at";
@@ -0,0 +1,21 @@
+-- +goose Up
+-- create "oidc_states" table
+CREATE TABLE "oidc_states" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "provider" text NOT NULL, "state_hash" text NOT NULL, "code_verifier" text NOT NULL, "nonce" text NOT NULL, "expires_at" timestamptz NOT NULL, "created_at" timestamptz NULL, "updated_at" timestamptz NULL, "deleted_at" timestamptz NULL, PRIMARY KEY ("id"));
+-- create index "idx_oidc_states_deleted_at" to table: "oidc_states"
+CREATE INDEX "idx_oidc_states_deleted_at" ON "oidc_states" ("deleted_at");
+-- create index "idx_oidc_states_expires_at" to table: "oidc_states"
+CREATE INDEX "idx_oidc_states_expires_at" ON "oidc_states" ("expires_at");
+-- create index "idx_oidc_states_state_hash" to table: "oidc_states"
+CREATE UNIQUE INDEX "idx_oidc_states_state_hash" ON "oidc_states" ("state_hash");
+-- backfill "provider" of password accounts
+UPDATE "users" SET "provider" = 'local' WHERE "provider" = '';
+
+-- +goose Down
+-- reverse: create index "idx_oidc_states_state_hash" to table: "oidc_states"
+DROP INDEX "idx_oidc_states_state_hash";
+-- reverse: create index "idx_oidc_states_expires_at" to table: "oidc_states"
+DROP INDEX "idx_oidc_states_expires_at";
+-- reverse: create index "idx_oidc_states_deleted_at" to table: "oidc_states"
+DROP INDEX "idx_oidc_states_deleted_at";
+-- reverse: create "oidc_states" table
+DROP TABLE "oidc_states";
@@ -0,0 +1,43 @@
+-- +goose Up
+-- create "totp_credentials" table
+CREATE TABLE "totp_credentials" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "user_id" uuid NOT NULL, "secret" text NOT NULL, "confirmed_at" timestamptz NULL, "last_used_step" bigint NOT NULL DEFAULT 0, "created_at" timestamptz NULL, "updated_at" timestamptz NULL, "deleted_at" timestamptz NULL, PRIMARY KEY ("id"));
+-- create index "idx_totp_credentials_deleted_at" to table: "totp_credentials"
+CREATE INDEX "idx_totp_credentials_deleted_at" ON "totp_credentials" ("deleted_at");
+-- create index "idx_totp_credentials_user_id" to table: "totp_credentials"
+CREATE UNIQUE INDEX "idx_totp_credentials_user_id" ON "totp_credentials" ("user_id");
+-- create "recovery_codes" table
+CREATE TABLE "recovery_codes" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "user_id" uuid NOT NULL, "code_hash" text NOT NULL, "used_at" timestamptz NULL, "created_at" timestamptz NULL, "updated_at" timestamptz NULL, "deleted_at" timestamptz NULL, PRIMARY KEY ("id"));
+-- create index "idx_recovery_codes_deleted_at" to table: "recovery_codes"
+CREATE INDEX "idx_recovery_codes_deleted_at" ON "recovery_codes" ("deleted_at");
+-- create index "idx_recovery_codes_user_id" to table: "recovery_codes"
+CREATE INDEX "idx_recovery_codes_user_id" ON "recovery_codes" ("user_id");
+-- create "two_factor_challenges" table
+CREATE TABLE "two_factor_challenges" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "user_id" uuid NOT NULL, "token_hash" text NOT NULL, "expires_at" timestamptz NOT NULL, "attempts" bigint NOT NULL DEFAULT 0, "used_at" timestamptz NULL, "created_at" timestamptz NULL, "updated_at" timestamptz NULL, "deleted_at" timestamptz NULL, PRIMARY KEY ("id"));
+-- create index "idx_two_factor_challenges_deleted_at" to table: "two_factor_challenges"
+CREATE INDEX "idx_two_factor_challenges_deleted_at" ON "two_factor_challenges" ("deleted_at");
+-- create index "idx_two_factor_challenges_token_hash" to table: "two_factor_challenges"
+CREATE UNIQUE INDEX "idx_two_factor_challenges_token_hash" ON "two_factor_challenges" ("token_hash");
+-- create index "idx_two_factor_challenges_user_id" to table: "two_factor_challenges"
+CREATE INDEX "idx_two_factor_challenges_user_id" ON "two_factor_challenges" ("user_id");
+
+-- +goose Down
+-- reverse: create index "idx_two_factor_challenges_user_id" to table: "two_factor_challenges"
+DROP INDEX "idx_two_factor_challenges_user_id";
+-- reverse: create index "idx_two_factor_challenges_token_hash" to table: "two_factor_challenges"
+DROP INDEX "idx_two_factor_challenges_token_hash";
+-- reverse: create index "idx_two_factor_challenges_deleted_at" to table: "two_factor_challenges"
+DROP INDEX "idx_two_factor_challenges_deleted_at";
+-- reverse: create "two_factor_challenges" table
+DROP TABLE "two_factor_challenges";
+-- reverse: create index "idx_recovery_codes_user_id" to table: "recovery_codes"
+DROP INDEX "idx_recovery_codes_user_id";
+-- reverse: create index "idx_recovery_codes_deleted_at" to table: "recovery_codes"
+DROP INDEX "idx_recovery_codes_deleted_at";
+-- reverse: create "recovery_codes" table
+DROP TABLE "recovery_codes";
+-- reverse: create index "idx_totp_credentials_user_id" to table: "totp_credentials"
+DROP INDEX "idx_totp_credentials_user_id";
+-- reverse: create index "idx_totp_credentials_deleted_at" to table: "totp_credentials"
+DROP INDEX "idx_totp_credentials_deleted_at";
+-- reverse: create "totp_credentials" table
+DROP TABLE "totp_credentials";
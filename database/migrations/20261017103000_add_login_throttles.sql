@@ -0,0 +1,15 @@
+-- +goose Up
+-- create "login_throttles" table
+CREATE TABLE "login_throttles" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "key" text NOT NULL, "failures" bigint NOT NULL DEFAULT 0, "last_failure_at" timestamptz NOT NULL, "next_attempt_at" timestamptz NULL, "locked_until" timestamptz NULL, "created_at" timestamptz NULL, "updated_at" timestamptz NULL, "deleted_at" timestamptz NULL, PRIMARY KEY ("id"));
+-- create index "idx_login_throttles_deleted_at" to table: "login_throttles"
+CREATE INDEX "idx_login_throttles_deleted_at" ON "login_throttles" ("deleted_at");
+-- create index "idx_login_throttles_key" to table: "login_throttles"
+CREATE UNIQUE INDEX "idx_login_throttles_key" ON "login_throttles" ("key");
+
+-- +goose Down
+-- reverse: create index "idx_login_throttles_key" to table: "login_throttles"
+DROP INDEX "idx_login_throttles_key";
+-- reverse: create index "idx_login_throttles_deleted_at" to table: "login_throttles"
+DROP INDEX "idx_login_throttles_deleted_at";
+-- reverse: create "login_throttles" table
+DROP TABLE "login_throttles";
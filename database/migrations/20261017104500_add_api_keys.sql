@@ -0,0 +1,19 @@
+-- +goose Up
+-- create "api_keys" table
+CREATE TABLE "api_keys" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "name" text NOT NULL, "prefix" text NOT NULL, "key_hash" text NOT NULL, "scopes" text NOT NULL, "created_by_id" uuid NOT NULL, "expires_at" timestamptz NOT NULL, "last_used_at" timestamptz NULL, "revoked_at" timestamptz NULL, "created_at" timestamptz NULL, "updated_at" timestamptz NULL, "deleted_at" timestamptz NULL, PRIMARY KEY ("id"));
+-- create index "idx_api_keys_created_by_id" to table: "api_keys"
+CREATE INDEX "idx_api_keys_created_by_id" ON "api_keys" ("created_by_id");
+-- create index "idx_api_keys_deleted_at" to table: "api_keys"
+CREATE INDEX "idx_api_keys_deleted_at" ON "api_keys" ("deleted_at");
+-- create index "idx_api_keys_key_hash" to table: "api_keys"
+CREATE UNIQUE INDEX "idx_api_keys_key_hash" ON "api_keys" ("key_hash");
+
+-- +goose Down
+-- reverse: create index "idx_api_keys_key_hash" to table: "api_keys"
+DROP INDEX "idx_api_keys_key_hash";
+-- reverse: create index "idx_api_keys_deleted_at" to table: "api_keys"
+DROP INDEX "idx_api_keys_deleted_at";
+-- reverse: create index "idx_api_keys_created_by_id" to table: "api_keys"
+DROP INDEX "idx_api_keys_created_by_id";
+-- reverse: create "api_keys" table
+DROP TABLE "api_keys";
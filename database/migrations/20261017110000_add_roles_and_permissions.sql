@@ -0,0 +1,45 @@
+-- +goose Up
+-- create "permissions" table
+CREATE TABLE "permissions" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "name" text NOT NULL, "description" text NULL, "created_at" timestamptz NULL, "updated_at" timestamptz NULL, "deleted_at" timestamptz NULL, PRIMARY KEY ("id"));
+-- create index "idx_permissions_deleted_at" to table: "permissions"
+CREATE INDEX "idx_permissions_deleted_at" ON "permissions" ("deleted_at");
+-- create index "idx_permissions_name" to table: "permissions"
+CREATE UNIQUE INDEX "idx_permissions_name" ON "permissions" ("name");
+-- create "roles" table
+CREATE TABLE "roles" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "name" text NOT NULL, "description" text NULL, "system" boolean NOT NULL DEFAULT false, "created_at" timestamptz NULL, "updated_at" timestamptz NULL, "deleted_at" timestamptz NULL, PRIMARY KEY ("id"));
+-- create index "idx_roles_deleted_at" to table: "roles"
+CREATE INDEX "idx_roles_deleted_at" ON "roles" ("deleted_at");
+-- create index "idx_roles_name" to table: "roles"
+CREATE UNIQUE INDEX "idx_roles_name" ON "roles" ("name");
+-- create "role_permissions" table
+CREATE TABLE "role_permissions" ("role_id" uuid NOT NULL, "permission_id" uuid NOT NULL, PRIMARY KEY ("role_id", "permission_id"), CONSTRAINT "fk_role_permissions_permission" FOREIGN KEY ("permission_id") REFERENCES "permissions" ("id") ON UPDATE NO ACTION ON DELETE NO ACTION, CONSTRAINT "fk_role_permissions_role" FOREIGN KEY ("role_id") REFERENCES "roles" ("id") ON UPDATE NO ACTION ON DELETE NO ACTION);
+-- seed the permission catalog and the built-in roles, admin keeps every permission it had implicitly
+INSERT INTO "permissions" ("name", "description", "created_at", "updated_at") VALUES
+  ('users:read', 'List users', now(), now()),
+  ('users:write', 'Update, delete, unlock and maintain users', now(), now()),
+  ('products:write', 'Create, update and delete products', now(), now()),
+  ('products:stock', 'Adjust product stock', now(), now()),
+  ('categories:write', 'Create, update and delete categories', now(), now()),
+  ('api_keys:manage', 'Create, list and revoke API keys', now(), now()),
+  ('roles:manage', 'Manage roles and their permissions', now(), now());
+INSERT INTO "roles" ("name", "description", "system", "created_at", "updated_at") VALUES
+  ('admin', 'Full access', true, now(), now()),
+  ('user', 'Regular account', true, now(), now());
+INSERT INTO "role_permissions" ("role_id", "permission_id")
+  SELECT "roles"."id", "permissions"."id" FROM "roles" CROSS JOIN "permissions" WHERE "roles"."name" = 'admin';
+
+-- +goose Down
+-- reverse: create "role_permissions" table
+DROP TABLE "role_permissions";
+-- reverse: create index "idx_roles_name" to table: "roles"
+DROP INDEX "idx_roles_name";
+-- reverse: create index "idx_roles_deleted_at" to table: "roles"
+DROP INDEX "idx_roles_deleted_at";
+-- reverse: create "roles" table
+DROP TABLE "roles";
+-- reverse: create index "idx_permissions_name" to table: "permissions"
+DROP INDEX "idx_permissions_name";
+-- reverse: create index "idx_permissions_deleted_at" to table: "permissions"
+DROP INDEX "idx_permissions_deleted_at";
+-- reverse: create "permissions" table
+DROP TABLE "permissions";
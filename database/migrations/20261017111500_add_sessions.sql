@@ -0,0 +1,23 @@
+-- +goose Up
+-- create "sessions" table
+CREATE TABLE "sessions" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "user_id" uuid NOT NULL, "user_agent" text NULL, "ip_address" text NULL, "last_seen_at" timestamptz NOT NULL, "expires_at" timestamptz NOT NULL, "ended_at" timestamptz NULL, "created_at" timestamptz NULL, "updated_at" timestamptz NULL, "deleted_at" timestamptz NULL, PRIMARY KEY ("id"));
+-- create index "idx_sessions_deleted_at" to table: "sessions"
+CREATE INDEX "idx_sessions_deleted_at" ON "sessions" ("deleted_at");
+-- create index "idx_sessions_user_id" to table: "sessions"
+CREATE INDEX "idx_sessions_user_id" ON "sessions" ("user_id");
+-- modify "token_revocations" table
+ALTER TABLE "token_revocations" ADD COLUMN "session_id" uuid NULL;
+-- create index "idx_token_revocations_session_id" to table: "token_revocations"
+CREATE INDEX "idx_token_revocations_session_id" ON "token_revocations" ("session_id");
+
+-- +goose Down
+-- reverse: create index "idx_token_revocations_session_id" to table: "token_revocations"
+DROP INDEX "idx_token_revocations_session_id";
+-- reverse: modify "token_revocations" table
+ALTER TABLE "token_revocations" DROP COLUMN "session_id";
+-- reverse: create index "idx_sessions_user_id" to table: "sessions"
+DROP INDEX "idx_sessions_user_id";
+-- reverse: create index "idx_sessions_deleted_at" to table: "sessions"
+DROP INDEX "idx_sessions_deleted_at";
+-- reverse: create "sessions" table
+DROP TABLE "sessions";
@@ -0,0 +1,12 @@
+-- +goose Up
+-- add the "users:impersonate" permission and grant it to the admin role
+INSERT INTO "permissions" ("name", "description", "created_at", "updated_at") VALUES
+  ('users:impersonate', 'Act as another user for support', now(), now());
+INSERT INTO "role_permissions" ("role_id", "permission_id")
+  SELECT "roles"."id", "permissions"."id" FROM "roles" CROSS JOIN "permissions"
+  WHERE "roles"."name" = 'admin' AND "permissions"."name" = 'users:impersonate';
+
+-- +goose Down
+-- reverse: add the "users:impersonate" permission
+DELETE FROM "role_permissions" WHERE "permission_id" IN (SELECT "id" FROM "permissions" WHERE "name" = 'users:impersonate');
+DELETE FROM "permissions" WHERE "name" = 'users:impersonate';
@@ -0,0 +1,11 @@
+-- +goose Up
+-- modify "users" table
+ALTER TABLE "users" ADD COLUMN "scheduled_deletion_at" timestamptz NULL;
+-- create index "idx_users_scheduled_deletion_at" to table: "users"
+CREATE INDEX "idx_users_scheduled_deletion_at" ON "users" ("scheduled_deletion_at");
+
+-- +goose Down
+-- reverse: create index "idx_users_scheduled_deletion_at" to table: "users"
+DROP INDEX "idx_users_scheduled_deletion_at";
+-- reverse: modify "users" table
+ALTER TABLE "users" DROP COLUMN "scheduled_deletion_at";
@@ -0,0 +1,32 @@
+-- +goose Up
+-- create "invitations" table
+CREATE TABLE "invitations" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "email" text NOT NULL, "role" text NOT NULL, "token_hash" text NOT NULL, "invited_by_id" uuid NOT NULL, "expires_at" timestamptz NOT NULL, "accepted_at" timestamptz NULL, "revoked_at" timestamptz NULL, "created_at" timestamptz NULL, "updated_at" timestamptz NULL, "deleted_at" timestamptz NULL, PRIMARY KEY ("id"));
+-- create index "idx_invitations_deleted_at" to table: "invitations"
+CREATE INDEX "idx_invitations_deleted_at" ON "invitations" ("deleted_at");
+-- create index "idx_invitations_email" to table: "invitations"
+CREATE INDEX "idx_invitations_email" ON "invitations" ("email");
+-- create index "idx_invitations_invited_by_id" to table: "invitations"
+CREATE INDEX "idx_invitations_invited_by_id" ON "invitations" ("invited_by_id");
+-- create index "idx_invitations_token_hash" to table: "invitations"
+CREATE UNIQUE INDEX "idx_invitations_token_hash" ON "invitations" ("token_hash");
+-- add the "users:invite" permission and grant it to the admin role
+INSERT INTO "permissions" ("name", "description", "created_at", "updated_at") VALUES
+  ('users:invite', 'Invite users with a role and manage invitations', now(), now());
+INSERT INTO "role_permissions" ("role_id", "permission_id")
+  SELECT "roles"."id", "permissions"."id" FROM "roles" CROSS JOIN "permissions"
+  WHERE "roles"."name" = 'admin' AND "permissions"."name" = 'users:invite';
+
+-- +goose Down
+-- reverse: add the "users:invite" permission
+DELETE FROM "role_permissions" WHERE "permission_id" IN (SELECT "id" FROM "permissions" WHERE "name" = 'users:invite');
+DELETE FROM "permissions" WHERE "name" = 'users:invite';
+-- reverse: create index "idx_invitations_token_hash" to table: "invitations"
+DROP INDEX "idx_invitations_token_hash";
+-- reverse: create index "idx_invitations_invited_by_id" to table: "invitations"
+DROP INDEX "idx_invitations_invited_by_id";
+-- reverse: create index "idx_invitations_email" to table: "invitations"
+DROP INDEX "idx_invitations_email";
+-- reverse: create index "idx_invitations_deleted_at" to table: "invitations"
+DROP INDEX "idx_invitations_deleted_at";
+-- reverse: create "invitations" table
+DROP TABLE "invitations";
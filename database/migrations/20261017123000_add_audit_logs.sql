@@ -0,0 +1,36 @@
+-- +goose Up
+-- create "audit_logs" table
+CREATE TABLE "audit_logs" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "actor_id" uuid NULL, "actor_type" text NOT NULL, "impersonator_id" uuid NULL, "action" text NOT NULL, "entity_type" text NOT NULL, "entity_id" text NOT NULL, "changes" jsonb NOT NULL, "request_id" text NULL, "ip_address" text NULL, "created_at" timestamptz NULL, "updated_at" timestamptz NULL, "deleted_at" timestamptz NULL, PRIMARY KEY ("id"));
+-- create index "idx_audit_logs_action" to table: "audit_logs"
+CREATE INDEX "idx_audit_logs_action" ON "audit_logs" ("action");
+-- create index "idx_audit_logs_actor_id" to table: "audit_logs"
+CREATE INDEX "idx_audit_logs_actor_id" ON "audit_logs" ("actor_id");
+-- create index "idx_audit_logs_deleted_at" to table: "audit_logs"
+CREATE INDEX "idx_audit_logs_deleted_at" ON "audit_logs" ("deleted_at");
+-- create index "idx_audit_logs_entity" to table: "audit_logs"
+CREATE INDEX "idx_audit_logs_entity" ON "audit_logs" ("entity_type", "entity_id");
+-- create index "idx_audit_logs_request_id" to table: "audit_logs"
+CREATE INDEX "idx_audit_logs_request_id" ON "audit_logs" ("request_id");
+-- add the "audit_logs:read" permission and grant it to the admin role
+INSERT INTO "permissions" ("name", "description", "created_at", "updated_at") VALUES
+  ('audit_logs:read', 'Read the audit log', now(), now());
+INSERT INTO "role_permissions" ("role_id", "permission_id")
+  SELECT "roles"."id", "permissions"."id" FROM "roles" CROSS JOIN "permissions"
+  WHERE "roles"."name" = 'admin' AND "permissions"."name" = 'audit_logs:read';
+
+-- +goose Down
+-- reverse: add the "audit_logs:read" permission
+DELETE FROM "role_permissions" WHERE "permission_id" IN (SELECT "id" FROM "permissions" WHERE "name" = 'audit_logs:read');
+DELETE FROM "permissions" WHERE "name" = 'audit_logs:read';
+-- reverse: create index "idx_audit_logs_request_id" to table: "audit_logs"
+DROP INDEX "idx_audit_logs_request_id";
+-- reverse: create index "idx_audit_logs_entity" to table: "audit_logs"
+DROP INDEX "idx_audit_logs_entity";
+-- reverse: create index "idx_audit_logs_deleted_at" to table: "audit_logs"
+DROP INDEX "idx_audit_logs_deleted_at";
+-- reverse: create index "idx_audit_logs_actor_id" to table: "audit_logs"
+DROP INDEX "idx_audit_logs_actor_id";
+-- reverse: create index "idx_audit_logs_action" to table: "audit_logs"
+DROP INDEX "idx_audit_logs_action";
+-- reverse: create "audit_logs" table
+DROP TABLE "audit_logs";
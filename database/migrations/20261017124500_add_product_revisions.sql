@@ -0,0 +1,29 @@
+-- +goose Up
+-- create "product_revisions" table
+CREATE TABLE "product_revisions" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "product_id" uuid NOT NULL, "revision" bigint NOT NULL, "action" text NOT NULL, "name" text NOT NULL, "description" text NULL, "sku" text NOT NULL, "price" numeric(15,2) NOT NULL, "stock" bigint NOT NULL, "category_id" uuid NULL, "is_active" boolean NOT NULL, "image" text NULL, "changed_by_id" uuid NULL, "created_at" timestamptz NULL, "updated_at" timestamptz NULL, "deleted_at" timestamptz NULL, PRIMARY KEY ("id"));
+-- create index "idx_product_revisions_deleted_at" to table: "product_revisions"
+CREATE INDEX "idx_product_revisions_deleted_at" ON "product_revisions" ("deleted_at");
+-- create index "idx_product_revisions_product_revision" to table: "product_revisions"
+CREATE UNIQUE INDEX "idx_product_revisions_product_revision" ON "product_revisions" ("product_id", "revision");
+-- the current state of existing products is their first revision, valid
+-- from their last update on
+INSERT INTO "product_revisions" ("product_id", "revision", "action", "name", "description", "sku", "price", "stock", "category_id", "is_active", "image", "created_at", "updated_at")
+  SELECT "id", 1, 'create', "name", "description", "sku", "price", "stock", "category_id", "is_active", "image", COALESCE("updated_at", "created_at", now()), now()
+  FROM "products" WHERE "deleted_at" IS NULL;
+-- add the "products:history" permission and grant it to the admin role
+INSERT INTO "permissions" ("name", "description", "created_at", "updated_at") VALUES
+  ('products:history', 'View the revision history of products', now(), now());
+INSERT INTO "role_permissions" ("role_id", "permission_id")
+  SELECT "roles"."id", "permissions"."id" FROM "roles" CROSS JOIN "permissions"
+  WHERE "roles"."name" = 'admin' AND "permissions"."name" = 'products:history';
+
+-- +goose Down
+-- reverse: add the "products:history" permission
+DELETE FROM "role_permissions" WHERE "permission_id" IN (SELECT "id" FROM "permissions" WHERE "name" = 'products:history');
+DELETE FROM "permissions" WHERE "name" = 'products:history';
+-- reverse: create index "idx_product_revisions_product_revision" to table: "product_revisions"
+DROP INDEX "idx_product_revisions_product_revision";
+-- reverse: create index "idx_product_revisions_deleted_at" to table: "product_revisions"
+DROP INDEX "idx_product_revisions_deleted_at";
+-- reverse: create "product_revisions" table
+DROP TABLE "product_revisions";
@@ -0,0 +1,12 @@
+-- +goose Up
+-- add the "trash:manage" permission and grant it to the admin role
+INSERT INTO "permissions" ("name", "description", "created_at", "updated_at") VALUES
+  ('trash:manage', 'List, restore and purge deleted records', now(), now());
+INSERT INTO "role_permissions" ("role_id", "permission_id")
+  SELECT "roles"."id", "permissions"."id" FROM "roles" CROSS JOIN "permissions"
+  WHERE "roles"."name" = 'admin' AND "permissions"."name" = 'trash:manage';
+
+-- +goose Down
+-- reverse: add the "trash:manage" permission
+DELETE FROM "role_permissions" WHERE "permission_id" IN (SELECT "id" FROM "permissions" WHERE "name" = 'trash:manage');
+DELETE FROM "permissions" WHERE "name" = 'trash:manage';
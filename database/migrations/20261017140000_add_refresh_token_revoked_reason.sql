@@ -0,0 +1,9 @@
+-- +goose Up
+-- modify "refresh_tokens" table
+ALTER TABLE "refresh_tokens" ADD COLUMN "revoked_reason" text NULL;
+-- tokens replaced by a newer one were revoked by rotating them
+UPDATE "refresh_tokens" SET "revoked_reason" = 'rotated' WHERE "replaced_by" IS NOT NULL;
+
+-- +goose Down
+-- reverse: modify "refresh_tokens" table
+ALTER TABLE "refresh_tokens" DROP COLUMN "revoked_reason";
@@ -0,0 +1,19 @@
+-- +goose Up
+-- create "user_identities" table
+CREATE TABLE "user_identities" ("id" uuid NOT NULL DEFAULT gen_random_uuid(), "user_id" uuid NOT NULL, "provider" text NOT NULL, "subject" text NOT NULL, "created_at" timestamptz NULL, "updated_at" timestamptz NULL, "deleted_at" timestamptz NULL, PRIMARY KEY ("id"));
+-- create index "idx_user_identities_deleted_at" to table: "user_identities"
+CREATE INDEX "idx_user_identities_deleted_at" ON "user_identities" ("deleted_at");
+-- create index "idx_user_identities_provider_subject" to table: "user_identities"
+CREATE UNIQUE INDEX "idx_user_identities_provider_subject" ON "user_identities" ("provider", "subject");
+-- create index "idx_user_identities_user_id" to table: "user_identities"
+CREATE INDEX "idx_user_identities_user_id" ON "user_identities" ("user_id");
+
+-- +goose Down
+-- reverse: create index "idx_user_identities_user_id" to table: "user_identities"
+DROP INDEX "idx_user_identities_user_id";
+-- reverse: create index "idx_user_identities_provider_subject" to table: "user_identities"
+DROP INDEX "idx_user_identities_provider_subject";
+-- reverse: create index "idx_user_identities_deleted_at" to table: "user_identities"
+DROP INDEX "idx_user_identities_deleted_at";
+-- reverse: create "user_identities" table
+DROP TABLE "user_identities";
@@ -0,0 +1,28 @@
+-- +goose Up
+-- trashed rows no longer hold on to their email, SKU or name
+-- modify "categories" table
+ALTER TABLE "categories" DROP CONSTRAINT "uni_categories_name";
+-- create index "uni_categories_name" to table: "categories"
+CREATE UNIQUE INDEX "uni_categories_name" ON "categories" ("name") WHERE (deleted_at IS NULL);
+-- modify "products" table
+ALTER TABLE "products" DROP CONSTRAINT "uni_products_sku";
+-- create index "uni_products_sku" to table: "products"
+CREATE UNIQUE INDEX "uni_products_sku" ON "products" ("sku") WHERE (deleted_at IS NULL);
+-- modify "users" table
+ALTER TABLE "users" DROP CONSTRAINT "uni_users_email";
+-- create index "uni_users_email" to table: "users"
+CREATE UNIQUE INDEX "uni_users_email" ON "users" ("email") WHERE (deleted_at IS NULL);
+
+-- +goose Down
+-- reverse: create index "uni_users_email" to table: "users"
+DROP INDEX "uni_users_email";
+-- reverse: modify "users" table
+ALTER TABLE "users" ADD CONSTRAINT "uni_users_email" UNIQUE ("email");
+-- reverse: create index "uni_products_sku" to table: "products"
+DROP INDEX "uni_products_sku";
+-- reverse: modify "products" table
+ALTER TABLE "products" ADD CONSTRAINT "uni_products_sku" UNIQUE ("sku");
+-- reverse: create index "uni_categories_name" to table: "categories"
+DROP INDEX "uni_categories_name";
+-- reverse: modify "categories" table
+ALTER TABLE "categories" ADD CONSTRAINT "uni_categories_name" UNIQUE ("name");
@@ -10,6 +10,11 @@ import (
 
 // DBSeed runs all database seeders
 func DBSeed(db *gorm.DB) {
+	if err := seeder.RoleSeeder(db); err != nil {
+		fmt.Println("Failed to seed roles: ", err)
+		panic(err)
+	}
+
 	if err := seeder.UserSeeder(db); err != nil {
 		fmt.Println("Failed to seed users: ", err)
 		panic(err)
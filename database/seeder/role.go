@@ -0,0 +1,61 @@
+package seeder
+
+import (
+	"errors"
+
+	"myapp/core/entity"
+	"myapp/support/constant"
+
+	"gorm.io/gorm"
+)
+
+// RoleSeeder makes sure the permission catalog and the built-in roles exist.
+// It mirrors what the roles migration inserts, for databases built with
+// AutoMigrate. Existing rows are left alone so edits made through the API
+// survive a re-seed.
+func RoleSeeder(db *gorm.DB) error {
+	var permissionCatalog = []entity.Permission{
+		{Name: constant.EnumPermissionUsersRead, Description: "List users"},
+		{Name: constant.EnumPermissionUsersWrite, Description: "Update, delete, unlock and maintain users"},
+		{Name: constant.EnumPermissionUsersImpersonate, Description: "Act as another user for support"},
+		{Name: constant.EnumPermissionUsersInvite, Description: "Invite users with a role and manage invitations"},
+		{Name: constant.EnumPermissionProductsWrite, Description: "Create, update and delete products"},
+		{Name: constant.EnumPermissionProductsStock, Description: "Adjust product stock"},
+		{Name: constant.EnumPermissionProductsHistory, Description: "View the revision history of products"},
+		{Name: constant.EnumPermissionCategoriesWrite, Description: "Create, update and delete categories"},
+		{Name: constant.EnumPermissionAPIKeysManage, Description: "Create, list and revoke API keys"},
+		{Name: constant.EnumPermissionRolesManage, Description: "Manage roles and their permissions"},
+		{Name: constant.EnumPermissionAuditLogsRead, Description: "Read the audit log"},
+		{Name: constant.EnumPermissionTrashManage, Description: "List, restore and purge deleted records"},
+	}
+
+	permissions := make([]entity.Permission, 0, len(permissionCatalog))
+	for _, data := range permissionCatalog {
+		var permission entity.Permission
+		if err := db.Where(&entity.Permission{Name: data.Name}).Attrs(data).FirstOrCreate(&permission).Error; err != nil {
+			return err
+		}
+		permissions = append(permissions, permission)
+	}
+
+	var systemRoles = []entity.Role{
+		{Name: constant.EnumRoleAdmin, Description: "Full access", System: true, Permissions: permissions},
+		{Name: constant.EnumRoleUser, Description: "Regular account", System: true},
+	}
+
+	for _, data := range systemRoles {
+		var role entity.Role
+		err := db.Unscoped().Where(&entity.Role{Name: data.Name}).First(&role).Error
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+
+			if err := db.Create(&data).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
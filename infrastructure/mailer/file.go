@@ -0,0 +1,42 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	maileriface "myapp/core/interface/mailer"
+	"myapp/support/logger"
+
+	"github.com/google/uuid"
+)
+
+// fileMailer never sends anything. Each message is logged and, when dir is
+// set, written there as an .eml file, which makes it suitable for local
+// development and for tests that need to read what was "sent".
+type fileMailer struct {
+	dir  string
+	from string
+}
+
+func NewFileMailer(dir string, from string) *fileMailer {
+	return &fileMailer{dir: dir, from: from}
+}
+
+func (m *fileMailer) Send(_ context.Context, msg maileriface.Message) error {
+	logger.Info("📧 Mail to %s: %s\n%s", strings.Join(msg.To, ", "), msg.Subject, msg.Body)
+
+	if m.dir == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s_%s.eml", time.Now().Format("20060102T150405.000000000"), uuid.NewString())
+	return os.WriteFile(filepath.Join(m.dir, name), buildMessage(m.from, msg), 0o644)
+}
@@ -0,0 +1,89 @@
+package oidc_test
+
+import (
+	"context"
+	"testing"
+
+	errs "myapp/core/helper/errors"
+	oidciface "myapp/core/interface/oidc"
+	support "myapp/tests/testutil"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+const testRedirectURL = "http://localhost/api/v1/auth/test/callback"
+
+// --- Test Helpers ---
+
+func setupOIDCProviderTest(t *testing.T) (*support.OIDCIssuer, oidciface.Provider, context.Context) {
+	t.Helper()
+
+	issuer := support.NewOIDCIssuer(t)
+	issuer.SetIdentity(oidciface.Identity{
+		Subject:       "subject-1",
+		Email:         "jane@example.com",
+		EmailVerified: true,
+		Name:          "Jane Doe",
+	})
+	return issuer, issuer.Provider("test", testRedirectURL), context.Background()
+}
+
+// authorize runs the browser part of the flow and returns the code.
+func authorize(t *testing.T, issuer *support.OIDCIssuer, p oidciface.Provider,
+	state string, nonce string, verifier string) string {
+	t.Helper()
+
+	authURL, err := p.AuthCodeURL(context.Background(), state, nonce, verifier)
+	require.NoError(t, err)
+
+	callback := issuer.Authorize(t, authURL)
+	require.Equal(t, state, callback.Query().Get("state"))
+	return callback.Query().Get("code")
+}
+
+// --- Tests ---
+
+func TestOIDCProvider_Exchange(t *testing.T) {
+	issuer, p, ctx := setupOIDCProviderTest(t)
+	verifier := oauth2.GenerateVerifier()
+
+	code := authorize(t, issuer, p, "state-1", "nonce-1", verifier)
+	identity, err := p.Exchange(ctx, code, verifier, "nonce-1")
+	require.NoError(t, err)
+	require.Equal(t, "subject-1", identity.Subject)
+	require.Equal(t, "jane@example.com", identity.Email)
+	require.True(t, identity.EmailVerified)
+	require.Equal(t, "Jane Doe", identity.Name)
+
+	// Codes are single-use
+	_, err = p.Exchange(ctx, code, verifier, "nonce-1")
+	require.ErrorIs(t, err, errs.ErrOIDCExchangeFailed)
+}
+
+func TestOIDCProvider_Exchange_WrongVerifier(t *testing.T) {
+	issuer, p, ctx := setupOIDCProviderTest(t)
+
+	code := authorize(t, issuer, p, "state-1", "nonce-1", oauth2.GenerateVerifier())
+	_, err := p.Exchange(ctx, code, oauth2.GenerateVerifier(), "nonce-1")
+	require.ErrorIs(t, err, errs.ErrOIDCExchangeFailed)
+}
+
+func TestOIDCProvider_Exchange_NonceMismatch(t *testing.T) {
+	issuer, p, ctx := setupOIDCProviderTest(t)
+	verifier := oauth2.GenerateVerifier()
+
+	code := authorize(t, issuer, p, "state-1", "nonce-1", verifier)
+	_, err := p.Exchange(ctx, code, verifier, "nonce-2")
+	require.ErrorIs(t, err, errs.ErrOIDCIDTokenInvalid)
+}
+
+func TestOIDCProvider_Exchange_UnpublishedKey(t *testing.T) {
+	issuer, p, ctx := setupOIDCProviderTest(t)
+	verifier := oauth2.GenerateVerifier()
+	issuer.SignWithUnpublishedKey(t)
+
+	code := authorize(t, issuer, p, "state-1", "nonce-1", verifier)
+	_, err := p.Exchange(ctx, code, verifier, "nonce-1")
+	require.ErrorIs(t, err, errs.ErrOIDCIDTokenInvalid)
+}
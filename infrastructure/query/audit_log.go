@@ -0,0 +1,62 @@
+package query
+
+import (
+	"context"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	"myapp/support/base"
+
+	"gorm.io/gorm"
+)
+
+var auditLogAllowedSorts = []string{"created_at", "action", "entity_type"}
+var auditLogAllowedIncludes = []string{}
+
+type auditLogQuery struct {
+	db *gorm.DB
+}
+
+func NewAuditLogQuery(db *gorm.DB) *auditLogQuery {
+	return &auditLogQuery{db: db}
+}
+
+func (qr *auditLogQuery) GetAllAuditLogs(ctx context.Context, req dto.AuditLogGetsRequest,
+) ([]entity.AuditLog, base.PaginationResponse, error) {
+	stmt := qr.db.WithContext(ctx).Debug().Model(&entity.AuditLog{})
+
+	if req.ActorID != "" {
+		stmt = stmt.Where("actor_id = ?", req.ActorID)
+	}
+
+	if req.Action != "" {
+		stmt = stmt.Where("action = ?", req.Action)
+	}
+
+	if req.EntityType != "" {
+		stmt = stmt.Where("entity_type = ?", req.EntityType)
+	}
+
+	if req.EntityID != "" {
+		stmt = stmt.Where("entity_id = ?", req.EntityID)
+	}
+
+	if req.RequestID != "" {
+		stmt = stmt.Where("request_id = ?", req.RequestID)
+	}
+
+	// Time range filters
+	if req.From != nil {
+		stmt = stmt.Where("created_at >= ?", *req.From)
+	}
+	if req.To != nil {
+		stmt = stmt.Where("created_at <= ?", *req.To)
+	}
+
+	logs, pageResp, err := GetWithPagination[entity.AuditLog](stmt,
+		req.PaginationRequest, auditLogAllowedSorts, auditLogAllowedIncludes)
+	if err != nil {
+		return nil, pageResp, err
+	}
+	return logs, pageResp, nil
+}
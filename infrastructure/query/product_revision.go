@@ -0,0 +1,52 @@
+package query
+
+import (
+	"context"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	"myapp/support/base"
+
+	"gorm.io/gorm"
+)
+
+var productRevisionAllowedSorts = []string{"revision", "created_at"}
+var productRevisionAllowedIncludes = []string{}
+
+type productRevisionQuery struct {
+	db *gorm.DB
+}
+
+func NewProductRevisionQuery(db *gorm.DB) *productRevisionQuery {
+	return &productRevisionQuery{db: db}
+}
+
+func (qr *productRevisionQuery) GetProductRevisions(ctx context.Context, req dto.ProductHistoryRequest,
+) ([]entity.ProductRevision, base.PaginationResponse, error) {
+	stmt := qr.db.WithContext(ctx).Debug().Model(&entity.ProductRevision{}).
+		Where("product_id = ?", req.ProductID)
+
+	if req.Action != "" {
+		stmt = stmt.Where("action = ?", req.Action)
+	}
+
+	// Time range filters
+	if req.From != nil {
+		stmt = stmt.Where("created_at >= ?", *req.From)
+	}
+	if req.To != nil {
+		stmt = stmt.Where("created_at <= ?", *req.To)
+	}
+
+	// Newest first unless asked otherwise
+	if req.Sort == "" {
+		req.Sort = "-revision"
+	}
+
+	revisions, pageResp, err := GetWithPagination[entity.ProductRevision](stmt,
+		req.PaginationRequest, productRevisionAllowedSorts, productRevisionAllowedIncludes)
+	if err != nil {
+		return nil, pageResp, err
+	}
+	return revisions, pageResp, nil
+}
@@ -0,0 +1,83 @@
+package query
+
+import (
+	"context"
+
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
+	"myapp/support/base"
+
+	"gorm.io/gorm"
+)
+
+var trashedUserAllowedSorts = []string{"deleted_at", "name", "email", "created_at"}
+var trashedProductAllowedSorts = []string{"deleted_at", "name", "sku", "created_at"}
+var trashedCategoryAllowedSorts = []string{"deleted_at", "name", "created_at"}
+var trashAllowedIncludes = []string{}
+
+type trashQuery struct {
+	db *gorm.DB
+}
+
+func NewTrashQuery(db *gorm.DB) *trashQuery {
+	return &trashQuery{db: db}
+}
+
+// trashed selects the soft-deleted rows of model, most recently deleted
+// first unless the request sorts otherwise.
+func (qr *trashQuery) trashed(ctx context.Context, model any, req *dto.TrashGetsRequest) *gorm.DB {
+	if req.Sort == "" {
+		req.Sort = "-deleted_at"
+	}
+	return qr.db.WithContext(ctx).Debug().Unscoped().Model(model).Where("deleted_at IS NOT NULL")
+}
+
+func (qr *trashQuery) GetTrashedUsers(ctx context.Context, req dto.TrashGetsRequest,
+) ([]entity.User, base.PaginationResponse, error) {
+	stmt := qr.trashed(ctx, &entity.User{}, &req)
+
+	if req.Search != "" {
+		search := "%" + req.Search + "%"
+		stmt = stmt.Where("name ILIKE ? OR email ILIKE ?", search, search)
+	}
+
+	users, pageResp, err := GetWithPagination[entity.User](stmt,
+		req.PaginationRequest, trashedUserAllowedSorts, trashAllowedIncludes)
+	if err != nil {
+		return nil, pageResp, err
+	}
+	return users, pageResp, nil
+}
+
+func (qr *trashQuery) GetTrashedProducts(ctx context.Context, req dto.TrashGetsRequest,
+) ([]entity.Product, base.PaginationResponse, error) {
+	stmt := qr.trashed(ctx, &entity.Product{}, &req)
+
+	if req.Search != "" {
+		search := "%" + req.Search + "%"
+		stmt = stmt.Where("name ILIKE ? OR sku ILIKE ?", search, search)
+	}
+
+	products, pageResp, err := GetWithPagination[entity.Product](stmt,
+		req.PaginationRequest, trashedProductAllowedSorts, trashAllowedIncludes)
+	if err != nil {
+		return nil, pageResp, err
+	}
+	return products, pageResp, nil
+}
+
+func (qr *trashQuery) GetTrashedCategories(ctx context.Context, req dto.TrashGetsRequest,
+) ([]entity.Category, base.PaginationResponse, error) {
+	stmt := qr.trashed(ctx, &entity.Category{}, &req)
+
+	if req.Search != "" {
+		stmt = stmt.Where("name ILIKE ?", "%"+req.Search+"%")
+	}
+
+	categories, pageResp, err := GetWithPagination[entity.Category](stmt,
+		req.PaginationRequest, trashedCategoryAllowedSorts, trashAllowedIncludes)
+	if err != nil {
+		return nil, pageResp, err
+	}
+	return categories, pageResp, nil
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"myapp/core/entity"
+	errs "myapp/core/helper/errors"
+
+	"gorm.io/gorm"
+)
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) *apiKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (rp *apiKeyRepository) DB() *gorm.DB {
+	return rp.db
+}
+
+func (rp *apiKeyRepository) CreateAPIKey(ctx context.Context, tx *gorm.DB,
+	key entity.APIKey) (entity.APIKey, error) {
+	return Create(ctx, tx, rp.DB(), key)
+}
+
+func (rp *apiKeyRepository) GetAllAPIKeys(ctx context.Context, tx *gorm.DB) ([]entity.APIKey, error) {
+	var keys []entity.APIKey
+
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().Order("created_at DESC").Find(&keys).Error
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (rp *apiKeyRepository) GetAPIKeyByHash(ctx context.Context, tx *gorm.DB,
+	hash string) (entity.APIKey, error) {
+	var key entity.APIKey
+
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().Where("key_hash = ?", hash).Take(&key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.APIKey{}, errs.ErrAPIKeyInvalid
+		}
+		return key, err
+	}
+	return key, nil
+}
+
+// TouchAPIKey records that a key was used. Keys used again since
+// staleBefore are left alone, which keeps busy keys from writing on every
+// request.
+func (rp *apiKeyRepository) TouchAPIKey(ctx context.Context, tx *gorm.DB, id string,
+	now time.Time, staleBefore time.Time) error {
+	return useDB(tx, rp.db).WithContext(ctx).Debug().
+		Model(&entity.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, staleBefore).
+		Update("last_used_at", now).Error
+}
+
+// RevokeAPIKey marks an active key as revoked. The row is kept so the key
+// still shows up in the list with its history.
+func (rp *apiKeyRepository) RevokeAPIKey(ctx context.Context, tx *gorm.DB, id string) error {
+	result := useDB(tx, rp.db).WithContext(ctx).Debug().
+		Model(&entity.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errs.ErrAPIKeyNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+
+	"myapp/core/entity"
+
+	"gorm.io/gorm"
+)
+
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository(db *gorm.DB) *auditLogRepository {
+	return &auditLogRepository{db: db}
+}
+
+func (rp *auditLogRepository) DB() *gorm.DB {
+	return rp.db
+}
+
+func (rp *auditLogRepository) CreateAuditLog(ctx context.Context, tx *gorm.DB,
+	log entity.AuditLog) (entity.AuditLog, error) {
+	return Create(ctx, tx, rp.DB(), log)
+}
+
+func (rp *auditLogRepository) RedactAuditLogs(ctx context.Context, tx *gorm.DB, entityType string,
+	entityID string, fields []string) error {
+	db := useDB(tx, rp.db).WithContext(ctx).Debug()
+
+	for _, field := range fields {
+		for _, side := range []string{"before", "after"} {
+			// #>> yields NULL for a missing field and a JSON null alike
+			path := "{" + field + "," + side + "}"
+			err := db.Model(&entity.AuditLog{}).
+				Where("entity_type = ? AND entity_id = ? AND changes #>> ?::text[] IS NOT NULL",
+					entityType, entityID, path).
+				UpdateColumn("changes", gorm.Expr("jsonb_set(changes, ?::text[], ?::jsonb)", path, `"[redacted]"`)).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
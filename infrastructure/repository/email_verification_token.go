@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"myapp/core/entity"
+	errs "myapp/core/helper/errors"
+
+	"gorm.io/gorm"
+)
+
+type emailVerificationTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailVerificationTokenRepository(db *gorm.DB) *emailVerificationTokenRepository {
+	return &emailVerificationTokenRepository{db: db}
+}
+
+func (rp *emailVerificationTokenRepository) DB() *gorm.DB {
+	return rp.db
+}
+
+func (rp *emailVerificationTokenRepository) CreateEmailVerificationToken(ctx context.Context, tx *gorm.DB,
+	token entity.EmailVerificationToken) (entity.EmailVerificationToken, error) {
+	return Create(ctx, tx, rp.DB(), token)
+}
+
+func (rp *emailVerificationTokenRepository) GetEmailVerificationTokenByHash(ctx context.Context, tx *gorm.DB,
+	hash string) (entity.EmailVerificationToken, error) {
+	var token entity.EmailVerificationToken
+
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().Where("token_hash = ?", hash).Take(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.EmailVerificationToken{}, errs.ErrEmailVerificationTokenInvalid
+		}
+		return token, err
+	}
+	return token, nil
+}
+
+// UseEmailVerificationToken marks an unused token as used. The update is
+// conditional so a token presented twice concurrently is only accepted once.
+func (rp *emailVerificationTokenRepository) UseEmailVerificationToken(ctx context.Context, tx *gorm.DB, id string) error {
+	result := useDB(tx, rp.db).WithContext(ctx).Debug().
+		Model(&entity.EmailVerificationToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errs.ErrEmailVerificationTokenInvalid
+	}
+
+	return nil
+}
+
+// InvalidateUserEmailVerificationTokens marks every unused token of a user as
+// used, so only the most recently mailed link verifies the address.
+func (rp *emailVerificationTokenRepository) InvalidateUserEmailVerificationTokens(ctx context.Context,
+	tx *gorm.DB, userID string) error {
+	return useDB(tx, rp.db).WithContext(ctx).Debug().
+		Model(&entity.EmailVerificationToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
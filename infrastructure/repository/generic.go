@@ -3,7 +3,11 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
 
+	errs "myapp/core/helper/errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
 )
 
@@ -47,3 +51,86 @@ func Update[T any](ctx context.Context, tx *gorm.DB, defaultDB *gorm.DB, entity
 func Delete[T any](ctx context.Context, tx *gorm.DB, defaultDB *gorm.DB, id string) error {
 	return useDB(tx, defaultDB).WithContext(ctx).Debug().Delete(new(T), &id).Error
 }
+
+// ============== Soft-deleted rows ==============
+
+// uniqueViolations maps unique indexes to the error of the value taken. They
+// only cover rows that are not soft deleted, so a trashed row frees its
+// value and restoring it fails once the value is taken again.
+var uniqueViolations = map[string]error{
+	"uni_users_email":     errs.ErrEmailAlreadyExists,
+	"uni_products_sku":    errs.ErrProductSKUExists,
+	"uni_categories_name": errs.ErrCategoryNameExists,
+}
+
+// translateUniqueViolation turns a violation of a known unique index into
+// its error and leaves any other error as is.
+func translateUniqueViolation(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		if uniqueErr, ok := uniqueViolations[pgErr.ConstraintName]; ok {
+			return uniqueErr
+		}
+	}
+	return err
+}
+
+func GetTrashedByID[T any](ctx context.Context, tx *gorm.DB, defaultDB *gorm.DB,
+	id string, notFoundErr error) (T, error) {
+	var entity T
+
+	err := useDB(tx, defaultDB).WithContext(ctx).Debug().Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Take(&entity).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity, notFoundErr
+		}
+		return entity, err
+	}
+	return entity, nil
+}
+
+func GetTrashedBefore[T any](ctx context.Context, tx *gorm.DB, defaultDB *gorm.DB,
+	before time.Time, limit int) ([]T, error) {
+	var entities []T
+	err := useDB(tx, defaultDB).WithContext(ctx).Debug().Unscoped().
+		Where("deleted_at < ?", before).
+		Order("deleted_at").
+		Limit(limit).
+		Find(&entities).Error
+	return entities, err
+}
+
+func Restore[T any](ctx context.Context, tx *gorm.DB, defaultDB *gorm.DB, id string, notFoundErr error) error {
+	result := useDB(tx, defaultDB).WithContext(ctx).Debug().Unscoped().
+		Model(new(T)).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+
+	if result.Error != nil {
+		return translateUniqueViolation(result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return notFoundErr
+	}
+
+	return nil
+}
+
+func Purge[T any](ctx context.Context, tx *gorm.DB, defaultDB *gorm.DB, id string, notFoundErr error) error {
+	result := useDB(tx, defaultDB).WithContext(ctx).Debug().Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Delete(new(T))
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return notFoundErr
+	}
+
+	return nil
+}
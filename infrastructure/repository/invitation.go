@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"myapp/core/entity"
+	errs "myapp/core/helper/errors"
+
+	"gorm.io/gorm"
+)
+
+type invitationRepository struct {
+	db *gorm.DB
+}
+
+func NewInvitationRepository(db *gorm.DB) *invitationRepository {
+	return &invitationRepository{db: db}
+}
+
+func (rp *invitationRepository) DB() *gorm.DB {
+	return rp.db
+}
+
+func (rp *invitationRepository) CreateInvitation(ctx context.Context, tx *gorm.DB,
+	invitation entity.Invitation) (entity.Invitation, error) {
+	return Create(ctx, tx, rp.DB(), invitation)
+}
+
+func (rp *invitationRepository) GetAllInvitations(ctx context.Context, tx *gorm.DB) ([]entity.Invitation, error) {
+	var invitations []entity.Invitation
+
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().Order("created_at DESC").Find(&invitations).Error
+	if err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+func (rp *invitationRepository) GetInvitationByID(ctx context.Context, tx *gorm.DB,
+	id string) (entity.Invitation, error) {
+	var invitation entity.Invitation
+
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().Where("id = ?", id).Take(&invitation).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.Invitation{}, errs.ErrInvitationNotFound
+		}
+		return invitation, err
+	}
+	return invitation, nil
+}
+
+func (rp *invitationRepository) GetInvitationByHash(ctx context.Context, tx *gorm.DB,
+	hash string) (entity.Invitation, error) {
+	var invitation entity.Invitation
+
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().Where("token_hash = ?", hash).Take(&invitation).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.Invitation{}, errs.ErrInvitationInvalid
+		}
+		return invitation, err
+	}
+	return invitation, nil
+}
+
+func (rp *invitationRepository) GetPendingInvitationByEmail(ctx context.Context, tx *gorm.DB,
+	email string, now time.Time) (entity.Invitation, error) {
+	var invitation entity.Invitation
+
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().
+		Where("email = ? AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > ?", email, now).
+		Take(&invitation).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.Invitation{}, errs.ErrInvitationNotFound
+		}
+		return invitation, err
+	}
+	return invitation, nil
+}
+
+func (rp *invitationRepository) RenewInvitation(ctx context.Context, tx *gorm.DB, id string,
+	hash string, expiresAt time.Time) error {
+	result := useDB(tx, rp.db).WithContext(ctx).Debug().
+		Model(&entity.Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+		Updates(map[string]any{"token_hash": hash, "expires_at": expiresAt})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errs.ErrInvitationNotFound
+	}
+
+	return nil
+}
+
+// AcceptInvitation marks an open invitation as accepted. The update is
+// conditional so a link presented twice concurrently is only accepted once.
+func (rp *invitationRepository) AcceptInvitation(ctx context.Context, tx *gorm.DB, id string) error {
+	result := useDB(tx, rp.db).WithContext(ctx).Debug().
+		Model(&entity.Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+		Update("accepted_at", time.Now())
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errs.ErrInvitationInvalid
+	}
+
+	return nil
+}
+
+// RevokeInvitation marks an open invitation as revoked. The row is kept so
+// the invitation still shows up in the list.
+func (rp *invitationRepository) RevokeInvitation(ctx context.Context, tx *gorm.DB, id string) error {
+	result := useDB(tx, rp.db).WithContext(ctx).Debug().
+		Model(&entity.Invitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+		Update("revoked_at", time.Now())
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errs.ErrInvitationNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"myapp/core/entity"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type loginThrottleRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginThrottleRepository(db *gorm.DB) *loginThrottleRepository {
+	return &loginThrottleRepository{db: db}
+}
+
+func (rp *loginThrottleRepository) DB() *gorm.DB {
+	return rp.db
+}
+
+func (rp *loginThrottleRepository) GetLoginThrottles(ctx context.Context, tx *gorm.DB,
+	keys []string) ([]entity.LoginThrottle, error) {
+	var throttles []entity.LoginThrottle
+
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().Where("key IN ?", keys).Find(&throttles).Error
+	if err != nil {
+		return nil, err
+	}
+	return throttles, nil
+}
+
+// RecordLoginFailure counts a failed login in a single upsert, so concurrent
+// attempts are all counted. Failures from before windowStart are forgotten
+// and the count starts over.
+func (rp *loginThrottleRepository) RecordLoginFailure(ctx context.Context, tx *gorm.DB, key string,
+	now time.Time, windowStart time.Time) (entity.LoginThrottle, error) {
+	throttle := entity.LoginThrottle{
+		ID:            uuid.New(),
+		Key:           key,
+		Failures:      1,
+		LastFailureAt: now,
+	}
+
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().
+		Clauses(
+			clause.OnConflict{
+				Columns: []clause.Column{{Name: "key"}},
+				DoUpdates: clause.Assignments(map[string]any{
+					"failures": gorm.Expr("CASE WHEN login_throttles.last_failure_at < ? "+
+						"THEN 1 ELSE login_throttles.failures + 1 END", windowStart),
+					"last_failure_at": now,
+					"updated_at":      now,
+				}),
+			},
+			clause.Returning{},
+		).
+		Create(&throttle).Error
+	if err != nil {
+		return entity.LoginThrottle{}, err
+	}
+	return throttle, nil
+}
+
+func (rp *loginThrottleRepository) UpdateLoginThrottle(ctx context.Context, tx *gorm.DB,
+	throttle entity.LoginThrottle) error {
+	return useDB(tx, rp.db).WithContext(ctx).Debug().
+		Model(&entity.LoginThrottle{}).
+		Where("key = ?", throttle.Key).
+		Updates(map[string]any{
+			"next_attempt_at": throttle.NextAttemptAt,
+			"locked_until":    throttle.LockedUntil,
+		}).Error
+}
+
+// DeleteLoginThrottle forgets all failures of a key. The row is removed for
+// good since the unique key index also covers soft deleted rows.
+func (rp *loginThrottleRepository) DeleteLoginThrottle(ctx context.Context, tx *gorm.DB, key string) error {
+	return useDB(tx, rp.db).WithContext(ctx).Debug().Unscoped().
+		Where("key = ?", key).
+		Delete(&entity.LoginThrottle{}).Error
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"myapp/core/entity"
+	errs "myapp/core/helper/errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type oidcStateRepository struct {
+	db *gorm.DB
+}
+
+func NewOIDCStateRepository(db *gorm.DB) *oidcStateRepository {
+	return &oidcStateRepository{db: db}
+}
+
+func (rp *oidcStateRepository) DB() *gorm.DB {
+	return rp.db
+}
+
+func (rp *oidcStateRepository) CreateOIDCState(ctx context.Context, tx *gorm.DB,
+	state entity.OIDCState) (entity.OIDCState, error) {
+	return Create(ctx, tx, rp.DB(), state)
+}
+
+// ConsumeOIDCState deletes the state with the given hash and returns it, so a
+// state can complete at most one login even when the callback is replayed
+// concurrently. Expired states are swept on the way.
+func (rp *oidcStateRepository) ConsumeOIDCState(ctx context.Context, tx *gorm.DB,
+	hash string) (entity.OIDCState, error) {
+	db := useDB(tx, rp.db).WithContext(ctx).Debug()
+
+	if err := db.Unscoped().Where("expires_at <= ?", time.Now()).Delete(&entity.OIDCState{}).Error; err != nil {
+		return entity.OIDCState{}, err
+	}
+
+	var states []entity.OIDCState
+	result := db.Unscoped().Clauses(clause.Returning{}).
+		Where("state_hash = ?", hash).
+		Delete(&states)
+
+	if result.Error != nil {
+		return entity.OIDCState{}, result.Error
+	}
+
+	if result.RowsAffected == 0 || len(states) == 0 {
+		return entity.OIDCState{}, errs.ErrOIDCStateInvalid
+	}
+
+	return states[0], nil
+}
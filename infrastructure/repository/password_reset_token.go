@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"myapp/core/entity"
+	errs "myapp/core/helper/errors"
+
+	"gorm.io/gorm"
+)
+
+type passwordResetTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetTokenRepository(db *gorm.DB) *passwordResetTokenRepository {
+	return &passwordResetTokenRepository{db: db}
+}
+
+func (rp *passwordResetTokenRepository) DB() *gorm.DB {
+	return rp.db
+}
+
+func (rp *passwordResetTokenRepository) CreatePasswordResetToken(ctx context.Context, tx *gorm.DB,
+	token entity.PasswordResetToken) (entity.PasswordResetToken, error) {
+	return Create(ctx, tx, rp.DB(), token)
+}
+
+func (rp *passwordResetTokenRepository) GetPasswordResetTokenByHash(ctx context.Context, tx *gorm.DB,
+	hash string) (entity.PasswordResetToken, error) {
+	var token entity.PasswordResetToken
+
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().Where("token_hash = ?", hash).Take(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.PasswordResetToken{}, errs.ErrPasswordResetTokenInvalid
+		}
+		return token, err
+	}
+	return token, nil
+}
+
+// UsePasswordResetToken marks an unused token as used. The update is
+// conditional so a token presented twice concurrently is only accepted once.
+func (rp *passwordResetTokenRepository) UsePasswordResetToken(ctx context.Context, tx *gorm.DB, id string) error {
+	result := useDB(tx, rp.db).WithContext(ctx).Debug().
+		Model(&entity.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errs.ErrPasswordResetTokenInvalid
+	}
+
+	return nil
+}
+
+// InvalidateUserPasswordResetTokens marks every unused token of a user as
+// used, so only the most recently issued token can reset the password.
+func (rp *passwordResetTokenRepository) InvalidateUserPasswordResetTokens(ctx context.Context,
+	tx *gorm.DB, userID string) error {
+	return useDB(tx, rp.db).WithContext(ctx).Debug().
+		Model(&entity.PasswordResetToken{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Update("used_at", time.Now()).Error
+}
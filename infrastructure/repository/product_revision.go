@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"myapp/core/entity"
+	errs "myapp/core/helper/errors"
+
+	"gorm.io/gorm"
+)
+
+type productRevisionRepository struct {
+	db *gorm.DB
+}
+
+func NewProductRevisionRepository(db *gorm.DB) *productRevisionRepository {
+	return &productRevisionRepository{db: db}
+}
+
+func (rp *productRevisionRepository) DB() *gorm.DB {
+	return rp.db
+}
+
+// CreateProductRevision numbers the revision after the latest one of the
+// product. Callers update the product row first in the same transaction,
+// whose row lock keeps concurrent changes from taking the same number.
+func (rp *productRevisionRepository) CreateProductRevision(ctx context.Context, tx *gorm.DB,
+	revision entity.ProductRevision) (entity.ProductRevision, error) {
+	var last int
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().
+		Model(&entity.ProductRevision{}).
+		Where("product_id = ?", revision.ProductID).
+		Select("COALESCE(MAX(revision), 0)").
+		Scan(&last).Error
+	if err != nil {
+		return revision, err
+	}
+
+	revision.Revision = last + 1
+	return Create(ctx, tx, rp.DB(), revision)
+}
+
+func (rp *productRevisionRepository) GetProductRevisionAsOf(ctx context.Context, tx *gorm.DB,
+	productID string, asOf time.Time) (entity.ProductRevision, error) {
+	var revision entity.ProductRevision
+
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().
+		Where("product_id = ? AND created_at <= ?", productID, asOf).
+		Order("revision DESC").
+		Take(&revision).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.ProductRevision{}, errs.ErrProductNotFoundAsOf
+		}
+		return revision, err
+	}
+	return revision, nil
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"myapp/core/entity"
+	errs "myapp/core/helper/errors"
+
+	"gorm.io/gorm"
+)
+
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+func NewRecoveryCodeRepository(db *gorm.DB) *recoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+func (rp *recoveryCodeRepository) DB() *gorm.DB {
+	return rp.db
+}
+
+func (rp *recoveryCodeRepository) CreateRecoveryCodes(ctx context.Context, tx *gorm.DB,
+	codes []entity.RecoveryCode) error {
+	_, err := Create(ctx, tx, rp.DB(), codes)
+	return err
+}
+
+// UseRecoveryCode marks an unused code of the user as used. The update is
+// conditional so a code presented twice concurrently is only accepted once.
+func (rp *recoveryCodeRepository) UseRecoveryCode(ctx context.Context, tx *gorm.DB,
+	userID string, hash string) error {
+	result := useDB(tx, rp.db).WithContext(ctx).Debug().
+		Model(&entity.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errs.ErrTwoFactorCodeInvalid
+	}
+
+	return nil
+}
+
+func (rp *recoveryCodeRepository) DeleteUserRecoveryCodes(ctx context.Context, tx *gorm.DB, userID string) error {
+	return useDB(tx, rp.db).WithContext(ctx).Debug().Unscoped().
+		Where("user_id = ?", userID).
+		Delete(&entity.RecoveryCode{}).Error
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"myapp/core/entity"
+	errs "myapp/core/helper/errors"
+	"myapp/support/constant"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) *refreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+func (rp *refreshTokenRepository) DB() *gorm.DB {
+	return rp.db
+}
+
+func (rp *refreshTokenRepository) CreateRefreshToken(ctx context.Context, tx *gorm.DB,
+	token entity.RefreshToken) (entity.RefreshToken, error) {
+	return Create(ctx, tx, rp.DB(), token)
+}
+
+func (rp *refreshTokenRepository) GetRefreshTokenByHash(ctx context.Context, tx *gorm.DB,
+	hash string) (entity.RefreshToken, error) {
+	var token entity.RefreshToken
+
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().Where("token_hash = ?", hash).Take(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.RefreshToken{}, errs.ErrRefreshTokenInvalid
+		}
+		return token, err
+	}
+	return token, nil
+}
+
+// RevokeRefreshToken marks a still-active token as rotated. The update is
+// conditional on the token not being revoked yet, so when two requests race
+// to rotate the same token only one of them wins; the loser gets
+// ErrRefreshTokenReused.
+func (rp *refreshTokenRepository) RevokeRefreshToken(ctx context.Context, tx *gorm.DB,
+	id string, replacedBy *uuid.UUID) error {
+	result := useDB(tx, rp.db).WithContext(ctx).Debug().
+		Model(&entity.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]any{
+			"revoked_at":     time.Now(),
+			"revoked_reason": constant.EnumRefreshTokenRevokedRotated,
+			"replaced_by":    replacedBy,
+		})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errs.ErrRefreshTokenReused
+	}
+
+	return nil
+}
+
+// RevokeRefreshTokenFamily revokes every still-active token of a family.
+func (rp *refreshTokenRepository) RevokeRefreshTokenFamily(ctx context.Context, tx *gorm.DB,
+	familyID string, reason string) error {
+	return useDB(tx, rp.db).WithContext(ctx).Debug().
+		Model(&entity.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Updates(map[string]any{"revoked_at": time.Now(), "revoked_reason": reason}).Error
+}
+
+// RevokeUserRefreshTokens revokes every still-active token of a user.
+func (rp *refreshTokenRepository) RevokeUserRefreshTokens(ctx context.Context, tx *gorm.DB,
+	userID string, reason string) error {
+	return useDB(tx, rp.db).WithContext(ctx).Debug().
+		Model(&entity.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]any{"revoked_at": time.Now(), "revoked_reason": reason}).Error
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"myapp/core/entity"
+	errs "myapp/core/helper/errors"
+
+	"gorm.io/gorm"
+)
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) *roleRepository {
+	return &roleRepository{db: db}
+}
+
+func (rp *roleRepository) DB() *gorm.DB {
+	return rp.db
+}
+
+func (rp *roleRepository) CreateRole(ctx context.Context, tx *gorm.DB, role entity.Role) (entity.Role, error) {
+	return Create(ctx, tx, rp.DB(), role)
+}
+
+func (rp *roleRepository) GetAllRoles(ctx context.Context, tx *gorm.DB) ([]entity.Role, error) {
+	var roles []entity.Role
+
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().Preload("Permissions").Order("name").Find(&roles).Error
+	if err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (rp *roleRepository) GetRoleByPrimaryKey(ctx context.Context, tx *gorm.DB,
+	key string, val string) (entity.Role, error) {
+	var role entity.Role
+
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().Preload("Permissions").
+		Where(key+" = ?", val).Take(&role).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.Role{}, errs.ErrRoleNotFound
+		}
+		return role, err
+	}
+	return role, nil
+}
+
+func (rp *roleRepository) ReplaceRolePermissions(ctx context.Context, tx *gorm.DB, role entity.Role,
+	permissions []entity.Permission) error {
+	association := useDB(tx, rp.db).WithContext(ctx).Debug().Model(&role).Association("Permissions")
+	if len(permissions) == 0 {
+		return association.Clear()
+	}
+	return association.Replace(permissions)
+}
+
+// DeleteRole removes the role together with its permission assignments. The
+// row is removed for good since the unique name index also covers soft
+// deleted rows.
+func (rp *roleRepository) DeleteRole(ctx context.Context, tx *gorm.DB, role entity.Role) error {
+	db := useDB(tx, rp.db).WithContext(ctx).Debug()
+	if err := db.Model(&role).Association("Permissions").Clear(); err != nil {
+		return err
+	}
+	return db.Unscoped().Delete(&entity.Role{}, "id = ?", role.ID).Error
+}
+
+func (rp *roleRepository) IsRoleAssigned(ctx context.Context, tx *gorm.DB, name string) (bool, error) {
+	var count int64
+
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().Model(&entity.User{}).Where("role = ?", name).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (rp *roleRepository) RoleHasPermission(ctx context.Context, tx *gorm.DB,
+	name string, permission string) (bool, error) {
+	var count int64
+
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().
+		Table("role_permissions").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id AND roles.deleted_at IS NULL").
+		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id AND permissions.deleted_at IS NULL").
+		Where("roles.name = ? AND permissions.name = ?", name, permission).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (rp *roleRepository) GetAllPermissions(ctx context.Context, tx *gorm.DB) ([]entity.Permission, error) {
+	var permissions []entity.Permission
+
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().Order("name").Find(&permissions).Error
+	if err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
+
+func (rp *roleRepository) GetPermissionsByNames(ctx context.Context, tx *gorm.DB,
+	names []string) ([]entity.Permission, error) {
+	var permissions []entity.Permission
+
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().Where("name IN ?", names).Find(&permissions).Error
+	if err != nil {
+		return nil, err
+	}
+	return permissions, nil
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"myapp/core/entity"
+	errs "myapp/core/helper/errors"
+
+	"gorm.io/gorm"
+)
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) *sessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (rp *sessionRepository) DB() *gorm.DB {
+	return rp.db
+}
+
+func (rp *sessionRepository) CreateSession(ctx context.Context, tx *gorm.DB,
+	session entity.Session) (entity.Session, error) {
+	return Create(ctx, tx, rp.DB(), session)
+}
+
+// GetActiveUserSessions returns the sessions of a user that neither ended
+// nor expired, most recently used first.
+func (rp *sessionRepository) GetActiveUserSessions(ctx context.Context, tx *gorm.DB,
+	userID string) ([]entity.Session, error) {
+	var sessions []entity.Session
+
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().
+		Where("user_id = ? AND ended_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// TouchSession records activity on a session unless last_seen_at is still
+// newer than staleBefore.
+func (rp *sessionRepository) TouchSession(ctx context.Context, tx *gorm.DB, id string,
+	now time.Time, staleBefore time.Time) error {
+	return useDB(tx, rp.db).WithContext(ctx).Debug().
+		Model(&entity.Session{}).
+		Where("id = ? AND ended_at IS NULL AND last_seen_at < ?", id, staleBefore).
+		Update("last_seen_at", now).Error
+}
+
+// ExtendSession moves the expiry of a session along with its newest refresh
+// token. Sessions that ended or never existed are left alone.
+func (rp *sessionRepository) ExtendSession(ctx context.Context, tx *gorm.DB, id string,
+	now time.Time, expiresAt time.Time) error {
+	return useDB(tx, rp.db).WithContext(ctx).Debug().
+		Model(&entity.Session{}).
+		Where("id = ? AND ended_at IS NULL", id).
+		Updates(map[string]any{
+			"last_seen_at": now,
+			"expires_at":   expiresAt,
+		}).Error
+}
+
+// EndSession ends an active session of the given user. Sessions of other
+// users are reported as not found.
+func (rp *sessionRepository) EndSession(ctx context.Context, tx *gorm.DB, userID string, id string) error {
+	result := useDB(tx, rp.db).WithContext(ctx).Debug().
+		Model(&entity.Session{}).
+		Where("id = ? AND user_id = ? AND ended_at IS NULL", id, userID).
+		Update("ended_at", time.Now())
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errs.ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// EndUserSessions ends every active session of a user.
+func (rp *sessionRepository) EndUserSessions(ctx context.Context, tx *gorm.DB, userID string) error {
+	return useDB(tx, rp.db).WithContext(ctx).Debug().
+		Model(&entity.Session{}).
+		Where("user_id = ? AND ended_at IS NULL", userID).
+		Update("ended_at", time.Now()).Error
+}
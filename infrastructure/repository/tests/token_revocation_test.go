@@ -0,0 +1,57 @@
+package repository_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"myapp/core/entity"
+	"myapp/infrastructure/repository"
+	"myapp/support/constant"
+	support "myapp/tests/testutil"
+	"myapp/tests/testutil/factory"
+
+	"github.com/stretchr/testify/require"
+)
+
+// --- Tests ---
+
+func TestTokenRevocationRepository_PasswordChange(t *testing.T) {
+	db := support.NewTestDB(t)
+	ur := repository.NewUserRepository(db)
+	// a zero cache TTL reloads the snapshot on every lookup
+	rr := repository.NewTokenRevocationRepository(db, 0, time.Hour)
+	ctx := context.Background()
+
+	seed := factory.SeedUsers(t, ur, 1)[0]
+	userID := seed.ID.String()
+	issuedAt := time.Now().Add(-time.Minute).Truncate(time.Second)
+
+	revoked, err := rr.IsTokenRevoked(ctx, userID, "jti-1", "", issuedAt)
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	require.NoError(t, ur.UpdateUser(ctx, nil, entity.User{ID: seed.ID, Password: "new-password"}))
+
+	revoked, err = rr.IsTokenRevoked(ctx, userID, "jti-1", "", issuedAt)
+	require.NoError(t, err)
+	require.True(t, revoked)
+
+	revoked, err = rr.IsTokenRevoked(ctx, userID, "jti-2", "", time.Now().Add(time.Second))
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	// "iat" has whole seconds, so a token from the second of the change,
+	// like one of the log in right after it, is still accepted
+	user, err := ur.GetUserByPrimaryKey(ctx, nil, constant.DBAttrID, userID)
+	require.NoError(t, err)
+	changedAt := *user.PasswordChangedAt
+
+	revoked, err = rr.IsTokenRevoked(ctx, userID, "jti-3", "", changedAt.Truncate(time.Second))
+	require.NoError(t, err)
+	require.False(t, revoked)
+
+	revoked, err = rr.IsTokenRevoked(ctx, userID, "jti-4", "", changedAt.Truncate(time.Second).Add(-time.Second))
+	require.NoError(t, err)
+	require.True(t, revoked)
+}
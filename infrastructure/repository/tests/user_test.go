@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"myapp/core/entity"
+	errs "myapp/core/helper/errors"
 	repositoryiface "myapp/core/interface/repository"
 	"myapp/infrastructure/repository"
 	"myapp/support/constant"
@@ -87,3 +88,24 @@ func TestUserRepository_DeleteUserByID(t *testing.T) {
 	_, err = ur.GetUserByPrimaryKey(ctx, nil, constant.DBAttrID, seed.ID.String())
 	require.Error(t, err)
 }
+
+func TestUserRepository_EmailUniqueAmongLiveUsers(t *testing.T) {
+	db := support.NewTestDB(t)
+	ur := repository.NewUserRepository(db)
+	tr := repository.NewTrashRepository(db)
+	ctx := context.Background()
+
+	trashed := factory.SeedUser(t, ur, "Old Account", "reuse@mail.com", "password", constant.EnumRoleUser)
+	_, err := ur.CreateNewUser(ctx, nil, entity.User{
+		ID: uuid.New(), Name: "Twin", Email: trashed.Email, Password: "password", Role: constant.EnumRoleUser,
+	})
+	require.Error(t, err)
+
+	// A trashed account frees its email
+	require.NoError(t, ur.DeleteUserByID(ctx, nil, trashed.ID.String()))
+	factory.SeedUser(t, ur, "New Account", trashed.Email, "password", constant.EnumRoleUser)
+
+	// and cannot come back while it is taken
+	err = tr.RestoreUserByID(ctx, nil, trashed.ID.String())
+	require.ErrorIs(t, err, errs.ErrEmailAlreadyExists)
+}
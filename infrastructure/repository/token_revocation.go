@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"myapp/core/entity"
+
+	"gorm.io/gorm"
+)
+
+// tokenRevocationRepository persists revocations in Postgres and answers
+// lookups from an in-memory snapshot of all unexpired rows, so checking a
+// token on every request does not cost a query. The snapshot is reloaded
+// once it is older than cacheTTL, which bounds how long a revocation made on
+// another replica can go unnoticed.
+//
+// A password change revokes every token issued before it as well, see
+// entity.PasswordChangeCutoff. The snapshot picks up users whose password
+// changed within maxTokenAge, the lifetime of an access token, so tokens are
+// rejected within cacheTTL even when no revocation row was written for the
+// change.
+type tokenRevocationRepository struct {
+	db          *gorm.DB
+	cacheTTL    time.Duration
+	maxTokenAge time.Duration
+
+	loadMu   sync.Mutex
+	mu       sync.RWMutex
+	tokens   map[string]time.Time // jti -> expires at
+	sessions map[string]time.Time // session id -> expires at
+	users    map[string]time.Time // user id -> revoked before
+	loadedAt time.Time
+}
+
+func NewTokenRevocationRepository(db *gorm.DB, cacheTTL time.Duration,
+	maxTokenAge time.Duration) *tokenRevocationRepository {
+	return &tokenRevocationRepository{
+		db:          db,
+		cacheTTL:    cacheTTL,
+		maxTokenAge: maxTokenAge,
+		tokens:      map[string]time.Time{},
+		sessions:    map[string]time.Time{},
+		users:       map[string]time.Time{},
+	}
+}
+
+func (rp *tokenRevocationRepository) DB() *gorm.DB {
+	return rp.db
+}
+
+func (rp *tokenRevocationRepository) CreateTokenRevocation(ctx context.Context, tx *gorm.DB,
+	revocation entity.TokenRevocation) error {
+	if _, err := Create(ctx, tx, rp.DB(), revocation); err != nil {
+		return err
+	}
+
+	// Make the revocation visible on this replica right away instead of
+	// waiting for the next reload.
+	rp.mu.Lock()
+	rp.remember(revocation)
+	rp.mu.Unlock()
+	return nil
+}
+
+func (rp *tokenRevocationRepository) IsTokenRevoked(ctx context.Context, userID string,
+	tokenID string, sessionID string, issuedAt time.Time) (bool, error) {
+	if err := rp.reloadIfStale(ctx); err != nil {
+		return false, err
+	}
+
+	rp.mu.RLock()
+	defer rp.mu.RUnlock()
+
+	if expiresAt, ok := rp.tokens[tokenID]; ok && time.Now().Before(expiresAt) {
+		return true, nil
+	}
+	if expiresAt, ok := rp.sessions[sessionID]; ok && sessionID != "" && time.Now().Before(expiresAt) {
+		return true, nil
+	}
+	if revokedBefore, ok := rp.users[userID]; ok && !issuedAt.After(revokedBefore) {
+		return true, nil
+	}
+	return false, nil
+}
+
+// remember adds a revocation to the snapshot. Callers must hold mu.
+func (rp *tokenRevocationRepository) remember(revocation entity.TokenRevocation) {
+	if revocation.TokenID != nil {
+		rp.tokens[*revocation.TokenID] = revocation.ExpiresAt
+	}
+	if revocation.SessionID != nil {
+		rp.sessions[revocation.SessionID.String()] = revocation.ExpiresAt
+	}
+	if revocation.RevokedBefore != nil {
+		rp.rememberUser(revocation.UserID.String(), *revocation.RevokedBefore)
+	}
+}
+
+// rememberUser revokes the tokens of a user issued up to revokedBefore,
+// keeping the latest cut-off. Callers must hold mu.
+func (rp *tokenRevocationRepository) rememberUser(userID string, revokedBefore time.Time) {
+	if current, ok := rp.users[userID]; !ok || revokedBefore.After(current) {
+		rp.users[userID] = revokedBefore
+	}
+}
+
+func (rp *tokenRevocationRepository) isStale() bool {
+	rp.mu.RLock()
+	defer rp.mu.RUnlock()
+	return time.Since(rp.loadedAt) >= rp.cacheTTL
+}
+
+func (rp *tokenRevocationRepository) reloadIfStale(ctx context.Context) error {
+	if !rp.isStale() {
+		return nil
+	}
+
+	// Only one goroutine reloads; the others wait and reuse its result.
+	rp.loadMu.Lock()
+	defer rp.loadMu.Unlock()
+	if !rp.isStale() {
+		return nil
+	}
+
+	now := time.Now()
+	db := rp.db.WithContext(ctx).Debug()
+
+	// Expired rows no longer cover any valid token
+	if err := db.Unscoped().Where("expires_at <= ?", now).Delete(&entity.TokenRevocation{}).Error; err != nil {
+		return err
+	}
+
+	var revocations []entity.TokenRevocation
+	if err := db.Where("expires_at > ?", now).Find(&revocations).Error; err != nil {
+		return err
+	}
+
+	// Tokens issued before an older password change are expired anyway
+	var passwordChanges []entity.User
+	if err := db.Select("id", "password_changed_at").
+		Where("password_changed_at > ?", now.Add(-rp.maxTokenAge)).
+		Find(&passwordChanges).Error; err != nil {
+		return err
+	}
+
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	rp.tokens = make(map[string]time.Time, len(revocations))
+	rp.sessions = map[string]time.Time{}
+	rp.users = map[string]time.Time{}
+	for _, revocation := range revocations {
+		rp.remember(revocation)
+	}
+	for _, user := range passwordChanges {
+		rp.rememberUser(user.ID.String(), entity.PasswordChangeCutoff(*user.PasswordChangedAt))
+	}
+	rp.loadedAt = now
+	return nil
+}
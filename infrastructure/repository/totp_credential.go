@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"myapp/core/entity"
+	errs "myapp/core/helper/errors"
+
+	"gorm.io/gorm"
+)
+
+type totpCredentialRepository struct {
+	db *gorm.DB
+}
+
+func NewTOTPCredentialRepository(db *gorm.DB) *totpCredentialRepository {
+	return &totpCredentialRepository{db: db}
+}
+
+func (rp *totpCredentialRepository) DB() *gorm.DB {
+	return rp.db
+}
+
+func (rp *totpCredentialRepository) CreateTOTPCredential(ctx context.Context, tx *gorm.DB,
+	credential entity.TOTPCredential) (entity.TOTPCredential, error) {
+	return Create(ctx, tx, rp.DB(), credential)
+}
+
+func (rp *totpCredentialRepository) GetTOTPCredentialByUserID(ctx context.Context, tx *gorm.DB,
+	userID string) (entity.TOTPCredential, error) {
+	var credential entity.TOTPCredential
+
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().Where("user_id = ?", userID).Take(&credential).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.TOTPCredential{}, errs.ErrTwoFactorNotEnrolled
+		}
+		return credential, err
+	}
+	return credential, nil
+}
+
+// ConfirmTOTPCredential completes an enrollment with the step of its first
+// valid code. The update is conditional so an enrollment is confirmed once.
+func (rp *totpCredentialRepository) ConfirmTOTPCredential(ctx context.Context, tx *gorm.DB,
+	id string, step int64) error {
+	result := useDB(tx, rp.db).WithContext(ctx).Debug().
+		Model(&entity.TOTPCredential{}).
+		Where("id = ? AND confirmed_at IS NULL", id).
+		Updates(map[string]any{"confirmed_at": time.Now(), "last_used_step": step})
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errs.ErrTwoFactorAlreadyEnabled
+	}
+
+	return nil
+}
+
+// UseTOTPStep records the step of an accepted code. It fails for steps at or
+// before the last accepted one, so each code works only once.
+func (rp *totpCredentialRepository) UseTOTPStep(ctx context.Context, tx *gorm.DB, id string, step int64) error {
+	result := useDB(tx, rp.db).WithContext(ctx).Debug().
+		Model(&entity.TOTPCredential{}).
+		Where("id = ? AND last_used_step < ?", id, step).
+		Update("last_used_step", step)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errs.ErrTwoFactorCodeInvalid
+	}
+
+	return nil
+}
+
+// DeleteUserTOTPCredential removes the credential for good, the unique user
+// index would otherwise block a new enrollment.
+func (rp *totpCredentialRepository) DeleteUserTOTPCredential(ctx context.Context, tx *gorm.DB,
+	userID string) error {
+	return useDB(tx, rp.db).WithContext(ctx).Debug().Unscoped().
+		Where("user_id = ?", userID).
+		Delete(&entity.TOTPCredential{}).Error
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"myapp/core/entity"
+	errs "myapp/core/helper/errors"
+
+	"gorm.io/gorm"
+)
+
+type trashRepository struct {
+	db *gorm.DB
+}
+
+func NewTrashRepository(db *gorm.DB) *trashRepository {
+	return &trashRepository{db: db}
+}
+
+func (rp *trashRepository) DB() *gorm.DB {
+	return rp.db
+}
+
+func (rp *trashRepository) GetTrashedUserByID(ctx context.Context, tx *gorm.DB, id string) (entity.User, error) {
+	return GetTrashedByID[entity.User](ctx, tx, rp.DB(), id, errs.ErrUserNotFound)
+}
+
+func (rp *trashRepository) GetTrashedProductByID(ctx context.Context, tx *gorm.DB, id string) (entity.Product, error) {
+	return GetTrashedByID[entity.Product](ctx, tx, rp.DB(), id, errs.ErrProductNotFound)
+}
+
+func (rp *trashRepository) GetTrashedCategoryByID(ctx context.Context, tx *gorm.DB, id string) (entity.Category, error) {
+	return GetTrashedByID[entity.Category](ctx, tx, rp.DB(), id, errs.ErrCategoryNotFound)
+}
+
+func (rp *trashRepository) RestoreUserByID(ctx context.Context, tx *gorm.DB, id string) error {
+	return Restore[entity.User](ctx, tx, rp.DB(), id, errs.ErrUserNotFound)
+}
+
+func (rp *trashRepository) RestoreProductByID(ctx context.Context, tx *gorm.DB, id string) error {
+	return Restore[entity.Product](ctx, tx, rp.DB(), id, errs.ErrProductNotFound)
+}
+
+func (rp *trashRepository) RestoreCategoryByID(ctx context.Context, tx *gorm.DB, id string) error {
+	return Restore[entity.Category](ctx, tx, rp.DB(), id, errs.ErrCategoryNotFound)
+}
+
+func (rp *trashRepository) PurgeUserByID(ctx context.Context, tx *gorm.DB, id string) error {
+	if err := Purge[entity.User](ctx, tx, rp.DB(), id, errs.ErrUserNotFound); err != nil {
+		return err
+	}
+	return deleteUserCredentials(useDB(tx, rp.db).WithContext(ctx).Debug(), id)
+}
+
+func (rp *trashRepository) PurgeProductByID(ctx context.Context, tx *gorm.DB, id string) error {
+	if err := Purge[entity.Product](ctx, tx, rp.DB(), id, errs.ErrProductNotFound); err != nil {
+		return err
+	}
+	return useDB(tx, rp.db).WithContext(ctx).Debug().Unscoped().
+		Where("product_id = ?", id).
+		Delete(&entity.ProductRevision{}).Error
+}
+
+func (rp *trashRepository) PurgeCategoryByID(ctx context.Context, tx *gorm.DB, id string) error {
+	var products int64
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().Unscoped().
+		Model(&entity.Product{}).
+		Where("category_id = ?", id).
+		Count(&products).Error
+	if err != nil {
+		return err
+	}
+	if products > 0 {
+		return errs.ErrCategoryHasProducts
+	}
+
+	return Purge[entity.Category](ctx, tx, rp.DB(), id, errs.ErrCategoryNotFound)
+}
+
+func (rp *trashRepository) GetUsersTrashedBefore(ctx context.Context, tx *gorm.DB,
+	before time.Time, limit int) ([]entity.User, error) {
+	return GetTrashedBefore[entity.User](ctx, tx, rp.DB(), before, limit)
+}
+
+func (rp *trashRepository) GetProductsTrashedBefore(ctx context.Context, tx *gorm.DB,
+	before time.Time, limit int) ([]entity.Product, error) {
+	return GetTrashedBefore[entity.Product](ctx, tx, rp.DB(), before, limit)
+}
+
+func (rp *trashRepository) GetCategoriesTrashedBefore(ctx context.Context, tx *gorm.DB,
+	before time.Time, limit int) ([]entity.Category, error) {
+	var categories []entity.Category
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().Unscoped().
+		Where("deleted_at < ?", before).
+		Where("NOT EXISTS (SELECT 1 FROM products WHERE products.category_id = categories.id)").
+		Order("deleted_at").
+		Limit(limit).
+		Find(&categories).Error
+	return categories, err
+}
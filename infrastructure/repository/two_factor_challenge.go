@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"myapp/core/entity"
+	errs "myapp/core/helper/errors"
+
+	"gorm.io/gorm"
+)
+
+type twoFactorChallengeRepository struct {
+	db *gorm.DB
+}
+
+func NewTwoFactorChallengeRepository(db *gorm.DB) *twoFactorChallengeRepository {
+	return &twoFactorChallengeRepository{db: db}
+}
+
+func (rp *twoFactorChallengeRepository) DB() *gorm.DB {
+	return rp.db
+}
+
+func (rp *twoFactorChallengeRepository) CreateTwoFactorChallenge(ctx context.Context, tx *gorm.DB,
+	challenge entity.TwoFactorChallenge) (entity.TwoFactorChallenge, error) {
+	return Create(ctx, tx, rp.DB(), challenge)
+}
+
+func (rp *twoFactorChallengeRepository) GetTwoFactorChallengeByHash(ctx context.Context, tx *gorm.DB,
+	hash string) (entity.TwoFactorChallenge, error) {
+	var challenge entity.TwoFactorChallenge
+
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().Where("token_hash = ?", hash).Take(&challenge).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.TwoFactorChallenge{}, errs.ErrTwoFactorChallengeInvalid
+		}
+		return challenge, err
+	}
+	return challenge, nil
+}
+
+// AttemptTwoFactorChallenge counts one code attempt against an open
+// challenge and fails once maxAttempts are used up, which caps how many
+// codes can be guessed per password login.
+func (rp *twoFactorChallengeRepository) AttemptTwoFactorChallenge(ctx context.Context, tx *gorm.DB,
+	id string, maxAttempts int) error {
+	result := useDB(tx, rp.db).WithContext(ctx).Debug().
+		Model(&entity.TwoFactorChallenge{}).
+		Where("id = ? AND used_at IS NULL AND attempts < ?", id, maxAttempts).
+		Update("attempts", gorm.Expr("attempts + 1"))
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errs.ErrTwoFactorChallengeInvalid
+	}
+
+	return nil
+}
+
+// UseTwoFactorChallenge marks an open challenge as used. The update is
+// conditional so a challenge completes at most one login.
+func (rp *twoFactorChallengeRepository) UseTwoFactorChallenge(ctx context.Context, tx *gorm.DB, id string) error {
+	result := useDB(tx, rp.db).WithContext(ctx).Debug().
+		Model(&entity.TwoFactorChallenge{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errs.ErrTwoFactorChallengeInvalid
+	}
+
+	return nil
+}
@@ -3,9 +3,12 @@ package repository
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"myapp/core/entity"
 	errs "myapp/core/helper/errors"
+	"myapp/support/constant"
 
 	"gorm.io/gorm"
 )
@@ -48,6 +51,98 @@ func (ur *userRepository) UpdateUser(ctx context.Context, tx *gorm.DB, user enti
 	return Update(ctx, tx, ur.DB(), &user)
 }
 
+func (ur *userRepository) UpdateUserPasswordHash(ctx context.Context, tx *gorm.DB,
+	id string, hash string) error {
+	// UpdateColumn skips the hooks that would hash the value again
+	return useDB(tx, ur.db).WithContext(ctx).Debug().
+		Model(&entity.User{}).
+		Where("id = ?", id).
+		UpdateColumn("password", hash).Error
+}
+
 func (ur *userRepository) DeleteUserByID(ctx context.Context, tx *gorm.DB, id string) error {
 	return Delete[entity.User](ctx, tx, ur.DB(), id)
 }
+
+func (ur *userRepository) ScheduleUserDeletion(ctx context.Context, tx *gorm.DB,
+	id string, at time.Time) error {
+	result := useDB(tx, ur.db).WithContext(ctx).Debug().
+		Model(&entity.User{}).
+		Where("id = ? AND scheduled_deletion_at IS NULL", id).
+		UpdateColumn("scheduled_deletion_at", at)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errs.ErrUserDeletionAlreadyScheduled
+	}
+
+	return nil
+}
+
+func (ur *userRepository) CancelUserDeletion(ctx context.Context, tx *gorm.DB, id string) error {
+	result := useDB(tx, ur.db).WithContext(ctx).Debug().
+		Model(&entity.User{}).
+		Where("id = ? AND scheduled_deletion_at IS NOT NULL", id).
+		UpdateColumn("scheduled_deletion_at", nil)
+
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected == 0 {
+		return errs.ErrUserDeletionNotScheduled
+	}
+
+	return nil
+}
+
+func (ur *userRepository) GetUsersDueForDeletion(ctx context.Context, tx *gorm.DB,
+	now time.Time, limit int) ([]entity.User, error) {
+	var users []entity.User
+	err := useDB(tx, ur.db).WithContext(ctx).Debug().
+		Where("scheduled_deletion_at <= ?", now).
+		Order("scheduled_deletion_at").
+		Limit(limit).
+		Find(&users).Error
+	return users, err
+}
+
+func (ur *userRepository) PurgeUser(ctx context.Context, tx *gorm.DB, user entity.User) error {
+	db := useDB(tx, ur.db).WithContext(ctx).Debug()
+
+	// Only rows still pending deletion, so a cancel racing the purge wins
+	result := db.Model(&entity.User{}).
+		Where("id = ? AND scheduled_deletion_at IS NOT NULL", user.ID).
+		UpdateColumns(map[string]any{
+			"name":              "Deleted user",
+			"email":             fmt.Sprintf("deleted-%s@%s", user.ID, constant.AnonymizedEmailDomain),
+			"password":          "",
+			"email_verified_at": nil,
+			"deleted_at":        time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errs.ErrUserDeletionNotScheduled
+	}
+
+	return deleteUserCredentials(db, user.ID.String())
+}
+
+// deleteUserCredentials drops the sessions, which keep IP addresses and
+// user agents, and the credentials of a user.
+func deleteUserCredentials(db *gorm.DB, userID string) error {
+	for _, model := range []any{
+		&entity.Session{}, &entity.RefreshToken{}, &entity.TOTPCredential{}, &entity.RecoveryCode{},
+		&entity.TwoFactorChallenge{}, &entity.PasswordResetToken{}, &entity.EmailVerificationToken{},
+	} {
+		if err := db.Unscoped().Where("user_id = ?", userID).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
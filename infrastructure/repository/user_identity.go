@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"myapp/core/entity"
+	errs "myapp/core/helper/errors"
+
+	"gorm.io/gorm"
+)
+
+type userIdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewUserIdentityRepository(db *gorm.DB) *userIdentityRepository {
+	return &userIdentityRepository{db: db}
+}
+
+func (rp *userIdentityRepository) DB() *gorm.DB {
+	return rp.db
+}
+
+func (rp *userIdentityRepository) CreateUserIdentity(ctx context.Context, tx *gorm.DB,
+	identity entity.UserIdentity) (entity.UserIdentity, error) {
+	return Create(ctx, tx, rp.DB(), identity)
+}
+
+func (rp *userIdentityRepository) GetUserIdentity(ctx context.Context, tx *gorm.DB,
+	provider string, subject string) (entity.UserIdentity, error) {
+	var identity entity.UserIdentity
+
+	err := useDB(tx, rp.db).WithContext(ctx).Debug().
+		Where("provider = ? AND subject = ?", provider, subject).
+		Take(&identity).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return entity.UserIdentity{}, errs.ErrOIDCIdentityNotFound
+		}
+		return identity, err
+	}
+	return identity, nil
+}
+
+// DeleteUserIdentity removes the identity for good, so its subject can be
+// linked again.
+func (rp *userIdentityRepository) DeleteUserIdentity(ctx context.Context, tx *gorm.DB, id string) error {
+	return useDB(tx, rp.db).WithContext(ctx).Debug().
+		Unscoped().Where("id = ?", id).Delete(&entity.UserIdentity{}).Error
+}
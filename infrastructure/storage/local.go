@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	errs "myapp/core/helper/errors"
+	storageiface "myapp/core/interface/storage"
+)
+
+// localStorage keeps files on the local disk below dir. Every replica of the
+// API needs to see the same directory, so it only suits single instance
+// deployments, development and tests.
+type localStorage struct {
+	dir string
+}
+
+func NewLocalStorage(dir string) *localStorage {
+	return &localStorage{dir: dir}
+}
+
+// path maps key into dir, refusing keys that could point outside of it.
+func (s *localStorage) path(key string) (string, error) {
+	if !fs.ValidPath(key) || key == "." {
+		return "", errs.ErrFileInvalidKey
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// Put writes to a temporary file first and renames it into place, so a
+// failed upload never leaves a truncated file under key.
+func (s *localStorage) Put(_ context.Context, key string, r io.Reader, _ int64, _ string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (s *localStorage) Get(_ context.Context, key string) (storageiface.Object, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return storageiface.Object{}, err
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return storageiface.Object{}, errs.ErrFileNotFound
+	}
+	if err != nil {
+		return storageiface.Object{}, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return storageiface.Object{}, err
+	}
+	if info.IsDir() {
+		_ = file.Close()
+		return storageiface.Object{}, errs.ErrFileNotFound
+	}
+
+	return storageiface.Object{
+		Body:    file,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+		ETag:    fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()),
+	}, nil
+}
+
+func (s *localStorage) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+		return errs.ErrFileNotFound
+	}
+
+	if err := os.Remove(path); err != nil {
+		return errs.ErrFileDeleteFailed
+	}
+	return nil
+}
@@ -0,0 +1,340 @@
+package storage
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	errs "myapp/core/helper/errors"
+	storageiface "myapp/core/interface/storage"
+)
+
+type S3Config struct {
+	// Endpoint is the base URL of the service, like
+	// https://s3.eu-central-1.amazonaws.com or http://localhost:9000
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PathStyle addresses objects as <endpoint>/<bucket>/<key> instead of
+	// <bucket>.<endpoint host>/<key>, which MinIO and most other
+	// self-hosted S3 compatible servers expect.
+	PathStyle bool
+}
+
+// s3Storage keeps files in a bucket of an S3 compatible object store, so
+// all replicas of the API share them. Requests are signed with AWS
+// Signature Version 4; payloads are sent unsigned to allow streaming them.
+type s3Storage struct {
+	cfg      S3Config
+	endpoint *url.URL
+	client   *http.Client
+}
+
+func NewS3Storage(cfg S3Config) (*s3Storage, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("missing S3 bucket")
+	}
+
+	return &s3Storage{
+		cfg:      cfg,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+func (s *s3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	if size == 0 {
+		// Otherwise sent chunked, which S3 refuses
+		r = nil
+	}
+
+	resp, err := s.do(ctx, http.MethodPut, key, r, size, header)
+	if err != nil {
+		return err
+	}
+	defer drain(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return s3Error(resp, http.MethodPut, key)
+	}
+	return nil
+}
+
+func (s *s3Storage) Get(ctx context.Context, key string) (storageiface.Object, error) {
+	resp, err := s.do(ctx, http.MethodGet, key, nil, 0, nil)
+	if err != nil {
+		return storageiface.Object{}, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		drain(resp)
+		return storageiface.Object{}, errs.ErrFileNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer drain(resp)
+		return storageiface.Object{}, s3Error(resp, http.MethodGet, key)
+	}
+
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return storageiface.Object{
+		Body: &s3Body{
+			storage: s,
+			ctx:     ctx,
+			key:     key,
+			size:    resp.ContentLength,
+			body:    resp.Body,
+		},
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+		ModTime:     modTime,
+		ETag:        resp.Header.Get("ETag"),
+	}, nil
+}
+
+// Delete checks for the object first, since S3 reports success when
+// deleting a key that does not exist.
+func (s *s3Storage) Delete(ctx context.Context, key string) error {
+	resp, err := s.do(ctx, http.MethodHead, key, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	drain(resp)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errs.ErrFileNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return s3Error(resp, http.MethodHead, key)
+	}
+
+	resp, err = s.do(ctx, http.MethodDelete, key, nil, 0, nil)
+	if err != nil {
+		return err
+	}
+	defer drain(resp)
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return errs.ErrFileDeleteFailed
+	}
+	return nil
+}
+
+// s3Body reads an object, starting with the body of the GET that found it.
+// Reading after a seek elsewhere requests the rest of the object from the
+// new offset with a Range header. It keeps the context of Get, since the
+// requests belong to that call.
+type s3Body struct {
+	storage *s3Storage
+	ctx     context.Context
+	key     string
+	size    int64
+	offset  int64
+
+	body       io.ReadCloser
+	bodyOffset int64
+}
+
+func (b *s3Body) Read(p []byte) (int, error) {
+	if b.offset >= b.size {
+		return 0, io.EOF
+	}
+
+	if b.body != nil && b.bodyOffset != b.offset {
+		_ = b.body.Close()
+		b.body = nil
+	}
+	if b.body == nil {
+		header := http.Header{}
+		header.Set("Range", fmt.Sprintf("bytes=%d-", b.offset))
+		resp, err := b.storage.do(b.ctx, http.MethodGet, b.key, nil, 0, header)
+		if err != nil {
+			return 0, err
+		}
+		if resp.StatusCode != http.StatusPartialContent {
+			defer drain(resp)
+			return 0, s3Error(resp, http.MethodGet, b.key)
+		}
+		b.body = resp.Body
+		b.bodyOffset = b.offset
+	}
+
+	n, err := b.body.Read(p)
+	b.offset += int64(n)
+	b.bodyOffset += int64(n)
+	return n, err
+}
+
+// Seek only moves the offset; the next Read decides whether the open body
+// can be kept.
+func (b *s3Body) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += b.offset
+	case io.SeekEnd:
+		offset += b.size
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("s3 seek %s: negative offset", b.key)
+	}
+	b.offset = offset
+	return offset, nil
+}
+
+func (b *s3Body) Close() error {
+	if b.body == nil {
+		return nil
+	}
+	return b.body.Close()
+}
+
+// do sends a signed request for the object under key.
+func (s *s3Storage) do(ctx context.Context, method string, key string, body io.Reader, size int64,
+	header http.Header) (*http.Response, error) {
+	if !fs.ValidPath(key) || key == "." {
+		return nil, errs.ErrFileInvalidKey
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(key).String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	s.sign(req, time.Now())
+	return s.client.Do(req)
+}
+
+func (s *s3Storage) objectURL(key string) *url.URL {
+	u := *s.endpoint
+	path := strings.TrimSuffix(s.endpoint.Path, "/")
+	if s.cfg.PathStyle {
+		path += "/" + s.cfg.Bucket
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+	}
+	path += "/" + key
+
+	u.Path = path
+	u.RawPath = s3EscapePath(path)
+	return &u
+}
+
+// sign adds the AWS Signature Version 4 headers to req.
+func (s *s3Storage) sign(req *http.Request, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	scope := strings.Join([]string{date, s.cfg.Region, "s3", "aws4_request"}, "/")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", "UNSIGNED-PAYLOAD")
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		"UNSIGNED-PAYLOAD",
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+s.cfg.SecretAccessKey), date)
+	for _, part := range []string{s.cfg.Region, "s3", "aws4_request"} {
+		signingKey = hmacSHA256(signingKey, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3EscapePath percent-encodes everything but unreserved characters and
+// slashes, as the canonical request of Signature Version 4 requires.
+func s3EscapePath(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if c == '/' || c == '-' || c == '_' || c == '.' || c == '~' ||
+			('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9') {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// s3Error describes an unexpected response, including the error code S3
+// puts in the XML body.
+func s3Error(resp *http.Response, method string, key string) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	code := ""
+	if start := strings.Index(string(body), "<Code>"); start >= 0 {
+		if end := strings.Index(string(body[start:]), "</Code>"); end >= 0 {
+			code = ": " + string(body[start+len("<Code>"):start+end])
+		}
+	}
+	return fmt.Errorf("s3 %s %s: %s%s", method, key, resp.Status, code)
+}
+
+// drain reads the rest of the body so the connection can be reused.
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,154 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	errs "myapp/core/helper/errors"
+	storageiface "myapp/core/interface/storage"
+	"myapp/infrastructure/storage"
+	support "myapp/tests/testutil"
+
+	"github.com/stretchr/testify/require"
+)
+
+// --- Test Helpers ---
+
+// backends runs test against every storage implementation.
+func backends(t *testing.T, test func(t *testing.T, st storageiface.Storage)) {
+	t.Run("local", func(t *testing.T) {
+		test(t, storage.NewLocalStorage(t.TempDir()))
+	})
+	t.Run("s3", func(t *testing.T) {
+		server := support.NewS3Server(t)
+		test(t, server.Storage(t, support.S3TestSecretAccessKey))
+	})
+}
+
+func put(t *testing.T, st storageiface.Storage, key string, content string) {
+	t.Helper()
+	err := st.Put(context.Background(), key, strings.NewReader(content), int64(len(content)), "text/plain")
+	require.NoError(t, err)
+}
+
+func read(t *testing.T, st storageiface.Storage, key string) string {
+	t.Helper()
+	obj, err := st.Get(context.Background(), key)
+	require.NoError(t, err)
+	defer obj.Body.Close()
+
+	data, err := io.ReadAll(obj.Body)
+	require.NoError(t, err)
+	require.Equal(t, int64(len(data)), obj.Size)
+	return string(data)
+}
+
+// --- Tests ---
+
+func TestStorage_PutGet(t *testing.T) {
+	backends(t, func(t *testing.T, st storageiface.Storage) {
+		put(t, st, "user_picture/a", "hello")
+		require.Equal(t, "hello", read(t, st, "user_picture/a"))
+
+		// Replaces what was there
+		put(t, st, "user_picture/a", "bye")
+		require.Equal(t, "bye", read(t, st, "user_picture/a"))
+	})
+}
+
+func TestStorage_PutEmpty(t *testing.T) {
+	backends(t, func(t *testing.T, st storageiface.Storage) {
+		put(t, st, "product_image/empty", "")
+		require.Equal(t, "", read(t, st, "product_image/empty"))
+	})
+}
+
+func TestStorage_GetMissing(t *testing.T) {
+	backends(t, func(t *testing.T, st storageiface.Storage) {
+		_, err := st.Get(context.Background(), "user_picture/missing")
+		require.ErrorIs(t, err, errs.ErrFileNotFound)
+	})
+}
+
+func TestStorage_Delete(t *testing.T) {
+	backends(t, func(t *testing.T, st storageiface.Storage) {
+		ctx := context.Background()
+		put(t, st, "user_picture/a", "hello")
+
+		require.NoError(t, st.Delete(ctx, "user_picture/a"))
+		_, err := st.Get(ctx, "user_picture/a")
+		require.ErrorIs(t, err, errs.ErrFileNotFound)
+
+		require.ErrorIs(t, st.Delete(ctx, "user_picture/a"), errs.ErrFileNotFound)
+	})
+}
+
+func TestStorage_InvalidKey(t *testing.T) {
+	backends(t, func(t *testing.T, st storageiface.Storage) {
+		ctx := context.Background()
+		for _, key := range []string{"../secret", "/etc/passwd", "user_picture/../../x", ""} {
+			err := st.Put(ctx, key, bytes.NewReader([]byte("x")), 1, "")
+			require.ErrorIs(t, err, errs.ErrFileInvalidKey, key)
+
+			_, err = st.Get(ctx, key)
+			require.ErrorIs(t, err, errs.ErrFileInvalidKey, key)
+		}
+	})
+}
+
+func TestS3Storage_SendsContentType(t *testing.T) {
+	server := support.NewS3Server(t)
+	st := server.Storage(t, support.S3TestSecretAccessKey)
+	put(t, st, "user_picture/a", "hello")
+
+	obj, err := st.Get(context.Background(), "user_picture/a")
+	require.NoError(t, err)
+	defer obj.Body.Close()
+	require.Equal(t, "text/plain", obj.ContentType)
+	require.False(t, obj.ModTime.IsZero())
+
+	data, ok := server.Object("user_picture/a")
+	require.True(t, ok)
+	require.Equal(t, "hello", string(data))
+}
+
+func TestS3Storage_WrongCredentials(t *testing.T) {
+	server := support.NewS3Server(t)
+	st := server.Storage(t, "wrong-secret")
+
+	err := st.Put(context.Background(), "user_picture/a", strings.NewReader("hello"), 5, "")
+	require.ErrorContains(t, err, "SignatureDoesNotMatch")
+
+	_, ok := server.Object("user_picture/a")
+	require.False(t, ok)
+}
+
+func TestStorage_GetSeek(t *testing.T) {
+	backends(t, func(t *testing.T, st storageiface.Storage) {
+		put(t, st, "product_image/a", "0123456789")
+
+		obj, err := st.Get(context.Background(), "product_image/a")
+		require.NoError(t, err)
+		defer obj.Body.Close()
+		require.NotEmpty(t, obj.ETag)
+
+		head := make([]byte, 3)
+		_, err = io.ReadFull(obj.Body, head)
+		require.NoError(t, err)
+		require.Equal(t, "012", string(head))
+
+		// Like http.ServeContent serving a range
+		size, err := obj.Body.Seek(0, io.SeekEnd)
+		require.NoError(t, err)
+		require.Equal(t, int64(10), size)
+		_, err = obj.Body.Seek(6, io.SeekStart)
+		require.NoError(t, err)
+
+		rest, err := io.ReadAll(obj.Body)
+		require.NoError(t, err)
+		require.Equal(t, "6789", string(rest))
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"myapp/api/v1/router"
 	"myapp/cmd"
 	"myapp/config"
+	"myapp/core/service"
 	"myapp/database/migrations"
 	docs "myapp/docs" // Swagger docs (allow runtime modifications)
 	"myapp/provider"
@@ -42,6 +43,11 @@ import (
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
 
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key issued by an admin, limited to the scopes it was issued with.
+
 func main() {
 	// Initialize logger level based on environment
 	if os.Getenv("DEBUG") == "true" {
@@ -59,6 +65,12 @@ func main() {
 	defer config.DBClose(db)
 	logger.Info("✅ Database connected")
 
+	// Refuse to start without usable token signing keys
+	if _, err := do.Invoke[service.JWTService](injector); err != nil {
+		logger.Error("❌ Failed to load JWT keys: %v", err)
+		os.Exit(1)
+	}
+
 	// Handling CLI Commands
 	cmd.Execute(db)
 
@@ -74,6 +86,9 @@ func main() {
 		logger.Info("✅ Database is up to date")
 	}
 
+	// Background jobs run against the migrated schema
+	cmd.StartJobs(injector)
+
 	// Setting Up Server with custom recovery and logger
 	gin.SetMode(gin.ReleaseMode) // Disable default Gin logger
 	server := gin.New()          // Use gin.New() instead of gin.Default() for custom middlewares
@@ -81,11 +96,13 @@ func main() {
 	// Apply middlewares in order:
 	// 1. Recovery - catches panics and prevents crashes
 	// 2. Request Logger - logs all incoming requests
-	// 3. CORS - handles cross-origin requests
-	// 4. Error Handler - handles errors set in context
+	// 3. Request ID - tags requests for the audit log
+	// 4. CORS - handles cross-origin requests
+	// 5. Error Handler - handles errors set in context
 	server.Use(
 		middleware.RecoveryMiddleware(),
 		middleware.RequestLoggerMiddleware(),
+		middleware.RequestID(),
 		middleware.CORSMiddleware(),
 		middleware.ErrorHandler(),
 	)
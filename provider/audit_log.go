@@ -0,0 +1,36 @@
+package provider
+
+import (
+	"myapp/api/v1/controller"
+	queryiface "myapp/core/interface/query"
+	repositoryiface "myapp/core/interface/repository"
+	"myapp/core/service"
+	"myapp/infrastructure/query"
+	"myapp/infrastructure/repository"
+	"myapp/support/constant"
+
+	"github.com/samber/do"
+	"gorm.io/gorm"
+)
+
+func SetupAuditLogDependencies(injector *do.Injector) {
+	do.Provide(injector, func(i *do.Injector) (repositoryiface.AuditLogRepository, error) {
+		db := do.MustInvokeNamed[*gorm.DB](i, constant.DBInjectorKey)
+		return repository.NewAuditLogRepository(db), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (queryiface.AuditLogQuery, error) {
+		db := do.MustInvokeNamed[*gorm.DB](i, constant.DBInjectorKey)
+		return query.NewAuditLogQuery(db), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (service.AuditLogService, error) {
+		auditLogQ := do.MustInvoke[queryiface.AuditLogQuery](i)
+		return service.NewAuditLogService(auditLogQ), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (controller.AuditLogController, error) {
+		auditLogS := do.MustInvoke[service.AuditLogService](i)
+		return controller.NewAuditLogController(auditLogS), nil
+	})
+}
@@ -0,0 +1,129 @@
+package provider
+
+import (
+	"myapp/api/v1/controller"
+	oidciface "myapp/core/interface/oidc"
+	repositoryiface "myapp/core/interface/repository"
+	"myapp/core/service"
+	"myapp/infrastructure/repository"
+	"myapp/support/constant"
+	"myapp/support/util"
+
+	"github.com/samber/do"
+	"gorm.io/gorm"
+)
+
+func SetupAuthDependencies(injector *do.Injector) {
+	do.Provide(injector, func(i *do.Injector) (repositoryiface.RefreshTokenRepository, error) {
+		db := do.MustInvokeNamed[*gorm.DB](i, constant.DBInjectorKey)
+		return repository.NewRefreshTokenRepository(db), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (repositoryiface.TokenRevocationRepository, error) {
+		db := do.MustInvokeNamed[*gorm.DB](i, constant.DBInjectorKey)
+		cacheTTL := util.GetEnvDuration("TOKEN_REVOCATION_CACHE_TTL", constant.DefaultRevocationCacheTTL)
+		accessTokenTTL := util.GetEnvDuration("JWT_ACCESS_TOKEN_TTL", constant.DefaultAccessTokenTTL)
+		return repository.NewTokenRevocationRepository(db, cacheTTL, accessTokenTTL), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (repositoryiface.SessionRepository, error) {
+		db := do.MustInvokeNamed[*gorm.DB](i, constant.DBInjectorKey)
+		return repository.NewSessionRepository(db), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (service.AuthService, error) {
+		jwtS := do.MustInvoke[service.JWTService](i)
+		refreshTokenR := do.MustInvoke[repositoryiface.RefreshTokenRepository](i)
+		revocationR := do.MustInvoke[repositoryiface.TokenRevocationRepository](i)
+		sessionR := do.MustInvoke[repositoryiface.SessionRepository](i)
+		userR := do.MustInvoke[repositoryiface.UserRepository](i)
+		txR := do.MustInvoke[repositoryiface.TxRepository](i)
+		return service.NewAuthService(jwtS, refreshTokenR, revocationR, sessionR, userR, txR), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (repositoryiface.TOTPCredentialRepository, error) {
+		db := do.MustInvokeNamed[*gorm.DB](i, constant.DBInjectorKey)
+		return repository.NewTOTPCredentialRepository(db), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (repositoryiface.RecoveryCodeRepository, error) {
+		db := do.MustInvokeNamed[*gorm.DB](i, constant.DBInjectorKey)
+		return repository.NewRecoveryCodeRepository(db), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (repositoryiface.TwoFactorChallengeRepository, error) {
+		db := do.MustInvokeNamed[*gorm.DB](i, constant.DBInjectorKey)
+		return repository.NewTwoFactorChallengeRepository(db), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (service.TwoFactorService, error) {
+		userR := do.MustInvoke[repositoryiface.UserRepository](i)
+		totpR := do.MustInvoke[repositoryiface.TOTPCredentialRepository](i)
+		recoveryCodeR := do.MustInvoke[repositoryiface.RecoveryCodeRepository](i)
+		challengeR := do.MustInvoke[repositoryiface.TwoFactorChallengeRepository](i)
+		txR := do.MustInvoke[repositoryiface.TxRepository](i)
+		return service.NewTwoFactorService(userR, totpR, recoveryCodeR, challengeR, txR), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (repositoryiface.LoginThrottleRepository, error) {
+		db := do.MustInvokeNamed[*gorm.DB](i, constant.DBInjectorKey)
+		return repository.NewLoginThrottleRepository(db), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (service.LoginThrottleService, error) {
+		throttleR := do.MustInvoke[repositoryiface.LoginThrottleRepository](i)
+		userR := do.MustInvoke[repositoryiface.UserRepository](i)
+		return service.NewLoginThrottleService(throttleR, userR), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (repositoryiface.APIKeyRepository, error) {
+		db := do.MustInvokeNamed[*gorm.DB](i, constant.DBInjectorKey)
+		return repository.NewAPIKeyRepository(db), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (service.APIKeyService, error) {
+		apiKeyR := do.MustInvoke[repositoryiface.APIKeyRepository](i)
+		roleR := do.MustInvoke[repositoryiface.RoleRepository](i)
+		return service.NewAPIKeyService(apiKeyR, roleR), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (controller.APIKeyController, error) {
+		apiKeyS := do.MustInvoke[service.APIKeyService](i)
+		return controller.NewAPIKeyController(apiKeyS), nil
+	})
+
+	// Tests may register their own providers beforehand
+	if _, err := do.Invoke[oidciface.Providers](injector); err != nil {
+		do.Provide(injector, func(i *do.Injector) (oidciface.Providers, error) {
+			return newOIDCProviders()
+		})
+	}
+
+	do.Provide(injector, func(i *do.Injector) (repositoryiface.OIDCStateRepository, error) {
+		db := do.MustInvokeNamed[*gorm.DB](i, constant.DBInjectorKey)
+		return repository.NewOIDCStateRepository(db), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (repositoryiface.UserIdentityRepository, error) {
+		db := do.MustInvokeNamed[*gorm.DB](i, constant.DBInjectorKey)
+		return repository.NewUserIdentityRepository(db), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (service.OIDCService, error) {
+		providers := do.MustInvoke[oidciface.Providers](i)
+		stateR := do.MustInvoke[repositoryiface.OIDCStateRepository](i)
+		userR := do.MustInvoke[repositoryiface.UserRepository](i)
+		identityR := do.MustInvoke[repositoryiface.UserIdentityRepository](i)
+		txR := do.MustInvoke[repositoryiface.TxRepository](i)
+		authS := do.MustInvoke[service.AuthService](i)
+		return service.NewOIDCService(providers, stateR, userR, identityR, txR, authS), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (controller.AuthController, error) {
+		jwtS := do.MustInvoke[service.JWTService](i)
+		authS := do.MustInvoke[service.AuthService](i)
+		oidcS := do.MustInvoke[service.OIDCService](i)
+		twoFactorS := do.MustInvoke[service.TwoFactorService](i)
+		return controller.NewAuthController(jwtS, authS, oidcS, twoFactorS), nil
+	})
+}
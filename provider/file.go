@@ -2,12 +2,24 @@ package provider
 
 import (
 	"myapp/api/v1/controller"
+	storageiface "myapp/core/interface/storage"
+	"myapp/core/service"
+	"myapp/support/constant"
+	"myapp/support/util"
 
 	"github.com/samber/do"
 )
 
 func SetupFileDependencies(injector *do.Injector) {
 	do.Provide(injector, func(i *do.Injector) (controller.FileController, error) {
-		return controller.NewFileController(), nil
+		storage := do.MustInvoke[storageiface.Storage](i)
+		fileURLs := do.MustInvoke[service.FileURLService](i)
+		cacheControl := map[string]string{
+			constant.EnumFileNamespaceUserPicture: util.GetEnv("FILE_CACHE_CONTROL_USER_PICTURE",
+				constant.DefaultUserPictureCacheControl),
+			constant.EnumFileNamespaceProductImage: util.GetEnv("FILE_CACHE_CONTROL_PRODUCT_IMAGE",
+				constant.DefaultProductImageCacheControl),
+		}
+		return controller.NewFileController(storage, fileURLs, cacheControl), nil
 	})
 }
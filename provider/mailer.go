@@ -0,0 +1,30 @@
+package provider
+
+import (
+	"fmt"
+
+	maileriface "myapp/core/interface/mailer"
+	"myapp/infrastructure/mailer"
+	"myapp/support/util"
+)
+
+// newMailer picks the mailer from MAIL_DRIVER: "smtp" delivers real mail,
+// "file" (the default) only logs messages and writes them to MAIL_FILE_DIR.
+func newMailer() (maileriface.Mailer, error) {
+	from := util.GetEnv("MAIL_FROM", "no-reply@localhost")
+
+	switch driver := util.GetEnv("MAIL_DRIVER", "file"); driver {
+	case "smtp":
+		return mailer.NewSMTPMailer(mailer.SMTPConfig{
+			Host:     util.GetEnv("SMTP_HOST", "localhost"),
+			Port:     util.GetEnvInt("SMTP_PORT", 587),
+			Username: util.GetEnv("SMTP_USERNAME", ""),
+			Password: util.GetEnv("SMTP_PASSWORD", ""),
+			From:     from,
+		}), nil
+	case "file":
+		return mailer.NewFileMailer(util.GetEnv("MAIL_FILE_DIR", ""), from), nil
+	default:
+		return nil, fmt.Errorf("unknown MAIL_DRIVER %q", driver)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"myapp/api/v1/controller"
 	queryiface "myapp/core/interface/query"
 	repositoryiface "myapp/core/interface/repository"
+	storageiface "myapp/core/interface/storage"
 	"myapp/core/service"
 	"myapp/infrastructure/query"
 	"myapp/infrastructure/repository"
@@ -32,6 +33,18 @@ func SetupProductDependencies(injector *do.Injector) {
 		return query.NewProductQuery(db), nil
 	})
 
+	// Product Revision Repository
+	do.Provide(injector, func(i *do.Injector) (repositoryiface.ProductRevisionRepository, error) {
+		db := do.MustInvokeNamed[*gorm.DB](i, constant.DBInjectorKey)
+		return repository.NewProductRevisionRepository(db), nil
+	})
+
+	// Product Revision Query
+	do.Provide(injector, func(i *do.Injector) (queryiface.ProductRevisionQuery, error) {
+		db := do.MustInvokeNamed[*gorm.DB](i, constant.DBInjectorKey)
+		return query.NewProductRevisionQuery(db), nil
+	})
+
 	// Category Query
 	do.Provide(injector, func(i *do.Injector) (queryiface.CategoryQuery, error) {
 		db := do.MustInvokeNamed[*gorm.DB](i, constant.DBInjectorKey)
@@ -45,7 +58,13 @@ func SetupProductDependencies(injector *do.Injector) {
 		productQ := do.MustInvoke[queryiface.ProductQuery](i)
 		categoryQ := do.MustInvoke[queryiface.CategoryQuery](i)
 		txR := do.MustInvoke[repositoryiface.TxRepository](i)
-		return service.NewProductService(productR, categoryR, productQ, categoryQ, txR), nil
+		auditLogR := do.MustInvoke[repositoryiface.AuditLogRepository](i)
+		revisionR := do.MustInvoke[repositoryiface.ProductRevisionRepository](i)
+		revisionQ := do.MustInvoke[queryiface.ProductRevisionQuery](i)
+		storage := do.MustInvoke[storageiface.Storage](i)
+		fileURLs := do.MustInvoke[service.FileURLService](i)
+		return service.NewProductService(productR, categoryR, productQ, categoryQ, txR, auditLogR,
+			revisionR, revisionQ, storage, fileURLs), nil
 	})
 
 	// Category Service
@@ -54,7 +73,9 @@ func SetupProductDependencies(injector *do.Injector) {
 		productR := do.MustInvoke[repositoryiface.ProductRepository](i)
 		categoryQ := do.MustInvoke[queryiface.CategoryQuery](i)
 		productQ := do.MustInvoke[queryiface.ProductQuery](i)
-		return service.NewCategoryService(categoryR, productR, categoryQ, productQ), nil
+		txR := do.MustInvoke[repositoryiface.TxRepository](i)
+		auditLogR := do.MustInvoke[repositoryiface.AuditLogRepository](i)
+		return service.NewCategoryService(categoryR, productR, categoryQ, productQ, txR, auditLogR), nil
 	})
 
 	// Product Controller
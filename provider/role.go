@@ -0,0 +1,30 @@
+package provider
+
+import (
+	"myapp/api/v1/controller"
+	repositoryiface "myapp/core/interface/repository"
+	"myapp/core/service"
+	"myapp/infrastructure/repository"
+	"myapp/support/constant"
+
+	"github.com/samber/do"
+	"gorm.io/gorm"
+)
+
+func SetupRoleDependencies(injector *do.Injector) {
+	do.Provide(injector, func(i *do.Injector) (repositoryiface.RoleRepository, error) {
+		db := do.MustInvokeNamed[*gorm.DB](i, constant.DBInjectorKey)
+		return repository.NewRoleRepository(db), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (service.RoleService, error) {
+		roleR := do.MustInvoke[repositoryiface.RoleRepository](i)
+		txR := do.MustInvoke[repositoryiface.TxRepository](i)
+		return service.NewRoleService(roleR, txR), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (controller.RoleController, error) {
+		roleS := do.MustInvoke[service.RoleService](i)
+		return controller.NewRoleController(roleS), nil
+	})
+}
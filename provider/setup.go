@@ -2,7 +2,9 @@ package provider
 
 import (
 	"myapp/config"
+	maileriface "myapp/core/interface/mailer"
 	repositoryiface "myapp/core/interface/repository"
+	storageiface "myapp/core/interface/storage"
 	"myapp/core/service"
 	"myapp/infrastructure/repository"
 	"myapp/support/constant"
@@ -24,11 +26,32 @@ func SetupDependencies(injector *do.Injector) {
 		return repository.NewTxRepository(db), nil
 	})
 
+	// Tests may register their own mailer beforehand
+	if _, err := do.Invoke[maileriface.Mailer](injector); err != nil {
+		do.Provide(injector, func(i *do.Injector) (maileriface.Mailer, error) {
+			return newMailer()
+		})
+	}
+
+	// Tests may register their own storage beforehand
+	if _, err := do.Invoke[storageiface.Storage](injector); err != nil {
+		do.Provide(injector, func(i *do.Injector) (storageiface.Storage, error) {
+			return newStorage()
+		})
+	}
+
 	do.Provide(injector, func(i *do.Injector) (service.JWTService, error) {
-		return service.NewJWTService(), nil
+		return service.NewJWTService()
+	})
+	do.Provide(injector, func(i *do.Injector) (service.FileURLService, error) {
+		return service.NewFileURLService()
 	})
 
+	SetupAuditLogDependencies(injector)
+	SetupAuthDependencies(injector)
+	SetupRoleDependencies(injector)
 	SetupUserDependencies(injector)
 	SetupFileDependencies(injector)
 	SetupProductDependencies(injector)
+	SetupTrashDependencies(injector)
 }
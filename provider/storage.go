@@ -0,0 +1,31 @@
+package provider
+
+import (
+	"fmt"
+
+	storageiface "myapp/core/interface/storage"
+	"myapp/infrastructure/storage"
+	"myapp/support/constant"
+	"myapp/support/util"
+)
+
+// newStorage picks where uploads are kept from STORAGE_DRIVER: "local" (the
+// default) writes below STORAGE_LOCAL_DIR, "s3" to a bucket of an S3
+// compatible object store, which every replica of the API can reach.
+func newStorage() (storageiface.Storage, error) {
+	switch driver := util.GetEnv("STORAGE_DRIVER", "local"); driver {
+	case "local":
+		return storage.NewLocalStorage(util.GetEnv("STORAGE_LOCAL_DIR", constant.FileBasePath)), nil
+	case "s3":
+		return storage.NewS3Storage(storage.S3Config{
+			Endpoint:        util.GetEnv("S3_ENDPOINT", "https://s3.amazonaws.com"),
+			Region:          util.GetEnv("S3_REGION", "us-east-1"),
+			Bucket:          util.GetEnv("S3_BUCKET", ""),
+			AccessKeyID:     util.GetEnv("S3_ACCESS_KEY_ID", ""),
+			SecretAccessKey: util.GetEnv("S3_SECRET_ACCESS_KEY", ""),
+			PathStyle:       util.GetEnvBool("S3_PATH_STYLE", false),
+		})
+	default:
+		return nil, fmt.Errorf("unknown STORAGE_DRIVER %q", driver)
+	}
+}
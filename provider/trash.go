@@ -0,0 +1,47 @@
+package provider
+
+import (
+	"myapp/api/v1/controller"
+	queryiface "myapp/core/interface/query"
+	repositoryiface "myapp/core/interface/repository"
+	storageiface "myapp/core/interface/storage"
+	"myapp/core/service"
+	"myapp/infrastructure/query"
+	"myapp/infrastructure/repository"
+	"myapp/support/constant"
+
+	"github.com/samber/do"
+	"gorm.io/gorm"
+)
+
+func SetupTrashDependencies(injector *do.Injector) {
+	do.Provide(injector, func(i *do.Injector) (repositoryiface.TrashRepository, error) {
+		db := do.MustInvokeNamed[*gorm.DB](i, constant.DBInjectorKey)
+		return repository.NewTrashRepository(db), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (queryiface.TrashQuery, error) {
+		db := do.MustInvokeNamed[*gorm.DB](i, constant.DBInjectorKey)
+		return query.NewTrashQuery(db), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (service.TrashService, error) {
+		userR := do.MustInvoke[repositoryiface.UserRepository](i)
+		productR := do.MustInvoke[repositoryiface.ProductRepository](i)
+		categoryR := do.MustInvoke[repositoryiface.CategoryRepository](i)
+		trashR := do.MustInvoke[repositoryiface.TrashRepository](i)
+		trashQ := do.MustInvoke[queryiface.TrashQuery](i)
+		txR := do.MustInvoke[repositoryiface.TxRepository](i)
+		auditLogR := do.MustInvoke[repositoryiface.AuditLogRepository](i)
+		revisionR := do.MustInvoke[repositoryiface.ProductRevisionRepository](i)
+		storage := do.MustInvoke[storageiface.Storage](i)
+		fileURLs := do.MustInvoke[service.FileURLService](i)
+		return service.NewTrashService(userR, productR, categoryR, trashR, trashQ, txR, auditLogR, revisionR,
+			storage, fileURLs), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (controller.TrashController, error) {
+		trashS := do.MustInvoke[service.TrashService](i)
+		return controller.NewTrashController(trashS), nil
+	})
+}
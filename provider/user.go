@@ -2,8 +2,10 @@ package provider
 
 import (
 	"myapp/api/v1/controller"
+	maileriface "myapp/core/interface/mailer"
 	queryiface "myapp/core/interface/query"
 	repositoryiface "myapp/core/interface/repository"
+	storageiface "myapp/core/interface/storage"
 	"myapp/core/service"
 	"myapp/infrastructure/query"
 	"myapp/infrastructure/repository"
@@ -24,16 +26,91 @@ func SetupUserDependencies(injector *do.Injector) {
 		return query.NewUserQuery(db), nil
 	})
 
+	do.Provide(injector, func(i *do.Injector) (service.PasswordPolicy, error) {
+		return service.NewPasswordPolicy()
+	})
+
 	do.Provide(injector, func(i *do.Injector) (service.UserService, error) {
 		userR := do.MustInvoke[repositoryiface.UserRepository](i)
 		userQ := do.MustInvoke[queryiface.UserQuery](i)
+		roleR := do.MustInvoke[repositoryiface.RoleRepository](i)
+		txR := do.MustInvoke[repositoryiface.TxRepository](i)
+		auditLogR := do.MustInvoke[repositoryiface.AuditLogRepository](i)
+		storage := do.MustInvoke[storageiface.Storage](i)
+		fileURLs := do.MustInvoke[service.FileURLService](i)
+		passwordPolicy := do.MustInvoke[service.PasswordPolicy](i)
+		return service.NewUserService(userR, userQ, roleR, txR, auditLogR, storage, fileURLs, passwordPolicy), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (repositoryiface.PasswordResetTokenRepository, error) {
+		db := do.MustInvokeNamed[*gorm.DB](i, constant.DBInjectorKey)
+		return repository.NewPasswordResetTokenRepository(db), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (service.PasswordService, error) {
+		jwtS := do.MustInvoke[service.JWTService](i)
+		userR := do.MustInvoke[repositoryiface.UserRepository](i)
+		passwordResetR := do.MustInvoke[repositoryiface.PasswordResetTokenRepository](i)
+		refreshTokenR := do.MustInvoke[repositoryiface.RefreshTokenRepository](i)
+		sessionR := do.MustInvoke[repositoryiface.SessionRepository](i)
+		revocationR := do.MustInvoke[repositoryiface.TokenRevocationRepository](i)
+		auditLogR := do.MustInvoke[repositoryiface.AuditLogRepository](i)
 		txR := do.MustInvoke[repositoryiface.TxRepository](i)
-		return service.NewUserService(userR, userQ, txR), nil
+		mailer := do.MustInvoke[maileriface.Mailer](i)
+		passwordPolicy := do.MustInvoke[service.PasswordPolicy](i)
+		return service.NewPasswordService(jwtS, userR, passwordResetR, refreshTokenR, sessionR, revocationR,
+			auditLogR, txR, mailer, passwordPolicy), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (repositoryiface.EmailVerificationTokenRepository, error) {
+		db := do.MustInvokeNamed[*gorm.DB](i, constant.DBInjectorKey)
+		return repository.NewEmailVerificationTokenRepository(db), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (service.EmailVerificationService, error) {
+		userR := do.MustInvoke[repositoryiface.UserRepository](i)
+		verificationR := do.MustInvoke[repositoryiface.EmailVerificationTokenRepository](i)
+		txR := do.MustInvoke[repositoryiface.TxRepository](i)
+		mailer := do.MustInvoke[maileriface.Mailer](i)
+		return service.NewEmailVerificationService(userR, verificationR, txR, mailer), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (service.ImpersonationService, error) {
+		jwtS := do.MustInvoke[service.JWTService](i)
+		userR := do.MustInvoke[repositoryiface.UserRepository](i)
+		roleR := do.MustInvoke[repositoryiface.RoleRepository](i)
+		return service.NewImpersonationService(jwtS, userR, roleR), nil
 	})
 
 	do.Provide(injector, func(i *do.Injector) (controller.UserController, error) {
 		userS := do.MustInvoke[service.UserService](i)
-		jwtS := do.MustInvoke[service.JWTService](i)
-		return controller.NewUserController(userS, jwtS), nil
+		authS := do.MustInvoke[service.AuthService](i)
+		passwordS := do.MustInvoke[service.PasswordService](i)
+		verificationS := do.MustInvoke[service.EmailVerificationService](i)
+		twoFactorS := do.MustInvoke[service.TwoFactorService](i)
+		throttleS := do.MustInvoke[service.LoginThrottleService](i)
+		impersonationS := do.MustInvoke[service.ImpersonationService](i)
+		return controller.NewUserController(userS, authS, passwordS, verificationS, twoFactorS, throttleS,
+			impersonationS), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (repositoryiface.InvitationRepository, error) {
+		db := do.MustInvokeNamed[*gorm.DB](i, constant.DBInjectorKey)
+		return repository.NewInvitationRepository(db), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (service.InvitationService, error) {
+		invitationR := do.MustInvoke[repositoryiface.InvitationRepository](i)
+		userR := do.MustInvoke[repositoryiface.UserRepository](i)
+		roleR := do.MustInvoke[repositoryiface.RoleRepository](i)
+		txR := do.MustInvoke[repositoryiface.TxRepository](i)
+		mailer := do.MustInvoke[maileriface.Mailer](i)
+		passwordPolicy := do.MustInvoke[service.PasswordPolicy](i)
+		return service.NewInvitationService(invitationR, userR, roleR, txR, mailer, passwordPolicy), nil
+	})
+
+	do.Provide(injector, func(i *do.Injector) (controller.InvitationController, error) {
+		invitationS := do.MustInvoke[service.InvitationService](i)
+		return controller.NewInvitationController(invitationS), nil
 	})
 }
@@ -1,5 +1,7 @@
 package base
 
+import "time"
+
 type Response struct {
 	IsSuccess bool                `json:"success"`
 	Message   string              `json:"message"`
@@ -10,8 +12,10 @@ type Response struct {
 }
 
 type AuthResponse struct {
-	Token string `json:"token"`
-	Role  string `json:"role"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+	Role         string `json:"role"`
 }
 
 type PaginationResponse struct {
@@ -39,8 +43,8 @@ func CreatePaginatedResponse(msg string, statusCode uint, d any, pageMeta Pagina
 	}
 }
 
-func CreateAuthResponse(token string, role string) AuthResponse {
+func CreateAuthResponse(token string, refreshToken string, expiresIn time.Duration, role string) AuthResponse {
 	return AuthResponse{
-		Token: token, Role: role,
+		Token: token, RefreshToken: refreshToken, ExpiresIn: int64(expiresIn.Seconds()), Role: role,
 	}
 }
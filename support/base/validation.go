@@ -1,6 +1,7 @@
 package base
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"regexp"
@@ -21,6 +22,11 @@ var basicErrorMessages = map[string]string{
 	"alphaunicode":    "The value of '%s' must contain only Unicode letters without numbers or symbols.",
 	"ascii":           "The value of '%s' must contain only ASCII characters.",
 	"base64":          "The value of '%s' must be a valid Base64 string.",
+
+	"password_personal": "The value of '%s' must not contain your name or email address.",
+	"password_breached": "The value of '%s' is too common or appeared in a data breach, choose another one.",
+
+	"image": "The file in '%s' must be a valid image.",
 }
 
 var paramErrorMessages = map[string]string{
@@ -34,16 +40,39 @@ var paramErrorMessages = map[string]string{
 	"datetime": "The value of '%s' must be in a valid date-time format (example: %s).",
 	"oneof":    "The value of '%s' must be one of the following: %s.",
 	"unique":   "The value of '%s' which is '%s' must be unique and not duplicate any other value.",
+
+	"min_length":       "The value of '%s' must be at least %s characters long.",
+	"max_length":       "The value of '%s' must be at most %s characters long.",
+	"password_classes": "The value of '%s' must mix at least %s of lowercase letters, uppercase letters, numbers and symbols.",
+
+	"file_max_size":  "The file in '%s' must be at most %s.",
+	"file_types":     "The file in '%s' must be one of the following types: %s.",
+	"image_min_size": "The image in '%s' must be at least %s pixels.",
+	"image_max_size": "The image in '%s' must be at most %s pixels.",
+}
+
+// FieldErrors is implemented by errors that carry validation messages of
+// their own, for rules checked outside of binding tags.
+type FieldErrors interface {
+	error
+	FieldErrors() []string
 }
 
 func getErrorMessage(fieldName string, fe validator.FieldError) string {
-	if msgTemplate, exists := basicErrorMessages[fe.Tag()]; exists {
+	return FormatFieldErrorMessage(fieldName, fe.Tag(), fe.Param())
+}
+
+// FormatFieldErrorMessage words a failed rule the same way binding
+// validation errors are worded. tag is a validator tag or one of the extra
+// rules in the message tables above.
+func FormatFieldErrorMessage(fieldName string, tag string, param string) string {
+	if msgTemplate, exists := basicErrorMessages[tag]; exists {
 		return fmt.Sprintf(msgTemplate, fieldName)
-	} else if msgTemplate, exists := paramErrorMessages[fe.Tag()]; exists {
-		return fmt.Sprintf(msgTemplate, fieldName, fe.Param())
+	} else if msgTemplate, exists := paramErrorMessages[tag]; exists {
+		return fmt.Sprintf(msgTemplate, fieldName, param)
 	}
 
-	switch fe.Tag() {
+	switch tag {
 	case "required", "required_if", "required_with":
 		return "The value of '" + fieldName + "' is required."
 	case "numeric", "number":
@@ -61,11 +90,23 @@ func GetValidationErrorMessage(err error, reqStruct interface{}, defaultMsg stri
 	return defaultMsg
 }
 
+// GetFieldErrorMessage picks the response message for an error returned
+// after binding succeeded: the first message of an error implementing
+// FieldErrors, or defaultMsg for any other error.
+func GetFieldErrorMessage(err error, defaultMsg string) string {
+	var fieldErrs FieldErrors
+	if errors.As(err, &fieldErrs) && len(fieldErrs.FieldErrors()) > 0 {
+		return fieldErrs.FieldErrors()[0]
+	}
+	return defaultMsg
+}
+
 func FormatValidationErrors(err error, reqStruct interface{}, customAdditionalErrs ...string) []string {
 	jsonTagMap := getTagValueFromStruct(reqStruct, "json")
 	fieldNameMap := getTagValueFromStruct(reqStruct, "field")
 
 	messages := []string{}
+	var fieldErrs FieldErrors
 	if validationErrs, ok := err.(validator.ValidationErrors); ok {
 		for _, fieldErr := range validationErrs {
 			fieldPath := fieldErr.Namespace()
@@ -86,6 +127,8 @@ func FormatValidationErrors(err error, reqStruct interface{}, customAdditionalEr
 			message := getErrorMessage(fieldName, fieldErr)
 			messages = append(messages, message)
 		}
+	} else if errors.As(err, &fieldErrs) {
+		messages = append(messages, fieldErrs.FieldErrors()...)
 	} else if err != nil {
 		return []string{err.Error()}
 	}
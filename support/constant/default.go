@@ -1,9 +1,112 @@
 package constant
 
+import "time"
+
 const (
 	FileBasePath = "files"
 
 	DefaultPaginationPerPage = 10
 
 	DBInjectorKey = "DATABASE"
+
+	DefaultAccessTokenTTL  = 120 * time.Minute
+	DefaultRefreshTokenTTL = 30 * 24 * time.Hour
+	RefreshTokenBytes      = 32
+
+	DefaultRevocationCacheTTL = 30 * time.Second
+	// SessionLastSeenInterval is how stale a session's last_seen_at may get
+	// before a request writes it again.
+	SessionLastSeenInterval = time.Minute
+
+	DefaultPasswordResetTokenTTL = time.Hour
+	DefaultPasswordResetURL      = "http://localhost:3000/reset-password"
+	PasswordResetTokenBytes      = 32
+
+	DefaultPasswordMinLength = 8
+	// DefaultPasswordMaxLength is the bcrypt input limit; longer passwords
+	// would be silently truncated when hashed.
+	DefaultPasswordMaxLength  = 72
+	DefaultPasswordMinClasses = 1
+
+	// Password hashing follows the OWASP recommendations for argon2id
+	DefaultPasswordHashAlgorithm = "argon2id"
+	DefaultPasswordBcryptCost    = 12
+	DefaultPasswordArgon2Memory  = 19 * 1024 // KiB
+	DefaultPasswordArgon2Time    = 2
+	DefaultPasswordArgon2Threads = 1
+	PasswordArgon2KeyLength      = 32
+	PasswordArgon2SaltLength     = 16
+
+	DefaultEmailVerificationTokenTTL = 24 * time.Hour
+	DefaultEmailVerificationURL      = "http://localhost:8080/api/v1/users/verify-email"
+	EmailVerificationTokenBytes      = 32
+
+	DefaultOIDCStateTTL = 10 * time.Minute
+	OIDCStateBytes      = 32
+	// Cookie tying a pending provider login to the browser that started it
+	OIDCStateCookie = "oidc_state"
+
+	DefaultTOTPIssuer            = "myapp"
+	DefaultTwoFactorChallengeTTL = 5 * time.Minute
+	DefaultTwoFactorMaxAttempts  = 5
+	TwoFactorChallengeBytes      = 32
+	RecoveryCodeCount            = 10
+
+	DefaultLoginThrottleWindow     = 15 * time.Minute
+	DefaultLoginDelayAfter         = 3
+	DefaultLoginDelayBase          = time.Second
+	DefaultLoginDelayMax           = 30 * time.Second
+	DefaultLoginLockoutThreshold   = 10
+	DefaultLoginIPLockoutThreshold = 50
+	DefaultLoginLockoutDuration    = 15 * time.Minute
+
+	DefaultImpersonationTokenTTL = 15 * time.Minute
+
+	DefaultAccountDeletionGracePeriod = 30 * 24 * time.Hour
+	DefaultAccountPurgeInterval       = time.Hour
+	AccountPurgeBatchSize             = 100
+	// AnonymizedEmailDomain is where purged accounts get their placeholder
+	// email address.
+	AnonymizedEmailDomain = "deleted.invalid"
+
+	DefaultTrashRetentionPeriod = 30 * 24 * time.Hour
+	DefaultTrashPurgeInterval   = 24 * time.Hour
+	TrashPurgeBatchSize         = 100
+
+	DefaultUserPictureMaxBytes      = 5 << 20
+	DefaultUserPictureMinDimension  = 32
+	DefaultUserPictureMaxDimension  = 4096
+	DefaultProductImageMaxBytes     = 10 << 20
+	DefaultProductImageMinDimension = 32
+	DefaultProductImageMaxDimension = 8192
+	DefaultImageUploadTypes         = "image/jpeg,image/png,image/gif,image/webp"
+	// Room for the multipart framing and the other form fields of an upload
+	UploadFormOverheadBytes = 64 << 10
+	// Variants made of uploaded images, as name:size:format
+	DefaultUserPictureVariants  = "thumbnail:128:jpeg,medium:512:jpeg,webp:512:webp"
+	DefaultProductImageVariants = "thumbnail:256:jpeg,medium:1024:jpeg,webp:1024:webp"
+	ImageVariantJPEGQuality     = 85
+
+	// Uploads get a new key on every change, so they can be cached long.
+	// User pictures stay out of shared caches.
+	DefaultUserPictureCacheControl  = "private, max-age=86400"
+	DefaultProductImageCacheControl = "public, max-age=604800"
+
+	DefaultFileURLBase            = "/api/v1/files"
+	DefaultFileURLTTL             = time.Hour
+	DefaultUserPictureVisibility  = "private"
+	DefaultProductImageVisibility = "public"
+	FileURLSecretBytes            = 32
+
+	DefaultInvitationTTL = 7 * 24 * time.Hour
+	DefaultInvitationURL = "http://localhost:3000/accept-invitation"
+	InvitationTokenBytes = 32
+
+	DefaultAPIKeyTTL    = 90 * 24 * time.Hour
+	DefaultAPIKeyMaxTTL = 365 * 24 * time.Hour
+	APIKeyBytes         = 32
+	APIKeyPrefix        = "mak_"
+	// APIKeyLastUsedInterval is how stale last_used_at may get before a
+	// request writes it again, so busy keys do not write on every call.
+	APIKeyLastUsedInterval = time.Minute
 )
@@ -3,6 +3,68 @@ package constant
 const (
 	EnumRoleAdmin = "admin"
 	EnumRoleUser  = "user"
+	// Role of requests authenticated with an API key instead of a user
+	// token. Such requests hold the permissions granted as key scopes.
+	EnumRoleAPIKey = "api_key"
+
+	// Permissions checked by RequirePermission. Roles get them assigned in
+	// the database, API keys as scopes.
+	EnumPermissionUsersRead        = "users:read"
+	EnumPermissionUsersWrite       = "users:write"
+	EnumPermissionUsersImpersonate = "users:impersonate"
+	EnumPermissionUsersInvite      = "users:invite"
+	EnumPermissionProductsWrite    = "products:write"
+	EnumPermissionProductsStock    = "products:stock"
+	EnumPermissionProductsHistory  = "products:history"
+	EnumPermissionCategoriesWrite  = "categories:write"
+	EnumPermissionAPIKeysManage    = "api_keys:manage"
+	EnumPermissionRolesManage      = "roles:manage"
+	EnumPermissionAuditLogsRead    = "audit_logs:read"
+	EnumPermissionTrashManage      = "trash:manage"
+
+	// Why a refresh token was revoked. Only a rotated token presented again
+	// hints at theft, the others were ended on purpose.
+	EnumRefreshTokenRevokedRotated         = "rotated"
+	EnumRefreshTokenRevokedReuse           = "reuse"
+	EnumRefreshTokenRevokedLogout          = "logout"
+	EnumRefreshTokenRevokedSessionEnded    = "session_ended"
+	EnumRefreshTokenRevokedSessionsRevoked = "sessions_revoked"
+
+	// Provider of accounts that log in with a password. Accounts created
+	// through social login carry the name of their OIDC provider instead.
+	EnumProviderLocal = "local"
+
+	// Where an unverified email address is refused: "login" blocks logging
+	// in (and every protected route), "routes" only the routes guarded by
+	// RequireVerifiedEmail, "none" nowhere.
+	EnumEmailVerificationNone   = "none"
+	EnumEmailVerificationLogin  = "login"
+	EnumEmailVerificationRoutes = "routes"
+
+	// Audit log actions and the entities they target
+	EnumAuditActionCreate   = "create"
+	EnumAuditActionUpdate   = "update"
+	EnumAuditActionDelete   = "delete"
+	EnumAuditActionRestore  = "restore"
+	EnumAuditActionPurge    = "purge"
+	EnumAuditEntityUser     = "user"
+	EnumAuditEntityProduct  = "product"
+	EnumAuditEntityCategory = "category"
+	// Who made an audited change. Requests without a login, like signing
+	// up, are "anonymous", background jobs act as "system".
+	EnumAuditActorUser      = "user"
+	EnumAuditActorAPIKey    = "api_key"
+	EnumAuditActorAnonymous = "anonymous"
+	EnumAuditActorSystem    = "system"
+
+	// Namespaces of uploaded files, the first part of their storage keys.
+	// Only these are served.
+	EnumFileNamespaceUserPicture  = "user_picture"
+	EnumFileNamespaceProductImage = "product_image"
+	// Public files are served to anyone, private ones only through signed
+	// URLs that expire.
+	EnumFileVisibilityPublic  = "public"
+	EnumFileVisibilityPrivate = "private"
 
 	DBAttrID    = "id"
 	DBAttrEmail = "email"
@@ -7,12 +7,36 @@ import (
 	"myapp/core/helper/messages"
 	"myapp/core/service"
 	"myapp/support/base"
+	"myapp/support/constant"
+	"myapp/support/logger"
 
 	"github.com/gin-gonic/gin"
 )
 
-func Authenticate(jwtService service.JWTService) gin.HandlerFunc {
+// Authenticate accepts a Bearer JWT in the Authorization header or an API
+// key in the X-API-Key header. Requests made with an API key carry the key
+// ID as ID, EnumRoleAPIKey as ROLE and the key scopes as SCOPES. Requests
+// made with an impersonation token carry the real user as ACTOR_ID and are
+// logged.
+func Authenticate(jwtService service.JWTService, authService service.AuthService,
+	apiKeyService service.APIKeyService) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if rawKey := c.GetHeader("X-API-Key"); rawKey != "" {
+			key, err := apiKeyService.AuthenticateAPIKey(c.Request.Context(), rawKey)
+			if err != nil {
+				_ = c.Error(base.NewAppError(http.StatusUnauthorized,
+					messages.MsgAuthInvalidAPIKey, err))
+				c.Abort()
+				return
+			}
+
+			c.Set("ID", key.ID)
+			c.Set("ROLE", constant.EnumRoleAPIKey)
+			c.Set("SCOPES", key.Scopes)
+			c.Next()
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			_ = c.Error(base.NewAppError(http.StatusUnauthorized,
@@ -42,16 +66,52 @@ func Authenticate(jwtService service.JWTService) gin.HandlerFunc {
 			return
 		}
 
-		// get role from token
-		idRes, roleRes, err := jwtService.GetAttrByToken(authHeader)
+		// get id, role and token identity from token
+		claims, err := jwtService.GetClaimsByToken(authHeader)
 		if err != nil {
 			_ = c.Error(base.NewAppError(http.StatusUnauthorized,
 				messages.MsgAuthFailedProcess, err))
 			c.Abort()
 			return
 		}
-		c.Set("ID", idRes)
-		c.Set("ROLE", roleRes)
+
+		// reject tokens revoked by logout or session revocation
+		if err := authService.VerifyAccessToken(c.Request.Context(), claims); err != nil {
+			_ = c.Error(base.NewAppError(http.StatusUnauthorized,
+				messages.MsgAuthInvalidToken, err))
+			c.Abort()
+			return
+		}
+
+		c.Set("ID", claims.UserID)
+		c.Set("ROLE", claims.Role)
+		c.Set("TOKEN_ID", claims.TokenID)
+		c.Set("TOKEN_EXP", claims.ExpiresAt)
+		c.Set("SESSION_ID", claims.SessionID)
+		if claims.ActorID == "" {
+			c.Next()
+			return
+		}
+
+		c.Set("ACTOR_ID", claims.ActorID)
+		c.Next()
+		logger.Info("[impersonation] user %s as user %s: %s %s - %d", claims.ActorID, claims.UserID,
+			c.Request.Method, c.Request.URL.Path, c.Writer.Status())
+	}
+}
+
+// ForbidAPIKey rejects requests made with an API key, for routes that act on
+// the caller's own account and so need a user token. It must run after
+// Authenticate.
+func ForbidAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("ROLE") == constant.EnumRoleAPIKey {
+			_ = c.Error(base.NewAppError(http.StatusForbidden,
+				messages.MsgAuthAPIKeyNotAllowed, nil))
+			c.Abort()
+			return
+		}
+
 		c.Next()
 	}
 }
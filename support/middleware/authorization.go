@@ -5,13 +5,17 @@ import (
 	"slices"
 
 	"myapp/core/helper/messages"
+	"myapp/core/service"
 	"myapp/support/base"
 	"myapp/support/constant"
 
 	"github.com/gin-gonic/gin"
 )
 
-func Authorize(roles ...string) gin.HandlerFunc {
+// RequirePermission lets a request through only if it may perform
+// permission. It must run after Authenticate. Users are checked against the
+// permissions of their role, API keys against their scopes.
+func RequirePermission(roleService service.RoleService, permission string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		roleRes, exists := c.Get("ROLE")
 		if !exists {
@@ -29,7 +33,23 @@ func Authorize(roles ...string) gin.HandlerFunc {
 			return
 		}
 
-		if role != constant.EnumRoleAdmin && !slices.Contains(roles, role) {
+		var allowed bool
+		if role == constant.EnumRoleAPIKey {
+			scopes, _ := c.Get("SCOPES")
+			granted, _ := scopes.([]string)
+			allowed = slices.Contains(granted, permission)
+		} else {
+			var err error
+			allowed, err = roleService.HasPermission(c.Request.Context(), role, permission)
+			if err != nil {
+				_ = c.Error(base.NewAppError(http.StatusUnauthorized,
+					messages.MsgAuthFailedProcess, err))
+				c.Abort()
+				return
+			}
+		}
+
+		if !allowed {
 			_ = c.Error(base.NewAppError(http.StatusForbidden,
 				messages.MsgAuthActionUnauthorized, nil))
 			c.Abort()
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	errs "myapp/core/helper/errors"
+	"myapp/core/helper/messages"
+	"myapp/core/service"
+	"myapp/support/base"
+	"myapp/support/constant"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireVerifiedEmail rejects users whose email address is not verified
+// when EMAIL_VERIFICATION_POLICY covers routes. It must run after
+// Authenticate.
+func RequireVerifiedEmail(verificationService service.EmailVerificationService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := c.Get("ID")
+		if !ok {
+			_ = c.Error(base.NewAppError(http.StatusUnauthorized,
+				messages.MsgAuthFailedProcess, nil))
+			c.Abort()
+			return
+		}
+
+		err := verificationService.CheckEmailVerified(c.Request.Context(), id.(string),
+			constant.EnumEmailVerificationRoutes)
+		if errors.Is(err, errs.ErrEmailNotVerified) {
+			_ = c.Error(base.NewAppError(http.StatusForbidden,
+				messages.MsgUserEmailNotVerified, err))
+			c.Abort()
+			return
+		}
+		if err != nil {
+			_ = c.Error(base.NewAppError(http.StatusUnauthorized,
+				messages.MsgAuthFailedProcess, err))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"net/http"
+
+	"myapp/core/helper/messages"
+	"myapp/support/base"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ForbidImpersonation rejects requests made with an impersonation token, for
+// actions support staff must not take on a user's behalf. It must run after
+// Authenticate.
+func ForbidImpersonation() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("ACTOR_ID") != "" {
+			_ = c.Error(base.NewAppError(http.StatusForbidden,
+				messages.MsgAuthImpersonating, nil))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// requestIDPattern is what an incoming X-Request-ID must look like to be
+// kept, so a client cannot stuff arbitrary text into the audit log.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID tags every request with an ID, taken over from a proxy's
+// X-Request-ID header or generated, and echoes it in the response. It sets
+// REQUEST_ID and CLIENT_IP, which the audit log records.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader("X-Request-ID")
+		if !requestIDPattern.MatchString(id) {
+			id = uuid.NewString()
+		}
+
+		c.Set("REQUEST_ID", id)
+		c.Set("CLIENT_IP", c.ClientIP())
+		c.Header("X-Request-ID", id)
+		c.Next()
+	}
+}
@@ -1,10 +1,13 @@
 package middleware_test
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"myapp/core/helper/dto"
+	errs "myapp/core/helper/errors"
 	"myapp/core/service"
 	"myapp/support/middleware"
 
@@ -12,26 +15,67 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// --- Stubs ---
+
+// authServiceStub only implements the revocation check used by Authenticate.
+// revoked holds revoked token and session IDs.
+type authServiceStub struct {
+	service.AuthService
+	revoked map[string]bool
+}
+
+func (s *authServiceStub) VerifyAccessToken(_ context.Context, claims *service.TokenClaims) error {
+	if s.revoked[claims.TokenID] || s.revoked[claims.SessionID] {
+		return errs.ErrAccessTokenRevoked
+	}
+	return nil
+}
+
+// apiKeyServiceStub accepts the raw keys in keys.
+type apiKeyServiceStub struct {
+	service.APIKeyService
+	keys map[string]dto.APIKeyResponse
+}
+
+func (s *apiKeyServiceStub) AuthenticateAPIKey(_ context.Context, rawKey string) (dto.APIKeyResponse, error) {
+	key, ok := s.keys[rawKey]
+	if !ok {
+		return dto.APIKeyResponse{}, errs.ErrAPIKeyInvalid
+	}
+	return key, nil
+}
+
 // --- Test Helpers ---
 
-func setupAuthenticationTest(t *testing.T) (*gin.Engine, service.JWTService) {
+func setupAuthenticationTest(t *testing.T) (*gin.Engine, service.JWTService, *authServiceStub) {
 	t.Parallel()
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
 	r.Use(middleware.ErrorHandler())
 
-	jwtS := service.NewJWTService()
-	r.GET("/protected", middleware.Authenticate(jwtS), func(c *gin.Context) {
-		c.String(http.StatusOK, "ok")
+	jwtS, err := service.NewJWTService()
+	require.NoError(t, err)
+	authS := &authServiceStub{revoked: map[string]bool{}}
+	apiKeyS := &apiKeyServiceStub{keys: map[string]dto.APIKeyResponse{
+		"valid-key": {ID: "key-1", Scopes: []string{"products:stock"}},
+	}}
+	r.GET("/protected", middleware.Authenticate(jwtS, authS, apiKeyS), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("ID")+" "+c.GetString("ROLE"))
+	})
+	r.GET("/me", middleware.Authenticate(jwtS, authS, apiKeyS), middleware.ForbidAPIKey(), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("ID"))
+	})
+	r.GET("/session", middleware.Authenticate(jwtS, authS, apiKeyS), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("SESSION_ID"))
 	})
 
-	return r, jwtS
+	return r, jwtS, authS
 }
 
 // --- Tests ---
 
 func TestAuthenticate_MissingToken(t *testing.T) {
-	r, _ := setupAuthenticationTest(t)
+	r, _, _ := setupAuthenticationTest(t)
 
 	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
 	w := httptest.NewRecorder()
@@ -40,12 +84,82 @@ func TestAuthenticate_MissingToken(t *testing.T) {
 }
 
 func TestAuthenticate_ValidTokenAndRole(t *testing.T) {
-	r, jwtS := setupAuthenticationTest(t)
+	r, jwtS, _ := setupAuthenticationTest(t)
+
+	token := jwtS.GenerateToken("abc", "user", "")
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAuthenticate_RevokedToken(t *testing.T) {
+	r, jwtS, authS := setupAuthenticationTest(t)
+
+	token := jwtS.GenerateToken("abc", "user", "")
+	claims, err := jwtS.GetClaimsByToken(token)
+	require.NoError(t, err)
+	authS.revoked[claims.TokenID] = true
 
-	token := jwtS.GenerateToken("abc", "user")
 	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
 	req.Header.Set("Authorization", "Bearer "+token)
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthenticate_EndedSession(t *testing.T) {
+	r, jwtS, authS := setupAuthenticationTest(t)
+
+	token := jwtS.GenerateToken("abc", "user", "session-1")
+	req := httptest.NewRequest(http.MethodGet, "/session", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "session-1", w.Body.String())
+
+	authS.revoked["session-1"] = true
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAuthenticate_APIKey(t *testing.T) {
+	r, _, _ := setupAuthenticationTest(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("X-API-Key", "valid-key")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "key-1 api_key", w.Body.String())
+}
+
+func TestAuthenticate_InvalidAPIKey(t *testing.T) {
+	r, _, _ := setupAuthenticationTest(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("X-API-Key", "unknown-key")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestForbidAPIKey(t *testing.T) {
+	r, jwtS, _ := setupAuthenticationTest(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("X-API-Key", "valid-key")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.Header.Set("Authorization", "Bearer "+jwtS.GenerateToken("abc", "user", ""))
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
 	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "abc", w.Body.String())
 }
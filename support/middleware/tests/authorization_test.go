@@ -1,17 +1,33 @@
 package middleware_test
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"testing"
 
+	"myapp/core/helper/dto"
 	"myapp/core/service"
+	"myapp/support/constant"
 	"myapp/support/middleware"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/require"
 )
 
+// --- Stubs ---
+
+// roleServiceStub grants each role the permissions in permissions.
+type roleServiceStub struct {
+	service.RoleService
+	permissions map[string][]string
+}
+
+func (s *roleServiceStub) HasPermission(_ context.Context, role string, permission string) (bool, error) {
+	return slices.Contains(s.permissions[role], permission), nil
+}
+
 // --- Test Helpers ---
 
 func setupAuthorizationTest(t *testing.T) (*gin.Engine, service.JWTService) {
@@ -20,33 +36,46 @@ func setupAuthorizationTest(t *testing.T) (*gin.Engine, service.JWTService) {
 	r := gin.New()
 	r.Use(middleware.ErrorHandler())
 
-	jwtS := service.NewJWTService()
-	r.GET("/protected", middleware.Authenticate(jwtS), middleware.Authorize(), func(c *gin.Context) {
+	jwtS, err := service.NewJWTService()
+	require.NoError(t, err)
+	apiKeyS := &apiKeyServiceStub{keys: map[string]dto.APIKeyResponse{
+		"stock-key":   {ID: "key-1", Scopes: []string{constant.EnumPermissionProductsStock}},
+		"catalog-key": {ID: "key-2", Scopes: []string{constant.EnumPermissionProductsWrite}},
+	}}
+	roleS := &roleServiceStub{permissions: map[string][]string{
+		constant.EnumRoleAdmin:  {constant.EnumPermissionUsersRead, constant.EnumPermissionProductsStock},
+		"catalog_manager":       {constant.EnumPermissionProductsStock},
+		constant.EnumRoleAPIKey: {constant.EnumPermissionUsersRead},
+	}}
+	authenticate := middleware.Authenticate(jwtS, &authServiceStub{}, apiKeyS)
+	ok := func(c *gin.Context) {
 		c.String(http.StatusOK, "ok")
-	})
+	}
+	r.GET("/users", authenticate, middleware.RequirePermission(roleS, constant.EnumPermissionUsersRead), ok)
+	r.GET("/stock", authenticate, middleware.RequirePermission(roleS, constant.EnumPermissionProductsStock), ok)
 
 	return r, jwtS
 }
 
 // --- Tests ---
 
-func TestAuthorize_ForbiddenRole(t *testing.T) {
+func TestRequirePermission_ForbiddenRole(t *testing.T) {
 	r, jwtS := setupAuthorizationTest(t)
 
-	token := jwtS.GenerateToken("abc", "user")
-	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	token := jwtS.GenerateToken("abc", constant.EnumRoleUser, "")
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
 	req.Header.Set("Authorization", "Bearer "+token)
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 	require.Equal(t, http.StatusForbidden, w.Code)
 }
 
-func TestAuthorize_ValidRole(t *testing.T) {
+func TestRequirePermission_ValidRole(t *testing.T) {
 	r, jwtS := setupAuthorizationTest(t)
 
-	token := jwtS.GenerateToken("abc", "admin")
+	token := jwtS.GenerateToken("abc", constant.EnumRoleAdmin, "")
 
-	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
 	req.Header.Set("Authorization", "Bearer "+token)
 	w := httptest.NewRecorder()
 
@@ -55,3 +84,39 @@ func TestAuthorize_ValidRole(t *testing.T) {
 	require.Equal(t, http.StatusOK, w.Code)
 	require.Equal(t, "ok", w.Body.String())
 }
+
+func TestRequirePermission_APIKeyIgnoresRolePermissions(t *testing.T) {
+	r, _ := setupAuthorizationTest(t)
+
+	// The api_key role is granted users:read above, but keys only get their scopes
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set("X-API-Key", "stock-key")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestRequirePermission(t *testing.T) {
+	r, jwtS := setupAuthorizationTest(t)
+
+	testCases := []struct {
+		name   string
+		header string
+		value  string
+		want   int
+	}{
+		{"key with scope", "X-API-Key", "stock-key", http.StatusOK},
+		{"key without scope", "X-API-Key", "catalog-key", http.StatusForbidden},
+		{"admin token", "Authorization", "Bearer " + jwtS.GenerateToken("abc", constant.EnumRoleAdmin, ""), http.StatusOK},
+		{"custom role token", "Authorization", "Bearer " + jwtS.GenerateToken("abc", "catalog_manager", ""), http.StatusOK},
+		{"user token", "Authorization", "Bearer " + jwtS.GenerateToken("abc", constant.EnumRoleUser, ""), http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		req := httptest.NewRequest(http.MethodGet, "/stock", nil)
+		req.Header.Set(tc.header, tc.value)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		require.Equal(t, tc.want, w.Code, tc.name)
+	}
+}
@@ -0,0 +1,67 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	errs "myapp/core/helper/errors"
+	"myapp/core/service"
+	"myapp/support/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Stubs ---
+
+// verificationServiceStub treats the users in unverified as unverified.
+type verificationServiceStub struct {
+	service.EmailVerificationService
+	unverified map[string]bool
+}
+
+func (s *verificationServiceStub) CheckEmailVerified(_ context.Context, userID string, _ string) error {
+	if s.unverified[userID] {
+		return errs.ErrEmailNotVerified
+	}
+	return nil
+}
+
+// --- Test Helpers ---
+
+func setupEmailVerificationTest(t *testing.T, userID string) *gin.Engine {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+
+	verificationS := &verificationServiceStub{unverified: map[string]bool{"unverified": true}}
+	setID := func(c *gin.Context) { c.Set("ID", userID) }
+	r.GET("/protected", setID, middleware.RequireVerifiedEmail(verificationS), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	return r
+}
+
+// --- Tests ---
+
+func TestRequireVerifiedEmail_Verified(t *testing.T) {
+	r := setupEmailVerificationTest(t, "verified")
+
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRequireVerifiedEmail_Unverified(t *testing.T) {
+	r := setupEmailVerificationTest(t, "unverified")
+
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
@@ -0,0 +1,71 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"myapp/support/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Test Helpers ---
+
+// setupRequestIDTest echoes the request ID seen by handlers in the body.
+func setupRequestIDTest(t *testing.T) *gin.Engine {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(middleware.RequestID())
+	router.GET("/id", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString("REQUEST_ID"))
+	})
+	return router
+}
+
+// --- Tests ---
+
+func TestRequestID_Generated(t *testing.T) {
+	router := setupRequestIDTest(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/id", nil)
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	require.Equal(t, http.StatusOK, resp.Code)
+	_, err := uuid.Parse(resp.Body.String())
+	require.NoError(t, err)
+	require.Equal(t, resp.Body.String(), resp.Header().Get("X-Request-ID"))
+}
+
+func TestRequestID_KeepsIncoming(t *testing.T) {
+	router := setupRequestIDTest(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/id", nil)
+	req.Header.Set("X-Request-ID", "proxy-42.a_b")
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	require.Equal(t, "proxy-42.a_b", resp.Body.String())
+	require.Equal(t, "proxy-42.a_b", resp.Header().Get("X-Request-ID"))
+}
+
+func TestRequestID_ReplacesMalformed(t *testing.T) {
+	router := setupRequestIDTest(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/id", nil)
+	req.Header.Set("X-Request-ID", "bad id\" injected")
+	resp := httptest.NewRecorder()
+
+	router.ServeHTTP(resp, req)
+
+	require.NotEqual(t, "bad id\" injected", resp.Body.String())
+	_, err := uuid.Parse(resp.Body.String())
+	require.NoError(t, err)
+}
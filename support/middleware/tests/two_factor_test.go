@@ -0,0 +1,81 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	errs "myapp/core/helper/errors"
+	"myapp/core/service"
+	"myapp/support/constant"
+	"myapp/support/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+// --- Stubs ---
+
+// twoFactorServiceStub requires two-factor authentication of admins and
+// treats the users in enrolled as enrolled.
+type twoFactorServiceStub struct {
+	service.TwoFactorService
+	enrolled map[string]bool
+}
+
+func (s *twoFactorServiceStub) CheckTwoFactorRequired(_ context.Context, userID string, role string) error {
+	if role == constant.EnumRoleAdmin && !s.enrolled[userID] {
+		return errs.ErrTwoFactorRequired
+	}
+	return nil
+}
+
+// --- Test Helpers ---
+
+func setupTwoFactorTest(t *testing.T, userID string, role string) *gin.Engine {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(middleware.ErrorHandler())
+
+	twoFactorS := &twoFactorServiceStub{enrolled: map[string]bool{"enrolled": true}}
+	setClaims := func(c *gin.Context) {
+		c.Set("ID", userID)
+		c.Set("ROLE", role)
+	}
+	r.GET("/admin", setClaims, middleware.RequireTwoFactor(twoFactorS), func(c *gin.Context) {
+		c.String(http.StatusOK, "ok")
+	})
+
+	return r
+}
+
+// --- Tests ---
+
+func TestRequireTwoFactor_EnrolledAdmin(t *testing.T) {
+	r := setupTwoFactorTest(t, "enrolled", constant.EnumRoleAdmin)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestRequireTwoFactor_UnenrolledAdmin(t *testing.T) {
+	r := setupTwoFactorTest(t, "unenrolled", constant.EnumRoleAdmin)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestRequireTwoFactor_UnenrolledUser(t *testing.T) {
+	r := setupTwoFactorTest(t, "unenrolled", constant.EnumRoleUser)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+}
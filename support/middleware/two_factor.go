@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	errs "myapp/core/helper/errors"
+	"myapp/core/helper/messages"
+	"myapp/core/service"
+	"myapp/support/base"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireTwoFactor rejects admins without two-factor authentication when
+// TWO_FACTOR_REQUIRE_ADMIN is set. It must run after Authenticate. Such
+// admins can still log in, so they are able to enroll.
+func RequireTwoFactor(twoFactorService service.TwoFactorService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, idOK := c.Get("ID")
+		role, roleOK := c.Get("ROLE")
+		if !idOK || !roleOK {
+			_ = c.Error(base.NewAppError(http.StatusUnauthorized,
+				messages.MsgAuthFailedProcess, nil))
+			c.Abort()
+			return
+		}
+
+		err := twoFactorService.CheckTwoFactorRequired(c.Request.Context(), id.(string), role.(string))
+		if errors.Is(err, errs.ErrTwoFactorRequired) {
+			_ = c.Error(base.NewAppError(http.StatusForbidden,
+				messages.MsgAuthTwoFactorRequired, err))
+			c.Abort()
+			return
+		}
+		if err != nil {
+			_ = c.Error(base.NewAppError(http.StatusUnauthorized,
+				messages.MsgAuthFailedProcess, err))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,66 @@
+package util
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"myapp/support/logger"
+)
+
+// GetEnv returns the value of the environment variable or the given default
+// when it is unset or empty.
+func GetEnv(key string, def string) string {
+	if val := os.Getenv(key); val != "" {
+		return val
+	}
+	return def
+}
+
+// GetEnvDuration parses a Go duration string (e.g. "15m", "720h") from the
+// environment, falling back to the default when unset or invalid.
+func GetEnvDuration(key string, def time.Duration) time.Duration {
+	val := os.Getenv(key)
+	if val == "" {
+		return def
+	}
+
+	d, err := time.ParseDuration(val)
+	if err != nil || d <= 0 {
+		logger.Warn("Invalid duration for %s (%q), using default %v", key, val, def)
+		return def
+	}
+	return d
+}
+
+// GetEnvInt parses an integer from the environment, falling back to the
+// default when unset or invalid.
+func GetEnvInt(key string, def int) int {
+	val := os.Getenv(key)
+	if val == "" {
+		return def
+	}
+
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		logger.Warn("Invalid integer for %s (%q), using default %d", key, val, def)
+		return def
+	}
+	return n
+}
+
+// GetEnvBool parses a boolean from the environment, falling back to the
+// default when unset or invalid.
+func GetEnvBool(key string, def bool) bool {
+	val := os.Getenv(key)
+	if val == "" {
+		return def
+	}
+
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		logger.Warn("Invalid boolean for %s (%q), using default %t", key, val, def)
+		return def
+	}
+	return b
+}
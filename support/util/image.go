@@ -0,0 +1,162 @@
+package util
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+
+	_ "golang.org/x/image/webp"
+)
+
+var errInvalidWebP = errors.New("invalid webp header")
+
+// ImageDimensions reads the width and height of an image from its header
+// without decoding the pixels. contentType is the sniffed MIME type; WebP
+// is read from its first chunk here.
+func ImageDimensions(r io.Reader, contentType string) (int, int, error) {
+	if contentType == "image/webp" {
+		return webpDimensions(r)
+	}
+
+	cfg, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return 0, 0, err
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+// webpDimensions reads the canvas size from the first chunk of a WebP file,
+// which is VP8 (lossy), VP8L (lossless) or VP8X (extended).
+func webpDimensions(r io.Reader) (int, int, error) {
+	head := make([]byte, 30)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return 0, 0, errInvalidWebP
+	}
+	head = head[:n]
+	if n < 20 || string(head[0:4]) != "RIFF" || string(head[8:12]) != "WEBP" {
+		return 0, 0, errInvalidWebP
+	}
+
+	// A lossless header is the shortest, at 25 bytes
+	switch chunk := string(head[12:16]); {
+	case chunk == "VP8L" && n >= 25:
+		if head[20] != 0x2f {
+			return 0, 0, errInvalidWebP
+		}
+		bits := binary.LittleEndian.Uint32(head[21:25])
+		return int(bits&0x3fff) + 1, int(bits>>14&0x3fff) + 1, nil
+	case n < 30:
+		return 0, 0, errInvalidWebP
+	case chunk == "VP8 ":
+		// Frame tag, then the start code of a key frame
+		if head[23] != 0x9d || head[24] != 0x01 || head[25] != 0x2a {
+			return 0, 0, errInvalidWebP
+		}
+		width := int(binary.LittleEndian.Uint16(head[26:28]) & 0x3fff)
+		height := int(binary.LittleEndian.Uint16(head[28:30]) & 0x3fff)
+		return width, height, nil
+	case chunk == "VP8X":
+		width := int(head[24]) | int(head[25])<<8 | int(head[26])<<16
+		height := int(head[27]) | int(head[28])<<8 | int(head[29])<<16
+		return width + 1, height + 1, nil
+	default:
+		return 0, 0, errInvalidWebP
+	}
+}
+
+// OrientImage turns img upright according to its EXIF orientation.
+func OrientImage(img image.Image, orientation int) *image.RGBA {
+	src := toRGBA(img)
+	if orientation < 2 || orientation > 8 {
+		return src
+	}
+
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		// These turn the image by a quarter
+		dw, dh = h, w
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored
+				sx, sy = w-1-x, y
+			case 3: // upside down
+				sx, sy = w-1-x, h-1-y
+			case 4: // upside down and mirrored
+				sx, sy = x, h-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // needs turning clockwise
+				sx, sy = y, h-1-x
+			case 7: // transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // needs turning counterclockwise
+				sx, sy = w-1-y, x
+			}
+			i, j := dst.PixOffset(x, y), src.PixOffset(sx, sy)
+			copy(dst.Pix[i:i+4], src.Pix[j:j+4])
+		}
+	}
+	return dst
+}
+
+// ResizeImage scales img down to fit within maxSize pixels on both sides,
+// keeping its aspect ratio, by averaging the pixels each one covers.
+// Images that already fit, or a maxSize of 0, keep their size.
+func ResizeImage(img image.Image, maxSize int) *image.RGBA {
+	src := toRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	if maxSize <= 0 || (w <= maxSize && h <= maxSize) {
+		return src
+	}
+
+	dw, dh := maxSize, max(1, (h*maxSize+w/2)/w)
+	if h > w {
+		dw, dh = max(1, (w*maxSize+h/2)/h), maxSize
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := y*h/dh, (y+1)*h/dh
+		for x := 0; x < dw; x++ {
+			x0, x1 := x*w/dw, (x+1)*w/dw
+
+			// Premultiplied, so transparent pixels do not darken edges
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[src.PixOffset(x0, sy):src.PixOffset(x1, sy)]
+				for i := 0; i < len(row); i += 4 {
+					sum[0] += int(row[i])
+					sum[1] += int(row[i+1])
+					sum[2] += int(row[i+2])
+					sum[3] += int(row[i+3])
+				}
+			}
+			n := (x1 - x0) * (y1 - y0)
+			pix := dst.Pix[dst.PixOffset(x, y):]
+			for c := 0; c < 4; c++ {
+				pix[c] = uint8((sum[c] + n/2) / n)
+			}
+		}
+	}
+	return dst
+}
+
+// toRGBA copies img into an RGBA image starting at the origin.
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+	return dst
+}
@@ -0,0 +1,363 @@
+package util
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+var (
+	errInvalidJPEG = errors.New("invalid jpeg stream")
+	errInvalidPNG  = errors.New("invalid png stream")
+)
+
+const (
+	jpegMarkerSOS  = 0xda
+	jpegMarkerEOI  = 0xd9
+	jpegMarkerAPP0 = 0xe0
+)
+
+// jpegDroppedMarkers hold EXIF and XMP (APP1), IPTC (APP13) and comments.
+// ICC color profiles (APP2) are kept since colors depend on them.
+var jpegDroppedMarkers = map[byte]bool{0xe1: true, 0xed: true, 0xfe: true}
+
+var pngDroppedChunks = map[string]bool{"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true}
+
+var webpDroppedChunks = map[string]bool{"EXIF": true, "XMP ": true}
+
+// StripImageMetadata copies the image in src to dst without the metadata
+// that can tell where, when or with what it was taken: EXIF, XMP and IPTC
+// in JPEG, text and EXIF chunks in PNG, EXIF and XMP in WebP. A JPEG keeps
+// its EXIF orientation in a minimal EXIF segment so it still displays
+// upright. Other types are copied as they are. It returns how many bytes
+// were written.
+func StripImageMetadata(dst io.Writer, src io.ReadSeeker, contentType string) (int64, error) {
+	w := &countingWriter{w: dst}
+	var err error
+	switch contentType {
+	case "image/jpeg":
+		orientation := ImageOrientation(src, contentType)
+		if _, err := src.Seek(0, io.SeekStart); err != nil {
+			return 0, err
+		}
+		err = stripJPEG(w, bufio.NewReader(src), orientation)
+	case "image/png":
+		err = stripPNG(w, src)
+	case "image/webp":
+		err = stripWebP(w, src)
+	default:
+		_, err = io.Copy(w, src)
+	}
+	return w.n, err
+}
+
+// ImageOrientation returns the EXIF orientation of a JPEG, from 1 (upright)
+// to 8, and 1 for anything else or when there is none.
+func ImageOrientation(r io.Reader, contentType string) int {
+	if contentType != "image/jpeg" {
+		return 1
+	}
+
+	br := bufio.NewReader(r)
+	var soi [2]byte
+	if _, err := io.ReadFull(br, soi[:]); err != nil || soi != [2]byte{0xff, 0xd8} {
+		return 1
+	}
+	for {
+		marker, err := readJPEGMarker(br)
+		if err != nil || marker == jpegMarkerSOS || marker == jpegMarkerEOI {
+			return 1
+		}
+		if jpegStandalone(marker) {
+			continue
+		}
+		segment, err := readJPEGSegment(br)
+		if err != nil {
+			return 1
+		}
+		if marker == 0xe1 && len(segment) > 6 && string(segment[:6]) == "Exif\x00\x00" {
+			return exifOrientation(segment[6:])
+		}
+	}
+}
+
+// exifOrientation looks up the orientation tag in the first IFD of a TIFF
+// structure.
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:8]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 && order.Uint16(tiff[entry+2:]) == 3 {
+			if value := int(order.Uint16(tiff[entry+8:])); value >= 1 && value <= 8 {
+				return value
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// exifOrientationSegment builds an APP1 segment holding nothing but the
+// orientation tag.
+func exifOrientationSegment(orientation int) []byte {
+	return []byte{
+		0xff, 0xe1, 0, 34,
+		'E', 'x', 'i', 'f', 0, 0,
+		'M', 'M', 0, 42, 0, 0, 0, 8, // TIFF header, first IFD at 8
+		0, 1, // one entry
+		0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, byte(orientation), 0, 0,
+		0, 0, 0, 0, // no next IFD
+	}
+}
+
+func stripJPEG(w io.Writer, r *bufio.Reader, orientation int) error {
+	var soi [2]byte
+	if _, err := io.ReadFull(r, soi[:]); err != nil || soi != [2]byte{0xff, 0xd8} {
+		return errInvalidJPEG
+	}
+	if _, err := w.Write(soi[:]); err != nil {
+		return err
+	}
+
+	// JFIF wants its APP0 right after SOI, so the orientation goes after it
+	exifWritten := orientation <= 1
+	writeExif := func() error {
+		exifWritten = true
+		_, err := w.Write(exifOrientationSegment(orientation))
+		return err
+	}
+
+	for {
+		marker, err := readJPEGMarker(r)
+		if err != nil {
+			return err
+		}
+		if !exifWritten && marker != jpegMarkerAPP0 && !jpegStandalone(marker) {
+			if err := writeExif(); err != nil {
+				return err
+			}
+		}
+
+		switch {
+		case marker == jpegMarkerEOI:
+			_, err := w.Write([]byte{0xff, marker})
+			return err
+		case marker == jpegMarkerSOS:
+			// Entropy coded data up to the end, with no metadata in it
+			if _, err := w.Write([]byte{0xff, marker}); err != nil {
+				return err
+			}
+			_, err := io.Copy(w, r)
+			return err
+		case jpegStandalone(marker):
+			if _, err := w.Write([]byte{0xff, marker}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		segment, err := readJPEGSegment(r)
+		if err != nil {
+			return err
+		}
+		if jpegDroppedMarkers[marker] {
+			continue
+		}
+		header := []byte{0xff, marker, 0, 0}
+		binary.BigEndian.PutUint16(header[2:], uint16(len(segment)+2))
+		if _, err := w.Write(header); err != nil {
+			return err
+		}
+		if _, err := w.Write(segment); err != nil {
+			return err
+		}
+		if marker == jpegMarkerAPP0 && !exifWritten {
+			if err := writeExif(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// readJPEGMarker reads the next marker, skipping fill bytes.
+func readJPEGMarker(r *bufio.Reader) (byte, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if b != 0xff {
+		return 0, errInvalidJPEG
+	}
+	for b == 0xff {
+		if b, err = r.ReadByte(); err != nil {
+			return 0, err
+		}
+	}
+	return b, nil
+}
+
+// readJPEGSegment reads the payload of a segment after its length.
+func readJPEGSegment(r *bufio.Reader) ([]byte, error) {
+	var length [2]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, err
+	}
+	n := int(binary.BigEndian.Uint16(length[:]))
+	if n < 2 {
+		return nil, errInvalidJPEG
+	}
+	segment := make([]byte, n-2)
+	if _, err := io.ReadFull(r, segment); err != nil {
+		return nil, err
+	}
+	return segment, nil
+}
+
+// jpegStandalone reports markers that carry no length or payload.
+func jpegStandalone(marker byte) bool {
+	return marker == 0x01 || (marker >= 0xd0 && marker <= 0xd8)
+}
+
+func stripPNG(w io.Writer, r io.Reader) error {
+	var signature [8]byte
+	if _, err := io.ReadFull(r, signature[:]); err != nil || string(signature[:]) != "\x89PNG\r\n\x1a\n" {
+		return errInvalidPNG
+	}
+	if _, err := w.Write(signature[:]); err != nil {
+		return err
+	}
+
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return errInvalidPNG
+		}
+		// Data and CRC
+		size := int64(binary.BigEndian.Uint32(header[:4])) + 4
+		chunk := string(header[4:])
+
+		if pngDroppedChunks[chunk] {
+			if _, err := io.CopyN(io.Discard, r, size); err != nil {
+				return errInvalidPNG
+			}
+			continue
+		}
+		if _, err := w.Write(header[:]); err != nil {
+			return err
+		}
+		if _, err := io.CopyN(w, r, size); err != nil {
+			return err
+		}
+		if chunk == "IEND" {
+			return nil
+		}
+	}
+}
+
+// stripWebP reads the chunk headers first to know the size of the RIFF
+// container without the dropped chunks, then copies the rest.
+func stripWebP(w io.Writer, r io.ReadSeeker) error {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil ||
+		string(header[:4]) != "RIFF" || string(header[8:]) != "WEBP" {
+		return errInvalidWebP
+	}
+	riffSize := int64(binary.LittleEndian.Uint32(header[4:8]))
+
+	dropped := int64(0)
+	for offset := int64(4); offset < riffSize; {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return errInvalidWebP
+		}
+		size := webpChunkSize(chunk)
+		if webpDroppedChunks[string(chunk[:4])] {
+			dropped += 8 + size
+		}
+		if _, err := r.Seek(size, io.SeekCurrent); err != nil {
+			return err
+		}
+		offset += 8 + size
+	}
+	if _, err := r.Seek(12, io.SeekStart); err != nil {
+		return err
+	}
+
+	binary.LittleEndian.PutUint32(header[4:8], uint32(riffSize-dropped))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	for offset := int64(4); offset < riffSize; {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return errInvalidWebP
+		}
+		size := webpChunkSize(chunk)
+		offset += 8 + size
+
+		name := string(chunk[:4])
+		if webpDroppedChunks[name] {
+			if _, err := r.Seek(size, io.SeekCurrent); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := w.Write(chunk[:]); err != nil {
+			return err
+		}
+		if name == "VP8X" && size > 0 {
+			// Clear the flags announcing EXIF and XMP
+			flags := []byte{0}
+			if _, err := io.ReadFull(r, flags); err != nil {
+				return errInvalidWebP
+			}
+			flags[0] &^= 0x0c
+			if _, err := w.Write(flags); err != nil {
+				return err
+			}
+			size--
+		}
+		if _, err := io.CopyN(w, r, size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// webpChunkSize is the padded size of the chunk payload.
+func webpChunkSize(header [8]byte) int64 {
+	size := int64(binary.LittleEndian.Uint32(header[4:]))
+	return size + size&1
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,196 @@
+package util
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"myapp/support/constant"
+	"myapp/support/logger"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	PasswordAlgorithmBcrypt   = "bcrypt"
+	PasswordAlgorithmArgon2id = "argon2id"
+)
+
+var errPasswordHashFormat = errors.New("unrecognized password hash format")
+
+// PasswordHashPolicy decides how new password hashes are made. Every hash
+// records its algorithm and parameters, bcrypt as "$2a$<cost>$..." and
+// argon2id as a PHC string "$argon2id$v=19$m=<KiB>,t=<passes>,p=<threads>$<salt>$<key>",
+// so hashes made under an older policy keep verifying and can be told apart
+// for rehashing.
+type PasswordHashPolicy struct {
+	Algorithm     string
+	BcryptCost    int
+	Argon2Memory  uint32
+	Argon2Time    uint32
+	Argon2Threads uint8
+}
+
+var passwordHashPolicy atomic.Pointer[PasswordHashPolicy]
+
+// LoadPasswordHashPolicy reads the hash policy from the environment.
+func LoadPasswordHashPolicy() PasswordHashPolicy {
+	policy := PasswordHashPolicy{
+		Algorithm:     GetEnv("PASSWORD_HASH_ALGORITHM", constant.DefaultPasswordHashAlgorithm),
+		BcryptCost:    GetEnvInt("PASSWORD_BCRYPT_COST", constant.DefaultPasswordBcryptCost),
+		Argon2Memory:  uint32(GetEnvInt("PASSWORD_ARGON2_MEMORY", constant.DefaultPasswordArgon2Memory)),
+		Argon2Time:    uint32(GetEnvInt("PASSWORD_ARGON2_TIME", constant.DefaultPasswordArgon2Time)),
+		Argon2Threads: uint8(GetEnvInt("PASSWORD_ARGON2_THREADS", constant.DefaultPasswordArgon2Threads)),
+	}
+
+	if policy.Algorithm != PasswordAlgorithmBcrypt && policy.Algorithm != PasswordAlgorithmArgon2id {
+		logger.Warn("Unknown password hash algorithm %q, using %s", policy.Algorithm,
+			constant.DefaultPasswordHashAlgorithm)
+		policy.Algorithm = constant.DefaultPasswordHashAlgorithm
+	}
+	if policy.BcryptCost < bcrypt.MinCost || policy.BcryptCost > bcrypt.MaxCost {
+		logger.Warn("Invalid bcrypt cost %d, using %d", policy.BcryptCost, constant.DefaultPasswordBcryptCost)
+		policy.BcryptCost = constant.DefaultPasswordBcryptCost
+	}
+	if policy.Argon2Memory == 0 || policy.Argon2Time == 0 || policy.Argon2Threads == 0 {
+		logger.Warn("Invalid argon2id parameters, using the defaults")
+		policy.Argon2Memory = constant.DefaultPasswordArgon2Memory
+		policy.Argon2Time = constant.DefaultPasswordArgon2Time
+		policy.Argon2Threads = constant.DefaultPasswordArgon2Threads
+	}
+	return policy
+}
+
+// SetPasswordHashPolicy replaces the policy new hashes are made with. Without
+// it the policy is loaded from the environment on first use.
+func SetPasswordHashPolicy(policy PasswordHashPolicy) {
+	passwordHashPolicy.Store(&policy)
+}
+
+func currentPasswordHashPolicy() PasswordHashPolicy {
+	if policy := passwordHashPolicy.Load(); policy != nil {
+		return *policy
+	}
+	policy := LoadPasswordHashPolicy()
+	passwordHashPolicy.CompareAndSwap(nil, &policy)
+	return *passwordHashPolicy.Load()
+}
+
+// ============== Helper Functions ==============
+
+type argon2idHash struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+func parseArgon2idHash(hashed string) (argon2idHash, error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(hashed, "$")
+	if len(parts) != 6 || parts[1] != PasswordAlgorithmArgon2id {
+		return argon2idHash{}, errPasswordHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return argon2idHash{}, errPasswordHashFormat
+	}
+
+	var h argon2idHash
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.memory, &h.time, &h.threads); err != nil {
+		return argon2idHash{}, errPasswordHashFormat
+	}
+
+	var err error
+	if h.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return argon2idHash{}, errPasswordHashFormat
+	}
+	if h.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(h.key) == 0 {
+		return argon2idHash{}, errPasswordHashFormat
+	}
+	return h, nil
+}
+
+func hashArgon2id(password string, policy PasswordHashPolicy) (string, error) {
+	salt := make([]byte, constant.PasswordArgon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, policy.Argon2Time, policy.Argon2Memory,
+		policy.Argon2Threads, constant.PasswordArgon2KeyLength)
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", PasswordAlgorithmArgon2id, argon2.Version,
+		policy.Argon2Memory, policy.Argon2Time, policy.Argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func isArgon2idHash(hashed string) bool {
+	return strings.HasPrefix(hashed, "$"+PasswordAlgorithmArgon2id+"$")
+}
+
+// PasswordCompare checks a password against a hash made by PasswordHash
+// under the current or any earlier policy.
+func PasswordCompare(hashed string, password []byte) (bool, error) {
+	if isArgon2idHash(hashed) {
+		h, err := parseArgon2idHash(hashed)
+		if err != nil {
+			return false, err
+		}
+		key := argon2.IDKey(password, h.salt, h.time, h.memory, h.threads, uint32(len(h.key)))
+		return subtle.ConstantTimeCompare(key, h.key) == 1, nil
+	}
+
+	hashByte := []byte(hashed)
+	err := bcrypt.CompareHashAndPassword(hashByte, password)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// PasswordHash hashes a password with the current policy.
+func PasswordHash(password string) (string, error) {
+	policy := currentPasswordHashPolicy()
+	if policy.Algorithm == PasswordAlgorithmArgon2id {
+		return hashArgon2id(password, policy)
+	}
+
+	pwByte := []byte(password)
+	hashed, err := bcrypt.GenerateFromPassword(pwByte, policy.BcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+// PasswordNeedsRehash reports whether a hash was made with another algorithm
+// or other parameters than the current policy asks for. Callers rehash it
+// once they know the password, i.e. after a successful log in.
+func PasswordNeedsRehash(hashed string) bool {
+	policy := currentPasswordHashPolicy()
+
+	if isArgon2idHash(hashed) {
+		if policy.Algorithm != PasswordAlgorithmArgon2id {
+			return true
+		}
+		h, err := parseArgon2idHash(hashed)
+		if err != nil {
+			return true
+		}
+		return h.memory != policy.Argon2Memory || h.time != policy.Argon2Time ||
+			h.threads != policy.Argon2Threads || len(h.key) != constant.PasswordArgon2KeyLength
+	}
+
+	if policy.Algorithm != PasswordAlgorithmBcrypt {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(hashed))
+	return err != nil || cost != policy.BcryptCost
+}
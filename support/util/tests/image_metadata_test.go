@@ -0,0 +1,144 @@
+package util_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"slices"
+	"testing"
+
+	"myapp/support/util"
+
+	"github.com/stretchr/testify/require"
+)
+
+// exifSegment builds an APP1 segment with the orientation and a camera
+// make, in little endian unlike the one written back.
+func exifSegment(orientation byte) []byte {
+	return []byte{
+		0xff, 0xe1, 0, 46, 'E', 'x', 'i', 'f', 0, 0,
+		'I', 'I', 42, 0, 8, 0, 0, 0, 2, 0,
+		0x12, 0x01, 3, 0, 1, 0, 0, 0, orientation, 0, 0, 0,
+		0x0f, 0x01, 2, 0, 4, 0, 0, 0, 'A', 'C', 'M', 0,
+		0, 0, 0, 0,
+	}
+}
+
+// jpegMarkers walks the segments of a JPEG up to the scan, failing on any
+// length that does not land on the next marker.
+func jpegMarkers(t *testing.T, data []byte) []byte {
+	t.Helper()
+	require.Equal(t, []byte{0xff, 0xd8}, data[:2])
+	var markers []byte
+	for pos := 2; ; {
+		require.Equal(t, byte(0xff), data[pos], "segment at %d", pos)
+		marker := data[pos+1]
+		markers = append(markers, marker)
+		if marker == 0xda {
+			return markers
+		}
+		pos += 2 + int(binary.BigEndian.Uint16(data[pos+2:]))
+	}
+}
+
+func pngChunk(name string, data []byte) []byte {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, name...)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+func TestStripImageMetadata_JPEG(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8)), nil))
+	encoded := buf.Bytes()
+	comment := append([]byte{0xff, 0xfe, 0, 7}, "hello"...)
+	jfif := []byte{0xff, 0xe0, 0, 16, 'J', 'F', 'I', 'F', 0, 1, 1, 0, 0, 1, 0, 1, 0, 0}
+
+	tests := []struct {
+		name        string
+		orientation byte
+		markers     []byte
+	}{
+		// Only the orientation survives, after the JFIF header
+		{"rotated", 6, []byte{0xe0, 0xe1, 0xdb}},
+		{"upright", 1, []byte{0xe0, 0xdb}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := slices.Concat(encoded[:2], jfif, exifSegment(tt.orientation), comment, encoded[2:])
+			require.Equal(t, int(tt.orientation), util.ImageOrientation(bytes.NewReader(src), "image/jpeg"))
+
+			var out bytes.Buffer
+			n, err := util.StripImageMetadata(&out, bytes.NewReader(src), "image/jpeg")
+			require.NoError(t, err)
+			require.Equal(t, int64(out.Len()), n)
+
+			stripped := out.Bytes()
+			require.Equal(t, tt.markers, jpegMarkers(t, stripped)[:len(tt.markers)])
+			require.NotContains(t, string(stripped), "ACM")
+			require.NotContains(t, string(stripped), "hello")
+			require.Equal(t, int(tt.orientation), util.ImageOrientation(bytes.NewReader(stripped), "image/jpeg"))
+
+			_, err = jpeg.Decode(bytes.NewReader(stripped))
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestStripImageMetadata_PNG(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 8))))
+	encoded := buf.Bytes()
+
+	// Right after IHDR
+	src := slices.Concat(encoded[:33],
+		pngChunk("tEXt", []byte("Author\x00someone")),
+		pngChunk("eXIf", []byte("MM\x00\x2a")),
+		encoded[33:])
+
+	var out bytes.Buffer
+	n, err := util.StripImageMetadata(&out, bytes.NewReader(src), "image/png")
+	require.NoError(t, err)
+	require.Equal(t, int64(out.Len()), n)
+	require.Equal(t, encoded, out.Bytes())
+}
+
+func TestStripImageMetadata_WebP(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, util.EncodeWebP(&buf, image.NewRGBA(image.Rect(0, 0, 3, 2))))
+	encoded := buf.Bytes()
+
+	extended := []byte("VP8X\x0a\x00\x00\x00\x0c\x00\x00\x00\x02\x00\x00\x01\x00\x00")
+	exif := []byte("EXIF\x03\x00\x00\x00ACM\x00")
+	xmp := []byte("XMP \x04\x00\x00\x00<x/>")
+	src := slices.Concat([]byte("RIFF\x00\x00\x00\x00WEBP"), extended, encoded[12:], exif, xmp)
+	binary.LittleEndian.PutUint32(src[4:], uint32(len(src)-8))
+
+	var out bytes.Buffer
+	n, err := util.StripImageMetadata(&out, bytes.NewReader(src), "image/webp")
+	require.NoError(t, err)
+	require.Equal(t, int64(out.Len()), n)
+
+	stripped := out.Bytes()
+	extended[8] = 0
+	expected := slices.Concat([]byte("RIFF\x00\x00\x00\x00WEBP"), extended, encoded[12:])
+	binary.LittleEndian.PutUint32(expected[4:], uint32(len(expected)-8))
+	require.Equal(t, expected, stripped)
+}
+
+func TestStripImageMetadata_Invalid(t *testing.T) {
+	for _, contentType := range []string{"image/jpeg", "image/png", "image/webp"} {
+		_, err := util.StripImageMetadata(&bytes.Buffer{}, bytes.NewReader([]byte("not an image")), contentType)
+		require.Error(t, err, contentType)
+	}
+
+	// Anything else goes through untouched
+	var out bytes.Buffer
+	_, err := util.StripImageMetadata(&out, bytes.NewReader([]byte("GIF89a")), "image/gif")
+	require.NoError(t, err)
+	require.Equal(t, "GIF89a", out.String())
+}
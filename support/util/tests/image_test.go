@@ -0,0 +1,191 @@
+package util_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"myapp/support/util"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/image/webp"
+)
+
+// webpHeader builds the first bytes of a WebP file with the given chunk.
+func webpHeader(chunk string, payload []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	_ = binary.Write(&b, binary.LittleEndian, uint32(4+8+len(payload)))
+	b.WriteString("WEBP")
+	b.WriteString(chunk)
+	_ = binary.Write(&b, binary.LittleEndian, uint32(len(payload)))
+	b.Write(payload)
+	return b.Bytes()
+}
+
+func TestImageDimensions_PNG(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 40, 30))))
+
+	width, height, err := util.ImageDimensions(&buf, "image/png")
+	require.NoError(t, err)
+	require.Equal(t, 40, width)
+	require.Equal(t, 30, height)
+}
+
+func TestImageDimensions_WebP(t *testing.T) {
+	lossy := []byte{0, 0, 0, 0x9d, 0x01, 0x2a, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint16(lossy[6:], 640)
+	binary.LittleEndian.PutUint16(lossy[8:], 480)
+
+	lossless := []byte{0x2f, 0, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(lossless[1:], uint32(100-1)|uint32(50-1)<<14)
+
+	extended := []byte{0, 0, 0, 0, 0xff, 0x0f, 0, 0xdf, 0x07, 0}
+
+	tests := []struct {
+		name          string
+		data          []byte
+		width, height int
+	}{
+		{"lossy", webpHeader("VP8 ", lossy), 640, 480},
+		{"lossless", webpHeader("VP8L", lossless), 100, 50},
+		{"extended", webpHeader("VP8X", extended), 4096, 2016},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width, height, err := util.ImageDimensions(bytes.NewReader(tt.data), "image/webp")
+			require.NoError(t, err)
+			require.Equal(t, tt.width, width)
+			require.Equal(t, tt.height, height)
+		})
+	}
+}
+
+func TestImageDimensions_Invalid(t *testing.T) {
+	_, _, err := util.ImageDimensions(bytes.NewReader([]byte("RIFF....WEBPnope")), "image/webp")
+	require.Error(t, err)
+
+	_, _, err = util.ImageDimensions(bytes.NewReader([]byte("not an image")), "image/png")
+	require.Error(t, err)
+}
+
+// corners builds a 3x2 image with a distinct color in each corner.
+func corners() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 3, 2))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	img.Set(2, 0, color.RGBA{G: 255, A: 255})
+	img.Set(0, 1, color.RGBA{B: 255, A: 255})
+	img.Set(2, 1, color.RGBA{R: 255, G: 255, A: 255})
+	return img
+}
+
+func TestOrientImage(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	tests := []struct {
+		orientation   int
+		width, height int
+		x, y          int // where the top left pixel ends up
+	}{
+		{1, 3, 2, 0, 0},
+		{2, 3, 2, 2, 0},
+		{3, 3, 2, 2, 1},
+		{4, 3, 2, 0, 1},
+		{5, 2, 3, 0, 0},
+		{6, 2, 3, 1, 0},
+		{7, 2, 3, 1, 2},
+		{8, 2, 3, 0, 2},
+		{9, 3, 2, 0, 0},
+	}
+	for _, tt := range tests {
+		img := util.OrientImage(corners(), tt.orientation)
+		require.Equal(t, image.Rect(0, 0, tt.width, tt.height), img.Bounds(), tt.orientation)
+		require.Equal(t, red, img.RGBAAt(tt.x, tt.y), tt.orientation)
+	}
+}
+
+func TestResizeImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 400, 100))
+	for x := 200; x < 400; x++ {
+		for y := 0; y < 100; y++ {
+			img.Set(x, y, color.RGBA{R: 200, A: 255})
+		}
+	}
+
+	resized := util.ResizeImage(img, 100)
+	require.Equal(t, image.Rect(0, 0, 100, 25), resized.Bounds())
+	require.Equal(t, color.RGBA{}, resized.RGBAAt(10, 10))
+	require.Equal(t, color.RGBA{R: 200, A: 255}, resized.RGBAAt(90, 10))
+
+	// Only ever smaller
+	require.Equal(t, image.Rect(0, 0, 400, 100), util.ResizeImage(img, 1000).Bounds())
+	require.Equal(t, image.Rect(0, 0, 400, 100), util.ResizeImage(img, 0).Bounds())
+	require.Equal(t, image.Rect(0, 0, 1, 10), util.ResizeImage(image.NewRGBA(image.Rect(0, 0, 2, 200)), 10).Bounds())
+}
+
+func TestEncodeWebP(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, util.EncodeWebP(&buf, corners()))
+
+	data := buf.Bytes()
+	require.Equal(t, "RIFF", string(data[:4]))
+	require.Equal(t, uint32(len(data)-8), binary.LittleEndian.Uint32(data[4:]))
+	require.Equal(t, "WEBPVP8L", string(data[8:16]))
+
+	width, height, err := util.ImageDimensions(bytes.NewReader(data), "image/webp")
+	require.NoError(t, err)
+	require.Equal(t, 3, width)
+	require.Equal(t, 2, height)
+
+	require.Error(t, util.EncodeWebP(&buf, image.NewRGBA(image.Rect(0, 0, 0, 0))))
+}
+
+// gradient fills an image with distinct pixels, translucent when alpha is
+// set.
+func gradient(width, height int, alpha bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.NRGBA{R: uint8(x * 7), G: uint8(y * 13), B: uint8(x ^ y), A: 255}
+			if alpha {
+				c.A = uint8(255 - (x+y)%200)
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestEncodeWebP_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		img  *image.NRGBA
+	}{
+		{"single pixel", gradient(1, 1, false)},
+		{"alpha", gradient(7, 5, true)},
+		// Spans more than one 512x512 predictor block each way
+		{"large", gradient(600, 520, false)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, util.EncodeWebP(&buf, tt.img))
+
+			decoded, err := webp.Decode(&buf)
+			require.NoError(t, err)
+			require.Equal(t, tt.img.Bounds(), decoded.Bounds())
+
+			b := tt.img.Bounds()
+			for y := 0; y < b.Dy(); y++ {
+				for x := 0; x < b.Dx(); x++ {
+					want := tt.img.NRGBAAt(x, y)
+					got := color.NRGBAModel.Convert(decoded.At(x, y)).(color.NRGBA)
+					require.Equal(t, want, got, "pixel %d,%d", x, y)
+				}
+			}
+		})
+	}
+}
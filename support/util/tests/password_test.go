@@ -0,0 +1,66 @@
+package util_test
+
+import (
+	"strings"
+	"testing"
+
+	"myapp/support/util"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func usePasswordHashPolicy(t *testing.T, policy util.PasswordHashPolicy) {
+	t.Helper()
+	util.SetPasswordHashPolicy(policy)
+	t.Cleanup(func() { util.SetPasswordHashPolicy(util.LoadPasswordHashPolicy()) })
+}
+
+func TestPasswordHash_Argon2id(t *testing.T) {
+	usePasswordHashPolicy(t, util.PasswordHashPolicy{
+		Algorithm: util.PasswordAlgorithmArgon2id, Argon2Memory: 1024, Argon2Time: 1, Argon2Threads: 1,
+	})
+
+	hashed, err := util.PasswordHash("correct horse")
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(hashed, "$argon2id$v=19$m=1024,t=1,p=1$"))
+	require.False(t, util.PasswordNeedsRehash(hashed))
+
+	ok, err := util.PasswordCompare(hashed, []byte("correct horse"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	ok, err = util.PasswordCompare(hashed, []byte("wrong horse"))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// stronger parameters make existing hashes outdated but still valid
+	usePasswordHashPolicy(t, util.PasswordHashPolicy{
+		Algorithm: util.PasswordAlgorithmArgon2id, Argon2Memory: 2048, Argon2Time: 1, Argon2Threads: 1,
+	})
+	require.True(t, util.PasswordNeedsRehash(hashed))
+	ok, err = util.PasswordCompare(hashed, []byte("correct horse"))
+	require.NoError(t, err)
+	require.True(t, ok)
+}
+
+func TestPasswordHash_BcryptCost(t *testing.T) {
+	usePasswordHashPolicy(t, util.PasswordHashPolicy{Algorithm: util.PasswordAlgorithmBcrypt, BcryptCost: bcrypt.MinCost})
+
+	hashed, err := util.PasswordHash("correct horse")
+	require.NoError(t, err)
+	require.False(t, util.PasswordNeedsRehash(hashed))
+
+	usePasswordHashPolicy(t, util.PasswordHashPolicy{Algorithm: util.PasswordAlgorithmBcrypt, BcryptCost: bcrypt.MinCost + 1})
+	require.True(t, util.PasswordNeedsRehash(hashed))
+
+	usePasswordHashPolicy(t, util.PasswordHashPolicy{
+		Algorithm: util.PasswordAlgorithmArgon2id, Argon2Memory: 1024, Argon2Time: 1, Argon2Threads: 1,
+	})
+	require.True(t, util.PasswordNeedsRehash(hashed))
+}
+
+func TestPasswordCompare_MalformedHash(t *testing.T) {
+	_, err := util.PasswordCompare("$argon2id$v=19$m=1024$bad", []byte("correct horse"))
+	require.Error(t, err)
+	require.True(t, util.PasswordNeedsRehash("$argon2id$v=19$m=1024$bad"))
+}
@@ -0,0 +1,373 @@
+package util
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+	"sort"
+)
+
+// WebP lossless (VP8L) as specified in RFC 9649. The encoder applies the
+// subtract green and predictor transforms and codes every pixel literally
+// with Huffman codes built from the image, without backward references.
+// That keeps it short while still compressing photos reasonably.
+
+const (
+	vp8lSignature      = 0x2f
+	vp8lMaxDimension   = 1 << 14
+	vp8lMaxCodeLength  = 15
+	vp8lPredictorBits  = 9 // predictor blocks of 512x512 pixels
+	vp8lPredictorMode  = 11
+	vp8lGreenAlphabet  = 256 + 24
+	vp8lColorAlphabet  = 256
+	vp8lDistAlphabet   = 40
+	vp8lCodeLengthSize = 19
+)
+
+var vp8lCodeLengthOrder = [vp8lCodeLengthSize]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// EncodeWebP writes img as a lossless WebP file.
+func EncodeWebP(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 1 || height < 1 || width > vp8lMaxDimension || height > vp8lMaxDimension {
+		return errors.New("webp: image dimensions out of range")
+	}
+
+	nrgba := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+
+	argb := make([]uint32, width*height)
+	hasAlpha := false
+	for i := range argb {
+		p := nrgba.Pix[i*4 : i*4+4]
+		argb[i] = uint32(p[3])<<24 | uint32(p[0])<<16 | uint32(p[1])<<8 | uint32(p[2])
+		hasAlpha = hasAlpha || p[3] != 0xff
+	}
+
+	var bw vp8lBitWriter
+	bw.writeBits(vp8lSignature, 8)
+	bw.writeBits(uint32(width-1), 14)
+	bw.writeBits(uint32(height-1), 14)
+	bw.writeBits(boolBit(hasAlpha), 1)
+	bw.writeBits(0, 3) // version
+
+	// Transforms are undone in reverse order, so the predictor residuals
+	// are taken of the image with green already subtracted
+	bw.writeBits(1, 1)
+	bw.writeBits(2, 2) // subtract green
+	vp8lSubtractGreen(argb)
+
+	bw.writeBits(1, 1)
+	bw.writeBits(0, 2) // predictor
+	bw.writeBits(vp8lPredictorBits-2, 3)
+	blocks := ((width + 1<<vp8lPredictorBits - 1) >> vp8lPredictorBits) *
+		((height + 1<<vp8lPredictorBits - 1) >> vp8lPredictorBits)
+	modes := make([]uint32, blocks)
+	for i := range modes {
+		modes[i] = vp8lPredictorMode << 8
+	}
+	vp8lWriteImage(&bw, modes, false)
+	argb = vp8lPredict(argb, width, height)
+
+	bw.writeBits(0, 1) // no more transforms
+	vp8lWriteImage(&bw, argb, true)
+
+	payload := bw.bytes()
+	padded := len(payload) + len(payload)&1
+	header := make([]byte, 20)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(4+8+padded))
+	copy(header[8:16], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(header[16:20], uint32(len(payload)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if len(payload) != padded {
+		payload = append(payload, 0)
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+func vp8lSubtractGreen(argb []uint32) {
+	for i, p := range argb {
+		green := (p >> 8) & 0xff
+		red := ((p >> 16) - green) & 0xff
+		blue := (p - green) & 0xff
+		argb[i] = p&0xff00ff00 | red<<16 | blue
+	}
+}
+
+// vp8lPredict returns the residuals of predicting every pixel with the
+// select predictor, or the fixed ones the format uses on the edges.
+func vp8lPredict(argb []uint32, width int, height int) []uint32 {
+	residuals := make([]uint32, len(argb))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := y*width + x
+			var predicted uint32
+			switch {
+			case x == 0 && y == 0:
+				predicted = 0xff000000
+			case y == 0:
+				predicted = argb[i-1]
+			case x == 0:
+				predicted = argb[i-width]
+			default:
+				predicted = vp8lSelect(argb[i-1], argb[i-width], argb[i-width-1])
+			}
+			residuals[i] = vp8lSubPixels(argb[i], predicted)
+		}
+	}
+	return residuals
+}
+
+func vp8lSelect(left uint32, top uint32, topLeft uint32) uint32 {
+	distLeft, distTop := 0, 0
+	for shift := 0; shift < 32; shift += 8 {
+		l, t, tl := int(left>>shift&0xff), int(top>>shift&0xff), int(topLeft>>shift&0xff)
+		estimate := l + t - tl
+		distLeft += absInt(estimate - l)
+		distTop += absInt(estimate - t)
+	}
+	if distLeft < distTop {
+		return left
+	}
+	return top
+}
+
+func vp8lSubPixels(a uint32, b uint32) uint32 {
+	var out uint32
+	for shift := 0; shift < 32; shift += 8 {
+		out |= ((a>>shift - b>>shift) & 0xff) << shift
+	}
+	return out
+}
+
+// vp8lWriteImage writes pixels coded with one group of prefix codes. The
+// main image carries a bit for meta prefix codes, transform data does not.
+func vp8lWriteImage(bw *vp8lBitWriter, argb []uint32, main bool) {
+	bw.writeBits(0, 1) // no color cache
+	if main {
+		bw.writeBits(0, 1) // no meta prefix codes
+	}
+
+	green := make([]int, vp8lGreenAlphabet)
+	red := make([]int, vp8lColorAlphabet)
+	blue := make([]int, vp8lColorAlphabet)
+	alpha := make([]int, vp8lColorAlphabet)
+	for _, p := range argb {
+		green[p>>8&0xff]++
+		red[p>>16&0xff]++
+		blue[p&0xff]++
+		alpha[p>>24]++
+	}
+	distance := make([]int, vp8lDistAlphabet)
+	distance[0] = 1
+
+	codes := make([]vp8lPrefixCode, 0, 5)
+	for _, histogram := range [][]int{green, red, blue, alpha, distance} {
+		code := newVP8LPrefixCode(histogram, vp8lMaxCodeLength)
+		code.write(bw)
+		codes = append(codes, code)
+	}
+
+	for _, p := range argb {
+		codes[0].writeSymbol(bw, int(p>>8&0xff))
+		codes[1].writeSymbol(bw, int(p>>16&0xff))
+		codes[2].writeSymbol(bw, int(p&0xff))
+		codes[3].writeSymbol(bw, int(p>>24))
+	}
+}
+
+// vp8lPrefixCode is a canonical Huffman code. A code with a single symbol
+// takes no bits at all.
+type vp8lPrefixCode struct {
+	lengths []int
+	codes   []uint32
+	used    []int
+}
+
+func newVP8LPrefixCode(histogram []int, maxLength int) vp8lPrefixCode {
+	code := vp8lPrefixCode{
+		lengths: huffmanLengths(histogram, maxLength),
+		codes:   make([]uint32, len(histogram)),
+	}
+	for symbol, count := range histogram {
+		if count > 0 {
+			code.used = append(code.used, symbol)
+		}
+	}
+
+	// Canonical codes, as the decoder rebuilds them from the lengths
+	var lengthCount, next [vp8lMaxCodeLength + 1]uint32
+	for _, length := range code.lengths {
+		lengthCount[length]++
+	}
+	lengthCount[0] = 0
+	for length, value := 1, uint32(0); length <= vp8lMaxCodeLength; length++ {
+		value = (value + lengthCount[length-1]) << 1
+		next[length] = value
+	}
+	for symbol, length := range code.lengths {
+		if length > 0 {
+			code.codes[symbol] = next[length]
+			next[length]++
+		}
+	}
+	return code
+}
+
+func (c vp8lPrefixCode) write(bw *vp8lBitWriter) {
+	if len(c.used) <= 2 && c.used[len(c.used)-1] < 256 {
+		bw.writeBits(1, 1) // simple code
+		bw.writeBits(uint32(len(c.used)-1), 1)
+		if c.used[0] <= 1 {
+			bw.writeBits(0, 1)
+			bw.writeBits(uint32(c.used[0]), 1)
+		} else {
+			bw.writeBits(1, 1)
+			bw.writeBits(uint32(c.used[0]), 8)
+		}
+		if len(c.used) == 2 {
+			bw.writeBits(uint32(c.used[1]), 8)
+		}
+		return
+	}
+
+	// Normal code: the code lengths are themselves Huffman coded, each one
+	// literally without the run length symbols
+	lengthHistogram := make([]int, vp8lCodeLengthSize)
+	for _, length := range c.lengths {
+		lengthHistogram[length]++
+	}
+	lengthCode := newVP8LPrefixCode(lengthHistogram, 7)
+
+	bw.writeBits(0, 1)
+	bw.writeBits(vp8lCodeLengthSize-4, 4)
+	for _, symbol := range vp8lCodeLengthOrder {
+		bw.writeBits(uint32(lengthCode.lengths[symbol]), 3)
+	}
+	bw.writeBits(0, 1) // lengths for the whole alphabet follow
+	for _, length := range c.lengths {
+		lengthCode.writeSymbol(bw, length)
+	}
+}
+
+func (c vp8lPrefixCode) writeSymbol(bw *vp8lBitWriter, symbol int) {
+	if len(c.used) == 1 {
+		return
+	}
+	bw.writeBits(reverseBits(c.codes[symbol], c.lengths[symbol]), c.lengths[symbol])
+}
+
+// huffmanLengths builds code lengths for histogram no longer than
+// maxLength. When the tree gets too deep the counts are flattened and it is
+// built again. A single used symbol gets length 1.
+func huffmanLengths(histogram []int, maxLength int) []int {
+	counts := append([]int(nil), histogram...)
+	for {
+		lengths := huffmanTreeLengths(counts)
+		longest := 0
+		for _, length := range lengths {
+			longest = max(longest, length)
+		}
+		if longest <= maxLength {
+			return lengths
+		}
+		for i, count := range counts {
+			if count > 0 {
+				counts[i] = (count + 1) / 2
+			}
+		}
+	}
+}
+
+func huffmanTreeLengths(counts []int) []int {
+	type node struct {
+		count       int
+		symbol      int
+		left, right *node
+	}
+
+	var nodes []*node
+	for symbol, count := range counts {
+		if count > 0 {
+			nodes = append(nodes, &node{count: count, symbol: symbol})
+		}
+	}
+	lengths := make([]int, len(counts))
+	if len(nodes) == 1 {
+		lengths[nodes[0].symbol] = 1
+		return lengths
+	}
+
+	for len(nodes) > 1 {
+		sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].count < nodes[j].count })
+		merged := &node{count: nodes[0].count + nodes[1].count, symbol: -1, left: nodes[0], right: nodes[1]}
+		nodes = append([]*node{merged}, nodes[2:]...)
+	}
+
+	var walk func(n *node, depth int)
+	walk = func(n *node, depth int) {
+		if n.left == nil {
+			lengths[n.symbol] = depth
+			return
+		}
+		walk(n.left, depth+1)
+		walk(n.right, depth+1)
+	}
+	walk(nodes[0], 0)
+	return lengths
+}
+
+type vp8lBitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits uint
+}
+
+// writeBits appends the n low bits of v, least significant first.
+func (bw *vp8lBitWriter) writeBits(v uint32, n int) {
+	bw.acc |= uint64(v) << bw.nbits
+	bw.nbits += uint(n)
+	for bw.nbits >= 8 {
+		bw.buf = append(bw.buf, byte(bw.acc))
+		bw.acc >>= 8
+		bw.nbits -= 8
+	}
+}
+
+func (bw *vp8lBitWriter) bytes() []byte {
+	if bw.nbits > 0 {
+		bw.buf = append(bw.buf, byte(bw.acc))
+		bw.acc, bw.nbits = 0, 0
+	}
+	return bw.buf
+}
+
+func reverseBits(v uint32, n int) uint32 {
+	var out uint32
+	for i := 0; i < n; i++ {
+		out = out<<1 | v&1
+		v >>= 1
+	}
+	return out
+}
+
+func boolBit(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
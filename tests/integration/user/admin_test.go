@@ -209,3 +209,41 @@ func TestIntegration_UpdateUserByAdmin_Forbidden(t *testing.T) {
 	json.Unmarshal(w.Body.Bytes(), &resp)
 	require.False(t, resp.IsSuccess)
 }
+
+// Test lockout after repeated failed logins and unlock by admin
+func TestIntegration_LoginLockoutAndUnlockByAdmin(t *testing.T) {
+	t.Setenv("LOGIN_LOCKOUT_THRESHOLD", "2")
+	testApp := testutil.SetupTestApp(t)
+	server := testApp.Server
+
+	adminEmail, adminPass := "admin@mail.com", "password123"
+	factory.SeedUser(t, testApp.UserRepo, "Admin User", adminEmail, adminPass, constant.EnumRoleAdmin)
+	token := testutil.GetToken(t, server, adminEmail, adminPass)
+
+	user := factory.SeedUser(t, testApp.UserRepo, "Locked User", "locked@example.com", "password123", constant.EnumRoleUser)
+
+	login := func(password string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(dto.UserLoginRequest{Email: user.Email, Password: password})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users/login", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	require.Equal(t, http.StatusUnauthorized, login("wrong-1").Code)
+	require.Equal(t, http.StatusUnauthorized, login("wrong-2").Code)
+
+	// Locked even with the right password
+	w := login("password123")
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/"+user.ID.String()+"/lockout", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	require.Equal(t, http.StatusOK, login("password123").Code)
+}
@@ -0,0 +1,96 @@
+package user_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"myapp/core/helper/dto"
+	"myapp/support/base"
+	"myapp/support/constant"
+	"myapp/tests/testutil"
+	"myapp/tests/testutil/factory"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+// Test an admin issued API key through its whole life
+func TestIntegration_APIKeyLifecycle(t *testing.T) {
+	testApp := testutil.SetupTestApp(t)
+	server := testApp.Server
+
+	adminEmail, adminPass := "admin@mail.com", "password123"
+	factory.SeedUser(t, testApp.UserRepo, "Admin User", adminEmail, adminPass, constant.EnumRoleAdmin)
+	token := testutil.GetToken(t, server, adminEmail, adminPass)
+
+	send := func(method, target string, header map[string]string, body any) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, target, bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+	admin := map[string]string{"Authorization": "Bearer " + token}
+
+	// Keys never manage keys, and only get permissions that exist
+	w := send(http.MethodPost, "/api/v1/api-keys", admin, dto.APIKeyCreateRequest{
+		Name:   "minter",
+		Scopes: []string{constant.EnumPermissionAPIKeysManage},
+	})
+	require.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	w = send(http.MethodPost, "/api/v1/api-keys", admin, dto.APIKeyCreateRequest{
+		Name:   "reports",
+		Scopes: []string{"reports:read"},
+	})
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	// Issue a stock-only key, the raw key is returned once
+	w = send(http.MethodPost, "/api/v1/api-keys", admin, dto.APIKeyCreateRequest{
+		Name:   "warehouse",
+		Scopes: []string{constant.EnumPermissionProductsStock},
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data dto.APIKeyCreateResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	rawKey := created.Data.Key
+	require.NotEmpty(t, rawKey)
+	apiKey := map[string]string{"X-API-Key": rawKey}
+
+	// The key gets past authentication on the stock route, the product
+	// itself does not exist
+	w = send(http.MethodPatch, "/api/v1/products/"+uuid.NewString()+"/stock", apiKey,
+		dto.ProductStockUpdateRequest{Quantity: 5})
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	// but not on routes outside its scopes
+	w = send(http.MethodPost, "/api/v1/products", apiKey, nil)
+	require.Equal(t, http.StatusForbidden, w.Code)
+	w = send(http.MethodGet, "/api/v1/users", apiKey, nil)
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	// The list shows the key without its secret, with its last use
+	w = send(http.MethodGet, "/api/v1/api-keys", admin, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotContains(t, w.Body.String(), rawKey)
+	var listed base.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	keys := listed.Data.([]interface{})
+	require.Len(t, keys, 1)
+	require.NotNil(t, keys[0].(map[string]interface{})["last_used_at"])
+
+	// Revoked keys stop working
+	w = send(http.MethodDelete, "/api/v1/api-keys/"+created.Data.ID, admin, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	w = send(http.MethodPatch, "/api/v1/products/"+uuid.NewString()+"/stock", apiKey,
+		dto.ProductStockUpdateRequest{Quantity: 5})
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
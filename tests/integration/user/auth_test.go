@@ -5,11 +5,19 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
 	"testing"
+	"time"
 
+	"myapp/core/entity"
 	"myapp/core/helper/dto"
 	"myapp/core/helper/messages"
+	oidciface "myapp/core/interface/oidc"
 	"myapp/support/base"
+	"myapp/support/constant"
+	"myapp/support/util"
 	"myapp/tests/testutil"
 
 	"github.com/stretchr/testify/require"
@@ -227,3 +235,352 @@ func TestIntegration_UserLogin_InvalidCredentials(t *testing.T) {
 		})
 	}
 }
+
+// Test refresh token rotation and reuse detection
+func TestIntegration_RefreshToken_RotationAndReuse(t *testing.T) {
+	testApp := testutil.SetupTestApp(t)
+	server := testApp.Server
+
+	testutil.CreateUserAndGetToken(t, server, "Rita Rotate", "rita@example.com", "password123")
+
+	// Login to get the initial refresh token
+	body, _ := json.Marshal(dto.UserLoginRequest{Email: "rita@example.com", Password: "password123"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/login", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var loginResp base.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &loginResp))
+	firstRefresh := loginResp.Data.(map[string]interface{})["refresh_token"].(string)
+	require.NotEmpty(t, firstRefresh)
+
+	refresh := func(token string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(dto.UserRefreshTokenRequest{RefreshToken: token})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users/token/refresh", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	// Rotation returns a new refresh token
+	w = refresh(firstRefresh)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var refreshResp base.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &refreshResp))
+	require.Equal(t, messages.MsgUserTokenRefreshSuccess, refreshResp.Message)
+	secondRefresh := refreshResp.Data.(map[string]interface{})["refresh_token"].(string)
+	require.NotEqual(t, firstRefresh, secondRefresh)
+
+	// Replaying the rotated token is rejected...
+	w = refresh(firstRefresh)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// ...and revokes the rest of the family
+	w = refresh(secondRefresh)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// Test that logging out revokes both the access token and its refresh token
+func TestIntegration_Logout_RevokesTokens(t *testing.T) {
+	testApp := testutil.SetupTestApp(t)
+	server := testApp.Server
+
+	testutil.CreateUserAndGetToken(t, server, "Lou Logout", "lou@example.com", "password123")
+
+	body, _ := json.Marshal(dto.UserLoginRequest{Email: "lou@example.com", Password: "password123"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users/login", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var loginResp base.Response
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &loginResp))
+	data := loginResp.Data.(map[string]interface{})
+	accessToken := data["token"].(string)
+	refreshToken := data["refresh_token"].(string)
+
+	body, _ = json.Marshal(dto.UserLogoutRequest{RefreshToken: refreshToken})
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/users/logout", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// The access token no longer authenticates
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/users/me", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// The refresh token cannot be used to get a new one
+	body, _ = json.Marshal(dto.UserRefreshTokenRequest{RefreshToken: refreshToken})
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/users/token/refresh", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// Test the forgot/reset password flow end to end, including single use
+func TestIntegration_PasswordReset(t *testing.T) {
+	testApp := testutil.SetupTestApp(t)
+	server := testApp.Server
+
+	testutil.CreateUserAndGetToken(t, server, "Pat Reset", "pat@example.com", "old-password")
+
+	post := func(path string, payload any) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post("/api/v1/users/password/forgot", dto.UserPasswordForgotRequest{Email: "pat@example.com"})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// Unknown addresses get the same answer
+	w = post("/api/v1/users/password/forgot", dto.UserPasswordForgotRequest{Email: "nobody@example.com"})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	mails, err := os.ReadDir(testApp.MailDir)
+	require.NoError(t, err)
+	require.Len(t, mails, 1)
+	mail, err := os.ReadFile(filepath.Join(testApp.MailDir, mails[0].Name()))
+	require.NoError(t, err)
+	match := regexp.MustCompile(`token=([A-Za-z0-9_-]+)`).FindSubmatch(mail)
+	require.NotNil(t, match)
+	token := string(match[1])
+
+	resetReq := dto.UserPasswordResetRequest{Token: token, Password: "new-password"}
+	w = post("/api/v1/users/password/reset", resetReq)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// The token only works once
+	w = post("/api/v1/users/password/reset", resetReq)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = post("/api/v1/users/login", dto.UserLoginRequest{Email: "pat@example.com", Password: "old-password"})
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+	testutil.GetToken(t, server, "pat@example.com", "new-password")
+}
+
+// Test that changing the password needs the current one and signs out
+// tokens issued before the change
+func TestIntegration_ChangePassword(t *testing.T) {
+	testApp := testutil.SetupTestApp(t)
+	server := testApp.Server
+
+	token := testutil.CreateUserAndGetToken(t, server, "Cam Change", "cam@example.com", "old-password")
+
+	patch := func(payload any) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest(http.MethodPatch, "/api/v1/users/me/password", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	w := patch(dto.UserPasswordChangeRequest{CurrentPassword: "wrong-password", Password: "new-password"})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Tokens from the second of the change stay valid, so change in the next one
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	w = patch(dto.UserPasswordChangeRequest{CurrentPassword: "old-password", Password: "new-password"})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// The token used for the change was issued before it
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/me", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	testutil.GetToken(t, server, "cam@example.com", "new-password")
+}
+
+// Test that with the "login" policy an account can only log in once the
+// address from the registration mail is confirmed
+func TestIntegration_EmailVerification_BlocksLogin(t *testing.T) {
+	t.Setenv("EMAIL_VERIFICATION_POLICY", "login")
+	testApp := testutil.SetupTestApp(t)
+	server := testApp.Server
+
+	body, _ := json.Marshal(dto.UserRegisterRequest{Name: "Val Verify", Email: "val@example.com", Password: "password123"})
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	login := func() *httptest.ResponseRecorder {
+		body, _ := json.Marshal(dto.UserLoginRequest{Email: "val@example.com", Password: "password123"})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users/login", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	w = login()
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	mails, err := os.ReadDir(testApp.MailDir)
+	require.NoError(t, err)
+	require.Len(t, mails, 1)
+	mail, err := os.ReadFile(filepath.Join(testApp.MailDir, mails[0].Name()))
+	require.NoError(t, err)
+	match := regexp.MustCompile(`verify-email\?token=([A-Za-z0-9_-]+)`).FindSubmatch(mail)
+	require.NotNil(t, match)
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/users/verify-email?token="+string(match[1]), nil)
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	w = login()
+	require.Equal(t, http.StatusOK, w.Code)
+}
+
+// Test social login against the local stand-in issuer: the first login
+// creates a provider account, a later one with a password account's email
+// links that account, and identities are found by subject afterwards
+func TestIntegration_OIDCLogin(t *testing.T) {
+	testApp := testutil.SetupTestApp(t)
+	server := testApp.Server
+
+	oidcLogin := func(identity oidciface.Identity) *httptest.ResponseRecorder {
+		testApp.OIDCIssuer.SetIdentity(identity)
+
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/test/login", nil)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		require.Equal(t, http.StatusFound, w.Code)
+
+		callback := testApp.OIDCIssuer.Authorize(t, w.Header().Get("Location"))
+		cookies := w.Result().Cookies()
+		req = httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil)
+		for _, cookie := range cookies {
+			req.AddCookie(cookie)
+		}
+		w = httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	// New account
+	w := oidcLogin(oidciface.Identity{Subject: "s-1", Email: "sam@example.com", EmailVerified: true, Name: "Sam"})
+	require.Equal(t, http.StatusOK, w.Code)
+	user, err := testApp.UserRepo.GetUserByPrimaryKey(t.Context(), nil, constant.DBAttrEmail, "sam@example.com")
+	require.NoError(t, err)
+	require.Equal(t, "test", user.Provider)
+	require.NotNil(t, user.EmailVerifiedAt)
+
+	passwordLogin := func(email string, password string) int {
+		body, _ := json.Marshal(dto.UserLoginRequest{Email: email, Password: password})
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/users/login", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Linking a verified password account keeps its password
+	testutil.CreateUserAndGetToken(t, server, "Lin Link", "lin@example.com", "password123")
+	user, err = testApp.UserRepo.GetUserByPrimaryKey(t.Context(), nil, constant.DBAttrEmail, "lin@example.com")
+	require.NoError(t, err)
+	verifiedAt := time.Now()
+	require.NoError(t, testApp.UserRepo.UpdateUser(t.Context(), nil, entity.User{ID: user.ID, EmailVerifiedAt: &verifiedAt}))
+	w = oidcLogin(oidciface.Identity{Subject: "s-2", Email: "lin@example.com", EmailVerified: true})
+	require.Equal(t, http.StatusOK, w.Code)
+	user, err = testApp.UserRepo.GetUserByPrimaryKey(t.Context(), nil, constant.DBAttrEmail, "lin@example.com")
+	require.NoError(t, err)
+	require.Equal(t, "test", user.Provider)
+	require.Equal(t, http.StatusOK, passwordLogin("lin@example.com", "password123"))
+
+	// Whoever signed up with an address they never verified loses the
+	// account to its owner
+	squatter := testutil.CreateUserAndGetToken(t, server, "Ursula", "uma@example.com", "password123")
+	w = oidcLogin(oidciface.Identity{Subject: "s-4", Email: "uma@example.com", EmailVerified: true})
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, http.StatusUnauthorized, passwordLogin("uma@example.com", "password123"))
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/me", nil)
+	req.Header.Set("Authorization", "Bearer "+squatter)
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// A linked identity is found by subject, whatever its address is now
+	w = oidcLogin(oidciface.Identity{Subject: "s-2", Email: "lin@elsewhere.example", EmailVerified: false})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// Unverified addresses are never linked
+	w = oidcLogin(oidciface.Identity{Subject: "s-3", Email: "lin@example.com", EmailVerified: false})
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	// A callback opened in another browser, as in login CSRF, has no
+	// state cookie and signs nobody in
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/auth/test/login", nil)
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	callback := testApp.OIDCIssuer.Authorize(t, w.Header().Get("Location"))
+	req = httptest.NewRequest(http.MethodGet, callback.RequestURI(), nil)
+	w = httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+// Test enrolling TOTP and the two-step login it turns on
+func TestIntegration_TwoFactorLogin(t *testing.T) {
+	testApp := testutil.SetupTestApp(t)
+	server := testApp.Server
+	token := testutil.CreateUserAndGetToken(t, server, "Tom Totp", "tom@example.com", "password123")
+
+	send := func(method, target, bearer string, body any) map[string]interface{} {
+		t.Helper()
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, target, bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp base.Response
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		data, _ := resp.Data.(map[string]interface{})
+		return data
+	}
+
+	enroll := send(http.MethodPost, "/api/v1/users/me/2fa/totp", token, nil)
+	secret := enroll["secret"].(string)
+	code, err := util.TOTPCode(secret, util.TOTPStep(time.Now()))
+	require.NoError(t, err)
+
+	confirm := send(http.MethodPost, "/api/v1/users/me/2fa/totp/confirm", token, dto.UserTwoFactorCodeRequest{Code: code})
+	recoveryCodes := confirm["recovery_codes"].([]interface{})
+	require.Len(t, recoveryCodes, constant.RecoveryCodeCount)
+
+	login := send(http.MethodPost, "/api/v1/users/login", "",
+		dto.UserLoginRequest{Email: "tom@example.com", Password: "password123"})
+	require.Equal(t, true, login["two_factor_required"])
+	require.Nil(t, login["token"])
+
+	tokens := send(http.MethodPost, "/api/v1/users/login/2fa", "", dto.UserLoginTwoFactorRequest{
+		ChallengeToken: login["challenge_token"].(string),
+		Code:           recoveryCodes[0].(string),
+	})
+	require.NotEmpty(t, tokens["token"])
+}
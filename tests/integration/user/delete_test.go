@@ -4,17 +4,24 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
-	errs "myapp/core/helper/errors"
+	"myapp/core/entity"
+	"myapp/core/helper/dto"
 	"myapp/core/helper/messages"
 	"myapp/support/base"
+	"myapp/support/constant"
 	"myapp/tests/testutil"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
-// Test delete user endpoint
+// Test deleting yourself schedules the deletion and signs you out, and that
+// logging in again lets you cancel it
 func TestIntegration_DeleteUser(t *testing.T) {
 	testApp := testutil.SetupTestApp(t)
 	server := testApp.Server
@@ -22,33 +29,141 @@ func TestIntegration_DeleteUser(t *testing.T) {
 	// Create user and get token
 	token := testutil.CreateUserAndGetToken(t, server, "Charlie Brown", "charlie@example.com", "password123")
 
-	// Test delete user
+	send := func(method, target, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(http.MethodDelete, "/api/v1/users/me", token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var scheduled struct {
+		base.Response
+		Data dto.UserResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &scheduled))
+	require.True(t, scheduled.IsSuccess)
+	require.Equal(t, messages.MsgUserDeletionScheduleSuccess, scheduled.Message)
+	require.NotNil(t, scheduled.Data.ScheduledDeletionAt)
+	require.WithinDuration(t, time.Now().Add(constant.DefaultAccountDeletionGracePeriod),
+		*scheduled.Data.ScheduledDeletionAt, time.Minute)
+
+	// Every session was signed out
+	w = send(http.MethodGet, "/api/v1/users/me", token)
+	require.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// Tokens issued within the second of the revocation are revoked too
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	token = testutil.GetToken(t, server, "charlie@example.com", "password123")
+
+	w = send(http.MethodGet, "/api/v1/users/me", token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Contains(t, w.Body.String(), `"scheduled_deletion_at":"`)
+
+	w = send(http.MethodDelete, "/api/v1/users/me/deletion", token)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Contains(t, w.Body.String(), messages.MsgUserDeletionCancelSuccess)
+
+	w = send(http.MethodDelete, "/api/v1/users/me/deletion", token)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = send(http.MethodGet, "/api/v1/users/me", token)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Contains(t, w.Body.String(), `"scheduled_deletion_at":null`)
+}
+
+// Test the purge job anonymizes accounts once their grace period is over
+func TestIntegration_DeleteUser_Purge(t *testing.T) {
+	t.Setenv("ACCOUNT_DELETION_GRACE_PERIOD", "1ms")
+	testApp := testutil.SetupTestApp(t)
+	server := testApp.Server
+
+	token := testutil.CreateUserAndGetToken(t, server, "Pat Purge", "pat@example.com", "password123")
+	keep := testutil.CreateUserAndGetToken(t, server, "Kim Keep", "kim@example.com", "password123")
+
 	req := httptest.NewRequest(http.MethodDelete, "/api/v1/users/me", nil)
 	req.Header.Set("Authorization", "Bearer "+token)
 	w := httptest.NewRecorder()
-
 	server.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
 
-	require.Equal(t, http.StatusOK, w.Code)
-
-	var resp base.Response
-	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	time.Sleep(10 * time.Millisecond)
+	purged, err := testApp.UserService.PurgeScheduledUsers(t.Context())
 	require.NoError(t, err)
-	require.True(t, resp.IsSuccess)
-	require.Equal(t, messages.MsgUserDeleteSuccess, resp.Message)
-
-	// Verify user cannot access profile after deletion
+	require.Equal(t, 1, purged)
+
+	var user entity.User
+	require.NoError(t, testApp.DB.Unscoped().Where("email LIKE ?", "deleted-%").First(&user).Error)
+	require.Equal(t, "deleted-"+user.ID.String()+"@deleted.invalid", user.Email)
+	require.NotEqual(t, "Pat Purge", user.Name)
+	require.Empty(t, user.Password)
+	require.True(t, user.DeletedAt.Valid)
+
+	var sessions int64
+	require.NoError(t, testApp.DB.Model(&entity.Session{}).Where("user_id = ?", user.ID).Count(&sessions).Error)
+	require.Zero(t, sessions)
+
+	// The audit log keeps no trace of the erased data
+	var logs []entity.AuditLog
+	require.NoError(t, testApp.DB.Where("entity_id = ?", user.ID.String()).Find(&logs).Error)
+	require.NotEmpty(t, logs)
+	for _, log := range logs {
+		changes, err := json.Marshal(log.Changes)
+		require.NoError(t, err)
+		require.NotContains(t, string(changes), "pat@example.com")
+		require.NotContains(t, string(changes), "Pat Purge")
+	}
+
+	// The email is free again and the other account is untouched
+	testutil.CreateUserAndGetToken(t, server, "Pat Again", "pat@example.com", "password123")
 	req = httptest.NewRequest(http.MethodGet, "/api/v1/users/me", nil)
-	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Authorization", "Bearer "+keep)
 	w = httptest.NewRecorder()
 	server.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
 
-	// The repository returns error
-	require.Equal(t, http.StatusBadRequest, w.Code)
+	// Nothing is left to purge
+	purged, err = testApp.UserService.PurgeScheduledUsers(t.Context())
+	require.NoError(t, err)
+	require.Zero(t, purged)
+}
 
-	json.Unmarshal(w.Body.Bytes(), &resp)
-	require.False(t, resp.IsSuccess)
-	require.Equal(t, errs.ErrUserNotFound.Error(), resp.Error)
+// Test exporting your data returns a downloadable archive
+func TestIntegration_ExportUser(t *testing.T) {
+	testApp := testutil.SetupTestApp(t)
+	server := testApp.Server
+
+	token := testutil.CreateUserAndGetToken(t, server, "Eve Export", "eve@example.com", "password123")
+
+	// A stored picture is embedded in the archive
+	cwd, err := os.Getwd()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.Chdir(cwd) })
+	require.NoError(t, os.Chdir(t.TempDir()))
+	picPath := "user_picture/" + uuid.NewString()
+	require.NoError(t, os.MkdirAll(filepath.Join(constant.FileBasePath, "user_picture"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(constant.FileBasePath, picPath), []byte("picture"), 0644))
+	require.NoError(t, testApp.DB.Model(&entity.User{}).Where("email = ?", "eve@example.com").
+		Update("picture", picPath).Error)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users/me/export", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+	require.NotContains(t, w.Body.String(), "password123")
+
+	var export dto.UserExportResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &export))
+	require.Equal(t, "eve@example.com", export.Profile.Email)
+	require.Equal(t, "Eve Export", export.Profile.Name)
+	require.Equal(t, []byte("picture"), export.Picture.Data)
+	require.Len(t, export.Sessions, 1)
+	require.True(t, export.Sessions[0].Current)
 }
 
 // Test delete user without authentication
@@ -0,0 +1,73 @@
+package user_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"myapp/core/helper/dto"
+	"myapp/support/base"
+	"myapp/support/constant"
+	"myapp/tests/testutil"
+	"myapp/tests/testutil/factory"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test an admin can act as a customer for reads but not take sensitive
+// actions on their behalf
+func TestIntegration_Impersonation(t *testing.T) {
+	testApp := testutil.SetupTestApp(t)
+	server := testApp.Server
+
+	adminEmail, adminPass := "admin@mail.com", "password123"
+	factory.SeedUser(t, testApp.UserRepo, "Admin User", adminEmail, adminPass, constant.EnumRoleAdmin)
+	adminToken := testutil.GetToken(t, server, adminEmail, adminPass)
+	customer := factory.SeedUser(t, testApp.UserRepo, "Cora Customer", "cora@mail.com", "password123",
+		constant.EnumRoleUser)
+	customerToken := testutil.GetToken(t, server, "cora@mail.com", "password123")
+
+	send := func(method, target, token string, body any) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, target, bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	// Regular users cannot impersonate anyone
+	w := send(http.MethodPost, "/api/v1/users/"+customer.ID.String()+"/impersonate", customerToken, nil)
+	require.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	w = send(http.MethodPost, "/api/v1/users/"+customer.ID.String()+"/impersonate", adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var started struct {
+		Data base.AuthResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &started))
+	require.Empty(t, started.Data.RefreshToken)
+	impersonationToken := started.Data.Token
+
+	w = send(http.MethodGet, "/api/v1/users/me", impersonationToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var me struct {
+		Data dto.UserResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &me))
+	require.Equal(t, "cora@mail.com", me.Data.Email)
+
+	w = send(http.MethodPatch, "/api/v1/users/me/password", impersonationToken,
+		dto.UserPasswordChangeRequest{CurrentPassword: "password123", Password: "taken-over"})
+	require.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	w = send(http.MethodDelete, "/api/v1/users/me", impersonationToken, nil)
+	require.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	w = send(http.MethodGet, "/api/v1/users/me/sessions", impersonationToken, nil)
+	require.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	// The customer's account is untouched
+	testutil.GetToken(t, server, "cora@mail.com", "password123")
+}
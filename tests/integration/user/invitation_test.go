@@ -0,0 +1,166 @@
+package user_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"myapp/core/helper/dto"
+	"myapp/support/constant"
+	"myapp/tests/testutil"
+	"myapp/tests/testutil/factory"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test an admin invites a user with a role, the invitee signs up through the
+// mailed link, and the link cannot be used again
+func TestIntegration_InvitationLifecycle(t *testing.T) {
+	testApp := testutil.SetupTestApp(t)
+	server := testApp.Server
+
+	adminEmail, adminPass := "admin@mail.com", "password123"
+	factory.SeedUser(t, testApp.UserRepo, "Admin User", adminEmail, adminPass, constant.EnumRoleAdmin)
+	adminToken := testutil.GetToken(t, server, adminEmail, adminPass)
+
+	send := func(method, target, token string, body any) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, target, bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+	lastMailedToken := func() string {
+		mails, err := os.ReadDir(testApp.MailDir)
+		require.NoError(t, err)
+		require.NotEmpty(t, mails)
+		mail, err := os.ReadFile(filepath.Join(testApp.MailDir, mails[len(mails)-1].Name()))
+		require.NoError(t, err)
+		match := regexp.MustCompile(`accept-invitation\?token=([A-Za-z0-9_-]+)`).FindSubmatch(mail)
+		require.NotNil(t, match)
+		return string(match[1])
+	}
+
+	w := send(http.MethodPost, "/api/v1/invitations", adminToken, dto.InvitationCreateRequest{
+		Email: "ivy@mail.com", Role: constant.EnumRoleAdmin,
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data dto.InvitationResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	require.Equal(t, dto.InvitationStatusPending, created.Data.Status)
+	firstToken := lastMailedToken()
+
+	// One open invitation per address
+	w = send(http.MethodPost, "/api/v1/invitations", adminToken, dto.InvitationCreateRequest{
+		Email: "ivy@mail.com", Role: constant.EnumRoleUser,
+	})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Resending replaces the link
+	w = send(http.MethodPost, "/api/v1/invitations/"+created.Data.ID+"/resend", adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	token := lastMailedToken()
+	require.NotEqual(t, firstToken, token)
+
+	accept := func(token string) *httptest.ResponseRecorder {
+		return send(http.MethodPost, "/api/v1/invitations/accept", "", dto.InvitationAcceptRequest{
+			Token: token, Name: "Ivy Invited", Password: "correct horse battery",
+		})
+	}
+	w = accept(firstToken)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = accept(token)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	w = accept(token)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	// The account has the invited role and can log in right away
+	ivyToken := testutil.GetToken(t, server, "ivy@mail.com", "correct horse battery")
+	w = send(http.MethodGet, "/api/v1/users/me", ivyToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var me struct {
+		Data dto.UserResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &me))
+	require.Equal(t, constant.EnumRoleAdmin, me.Data.Role)
+	require.NotNil(t, me.Data.EmailVerifiedAt)
+
+	w = send(http.MethodGet, "/api/v1/invitations", adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var list struct {
+		Data []dto.InvitationResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	require.Len(t, list.Data, 1)
+	require.Equal(t, dto.InvitationStatusAccepted, list.Data[0].Status)
+
+	// Accepted invitations can no longer be revoked
+	w = send(http.MethodDelete, "/api/v1/invitations/"+created.Data.ID, adminToken, nil)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// Test a revoked invitation cannot be accepted and regular users cannot
+// invite anyone
+func TestIntegration_InvitationRevoked(t *testing.T) {
+	testApp := testutil.SetupTestApp(t)
+	server := testApp.Server
+
+	adminEmail, adminPass := "admin@mail.com", "password123"
+	factory.SeedUser(t, testApp.UserRepo, "Admin User", adminEmail, adminPass, constant.EnumRoleAdmin)
+	adminToken := testutil.GetToken(t, server, adminEmail, adminPass)
+	userToken := testutil.CreateUserAndGetToken(t, server, "Uma User", "uma@mail.com", "password123")
+
+	send := func(method, target, token string, body any) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, target, bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	invite := dto.InvitationCreateRequest{Email: "rex@mail.com", Role: constant.EnumRoleUser}
+	w := send(http.MethodPost, "/api/v1/invitations", userToken, invite)
+	require.Equal(t, http.StatusForbidden, w.Code)
+
+	w = send(http.MethodPost, "/api/v1/invitations", adminToken, invite)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data dto.InvitationResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	mails, err := os.ReadDir(testApp.MailDir)
+	require.NoError(t, err)
+	mail, err := os.ReadFile(filepath.Join(testApp.MailDir, mails[len(mails)-1].Name()))
+	require.NoError(t, err)
+	match := regexp.MustCompile(`accept-invitation\?token=([A-Za-z0-9_-]+)`).FindSubmatch(mail)
+	require.NotNil(t, match)
+
+	w = send(http.MethodDelete, "/api/v1/invitations/"+created.Data.ID, adminToken, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = send(http.MethodPost, "/api/v1/invitations/accept", "", dto.InvitationAcceptRequest{
+		Token: string(match[1]), Name: "Rex", Password: "correct horse battery",
+	})
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	// Revoking frees the address for a new invitation
+	w = send(http.MethodPost, "/api/v1/invitations", adminToken, invite)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+}
@@ -0,0 +1,79 @@
+package user_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"myapp/core/helper/dto"
+	"myapp/support/constant"
+	"myapp/tests/testutil"
+	"myapp/tests/testutil/factory"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test a role added at runtime grants exactly the permissions it was given
+func TestIntegration_CustomRolePermissions(t *testing.T) {
+	testApp := testutil.SetupTestApp(t)
+	server := testApp.Server
+
+	adminEmail, adminPass := "admin@mail.com", "password123"
+	factory.SeedUser(t, testApp.UserRepo, "Admin User", adminEmail, adminPass, constant.EnumRoleAdmin)
+	adminToken := testutil.GetToken(t, server, adminEmail, adminPass)
+
+	send := func(method, target, token string, body any) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, target, bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	// Create a catalog manager role that may only touch products
+	w := send(http.MethodPost, "/api/v1/roles", adminToken, dto.RoleCreateRequest{
+		Name:        "catalog_manager",
+		Description: "Maintains the product catalog",
+		Permissions: []string{constant.EnumPermissionProductsWrite},
+	})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data dto.RoleResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	require.Equal(t, []string{constant.EnumPermissionProductsWrite}, created.Data.Permissions)
+
+	managerEmail, managerPass := "manager@mail.com", "password123"
+	manager := factory.SeedUser(t, testApp.UserRepo, "Manager", managerEmail, managerPass, "catalog_manager")
+	managerToken := testutil.GetToken(t, server, managerEmail, managerPass)
+
+	// Past authorization on products, the empty body then fails validation
+	w = send(http.MethodPost, "/api/v1/products", managerToken, map[string]any{})
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	// Categories and users stay off limits
+	w = send(http.MethodPost, "/api/v1/categories", managerToken, map[string]any{})
+	require.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+	w = send(http.MethodGet, "/api/v1/users", managerToken, nil)
+	require.Equal(t, http.StatusForbidden, w.Code, w.Body.String())
+
+	// Granting categories:write takes effect without a new token
+	w = send(http.MethodPut, "/api/v1/roles/"+created.Data.ID+"/permissions", adminToken, map[string]any{
+		"permissions": []string{constant.EnumPermissionProductsWrite, constant.EnumPermissionCategoriesWrite},
+	})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = send(http.MethodPost, "/api/v1/categories", managerToken, map[string]any{})
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	// Roles still assigned to someone cannot be deleted
+	w = send(http.MethodDelete, "/api/v1/roles/"+created.Data.ID, adminToken, nil)
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+	// Users can only be given roles that exist
+	w = send(http.MethodPatch, "/api/v1/users/"+manager.ID.String(), adminToken, dto.UserUpdateRequest{Role: "superuser"})
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+}
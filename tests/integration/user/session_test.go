@@ -0,0 +1,68 @@
+package user_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"myapp/core/helper/dto"
+	"myapp/support/constant"
+	"myapp/tests/testutil"
+	"myapp/tests/testutil/factory"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Test a user sees each login as a session and can sign out another device
+func TestIntegration_ListAndEndSessions(t *testing.T) {
+	testApp := testutil.SetupTestApp(t)
+	server := testApp.Server
+
+	email, pass := "user@mail.com", "password123"
+	factory.SeedUser(t, testApp.UserRepo, "User", email, pass, constant.EnumRoleUser)
+	laptopToken := testutil.GetToken(t, server, email, pass)
+	phoneToken := testutil.GetToken(t, server, email, pass)
+
+	send := func(method, target, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, req)
+		return w
+	}
+
+	// Both logins are listed, the laptop one marked as current
+	w := send(http.MethodGet, "/api/v1/users/me/sessions", laptopToken)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var listed struct {
+		Data []dto.UserSessionResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	require.Len(t, listed.Data, 2)
+
+	var phoneSessionID string
+	for _, session := range listed.Data {
+		require.NotEmpty(t, session.IPAddress)
+		if !session.Current {
+			phoneSessionID = session.ID
+		}
+	}
+	require.NotEmpty(t, phoneSessionID)
+
+	// Signing out the phone from the laptop cuts off the phone's token only
+	w = send(http.MethodDelete, "/api/v1/users/me/sessions/"+phoneSessionID, laptopToken)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = send(http.MethodGet, "/api/v1/users/me", phoneToken)
+	require.Equal(t, http.StatusUnauthorized, w.Code, w.Body.String())
+	w = send(http.MethodGet, "/api/v1/users/me", laptopToken)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	// An ended session is gone from the list and cannot be ended twice
+	w = send(http.MethodGet, "/api/v1/users/me/sessions", laptopToken)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	require.Len(t, listed.Data, 1)
+	w = send(http.MethodDelete, "/api/v1/users/me/sessions/"+phoneSessionID, laptopToken)
+	require.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+}
@@ -91,8 +91,8 @@ func TestIntegration_MultipleUsersIsolation(t *testing.T) {
 	server := testApp.Server
 
 	// Create multiple users
-	token1 := testutil.CreateUserAndGetToken(t, server, "User One", "user1@example.com", "pass1")
-	token2 := testutil.CreateUserAndGetToken(t, server, "User Two", "user2@example.com", "pass2")
+	token1 := testutil.CreateUserAndGetToken(t, server, "User One", "user1@example.com", "password-one")
+	token2 := testutil.CreateUserAndGetToken(t, server, "User Two", "user2@example.com", "password-two")
 
 	// User 1 updates their name
 	updateReq := dto.UserNameUpdateRequest{Name: "User One Updated"}
@@ -10,6 +10,7 @@ import (
 	"myapp/core/service"
 	"myapp/infrastructure/query"
 	"myapp/infrastructure/repository"
+	"myapp/infrastructure/storage"
 	"myapp/support/constant"
 
 	"github.com/google/uuid"
@@ -32,8 +33,14 @@ func NewUserService(t *testing.T, db *gorm.DB) service.UserService {
 	t.Helper()
 	ur := NewUserRepository(t, db)
 	uq := NewUserQuery(t, db)
+	rr := repository.NewRoleRepository(db)
 	txr := repository.NewTxRepository(db)
-	return service.NewUserService(ur, uq, txr)
+	alr := repository.NewAuditLogRepository(db)
+	policy, err := service.NewPasswordPolicy()
+	require.NoError(t, err)
+	fileURLs, _ := service.NewFileURLService()
+	return service.NewUserService(ur, uq, rr, txr, alr, storage.NewLocalStorage(constant.FileBasePath), fileURLs,
+		policy)
 }
 
 func SeedUsers(t *testing.T, ur repositoryiface.UserRepository, n int) []entity.User {
@@ -0,0 +1,250 @@
+package testutil
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	oidciface "myapp/core/interface/oidc"
+	"myapp/infrastructure/oidc"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	OIDCTestClientID     = "test-client"
+	OIDCTestClientSecret = "test-secret"
+)
+
+// OIDCIssuer is a minimal local OpenID Connect provider implementing
+// discovery, JWKS, the authorization endpoint and the authorization code
+// grant with PKCE. The authorization endpoint signs in whichever identity
+// was last set with SetIdentity, without any user interaction.
+type OIDCIssuer struct {
+	Server *httptest.Server
+
+	key *rsa.PrivateKey
+	kid string
+	// signingKey differs from key when simulating forged ID tokens
+	signingKey *rsa.PrivateKey
+
+	mu       sync.Mutex
+	identity oidciface.Identity
+	codes    map[string]oidcPendingCode
+}
+
+type oidcPendingCode struct {
+	redirectURI string
+	challenge   string
+	nonce       string
+	identity    oidciface.Identity
+}
+
+func NewOIDCIssuer(t *testing.T) *OIDCIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	is := &OIDCIssuer{
+		key:        key,
+		kid:        "test-key",
+		signingKey: key,
+		codes:      map[string]oidcPendingCode{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", is.handleDiscovery)
+	mux.HandleFunc("GET /jwks", is.handleJWKS)
+	mux.HandleFunc("GET /authorize", is.handleAuthorize)
+	mux.HandleFunc("POST /token", is.handleToken)
+
+	is.Server = httptest.NewServer(mux)
+	t.Cleanup(is.Server.Close)
+	return is
+}
+
+// SetIdentity sets who signs in on the next authorization request.
+func (is *OIDCIssuer) SetIdentity(identity oidciface.Identity) {
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	is.identity = identity
+}
+
+// SignWithUnpublishedKey makes the issuer sign ID tokens with a key missing
+// from its JWKS, under the published key ID.
+func (is *OIDCIssuer) SignWithUnpublishedKey(t *testing.T) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	is.mu.Lock()
+	defer is.mu.Unlock()
+	is.signingKey = key
+}
+
+// Provider returns a client for this issuer as the app would configure it.
+func (is *OIDCIssuer) Provider(name string, redirectURL string) oidciface.Provider {
+	return oidc.NewProvider(oidc.Config{
+		Name:         name,
+		Issuer:       is.Server.URL,
+		ClientID:     OIDCTestClientID,
+		ClientSecret: OIDCTestClientSecret,
+		RedirectURL:  redirectURL,
+	}, is.Server.Client())
+}
+
+// Authorize plays the browser: it opens the provider URL produced by the
+// app and returns the callback URL the provider redirects back to.
+func (is *OIDCIssuer) Authorize(t *testing.T, authURL string) *url.URL {
+	t.Helper()
+
+	client := is.Server.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := client.Get(authURL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+
+	callback, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	return callback
+}
+
+// ============== Endpoints ==============
+
+func (is *OIDCIssuer) handleDiscovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                is.Server.URL,
+		"authorization_endpoint":                is.Server.URL + "/authorize",
+		"token_endpoint":                        is.Server.URL + "/token",
+		"jwks_uri":                              is.Server.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (is *OIDCIssuer) handleJWKS(w http.ResponseWriter, r *http.Request) {
+	enc := base64.RawURLEncoding
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": is.kid,
+			"n":   enc.EncodeToString(is.key.N.Bytes()),
+			"e":   enc.EncodeToString(big.NewInt(int64(is.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (is *OIDCIssuer) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("client_id") != OIDCTestClientID || q.Get("response_type") != "code" ||
+		q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		http.Error(w, "invalid authorization request", http.StatusBadRequest)
+		return
+	}
+
+	code := rand.Text()
+	is.mu.Lock()
+	is.codes[code] = oidcPendingCode{
+		redirectURI: q.Get("redirect_uri"),
+		challenge:   q.Get("code_challenge"),
+		nonce:       q.Get("nonce"),
+		identity:    is.identity,
+	}
+	is.mu.Unlock()
+
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	params := redirect.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	redirect.RawQuery = params.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (is *OIDCIssuer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != OIDCTestClientID || clientSecret != OIDCTestClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	is.mu.Lock()
+	pending, ok := is.codes[code]
+	delete(is.codes, code)
+	is.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if r.PostForm.Get("grant_type") != "authorization_code" || !ok ||
+		pending.redirectURI != r.PostForm.Get("redirect_uri") ||
+		pending.challenge != base64.RawURLEncoding.EncodeToString(sum[:]) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	is.mu.Lock()
+	signingKey := is.signingKey
+	is.mu.Unlock()
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            is.Server.URL,
+		"aud":            OIDCTestClientID,
+		"sub":            pending.identity.Subject,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          pending.nonce,
+		"email":          pending.identity.Email,
+		"email_verified": pending.identity.EmailVerified,
+		"name":           pending.identity.Name,
+	})
+	idToken.Header["kid"] = is.kid
+	signed, err := idToken.SignedString(signingKey)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": rand.Text(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     signed,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
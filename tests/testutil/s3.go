@@ -0,0 +1,226 @@
+package testutil
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	storageiface "myapp/core/interface/storage"
+	"myapp/infrastructure/storage"
+
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	S3TestRegion          = "us-east-1"
+	S3TestBucket          = "test-bucket"
+	S3TestAccessKeyID     = "test-access-key"
+	S3TestSecretAccessKey = "test-secret-key"
+)
+
+// S3Server is a minimal local stand-in for an S3 compatible object store,
+// in the manner of MinIO with path-style addressing. It serves PUT, GET,
+// HEAD and DELETE on the objects of a single bucket, with "bytes=N-" ranges,
+// and rejects requests without a valid Signature Version 4.
+type S3Server struct {
+	Server *httptest.Server
+
+	mu      sync.Mutex
+	objects map[string]s3StoredObject
+}
+
+type s3StoredObject struct {
+	data        []byte
+	contentType string
+	modTime     time.Time
+}
+
+func NewS3Server(t *testing.T) *S3Server {
+	t.Helper()
+
+	s := &S3Server{objects: map[string]s3StoredObject{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Server.Close)
+	return s
+}
+
+// Storage returns an S3 storage talking to the server with the given
+// secret, which only signs valid requests with S3TestSecretAccessKey.
+func (s *S3Server) Storage(t *testing.T, secret string) storageiface.Storage {
+	t.Helper()
+
+	st, err := storage.NewS3Storage(storage.S3Config{
+		Endpoint:        s.Server.URL,
+		Region:          S3TestRegion,
+		Bucket:          S3TestBucket,
+		AccessKeyID:     S3TestAccessKeyID,
+		SecretAccessKey: secret,
+		PathStyle:       true,
+	})
+	require.NoError(t, err)
+	return st
+}
+
+// Object returns the stored content under key, if any.
+func (s *S3Server) Object(key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	obj, ok := s.objects[key]
+	return obj.data, ok
+}
+
+func (s *S3Server) handle(w http.ResponseWriter, r *http.Request) {
+	if !s.validSignature(r) {
+		s3WriteError(w, http.StatusForbidden, "SignatureDoesNotMatch")
+		return
+	}
+
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != S3TestBucket {
+		s3WriteError(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		if r.ContentLength < 0 {
+			s3WriteError(w, http.StatusLengthRequired, "MissingContentLength")
+			return
+		}
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			s3WriteError(w, http.StatusBadRequest, "IncompleteBody")
+			return
+		}
+		s.objects[key] = s3StoredObject{
+			data:        data,
+			contentType: r.Header.Get("Content-Type"),
+			modTime:     time.Now().UTC().Truncate(time.Second),
+		}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		obj, ok := s.objects[key]
+		if !ok {
+			s3WriteError(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		contentType := obj.contentType
+		if contentType == "" {
+			contentType = "binary/octet-stream"
+		}
+		sum := md5.Sum(obj.data)
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Last-Modified", obj.modTime.Format(http.TimeFormat))
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+
+		// Only the open ended ranges the storage asks for
+		data, status := obj.data, http.StatusOK
+		if rng := r.Header.Get("Range"); rng != "" {
+			var start int
+			if _, err := fmt.Sscanf(rng, "bytes=%d-", &start); err != nil || start >= len(obj.data) {
+				s3WriteError(w, http.StatusRequestedRangeNotSatisfiable, "InvalidRange")
+				return
+			}
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, len(obj.data)-1, len(obj.data)))
+			data, status = obj.data[start:], http.StatusPartialContent
+		}
+
+		w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+		w.WriteHeader(status)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(data)
+		}
+	case http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		s3WriteError(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+// validSignature recomputes the Signature Version 4 of r from the headers
+// it claims to have signed.
+func (s *S3Server) validSignature(r *http.Request) bool {
+	auth, ok := strings.CutPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ")
+	if !ok {
+		return false
+	}
+
+	fields := map[string]string{}
+	for _, part := range strings.Split(auth, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		fields[name] = value
+	}
+
+	credential := strings.SplitN(fields["Credential"], "/", 2)
+	if len(credential) != 2 || credential[0] != S3TestAccessKeyID {
+		return false
+	}
+	scope := credential[1]
+	date, _, _ := strings.Cut(scope, "/")
+	if scope != date+"/"+S3TestRegion+"/s3/aws4_request" {
+		return false
+	}
+
+	signedHeaders := strings.Split(fields["SignedHeaders"], ";")
+	if !sort.StringsAreSorted(signedHeaders) {
+		return false
+	}
+	var canonicalHeaders strings.Builder
+	for _, name := range signedHeaders {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		r.URL.EscapedPath(),
+		r.URL.RawQuery,
+		canonicalHeaders.String(),
+		fields["SignedHeaders"],
+		r.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		r.Header.Get("X-Amz-Date"),
+		scope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := []byte("AWS4" + S3TestSecretAccessKey)
+	for _, part := range []string{date, S3TestRegion, "s3", "aws4_request"} {
+		key = s3HMAC(key, part)
+	}
+	expected := hex.EncodeToString(s3HMAC(key, stringToSign))
+	return hmac.Equal([]byte(expected), []byte(fields["Signature"]))
+}
+
+func s3HMAC(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func s3WriteError(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_, _ = fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<Error><Code>%s</Code></Error>", code)
+}
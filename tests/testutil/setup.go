@@ -4,8 +4,11 @@ import (
 	"testing"
 
 	"myapp/api/v1/router"
+	maileriface "myapp/core/interface/mailer"
+	oidciface "myapp/core/interface/oidc"
 	repositoryiface "myapp/core/interface/repository"
 	"myapp/core/service"
+	"myapp/infrastructure/mailer"
 	"myapp/provider"
 	"myapp/support/constant"
 	"myapp/support/middleware"
@@ -22,6 +25,10 @@ type TestApp struct {
 	UserRepo    repositoryiface.UserRepository
 	UserService service.UserService
 	JWTService  service.JWTService
+	// MailDir receives every mail sent by the app as an .eml file
+	MailDir string
+	// OIDCIssuer backs the "test" social login provider
+	OIDCIssuer *OIDCIssuer
 }
 
 func SetupTestApp(t *testing.T) *TestApp {
@@ -33,15 +40,32 @@ func SetupTestApp(t *testing.T) *TestApp {
 	do.ProvideNamed(injector, constant.DBInjectorKey, func(i *do.Injector) (*gorm.DB, error) {
 		return NewTestDB(t), nil
 	})
+	mailDir := t.TempDir()
+	do.Provide(injector, func(i *do.Injector) (maileriface.Mailer, error) {
+		return mailer.NewFileMailer(mailDir, "test@localhost"), nil
+	})
+	oidcIssuer := NewOIDCIssuer(t)
+	do.Provide(injector, func(i *do.Injector) (oidciface.Providers, error) {
+		return oidciface.Providers{
+			"test": oidcIssuer.Provider("test", "http://localhost/api/v1/auth/test/callback"),
+		}, nil
+	})
 	provider.SetupDependencies(injector)
 
 	// Router
 	r := gin.New()
 	r.Use(
 		middleware.CORSMiddleware(),
+		middleware.RequestID(),
 		middleware.ErrorHandler(),
 	)
+	router.AuthRouter(r, injector)
 	router.UserRouter(r, injector)
+	router.ProductRouter(r, injector)
+	router.APIKeyRouter(r, injector)
+	router.RoleRouter(r, injector)
+	router.AuditLogRouter(r, injector)
+	router.TrashRouter(r, injector)
 
 	// Invoke
 	testDB := do.MustInvokeNamed[*gorm.DB](injector, constant.DBInjectorKey)
@@ -57,5 +81,7 @@ func SetupTestApp(t *testing.T) *TestApp {
 		UserRepo:    userRepo,
 		UserService: userService,
 		JWTService:  jwtService,
+		MailDir:     mailDir,
+		OIDCIssuer:  oidcIssuer,
 	}
 }
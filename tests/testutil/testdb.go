@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"myapp/core/entity"
+	"myapp/database/seeder"
 
 	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
 	"gorm.io/driver/postgres"
@@ -48,8 +49,30 @@ func NewTestDB(t *testing.T) *gorm.DB {
 	if err := db.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp";`).Error; err != nil {
 		t.Fatalf("failed to enable uuid extension: %v", err)
 	}
-	if err := db.AutoMigrate(&entity.User{}); err != nil {
+	if err := db.AutoMigrate(
+		&entity.User{},
+		&entity.RefreshToken{},
+		&entity.TokenRevocation{},
+		&entity.PasswordResetToken{},
+		&entity.EmailVerificationToken{},
+		&entity.OIDCState{},
+		&entity.UserIdentity{},
+		&entity.TOTPCredential{},
+		&entity.RecoveryCode{},
+		&entity.TwoFactorChallenge{},
+		&entity.LoginThrottle{},
+		&entity.APIKey{},
+		&entity.Role{},
+		&entity.Permission{},
+		&entity.Session{},
+		&entity.Invitation{},
+		&entity.AuditLog{},
+		&entity.ProductRevision{},
+	); err != nil {
 		t.Fatalf("failed to automigrate: %v", err)
 	}
+	if err := seeder.RoleSeeder(db); err != nil {
+		t.Fatalf("failed to seed roles: %v", err)
+	}
 	return db
 }